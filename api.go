@@ -15,6 +15,7 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
@@ -228,6 +229,24 @@ func (api *API) query(ctx context.Context, req *QueryRequest) (QueryResponse, er
 	if err != nil {
 		return QueryResponse{}, errors.Wrap(err, "parsing")
 	}
+	write := isWriteQuery(q)
+	if api.ReadOnly() && write {
+		return QueryResponse{}, errors.Wrap(ErrReadOnly, "query contains write calls")
+	}
+
+	cache := api.server.resultCache
+	// Results of a query whose routing is pinned aren't cached, since it's
+	// meant to read the nodes it's pinned to.
+	cacheable := !write && resultCacheable(req) && QueryRouteFromContext(ctx) == nil
+	var gen uint64
+	if write {
+		defer api.invalidateResultCache(req.Index)
+	} else if cacheable {
+		if results, ok := cache.get(req); ok {
+			return QueryResponse{Results: results}, nil
+		}
+		gen = cache.generation(req.Index)
+	}
 
 	// TODO can we get rid of exec options and pass the QueryRequest directly to executor?
 	execOpts := &ExecOptions{
@@ -245,6 +264,8 @@ func (api *API) query(ctx context.Context, req *QueryRequest) (QueryResponse, er
 	// Check for an error embedded in the response.
 	if resp.Err != nil {
 		err = errors.Wrap(resp.Err, "executing")
+	} else if cacheable {
+		cache.put(req, resp.Results, gen)
 	}
 
 	return resp, err
@@ -305,6 +326,9 @@ func (api *API) DeleteDataframe(ctx context.Context, indexName string) error {
 	if err := api.validate(apiDeleteDataframe); err != nil {
 		return errors.Wrap(err, "validating api method")
 	}
+
+	defer api.invalidateResultCache(indexName)
+
 	// Delete index from the holder.
 	err := api.holder.DeleteDataframe(indexName)
 	if err != nil {
@@ -333,6 +357,8 @@ func (api *API) DeleteIndex(ctx context.Context, indexName string) error {
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(indexName)
+
 	// Delete index from the holder.
 	err := api.holder.DeleteIndex(indexName)
 	if err != nil {
@@ -432,6 +458,8 @@ type FieldUpdate struct {
 }
 
 func (api *API) UpdateField(ctx context.Context, indexName, fieldName string, update FieldUpdate) error {
+	defer api.invalidateResultCache(indexName)
+
 	// Find index.
 	index := api.holder.Index(indexName)
 	if index == nil {
@@ -624,6 +652,8 @@ func (api *API) ImportRoaring(ctx context.Context, indexName, fieldName string,
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(indexName)
+
 	api.server.logger.Debugf("ImportRoaring: %v %v %v", indexName, fieldName, shard)
 	index, field, err := api.indexField(indexName, fieldName, shard)
 	if index == nil || field == nil {
@@ -735,6 +765,8 @@ func (api *API) DeleteField(ctx context.Context, indexName string, fieldName str
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(indexName)
+
 	// Find index.
 	index := api.holder.Index(indexName)
 	if index == nil {
@@ -766,6 +798,8 @@ func (api *API) DeleteAvailableShard(_ context.Context, indexName, fieldName str
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(indexName)
+
 	// Find field.
 	field := api.holder.Field(indexName, fieldName)
 	if field == nil {
@@ -1191,6 +1225,8 @@ func (api *API) ApplySchema(ctx context.Context, s *Schema, remote bool) error {
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache("")
+
 	err := api.holder.applySchema(s)
 	if err != nil {
 		return errors.Wrap(err, "applying schema")
@@ -1228,6 +1264,8 @@ func (api *API) DeleteView(ctx context.Context, indexName string, fieldName stri
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(indexName)
+
 	// Retrieve field.
 	f := api.holder.Field(indexName, fieldName)
 	if f == nil {
@@ -1520,6 +1558,8 @@ func (api *API) ImportWithTx(ctx context.Context, qcx *Qcx, req *ImportRequest,
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(req.Index)
+
 	idx, field, err := api.indexField(req.Index, req.Field, req.Shard)
 	if err != nil {
 		return errors.Wrap(err, "getting index and field")
@@ -1645,6 +1685,8 @@ func (api *API) ImportWithTx(ctx context.Context, qcx *Qcx, req *ImportRequest,
 // providing corrected existence views for fields with existence
 // tracking. Our batch API does that.
 func (api *API) ImportRoaringShard(ctx context.Context, indexName string, shard uint64, req *ImportRoaringShardRequest) error {
+	defer api.invalidateResultCache(indexName)
+
 	index, err := api.Index(ctx, indexName)
 	if err != nil {
 		return errors.Wrap(err, "getting index")
@@ -1666,6 +1708,13 @@ func (api *API) ImportRoaringShard(ctx context.Context, indexName string, shard
 		return err1
 	}
 
+	// The conditions of a conditional write are checked in the same
+	// transaction as the write, so nothing else can write to the shard in
+	// between.
+	if err1 = checkWriteConditions(ctx, tx, index, shard, req.Conditions); err1 != nil {
+		return err1
+	}
+
 	for _, viewUpdate := range req.Views {
 		field := index.Field(viewUpdate.Field)
 		if field == nil {
@@ -1847,6 +1896,8 @@ func (api *API) ImportValueWithTx(ctx context.Context, qcx *Qcx, req *ImportValu
 		return errors.Wrap(err, "validating api method")
 	}
 
+	defer api.invalidateResultCache(req.Index)
+
 	numCols := len(req.ColumnIDs) + len(req.ColumnKeys)
 	numVals := len(req.Values) + len(req.FloatValues) + len(req.TimestampValues) + len(req.StringValues)
 	if numCols != numVals {
@@ -2160,6 +2211,7 @@ func (api *API) Info() serverInfo {
 		ReplicaN:         api.cluster.ReplicaN,
 		ShardHash:        api.cluster.Hasher.Name(),
 		KeyHash:          api.cluster.Hasher.Name(),
+		ReadOnly:         api.ReadOnly(),
 	}
 }
 
@@ -2551,7 +2603,13 @@ func (api *API) TranslateFieldDB(ctx context.Context, indexName, fieldName strin
 }
 
 // RestoreShard is used by the restore tool to restore previously backed up data. This call is specific to RBF data for a shard.
+//
+// The snapshot is checked before it replaces the shard's data. If it's
+// corrupt, RestoreShard returns ErrCorruptShardSnapshot and the shard is left
+// exactly as it was, along with its write-ahead log.
 func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64, rd io.Reader) error {
+	defer api.invalidateResultCache(indexName)
+
 	snap := api.cluster.NewSnapshot()
 	if !snap.OwnsShard(api.server.nodeID, indexName, shard) {
 		return ErrClusterDoesNotOwnShard // TODO (twg)really just node doesn't own shard but leave for now
@@ -2565,7 +2623,14 @@ func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64
 	}
 	db := dbs.W
 	finalPath := db.Path() + "/data"
-	tempPath := finalPath + ".tmp"
+	// The snapshot is written to a directory of its own, in which it can be
+	// opened and checked as a database of its own.
+	checkPath := db.Path() + "/restore"
+	if err := os.MkdirAll(checkPath, 0o755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(checkPath)
+	tempPath := checkPath + "/data"
 	o, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
 	if err != nil {
 		return err
@@ -2587,6 +2652,9 @@ func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64
 		_ = os.Remove(tempPath)
 		return err
 	}
+	if err := checkShardSnapshot(checkPath); err != nil {
+		return errors.Wrapf(ErrCorruptShardSnapshot, "index %s shard %d: %v", indexName, shard, err)
+	}
 	err = db.CloseDB()
 	if err != nil {
 		return err
@@ -2642,6 +2710,52 @@ func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64
 	return nil
 }
 
+// checkShardSnapshot checks the integrity of the RBF shard snapshot in the
+// data file in dir, including that every bitmap in it can be read.
+func checkShardSnapshot(dir string) (err error) {
+	f, err := os.Open(filepath.Join(dir, "data"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	page := make([]byte, rbf.PageSize)
+	if _, err := io.ReadFull(f, page); err != nil {
+		return errors.Wrap(err, "reading meta page")
+	} else if !rbf.IsMetaPage(page) {
+		return errors.New("not an RBF file")
+	}
+
+	// A corrupt page can make RBF panic, rather than return an error.
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("reading snapshot: %v", r)
+		}
+	}()
+	sdb := rbf.NewDB(dir, nil)
+	if err := sdb.Open(); err != nil {
+		return errors.Wrap(err, "opening snapshot")
+	}
+	defer sdb.Close()
+	if err := sdb.Check(); err != nil {
+		return err
+	}
+	tx, err := sdb.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	names, err := tx.BitmapNames()
+	if err != nil {
+		return errors.Wrap(err, "listing bitmaps")
+	}
+	for _, name := range names {
+		if _, err := tx.Count(name); err != nil {
+			return errors.Wrapf(err, "reading bitmap %s", name)
+		}
+	}
+	return nil
+}
+
 func (api *API) mutexCheckThisNode(ctx context.Context, qcx *Qcx, indexName string, fieldName string, details bool, limit int) (map[uint64]map[uint64][]uint64, error) {
 	index := api.holder.Index(indexName)
 	if index == nil {
@@ -3067,11 +3181,23 @@ func (api *API) SnapshotShardData(ctx context.Context, req *dax.SnapshotShardDat
 	// shard? Not sure we need to given that this request comes from
 	// the Controller, but might be a belt&suspenders situation.
 
+	if err := req.Priority.Valid(); err != nil {
+		return err
+	}
+
 	qtid := req.TableKey.QualifiedTableID()
 
 	partition := disco.ShardToShardPartition(string(req.TableKey), uint64(req.ShardNum), disco.DefaultPartitionN)
 	partitionNum := dax.PartitionNum(partition)
 
+	// Wait for the snapshot budget before taking the write Tx, so that
+	// queued background snapshots don't block writes.
+	done, err := api.server.snapshotBudget.begin(ctx, req.Priority)
+	if err != nil {
+		return errors.Wrap(err, "waiting for snapshot budget")
+	}
+	defer done()
+
 	// Open a write Tx snapshotting current version.
 	rc, err := api.IndexShardSnapshot(ctx, string(req.TableKey), uint64(req.ShardNum), true)
 	if err != nil {
@@ -3080,6 +3206,9 @@ func (api *API) SnapshotShardData(ctx context.Context, req *dax.SnapshotShardDat
 	defer rc.Close()
 
 	resource := api.serverlessStorage.GetShardResource(qtid, partitionNum, req.ShardNum)
+	if req.Force {
+		resource.MarkDirty()
+	}
 	// Bump writelog version while write Tx is held.
 	if ok, err := resource.IncrementWLVersion(); err != nil {
 		return errors.Wrap(err, "incrementing write log version")
@@ -3087,7 +3216,7 @@ func (api *API) SnapshotShardData(ctx context.Context, req *dax.SnapshotShardDat
 		return nil
 	}
 	// TODO(jaffee) look into downgrading Tx on RBF to read lock here now that WL version is incremented.
-	err = resource.Snapshot(rc)
+	err = resource.Snapshot(api.server.snapshotBudget.reader(ctx, rc, req.Priority))
 	return errors.Wrap(err, "snapshotting shard data")
 }
 
@@ -3173,6 +3302,7 @@ type serverInfo struct {
 	CPULogicalCores  int    `json:"cpuLogicalCores"`
 	CPUMHz           int    `json:"cpuMHz"`
 	StorageBackend   string `json:"storageBackend"`
+	ReadOnly         bool   `json:"readOnly"`
 }
 
 type apiMethod int
@@ -3222,6 +3352,7 @@ const (
 	apiMutexCheck
 	apiApplyChangeset
 	apiDeleteDataframe
+	apiShardChecksums
 )
 
 var methodsCommon = map[apiMethod]struct{}{
@@ -3247,6 +3378,7 @@ var methodsDegraded = map[apiMethod]struct{}{
 	apiActiveQueries:     {},
 	apiPastQueries:       {},
 	apiPartitionNodes:    {},
+	apiShardChecksums:    {},
 }
 
 var methodsNormal = map[apiMethod]struct{}{
@@ -3284,6 +3416,7 @@ var methodsNormal = map[apiMethod]struct{}{
 	apiMutexCheck:           {},
 	apiApplyChangeset:       {},
 	apiDeleteDataframe:      {},
+	apiShardChecksums:       {},
 }
 
 func shardInShards(i dax.ShardNum, s dax.ShardNums) bool {
@@ -17,6 +17,8 @@ import (
 // ApplyDirective applies a Directive received, from the Controller, at the
 // /directive endpoint.
 func (api *API) ApplyDirective(ctx context.Context, d *dax.Directive) error {
+	defer api.invalidateResultCache("")
+
 	// Get the current directive for comparison.
 	previousDirective := api.holder.Directive()
 
@@ -567,6 +569,10 @@ func (api *API) loadShard(ctx context.Context, tkey dax.TableKey, shard dax.Shar
 		return nil
 	}
 
+	// A corrupt snapshot fails the load (with ErrCorruptShardSnapshot)
+	// rather than being skipped: the write log only holds the writes since
+	// the snapshot, so replaying it without the snapshot would leave the
+	// shard missing data while looking up to date.
 	if rc, err := resource.LoadLatestSnapshot(); err != nil {
 		return errors.Wrap(err, "reading latest snapshot for shard")
 	} else if rc != nil {
@@ -1230,6 +1230,120 @@ func TestAPI_MutexCheck(t *testing.T) {
 	}
 }
 
+func TestAPI_VerifyShards(t *testing.T) {
+	c := test.MustUnsharedCluster(t, 3)
+	for _, c := range c.Nodes {
+		c.Config.Cluster.ReplicaN = 2
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("starting cluster: %v", err)
+	}
+	defer c.Close()
+
+	m0 := c.GetNode(0)
+	nodesByID := make(map[string]*test.Command, 3)
+	for i := 0; i < 3; i++ {
+		node := c.GetNode(i)
+		nodesByID[node.API.NodeID()] = node
+	}
+
+	ctx := context.Background()
+	indexName := c.Idx()
+	index, err := m0.API.CreateIndex(ctx, indexName, pilosa.IndexOptions{})
+	if err != nil {
+		t.Fatalf("creating index: %v", err)
+	}
+	field, err := m0.API.CreateField(ctx, indexName, "f", pilosa.OptFieldTypeSet(pilosa.CacheTypeNone, 0))
+	if err != nil {
+		t.Fatalf("creating field: %v", err)
+	}
+
+	const nShards = 4
+	for shard := uint64(0); shard < nShards; shard++ {
+		nodesForShard, err := m0.API.ShardNodes(ctx, indexName, shard)
+		if err != nil {
+			t.Fatalf("obtaining shard nodes: %v", err)
+		}
+		// Import doesn't forward to other replicas, so import to each. The
+		// request is rebuilt each time, because imports can alter it.
+		for _, n := range nodesForShard {
+			req := &pilosa.ImportRequest{
+				Index:          indexName,
+				IndexCreatedAt: index.CreatedAt(),
+				Field:          "f",
+				FieldCreatedAt: field.CreatedAt(),
+				Shard:          shard,
+				RowIDs:         []uint64{1, 2},
+				ColumnIDs:      []uint64{shard << shardwidth.Exponent, (shard << shardwidth.Exponent) + 7},
+			}
+			node := nodesByID[n.ID]
+			qcx := node.API.Txf().NewQcx()
+			defer qcx.Abort()
+			if err := node.API.Import(ctx, qcx, req); err != nil {
+				t.Fatalf("importing data: %v", err)
+			}
+			if err := qcx.Finish(); err != nil {
+				t.Fatalf("finishing import: %v", err)
+			}
+		}
+	}
+
+	res, err := m0.API.VerifyShards(ctx, indexName, nil)
+	if err != nil {
+		t.Fatalf("verifying shards: %v", err)
+	}
+	if len(res.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", res.Errors)
+	}
+	if len(res.Shards) != nShards {
+		t.Fatalf("expected %d shards, got %d", nShards, len(res.Shards))
+	}
+	for _, s := range res.Shards {
+		if len(s.Checksums) != 2 {
+			t.Fatalf("expected a checksum from each of 2 replicas of shard %d, got %v", s.Shard, s.Checksums)
+		}
+	}
+	if len(res.Divergent) != 0 {
+		t.Fatalf("expected no divergent shards, got %v", res.Divergent)
+	}
+
+	// Set a bit on only one replica of shard 2.
+	nodesForShard, err := m0.API.ShardNodes(ctx, indexName, 2)
+	if err != nil {
+		t.Fatalf("obtaining shard nodes: %v", err)
+	}
+	node := nodesByID[nodesForShard[0].ID]
+	nodeField, err := node.API.Field(ctx, indexName, "f")
+	if err != nil {
+		t.Fatalf("requesting field: %v", err)
+	}
+	qcx := node.API.Txf().NewQcx()
+	defer qcx.Abort()
+	if _, err := nodeField.SetBit(qcx, 3, (2<<shardwidth.Exponent)+9, nil); err != nil {
+		t.Fatalf("setting bit: %v", err)
+	}
+	if err := qcx.Finish(); err != nil {
+		t.Fatalf("finishing set: %v", err)
+	}
+
+	res, err = m0.API.VerifyShards(ctx, indexName, []uint64{1, 2})
+	if err != nil {
+		t.Fatalf("verifying shards: %v", err)
+	}
+	if !reflect.DeepEqual(res.Divergent, []uint64{2}) {
+		t.Fatalf("expected shard 2 to be divergent, got %v", res.Divergent)
+	}
+
+	// Too many shards must be requested in batches.
+	shards := make([]uint64, pilosa.MaxChecksumShards+1)
+	for i := range shards {
+		shards[i] = uint64(i)
+	}
+	if _, err := m0.API.VerifyShards(ctx, indexName, shards); !errors.As(err, &pilosa.BadRequestError{}) {
+		t.Fatalf("expected bad request error, got %v", err)
+	}
+}
+
 func createIndexForTest(index string, coord *test.Command, t *testing.T) {
 	ctx := context.Background()
 	_, err := coord.API.CreateIndex(ctx, index, pilosa.IndexOptions{Keys: true})
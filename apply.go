@@ -623,6 +623,8 @@ func getDataframeWritelock(shard uint64) *sync.Mutex {
 }
 
 func (api *API) ApplyDataframeChangeset(ctx context.Context, index string, cs *ChangesetRequest, shard uint64) error {
+	defer api.invalidateResultCache(index)
+
 	// TODO(twg) 2022/09/29 need to validate api call
 	idx := api.Holder().Index(index)
 
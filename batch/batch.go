@@ -185,6 +185,13 @@ type Batch struct {
 	clearFrags     fragments
 
 	useShardTransactionalEndpoint bool
+
+	// replaceSets is set by OptReplaceSets.
+	replaceSets bool
+
+	// conditions are the conditions of the next import, added by
+	// AddWriteCondition.
+	conditions []featurebase.WriteCondition
 }
 
 func (b *Batch) Len() int { return len(b.ids) }
@@ -236,6 +243,21 @@ func OptUseShardTransactionalEndpoint(use bool) BatchOption {
 	}
 }
 
+// OptReplaceSets tells the batch to replace, rather than add to, the
+// existing values of a record's set fields when a value is given for
+// them. A nil value leaves the existing values untouched, and an
+// empty set clears them. Time fields are unaffected: their values are
+// always added, so that their history is kept. The replacement of a
+// record's values happens in the same shard transaction as the rest of
+// its import, so it requires OptUseShardTransactionalEndpoint, and Clears
+// can't be given for set fields.
+func OptReplaceSets(replace bool) BatchOption {
+	return func(b *Batch) error {
+		b.replaceSets = replace
+		return nil
+	}
+}
+
 func OptImporter(i featurebase.Importer) BatchOption {
 	return func(b *Batch) error {
 		b.importer = i
@@ -335,6 +357,9 @@ func NewBatch(importer featurebase.Importer, size int, tbl *dax.Table, fields []
 			return nil, errors.Wrap(err, "applying options")
 		}
 	}
+	if b.replaceSets && !b.useShardTransactionalEndpoint {
+		return nil, errors.New("replacing set values requires the shard-transactional endpoint")
+	}
 
 	return b, nil
 }
@@ -450,6 +475,21 @@ func (b *Batch) getRowTranslation(field, key string) (uint64, bool) {
 	return trans.id, ok
 }
 
+// AddWriteCondition adds a condition to the next import of the batch, which
+// makes it a conditional write: the records in the shard of cond.Column are
+// written only if all of that shard's conditions hold, and otherwise Import
+// returns a featurebase.ConflictError wrapping a
+// featurebase.WriteConflictError. The condition is checked in the same shard
+// transaction as the write, so it requires OptUseShardTransactionalEndpoint.
+// cond.Column, and any row IDs in cond.Rows, must already be translated.
+func (b *Batch) AddWriteCondition(cond featurebase.WriteCondition) error {
+	if !b.useShardTransactionalEndpoint {
+		return errors.New("conditional writes require the shard-transactional endpoint")
+	}
+	b.conditions = append(b.conditions, cond)
+	return nil
+}
+
 // Add adds a record to the batch. Performance will be best if record
 // IDs are shard-sorted. That is, all records which belong to the same
 // Pilosa shard are added adjacent to each other. If the records are
@@ -675,6 +715,9 @@ func (b *Batch) Add(rec Row) error {
 		if field.Options.Type == featurebase.FieldTypeMutex && uval != nil {
 			return errors.Errorf("individual-bit clears not allowed on mutex fields; use nil to clear a mutex")
 		}
+		if field.Options.Type == featurebase.FieldTypeSet && b.replaceSets {
+			return errors.Errorf("clears not allowed on set fields when replacing set values; give the new values instead")
+		}
 		if _, ok := b.clearRowIDs[i]; !ok {
 			b.clearRowIDs[i] = make(map[int]uint64)
 		}
@@ -1169,7 +1212,7 @@ func (b *Batch) doImportShardTransactional(frags, clearFrags fragments) error {
 			if err != nil {
 				return errors.Wrap(err, "serializing bitmap")
 			}
-			request.Views = append(request.Views, featurebase.RoaringUpdate{Field: fragKey.field, View: view, Set: buf.Bytes()})
+			request.Views = append(request.Views, featurebase.RoaringUpdate{Field: fragKey.field, View: view, Set: buf.Bytes(), ClearRecords: b.clearsRecords(fragKey.field, view)})
 
 			// handle clear bitmap now if it exists so we don't have to go searching later
 			if clearVM := clearFrags.GetViewMap(fragKey.shard, fragKey.field); clearVM != nil {
@@ -1196,10 +1239,15 @@ func (b *Batch) doImportShardTransactional(frags, clearFrags fragments) error {
 			if err != nil {
 				return errors.Wrap(err, "serializing bitmap")
 			}
-			request.Views = append(request.Views, featurebase.RoaringUpdate{Field: fragKey.field, View: view, Clear: buf.Bytes()})
+			request.Views = append(request.Views, featurebase.RoaringUpdate{Field: fragKey.field, View: view, Clear: buf.Bytes(), ClearRecords: b.clearsRecords(fragKey.field, view)})
 		}
 	}
 
+	for _, cond := range b.conditions {
+		request := getOrCreate(requests, cond.Column/featurebase.ShardWidth)
+		request.Conditions = append(request.Conditions, cond)
+	}
+
 	featurebase.SummaryBatchShardImportBuildRequestsSeconds.Observe(time.Since(start).Seconds())
 	start = time.Now()
 	eg := egpool.Group{PoolSize: 20}
@@ -1217,6 +1265,17 @@ func (b *Batch) doImportShardTransactional(frags, clearFrags fragments) error {
 	return errors.Wrap(err, "doing shard-transactional imports")
 }
 
+// clearsRecords reports whether the clear bitmap for the given field and
+// view holds the records whose values are replaced (see
+// makeSingleValFragments), rather than individual bits to clear.
+func (b *Batch) clearsRecords(field, view string) bool {
+	if !b.replaceSets || view != "" {
+		return false
+	}
+	fld, ok := b.headerMap[field]
+	return ok && fld.Options.Type == featurebase.FieldTypeSet
+}
+
 func (b *Batch) doImport(frags, clearFrags fragments) error {
 	ctx := context.Background()
 
@@ -1555,6 +1614,30 @@ func (b *Batch) makeSingleValFragments(frags, clearFrags fragments) (fragments,
 		}
 	}
 
+	// -------------------------
+	// replaced set fields
+	// -------------------------
+	// With replaceSets, the first row of the clear bitmap for the
+	// standard view of a set field holds the records which were given a
+	// value, and is cleared from every row before the new values are set
+	// (see clearsRecords).
+	if b.replaceSets {
+		for findex, field := range b.header {
+			if field.Options.Type != featurebase.FieldTypeSet {
+				continue
+			}
+			rowIDs, rowIDSets := b.rowIDs[findex], b.rowIDSets[field.Name]
+			for i, id := range b.ids {
+				hasRow := i < len(rowIDs) && rowIDs[i] != nilSentinel
+				hasSet := i < len(rowIDSets) && rowIDSets[i] != nil
+				if !hasRow && !hasSet {
+					continue
+				}
+				clearFrags.GetOrCreate(id/shardWidth, field.Name, "").Add(id % shardWidth)
+			}
+		}
+	}
+
 	// -------------------------
 	// mutex fields
 	// -------------------------
@@ -1932,6 +2015,7 @@ func (b *Batch) reset() {
 	for k := range b.nullIndices {
 		delete(b.nullIndices, k) // TODO pool these slices
 	}
+	b.conditions = b.conditions[:0]
 	b.cycle++
 	for k, trans := range b.colTranslations {
 		if trans.lastUsed-b.cycle > b.maxAge {
@@ -86,6 +86,7 @@ func (qryr *serverlessQueryer) Query(org string, db string, sql io.Reader) (*fea
 		return nil, errors.Wrap(err, "creating new post request")
 	}
 	req.Header.Add("Content-Type", "text/plain")
+	req.Header.Add("Accept", "application/json")
 	req.Header.Add("OrganizationID", org)
 
 	var resp *http.Response
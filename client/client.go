@@ -648,10 +648,17 @@ func (c *Client) fetchPrimaryNode() (fragmentNode, error) {
 }
 
 func (c *Client) importData(uri *pnet.URI, path string, data []byte) error {
-	if status, _, err := c.doRequest(uri, "POST", path, c.augmentHeaders(defaultProtobufHeaders()), data); err != nil {
+	if status, body, err := c.doRequest(uri, "POST", path, c.augmentHeaders(defaultProtobufHeaders()), data); err != nil {
 		return errors.Wrapf(err, "import to %s", uri.HostPort())
 	} else if status == http.StatusPreconditionFailed {
 		return ErrPreconditionFailed
+	} else if status == http.StatusConflict {
+		// The conditions of a conditional write didn't hold.
+		resp := &pilosa.ImportResponse{}
+		if err := fbproto.DefaultSerializer.Unmarshal(body, resp); err != nil || resp.Err == "" {
+			return errors.Wrapf(ErrConflict, "import to %s", uri.HostPort())
+		}
+		return errors.Wrapf(ErrConflict, "import to %s: %s", uri.HostPort(), resp.Err)
 	}
 
 	return nil
@@ -664,6 +671,24 @@ func (c *Client) ImportRoaringShard(index string, shard uint64, request *pilosa.
 		return errors.Wrap(err, "getting URIs for import")
 	}
 
+	path := fmt.Sprintf("/index/%s/shard/%d/import-roaring", index, shard)
+	if len(request.Conditions) > 0 && len(uris) > 0 {
+		// A conditional write is checked and applied on the shard's
+		// primary, which is the first of its nodes, so that the replicas
+		// can't decide it differently. The replicas are then sent only
+		// the writes which were applied.
+		data, err := fbproto.DefaultSerializer.Marshal(request)
+		if err != nil {
+			return errors.Wrap(err, "marshaling")
+		}
+		if err := c.importData(uris[0], path, data); err != nil {
+			return errors.Wrap(err, "importing")
+		}
+		replicated := *request
+		replicated.Conditions = nil
+		request, uris = &replicated, uris[1:]
+	}
+
 	data, err := fbproto.DefaultSerializer.Marshal(request)
 	if err != nil {
 		return errors.Wrap(err, "marshaling")
@@ -672,7 +697,7 @@ func (c *Client) ImportRoaringShard(index string, shard uint64, request *pilosa.
 	for _, uri := range uris {
 		uri := uri
 		eg.Go(func() error {
-			return c.importData(uri, fmt.Sprintf("/index/%s/shard/%d/import-roaring", index, shard), data)
+			return c.importData(uri, path, data)
 		})
 	}
 	err = eg.Wait()
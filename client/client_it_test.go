@@ -4,6 +4,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"testing"
@@ -768,6 +769,78 @@ func TestClientAgainstCluster(t *testing.T) {
 				require.Truef(t, trns.Active, "TranslateColumnKeys Active")
 			})
 
+			t.Run("ImportRoaringShardConditional", func(t *testing.T) {
+				if replicaN < 2 {
+					t.Skip("replicas can only diverge with ReplicaN > 1")
+				}
+				setup(t, cli)
+				defer tearDown(t, cli)
+
+				const shard = 3
+				shardWidth := uint64(1 << shardwidth.Exponent)
+				col := shard*shardWidth + 1
+				ctx := context.Background()
+				owners, err := c.Nodes[0].API.ShardNodes(ctx, "test-index", shard)
+				require.NoError(t, err)
+				require.Len(t, owners, 2)
+				api := func(n *disco.Node) *featurebase.API {
+					for _, m := range c.Nodes {
+						if m.API.NodeID() == n.ID {
+							return m.API
+						}
+					}
+					t.Fatalf("no node %s", n.ID)
+					return nil
+				}
+				update := func(row uint64) []featurebase.RoaringUpdate {
+					buf := &bytes.Buffer{}
+					if _, err := roaring.NewBitmap(row*shardWidth + 1).WriteTo(buf); err != nil {
+						t.Fatalf("serializing bitmap: %v", err)
+					}
+					return []featurebase.RoaringUpdate{{Field: "test-field", View: "standard", Set: buf.Bytes()}}
+				}
+				rows := func(n *disco.Node) []uint64 {
+					resp, err := api(n).Query(ctx, &featurebase.QueryRequest{
+						Index:  "test-index",
+						Query:  fmt.Sprintf("Rows(test-field, column=%d)", col),
+						Shards: []uint64{shard},
+						Remote: true,
+					})
+					require.NoError(t, err)
+					return []uint64(resp.Results[0].(featurebase.RowIDs))
+				}
+
+				// Give the record a value on the replica, but not on the
+				// primary, so that the replicas would decide a condition
+				// on it differently.
+				require.NoError(t, api(owners[1]).ImportRoaringShard(ctx, "test-index", shard, &featurebase.ImportRoaringShardRequest{
+					Remote: true,
+					Views:  update(5),
+				}))
+
+				// The condition is checked on the primary only, and its
+				// write is then applied on both.
+				err = cli.ImportRoaringShard("test-index", shard, &featurebase.ImportRoaringShardRequest{
+					Remote:     true,
+					Views:      update(6),
+					Conditions: []featurebase.WriteCondition{{Field: "test-field", Column: col, Absent: true}},
+				})
+				require.NoError(t, err, "conditional import-roaring-shard")
+				require.Equal(t, []uint64{6}, rows(owners[0]))
+				require.Equal(t, []uint64{5, 6}, rows(owners[1]))
+
+				// A condition which doesn't hold on the primary is written
+				// nowhere.
+				err = cli.ImportRoaringShard("test-index", shard, &featurebase.ImportRoaringShardRequest{
+					Remote:     true,
+					Views:      update(7),
+					Conditions: []featurebase.WriteCondition{{Field: "test-field", Column: col, Absent: true}},
+				})
+				require.ErrorIs(t, err, ErrConflict, "conditional import-roaring-shard")
+				require.Equal(t, []uint64{6}, rows(owners[0]))
+				require.Equal(t, []uint64{5, 6}, rows(owners[1]))
+			})
+
 			t.Run("ImportRoaringShard", func(t *testing.T) {
 				setup(t, cli)
 
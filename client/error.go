@@ -23,4 +23,5 @@ var (
 	ErrUnknownType                 = errors.New("Unknown type")
 	ErrSingleServerAddressRequired = errors.New("OptClientManualServerAddress requires a single URI or address")
 	ErrPreconditionFailed          = errors.New("Precondition failed")
+	ErrConflict                    = errors.New("Conflict")
 )
@@ -274,6 +274,23 @@ func (i *importer) CreateTableKeys(ctx context.Context, tid dax.TableID, keys ..
 }
 
 func (i *importer) CreateFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	cfld, err := i.clientField(ctx, tid, fname)
+	if err != nil {
+		return nil, err
+	}
+	return i.client.CreateFieldKeys(cfld, keys...)
+}
+
+func (i *importer) FindFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	cfld, err := i.clientField(ctx, tid, fname)
+	if err != nil {
+		return nil, err
+	}
+	return i.client.FindFieldKeys(cfld, keys...)
+}
+
+// clientField returns the client Field for the field of the table.
+func (i *importer) clientField(ctx context.Context, tid dax.TableID, fname dax.FieldName) (*Field, error) {
 	tbl, err := i.schemaAPI.TableByID(ctx, tid)
 	if err != nil {
 		return nil, err
@@ -288,7 +305,7 @@ func (i *importer) CreateFieldKeys(ctx context.Context, tid dax.TableID, fname d
 	if err != nil {
 		return nil, errors.Wrap(err, "converting to client field")
 	}
-	return i.client.CreateFieldKeys(cfld, keys...)
+	return cfld, nil
 }
 
 func (i *importer) ImportRoaringBitmap(ctx context.Context, tid dax.TableID, fld *dax.Field, shard uint64, views map[string]*roaring.Bitmap, clear bool) error {
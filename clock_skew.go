@@ -0,0 +1,130 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+const (
+	// HeaderRequestTimeout is the header in which the InternalClient sends
+	// the time remaining before the deadline of a request's context, as a
+	// duration. The receiving node gives the request the same amount of
+	// time, measured by its own clock, so that the deadline holds even if the
+	// two nodes' clocks disagree. Since the time the request spends in
+	// transit isn't subtracted, the receiver's deadline is slightly later
+	// than the sender's, never earlier.
+	HeaderRequestTimeout = "X-Pilosa-Request-Timeout"
+
+	// HeaderSentAt is the header in which the InternalClient sends the time,
+	// by its clock, at which it sent a request. It's used only to detect
+	// clock skew between nodes.
+	HeaderSentAt = "X-Pilosa-Sent-At"
+)
+
+// DefaultClockSkewTolerance is the default difference between a node's clock
+// and another's beyond which the skew is reported.
+const DefaultClockSkewTolerance = time.Second
+
+// clockSkewWarnInterval is the minimum time between warnings about the clock
+// skew of any one peer.
+const clockSkewWarnInterval = time.Minute
+
+// setRequestTimeoutHeaders sets HeaderSentAt on req, and HeaderRequestTimeout
+// if its context has a deadline. It's called before each attempt to send req.
+func setRequestTimeoutHeaders(req *http.Request) {
+	now := time.Now()
+	req.Header.Set(HeaderSentAt, now.UTC().Format(time.RFC3339Nano))
+	if deadline, ok := req.Context().Deadline(); ok {
+		req.Header.Set(HeaderRequestTimeout, deadline.Sub(now).String())
+	} else {
+		req.Header.Del(HeaderRequestTimeout)
+	}
+}
+
+// clockSkewMonitor estimates the clock skew of the nodes which send requests
+// to this one, from the times at which they say they sent them.
+type clockSkewMonitor struct {
+	tolerance time.Duration
+	logger    logger.Logger
+
+	mu     sync.Mutex
+	warned map[string]time.Time
+}
+
+func newClockSkewMonitor(tolerance time.Duration, log logger.Logger) *clockSkewMonitor {
+	if tolerance <= 0 {
+		tolerance = DefaultClockSkewTolerance
+	}
+	return &clockSkewMonitor{
+		tolerance: tolerance,
+		logger:    log,
+		warned:    make(map[string]time.Time),
+	}
+}
+
+// observe records that peer sent a message at sent, by its clock, which was
+// received at received, by ours, and returns the apparent skew: how far
+// peer's clock is behind ours. The apparent skew includes the time the
+// message spent in transit, which the tolerance must comfortably exceed. If
+// it's beyond the tolerance, a warning is logged, at most once per
+// clockSkewWarnInterval for each peer.
+func (m *clockSkewMonitor) observe(peer string, sent, received time.Time) time.Duration {
+	skew := received.Sub(sent)
+	GaugeClockSkewSeconds.WithLabelValues(peer).Set(skew.Seconds())
+	if skew <= m.tolerance && skew >= -m.tolerance {
+		return skew
+	}
+	CounterClockSkewExceeded.WithLabelValues(peer).Inc()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if last, ok := m.warned[peer]; ok && received.Sub(last) < clockSkewWarnInterval {
+		return skew
+	}
+	m.warned[peer] = received
+
+	direction := "behind"
+	if skew < 0 {
+		direction, skew = "ahead of", -skew
+	}
+	m.logger.Warnf("clock of %s appears to be %v %s this node's, beyond the tolerance of %v; check that NTP is running on both", peer, skew, direction, m.tolerance)
+	return skew
+}
+
+// applyRequestTimeout gives a request which carries HeaderRequestTimeout a
+// context with that timeout, and records the clock skew of a request which
+// carries HeaderSentAt.
+func (h *Handler) applyRequestTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received := time.Now()
+		if v := r.Header.Get(HeaderSentAt); v != "" && h.clockSkew != nil {
+			if sent, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				h.clockSkew.observe(requestPeer(r), sent, received)
+			}
+		}
+		if v := r.Header.Get(HeaderRequestTimeout); v != "" {
+			if timeout, err := time.ParseDuration(v); err == nil {
+				ctx, cancel := context.WithTimeout(r.Context(), timeout)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestPeer returns the host from which r was sent.
+func requestPeer(r *http.Request) string {
+	host := r.RemoteAddr
+	if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+	return strings.Trim(host, "[]")
+}
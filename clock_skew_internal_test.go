@@ -0,0 +1,102 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+func TestClockSkewMonitor(t *testing.T) {
+	log := logger.NewBufferLogger()
+	m := newClockSkewMonitor(time.Second, log)
+	now := time.Now()
+
+	if skew := m.observe("a", now.Add(-100*time.Millisecond), now); skew != 100*time.Millisecond {
+		t.Fatalf("expected a skew of 100ms, got %v", skew)
+	}
+	m.observe("a", now.Add(5*time.Second), now)
+	m.observe("a", now.Add(5*time.Second), now.Add(time.Second))
+	m.observe("b", now.Add(-5*time.Second), now)
+
+	buf, err := log.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(buf)
+	if n := strings.Count(out, "clock of a appears to be 5s ahead of"); n != 1 {
+		t.Fatalf("expected one warning about a, got %d: %s", n, out)
+	}
+	if !strings.Contains(out, "clock of b appears to be 5s behind") {
+		t.Fatalf("expected a warning about b: %s", out)
+	}
+}
+
+func TestApplyRequestTimeout(t *testing.T) {
+	h := &Handler{clockSkew: newClockSkewMonitor(0, logger.NopLogger)}
+
+	var remaining time.Duration
+	var hasDeadline bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/status", nil).WithContext(ctx)
+	setRequestTimeoutHeaders(req)
+	if req.Header.Get(HeaderSentAt) == "" || req.Header.Get(HeaderRequestTimeout) == "" {
+		t.Fatalf("expected headers to be set: %v", req.Header)
+	}
+
+	// The receiving request doesn't share the sender's context.
+	received := httptest.NewRequest("GET", "/status", nil)
+	received.Header = req.Header
+	h.applyRequestTimeout(next).ServeHTTP(httptest.NewRecorder(), received)
+	if !hasDeadline || remaining <= 50*time.Second || remaining > time.Minute {
+		t.Fatalf("expected a deadline about a minute away, got %v (%v)", remaining, hasDeadline)
+	}
+
+	req = httptest.NewRequest("GET", "/status", nil)
+	setRequestTimeoutHeaders(req)
+	h.applyRequestTimeout(next).ServeHTTP(httptest.NewRecorder(), req)
+	if hasDeadline {
+		t.Fatalf("expected no deadline, got one %v away", remaining)
+	}
+}
+
+func TestTransactionRemainingJSON(t *testing.T) {
+	trns := &Transaction{ID: "x", Timeout: time.Minute, Deadline: time.Now().Add(time.Minute)}
+	buf, err := json.Marshal(trns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Move the absolute deadline, as if it were set by a skewed clock; the
+	// remaining time takes precedence.
+	var tmp map[string]interface{}
+	if err := json.Unmarshal(buf, &tmp); err != nil {
+		t.Fatal(err)
+	}
+	tmp["deadline"] = time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+	if buf, err = json.Marshal(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &Transaction{}
+	if err := json.Unmarshal(buf, got); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(got.Deadline); d <= 50*time.Second || d > time.Minute {
+		t.Fatalf("expected a deadline about a minute away, got %v", d)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/roaring"
+	"github.com/pkg/errors"
+)
+
+// WriteCondition is a condition on the value of one of a record's fields
+// which must hold for a conditional write to the record to be applied. The
+// conditions of an ImportRoaringShardRequest are checked in the write
+// transaction of the shard which holds their records, before anything is
+// written, so the check and the write are atomic: no other write to the shard
+// can come in between. If any condition doesn't hold, nothing in the request
+// is written, and the import fails with a ConflictError wrapping a
+// WriteConflictError.
+//
+// With replicas, the conditions are checked on the shard's primary, and only
+// a write which was applied there is sent on to the other replicas, without
+// its conditions.
+type WriteCondition struct {
+	Field string
+
+	// Column is the ID of the record.
+	Column uint64
+
+	// Absent is true if the record must have no value for the field.
+	// Otherwise it must have the value given by Value or Rows.
+	Absent bool
+
+	// Value is the value an int, decimal or timestamp field must have, as
+	// it's returned by Field.Value: a decimal is scaled by the field's
+	// scale, and a timestamp is in the field's time unit since the epoch.
+	Value int64
+
+	// Rows are the IDs of the rows, in any order, the record must have in a
+	// set, mutex, bool or time field (in its standard view), and no others.
+	Rows []uint64
+}
+
+// WriteConflictError is the error of a conditional write whose conditions
+// don't hold.
+type WriteConflictError struct {
+	// Conflicts describes each condition which didn't hold, with the actual
+	// value of its field.
+	Conflicts []string
+}
+
+func (e WriteConflictError) Error() string {
+	return "write conflict: " + strings.Join(e.Conflicts, ", ")
+}
+
+// checkWriteConditions returns a ConflictError if any of conds doesn't hold
+// in tx, which is the write transaction for shard of idx.
+func checkWriteConditions(ctx context.Context, tx Tx, idx *Index, shard uint64, conds []WriteCondition) error {
+	var conflicts []string
+	for _, cond := range conds {
+		if cond.Column/ShardWidth != shard {
+			return errors.Errorf("condition on column %d is not in shard %d", cond.Column, shard)
+		}
+		field := idx.Field(cond.Field)
+		if field == nil {
+			return newNotFoundError(ErrFieldNotFound, cond.Field)
+		}
+		ok, actual, err := checkWriteCondition(ctx, tx, field, shard, cond)
+		if err != nil {
+			return errors.Wrapf(err, "checking condition on %s", cond.Field)
+		}
+		if !ok {
+			conflicts = append(conflicts, fmt.Sprintf("%s is %s", cond.Field, actual))
+		}
+	}
+	if len(conflicts) > 0 {
+		CounterWriteConflicts.WithLabelValues(idx.Name()).Inc()
+		return newConflictError(WriteConflictError{Conflicts: conflicts})
+	}
+	return nil
+}
+
+// checkWriteCondition returns whether cond holds for field, and the field's
+// actual value, formatted for an error message.
+func checkWriteCondition(ctx context.Context, tx Tx, field *Field, shard uint64, cond WriteCondition) (bool, string, error) {
+	opts := field.Options()
+	switch opts.Type {
+	case FieldTypeInt, FieldTypeDecimal, FieldTypeTimestamp:
+		bsig := field.bsiGroup(field.name)
+		if bsig == nil {
+			return false, "", ErrBSIGroupNotFound
+		}
+		var val int64
+		var exists bool
+		if view := field.view(viewBSIGroupPrefix + field.name); view != nil {
+			if frag := view.Fragment(shard); frag != nil {
+				v, ok, err := frag.value(tx, cond.Column, bsig.BitDepth)
+				if err != nil {
+					return false, "", err
+				}
+				val, exists = v+bsig.Base, ok
+			}
+		}
+		if !exists {
+			return cond.Absent, "NULL", nil
+		}
+		return !cond.Absent && val == cond.Value, formatConditionValue(opts, val), nil
+
+	case FieldTypeSet, FieldTypeMutex, FieldTypeBool, FieldTypeTime:
+		var rows []uint64
+		if view := field.view(viewStandard); view != nil {
+			if frag := view.Fragment(shard); frag != nil {
+				var err error
+				if rows, err = frag.rows(ctx, tx, 0, roaring.NewBitmapColumnFilter(cond.Column)); err != nil {
+					return false, "", err
+				}
+			}
+		}
+		if len(rows) == 0 {
+			return cond.Absent, "NULL", nil
+		}
+		return !cond.Absent && equalRows(rows, cond.Rows), formatConditionRows(field, rows), nil
+
+	default:
+		return false, "", errors.Errorf("field type %s is not supported", opts.Type)
+	}
+}
+
+// equalRows returns whether a, which is sorted, has the same rows as b.
+func equalRows(a, b []uint64) bool {
+	b = append([]uint64(nil), b...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	n := 0
+	for i := range b {
+		if i == 0 || b[i] != b[i-1] {
+			b[n] = b[i]
+			n++
+		}
+	}
+	b = b[:n]
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatConditionValue formats val, the value of an int-like field as it's
+// returned by Field.Value.
+func formatConditionValue(opts FieldOptions, val int64) string {
+	switch opts.Type {
+	case FieldTypeDecimal:
+		return pql.NewDecimal(val, opts.Scale).String()
+	case FieldTypeTimestamp:
+		if ts, err := ValToTimestamp(opts.TimeUnit, val); err == nil {
+			return "'" + ts.Format(time.RFC3339Nano) + "'"
+		}
+	}
+	return strconv.FormatInt(val, 10)
+}
+
+// formatConditionRows formats the rows a record has in field, translating
+// them to keys if the field has keys.
+func formatConditionRows(field *Field, rows []uint64) string {
+	opts := field.Options()
+	strs := make([]string, len(rows))
+	for i, row := range rows {
+		switch {
+		case opts.Type == FieldTypeBool:
+			strs[i] = strconv.FormatBool(row == trueRowID)
+		case opts.Keys:
+			if key, err := field.TranslateStore().TranslateID(row); err == nil {
+				strs[i] = "'" + key + "'"
+				continue
+			}
+			fallthrough
+		default:
+			strs[i] = strconv.FormatUint(row, 10)
+		}
+	}
+	if opts.Type == FieldTypeSet || opts.Type == FieldTypeTime {
+		return "[" + strings.Join(strs, ", ") + "]"
+	}
+	return strs[0]
+}
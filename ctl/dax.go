@@ -13,12 +13,33 @@ func BuildDAXFlags(cmd *cobra.Command, srv *server.Command) {
 	flags.StringVar(&srv.Config.Advertise, "advertise", srv.Config.Advertise, "Address to advertise externally.")
 	flags.BoolVar(&srv.Config.Verbose, "verbose", srv.Config.Verbose, "Enable verbose logging")
 	flags.StringVar(&srv.Config.LogPath, "log-path", srv.Config.LogPath, "Log path")
+	flags.BoolVar(&srv.Config.OpenAPI, "openapi", srv.Config.OpenAPI, "Serve an OpenAPI document describing the running services at /openapi.json.")
+	flags.StringSliceVar(&srv.Config.ServiceAuth.Keys, "service-auth.keys", srv.Config.ServiceAuth.Keys, "Comma separated list of keys shared by the services to authenticate requests to one another. The first signs; all are accepted. Empty disables service authentication.")
+	flags.DurationVar(&srv.Config.ServiceAuth.Grace, "service-auth.grace", srv.Config.ServiceAuth.Grace, "Length of time after startup that service auth keys other than the first are accepted. 0 accepts them for as long as they're configured.")
+	flags.DurationVar(&srv.Config.ServiceAuth.MaxAge, "service-auth.max-age", srv.Config.ServiceAuth.MaxAge, "Age beyond which a service token is rejected. 0 uses the default (5m).")
+	flags.Int64Var(&srv.Config.ServiceAuth.MaxBodySize, "service-auth.max-body-size", srv.Config.ServiceAuth.MaxBodySize, "Size in bytes of the largest request body read to verify a service token. 0 uses the default (256MiB).")
+	flags.BoolVar(&srv.Config.CoalesceHealthChecks, "coalesce-health-checks", srv.Config.CoalesceHealthChecks, "Have concurrent health checks share a single probe of the services.")
+	flags.StringSliceVar(&srv.Config.HistogramBuckets, "histogram-buckets", srv.Config.HistogramBuckets, "Comma separated list of <metric>=<upper-bound> <upper-bound> ... overriding the buckets (in seconds) of latency histograms, e.g. \"query_stage_duration_seconds=0.01 0.04 0.05 0.06 0.2 1\".")
 
 	// Controller
 	flags.BoolVar(&srv.Config.Controller.Run, "controller.run", srv.Config.Controller.Run, "Run the Controller service in process.")
 	flags.DurationVar(&srv.Config.Controller.Config.RegistrationBatchTimeout, "controller.config.registration-batch-timeout", srv.Config.Controller.Config.RegistrationBatchTimeout, "Timeout for node registration batches.")
 	flags.StringVar(&srv.Config.Controller.Config.StorageMethod, "controller.config.storage-method", srv.Config.Controller.Config.StorageMethod, "Backing store. boltdb or sqldb.")
 	flags.DurationVar(&srv.Config.Controller.Config.SnappingTurtleTimeout, "controller.config.snapping-turtle-timeout", srv.Config.Controller.Config.SnappingTurtleTimeout, "Period for running automatic snapshotting routine.")
+	flags.IntVar(&srv.Config.Controller.Config.SnapshotConcurrency, "controller.config.snapshot-concurrency", srv.Config.Controller.Config.SnapshotConcurrency, "Number of shard snapshots the automatic snapshotting routine has in progress at once. 0 uses the default (1).")
+	flags.DurationVar(&srv.Config.Controller.Config.SnapshotMaxAge, "controller.config.snapshot-max-age", srv.Config.Controller.Config.SnapshotMaxAge, "Age beyond which the shards of a table which doesn't set MAXSNAPSHOTAGE are snapshotted again, whether or not they've been written to. 0 for no maximum.")
+	flags.DurationVar(&srv.Config.Controller.Config.SnapshotAgeCheckInterval, "controller.config.snapshot-age-check-interval", srv.Config.Controller.Config.SnapshotAgeCheckInterval, "Period on which the ages of tables' snapshots are checked against their maximums. 0 uses the default (1m).")
+	flags.StringArrayVar(&srv.Config.Controller.Config.MaintenanceWindows, "controller.config.maintenance-windows", srv.Config.Controller.Config.MaintenanceWindows, "Periods during which the automatic snapshotting routine may run, each of the form '<days> <HH:MM>-<HH:MM>' in UTC, such as 'sat,sun 01:00-05:00'; may be repeated. Empty for any time.")
+	flags.IntVar(&srv.Config.Controller.Config.SchemaEventBufferSize, "controller.config.schema-event-buffer-size", srv.Config.Controller.Config.SchemaEventBufferSize, "Number of recent schema change events kept for reconnecting subscribers. 0 uses the default (1000).")
+	flags.DurationVar(&srv.Config.Controller.Config.SchemaEventRetention, "controller.config.schema-event-retention", srv.Config.Controller.Config.SchemaEventRetention, "Length of time schema change events are kept for reconnecting subscribers. 0 uses the default (1h).")
+	flags.DurationVar(&srv.Config.Controller.Config.ShardMigrationQuiesce, "controller.config.shard-migration-quiesce", srv.Config.Controller.Config.ShardMigrationQuiesce, "Length of time writes to a migrating shard are held off before cutover. 0 uses the default (2s).")
+	flags.IntVar(&srv.Config.Controller.Config.ReReplicationBatchSize, "controller.config.re-replication-batch-size", srv.Config.Controller.Config.ReReplicationBatchSize, "Number of under-replicated shards a forced re-replication reassigns at once. 0 uses the default (16).")
+	flags.DurationVar(&srv.Config.Controller.Config.ReReplicationInterval, "controller.config.re-replication-interval", srv.Config.Controller.Config.ReReplicationInterval, "Length of time a forced re-replication waits between batches, and period on which the under-replicated shard metric is refreshed. 0 uses the default (5s).")
+	flags.DurationVar(&srv.Config.Controller.Config.HealthStaleAfter, "controller.config.health-stale-after", srv.Config.Controller.Config.HealthStaleAfter, "Age beyond which a compute node's last health probe is reported as stale. 0 uses three times the poll interval.")
+	flags.BoolVar(&srv.Config.Controller.Config.AutoSnapshot.Enabled, "controller.config.auto-snapshot.enabled", srv.Config.Controller.Config.AutoSnapshot.Enabled, "Copy the snapshots and write logs of tables before destructive operations, so that they can be restored.")
+	flags.StringSliceVar(&srv.Config.Controller.Config.AutoSnapshot.Operations, "controller.config.auto-snapshot.operations", srv.Config.Controller.Config.AutoSnapshot.Operations, "Comma separated list of the operations before which an auto-snapshot is taken: drop-table, drop-database, drop-field. Empty for all of them.")
+	flags.StringVar(&srv.Config.Controller.Config.AutoSnapshot.OnFailure, "controller.config.auto-snapshot.on-failure", srv.Config.Controller.Config.AutoSnapshot.OnFailure, "What an operation does when its auto-snapshot fails: block (the default) or proceed.")
+	flags.StringVar(&srv.Config.Controller.Config.AutoSnapshot.Dir, "controller.config.auto-snapshot.dir", srv.Config.Controller.Config.AutoSnapshot.Dir, "Directory in which auto-snapshots are kept. Empty for a directory within the snapshotter directory.")
 
 	// Controller.SQLDB
 	flags.StringVar(&srv.Config.Controller.Config.SQLDB.Database, "controller.config.sqldb.database", srv.Config.Controller.Config.SQLDB.Database, "Database name.")
@@ -35,6 +56,33 @@ func BuildDAXFlags(cmd *cobra.Command, srv *server.Command) {
 	// Queryer
 	flags.BoolVar(&srv.Config.Queryer.Run, "queryer.run", srv.Config.Queryer.Run, "Run the Queryer service in process.")
 	flags.StringVar(&srv.Config.Queryer.Config.ControllerAddress, "queryer.config.controller-address", srv.Config.Queryer.Config.ControllerAddress, "Address of remote Controller process.")
+	flags.StringSliceVar(&srv.Config.Queryer.Config.ControllerAddresses, "queryer.config.controller-addresses", srv.Config.Queryer.Config.ControllerAddresses, "Comma separated list of addresses of Controller replicas, in order of preference, to which the Queryer fails over when the one it's using can't be reached.")
+	flags.DurationVar(&srv.Config.Queryer.Config.ControllerCheckInterval, "queryer.config.controller-check-interval", srv.Config.Queryer.Config.ControllerCheckInterval, "How often the health of the Controller replicas is checked. 0 uses the default (10s).")
+	flags.Int64Var(&srv.Config.Queryer.Config.MaxQueryTextSize, "queryer.config.max-query-text-size", srv.Config.Queryer.Config.MaxQueryTextSize, "Maximum size in bytes of the text of a query. 0 uses the default (16MiB); negative disables the limit.")
+	flags.StringSliceVar(&srv.Config.Queryer.Config.Labels.AllowedKeys, "queryer.config.labels.allowed-keys", srv.Config.Queryer.Config.Labels.AllowedKeys, "Query label keys which clients may attach to queries. Empty allows any key.")
+	flags.StringSliceVar(&srv.Config.Queryer.Config.Labels.MetricKeys, "queryer.config.labels.metric-keys", srv.Config.Queryer.Config.Labels.MetricKeys, "Query label keys recorded in metrics.")
+	flags.IntVar(&srv.Config.Queryer.Config.Labels.MaxMetricValues, "queryer.config.labels.max-metric-values", srv.Config.Queryer.Config.Labels.MaxMetricValues, "Maximum distinct values recorded in metrics per query label; further values are recorded as \"other\". 0 uses the default (50).")
+	flags.IntVar(&srv.Config.Queryer.Config.QueryHistorySize, "queryer.config.query-history-size", srv.Config.Queryer.Config.QueryHistorySize, "Number of recently completed queries kept in the query history. 0 uses the default (100); negative disables.")
+	flags.DurationVar(&srv.Config.Queryer.Config.SlowQueryThreshold, "queryer.config.slow-query-threshold", srv.Config.Queryer.Config.SlowQueryThreshold, "Duration at or above which a completed query is logged as slow. 0 uses the default (10s); negative disables.")
+	flags.IntVar(&srv.Config.Queryer.Config.ImportSampleSize, "queryer.config.import-sample-size", srv.Config.Queryer.Config.ImportSampleSize, "Number of rows sampled to infer the schema of an import which doesn't specify a sample size. 0 uses the default (1000).")
+	flags.Int64Var(&srv.Config.Queryer.Config.Distinct.MemoryLimit, "queryer.config.distinct.memory-limit", srv.Config.Queryer.Config.Distinct.MemoryLimit, "Bytes of memory used by each DISTINCT before it spills to disk. 0 uses the default (1MiB).")
+	flags.Int64Var(&srv.Config.Queryer.Config.Distinct.MaxRows, "queryer.config.distinct.max-rows", srv.Config.Queryer.Config.Distinct.MaxRows, "Maximum rows a DISTINCT may produce before its statement fails. 0 is unlimited.")
+	flags.StringVar((*string)(&srv.Config.Queryer.Config.DefaultOutputFormat), "queryer.config.default-output-format", string(srv.Config.Queryer.Config.DefaultOutputFormat), "Format (json, csv or arrow) of query results for clients whose Accept header doesn't ask for one. Empty uses json.")
+	flags.StringSliceVar(&srv.Config.Queryer.Config.Export.Buckets, "queryer.config.export.buckets", srv.Config.Queryer.Config.Export.Buckets, "S3 and GCS buckets to which query results may be exported. Empty disables exports to object storage.")
+	flags.StringVar(&srv.Config.Queryer.Config.Export.S3Region, "queryer.config.export.s3-region", srv.Config.Queryer.Config.Export.S3Region, "Region of the S3 export buckets. Empty takes the region from the environment.")
+	flags.StringVar(&srv.Config.Queryer.Config.Export.S3Endpoint, "queryer.config.export.s3-endpoint", srv.Config.Queryer.Config.Export.S3Endpoint, "Endpoint of an S3-compatible store to use for s3:// exports instead of Amazon S3.")
+	flags.StringVar(&srv.Config.Queryer.Config.Export.GCSEndpoint, "queryer.config.export.gcs-endpoint", srv.Config.Queryer.Config.Export.GCSEndpoint, "Endpoint of the S3-compatible API used for gs:// exports. Empty uses https://storage.googleapis.com.")
+	flags.StringVar(&srv.Config.Queryer.Config.Export.GCSProfile, "queryer.config.export.gcs-profile", srv.Config.Queryer.Config.Export.GCSProfile, "AWS shared credentials profile holding the GCS HMAC key for gs:// exports. Empty takes the key from the environment.")
+	flags.StringVar(&srv.Config.Queryer.Config.Export.Dir, "queryer.config.export.dir", srv.Config.Queryer.Config.Export.Dir, "Directory to which file:// exports are written. Empty disables file exports.")
+	flags.StringVar(&srv.Config.Queryer.Config.Encryption.DataKey, "queryer.config.encryption.data-key", srv.Config.Queryer.Config.Encryption.DataKey, "Base64 encoded, KMS encrypted, 256-bit data key from which the keys of encrypted fields are derived. Empty disables reads and writes of encrypted fields.")
+	flags.StringVar(&srv.Config.Queryer.Config.Encryption.KMSRegion, "queryer.config.encryption.kms-region", srv.Config.Queryer.Config.Encryption.KMSRegion, "Region of the KMS key which encrypted the data key. Empty takes the region from the environment.")
+	flags.StringVar(&srv.Config.Queryer.Config.Encryption.KMSEndpoint, "queryer.config.encryption.kms-endpoint", srv.Config.Queryer.Config.Encryption.KMSEndpoint, "Endpoint of a KMS-compatible service to use instead of AWS KMS.")
+	flags.StringSliceVar(&srv.Config.Queryer.Config.Encryption.DecryptOrganizations, "queryer.config.encryption.decrypt-organizations", srv.Config.Queryer.Config.Encryption.DecryptOrganizations, "Comma separated list of organizations permitted to read the values of encrypted fields. Others read their ciphertexts.")
+	flags.IntVar(&srv.Config.Queryer.Config.Breaker.FailureThreshold, "queryer.config.breaker.failure-threshold", srv.Config.Queryer.Config.Breaker.FailureThreshold, "Consecutive failed requests to a computer after which the queryer stops calling it for a cooldown. Negative disables.")
+	flags.DurationVar(&srv.Config.Queryer.Config.Breaker.Cooldown, "queryer.config.breaker.cooldown", srv.Config.Queryer.Config.Breaker.Cooldown, "Time to wait before probing a computer whose circuit breaker has opened.")
+	flags.IntVar(&srv.Config.Queryer.Config.Prewarm.MinIdleConns, "queryer.config.prewarm.min-idle-conns", srv.Config.Queryer.Config.Prewarm.MinIdleConns, "Idle connections the queryer keeps open to each computer. 0 disables pre-warming.")
+	flags.DurationVar(&srv.Config.Queryer.Config.Prewarm.RefreshInterval, "queryer.config.prewarm.refresh-interval", srv.Config.Queryer.Config.Prewarm.RefreshInterval, "How often the queryer refreshes the computers from the controller and tops up their idle connections.")
+	flags.BoolVar(&srv.Config.Queryer.Config.ReadOnly, "queryer.config.read-only", srv.Config.Queryer.Config.ReadOnly, "Start the queryer in read-only mode, rejecting all writes and DDL.")
 
 	// Computer
 	flags.BoolVar(&srv.Config.Computer.Run, "computer.run", srv.Config.Computer.Run, "Run the Computer service in process.")
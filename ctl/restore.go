@@ -12,8 +12,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -40,6 +42,20 @@ type RestoreCommand struct {
 	// Filepath to the backup file.
 	Path string
 
+	// SkipCorrupt makes the restore skip the shards of the backup which fail
+	// the nodes' integrity check, rather than abort on the first of them.
+	// Each node checks a shard before it replaces its own data for the
+	// shard, so a skipped shard is left on the node exactly as it was,
+	// along with its write-ahead log; nothing of the corrupt shard is
+	// applied. On a new, clean cluster, that means the shard's data is
+	// missing.
+	SkipCorrupt bool
+
+	// SkippedShards are the corrupt shards skipped by Run, ordered by index
+	// and shard. Their data wasn't restored.
+	SkippedShards []SkippedShard
+	skippedMu     sync.Mutex
+
 	// Amount of time after first failed request to continue retrying.
 	RetryPeriod time.Duration `json:"retry-period"`
 
@@ -57,6 +73,17 @@ type RestoreCommand struct {
 	AuthToken string
 }
 
+// SkippedShard is a shard of a backup which wasn't restored because it's
+// corrupt.
+type SkippedShard struct {
+	Index string
+	Shard uint64
+	Path  string
+
+	// Reason is the node's description of the corruption.
+	Reason string
+}
+
 // Logger returns the command's associated Logger to maintain CommandWithTLSSupport interface compatibility
 func (cmd *RestoreCommand) Logger() logger.Logger {
 	return cmd.logDest
@@ -125,6 +152,7 @@ func (cmd *RestoreCommand) Run(ctx context.Context) (err error) {
 	} else if err := cmd.restoreIDAlloc(ctx, primary); err != nil {
 		return fmt.Errorf("cannot restore idalloc: %w", err)
 	}
+	cmd.SkippedShards = nil
 	if err := cmd.restoreShards(ctx); err != nil {
 		return fmt.Errorf("cannot restore shards: %w", err)
 	} else if err := cmd.restoreDataframes(ctx); err != nil {
@@ -135,6 +163,17 @@ func (cmd *RestoreCommand) Run(ctx context.Context) (err error) {
 		return fmt.Errorf("cannot restore field translation: %w", err)
 	}
 
+	if len(cmd.SkippedShards) > 0 {
+		sort.Slice(cmd.SkippedShards, func(i, j int) bool {
+			a, b := cmd.SkippedShards[i], cmd.SkippedShards[j]
+			return a.Index < b.Index || (a.Index == b.Index && a.Shard < b.Shard)
+		})
+		logger.Warnf("restore completed, but %d corrupt shards were skipped, and their data is missing:", len(cmd.SkippedShards))
+		for _, s := range cmd.SkippedShards {
+			logger.Warnf("  index %s shard %d (%s): %s", s.Index, s.Shard, s.Path, s.Reason)
+		}
+	}
+
 	/*	Fetch the cluster nodes from the target host.
 		For each index:
 		Upload the RBF snapshot for each shard to the nodes that own the shard.
@@ -213,6 +252,10 @@ func (cmd *RestoreCommand) restoreSchema(ctx context.Context, primary *disco.Nod
 }
 
 func retryWith400(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	// A corrupt shard would only be rejected again.
+	if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+		return false, nil
+	}
 	if resp != nil && resp.StatusCode >= 400 { // we have some dumb status codes
 		return true, nil
 	}
@@ -374,8 +417,25 @@ func (cmd *RestoreCommand) restoreShard(ctx context.Context, filename string) er
 		resp, err := client.Do(req)
 		if err != nil {
 			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return err
 		} else if err := resp.Body.Close(); err != nil {
 			return err
+		} else if resp.StatusCode == http.StatusUnprocessableEntity {
+			// The shard is corrupt. The node checks it before using it, so
+			// its data for the shard is unchanged.
+			reason := strings.TrimSpace(string(body))
+			if !cmd.SkipCorrupt {
+				return fmt.Errorf("shard %d of index %s is corrupt (use --skip-corrupt to skip it): %s", shard, indexName, reason)
+			}
+			logger.Warnf("skipping corrupt shard %d of index %s: %s", shard, indexName, reason)
+			cmd.skippedMu.Lock()
+			cmd.SkippedShards = append(cmd.SkippedShards, SkippedShard{Index: indexName, Shard: shard, Path: filename, Reason: reason})
+			cmd.skippedMu.Unlock()
+			return nil
 		} else if resp.StatusCode != http.StatusOK {
 			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 		}
@@ -35,7 +35,17 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 	flags.StringVar(&srv.Name, pre("name"), srv.Name, "Name of the node in the cluster.")
 	flags.StringVar(&srv.ControllerAddress, pre("controller-address"), srv.ControllerAddress, "Controller service to register with.")
 	flags.StringVar(&srv.WriteloggerDir, pre("writelogger-dir"), srv.WriteloggerDir, "Writelogger directory to read/write append logs.")
+	flags.Uint64Var(&srv.WriteloggerMinFreeBytes, pre("writelogger-min-free-bytes"), srv.WriteloggerMinFreeBytes, "Disk headroom to reserve for the writelogger; writes are rejected below this. Zero to disable.")
+	flags.BoolVar(&srv.WriteloggerBlockOnFull, pre("writelogger-block-on-full"), srv.WriteloggerBlockOnFull, "Block writes (up to writelogger-block-timeout) rather than rejecting them when the writelogger disk is full.")
+	flags.DurationVar((*time.Duration)(&srv.WriteloggerBlockTimeout), pre("writelogger-block-timeout"), time.Duration(srv.WriteloggerBlockTimeout), "Maximum time a write will wait for writelogger disk space to be reclaimed.")
+	flags.BoolVar(&srv.WriteloggerAutoTruncate, pre("writelogger-auto-truncate"), srv.WriteloggerAutoTruncate, "Delete write logs automatically once they're covered by a snapshot.")
+	flags.IntVar(&srv.WriteloggerRetainLogs, pre("writelogger-retain-logs"), srv.WriteloggerRetainLogs, "Number of write logs covered by a snapshot to keep for each resource when truncating automatically.")
+	flags.DurationVar((*time.Duration)(&srv.WriteloggerTruncateInterval), pre("writelogger-truncate-interval"), time.Duration(srv.WriteloggerTruncateInterval), "Time between sweeps of every write log for those covered by a snapshot. 0 uses the default (5m).")
 	flags.StringVar(&srv.SnapshotterDir, pre("snapshotter-dir"), srv.SnapshotterDir, "Snapshotter directory to read/write snapshots.")
+	flags.StringVar(&srv.SnapshotterStagingDir, pre("snapshotter-staging-dir"), srv.SnapshotterStagingDir, "Directory in which snapshots are written before being moved into the snapshotter directory.")
+	flags.Uint64Var(&srv.SnapshotterMinFreeBytes, pre("snapshotter-min-free-bytes"), srv.SnapshotterMinFreeBytes, "Disk headroom to reserve for snapshot staging; snapshots are rejected below this. Zero to disable.")
+	flags.IntVar(&srv.SnapshotBudget.Concurrency, pre("snapshot-budget.concurrency"), srv.SnapshotBudget.Concurrency, "Maximum number of background shard snapshots taken at once. Zero for no limit.")
+	flags.Int64Var(&srv.SnapshotBudget.BytesPerSecond, pre("snapshot-budget.bytes-per-second"), srv.SnapshotBudget.BytesPerSecond, "Maximum rate at which background shard snapshots read shard data. Zero for no limit.")
 	flags.StringVarP(&srv.DataDir, pre("data-dir"), short("d"), srv.DataDir, "Directory to store FeatureBase data files.")
 	flags.StringVarP(&srv.Bind, pre("bind"), short("b"), srv.Bind, "Default URI on which FeatureBase should listen.")
 	flags.StringVar(&srv.BindGRPC, pre("bind-grpc"), srv.BindGRPC, "URI on which FeatureBase should listen for gRPC requests.")
@@ -49,6 +59,24 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 	flags.DurationVar((*time.Duration)(&srv.LongQueryTime), pre("long-query-time"), time.Duration(srv.LongQueryTime), "Duration that will trigger log and stat messages for slow queries. Zero to disable.")
 	flags.IntVar(&srv.QueryHistoryLength, pre("query-history-length"), srv.QueryHistoryLength, "Number of queries to remember in history.")
 	flags.Int64Var(&srv.MaxQueryMemory, pre("max-query-memory"), srv.MaxQueryMemory, "Maximum memory allowed per Extract() or SELECT query.")
+	flags.IntVar(&srv.QueryShardLimits.Default, pre("query-shard-limits.default"), srv.QueryShardLimits.Default, "Maximum number of shards of an index a single query may touch. 0 means no limit.")
+	flags.StringSliceVar(&srv.QueryShardLimits.Groups, pre("query-shard-limits.groups"), srv.QueryShardLimits.Groups, "Comma separated list of <group-id>=<limit> overrides of the query shard limit for members of user groups.")
+	flags.IntVar(&srv.QueryShardLimits.Ceiling, pre("query-shard-limits.ceiling"), srv.QueryShardLimits.Ceiling, "Maximum query shard limit for any user, capping the default and group limits. 0 means no ceiling.")
+	flags.IntVar(&srv.QueryConcurrencyLimits.Default, pre("query-concurrency-limits.default"), srv.QueryConcurrencyLimits.Default, "Maximum number of queries a single user may have executing at once. 0 means no limit.")
+	flags.StringSliceVar(&srv.QueryConcurrencyLimits.Users, pre("query-concurrency-limits.users"), srv.QueryConcurrencyLimits.Users, "Comma separated list of <user-id>=<limit> overrides of the query concurrency limit for particular users.")
+	flags.IntVar(&srv.QueryConcurrencyLimits.Total, pre("query-concurrency-limits.total"), srv.QueryConcurrencyLimits.Total, "Maximum number of queries the node may have executing at once. 0 means no limit.")
+	flags.Int64Var(&srv.QueryConcurrencyLimits.Memory, pre("query-concurrency-limits.memory"), srv.QueryConcurrencyLimits.Memory, "Maximum memory, in bytes, the node's executing queries may be admitted with in total. 0 means no limit.")
+	flags.Int64Var(&srv.QueryConcurrencyLimits.QueryMemory, pre("query-concurrency-limits.query-memory"), srv.QueryConcurrencyLimits.QueryMemory, "Memory, in bytes, a query is admitted with if its request doesn't set a max memory. Defaults to max-query-memory.")
+	flags.StringSliceVar(&srv.QueryConcurrencyLimits.Reservations, pre("query-concurrency-limits.reservations"), srv.QueryConcurrencyLimits.Reservations, "Comma separated list of <user-id>=<slots>[:<memory>] reservations of the node's query capacity for particular users.")
+	flags.StringVar(&srv.QueryRouting.Strategy, pre("query-routing.strategy"), srv.QueryRouting.Strategy, "How queries choose among the replicas of a shard: primary, round-robin, least-connections, or least-load. Empty uses primary.")
+	flags.DurationVar((*time.Duration)(&srv.QueryRouting.LoadStaleAfter), pre("query-routing.load-stale-after"), time.Duration(srv.QueryRouting.LoadStaleAfter), "Age after which a node's reported load is ignored by the least-load strategy. 0 uses the default (10s).")
+	flags.DurationVar((*time.Duration)(&srv.ResultCache.TTL), pre("result-cache.ttl"), time.Duration(srv.ResultCache.TTL), "How long the results of read-only PQL queries are cached. 0 disables the cache.")
+	flags.IntVar(&srv.ResultCache.MaxEntries, pre("result-cache.max-entries"), srv.ResultCache.MaxEntries, "Maximum number of cached query results. 0 uses the default (1000).")
+	flags.IntVar(&srv.ResultCache.MaxEntrySize, pre("result-cache.max-entry-size"), srv.ResultCache.MaxEntrySize, "Size in bytes of the largest query result cached. 0 uses the default (1MiB).")
+	flags.DurationVar((*time.Duration)(&srv.QueryPreemption.MaxWait), pre("query-preemption.max-wait"), time.Duration(srv.QueryPreemption.MaxWait), "Longest time a batch query waits for interactive queries before processing each shard. 0 uses the default (100ms); negative disables preemption.")
+	flags.Int64Var(&srv.Distinct.MemoryLimit, pre("distinct.memory-limit"), srv.Distinct.MemoryLimit, "Bytes of memory used by each SQL DISTINCT before it spills to disk. 0 uses the default (1MiB).")
+	flags.Int64Var(&srv.Distinct.MaxRows, pre("distinct.max-rows"), srv.Distinct.MaxRows, "Maximum rows a SQL DISTINCT may produce before its statement fails. 0 is unlimited.")
+	flags.DurationVar((*time.Duration)(&srv.ClockSkewTolerance), pre("clock-skew-tolerance"), time.Duration(srv.ClockSkewTolerance), "Difference between another node's clock and this one's beyond which the skew is logged as a warning. 0 uses the default (1s).")
 	flags.StringVar(&srv.VerChkAddress, pre("verchk-address"), srv.VerChkAddress, "Address to contact to check for latest version.")
 	flags.StringVar(&srv.UUIDFile, pre("uuid-file"), srv.UUIDFile, "File to store UUID used in checking latest version. If this is a relative path, the file will be stored in the server's data directory.")
 
@@ -57,6 +85,22 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 
 	// Handler
 	flags.StringSliceVar(&srv.Handler.AllowedOrigins, pre("handler.allowed-origins"), []string{}, "Comma separated list of allowed origin URIs (for CORS/Web UI).")
+	flags.BoolVar(&srv.Handler.ProxyProtocol, pre("handler.proxy-protocol"), srv.Handler.ProxyProtocol, "Parse PROXY protocol headers from trusted upstreams to obtain client addresses.")
+	flags.StringSliceVar(&srv.Handler.ProxyProtocolUpstreams, pre("handler.proxy-protocol-upstreams"), srv.Handler.ProxyProtocolUpstreams, "Comma separated list of IPs or CIDR networks trusted to send PROXY protocol headers.")
+	flags.BoolVar(&srv.Handler.Compression.Enabled, pre("handler.compression.enabled"), srv.Handler.Compression.Enabled, "Compress responses to clients which accept gzip.")
+	flags.IntVar(&srv.Handler.Compression.MinSize, pre("handler.compression.min-size"), srv.Handler.Compression.MinSize, "Size in bytes below which responses aren't compressed. 0 uses the default (1024).")
+	flags.StringSliceVar(&srv.Handler.Compression.ContentTypes, pre("handler.compression.content-types"), srv.Handler.Compression.ContentTypes, "Comma separated list of media types to compress (e.g. application/json,text/csv). If empty, all types not excluded are compressed.")
+	flags.StringSliceVar(&srv.Handler.Compression.ExcludeContentTypes, pre("handler.compression.exclude-content-types"), srv.Handler.Compression.ExcludeContentTypes, "Comma separated list of media types never to compress, in addition to known-compressed types.")
+	flags.StringSliceVar(&srv.Handler.Compression.Prefixes, pre("handler.compression.prefixes"), srv.Handler.Compression.Prefixes, "Comma separated list of <path-prefix>=<min-size> (or <path-prefix>=off) overriding the minimum size under a path prefix.")
+	flags.BoolVar(&srv.Handler.Decompression.Enabled, pre("handler.decompression.enabled"), srv.Handler.Decompression.Enabled, "Decompress request bodies sent with a gzip or zstd Content-Encoding.")
+	flags.Int64Var(&srv.Handler.Decompression.MaxSize, pre("handler.decompression.max-size"), srv.Handler.Decompression.MaxSize, "Size in bytes to which a request body may decompress. 0 uses the default (256MiB).")
+	flags.BoolVar(&srv.Handler.ReadOnly, pre("handler.read-only"), srv.Handler.ReadOnly, "Start in read-only mode, rejecting all writes and schema changes.")
+	flags.IntVar(&srv.Handler.ResultFlush.Rows, pre("handler.result-flush.rows"), srv.Handler.ResultFlush.Rows, "Number of rows of a streamed result after which they're flushed to the client. 0 uses the default (1000).")
+	flags.DurationVar((*time.Duration)(&srv.Handler.ResultFlush.Interval), pre("handler.result-flush.interval"), time.Duration(srv.Handler.ResultFlush.Interval), "Longest time a row of a streamed result is held before it's flushed to the client. 0 uses the default (100ms).")
+	flags.BoolVar(&srv.Handler.UnsafeFaultInjection, pre("handler.unsafe-fault-injection"), srv.Handler.UnsafeFaultInjection, "UNSAFE: enable the /internal/faults endpoints, which inject errors, latency, and unavailability for chaos testing. Never enable in production.")
+	flags.DurationVar((*time.Duration)(&srv.Handler.Shutdown.DrainTimeout), pre("handler.shutdown.drain-timeout"), time.Duration(srv.Handler.Shutdown.DrainTimeout), "Time in-flight requests are given to finish at shutdown before they're cancelled. 0 uses the default (20s).")
+	flags.DurationVar((*time.Duration)(&srv.Handler.Shutdown.CancelTimeout), pre("handler.shutdown.cancel-timeout"), time.Duration(srv.Handler.Shutdown.CancelTimeout), "Time cancelled requests are given to return at shutdown before their connections are closed. 0 uses the default (8s).")
+	flags.DurationVar((*time.Duration)(&srv.Handler.Shutdown.ForceCloseTimeout), pre("handler.shutdown.force-close-timeout"), time.Duration(srv.Handler.Shutdown.ForceCloseTimeout), "Time handlers are given to return at shutdown after their connections are closed. 0 uses the default (2s).")
 
 	// Cluster
 	flags.IntVar(&srv.Cluster.ReplicaN, pre("cluster.replicas"), 1, "Number of hosts each piece of data should be stored on.")
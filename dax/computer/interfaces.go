@@ -3,6 +3,7 @@ package computer
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 )
@@ -38,7 +39,7 @@ type SnapshotService interface {
 // SnapInfo holds metadata about a snapshot.
 type SnapInfo struct {
 	Version int
-	// Date    time.Time
+	Date    time.Time
 }
 
 // WriteLogInfo holds metadata about a write log.
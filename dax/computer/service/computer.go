@@ -6,6 +6,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	featurebase "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/dax"
@@ -26,7 +28,16 @@ type computerService struct {
 	cfg      CommandConfig
 	key      dax.ServiceKey
 	computer *fbserver.Command
-	logger   logger.Logger
+
+	// writelogger and snapshotter are the services injected into computer.
+	// They're held here so that they can be drained on shutdown.
+	writelogger computer.WritelogService
+	snapshotter computer.SnapshotService
+
+	// snapshotting is set while an emergency snapshot request is outstanding.
+	snapshotting int32
+
+	logger logger.Logger
 }
 
 func New(addr dax.Address, cfg CommandConfig, logger logger.Logger) *computerService {
@@ -48,10 +59,12 @@ func (c *computerService) Start() error {
 	// happen in a reasonable order like we do with the other service types.
 	if c.computer == nil {
 		c.cfg.Name = string(c.Key())
-		if cmd, err := newCommand(c.addr, c.cfg); err != nil {
+		if cmd, wlSvc, ssSvc, err := c.newCommand(); err != nil {
 			return errors.Wrapf(err, "getting new command for computer config: %s", c.cfg.Name)
 		} else {
 			c.computer = cmd
+			c.writelogger = wlSvc
+			c.snapshotter = ssSvc
 		}
 
 		if c.cfg.ComputerConfig.ControllerAddress != "" {
@@ -88,6 +101,69 @@ func (c *computerService) Stop() error {
 	return c.computer.Close()
 }
 
+// Drainer returns the component of the computer which participates in the
+// shutdown stage identified by prefix. It returns nil if there is nothing to
+// drain for that stage.
+func (c *computerService) Drainer(prefix string) dax.Drainer {
+	var svc interface{}
+	switch prefix {
+	case dax.ServicePrefixWritelogger:
+		svc = c.writelogger
+	case dax.ServicePrefixSnapshotter:
+		svc = c.snapshotter
+	}
+	if d, ok := svc.(dax.Drainer); ok {
+		return d
+	}
+	return nil
+}
+
+// Health returns an error if the computer's writelogger is unable to accept
+// writes.
+func (c *computerService) Health() error {
+	if h, ok := c.writelogger.(interface{ Health() error }); ok {
+		return h.Health()
+	}
+	return nil
+}
+
+// emergencySnapshotTimeout is how long requestSnapshot waits for the
+// Controller to snapshot each table.
+const emergencySnapshotTimeout = 5 * time.Minute
+
+// requestSnapshot asks the Controller to snapshot each table which this
+// computer holds data for. A successful snapshot truncates the write logs it
+// incorporates, reclaiming space on the writelogger's disk. Requests made
+// while a previous request is still outstanding are dropped.
+func (c *computerService) requestSnapshot() {
+	if !atomic.CompareAndSwapInt32(&c.snapshotting, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&c.snapshotting, 0)
+
+	if c.cfg.ComputerConfig.ControllerAddress == "" || c.computer == nil {
+		return
+	}
+	rm := c.computer.ServerlessStorage()
+	if rm == nil {
+		return
+	}
+	qtids := rm.Tables()
+	if len(qtids) == 0 {
+		return
+	}
+
+	c.logger.Warnf("requesting emergency snapshot of %d tables to reclaim writelogger disk space", len(qtids))
+	cli := controllerclient.New(dax.Address(c.cfg.ComputerConfig.ControllerAddress), c.logger)
+	for _, qtid := range qtids {
+		ctx, cancel := context.WithTimeout(context.Background(), emergencySnapshotTimeout)
+		if err := cli.SnapshotTable(ctx, qtid); err != nil {
+			c.logger.Errorf("requesting emergency snapshot of table %s: %v", qtid, err)
+		}
+		cancel()
+	}
+}
+
 func (c *computerService) Key() dax.ServiceKey {
 	return c.key
 }
@@ -129,18 +205,27 @@ type CommandConfig struct {
 // this is case-insensitive.
 var serviceOffValue = "off"
 
-func newCommand(addr dax.Address, cfg CommandConfig) (*fbserver.Command, error) {
+func (c *computerService) newCommand() (*fbserver.Command, computer.WritelogService, computer.SnapshotService, error) {
+	addr, cfg := c.addr, c.cfg
+
 	// Set up Writelogger.
 	var wlSvc computer.WritelogService
 	wlDirToCompare := strings.TrimSpace(strings.ToLower(cfg.ComputerConfig.WriteloggerDir))
 	switch wlDirToCompare {
 	case "":
-		return nil, errors.New(errors.ErrUncoded, "no writelogger directory configured")
+		return nil, nil, nil, errors.New(errors.ErrUncoded, "no writelogger directory configured")
 	case serviceOffValue:
 		wlSvc = computer.NewNopWritelogService()
 		cfg.Logger.Warnf("No writelogger configured, dynamic scaling will not function properly.")
 	default:
-		wlSvc = writelogger.New(cfg.ComputerConfig.WriteloggerDir, cfg.Logger)
+		wl := writelogger.New(cfg.ComputerConfig.WriteloggerDir, cfg.Logger)
+		wl.SetDiskGuard(writelogger.DiskGuard{
+			MinFreeBytes: cfg.ComputerConfig.WriteloggerMinFreeBytes,
+			Block:        cfg.ComputerConfig.WriteloggerBlockOnFull,
+			BlockTimeout: time.Duration(cfg.ComputerConfig.WriteloggerBlockTimeout),
+			OnLowDisk:    c.requestSnapshot,
+		})
+		wlSvc = wl
 	}
 
 	// Set up Snapshotter.
@@ -148,12 +233,25 @@ func newCommand(addr dax.Address, cfg CommandConfig) (*fbserver.Command, error)
 	ssDirToCompare := strings.TrimSpace(strings.ToLower(cfg.ComputerConfig.SnapshotterDir))
 	switch ssDirToCompare {
 	case "":
-		return nil, errors.New(errors.ErrUncoded, "no snapshotter directory configured")
+		return nil, nil, nil, errors.New(errors.ErrUncoded, "no snapshotter directory configured")
 	case serviceOffValue:
 		ssSvc = computer.NewNopSnapshotterService()
 		cfg.Logger.Warnf("No snapshotter configured, dynamic scaling will not function properly.")
 	default:
-		ssSvc = snapshotter.New(cfg.ComputerConfig.SnapshotterDir, cfg.Logger)
+		ss := snapshotter.New(cfg.ComputerConfig.SnapshotterDir, cfg.Logger)
+		ss.SetStaging(snapshotter.Staging{
+			Dir:          cfg.ComputerConfig.SnapshotterStagingDir,
+			MinFreeBytes: cfg.ComputerConfig.SnapshotterMinFreeBytes,
+		})
+		ssSvc = ss
+	}
+
+	if wl, ok := wlSvc.(*writelogger.Writelogger); ok && cfg.ComputerConfig.WriteloggerAutoTruncate {
+		wl.SetRetention(writelogger.Retention{
+			Snapshots: ssSvc,
+			Keep:      cfg.ComputerConfig.WriteloggerRetainLogs,
+			Interval:  time.Duration(cfg.ComputerConfig.WriteloggerTruncateInterval),
+		})
 	}
 
 	// Set the FeatureBase.Config values based on the top-level Config
@@ -176,7 +274,7 @@ func newCommand(addr dax.Address, cfg CommandConfig) (*fbserver.Command, error)
 		}),
 	)
 
-	return fbcmd, nil
+	return fbcmd, wlSvc, ssSvc, nil
 }
 
 type nopListener struct{}
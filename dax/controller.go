@@ -5,6 +5,14 @@ type Controller interface {
 	Schemar
 }
 
+// ControllerEndpoint is the state of one of the Controller replicas among
+// which a client fails over.
+type ControllerEndpoint struct {
+	Address Address `json:"address"`
+	Healthy bool    `json:"healthy"`
+	Active  bool    `json:"active"`
+}
+
 // Ensure type implements interface.
 var _ Noder = &nopController{}
 var _ Schemar = &nopController{}
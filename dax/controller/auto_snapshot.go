@@ -0,0 +1,649 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// The destructive operations before which an auto-snapshot can be taken.
+const (
+	AutoSnapshotDropTable    = "drop-table"
+	AutoSnapshotDropDatabase = "drop-database"
+	AutoSnapshotDropField    = "drop-field"
+)
+
+// AutoSnapshotOperations are all of the operations before which an
+// auto-snapshot can be taken.
+var AutoSnapshotOperations = []string{AutoSnapshotDropTable, AutoSnapshotDropDatabase, AutoSnapshotDropField}
+
+// What a destructive operation does when its auto-snapshot fails.
+const (
+	AutoSnapshotOnFailureBlock   = "block"
+	AutoSnapshotOnFailureProceed = "proceed"
+)
+
+// defaultAutoSnapshotDir is the directory, within the snapshotter directory,
+// in which auto-snapshots are kept when AutoSnapshotConfig.Dir is not set.
+const defaultAutoSnapshotDir = ".auto-snapshots"
+
+// autoSnapshotManifestFile is the name of the file, within the directory of
+// an auto-snapshot, which describes it.
+const autoSnapshotManifestFile = "auto-snapshot.json"
+
+// AutoSnapshotConfig configures the snapshots which the controller takes
+// automatically before destructive operations.
+//
+// An auto-snapshot is a copy of the snapshot and write log files of every
+// table the operation affects, along with their schema, from which the tables
+// can be restored with Controller.RestoreAutoSnapshot. Since a table's
+// snapshots and the write logs which follow them hold all of its data, this
+// doesn't require the compute nodes to take new snapshots. Writes which land
+// between the copy and the operation aren't in the auto-snapshot.
+//
+// Deleting records (for example, a SQL DELETE without a WHERE clause) is
+// applied by the compute nodes without involving the controller, so it
+// isn't covered.
+type AutoSnapshotConfig struct {
+	// Enabled turns on auto-snapshots.
+	Enabled bool `toml:"enabled"`
+
+	// Operations are the operations before which an auto-snapshot is taken:
+	// any of "drop-table", "drop-database" and "drop-field". If empty, all of
+	// them are.
+	Operations []string `toml:"operations"`
+
+	// OnFailure is what an operation does when its auto-snapshot fails:
+	// "block" fails the operation without making any change, and "proceed"
+	// logs a warning and carries on. If empty, "block" is used.
+	OnFailure string `toml:"on-failure"`
+
+	// Dir is the directory in which auto-snapshots are kept. If empty, a
+	// directory named ".auto-snapshots" within the snapshotter directory is
+	// used. Auto-snapshots are kept until they're deleted with
+	// Controller.DeleteAutoSnapshot.
+	Dir string `toml:"dir"`
+}
+
+// AutoSnapshot describes an auto-snapshot taken before a destructive
+// operation.
+type AutoSnapshot struct {
+	ID        string         `json:"id"`
+	Operation string         `json:"operation"`
+	Tables    []dax.TableKey `json:"tables"`
+	Created   time.Time      `json:"created"`
+
+	// Error is set when the auto-snapshot failed and the operation proceeded
+	// regardless. Such an auto-snapshot has no ID, and can't be restored.
+	Error string `json:"error,omitempty"`
+}
+
+// autoSnapshotManifest is the content of autoSnapshotManifestFile.
+type autoSnapshotManifest struct {
+	AutoSnapshot
+
+	// Database is set if the operation dropped the database, so that it can
+	// be re-created by a restore.
+	Database *dax.QualifiedDatabase `json:"database,omitempty"`
+	Schemas  []*dax.QualifiedTable  `json:"schemas"`
+}
+
+// autoSnapshots keeps the auto-snapshots in a directory.
+type autoSnapshots struct {
+	operations map[string]struct{}
+	block      bool
+
+	dir            string
+	snapshotterDir string
+	writeloggerDir string
+
+	// mu serializes changes to dir.
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// newAutoSnapshots validates cfg, and returns the auto-snapshots kept
+// according to it. The snapshot and write log files of tables are copied from
+// snapshotterDir and writeloggerDir.
+func newAutoSnapshots(cfg AutoSnapshotConfig, snapshotterDir, writeloggerDir string) (*autoSnapshots, error) {
+	a := &autoSnapshots{
+		operations:     make(map[string]struct{}),
+		block:          true,
+		dir:            cfg.Dir,
+		snapshotterDir: snapshotterDir,
+		writeloggerDir: writeloggerDir,
+		now:            time.Now,
+	}
+	if a.dir == "" {
+		a.dir = path.Join(snapshotterDir, defaultAutoSnapshotDir)
+	}
+
+	switch cfg.OnFailure {
+	case "", AutoSnapshotOnFailureBlock:
+	case AutoSnapshotOnFailureProceed:
+		a.block = false
+	default:
+		return nil, errors.Errorf("invalid auto-snapshot on-failure '%s': expected '%s' or '%s'", cfg.OnFailure, AutoSnapshotOnFailureBlock, AutoSnapshotOnFailureProceed)
+	}
+
+	ops := cfg.Operations
+	if len(ops) == 0 {
+		ops = AutoSnapshotOperations
+	}
+	for _, op := range ops {
+		valid := false
+		for _, o := range AutoSnapshotOperations {
+			valid = valid || o == op
+		}
+		if !valid {
+			return nil, errors.Errorf("invalid auto-snapshot operation '%s': expected one of %s", op, strings.Join(AutoSnapshotOperations, ", "))
+		}
+		if cfg.Enabled {
+			a.operations[op] = struct{}{}
+		}
+	}
+	return a, nil
+}
+
+// enabled returns true if an auto-snapshot is taken before op. It's safe to
+// call on a nil *autoSnapshots, which takes none.
+func (a *autoSnapshots) enabled(op string) bool {
+	if a == nil {
+		return false
+	}
+	_, ok := a.operations[op]
+	return ok
+}
+
+// take copies the files and schema of the tables into a new auto-snapshot.
+// The write logs of each table are copied before its snapshots: a snapshot
+// taken by a compute node during the copy may supersede a copied write log,
+// but since it includes everything in the log, nothing is lost. If qdb isn't
+// nil, the database is recorded too.
+func (a *autoSnapshots) take(op string, qdb *dax.QualifiedDatabase, qtbls []*dax.QualifiedTable) (*AutoSnapshot, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// The ID sorts by creation time; the random suffix keeps it unique.
+	rn := make([]byte, 4)
+	if _, err := rand.Read(rn); err != nil {
+		return nil, errors.Wrap(err, "generating auto-snapshot ID")
+	}
+	now := a.now().UTC()
+	m := autoSnapshotManifest{
+		AutoSnapshot: AutoSnapshot{
+			ID:        now.Format("20060102T150405Z") + "-" + hex.EncodeToString(rn),
+			Operation: op,
+			Tables:    []dax.TableKey{},
+			Created:   now,
+		},
+		Database: qdb,
+		Schemas:  qtbls,
+	}
+	for _, qtbl := range qtbls {
+		m.Tables = append(m.Tables, qtbl.Key())
+	}
+
+	// The auto-snapshot is assembled in a temporary directory, so that one
+	// which fails part way through never appears in the list.
+	if err := os.MkdirAll(a.dir, 0777); err != nil {
+		return nil, errors.Wrapf(err, "making directory: %s", a.dir)
+	}
+	tmp, err := os.MkdirTemp(a.dir, ".tmp-")
+	if err != nil {
+		return nil, errors.Wrap(err, "making temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, tkey := range m.Tables {
+		if err := copyDir(path.Join(a.writeloggerDir, string(tkey)), path.Join(tmp, string(tkey), "writelogs")); err != nil {
+			return nil, errors.Wrapf(err, "copying write logs of table %s", tkey)
+		}
+		if err := copyDir(path.Join(a.snapshotterDir, string(tkey)), path.Join(tmp, string(tkey), "snapshots")); err != nil {
+			return nil, errors.Wrapf(err, "copying snapshots of table %s", tkey)
+		}
+	}
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling auto-snapshot")
+	}
+	if err := os.WriteFile(path.Join(tmp, autoSnapshotManifestFile), buf, 0644); err != nil {
+		return nil, errors.Wrap(err, "writing auto-snapshot")
+	}
+	if err := os.Rename(tmp, path.Join(a.dir, m.ID)); err != nil {
+		return nil, errors.Wrap(err, "moving auto-snapshot into place")
+	}
+	return &m.AutoSnapshot, nil
+}
+
+// list returns the auto-snapshots, oldest first.
+func (a *autoSnapshots) list() ([]*AutoSnapshot, error) {
+	snaps := []*AutoSnapshot{}
+	if a == nil {
+		return snaps, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, err := os.ReadDir(a.dir)
+	if os.IsNotExist(err) {
+		return snaps, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading auto-snapshot directory")
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		m, err := a.manifest(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, &m.AutoSnapshot)
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Created.Before(snaps[j].Created) })
+	return snaps, nil
+}
+
+// dropped returns the newest auto-snapshot taken before a table or database
+// was dropped which holds a table for which match is true, along with that
+// table, or nil if there's none.
+func (a *autoSnapshots) dropped(match func(*dax.QualifiedTable) bool) (*AutoSnapshot, *dax.QualifiedTable, error) {
+	if a == nil {
+		return nil, nil, nil
+	}
+	snaps, err := a.list()
+	if err != nil {
+		return nil, nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := len(snaps) - 1; i >= 0; i-- {
+		if snaps[i].Operation == AutoSnapshotDropField || snaps[i].ID == "" {
+			continue
+		}
+		m, err := a.manifest(snaps[i].ID)
+		if errors.Is(err, ErrCodeAutoSnapshotNotFound) {
+			// Deleted since it was listed.
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		for _, qtbl := range m.Schemas {
+			if match(qtbl) {
+				return &m.AutoSnapshot, qtbl, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// manifest reads the manifest of the auto-snapshot with the given ID. The
+// caller must hold a.mu.
+func (a *autoSnapshots) manifest(id string) (*autoSnapshotManifest, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return nil, NewErrAutoSnapshotNotFound(id)
+	}
+	buf, err := os.ReadFile(path.Join(a.dir, id, autoSnapshotManifestFile))
+	if os.IsNotExist(err) {
+		return nil, NewErrAutoSnapshotNotFound(id)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "reading auto-snapshot: %s", id)
+	}
+	m := &autoSnapshotManifest{}
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, errors.Wrapf(err, "decoding auto-snapshot: %s", id)
+	}
+	return m, nil
+}
+
+// get returns the manifest of the auto-snapshot with the given ID.
+func (a *autoSnapshots) get(id string) (*autoSnapshotManifest, error) {
+	if a == nil {
+		return nil, NewErrAutoSnapshotNotFound(id)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.manifest(id)
+}
+
+// delete removes the auto-snapshot with the given ID.
+func (a *autoSnapshots) delete(id string) error {
+	if a == nil {
+		return NewErrAutoSnapshotNotFound(id)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.manifest(id); err != nil {
+		return err
+	}
+	return errors.Wrapf(os.RemoveAll(path.Join(a.dir, id)), "removing auto-snapshot: %s", id)
+}
+
+// restoreFiles copies the snapshot and write log files of the table back
+// from the auto-snapshot with the given ID, and returns the shards for which
+// it had files. The table mustn't have any files of its own.
+func (a *autoSnapshots) restoreFiles(id string, tkey dax.TableKey) (dax.ShardNums, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	src := path.Join(a.dir, id, string(tkey))
+	for _, dst := range []string{path.Join(a.snapshotterDir, string(tkey)), path.Join(a.writeloggerDir, string(tkey))} {
+		if _, err := os.Stat(dst); err == nil {
+			return nil, NewErrInvalidRequest(fmt.Sprintf("files of table %s already exist: %s", tkey, dst))
+		}
+	}
+	if err := copyDir(path.Join(src, "snapshots"), path.Join(a.snapshotterDir, string(tkey))); err != nil {
+		return nil, errors.Wrapf(err, "restoring snapshots of table %s", tkey)
+	}
+	if err := copyDir(path.Join(src, "writelogs"), path.Join(a.writeloggerDir, string(tkey))); err != nil {
+		return nil, errors.Wrapf(err, "restoring write logs of table %s", tkey)
+	}
+
+	// Shard files are kept at <table>/partition/<partition>/shard/<shard>.
+	seen := make(map[dax.ShardNum]struct{})
+	var shards dax.ShardNums
+	for _, dir := range []string{"snapshots", "writelogs"} {
+		partitions, _ := os.ReadDir(path.Join(src, dir, "partition"))
+		for _, p := range partitions {
+			entries, _ := os.ReadDir(path.Join(src, dir, "partition", p.Name(), "shard"))
+			for _, e := range entries {
+				n, err := strconv.ParseUint(e.Name(), 10, 64)
+				if err != nil {
+					continue
+				}
+				if _, ok := seen[dax.ShardNum(n)]; !ok {
+					seen[dax.ShardNum(n)] = struct{}{}
+					shards = append(shards, dax.ShardNum(n))
+				}
+			}
+		}
+	}
+	sort.Sort(shards)
+	return shards, nil
+}
+
+// copyDir copies the files in src, and the directories within it, to dst. It
+// does nothing if src doesn't exist. The lock files which compute nodes hold
+// on write logs aren't copied.
+func copyDir(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "reading directory: %s", src)
+	}
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return errors.Wrapf(err, "making directory: %s", dst)
+	}
+	for _, entry := range entries {
+		s, d := path.Join(src, entry.Name()), path.Join(dst, entry.Name())
+		if entry.IsDir() {
+			if err := copyDir(s, d); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), "_lock_") {
+			continue
+		}
+		if err := copyFile(s, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if os.IsNotExist(err) {
+		// The file was removed (for example, a write log superseded by a
+		// snapshot) after the directory was read.
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "opening %s", src)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrapf(err, "creating %s", dst)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return errors.Wrapf(err, "copying %s", src)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return errors.Wrapf(err, "syncing %s", dst)
+	}
+	return errors.Wrapf(out.Close(), "closing %s", dst)
+}
+
+type autoSnapshotKey struct{}
+
+// WithAutoSnapshotReceiver returns a copy of ctx which carries snap. A
+// destructive Controller method called with the returned context sets *snap
+// to the auto-snapshot it took before making its change, if any.
+func WithAutoSnapshotReceiver(ctx context.Context, snap **AutoSnapshot) context.Context {
+	return context.WithValue(ctx, autoSnapshotKey{}, snap)
+}
+
+// takeAutoSnapshot takes an auto-snapshot of the tables before op, if
+// auto-snapshots are enabled for op. If no tables are given, all of the
+// tables in the database are included, along with the database itself. If
+// the auto-snapshot fails and failures block, an ErrAutoSnapshotFailed error
+// is returned, and the operation must not go ahead. Nothing is taken in a dry
+// run.
+func (c *Controller) takeAutoSnapshot(ctx context.Context, op string, qdbid dax.QualifiedDatabaseID, qtids ...dax.QualifiedTableID) error {
+	if !c.autoSnapshots.enabled(op) || DryRunFromContext(ctx) != nil {
+		return nil
+	}
+
+	snap, err := func() (*AutoSnapshot, error) {
+		tx, err := c.Transactor.BeginTx(ctx, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "beginning tx")
+		}
+		defer tx.Rollback()
+
+		var qdb *dax.QualifiedDatabase
+		var qtbls []*dax.QualifiedTable
+		if len(qtids) == 0 {
+			if qdb, err = c.Schemar.DatabaseByID(tx, qdbid); err != nil {
+				return nil, errors.Wrapf(err, "getting database: %s", qdbid)
+			}
+			if qtbls, err = c.Schemar.Tables(tx, qdbid); err != nil {
+				return nil, errors.Wrapf(err, "getting tables for database: %s", qdbid)
+			}
+		}
+		for _, qtid := range qtids {
+			if err := c.sanitizeQTID(tx, &qtid); err != nil {
+				return nil, errors.Wrap(err, "sanitizing")
+			}
+			qtbl, err := c.Schemar.Table(tx, qtid)
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting table: %s", qtid)
+			}
+			qtbls = append(qtbls, qtbl)
+		}
+		return c.autoSnapshots.take(op, qdb, qtbls)
+	}()
+	if err != nil {
+		if c.autoSnapshots.block {
+			return NewErrAutoSnapshotFailed(op, err)
+		}
+		c.logger.Warnf("auto-snapshot before %s failed, proceeding: %v", op, err)
+		snap = &AutoSnapshot{Operation: op, Tables: []dax.TableKey{}, Created: c.autoSnapshots.now().UTC(), Error: err.Error()}
+	} else {
+		c.logger.Printf("took auto-snapshot %s of %v before %s", snap.ID, snap.Tables, op)
+	}
+
+	if receiver, ok := ctx.Value(autoSnapshotKey{}).(**AutoSnapshot); ok && receiver != nil {
+		*receiver = snap
+	}
+	return nil
+}
+
+// tableNotFound returns err, the Schemar's error for a table which doesn't
+// exist, as an ErrTableDropped error if the table was dropped and can be
+// restored from an auto-snapshot, so that a query on a table which was just
+// dropped says how to get it back.
+func (c *Controller) tableNotFound(err error, match func(*dax.QualifiedTable) bool) error {
+	if !dax.IsTableNotFound(err) {
+		return err
+	}
+	snap, qtbl, serr := c.autoSnapshots.dropped(match)
+	if serr != nil {
+		c.logger.Warnf("looking for an auto-snapshot of a missing table: %v", serr)
+		return err
+	} else if snap == nil {
+		return err
+	}
+	return dax.NewErrTableDropped(qtbl.Name, snap.Created, snap.ID)
+}
+
+// AutoSnapshots returns the auto-snapshots taken before destructive
+// operations, oldest first.
+func (c *Controller) AutoSnapshots(ctx context.Context) ([]*AutoSnapshot, error) {
+	return c.autoSnapshots.list()
+}
+
+// DeleteAutoSnapshot deletes the auto-snapshot with the given ID.
+func (c *Controller) DeleteAutoSnapshot(ctx context.Context, id string) error {
+	if err := rejectDryRun(ctx, "DeleteAutoSnapshot"); err != nil {
+		return err
+	}
+	return c.autoSnapshots.delete(id)
+}
+
+// RestoreAutoSnapshot re-creates the tables in the auto-snapshot with the
+// given ID, with their original IDs and schema, from the files it holds. The
+// tables mustn't exist; to restore a table which still exists (for example,
+// after a field was dropped), drop it first. The database is re-created if
+// the auto-snapshot was taken before it was dropped. Each shard for which
+// the auto-snapshot has files is assigned to a compute node, which loads it
+// from the restored snapshot and write log files. The auto-snapshot is kept.
+func (c *Controller) RestoreAutoSnapshot(ctx context.Context, id string) error {
+	if err := rejectDryRun(ctx, "RestoreAutoSnapshot"); err != nil {
+		return err
+	}
+
+	m, err := c.autoSnapshots.get(id)
+	if err != nil {
+		return err
+	}
+
+	// Check that the tables don't exist before touching any files.
+	if err := func() error {
+		tx, err := c.Transactor.BeginTx(ctx, false)
+		if err != nil {
+			return errors.Wrap(err, "beginning tx")
+		}
+		defer tx.Rollback()
+		for _, qtbl := range m.Schemas {
+			if _, err := c.Schemar.Table(tx, qtbl.QualifiedID()); err == nil {
+				return NewErrInvalidRequest(fmt.Sprintf("table %s exists; drop it before restoring it", qtbl.Key()))
+			}
+		}
+		return nil
+	}(); err != nil {
+		return err
+	}
+
+	// If the restore fails, the files which were restored are removed again,
+	// so that it can be retried.
+	restored := []dax.QualifiedTableID{}
+	cleanup := func() {
+		for _, qtid := range restored {
+			if err := c.Snapshotter.DeleteTable(qtid); err != nil {
+				c.logger.Warnf("removing restored snapshots: %v", err)
+			}
+			if err := c.Writelogger.DeleteTable(qtid); err != nil {
+				c.logger.Warnf("removing restored write logs: %v", err)
+			}
+		}
+	}
+
+	shards := make(map[dax.TableKey]dax.ShardNums, len(m.Schemas))
+	for _, qtbl := range m.Schemas {
+		s, err := c.autoSnapshots.restoreFiles(id, qtbl.Key())
+		if err != nil {
+			cleanup()
+			return err
+		}
+		restored = append(restored, qtbl.QualifiedID())
+		shards[qtbl.Key()] = s
+	}
+
+	var directives []*dax.Directive
+	var events []SchemaEvent
+
+	fn := func(tx dax.Transaction, writable bool) error {
+		events = events[:0]
+
+		if m.Database != nil {
+			qdbid := m.Database.QualifiedID()
+			if _, err := c.Schemar.DatabaseByID(tx, qdbid); errors.Is(err, dax.ErrDatabaseIDDoesNotExist) {
+				if err := c.Schemar.CreateDatabase(tx, m.Database); err != nil {
+					return errors.Wrap(err, "creating database in schemar")
+				}
+				events = append(events, SchemaEvent{Type: SchemaEventDatabaseCreated, Database: qdbid})
+			} else if err != nil {
+				return errors.Wrapf(err, "getting database: %s", qdbid)
+			}
+		}
+
+		workerSet := NewAddressSet()
+		for _, qtbl := range m.Schemas {
+			addrs, err := c.createTable(ctx, tx, qtbl)
+			if err != nil {
+				return err
+			}
+			workerSet.Merge(addrs)
+
+			jobs := make([]dax.Job, 0, len(shards[qtbl.Key()]))
+			for _, s := range shards[qtbl.Key()] {
+				jobs = append(jobs, shard(qtbl.Key(), s).Job())
+			}
+			if len(jobs) > 0 {
+				diffs, err := c.Balancer.AddJobs(tx, dax.RoleTypeCompute, qtbl.QualifiedID(), jobs...)
+				if err != nil {
+					return errors.Wrap(err, "adding shard jobs")
+				}
+				for _, diff := range diffs {
+					workerSet.Add(dax.Address(diff.Address))
+				}
+			}
+			events = append(events, SchemaEvent{Type: SchemaEventTableCreated, Database: qtbl.QualifiedDatabaseID, Table: qtbl.ID, Name: qtbl.Name})
+		}
+
+		var err error
+		directives, err = c.buildDirectives(ctx, tx, applyAddressMethod(workerSet.SortedSlice(), dax.DirectiveMethodFull))
+		if err != nil {
+			return errors.Wrap(err, "building directives")
+		}
+		return nil
+	}
+
+	if err := c.writeTx(ctx, fn); err != nil {
+		cleanup()
+		return errors.Wrap(err, "retry with tx: write")
+	}
+
+	return c.applyEffects(ctx, directives, events...)
+}
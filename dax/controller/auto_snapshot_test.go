@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/schemar"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tablesSchemar is a Schemar holding the tables of a single database in
+// memory.
+type tablesSchemar struct {
+	schemar.NopSchemar
+	qdb    *dax.QualifiedDatabase
+	tables map[dax.TableKey]*dax.QualifiedTable
+}
+
+func (s *tablesSchemar) DatabaseByID(tx dax.Transaction, qdbid dax.QualifiedDatabaseID) (*dax.QualifiedDatabase, error) {
+	return s.qdb, nil
+}
+
+func (s *tablesSchemar) CreateTable(tx dax.Transaction, qtbl *dax.QualifiedTable) error {
+	s.tables[qtbl.Key()] = qtbl
+	return nil
+}
+
+func (s *tablesSchemar) DropTable(tx dax.Transaction, qtid dax.QualifiedTableID) error {
+	delete(s.tables, qtid.Key())
+	return nil
+}
+
+func (s *tablesSchemar) Table(tx dax.Transaction, qtid dax.QualifiedTableID) (*dax.QualifiedTable, error) {
+	qtbl, ok := s.tables[qtid.Key()]
+	if !ok {
+		return nil, dax.NewErrTableIDDoesNotExist(qtid)
+	}
+	return qtbl, nil
+}
+
+func (s *tablesSchemar) TableID(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, name dax.TableName) (dax.QualifiedTableID, error) {
+	for _, qtbl := range s.tables {
+		if qtbl.QualifiedDatabaseID == qdbid && qtbl.Name == name {
+			return qtbl.QualifiedID(), nil
+		}
+	}
+	return dax.QualifiedTableID{}, dax.NewErrTableNameDoesNotExist(name)
+}
+
+// computeJobsBalancer is a Balancer which records the compute jobs added to
+// it.
+type computeJobsBalancer struct {
+	NopBalancer
+	jobs []dax.Job
+}
+
+func (b *computeJobsBalancer) AddJobs(tx dax.Transaction, roleType dax.RoleType, qtid dax.QualifiedTableID, jobs ...dax.Job) ([]dax.WorkerDiff, error) {
+	if roleType == dax.RoleTypeCompute {
+		b.jobs = append(b.jobs, jobs...)
+	}
+	return nil, nil
+}
+
+// writeFiles writes a file with contents at each of the paths, relative to
+// dir.
+func writeFiles(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		require.NoError(t, os.MkdirAll(path.Dir(path.Join(dir, p)), 0777))
+		require.NoError(t, os.WriteFile(path.Join(dir, p), []byte(p), 0644))
+	}
+}
+
+func TestNewAutoSnapshots(t *testing.T) {
+	a, err := newAutoSnapshots(AutoSnapshotConfig{}, "snap", "wl")
+	require.NoError(t, err)
+	assert.Equal(t, path.Join("snap", defaultAutoSnapshotDir), a.dir)
+	assert.False(t, a.enabled(AutoSnapshotDropTable))
+
+	a, err = newAutoSnapshots(AutoSnapshotConfig{Enabled: true, OnFailure: AutoSnapshotOnFailureProceed}, "snap", "wl")
+	require.NoError(t, err)
+	assert.False(t, a.block)
+	for _, op := range AutoSnapshotOperations {
+		assert.True(t, a.enabled(op), op)
+	}
+
+	a, err = newAutoSnapshots(AutoSnapshotConfig{Enabled: true, Operations: []string{AutoSnapshotDropField}, Dir: "auto"}, "snap", "wl")
+	require.NoError(t, err)
+	assert.Equal(t, "auto", a.dir)
+	assert.True(t, a.block)
+	assert.True(t, a.enabled(AutoSnapshotDropField))
+	assert.False(t, a.enabled(AutoSnapshotDropTable))
+
+	_, err = newAutoSnapshots(AutoSnapshotConfig{Operations: []string{"truncate"}}, "snap", "wl")
+	assert.Error(t, err)
+	_, err = newAutoSnapshots(AutoSnapshotConfig{OnFailure: "retry"}, "snap", "wl")
+	assert.Error(t, err)
+
+	var none *autoSnapshots
+	assert.False(t, none.enabled(AutoSnapshotDropTable))
+}
+
+func TestAutoSnapshots(t *testing.T) {
+	qdbid := dax.NewQualifiedDatabaseID("org", "db")
+	qtbl := dax.NewQualifiedTable(qdbid, &dax.Table{ID: "tbl", Name: "tbl"})
+	tkey := qtbl.Key()
+
+	snapDir, wlDir := t.TempDir(), t.TempDir()
+	writeFiles(t, snapDir,
+		path.Join(string(tkey), "partition/0/shard/1/3"),
+		path.Join(string(tkey), "partition/1/shard/4/0"),
+		path.Join(string(tkey), "partition/0/keys/2"),
+	)
+	writeFiles(t, wlDir,
+		path.Join(string(tkey), "partition/1/shard/4/1"),
+		path.Join(string(tkey), "partition/2/shard/6/0"),
+		path.Join(string(tkey), "partition/2/shard/6/_lock_0"),
+	)
+
+	a, err := newAutoSnapshots(AutoSnapshotConfig{Enabled: true}, snapDir, wlDir)
+	require.NoError(t, err)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	snaps, err := a.list()
+	require.NoError(t, err)
+	assert.Empty(t, snaps)
+
+	first, err := a.take(AutoSnapshotDropTable, nil, []*dax.QualifiedTable{qtbl})
+	require.NoError(t, err)
+	assert.Equal(t, []dax.TableKey{tkey}, first.Tables)
+	now = now.Add(time.Minute)
+	second, err := a.take(AutoSnapshotDropField, nil, []*dax.QualifiedTable{qtbl})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	snaps, err = a.list()
+	require.NoError(t, err)
+	require.Len(t, snaps, 2)
+	assert.Equal(t, first.ID, snaps[0].ID)
+	assert.Equal(t, AutoSnapshotDropField, snaps[1].Operation)
+
+	m, err := a.get(first.ID)
+	require.NoError(t, err)
+	require.Len(t, m.Schemas, 1)
+	assert.Equal(t, tkey, m.Schemas[0].Key())
+
+	_, err = a.get("missing")
+	assert.True(t, errors.Is(err, ErrCodeAutoSnapshotNotFound), err)
+	_, err = a.get("../" + first.ID)
+	assert.True(t, errors.Is(err, ErrCodeAutoSnapshotNotFound), err)
+
+	// Files can't be restored over the table's own files.
+	_, err = a.restoreFiles(first.ID, tkey)
+	assert.True(t, errors.Is(err, ErrCodeInvalidRequest), err)
+
+	require.NoError(t, os.RemoveAll(path.Join(snapDir, string(tkey))))
+	require.NoError(t, os.RemoveAll(path.Join(wlDir, string(tkey))))
+	shards, err := a.restoreFiles(first.ID, tkey)
+	require.NoError(t, err)
+	assert.Equal(t, dax.ShardNums{1, 4, 6}, shards)
+
+	buf, err := os.ReadFile(path.Join(snapDir, string(tkey), "partition/0/keys/2"))
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(string(tkey), "partition/0/keys/2"), string(buf))
+	assert.FileExists(t, path.Join(wlDir, string(tkey), "partition/1/shard/4/1"))
+	assert.NoFileExists(t, path.Join(wlDir, string(tkey), "partition/2/shard/6/_lock_0"))
+
+	require.NoError(t, a.delete(first.ID))
+	assert.True(t, errors.Is(a.delete(first.ID), ErrCodeAutoSnapshotNotFound))
+	snaps, err = a.list()
+	require.NoError(t, err)
+	require.Len(t, snaps, 1)
+	assert.Equal(t, second.ID, snaps[0].ID)
+}
+
+func TestControllerAutoSnapshot(t *testing.T) {
+	qdb := dax.NewQualifiedDatabase("org", &dax.Database{ID: "db", Name: "db"})
+	qtbl := dax.NewQualifiedTable(qdb.QualifiedID(), &dax.Table{ID: "tbl", Name: "tbl", PartitionN: 4})
+	qtid := qtbl.QualifiedID()
+
+	newController := func(t *testing.T, cfg AutoSnapshotConfig) (*Controller, *tablesSchemar, *computeJobsBalancer) {
+		cfg.Enabled = true
+		c := New(Config{SnapshotterDir: t.TempDir(), WriteloggerDir: t.TempDir()})
+		s := &tablesSchemar{qdb: qdb, tables: map[dax.TableKey]*dax.QualifiedTable{qtbl.Key(): qtbl}}
+		b := &computeJobsBalancer{}
+		c.Transactor = &countingTransactor{}
+		c.Schemar = s
+		c.Balancer = b
+		c.DirectiveVersion = zeroDirectiveVersion{}
+
+		var err error
+		c.autoSnapshots, err = newAutoSnapshots(cfg, c.snapshotterDir, c.writeloggerDir)
+		require.NoError(t, err)
+		writeFiles(t, c.snapshotterDir, path.Join(string(qtbl.Key()), "partition/2/shard/2/0"))
+		return c, s, b
+	}
+
+	t.Run("DropAndRestore", func(t *testing.T) {
+		c, s, b := newController(t, AutoSnapshotConfig{})
+
+		var snap *AutoSnapshot
+		ctx := WithAutoSnapshotReceiver(context.Background(), &snap)
+		require.NoError(t, c.DropTable(ctx, qtid))
+		require.NotNil(t, snap)
+		assert.Empty(t, snap.Error)
+		assert.Equal(t, AutoSnapshotDropTable, snap.Operation)
+		assert.Empty(t, s.tables)
+		assert.NoDirExists(t, path.Join(c.snapshotterDir, string(qtbl.Key())))
+
+		snaps, err := c.AutoSnapshots(context.Background())
+		require.NoError(t, err)
+		require.Len(t, snaps, 1)
+		assert.Equal(t, snap.ID, snaps[0].ID)
+
+		// Looking up the dropped table says it can be restored.
+		_, err = c.TableByName(context.Background(), qdb.QualifiedID(), qtbl.Name)
+		assert.True(t, errors.Is(err, dax.ErrTableDropped), err)
+		assert.True(t, dax.IsTableNotFound(err), err)
+		assert.Contains(t, errors.Message(err), snap.ID)
+		_, err = c.TableByID(context.Background(), qtid)
+		assert.True(t, errors.Is(err, dax.ErrTableDropped), err)
+		_, err = c.TableID(context.Background(), qdb.QualifiedID(), "other")
+		assert.True(t, errors.Is(err, dax.ErrTableNameDoesNotExist), err)
+
+		require.NoError(t, c.RestoreAutoSnapshot(context.Background(), snap.ID))
+		assert.Equal(t, qtbl.Key(), s.tables[qtbl.Key()].Key())
+		assert.Equal(t, []dax.Job{shard(qtbl.Key(), 2).Job()}, b.jobs)
+		assert.FileExists(t, path.Join(c.snapshotterDir, string(qtbl.Key()), "partition/2/shard/2/0"))
+
+		// The table now exists, so it can't be restored again.
+		err = c.RestoreAutoSnapshot(context.Background(), snap.ID)
+		assert.True(t, errors.Is(err, ErrCodeInvalidRequest), err)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		c, _, _ := newController(t, AutoSnapshotConfig{})
+		require.NoError(t, c.DropTable(WithDryRun(context.Background(), NewDryRun()), qtid))
+		snaps, err := c.AutoSnapshots(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, snaps)
+	})
+
+	// Auto-snapshots fail if their directory can't be made.
+	failingDir := func(t *testing.T) string {
+		f := path.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(f, nil, 0644))
+		return path.Join(f, "auto")
+	}
+
+	t.Run("FailureBlocks", func(t *testing.T) {
+		c, s, _ := newController(t, AutoSnapshotConfig{Dir: failingDir(t)})
+		err := c.DropTable(context.Background(), qtid)
+		assert.True(t, errors.Is(err, ErrCodeAutoSnapshotFailed), err)
+		assert.Len(t, s.tables, 1)
+	})
+
+	t.Run("FailureProceeds", func(t *testing.T) {
+		c, s, _ := newController(t, AutoSnapshotConfig{Dir: failingDir(t), OnFailure: AutoSnapshotOnFailureProceed})
+		var snap *AutoSnapshot
+		require.NoError(t, c.DropTable(WithAutoSnapshotReceiver(context.Background(), &snap), qtid))
+		require.NotNil(t, snap)
+		assert.NotEmpty(t, snap.Error)
+		assert.Empty(t, snap.ID)
+		assert.Empty(t, s.tables)
+	})
+
+	t.Run("NotEnabledForOperation", func(t *testing.T) {
+		c, s, _ := newController(t, AutoSnapshotConfig{Operations: []string{AutoSnapshotDropField}})
+		var snap *AutoSnapshot
+		require.NoError(t, c.DropTable(WithAutoSnapshotReceiver(context.Background(), &snap), qtid))
+		assert.Nil(t, snap)
+		assert.Empty(t, s.tables)
+	})
+}
@@ -22,6 +22,19 @@ type Balancer interface {
 	// RemoveJobs removes jobs for the given database.
 	RemoveJobs(tx dax.Transaction, roleType dax.RoleType, qtid dax.QualifiedTableID, jobs ...dax.Job) ([]dax.WorkerDiff, error)
 
+	// MoveJob reassigns a job from the worker currently responsible for it to
+	// the worker at addr, which must already be assigned to the database.
+	MoveJob(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, job dax.Job, addr dax.Address) ([]dax.WorkerDiff, error)
+
+	// FreeJobs returns the jobs for the given database which aren't assigned
+	// to a worker.
+	FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.Job, error)
+
+	// AssignFreeJobs assigns those of the given jobs which are free to the
+	// database's workers, without rebalancing the jobs which are already
+	// assigned. Jobs which aren't free are ignored.
+	AssignFreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, jobs ...dax.Job) ([]dax.WorkerDiff, error)
+
 	// BalanceDatabase forces a database balance. TODO(tlt): currently this is
 	// only used in tests, so perhaps we can get rid of it.
 	BalanceDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID) ([]dax.WorkerDiff, error)
@@ -73,6 +86,15 @@ func (b *NopBalancer) AddJobs(tx dax.Transaction, roleType dax.RoleType, qtid da
 func (b *NopBalancer) RemoveJobs(tx dax.Transaction, roleType dax.RoleType, qtid dax.QualifiedTableID, jobs ...dax.Job) ([]dax.WorkerDiff, error) {
 	return []dax.WorkerDiff{}, nil
 }
+func (b *NopBalancer) MoveJob(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, job dax.Job, addr dax.Address) ([]dax.WorkerDiff, error) {
+	return []dax.WorkerDiff{}, nil
+}
+func (b *NopBalancer) FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.Job, error) {
+	return []dax.Job{}, nil
+}
+func (b *NopBalancer) AssignFreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, jobs ...dax.Job) ([]dax.WorkerDiff, error) {
+	return []dax.WorkerDiff{}, nil
+}
 func (b *NopBalancer) BalanceDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID) ([]dax.WorkerDiff, error) {
 	return []dax.WorkerDiff{}, nil
 }
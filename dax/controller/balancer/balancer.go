@@ -442,6 +442,98 @@ func (b *Balancer) RemoveJobs(tx dax.Transaction, roleType dax.RoleType, qtid da
 	return diffs.Output(), nil
 }
 
+// MoveJob reassigns job from the worker currently responsible for it to the
+// worker at addr, which must already be assigned to the database.
+func (b *Balancer) MoveJob(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, job dax.Job, addr dax.Address) ([]dax.WorkerDiff, error) {
+	from, ok, err := b.workerForJob(tx, roleType, qdbid, job)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting worker for job: %s", job)
+	} else if !ok {
+		return nil, errors.Errorf("job is not assigned to a worker: (%s) %s, %s", roleType, qdbid, job)
+	} else if from == addr {
+		return []dax.WorkerDiff{}, nil
+	}
+
+	addrs, err := b.current.ListWorkers(tx, roleType, qdbid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing workers: (%s) %s", roleType, qdbid)
+	}
+	found := false
+	for _, a := range addrs {
+		if a == addr {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("worker is not assigned to database: (%s) %s, %s", roleType, qdbid, addr)
+	}
+
+	if err := b.current.DeleteJob(tx, roleType, qdbid, from, job); err != nil {
+		return nil, errors.Wrapf(err, "deleting job: (%s) %s, %s, %s", roleType, qdbid, from, job)
+	}
+	if err := b.current.AssignWorkerToJobs(tx, roleType, qdbid, addr, job); err != nil {
+		return nil, errors.Wrapf(err, "assigning job: (%s) %s, %s, %s", roleType, qdbid, addr, job)
+	}
+
+	diffs := NewInternalDiffs()
+	diffs.Removed(from, job)
+	diffs.Added(addr, job)
+
+	return diffs.Output(), nil
+}
+
+// FreeJobs returns the jobs for the given database which aren't assigned to a
+// worker.
+func (b *Balancer) FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.Job, error) {
+	jobs, err := b.freeJobs.ListJobs(tx, roleType, qdbid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing free jobs: (%s) %s", roleType, qdbid)
+	}
+	return jobs, nil
+}
+
+// AssignFreeJobs assigns those of the given jobs which are free to the
+// database's workers, each to the worker with the fewest jobs. Unlike a
+// balance, the jobs already assigned to workers stay where they are. If the
+// database has fewer than its minimum number of workers, free workers are
+// assigned to it first.
+func (b *Balancer) AssignFreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, jobs ...dax.Job) ([]dax.WorkerDiff, error) {
+	diffs := NewInternalDiffs()
+
+	if diff, err := b.assignMinWorkers(tx, roleType, qdbid); err != nil {
+		return nil, errors.Wrapf(err, "assigning min workers: (%s) %s", roleType, qdbid)
+	} else {
+		diffs.Merge(diff)
+	}
+
+	if cnt, err := b.current.WorkerCount(tx, roleType, qdbid); err != nil {
+		return nil, errors.Wrapf(err, "getting worker count: (%s) %s", roleType, qdbid)
+	} else if cnt == 0 {
+		return diffs.Output(), nil
+	}
+
+	free, err := b.freeJobs.ListJobs(tx, roleType, qdbid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing free jobs: (%s) %s", roleType, qdbid)
+	}
+	fset := dax.NewSet(free...)
+	toAssign := make([]dax.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if fset.Contains(job) {
+			toAssign = append(toAssign, job)
+		}
+	}
+
+	if diff, err := b.addDatabaseJobs(tx, roleType, qdbid, toAssign...); err != nil {
+		return nil, errors.Wrapf(err, "adding jobs: %s", toAssign)
+	} else {
+		diffs.Merge(diff)
+	}
+
+	return diffs.Output(), nil
+}
+
 func (b *Balancer) removeJobsForTable(tx dax.Transaction, roleType dax.RoleType, qtid dax.QualifiedTableID) (InternalDiffs, error) {
 	idiffs, err := b.current.DeleteJobsForTable(tx, roleType, qtid)
 	if err != nil {
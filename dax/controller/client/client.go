@@ -19,6 +19,10 @@ import (
 
 const (
 	defaultScheme = "http"
+
+	// ingestShardRetryInterval is how long IngestShard waits before retrying
+	// a request for a shard which is being migrated.
+	ingestShardRetryInterval = 250 * time.Millisecond
 )
 
 // Ensure type implements interface.
@@ -39,7 +43,8 @@ func New(address dax.Address, logger logger.Logger) *Client {
 		address: address,
 		logger:  logger,
 		httpClient: &http.Client{
-			Timeout: time.Second * 30,
+			Transport: dax.ServiceTransport(nil),
+			Timeout:   time.Second * 30,
 		},
 	}
 }
@@ -207,6 +212,43 @@ func (c *Client) Databases(ctx context.Context, orgID dax.OrganizationID, ids ..
 	return qdbs, nil
 }
 
+// ViewRefreshDatabases returns the databases, in every organization, whose
+// materialized views holder should refresh; see
+// controller.Controller.ViewRefreshDatabases.
+func (c *Client) ViewRefreshDatabases(ctx context.Context, holder string) ([]*dax.QualifiedDatabase, error) {
+	url := fmt.Sprintf("%s/view-refresh-databases", c.address.WithScheme(defaultScheme))
+
+	req := &controllerhttp.ViewRefreshDatabasesRequest{
+		Holder: holder,
+	}
+
+	// Encode the request.
+	postBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling post request")
+	}
+	responseBody := bytes.NewBuffer(postBody)
+
+	// Post the request.
+	c.logger.Debugf("POST view-refresh-databases request: url: %s", url)
+	resp, err := c.httpClient.Post(url, "application/json", responseBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "posting view-refresh-databases request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(errors.UnmarshalJSON(resp.Body), "status code: %d", resp.StatusCode)
+	}
+
+	var qdbs []*dax.QualifiedDatabase
+	if err := json.NewDecoder(resp.Body).Decode(&qdbs); err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+
+	return qdbs, nil
+}
+
 func (c *Client) SetDatabaseOption(ctx context.Context, qdbid dax.QualifiedDatabaseID, option string, value string) error {
 	url := fmt.Sprintf("%s/database/options", c.address.WithScheme(defaultScheme))
 
@@ -479,12 +521,26 @@ func (c *Client) IngestShard(ctx context.Context, qtid dax.QualifiedTableID, sha
 	if err != nil {
 		return host, errors.Wrap(err, "marshalling post request")
 	}
-	responseBody := bytes.NewBuffer(postBody)
 
-	// Post the request.
-	resp, err := c.httpClient.Post(url, "application/json", responseBody)
-	if err != nil {
-		return host, errors.Wrap(err, "posting ingest-shard request")
+	// Post the request. While the shard is being cut over to another compute
+	// node, the controller responds with StatusServiceUnavailable; the
+	// request is retried until the cutover completes or ctx is done.
+	var resp *http.Response
+	for {
+		resp, err = c.httpClient.Post(url, "application/json", bytes.NewReader(postBody))
+		if err != nil {
+			return host, errors.Wrap(err, "posting ingest-shard request")
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			break
+		}
+		resp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return host, errors.Wrap(ctx.Err(), "waiting for shard migration")
+		case <-time.After(ingestShardRetryInterval):
+		}
 	}
 	defer resp.Body.Close()
 
@@ -687,10 +743,16 @@ func (c *Client) SnapshotTable(ctx context.Context, qtid dax.QualifiedTableID) e
 	}
 	responseBody := bytes.NewBuffer(postBody)
 
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, responseBody)
+	if err != nil {
+		return errors.Wrap(err, "creating http request")
+	}
+	request.Header.Set("Content-Type", "application/json")
+
 	// Post the request.
-	resp, err := c.httpClient.Post(url, "application/json", responseBody)
+	resp, err := c.httpClient.Do(request)
 	if err != nil {
-		return errors.Wrap(err, "posting translate-nodes request")
+		return errors.Wrap(err, "posting snapshot request")
 	}
 	defer resp.Body.Close()
 
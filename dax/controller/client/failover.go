@@ -0,0 +1,327 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+// DefaultFailoverCheckInterval is how often a Failover checks the health of
+// its endpoints when it's given no interval.
+const DefaultFailoverCheckInterval = 10 * time.Second
+
+// Ensure type implements interface.
+var _ dax.Controller = (*Failover)(nil)
+
+// Failover is a Controller which uses one of several replicas of the
+// Controller, failing over to another when the one it's using can't be
+// reached. Endpoints are listed in order of preference; Run periodically
+// checks their health, and moves back to the most preferred healthy endpoint.
+type Failover struct {
+	clients  []*Client
+	interval time.Duration
+	logger   logger.Logger
+
+	mu      sync.RWMutex
+	active  int
+	healthy []bool
+}
+
+// NewFailover returns a Failover among the Controllers at addrs, which must
+// not be empty. It uses addrs[0] until it can't be reached.
+func NewFailover(addrs []dax.Address, interval time.Duration, logger logger.Logger) *Failover {
+	if interval <= 0 {
+		interval = DefaultFailoverCheckInterval
+	}
+	f := &Failover{
+		clients:  make([]*Client, len(addrs)),
+		interval: interval,
+		logger:   logger,
+		healthy:  make([]bool, len(addrs)),
+	}
+	for i, addr := range addrs {
+		f.clients[i] = New(addr, logger)
+		f.healthy[i] = true
+	}
+	return f
+}
+
+// Active returns the address of the Controller in use.
+func (f *Failover) Active() dax.Address {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.clients[f.active].address
+}
+
+// Endpoints returns the state of each endpoint, in order of preference.
+func (f *Failover) Endpoints() []dax.ControllerEndpoint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	out := make([]dax.ControllerEndpoint, len(f.clients))
+	for i, c := range f.clients {
+		out[i] = dax.ControllerEndpoint{
+			Address: c.address,
+			Healthy: f.healthy[i],
+			Active:  i == f.active,
+		}
+	}
+	return out
+}
+
+// Run checks the health of every endpoint each check interval until ctx is
+// done. After each check, the most preferred healthy endpoint becomes the
+// active one; if none is healthy, the active endpoint is left as it is.
+func (f *Failover) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.check()
+		}
+	}
+}
+
+// check checks the health of every endpoint, and selects the active one.
+func (f *Failover) check() {
+	healthy := make([]bool, len(f.clients))
+	var wg sync.WaitGroup
+	for i, c := range f.clients {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			healthy[i] = c.Health()
+		}(i, c)
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	copy(f.healthy, healthy)
+	for i, ok := range healthy {
+		if ok {
+			f.setActive(i)
+			return
+		}
+	}
+}
+
+// setActive makes the endpoint at i the active one. f.mu must be held.
+func (f *Failover) setActive(i int) {
+	if i == f.active {
+		return
+	}
+	f.logger.Warnf("controller failover: %s -> %s", f.clients[f.active].address, f.clients[i].address)
+	dax.CounterControllerFailovers.Inc()
+	f.active = i
+}
+
+// candidates returns the order in which endpoints are tried: the active one,
+// then the healthy ones, then the rest, in order of preference.
+func (f *Failover) candidates() []int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	out := make([]int, 0, len(f.clients))
+	out = append(out, f.active)
+	for _, healthy := range []bool{true, false} {
+		for i := range f.clients {
+			if i != f.active && f.healthy[i] == healthy {
+				out = append(out, i)
+			}
+		}
+	}
+	return out
+}
+
+// do calls fn with the active endpoint's client. If the endpoint can't be
+// reached, it's marked unhealthy, and fn is retried with each of the other
+// endpoints in turn; the first to be reached becomes the active one. Unless
+// idempotent is set, fn is only retried if the request wasn't sent, since the
+// endpoint may have applied a request whose response was lost. If ctx is
+// canceled or past its deadline, the error is returned as it is, and no
+// endpoint's health is changed, since the failure is the caller's.
+func (f *Failover) do(ctx context.Context, idempotent bool, fn func(*Client) error) error {
+	var err error
+	for _, i := range f.candidates() {
+		err = fn(f.clients[i])
+		if canceled(ctx, err) {
+			return err
+		}
+		if !unreachable(err, idempotent) {
+			f.mu.Lock()
+			f.healthy[i] = true
+			f.setActive(i)
+			f.mu.Unlock()
+			return err
+		}
+		f.mu.Lock()
+		f.healthy[i] = false
+		f.mu.Unlock()
+	}
+	return err
+}
+
+// canceled returns true if the request failed with err because ctx was
+// canceled or is past its deadline. The client's own timeout doesn't count;
+// an endpoint which doesn't respond in time is unreachable.
+func canceled(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
+
+// unreachable returns true if err means that the endpoint couldn't be reached,
+// and that the request may be retried at another endpoint. Unless idempotent
+// is set, that's only the case if the connection couldn't be made.
+func unreachable(err error, idempotent bool) bool {
+	var uerr *url.Error
+	if err == nil || !errors.As(err, &uerr) {
+		return false
+	}
+	if idempotent {
+		return true
+	}
+	var operr *net.OpError
+	return errors.As(err, &operr) && operr.Op == "dial"
+}
+
+func (f *Failover) CreateDatabase(ctx context.Context, qdb *dax.QualifiedDatabase) error {
+	return f.do(ctx, false, func(c *Client) error {
+		return c.CreateDatabase(ctx, qdb)
+	})
+}
+
+func (f *Failover) DropDatabase(ctx context.Context, qdbid dax.QualifiedDatabaseID) error {
+	return f.do(ctx, false, func(c *Client) error {
+		return c.DropDatabase(ctx, qdbid)
+	})
+}
+
+func (f *Failover) DatabaseByID(ctx context.Context, qdbid dax.QualifiedDatabaseID) (qdb *dax.QualifiedDatabase, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		qdb, err = c.DatabaseByID(ctx, qdbid)
+		return err
+	})
+	return qdb, err
+}
+
+func (f *Failover) DatabaseByName(ctx context.Context, orgID dax.OrganizationID, name dax.DatabaseName) (qdb *dax.QualifiedDatabase, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		qdb, err = c.DatabaseByName(ctx, orgID, name)
+		return err
+	})
+	return qdb, err
+}
+
+func (f *Failover) Databases(ctx context.Context, orgID dax.OrganizationID, ids ...dax.DatabaseID) (qdbs []*dax.QualifiedDatabase, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		qdbs, err = c.Databases(ctx, orgID, ids...)
+		return err
+	})
+	return qdbs, err
+}
+
+func (f *Failover) ViewRefreshDatabases(ctx context.Context, holder string) (qdbs []*dax.QualifiedDatabase, err error) {
+	// Asking again only renews the holder's lease.
+	err = f.do(ctx, true, func(c *Client) error {
+		qdbs, err = c.ViewRefreshDatabases(ctx, holder)
+		return err
+	})
+	return qdbs, err
+}
+
+func (f *Failover) SetDatabaseOption(ctx context.Context, qdbid dax.QualifiedDatabaseID, option string, value string) error {
+	// Setting an option to the same value twice is harmless.
+	return f.do(ctx, true, func(c *Client) error {
+		return c.SetDatabaseOption(ctx, qdbid, option, value)
+	})
+}
+
+func (f *Failover) TableByID(ctx context.Context, qtid dax.QualifiedTableID) (qtbl *dax.QualifiedTable, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		qtbl, err = c.TableByID(ctx, qtid)
+		return err
+	})
+	return qtbl, err
+}
+
+func (f *Failover) TableByName(ctx context.Context, qdbid dax.QualifiedDatabaseID, tname dax.TableName) (qtbl *dax.QualifiedTable, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		qtbl, err = c.TableByName(ctx, qdbid, tname)
+		return err
+	})
+	return qtbl, err
+}
+
+func (f *Failover) Tables(ctx context.Context, qdbid dax.QualifiedDatabaseID, ids ...dax.TableID) (qtbls []*dax.QualifiedTable, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		qtbls, err = c.Tables(ctx, qdbid, ids...)
+		return err
+	})
+	return qtbls, err
+}
+
+func (f *Failover) CreateTable(ctx context.Context, qtbl *dax.QualifiedTable) error {
+	return f.do(ctx, false, func(c *Client) error {
+		return c.CreateTable(ctx, qtbl)
+	})
+}
+
+func (f *Failover) DropTable(ctx context.Context, qtid dax.QualifiedTableID) error {
+	return f.do(ctx, false, func(c *Client) error {
+		return c.DropTable(ctx, qtid)
+	})
+}
+
+func (f *Failover) CreateField(ctx context.Context, qtid dax.QualifiedTableID, fld *dax.Field) error {
+	return f.do(ctx, false, func(c *Client) error {
+		return c.CreateField(ctx, qtid, fld)
+	})
+}
+
+func (f *Failover) DropField(ctx context.Context, qtid dax.QualifiedTableID, fldName dax.FieldName) error {
+	return f.do(ctx, false, func(c *Client) error {
+		return c.DropField(ctx, qtid, fldName)
+	})
+}
+
+// IngestShard and IngestPartition return the existing assignment if there is
+// one, so they're retried as reads are.
+
+func (f *Failover) IngestShard(ctx context.Context, qtid dax.QualifiedTableID, shard dax.ShardNum) (addr dax.Address, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		addr, err = c.IngestShard(ctx, qtid, shard)
+		return err
+	})
+	return addr, err
+}
+
+func (f *Failover) IngestPartition(ctx context.Context, qtid dax.QualifiedTableID, partition dax.PartitionNum) (addr dax.Address, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		addr, err = c.IngestPartition(ctx, qtid, partition)
+		return err
+	})
+	return addr, err
+}
+
+func (f *Failover) ComputeNodes(ctx context.Context, qtid dax.QualifiedTableID, shards ...dax.ShardNum) (nodes []dax.ComputeNode, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		nodes, err = c.ComputeNodes(ctx, qtid, shards...)
+		return err
+	})
+	return nodes, err
+}
+
+func (f *Failover) TranslateNodes(ctx context.Context, qtid dax.QualifiedTableID, partitions ...dax.PartitionNum) (nodes []dax.TranslateNode, err error) {
+	err = f.do(ctx, true, func(c *Client) error {
+		nodes, err = c.TranslateNodes(ctx, qtid, partitions...)
+		return err
+	})
+	return nodes, err
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// controllerReplica serves the Controller's table endpoint, counting the
+// requests it receives.
+type controllerReplica struct {
+	*httptest.Server
+	requests int32
+	healthy  int32
+}
+
+func newControllerReplica(t *testing.T) *controllerReplica {
+	r := &controllerReplica{healthy: 1}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/health":
+			if atomic.LoadInt32(&r.healthy) == 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/table":
+			atomic.AddInt32(&r.requests, 1)
+			_ = json.NewEncoder(w).Encode(&dax.QualifiedTable{Table: dax.Table{Name: "tbl"}})
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *controllerReplica) address() dax.Address {
+	return dax.Address(strings.TrimPrefix(r.URL, "http://"))
+}
+
+// unreachableAddress returns an address at which nothing is listening.
+func unreachableAddress(t *testing.T) dax.Address {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return dax.Address(addr)
+}
+
+func TestFailover(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Unreachable", func(t *testing.T) {
+		down := unreachableAddress(t)
+		a, b := newControllerReplica(t), newControllerReplica(t)
+		f := NewFailover([]dax.Address{down, a.address(), b.address()}, 0, logger.NopLogger)
+		assert.Equal(t, down, f.Active())
+
+		qtbl, err := f.TableByID(ctx, dax.QualifiedTableID{})
+		require.NoError(t, err)
+		assert.Equal(t, dax.TableName("tbl"), qtbl.Name)
+		assert.Equal(t, a.address(), f.Active())
+		assert.EqualValues(t, 1, a.requests)
+		assert.Equal(t, []dax.ControllerEndpoint{
+			{Address: down},
+			{Address: a.address(), Healthy: true, Active: true},
+			{Address: b.address(), Healthy: true},
+		}, f.Endpoints())
+
+		// Once failed over, requests go straight to the active endpoint.
+		_, err = f.TableByID(ctx, dax.QualifiedTableID{})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, a.requests)
+		assert.EqualValues(t, 0, b.requests)
+	})
+
+	t.Run("AllUnreachable", func(t *testing.T) {
+		f := NewFailover([]dax.Address{unreachableAddress(t), unreachableAddress(t)}, 0, logger.NopLogger)
+		_, err := f.TableByID(ctx, dax.QualifiedTableID{})
+		assert.Error(t, err)
+		for _, ep := range f.Endpoints() {
+			assert.False(t, ep.Healthy)
+		}
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		a, b := newControllerReplica(t), newControllerReplica(t)
+		f := NewFailover([]dax.Address{a.address(), b.address()}, 0, logger.NopLogger)
+
+		atomic.StoreInt32(&a.healthy, 0)
+		f.check()
+		assert.Equal(t, b.address(), f.Active())
+
+		// The preferred endpoint is used again once it's healthy.
+		atomic.StoreInt32(&a.healthy, 1)
+		f.check()
+		assert.Equal(t, a.address(), f.Active())
+
+		// With no healthy endpoint, the active one is kept.
+		atomic.StoreInt32(&a.healthy, 0)
+		atomic.StoreInt32(&b.healthy, 0)
+		f.check()
+		assert.Equal(t, a.address(), f.Active())
+	})
+
+	// A request canceled by its caller doesn't make any endpoint unhealthy,
+	// and isn't retried at the others.
+	// A request which fails once its caller has canceled it doesn't make the
+	// endpoint unhealthy, and isn't retried at the others.
+	t.Run("Canceled", func(t *testing.T) {
+		down := unreachableAddress(t)
+		a := newControllerReplica(t)
+		f := NewFailover([]dax.Address{down, a.address()}, 0, logger.NopLogger)
+		before := f.Endpoints()
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := f.TableByID(cctx, dax.QualifiedTableID{})
+		assert.Error(t, err)
+		assert.Equal(t, before, f.Endpoints())
+		assert.Equal(t, down, f.Active())
+		assert.EqualValues(t, 0, a.requests)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert.False(t, unreachable(nil, true))
+		assert.False(t, unreachable(dax.NewErrTableIDDoesNotExist(dax.QualifiedTableID{}), true))
+	})
+}
@@ -18,14 +18,61 @@ type Config struct {
 	// Poller
 	PollInterval time.Duration `toml:"poll-interval"`
 
+	// HealthStaleAfter is the age beyond which the result of a compute node's
+	// last probe is reported as stale by the node health endpoint. If 0,
+	// three times the poll interval is used.
+	HealthStaleAfter time.Duration `toml:"health-stale-after"`
+
 	// Storage
 	StorageMethod string `toml:"storage-method"`
 
 	SQLDB *SQLDBConfig `toml:"sqldb"`
 
+	// TxRetries is the number of times a schema or topology change is tried
+	// when it conflicts with a concurrent change. Every retry re-reads the
+	// state it depends on within a new transaction, so retrying is safe for
+	// all controller operations; operations made against an expected table
+	// version (for example, CreateFieldAtVersion) are re-checked on each try.
+	// If 0, a default is used.
+	TxRetries int `toml:"tx-retries"`
+
+	// SchemaEventBufferSize is the number of recent schema change events kept
+	// so that a subscriber to the schema event stream which reconnects can
+	// be sent the events it missed. If 0, DefaultSchemaEventBufferSize is
+	// used.
+	SchemaEventBufferSize int `toml:"schema-event-buffer-size"`
+
+	// SchemaEventRetention is the length of time for which schema change
+	// events are kept for reconnecting subscribers. A subscriber whose last
+	// event is no longer kept must re-read the schema. If 0,
+	// DefaultSchemaEventRetention is used.
+	SchemaEventRetention time.Duration `toml:"schema-event-retention"`
+
+	// ShardMigrationQuiesce is the length of time for which writes to a shard
+	// being migrated are held off before it's cut over to its new compute
+	// node, to allow writes which were already routed to the old node to
+	// complete. If 0, DefaultShardMigrationQuiesce is used.
+	ShardMigrationQuiesce time.Duration `toml:"shard-migration-quiesce"`
+
+	// ReReplicationBatchSize is the number of under-replicated shards a
+	// forced re-replication reassigns at once. If 0,
+	// DefaultReReplicationBatchSize is used.
+	ReReplicationBatchSize int `toml:"re-replication-batch-size"`
+
+	// ReReplicationInterval is the length of time a forced re-replication
+	// waits between batches, so that compute nodes can load the shards
+	// they've been given before they're given more. It's also the period on
+	// which the under-replicated shard metric is refreshed. If 0,
+	// DefaultReReplicationInterval is used.
+	ReReplicationInterval time.Duration `toml:"re-replication-interval"`
+
 	SnapshotterDir string `toml:"snapshotter-dir"`
 	WriteloggerDir string `toml:"writelogger-dir"`
 
+	// AutoSnapshot configures the snapshots taken automatically before
+	// destructive operations, such as dropping a table.
+	AutoSnapshot AutoSnapshotConfig `toml:"auto-snapshot"`
+
 	// RegistrationBatchTimeout is the time that the controller will
 	// wait after a node registers itself to see if any more nodes
 	// will register before sending out directives to all nodes which
@@ -39,6 +86,33 @@ type Config struct {
 	// until the timeout expires to start another round of snapshots.
 	SnappingTurtleTimeout time.Duration
 
+	// SnapshotConcurrency is the number of shard snapshots the automatic
+	// snapshotting routine has in progress at once, across all compute
+	// nodes. The snapshots it requests have background priority, so each
+	// compute node also limits them according to its own snapshot budget.
+	// 0 uses the default (1).
+	SnapshotConcurrency int `toml:"snapshot-concurrency"`
+
+	// SnapshotMaxAge is the age beyond which the shards of a table are
+	// snapshotted again, whether or not they've been written to, for tables
+	// which don't set their own maximum (see dax.Table.SnapshotMaxAge).
+	// Tables which can't be are reported by the snapshot_age_overdue metric.
+	// If 0, only tables which set their own maximum are checked.
+	SnapshotMaxAge time.Duration `toml:"snapshot-max-age"`
+
+	// SnapshotAgeCheckInterval is the period on which the ages of tables'
+	// snapshots are checked against their maximums. If 0,
+	// DefaultSnapshotAgeCheckInterval is used.
+	SnapshotAgeCheckInterval time.Duration `toml:"snapshot-age-check-interval"`
+
+	// MaintenanceWindows are the periods during which the automatic
+	// snapshotting routine may run. Each is of the form
+	// "<days> <HH:MM>-<HH:MM>", in UTC, where days is "*" or a
+	// comma-separated list of day names, such as "sat,sun 01:00-05:00". If
+	// there are none, it may run at any time. See
+	// Controller.MaintenanceState.
+	MaintenanceWindows []string `toml:"maintenance-windows"`
+
 	Logger logger.Logger `toml:"-"`
 }
 
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
@@ -12,6 +13,7 @@ import (
 	"github.com/featurebasedb/featurebase/v3/dax/controller/poller"
 	"github.com/featurebasedb/featurebase/v3/dax/controller/schemar"
 	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
+	"github.com/featurebasedb/featurebase/v3/dax/storage"
 	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
 	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
@@ -19,7 +21,10 @@ import (
 )
 
 const (
-	txRetry = 5
+	// defaultTxRetries is the number of times a transaction is tried, by
+	// default, before a conflict with a concurrent transaction is returned to
+	// the caller.
+	defaultTxRetries = 5
 )
 
 // Ensure type implements interface.
@@ -45,12 +50,61 @@ type Controller struct {
 
 	poller *poller.Poller
 
+	// txRetries is the number of times a transaction is tried before a
+	// conflict is returned to the caller.
+	txRetries int
+
 	registrationBatchTimeout time.Duration
 	nodeChan                 chan *dax.Node
 	snappingTurtleTimeout    time.Duration
-	snapControl              chan struct{}
+	snapshotConcurrency      int
+	snapControl              chan dax.SnapshotPriority
 	stopping                 chan struct{}
 
+	// schemaEvents broadcasts committed schema changes.
+	schemaEvents *schemaEvents
+
+	// shardMigrations tracks shards being moved between compute nodes.
+	shardMigrations       *shardMigrations
+	shardMigrationQuiesce time.Duration
+
+	// reReplications tracks forced re-replications of under-replicated
+	// shards.
+	reReplications         *reReplications
+	reReplicationBatchSize int
+	reReplicationInterval  time.Duration
+
+	// viewRefreshLease decides which queryer refreshes materialized views.
+	viewRefreshLease *viewRefreshLease
+
+	// healthStaleAfter is the default staleness threshold of NodeHealth.
+	healthStaleAfter time.Duration
+
+	// snapshotAges tracks the tables overdue for a snapshot. The ages of
+	// the shards' latest snapshots, as returned by latestShardSnapshot, are
+	// checked against snapshotMaxAgeDefault, or the table's own maximum,
+	// every snapshotAgeCheckInterval.
+	snapshotAges             *snapshotAges
+	latestShardSnapshot      func(dax.TableKey, dax.ShardNum) (time.Time, bool, error)
+	snapshotMaxAgeDefault    time.Duration
+	snapshotAgeCheckInterval time.Duration
+
+	// snapMu is held for the duration of each round of snapshots taken by the
+	// snapping turtle, or to enforce snapshot ages. Once snapDrained is set,
+	// no further rounds are started.
+	snapMu      sync.Mutex
+	snapDrained bool
+
+	// maintenance is parsed from maintenanceWindows by Start.
+	maintenanceWindows []string
+	maintenance        maintenanceSchedule
+
+	// autoSnapshots is set up from autoSnapshotConfig by Start.
+	autoSnapshotConfig AutoSnapshotConfig
+	autoSnapshots      *autoSnapshots
+	snapshotterDir     string
+	writeloggerDir     string
+
 	backgroundGroup errgroup.Group
 
 	logger logger.Logger
@@ -76,14 +130,58 @@ func New(cfg Config) *Controller {
 
 		Director: NewNopDirector(),
 
+		txRetries: defaultTxRetries,
+
 		registrationBatchTimeout: cfg.RegistrationBatchTimeout,
 		nodeChan:                 make(chan *dax.Node, 10),
 		snappingTurtleTimeout:    cfg.SnappingTurtleTimeout,
-		snapControl:              make(chan struct{}),
+		snapshotConcurrency:      1,
+		snapControl:              make(chan dax.SnapshotPriority),
+
+		schemaEvents: newSchemaEvents(cfg.SchemaEventBufferSize, cfg.SchemaEventRetention),
+
+		shardMigrations:       newShardMigrations(),
+		shardMigrationQuiesce: DefaultShardMigrationQuiesce,
+
+		snapshotAges:             newSnapshotAges(),
+		snapshotMaxAgeDefault:    cfg.SnapshotMaxAge,
+		snapshotAgeCheckInterval: DefaultSnapshotAgeCheckInterval,
+
+		reReplications:         newReReplications(),
+		reReplicationBatchSize: DefaultReReplicationBatchSize,
+		reReplicationInterval:  DefaultReReplicationInterval,
+
+		viewRefreshLease: newViewRefreshLease(),
+
+		maintenanceWindows: cfg.MaintenanceWindows,
+
+		autoSnapshotConfig: cfg.AutoSnapshot,
+		snapshotterDir:     cfg.SnapshotterDir,
+		writeloggerDir:     cfg.WriteloggerDir,
 
 		logger: logr,
 	}
 
+	if cfg.TxRetries > 0 {
+		c.txRetries = cfg.TxRetries
+	}
+	if cfg.ShardMigrationQuiesce > 0 {
+		c.shardMigrationQuiesce = cfg.ShardMigrationQuiesce
+	}
+	if cfg.ReReplicationBatchSize > 0 {
+		c.reReplicationBatchSize = cfg.ReReplicationBatchSize
+	}
+	if cfg.ReReplicationInterval > 0 {
+		c.reReplicationInterval = cfg.ReReplicationInterval
+	}
+	if cfg.SnapshotAgeCheckInterval > 0 {
+		c.snapshotAgeCheckInterval = cfg.SnapshotAgeCheckInterval
+	}
+	c.healthStaleAfter = cfg.HealthStaleAfter
+	if cfg.SnapshotConcurrency > 0 {
+		c.snapshotConcurrency = cfg.SnapshotConcurrency
+	}
+
 	// Poller.
 	pollerCfg := poller.Config{
 		AddressManager: c,
@@ -96,6 +194,9 @@ func New(cfg Config) *Controller {
 
 	// Snapshotter.
 	c.Snapshotter = snapshotter.New(cfg.SnapshotterDir, c.logger)
+	c.latestShardSnapshot = func(tkey dax.TableKey, shard dax.ShardNum) (time.Time, bool, error) {
+		return storage.LatestShardSnapshot(c.Snapshotter, tkey, shard)
+	}
 
 	// Writelogger.
 	c.Writelogger = writelogger.New(cfg.WriteloggerDir, c.logger)
@@ -108,6 +209,22 @@ func (c *Controller) Start() error {
 	// Set up the stopping channel here in case the controller restarts.
 	c.stopping = make(chan struct{})
 
+	c.snapMu.Lock()
+	c.snapDrained = false
+	c.snapMu.Unlock()
+
+	sched, err := newMaintenanceSchedule(c.maintenanceWindows)
+	if err != nil {
+		return errors.Wrap(err, "parsing maintenance windows")
+	}
+	c.maintenance = sched
+
+	autoSnaps, err := newAutoSnapshots(c.autoSnapshotConfig, c.snapshotterDir, c.writeloggerDir)
+	if err != nil {
+		return errors.Wrap(err, "configuring auto-snapshots")
+	}
+	c.autoSnapshots = autoSnaps
+
 	if err := c.Transactor.Start(); err != nil {
 		return errors.Wrap(err, "starting transactor")
 	}
@@ -121,6 +238,12 @@ func (c *Controller) Start() error {
 	c.backgroundGroup.Go(func() error {
 		return c.snappingTurtleRoutine(c.snappingTurtleTimeout, c.snapControl, c.logger.WithPrefix("Snapping Turtle: "))
 	})
+	c.backgroundGroup.Go(func() error {
+		return c.snapshotAgeRoutine(c.snapshotAgeCheckInterval, c.logger.WithPrefix("Snapshot Ages: "))
+	})
+	c.backgroundGroup.Go(func() error {
+		return c.underReplicationRoutine(c.reReplicationInterval)
+	})
 
 	return nil
 }
@@ -129,6 +252,10 @@ func (c *Controller) Start() error {
 func (c *Controller) Stop() error {
 	c.poller.Stop()
 
+	// Migrations which haven't started cutting over are aborted; those which
+	// have are waited for along with the other background routines.
+	c.shardMigrations.abortAll()
+
 	close(c.stopping)
 
 	err := c.backgroundGroup.Wait()
@@ -140,6 +267,25 @@ func (c *Controller) Stop() error {
 	return errors.Wrap(err2, "closing transactor")
 }
 
+// Drain waits for any in-progress round of snapshots to be finalized, and
+// prevents the snapping turtle from starting another.
+func (c *Controller) Drain(ctx context.Context) error {
+	locked := make(chan struct{})
+	go func() {
+		c.snapMu.Lock()
+		c.snapDrained = true
+		c.snapMu.Unlock()
+		close(locked)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for snapshots to finalize")
+	case <-locked:
+		return nil
+	}
+}
+
 // RegisterNodes adds nodes to the controller's list of registered
 // nodes.
 func (c *Controller) RegisterNodes(ctx context.Context, nodes ...*dax.Node) error {
@@ -197,6 +343,7 @@ func (c *Controller) RegisterNodes(ctx context.Context, nodes ...*dax.Node) erro
 			if err != nil {
 				return errors.Wrap(err, "adding worker")
 			}
+			DryRunFromContext(ctx).addDiffs(adiffs...)
 
 			for _, diff := range adiffs {
 				existingDiff, ok := diffByAddr[dax.Address(diff.Address)]
@@ -241,21 +388,21 @@ func (c *Controller) RegisterNodes(ctx context.Context, nodes ...*dax.Node) erro
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-
-	return nil
+	return c.applyEffects(ctx, directives)
 }
 
 // RegisterNode adds a node to the controller's list of registered
 // nodes. It makes no guarantees about when the node will actually be
 // used for anything or assigned any jobs.
 func (c *Controller) RegisterNode(ctx context.Context, n *dax.Node) error {
+	if err := rejectDryRun(ctx, "RegisterNode"); err != nil {
+		return err
+	}
+
 	// Validate input.
 	if n.Address == "" {
 		return NewErrNodeKeyInvalid(n.Address)
@@ -294,9 +441,14 @@ func (c *Controller) RegisterNode(ctx context.Context, n *dax.Node) error {
 // from its list (perhaps due to a network fault) and therefore the node needs
 // to be re-registered.
 func (c *Controller) CheckInNode(ctx context.Context, n *dax.Node) error {
+	if err := rejectDryRun(ctx, "CheckInNode"); err != nil {
+		return err
+	}
+
 	if n == nil || n.Address == "" {
 		return NewErrNodeKeyInvalid("")
 	}
+	c.poller.RecordCheckIn(n.Address)
 
 	tx, err := c.Transactor.BeginTx(ctx, false)
 	if err != nil {
@@ -350,6 +502,7 @@ func (c *Controller) DeregisterNodes(ctx context.Context, addresses ...dax.Addre
 			if err != nil {
 				return errors.Wrapf(err, "removing worker: %s", address)
 			}
+			DryRunFromContext(ctx).addDiffs(rdiffs...)
 
 			// we assume that the job names are different between the
 			// different role types so we don't have to track each
@@ -402,15 +555,11 @@ func (c *Controller) DeregisterNodes(ctx context.Context, addresses ...dax.Addre
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-
-	return nil
+	return c.applyEffects(ctx, directives)
 }
 
 // nodesTranslateReadOrWrite contains the logic for the c.nodesTranslate()
@@ -635,10 +784,21 @@ func (c *Controller) CreateDatabase(ctx context.Context, qdb *dax.QualifiedDatab
 		return nil
 	}
 
-	return dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry)
+	if err := c.writeTx(ctx, fn); err != nil {
+		return err
+	}
+
+	return c.applyEffects(ctx, nil, SchemaEvent{Type: SchemaEventDatabaseCreated, Database: qdb.QualifiedID()})
 }
 
+// DropDatabase drops the database and all of its tables. If auto-snapshots are
+// enabled for drop-database, one of the database and its tables is taken first
+// (see AutoSnapshotConfig).
 func (c *Controller) DropDatabase(ctx context.Context, qdbid dax.QualifiedDatabaseID) error {
+	if err := c.takeAutoSnapshot(ctx, AutoSnapshotDropDatabase, qdbid); err != nil {
+		return err
+	}
+
 	var directives []*dax.Directive
 
 	fn := func(tx dax.Transaction, writable bool) error {
@@ -686,14 +846,11 @@ func (c *Controller) DropDatabase(ctx context.Context, qdbid dax.QualifiedDataba
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-	return nil
+	return c.applyEffects(ctx, directives, SchemaEvent{Type: SchemaEventDatabaseDropped, Database: qdbid})
 }
 
 // DatabaseByName returns the database for the given name.
@@ -741,6 +898,7 @@ func (c *Controller) SetDatabaseOption(ctx context.Context, qdbid dax.QualifiedD
 		if err != nil {
 			return errors.Wrapf(err, "balancing database: %s", qdbid)
 		}
+		DryRunFromContext(ctx).addDiffs(diffs...)
 
 		workerSet := NewAddressSet()
 		for _, diff := range diffs {
@@ -759,14 +917,11 @@ func (c *Controller) SetDatabaseOption(ctx context.Context, qdbid dax.QualifiedD
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-	return nil
+	return c.applyEffects(ctx, directives, SchemaEvent{Type: SchemaEventDatabaseOptionSet, Database: qdbid})
 }
 
 func (c *Controller) Databases(ctx context.Context, orgID dax.OrganizationID, ids ...dax.DatabaseID) ([]*dax.QualifiedDatabase, error) {
@@ -797,93 +952,98 @@ func (c *Controller) CreateTable(ctx context.Context, qtbl *dax.QualifiedTable)
 	var directives []*dax.Directive
 
 	fn := func(tx dax.Transaction, writable bool) error {
-		// Create the table in schemar.
-		if err := c.Schemar.CreateTable(tx, qtbl); err != nil {
-			return errors.Wrapf(err, "creating table: %s", qtbl)
+		workerSet, err := c.createTable(ctx, tx, qtbl)
+		if err != nil {
+			return err
 		}
 
-		var addrMethods []addressMethod
-
-		// If the table is keyed, add partitions to the balancer.
-		if qtbl.StringKeys() {
-			// workerSet maintains the set of workers which have a job assignment change
-			// and therefore need to be sent an updated Directive.
-			workerSet := NewAddressSet()
+		// Convert the slice of addresses into a slice of addressMethod containing
+		// the appropriate method.
+		addrMethods := applyAddressMethod(workerSet.SortedSlice(), dax.DirectiveMethodFull)
 
-			// Generate the list of partitionsToAdd to be added.
-			partitionsToAdd := make(dax.PartitionNums, qtbl.PartitionN)
-			for partitionNum := 0; partitionNum < qtbl.PartitionN; partitionNum++ {
-				partitionsToAdd[partitionNum] = dax.PartitionNum(partitionNum)
-			}
+		directives, err = c.buildDirectives(ctx, tx, addrMethods)
+		if err != nil {
+			return errors.Wrap(err, "building directives")
+		}
 
-			jobs := make([]dax.Job, 0, len(partitionsToAdd))
-			for _, p := range partitionsToAdd {
-				jobs = append(jobs, partition(qtbl.Key(), p).Job())
-			}
+		return nil
+	}
 
-			diffs, err := c.Balancer.AddJobs(tx, dax.RoleTypeTranslate, qtbl.QualifiedID(), jobs...)
-			if err != nil {
-				return errors.Wrap(err, "adding job")
-			}
-			for _, diff := range diffs {
-				workerSet.Add(dax.Address(diff.Address))
-			}
+	if err := c.writeTx(ctx, fn); err != nil {
+		return errors.Wrap(err, "retry with tx: write")
+	}
 
-			// Convert the slice of addresses into a slice of addressMethod containing
-			// the appropriate method.
-			addrMethods = applyAddressMethod(workerSet.SortedSlice(), dax.DirectiveMethodFull)
-		}
+	return c.applyEffects(ctx, directives, SchemaEvent{Type: SchemaEventTableCreated, Database: qtbl.QualifiedDatabaseID, Table: qtbl.ID, Name: qtbl.Name})
+}
 
-		// This is more FieldVersion hackery. Even if the table is not keyed, we
-		// still want to manage partition 0 for the table in case any of the table's
-		// fields contain string keys (we use partition 0 for field string keys for
-		// now; in the future we should distribute/balance the field key translation
-		// like we do shards and partitions).
-		if !qtbl.StringKeys() {
-			// workerSet maintains the set of workers which have a job assignment change
-			// and therefore need to be sent an updated Directive.
-			workerSet := NewAddressSet()
+// createTable creates the table, which already has an ID, in schemar, and
+// assigns its partitions to translate workers. It returns the workers whose
+// jobs changed.
+func (c *Controller) createTable(ctx context.Context, tx dax.Transaction, qtbl *dax.QualifiedTable) (AddressSet, error) {
+	// Create the table in schemar.
+	if err := c.Schemar.CreateTable(tx, qtbl); err != nil {
+		return nil, errors.Wrapf(err, "creating table: %s", qtbl)
+	}
 
-			p := dax.PartitionNum(0)
+	// workerSet maintains the set of workers which have a job assignment change
+	// and therefore need to be sent an updated Directive.
+	workerSet := NewAddressSet()
 
-			// We don't currently use the returned diff, other than to determine
-			// which worker was affected, because we send the full Directive
-			// every time.
-			diffs, err := c.Balancer.AddJobs(tx, dax.RoleTypeTranslate, qtbl.QualifiedID(), partition(qtbl.Key(), p).Job())
-			if err != nil {
-				return errors.Wrap(err, "adding job")
-			}
-			for _, diff := range diffs {
-				workerSet.Add(dax.Address(diff.Address))
-			}
+	// If the table is keyed, add partitions to the balancer.
+	if qtbl.StringKeys() {
+		// Generate the list of partitionsToAdd to be added.
+		partitionsToAdd := make(dax.PartitionNums, qtbl.PartitionN)
+		for partitionNum := 0; partitionNum < qtbl.PartitionN; partitionNum++ {
+			partitionsToAdd[partitionNum] = dax.PartitionNum(partitionNum)
+		}
 
-			// Convert the slice of addresses into a slice of addressMethod containing
-			// the appropriate method.
-			addrMethods = applyAddressMethod(workerSet.SortedSlice(), dax.DirectiveMethodFull)
+		jobs := make([]dax.Job, 0, len(partitionsToAdd))
+		for _, p := range partitionsToAdd {
+			jobs = append(jobs, partition(qtbl.Key(), p).Job())
 		}
 
-		var err error
-		directives, err = c.buildDirectives(ctx, tx, addrMethods)
+		diffs, err := c.Balancer.AddJobs(tx, dax.RoleTypeTranslate, qtbl.QualifiedID(), jobs...)
 		if err != nil {
-			return errors.Wrap(err, "building directives")
+			return nil, errors.Wrap(err, "adding job")
+		}
+		DryRunFromContext(ctx).addDiffs(diffs...)
+		for _, diff := range diffs {
+			workerSet.Add(dax.Address(diff.Address))
 		}
-
-		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
-		return errors.Wrap(err, "retry with tx: write")
-	}
+	// This is more FieldVersion hackery. Even if the table is not keyed, we
+	// still want to manage partition 0 for the table in case any of the table's
+	// fields contain string keys (we use partition 0 for field string keys for
+	// now; in the future we should distribute/balance the field key translation
+	// like we do shards and partitions).
+	if !qtbl.StringKeys() {
+		p := dax.PartitionNum(0)
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
+		// We don't currently use the returned diff, other than to determine
+		// which worker was affected, because we send the full Directive
+		// every time.
+		diffs, err := c.Balancer.AddJobs(tx, dax.RoleTypeTranslate, qtbl.QualifiedID(), partition(qtbl.Key(), p).Job())
+		if err != nil {
+			return nil, errors.Wrap(err, "adding job")
+		}
+		DryRunFromContext(ctx).addDiffs(diffs...)
+		for _, diff := range diffs {
+			workerSet.Add(dax.Address(diff.Address))
+		}
 	}
-	return nil
+
+	return workerSet, nil
 }
 
 // DropTable removes a table from the schema and sends directives to all affected
-// nodes based on the change.
+// nodes based on the change. If auto-snapshots are enabled for drop-table, one
+// is taken first (see AutoSnapshotConfig).
 func (c *Controller) DropTable(ctx context.Context, qtid dax.QualifiedTableID) error {
+	if err := c.takeAutoSnapshot(ctx, AutoSnapshotDropTable, qtid.QualifiedDatabaseID, qtid); err != nil {
+		return err
+	}
+
 	var directives []*dax.Directive
 
 	fn := func(tx dax.Transaction, writable bool) error {
@@ -904,14 +1064,11 @@ func (c *Controller) DropTable(ctx context.Context, qtid dax.QualifiedTableID) e
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-	return nil
+	return c.applyEffects(ctx, directives, SchemaEvent{Type: SchemaEventTableDropped, Database: qtid.QualifiedDatabaseID, Table: qtid.ID, Name: qtid.Name})
 }
 
 // dropTable removes a table from the schema and sends directives to all affected
@@ -922,6 +1079,9 @@ func (c *Controller) dropTable(tx dax.Transaction, qtid dax.QualifiedTableID) (A
 		return nil, errors.Wrapf(err, "table not in schemar: %s", qtid)
 	}
 
+	dryRun := DryRunFromContext(tx.Context())
+	dryRun.addTable(qtid.Key())
+
 	// workerSet maintains the set of workers which have a job assignment change
 	// and therefore need to be sent an updated Directive.
 	workerSet := NewAddressSet()
@@ -930,6 +1090,7 @@ func (c *Controller) dropTable(tx dax.Transaction, qtid dax.QualifiedTableID) (A
 	if err != nil {
 		return nil, errors.Wrapf(err, "removing compute jobs for table: %s", qtid)
 	}
+	dryRun.addDiffs(diffs...)
 	for _, diff := range diffs {
 		workerSet.Add(dax.Address(diff.Address))
 	}
@@ -938,6 +1099,7 @@ func (c *Controller) dropTable(tx dax.Transaction, qtid dax.QualifiedTableID) (A
 	if err != nil {
 		return nil, errors.Wrapf(err, "removing translate jobs for table: %s", qtid)
 	}
+	dryRun.addDiffs(diffs...)
 	for _, diff := range diffs {
 		workerSet.Add(dax.Address(diff.Address))
 	}
@@ -947,7 +1109,11 @@ func (c *Controller) dropTable(tx dax.Transaction, qtid dax.QualifiedTableID) (A
 		return nil, errors.Wrapf(err, "dropping table from schemar: %s", qtid)
 	}
 
-	// Delete relevant table files from snapshotter and writelogger.
+	// Delete relevant table files from snapshotter and writelogger. These
+	// aren't part of the transaction, so they're left alone in a dry run.
+	if dryRun != nil {
+		return workerSet, nil
+	}
 	if err := c.Snapshotter.DeleteTable(qtid); err != nil {
 		return nil, errors.Wrap(err, "deleting from snapshotter")
 	}
@@ -967,7 +1133,13 @@ func (c *Controller) TableByID(ctx context.Context, qtid dax.QualifiedTableID) (
 	defer tx.Rollback()
 
 	// Get the table from the schemar.
-	return c.Schemar.Table(tx, qtid)
+	qtbl, err := c.Schemar.Table(tx, qtid)
+	if err != nil {
+		return nil, c.tableNotFound(err, func(dropped *dax.QualifiedTable) bool {
+			return dropped.Key() == qtid.Key()
+		})
+	}
+	return qtbl, nil
 }
 
 // Tables returns a list of tables by name.
@@ -1001,6 +1173,7 @@ func (c *Controller) RemoveShards(ctx context.Context, qtid dax.QualifiedTableID
 			if err != nil {
 				return errors.Wrap(err, "removing job")
 			}
+			DryRunFromContext(ctx).addDiffs(diffs...)
 			for _, diff := range diffs {
 				workerSet.Add(dax.Address(diff.Address))
 			}
@@ -1019,15 +1192,11 @@ func (c *Controller) RemoveShards(ctx context.Context, qtid dax.QualifiedTableID
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-
-	return nil
+	return c.applyEffects(ctx, directives)
 }
 
 func (c *Controller) sendDirectives(ctx context.Context, directives []*dax.Directive) error {
@@ -1550,26 +1719,41 @@ func (c *Controller) buildDirectivesAsDiffs(ctx context.Context, tx dax.Transact
 }
 
 // SnapshotTable snapshots a table. It might also snapshot everything
-// else... no guarantees here, only used in tests as of this writing.
+// else... no guarantees here, only used in tests as of this writing. The
+// snapshots are taken with urgent priority.
 func (c *Controller) SnapshotTable(ctx context.Context, qtid dax.QualifiedTableID) error {
-	c.snapControl <- struct{}{}
+	if err := rejectDryRun(ctx, "SnapshotTable"); err != nil {
+		return err
+	}
+
+	c.snapControl <- dax.SnapshotPriorityUrgent
 	return nil
 }
 
 // SnapshotShardData forces the compute node responsible for the given shard to
 // snapshot that shard, then increment its shard version for logs written to the
-// Writelogger.
-func (c *Controller) SnapshotShardData(ctx context.Context, qtid dax.QualifiedTableID, shardNum dax.ShardNum) error {
+// Writelogger. The snapshot is taken with the given priority; if it's empty,
+// the snapshot is urgent.
+func (c *Controller) SnapshotShardData(ctx context.Context, qtid dax.QualifiedTableID, shardNum dax.ShardNum, priority dax.SnapshotPriority) error {
+	if err := rejectDryRun(ctx, "SnapshotShardData"); err != nil {
+		return err
+	}
+	c.warnOutsideMaintenanceWindow(c.logger, "a shard snapshot")
+
+	if err := priority.Valid(); err != nil {
+		return NewErrInvalidRequest(err.Error())
+	}
+
 	tx, err := c.Transactor.BeginTx(ctx, false)
 	if err != nil {
 		return errors.Wrap(err, "beginning tx")
 	}
 	defer tx.Rollback()
 
-	return c.snapshotShardData(tx, qtid, shardNum)
+	return c.snapshotShardData(tx, qtid, shardNum, priority)
 }
 
-func (c *Controller) snapshotShardData(tx dax.Transaction, qtid dax.QualifiedTableID, shardNum dax.ShardNum) error {
+func (c *Controller) snapshotShardData(tx dax.Transaction, qtid dax.QualifiedTableID, shardNum dax.ShardNum, priority dax.SnapshotPriority) error {
 	qdbid := qtid.QualifiedDatabaseID
 
 	// Get the node responsible for the shard.
@@ -1590,6 +1774,7 @@ func (c *Controller) snapshotShardData(tx dax.Transaction, qtid dax.QualifiedTab
 		Address:  addr,
 		TableKey: qtid.Key(),
 		ShardNum: shardNum,
+		Priority: priority,
 	}
 
 	if err := c.Director.SendSnapshotShardDataRequest(tx.Context(), req); err != nil {
@@ -1603,6 +1788,11 @@ func (c *Controller) snapshotShardData(tx dax.Transaction, qtid dax.QualifiedTab
 // partition to snapshot the table keys for that partition, then increment its
 // version for logs written to the Writelogger.
 func (c *Controller) SnapshotTableKeys(ctx context.Context, qtid dax.QualifiedTableID, partitionNum dax.PartitionNum) error {
+	if err := rejectDryRun(ctx, "SnapshotTableKeys"); err != nil {
+		return err
+	}
+	c.warnOutsideMaintenanceWindow(c.logger, "a table keys snapshot")
+
 	tx, err := c.Transactor.BeginTx(ctx, false)
 	if err != nil {
 		return errors.Wrap(err, "beginning tx")
@@ -1646,6 +1836,11 @@ func (c *Controller) snapshotTableKeys(tx dax.Transaction, qtid dax.QualifiedTab
 // to snapshot the keys for that field, then increment its version for logs
 // written to the Writelogger.
 func (c *Controller) SnapshotFieldKeys(ctx context.Context, qtid dax.QualifiedTableID, field dax.FieldName) error {
+	if err := rejectDryRun(ctx, "SnapshotFieldKeys"); err != nil {
+		return err
+	}
+	c.warnOutsideMaintenanceWindow(c.logger, "a field keys snapshot")
+
 	tx, err := c.Transactor.BeginTx(ctx, false)
 	if err != nil {
 		return errors.Wrap(err, "beginning tx")
@@ -1830,6 +2025,10 @@ func assignedToTranslateNodes(nodes []dax.AssignedNode) ([]dax.TranslateNode, er
 }
 
 func (c *Controller) IngestPartition(ctx context.Context, qtid dax.QualifiedTableID, partition dax.PartitionNum) (dax.Address, error) {
+	if err := rejectDryRun(ctx, "IngestPartition"); err != nil {
+		return "", err
+	}
+
 	role := &dax.TranslateRole{
 		TableKey:   qtid.Key(),
 		Partitions: dax.PartitionNums{partition},
@@ -1867,7 +2066,7 @@ func (c *Controller) IngestPartition(ctx context.Context, qtid dax.QualifiedTabl
 	// If it's writable, and we couldn't find all the partitions with just a
 	// read, try again with a write transaction.
 	if retryAsWrite {
-		if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+		if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, c.txRetries); err != nil {
 			return "", errors.Wrap(err, "retry with tx: write")
 		}
 	}
@@ -1911,6 +2110,10 @@ func (c *Controller) IngestPartition(ctx context.Context, qtid dax.QualifiedTabl
 
 // IngestShard handles an ingest shard request.
 func (c *Controller) IngestShard(ctx context.Context, qtid dax.QualifiedTableID, shrdNum dax.ShardNum) (dax.Address, error) {
+	if err := rejectDryRun(ctx, "IngestShard"); err != nil {
+		return "", err
+	}
+
 	role := &dax.ComputeRole{
 		TableKey: qtid.Key(),
 		Shards:   dax.ShardNums{shrdNum},
@@ -1931,6 +2134,12 @@ func (c *Controller) IngestShard(ctx context.Context, qtid dax.QualifiedTableID,
 			return err
 		}
 
+		// Writes to a shard are held off while it's cut over to another
+		// compute node.
+		if id, ok := c.shardMigrations.quiesced(qtid.Key(), shrdNum); ok {
+			return NewErrShardMigrating(qtid, shrdNum, id)
+		}
+
 		var err error
 		nodes, retryAsWrite, directives, err = c.nodesComputeReadOrWrite(ctx, tx, role, qdbid, true, writable)
 		if err != nil {
@@ -1948,7 +2157,7 @@ func (c *Controller) IngestShard(ctx context.Context, qtid dax.QualifiedTableID,
 	// If it's writable, and we couldn't find all the partitions with just a
 	// read, try again with a write transaction.
 	if retryAsWrite {
-		if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+		if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, c.txRetries); err != nil {
 			return "", errors.Wrap(err, "retry with tx: write")
 		}
 		retryAsWrite = true
@@ -1993,6 +2202,17 @@ func (c *Controller) IngestShard(ctx context.Context, qtid dax.QualifiedTableID,
 ////
 
 func (c *Controller) CreateField(ctx context.Context, qtid dax.QualifiedTableID, fld *dax.Field) error {
+	return c.CreateFieldAtVersion(ctx, qtid, fld, "")
+}
+
+// CreateFieldAtVersion creates the field only if the table's schema is at the
+// given version (see dax.Table.Version). If version is empty, the field is
+// created regardless of the table's version.
+//
+// Conflicts with concurrent transactions are retried. When a version is
+// provided, each retry re-checks it, so a concurrent change to the table
+// results in an ErrTableVersionConflict rather than a blind re-apply.
+func (c *Controller) CreateFieldAtVersion(ctx context.Context, qtid dax.QualifiedTableID, fld *dax.Field, version string) error {
 	var directives []*dax.Directive
 
 	fn := func(tx dax.Transaction, writable bool) error {
@@ -2000,6 +2220,10 @@ func (c *Controller) CreateField(ctx context.Context, qtid dax.QualifiedTableID,
 			return errors.Wrap(err, "sanitizing")
 		}
 
+		if err := c.checkTableVersion(tx, qtid, version); err != nil {
+			return err
+		}
+
 		// Create the field in schemar.
 		if err := c.Schemar.CreateField(tx, qtid, fld); err != nil {
 			return errors.Wrapf(err, "creating field: %s, %s", qtid, fld)
@@ -2050,17 +2274,27 @@ func (c *Controller) CreateField(ctx context.Context, qtid dax.QualifiedTableID,
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
-	}
-	return nil
+	return c.applyEffects(ctx, directives, SchemaEvent{Type: SchemaEventFieldCreated, Database: qtid.QualifiedDatabaseID, Table: qtid.ID, Name: qtid.Name, Field: fld.Name})
 }
 
 func (c *Controller) DropField(ctx context.Context, qtid dax.QualifiedTableID, fldName dax.FieldName) error {
+	return c.DropFieldAtVersion(ctx, qtid, fldName, "")
+}
+
+// DropFieldAtVersion drops the field only if the table's schema is at the
+// given version (see dax.Table.Version). If version is empty, the field is
+// dropped regardless of the table's version. Retries behave as described on
+// CreateFieldAtVersion. If auto-snapshots are enabled for drop-field, one of
+// the table is taken first (see AutoSnapshotConfig).
+func (c *Controller) DropFieldAtVersion(ctx context.Context, qtid dax.QualifiedTableID, fldName dax.FieldName, version string) error {
+	if err := c.takeAutoSnapshot(ctx, AutoSnapshotDropField, qtid.QualifiedDatabaseID, qtid); err != nil {
+		return err
+	}
+
 	var directives []*dax.Directive
 
 	fn := func(tx dax.Transaction, writable bool) error {
@@ -2068,6 +2302,10 @@ func (c *Controller) DropField(ctx context.Context, qtid dax.QualifiedTableID, f
 			return errors.Wrap(err, "sanitizing")
 		}
 
+		if err := c.checkTableVersion(tx, qtid, version); err != nil {
+			return err
+		}
+
 		// Drop the field from schemar.
 		if err := c.Schemar.DropField(tx, qtid, fldName); err != nil {
 			return errors.Wrapf(err, "dropping field: %s, %s", qtid, fldName)
@@ -2118,12 +2356,25 @@ func (c *Controller) DropField(ctx context.Context, qtid dax.QualifiedTableID, f
 		return nil
 	}
 
-	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+	if err := c.writeTx(ctx, fn); err != nil {
 		return errors.Wrap(err, "retry with tx: write")
 	}
 
-	if err := c.sendDirectives(ctx, directives); err != nil {
-		return NewErrDirectiveSendFailure(err.Error())
+	return c.applyEffects(ctx, directives, SchemaEvent{Type: SchemaEventFieldDropped, Database: qtid.QualifiedDatabaseID, Table: qtid.ID, Name: qtid.Name, Field: fldName})
+}
+
+// checkTableVersion returns an ErrTableVersionConflict error if version is
+// not empty and does not match the current version of the table.
+func (c *Controller) checkTableVersion(tx dax.Transaction, qtid dax.QualifiedTableID, version string) error {
+	if version == "" {
+		return nil
+	}
+	qtbl, err := c.Schemar.Table(tx, qtid)
+	if err != nil {
+		return errors.Wrapf(err, "getting table: %s", qtid)
+	}
+	if current := qtbl.Version(); current != version {
+		return dax.NewErrTableVersionConflict(qtid, version, current)
 	}
 	return nil
 }
@@ -2149,6 +2400,18 @@ func (c *Controller) DebugNodes(ctx context.Context) ([]*dax.Node, error) {
 	return c.Balancer.Nodes(tx)
 }
 
+// NodeHealth returns the health of every compute node, as last probed by the
+// poller. It's served from the poller's state, so it's cheap enough for
+// monitoring to call as often as it likes. Nodes which haven't been probed
+// within staleAfter are marked stale; if staleAfter is 0, the configured
+// HealthStaleAfter is used.
+func (c *Controller) NodeHealth(staleAfter time.Duration) poller.ClusterHealth {
+	if staleAfter <= 0 {
+		staleAfter = c.healthStaleAfter
+	}
+	return c.poller.Health(staleAfter)
+}
+
 func (c *Controller) CurrentState(ctx context.Context) ([]dax.WorkerInfo, error) {
 	tx, err := c.Transactor.BeginTx(ctx, false)
 	if err != nil {
@@ -2182,7 +2445,7 @@ func (c *Controller) TableByName(ctx context.Context, qdbid dax.QualifiedDatabas
 
 	qtid, err := c.Schemar.TableID(tx, qdbid, name)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting table id")
+		return nil, errors.Wrap(c.tableNotFound(err, droppedTableNamed(qdbid, name)), "getting table id")
 	}
 
 	// Get the table from the schemar.
@@ -2198,7 +2461,19 @@ func (c *Controller) TableID(ctx context.Context, qdbid dax.QualifiedDatabaseID,
 	}
 	defer tx.Rollback()
 
-	return c.Schemar.TableID(tx, qdbid, name)
+	qtid, err := c.Schemar.TableID(tx, qdbid, name)
+	if err != nil {
+		return dax.QualifiedTableID{}, c.tableNotFound(err, droppedTableNamed(qdbid, name))
+	}
+	return qtid, nil
+}
+
+// droppedTableNamed returns a func matching the dropped table which had the
+// given name in qdbid.
+func droppedTableNamed(qdbid dax.QualifiedDatabaseID, name dax.TableName) func(*dax.QualifiedTable) bool {
+	return func(dropped *dax.QualifiedTable) bool {
+		return dropped.QualifiedDatabaseID == qdbid && dropped.Name == name
+	}
 }
 
 // WorkerRegistry
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// DryRun describes what a mutating Controller method would have done. It's
+// collected when the method is called with a context returned by WithDryRun,
+// in which case the method validates and applies the operation within a
+// transaction which is then rolled back. No state is changed, not even
+// partially: the schema, the job assignments, and the directive versions are
+// left as they were, no directives or snapshot requests are sent to workers,
+// no schema events are published, and no snapshot or write log files are
+// deleted. An operation which would fail returns the same error it would
+// return without a dry run.
+//
+// The methods which support dry runs are CreateDatabase, DropDatabase,
+// SetDatabaseOption, CreateTable, DropTable, CreateField, DropField,
+// RegisterNodes, DeregisterNodes, RemoveShards, and MigrateShard. Those
+// whose effect can't be separated from applying it, such as RegisterNode
+// (which is applied asynchronously) and the snapshot and ingest methods,
+// return an ErrDryRunUnsupported error instead.
+//
+// All methods are safe to call on a nil *DryRun, in which case they do
+// nothing.
+type DryRun struct {
+	// SchemaEvents are the schema changes which the operation would commit.
+	// They aren't assigned an ID or time.
+	SchemaEvents []SchemaEvent `json:"schema-events"`
+
+	// Tables are the tables whose schema or job assignments would change,
+	// including those which would be dropped.
+	Tables []dax.TableKey `json:"tables"`
+
+	// Workers are the jobs (shards and partitions) which would be assigned
+	// to, or removed from, each worker. A job which moves from one worker to
+	// another is removed from the first and added to the second.
+	Workers []DryRunWorker `json:"workers"`
+
+	// Directives are the directives which would be sent to workers.
+	Directives []*dax.Directive `json:"directives"`
+
+	tables map[dax.TableKey]struct{}
+	diffs  map[dax.Address]*dax.WorkerDiff
+}
+
+// DryRunWorker is the change in the jobs assigned to a single worker.
+type DryRunWorker struct {
+	Address     dax.Address `json:"address"`
+	AddedJobs   []dax.Job   `json:"added-jobs"`
+	RemovedJobs []dax.Job   `json:"removed-jobs"`
+}
+
+// NewDryRun returns a new, empty DryRun.
+func NewDryRun() *DryRun {
+	d := &DryRun{}
+	d.reset()
+	return d
+}
+
+// reset discards everything recorded in d. It's called before each try of a
+// transaction, so that a try which conflicts and is retried doesn't leave
+// behind its effects.
+func (d *DryRun) reset() {
+	if d == nil {
+		return
+	}
+	d.SchemaEvents = []SchemaEvent{}
+	d.Tables = []dax.TableKey{}
+	d.Workers = []DryRunWorker{}
+	d.Directives = []*dax.Directive{}
+	d.tables = make(map[dax.TableKey]struct{})
+	d.diffs = make(map[dax.Address]*dax.WorkerDiff)
+}
+
+// addTable records that the schema or jobs of the table would change.
+func (d *DryRun) addTable(tkey dax.TableKey) {
+	if d == nil {
+		return
+	}
+	d.tables[tkey] = struct{}{}
+}
+
+// addDiffs records changes to the jobs assigned to workers. Diffs for the
+// same worker are combined, so a job which is added and then removed again
+// isn't reported.
+func (d *DryRun) addDiffs(diffs ...dax.WorkerDiff) {
+	if d == nil {
+		return
+	}
+	for _, diff := range diffs {
+		existing, ok := d.diffs[diff.Address]
+		if !ok {
+			existing = &dax.WorkerDiff{Address: diff.Address}
+			d.diffs[diff.Address] = existing
+		}
+		existing.Add(diff)
+	}
+}
+
+// finish records the directives and schema events which the operation would
+// send and publish, and fills in Tables and Workers from everything
+// recorded.
+func (d *DryRun) finish(directives []*dax.Directive, events []SchemaEvent) {
+	if d == nil {
+		return
+	}
+	d.Directives = append(d.Directives, directives...)
+	d.SchemaEvents = append(d.SchemaEvents, events...)
+
+	for _, e := range events {
+		if e.Table != "" {
+			d.addTable(dax.NewQualifiedTableID(e.Database, e.Table).Key())
+		}
+	}
+	for _, diff := range d.diffs {
+		if len(diff.AddedJobs) == 0 && len(diff.RemovedJobs) == 0 {
+			continue
+		}
+		w := DryRunWorker{
+			Address:     diff.Address,
+			AddedJobs:   append([]dax.Job{}, diff.AddedJobs...),
+			RemovedJobs: append([]dax.Job{}, diff.RemovedJobs...),
+		}
+		sort.Slice(w.AddedJobs, func(i, j int) bool { return w.AddedJobs[i] < w.AddedJobs[j] })
+		sort.Slice(w.RemovedJobs, func(i, j int) bool { return w.RemovedJobs[i] < w.RemovedJobs[j] })
+		d.Workers = append(d.Workers, w)
+
+		for _, jobs := range [][]dax.Job{diff.AddedJobs, diff.RemovedJobs} {
+			for _, job := range jobs {
+				d.addTable(jobTable(job))
+			}
+		}
+	}
+	sort.Slice(d.Workers, func(i, j int) bool { return d.Workers[i].Address < d.Workers[j].Address })
+
+	d.Tables = d.Tables[:0]
+	for tkey := range d.tables {
+		d.Tables = append(d.Tables, tkey)
+	}
+	sort.Slice(d.Tables, func(i, j int) bool { return d.Tables[i] < d.Tables[j] })
+}
+
+// jobTable returns the key of the table to which a shard or partition job
+// belongs.
+func jobTable(job dax.Job) dax.TableKey {
+	if s, err := decodeShard(job); err == nil {
+		return s.table()
+	}
+	if p, err := decodePartition(job); err == nil {
+		return p.table()
+	}
+	tkey, _, _ := strings.Cut(string(job), "|")
+	return dax.TableKey(tkey)
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns a copy of ctx which carries d. A mutating Controller
+// method called with the returned context records its effect in d rather
+// than applying it. Only methods which document support for dry runs accept
+// such a context; the others return an ErrDryRunUnsupported error.
+func WithDryRun(ctx context.Context, d *DryRun) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, d)
+}
+
+// DryRunFromContext returns the DryRun carried by ctx, or nil if ctx does not
+// carry one.
+func DryRunFromContext(ctx context.Context) *DryRun {
+	d, _ := ctx.Value(dryRunKey{}).(*DryRun)
+	return d
+}
+
+// writeTx calls fn with a writable transaction, retrying conflicts. If ctx
+// carries a DryRun, the transaction is always rolled back rather than
+// committed.
+func (c *Controller) writeTx(ctx context.Context, fn func(tx dax.Transaction, writable bool) error) error {
+	dryRun := DryRunFromContext(ctx)
+	if dryRun == nil {
+		return dax.RetryWithTx(ctx, c.Transactor, fn, true, c.txRetries)
+	}
+	return dax.DryRunWithTx(ctx, c.Transactor, func(tx dax.Transaction, writable bool) error {
+		dryRun.reset()
+		return fn(tx, writable)
+	}, c.txRetries)
+}
+
+// applyEffects publishes the schema events and sends the directives which
+// result from a committed change. If ctx carries a DryRun, the change wasn't
+// committed, and they're recorded in the DryRun instead.
+func (c *Controller) applyEffects(ctx context.Context, directives []*dax.Directive, events ...SchemaEvent) error {
+	if dryRun := DryRunFromContext(ctx); dryRun != nil {
+		dryRun.finish(directives, events)
+		return nil
+	}
+
+	for _, e := range events {
+		c.schemaEvents.publish(e)
+	}
+	if err := c.sendDirectives(ctx, directives); err != nil {
+		return NewErrDirectiveSendFailure(err.Error())
+	}
+	return nil
+}
+
+// rejectDryRun returns an ErrDryRunUnsupported error if ctx carries a DryRun.
+// Methods which can't avoid applying their effect call it first.
+func rejectDryRun(ctx context.Context, method string) error {
+	if DryRunFromContext(ctx) != nil {
+		return NewErrDryRunUnsupported(method)
+	}
+	return nil
+}
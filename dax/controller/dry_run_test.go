@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransactor is a dax.Transactor whose transactions only count how
+// they were finished.
+type countingTransactor struct {
+	commits   int
+	rollbacks int
+}
+
+func (t *countingTransactor) Start() error { return nil }
+func (t *countingTransactor) Close() error { return nil }
+func (t *countingTransactor) BeginTx(ctx context.Context, writable bool) (dax.Transaction, error) {
+	return &countingTx{ctx: ctx, t: t}, nil
+}
+
+type countingTx struct {
+	ctx  context.Context
+	t    *countingTransactor
+	done bool
+}
+
+func (tx *countingTx) Context() context.Context { return tx.ctx }
+func (tx *countingTx) Commit() error {
+	tx.t.commits++
+	tx.done = true
+	return nil
+}
+func (tx *countingTx) Rollback() error {
+	if !tx.done {
+		tx.t.rollbacks++
+		tx.done = true
+	}
+	return nil
+}
+
+func TestDryRun(t *testing.T) {
+	qdbid := dax.NewQualifiedDatabaseID("org", "db")
+
+	t.Run("NotApplied", func(t *testing.T) {
+		trans := &countingTransactor{}
+		c := New(Config{})
+		c.Transactor = trans
+
+		_, events, unsubscribe, err := c.SubscribeSchemaEvents("")
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		dryRun := NewDryRun()
+		qdb := dax.NewQualifiedDatabase(qdbid.OrganizationID, &dax.Database{Name: "db"})
+		require.NoError(t, c.CreateDatabase(WithDryRun(context.Background(), dryRun), qdb))
+		assert.Equal(t, 0, trans.commits)
+		assert.Equal(t, 1, trans.rollbacks)
+		require.Len(t, dryRun.SchemaEvents, 1)
+		assert.Equal(t, SchemaEventDatabaseCreated, dryRun.SchemaEvents[0].Type)
+		select {
+		case ev := <-events:
+			t.Fatalf("unexpected schema event: %+v", ev)
+		default:
+		}
+
+		// Without a dry run, the same change is committed and published.
+		require.NoError(t, c.CreateDatabase(context.Background(), qdb))
+		assert.Equal(t, 1, trans.commits)
+		select {
+		case ev := <-events:
+			assert.Equal(t, SchemaEventDatabaseCreated, ev.Type)
+		default:
+			t.Fatal("expected a schema event")
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		c := New(Config{})
+		c.Transactor = &countingTransactor{}
+		ctx := WithDryRun(context.Background(), NewDryRun())
+
+		err := c.SnapshotTable(ctx, dax.NewQualifiedTableID(qdbid, "tbl"))
+		assert.True(t, errors.Is(err, ErrCodeDryRunUnsupported), err)
+		_, err = c.IngestShard(ctx, dax.NewQualifiedTableID(qdbid, "tbl"), 0)
+		assert.True(t, errors.Is(err, ErrCodeDryRunUnsupported), err)
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		tkey := dax.NewQualifiedTableID(qdbid, "tbl").Key()
+		other := dax.NewQualifiedTableID(qdbid, "other").Key()
+		s0, s1 := shard(tkey, 0).Job(), shard(tkey, 1).Job()
+		p0 := partition(other, 0).Job()
+
+		d := NewDryRun()
+		d.addDiffs(
+			dax.WorkerDiff{Address: "a", RemovedJobs: []dax.Job{s1, s0}},
+			dax.WorkerDiff{Address: "b", AddedJobs: []dax.Job{s0}},
+			dax.WorkerDiff{Address: "b", AddedJobs: []dax.Job{s1}},
+			dax.WorkerDiff{Address: "c", AddedJobs: []dax.Job{p0}},
+			dax.WorkerDiff{Address: "c", RemovedJobs: []dax.Job{p0}},
+		)
+		d.finish([]*dax.Directive{{Address: "a"}, {Address: "b"}}, nil)
+
+		assert.Equal(t, []DryRunWorker{
+			{Address: "a", AddedJobs: []dax.Job{}, RemovedJobs: []dax.Job{s0, s1}},
+			{Address: "b", AddedJobs: []dax.Job{s0, s1}, RemovedJobs: []dax.Job{}},
+		}, d.Workers)
+		assert.Equal(t, []dax.TableKey{tkey}, d.Tables)
+		assert.Len(t, d.Directives, 2)
+
+		// A retried transaction starts again from nothing.
+		d.reset()
+		d.finish(nil, []SchemaEvent{{Type: SchemaEventTableDropped, Database: qdbid, Table: "other"}})
+		assert.Empty(t, d.Workers)
+		assert.Equal(t, []dax.TableKey{other}, d.Tables)
+	})
+}
@@ -31,6 +31,18 @@ const (
 
 	ErrCodeUnassignedJobs errors.Code = "UnassignedJobs"
 
+	ErrCodeResyncRequired errors.Code = "ResyncRequired"
+
+	ErrCodeShardMigrating         errors.Code = "ShardMigrating"
+	ErrCodeShardMigrationNotFound errors.Code = "ShardMigrationNotFound"
+
+	ErrCodeReReplicationNotFound errors.Code = "ReReplicationNotFound"
+
+	ErrCodeDryRunUnsupported errors.Code = "DryRunUnsupported"
+
+	ErrCodeAutoSnapshotFailed   errors.Code = "AutoSnapshotFailed"
+	ErrCodeAutoSnapshotNotFound errors.Code = "AutoSnapshotNotFound"
+
 	UndefinedErrorMessage string = "undefined message format"
 )
 
@@ -99,3 +111,52 @@ func NewErrUnassignedJobs(jobs []dax.Job) error {
 		fmt.Sprintf("found %d unassigned jobs: %+v", len(jobs), jobs),
 	)
 }
+
+func NewErrResyncRequired(since string, reason string) error {
+	return errors.New(
+		ErrCodeResyncRequired,
+		fmt.Sprintf("cannot resume schema events from '%s' because %s; re-read the schema and subscribe again without a last event id", since, reason),
+	)
+}
+
+func NewErrShardMigrating(qtid dax.QualifiedTableID, shard dax.ShardNum, id string) error {
+	return errors.New(
+		ErrCodeShardMigrating,
+		fmt.Sprintf("shard %d of table '%s' is being migrated (migration '%s'); retry shortly", shard, qtid, id),
+	)
+}
+
+func NewErrShardMigrationNotFound(id string) error {
+	return errors.New(
+		ErrCodeShardMigrationNotFound,
+		fmt.Sprintf("shard migration '%s' not found", id),
+	)
+}
+
+func NewErrReReplicationNotFound() error {
+	return errors.New(
+		ErrCodeReReplicationNotFound,
+		"no re-replication has been started",
+	)
+}
+
+func NewErrDryRunUnsupported(method string) error {
+	return errors.New(
+		ErrCodeDryRunUnsupported,
+		fmt.Sprintf("%s doesn't support dry runs", method),
+	)
+}
+
+func NewErrAutoSnapshotFailed(op string, err error) error {
+	return errors.New(
+		ErrCodeAutoSnapshotFailed,
+		fmt.Sprintf("auto-snapshot before %s failed, so it wasn't done: %v", op, err),
+	)
+}
+
+func NewErrAutoSnapshotNotFound(id string) error {
+	return errors.New(
+		ErrCodeAutoSnapshotNotFound,
+		fmt.Sprintf("auto-snapshot '%s' not found", id),
+	)
+}
@@ -61,7 +61,7 @@ func (m *AddressManager) RemoveAddresses(ctx context.Context, addrs ...dax.Addre
 	request.Header.Add("Content-Type", "application/json")
 	request.Header.Add("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := (&http.Client{Transport: dax.ServiceTransport(nil)}).Do(request)
 	if err != nil {
 		return errors.Wrap(err, "doing deregister node request")
 	}
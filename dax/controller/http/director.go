@@ -46,7 +46,7 @@ func NewDirector(cfg DirectorConfig) *Director {
 		snapshotRequestPath: cfg.SnapshotRequestPath,
 		logger:              logr,
 		client: &http.Client{
-			Transport: &http.Transport{
+			Transport: dax.ServiceTransport(&http.Transport{
 				Proxy: http.ProxyFromEnvironment,
 				DialContext: (&net.Dialer{
 					Timeout:   2 * time.Second,
@@ -57,7 +57,7 @@ func NewDirector(cfg DirectorConfig) *Director {
 				IdleConnTimeout:       90 * time.Second,
 				TLSHandshakeTimeout:   3 * time.Second,
 				ExpectContinueTimeout: 1 * time.Second,
-			},
+			}),
 		},
 	}
 }
@@ -1,8 +1,13 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/controller"
@@ -16,6 +21,7 @@ func Handler(c *controller.Controller) http.Handler {
 	}
 
 	router := mux.NewRouter()
+	router.Use(dryRunMiddleware)
 	router.HandleFunc("/health", server.getHealth).Methods("GET").Name("GetHealth")
 
 	// controller endpoints.
@@ -25,11 +31,13 @@ func Handler(c *controller.Controller) http.Handler {
 	router.HandleFunc("/database-by-name", server.postDatabaseByName).Methods("POST").Name("PostDatabaseByName")
 	router.HandleFunc("/databases", server.postDatabases).Methods("POST").Name("PostDatabases")
 	router.HandleFunc("/database/options", server.patchDatabaseOptions).Methods("PATCH").Name("PatchDatabaseOptions")
+	router.HandleFunc("/view-refresh-databases", server.postViewRefreshDatabases).Methods("POST").Name("PostViewRefreshDatabases")
 
 	router.HandleFunc("/create-table", server.postCreateTable).Methods("POST").Name("PostCreateTable")
 	router.HandleFunc("/drop-table", server.postDropTable).Methods("POST").Name("PostDropTable")
 	router.HandleFunc("/create-field", server.postCreateField).Methods("POST").Name("PostCreateField")
 	router.HandleFunc("/drop-field", server.postDropField).Methods("POST").Name("PostDropField")
+	router.HandleFunc("/schema/events", server.getSchemaEvents).Methods("GET").Name("GetSchemaEvents")
 	router.HandleFunc("/table", server.postTable).Methods("POST").Name("PostTable")
 	router.HandleFunc("/table-id", server.postTableID).Methods("POST").Name("PostTable")
 	router.HandleFunc("/tables", server.postTables).Methods("POST").Name("PostTables")
@@ -37,6 +45,19 @@ func Handler(c *controller.Controller) http.Handler {
 	router.HandleFunc("/ingest-partition", server.postIngestPartition).Methods("POST").Name("PostIngestPartition")
 	router.HandleFunc("/ingest-shard", server.postIngestShard).Methods("POST").Name("PostIngestShard")
 
+	router.HandleFunc("/shard-migrations", server.postShardMigrations).Methods("POST").Name("PostShardMigrations")
+	router.HandleFunc("/shard-migrations", server.getShardMigrations).Methods("GET").Name("GetShardMigrations")
+	router.HandleFunc("/shard-migrations/{id}", server.getShardMigration).Methods("GET").Name("GetShardMigration")
+	router.HandleFunc("/shard-migrations/{id}/abort", server.postAbortShardMigration).Methods("POST").Name("PostAbortShardMigration")
+
+	router.HandleFunc("/under-replicated-shards", server.getUnderReplicatedShards).Methods("GET").Name("GetUnderReplicatedShards")
+	router.HandleFunc("/re-replication", server.postReReplication).Methods("POST").Name("PostReReplication")
+	router.HandleFunc("/re-replication", server.getReReplication).Methods("GET").Name("GetReReplication")
+
+	router.HandleFunc("/auto-snapshots", server.getAutoSnapshots).Methods("GET").Name("GetAutoSnapshots")
+	router.HandleFunc("/auto-snapshots/{id}", server.deleteAutoSnapshot).Methods("DELETE").Name("DeleteAutoSnapshot")
+	router.HandleFunc("/auto-snapshots/{id}/restore", server.postRestoreAutoSnapshot).Methods("POST").Name("PostRestoreAutoSnapshot")
+
 	router.HandleFunc("/snapshot", server.postSnapshot).Methods("POST").Name("PostSnapshot")
 	router.HandleFunc("/snapshot/shard-data", server.postSnapshotShardData).Methods("POST").Name("PostShapshotShardData")
 	router.HandleFunc("/snapshot/table-keys", server.postSnapshotTableKeys).Methods("POST").Name("PostShapshotTableKeys")
@@ -51,6 +72,9 @@ func Handler(c *controller.Controller) http.Handler {
 	router.HandleFunc("/translate-nodes", server.postTranslateNodes).Methods("POST").Name("PostTranslateNodes")
 
 	// debug endpoints
+	router.HandleFunc("/nodes/health", server.getNodesHealth).Methods("GET").Name("GetNodesHealth")
+	router.HandleFunc("/maintenance-window", server.getMaintenanceWindow).Methods("GET").Name("GetMaintenanceWindow")
+
 	router.HandleFunc("/debug/nodes", server.getDebugNodes).Methods("GET").Name("GetDebugNodes")
 	router.HandleFunc("/debug/balancer", server.getDebugBalancer).Methods("GET").Name("getDebugBalancer")
 
@@ -61,6 +85,82 @@ type server struct {
 	controller *controller.Controller
 }
 
+// errorStatus returns the http status code for an error returned by one of the
+// controller's mutating methods. Conflicts which could not be resolved by
+// retrying result in StatusConflict; the error message includes the current
+// table version where applicable so the client can reconcile.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dax.ErrTransactionConflict), errors.Is(err, dax.ErrTableVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, controller.ErrCodeShardMigrating):
+		return http.StatusConflict
+	case errors.Is(err, controller.ErrCodeShardMigrationNotFound), errors.Is(err, controller.ErrCodeReReplicationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, controller.ErrCodeAutoSnapshotNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, controller.ErrCodeAutoSnapshotFailed):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// dryRunMiddleware attaches a controller.DryRun to the context of requests
+// with a dry-run query parameter of true. Handlers for operations which
+// support dry runs respond with the DryRun (see writeDryRun) in place of their
+// usual response; operations which don't support them fail, rather than take
+// effect, because the controller rejects a context carrying a DryRun.
+// Read-only requests ignore it.
+func dryRunMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := r.URL.Query().Get("dry-run")
+		if v == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid dry-run value '%s': %s", v, err), http.StatusBadRequest)
+			return
+		} else if !dryRun {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(controller.WithDryRun(r.Context(), controller.NewDryRun())))
+	})
+}
+
+// writeDryRun writes the DryRun carried by ctx, if any, as the response,
+// returning true if it did.
+func writeDryRun(w http.ResponseWriter, ctx context.Context) bool {
+	dryRun := controller.DryRunFromContext(ctx)
+	if dryRun == nil {
+		return false
+	}
+	if err := json.NewEncoder(w).Encode(dryRun); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return true
+}
+
+// AutoSnapshotResponse is the response to a destructive operation before
+// which an auto-snapshot was taken.
+type AutoSnapshotResponse struct {
+	AutoSnapshot *controller.AutoSnapshot `json:"auto-snapshot"`
+}
+
+// writeAutoSnapshot writes the auto-snapshot taken before an operation, if
+// any, as the response.
+func writeAutoSnapshot(w http.ResponseWriter, snap *controller.AutoSnapshot) {
+	if snap == nil {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(AutoSnapshotResponse{AutoSnapshot: snap}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // GET /health
 func (s *server) getHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -81,7 +181,11 @@ func (s *server) postCreateDatabase(w http.ResponseWriter, r *http.Request) {
 
 	err := s.controller.CreateDatabase(ctx, req)
 	if err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
 		return
 	}
 
@@ -96,7 +200,8 @@ func (s *server) postDropDatabase(w http.ResponseWriter, r *http.Request) {
 	body := r.Body
 	defer body.Close()
 
-	ctx := r.Context()
+	var snap *controller.AutoSnapshot
+	ctx := controller.WithAutoSnapshotReceiver(r.Context(), &snap)
 
 	req := dax.QualifiedDatabaseID{}
 	if err := json.NewDecoder(body).Decode(&req); err != nil {
@@ -106,9 +211,15 @@ func (s *server) postDropDatabase(w http.ResponseWriter, r *http.Request) {
 
 	err := s.controller.DropDatabase(ctx, req)
 	if err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
 		return
 	}
+
+	writeAutoSnapshot(w, snap)
 }
 
 // POST /database-by-id
@@ -197,6 +308,37 @@ type DatabasesRequest struct {
 	// DatabaseNames     dax.DatabaseNames     `json:"database-names"`
 }
 
+// POST /view-refresh-databases
+func (s *server) postViewRefreshDatabases(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer body.Close()
+
+	ctx := r.Context()
+
+	req := ViewRefreshDatabasesRequest{}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.controller.ViewRefreshDatabases(ctx, req.Holder)
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// ViewRefreshDatabasesRequest identifies the queryer asking for the databases
+// whose materialized views it should refresh.
+type ViewRefreshDatabasesRequest struct {
+	Holder string `json:"holder"`
+}
+
 // handlePatchDatabaseOptions handles updates to database options.
 func (s *server) patchDatabaseOptions(w http.ResponseWriter, r *http.Request) {
 	// Decode request.
@@ -209,7 +351,11 @@ func (s *server) patchDatabaseOptions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.controller.SetDatabaseOption(r.Context(), req.QualifiedDatabaseID, req.Option, req.Value); err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, r.Context()) {
 		return
 	}
 }
@@ -238,7 +384,11 @@ func (s *server) postCreateTable(w http.ResponseWriter, r *http.Request) {
 
 	err := s.controller.CreateTable(ctx, req)
 	if err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
 		return
 	}
 
@@ -303,7 +453,8 @@ func (s *server) postDropTable(w http.ResponseWriter, r *http.Request) {
 	body := r.Body
 	defer body.Close()
 
-	ctx := r.Context()
+	var snap *controller.AutoSnapshot
+	ctx := controller.WithAutoSnapshotReceiver(r.Context(), &snap)
 
 	req := dax.QualifiedTableID{}
 	if err := json.NewDecoder(body).Decode(&req); err != nil {
@@ -313,9 +464,15 @@ func (s *server) postDropTable(w http.ResponseWriter, r *http.Request) {
 
 	err := s.controller.DropTable(ctx, req)
 	if err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
 		return
 	}
+
+	writeAutoSnapshot(w, snap)
 }
 
 // POST /create-field
@@ -333,9 +490,13 @@ func (s *server) postCreateField(w http.ResponseWriter, r *http.Request) {
 
 	qtid := req.TableKey.QualifiedTableID()
 
-	err := s.controller.CreateField(ctx, qtid, req.Field)
+	err := s.controller.CreateFieldAtVersion(ctx, qtid, req.Field, req.Version)
 	if err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
 		return
 	}
 }
@@ -343,6 +504,11 @@ func (s *server) postCreateField(w http.ResponseWriter, r *http.Request) {
 type CreateFieldRequest struct {
 	TableKey dax.TableKey `json:"table-key"`
 	Field    *dax.Field   `json:"field"`
+
+	// Version, if set, is the table version (see dax.Table.Version) the
+	// change was made against. If the table is no longer at that version, the
+	// request fails with StatusConflict.
+	Version string `json:"version,omitempty"`
 }
 
 // POST /drop-field
@@ -350,7 +516,8 @@ func (s *server) postDropField(w http.ResponseWriter, r *http.Request) {
 	body := r.Body
 	defer body.Close()
 
-	ctx := r.Context()
+	var snap *controller.AutoSnapshot
+	ctx := controller.WithAutoSnapshotReceiver(r.Context(), &snap)
 
 	req := DropFieldRequest{}
 	if err := json.NewDecoder(body).Decode(&req); err != nil {
@@ -360,16 +527,25 @@ func (s *server) postDropField(w http.ResponseWriter, r *http.Request) {
 
 	qtid := req.Table
 
-	err := s.controller.DropField(ctx, qtid, req.Field)
+	err := s.controller.DropFieldAtVersion(ctx, qtid, req.Field, req.Version)
 	if err != nil {
-		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
 		return
 	}
+
+	writeAutoSnapshot(w, snap)
 }
 
 type DropFieldRequest struct {
 	Table dax.QualifiedTableID `json:"table"`
 	Field dax.FieldName        `json:"fields"`
+
+	// Version behaves as it does on CreateFieldRequest.
+	Version string `json:"version,omitempty"`
 }
 
 // POST /tables
@@ -463,7 +639,13 @@ func (s *server) postIngestShard(w http.ResponseWriter, r *http.Request) {
 	qtid := req.Table
 
 	addr, err := s.controller.IngestShard(ctx, qtid, req.Shard)
-	if err != nil {
+	if errors.Is(err, controller.ErrCodeShardMigrating) {
+		// Writes to the shard are held off only briefly while it's cut over
+		// to another compute node.
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, errors.MarshalJSON(err), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -487,6 +669,182 @@ type IngestShardResponse struct {
 	Address dax.Address `json:"address"`
 }
 
+// POST /shard-migrations
+//
+// postShardMigrations starts moving a shard to another compute node. The
+// response is the migration's initial status; its progress can be followed
+// with GET /shard-migrations/{id}.
+func (s *server) postShardMigrations(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer body.Close()
+
+	ctx := r.Context()
+
+	req := ShardMigrationRequest{}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := s.controller.MigrateShard(ctx, req.Table, req.Shard, req.Target)
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if writeDryRun(w, ctx) {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+type ShardMigrationRequest struct {
+	Table  dax.QualifiedTableID `json:"table"`
+	Shard  dax.ShardNum         `json:"shard"`
+	Target dax.Address          `json:"target"`
+}
+
+// GET /shard-migrations
+func (s *server) getShardMigrations(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewEncoder(w).Encode(s.controller.ShardMigrations()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /shard-migrations/{id}
+func (s *server) getShardMigration(w http.ResponseWriter, r *http.Request) {
+	m, err := s.controller.ShardMigration(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /shard-migrations/{id}/abort
+//
+// postAbortShardMigration aborts a shard migration which hasn't started
+// cutting over. The migration is stopped asynchronously; its status becomes
+// "aborted" once writes to the shard have resumed on its original compute
+// node.
+func (s *server) postAbortShardMigration(w http.ResponseWriter, r *http.Request) {
+	if controller.DryRunFromContext(r.Context()) != nil {
+		err := controller.NewErrDryRunUnsupported("AbortShardMigration")
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+	if err := s.controller.AbortShardMigration(mux.Vars(r)["id"]); err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+}
+
+// GET /under-replicated-shards
+//
+// getUnderReplicatedShards returns the shards which aren't assigned to a
+// compute node, or whose compute node is down or stale.
+func (s *server) getUnderReplicatedShards(w http.ResponseWriter, r *http.Request) {
+	shards, err := s.controller.UnderReplicatedShards(r.Context())
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), http.StatusInternalServerError)
+		return
+	}
+	if shards == nil {
+		shards = []controller.UnderReplicatedShard{}
+	}
+
+	resp := UnderReplicatedShardsResponse{
+		Count:  len(shards),
+		Shards: shards,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+type UnderReplicatedShardsResponse struct {
+	Count  int                               `json:"count"`
+	Shards []controller.UnderReplicatedShard `json:"shards"`
+}
+
+// POST /re-replication
+//
+// postReReplication starts re-replicating the shards which are currently
+// under-replicated, or returns the status of the re-replication already
+// running. Its progress can be followed with GET /re-replication.
+func (s *server) postReReplication(w http.ResponseWriter, r *http.Request) {
+	rr, err := s.controller.ReReplicate(r.Context())
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rr); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /re-replication
+func (s *server) getReReplication(w http.ResponseWriter, r *http.Request) {
+	rr, err := s.controller.ReReplication()
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(rr); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /auto-snapshots
+//
+// getAutoSnapshots lists the auto-snapshots taken before destructive
+// operations, oldest first.
+func (s *server) getAutoSnapshots(w http.ResponseWriter, r *http.Request) {
+	snaps, err := s.controller.AutoSnapshots(r.Context())
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(snaps); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// DELETE /auto-snapshots/{id}
+func (s *server) deleteAutoSnapshot(w http.ResponseWriter, r *http.Request) {
+	if err := s.controller.DeleteAutoSnapshot(r.Context(), mux.Vars(r)["id"]); err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+}
+
+// POST /auto-snapshots/{id}/restore
+//
+// postRestoreAutoSnapshot re-creates the tables in an auto-snapshot; see
+// Controller.RestoreAutoSnapshot.
+func (s *server) postRestoreAutoSnapshot(w http.ResponseWriter, r *http.Request) {
+	if err := s.controller.RestoreAutoSnapshot(r.Context(), mux.Vars(r)["id"]); err != nil {
+		http.Error(w, errors.MarshalJSON(err), errorStatus(err))
+		return
+	}
+}
+
 // POST /snapshot
 // High level snapshot endpoint to snapshot everything in a table.
 func (s *server) postSnapshot(w http.ResponseWriter, r *http.Request) {
@@ -523,7 +881,7 @@ func (s *server) postSnapshotShardData(w http.ResponseWriter, r *http.Request) {
 
 	qtid := req.Table
 
-	if err := s.controller.SnapshotShardData(ctx, qtid, req.Shard); err != nil {
+	if err := s.controller.SnapshotShardData(ctx, qtid, req.Shard, req.Priority); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -531,10 +889,12 @@ func (s *server) postSnapshotShardData(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// SnapshotShardRequest is used to specify the table/shard to snapshot.
+// SnapshotShardRequest is used to specify the table/shard to snapshot. If
+// Priority is empty, the snapshot is urgent.
 type SnapshotShardRequest struct {
-	Table dax.QualifiedTableID `json:"table"`
-	Shard dax.ShardNum         `json:"shard"`
+	Table    dax.QualifiedTableID `json:"table"`
+	Shard    dax.ShardNum         `json:"shard"`
+	Priority dax.SnapshotPriority `json:"priority,omitempty"`
 }
 
 // POST /snapshot/table-keys
@@ -635,6 +995,10 @@ func (s *server) postRegisterNodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if writeDryRun(w, ctx) {
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -660,6 +1024,10 @@ func (s *server) postDeregisterNodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if writeDryRun(w, ctx) {
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -719,6 +1087,38 @@ func (s *server) postComputeNodes(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GET /nodes/health?stale-after=<duration>
+// getNodesHealth returns the health of every compute node, as last probed by
+// the controller.
+func (s *server) getNodesHealth(w http.ResponseWriter, r *http.Request) {
+	var staleAfter time.Duration
+	if v := r.URL.Query().Get("stale-after"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "stale-after must be a positive duration", http.StatusBadRequest)
+			return
+		}
+		staleAfter = d
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.controller.NodeHealth(staleAfter)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /maintenance-window
+// getMaintenanceWindow reports whether the controller is within a maintenance
+// window, and so whether background work may run.
+func (s *server) getMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.controller.MaintenanceState()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 func (s *server) getDebugNodes(w http.ResponseWriter, r *http.Request) {
 	nodes, err := s.controller.DebugNodes(r.Context())
 	if err != nil {
@@ -732,6 +1132,87 @@ func (s *server) getDebugNodes(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// GET /schema/events
+// getSchemaEvents streams committed schema changes as server-sent events. A
+// client resumes after the last event it received by passing that event's id
+// in the Last-Event-ID header (as EventSource does on reconnect) or in the
+// `since` query parameter; the events it missed are replayed before new events
+// are sent. If the missed events are no longer retained, the response is a 409
+// with an ErrCodeResyncRequired error, and the client must re-read the schema
+// and reconnect without a last event id.
+func (s *server) getSchemaEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	since := r.Header.Get("Last-Event-ID")
+	if since == "" {
+		since = r.URL.Query().Get("since")
+	}
+
+	replay, events, cancel, err := s.controller.SubscribeSchemaEvents(since)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, controller.ErrCodeResyncRequired) {
+			status = http.StatusConflict
+		}
+		http.Error(w, errors.MarshalJSON(err), status)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range replay {
+		if err := writeSchemaEvent(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(schemaEventKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				// The client fell behind; it will reconnect from the last
+				// event it received.
+				return
+			}
+			if err := writeSchemaEvent(w, ev); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// schemaEventKeepalive is the interval at which a comment is sent on an idle
+// schema event stream, so proxies don't close the connection.
+const schemaEventKeepalive = 15 * time.Second
+
+// writeSchemaEvent writes ev in the server-sent events format.
+func writeSchemaEvent(w io.Writer, ev controller.SchemaEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
+
 func (s *server) getDebugBalancer(w http.ResponseWriter, r *http.Request) {
 	nodes, err := s.controller.CurrentState(r.Context())
 	if err != nil {
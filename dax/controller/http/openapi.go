@@ -0,0 +1,86 @@
+package http
+
+import (
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/poller"
+)
+
+// OpenAPIAnnotations describes the request and response bodies of the
+// controller's routes, keyed by route name. Routes which are only used
+// internally between services are not annotated.
+func OpenAPIAnnotations() map[string]dax.OpenAPIAnnotation {
+	return map[string]dax.OpenAPIAnnotation{
+		"GetNodesHealth": {
+			Summary:  "Get the health of every compute node, from the controller's most recent probes. Nodes not probed within ?stale-after (a duration) are marked stale.",
+			Response: poller.ClusterHealth{},
+		},
+		"GetMaintenanceWindow": {
+			Summary:  "Report whether the controller is within a maintenance window, during which background snapshots may run.",
+			Response: controller.MaintenanceState{},
+		},
+		"PostCreateDatabase": {
+			Summary:  "Create a database. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request:  dax.QualifiedDatabase{},
+			Response: dax.QualifiedDatabase{},
+		},
+		"PostDropDatabase": {
+			Summary:  "Drop a database. If an auto-snapshot was taken first, responds with it. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request:  dax.QualifiedDatabaseID{},
+			Response: AutoSnapshotResponse{},
+		},
+		"PostDatabaseByID": {
+			Summary:  "Get a database by id.",
+			Request:  dax.QualifiedDatabaseID{},
+			Response: dax.QualifiedDatabase{},
+		},
+		"PostDatabaseByName": {
+			Summary:  "Get a database by name.",
+			Request:  DatabaseByNameRequest{},
+			Response: dax.QualifiedDatabase{},
+		},
+		"PostDatabases": {
+			Summary:  "List databases.",
+			Request:  DatabasesRequest{},
+			Response: []*dax.QualifiedDatabase{},
+		},
+		"PatchDatabaseOptions": {
+			Summary: "Set a database option. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request: DatabaseOptionRequest{},
+		},
+		"PostCreateTable": {
+			Summary:  "Create a table. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request:  dax.QualifiedTable{},
+			Response: dax.QualifiedTable{},
+		},
+		"PostDropTable": {
+			Summary:  "Drop a table. If an auto-snapshot was taken first, responds with it. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request:  dax.QualifiedTableID{},
+			Response: AutoSnapshotResponse{},
+		},
+		"PostCreateField": {
+			Summary: "Add a field to a table. If version is set, the table must be at that version. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request: CreateFieldRequest{},
+		},
+		"PostDropField": {
+			Summary:  "Drop a field from a table. If version is set, the table must be at that version. If an auto-snapshot was taken first, responds with it. With ?dry-run=true, responds with the changes which would be made (a controller.DryRun) without making them.",
+			Request:  DropFieldRequest{},
+			Response: AutoSnapshotResponse{},
+		},
+		"PostTables": {
+			Summary:  "List tables.",
+			Request:  TablesRequest{},
+			Response: []*dax.QualifiedTable{},
+		},
+		"GetAutoSnapshots": {
+			Summary:  "List the auto-snapshots taken before destructive operations, oldest first.",
+			Response: []*controller.AutoSnapshot{},
+		},
+		"DeleteAutoSnapshot": {
+			Summary: "Delete an auto-snapshot.",
+		},
+		"PostRestoreAutoSnapshot": {
+			Summary: "Re-create the tables in an auto-snapshot, with their original IDs, from the files it holds. The tables must not exist. The database is re-created if the auto-snapshot was taken before it was dropped.",
+		},
+	}
+}
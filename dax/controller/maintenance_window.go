@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// maintenanceWindow is a period of each of some days of the week, in UTC,
+// during which background work may run.
+type maintenanceWindow struct {
+	days [7]bool // indexed by time.Weekday
+
+	// start and end are offsets from midnight at the start of the day. end
+	// is after start, and may be on the following day.
+	start, end time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseMaintenanceWindow parses a window of the form "<days> <start>-<end>",
+// where days is "*" for every day, or a comma-separated list of day names
+// (sun, mon, tue, wed, thu, fri, sat), and start and end are times of day, in
+// UTC, of the form "HH:MM". A window whose end is before its start closes on
+// the following day.
+func parseMaintenanceWindow(s string) (maintenanceWindow, error) {
+	var w maintenanceWindow
+	invalid := func(reason string) error {
+		return errors.Errorf("invalid maintenance window '%s': %s; expected '<days> <HH:MM>-<HH:MM>', such as 'sat,sun 01:00-05:00'", s, reason)
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return w, invalid("wrong number of fields")
+	}
+
+	if fields[0] == "*" {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	} else {
+		for _, name := range strings.Split(fields[0], ",") {
+			day, ok := weekdays[strings.ToLower(name)]
+			if !ok {
+				return w, invalid("unknown day '" + name + "'")
+			}
+			w.days[day] = true
+		}
+	}
+
+	from, to, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return w, invalid("missing end time")
+	}
+	var err error
+	if w.start, err = parseTimeOfDay(from); err != nil {
+		return w, invalid(err.Error())
+	}
+	if w.end, err = parseTimeOfDay(to); err != nil {
+		return w, invalid(err.Error())
+	}
+	if w.end == w.start {
+		return w, invalid("start and end are the same")
+	} else if w.end < w.start {
+		w.end += 24 * time.Hour
+	}
+	return w, nil
+}
+
+// parseTimeOfDay returns the offset from midnight of a time of the form
+// "HH:MM".
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.Errorf("invalid time of day '%s'", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// occurrences returns the start and end of each occurrence of w which begins
+// on one of the days from first to last, inclusive.
+func (w maintenanceWindow) occurrences(first, last time.Time) [][2]time.Time {
+	var ret [][2]time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if w.days[day.Weekday()] {
+			ret = append(ret, [2]time.Time{day.Add(w.start), day.Add(w.end)})
+		}
+	}
+	return ret
+}
+
+// maintenanceSchedule is the set of maintenance windows during which the
+// controller's background work, such as the snapping turtle's scheduled
+// snapshots, may run. An empty schedule permits background work at any time.
+type maintenanceSchedule []maintenanceWindow
+
+func newMaintenanceSchedule(windows []string) (maintenanceSchedule, error) {
+	sched := make(maintenanceSchedule, 0, len(windows))
+	for _, s := range windows {
+		w, err := parseMaintenanceWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		sched = append(sched, w)
+	}
+	return sched, nil
+}
+
+// open reports whether background work may run at t.
+func (s maintenanceSchedule) open(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for _, w := range s {
+		// An occurrence which began yesterday may still be open.
+		for _, occ := range w.occurrences(midnight.AddDate(0, 0, -1), midnight) {
+			if !t.Before(occ[0]) && t.Before(occ[1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextChange returns the next time after t at which background work becomes
+// permitted, if it isn't at t, or stops being permitted, if it is. It returns
+// false if that never happens, as with an empty schedule, or one whose
+// windows together cover the whole week.
+func (s maintenanceSchedule) nextChange(t time.Time) (time.Time, bool) {
+	if len(s) == 0 {
+		return time.Time{}, false
+	}
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	var edges []time.Time
+	for _, w := range s {
+		for _, occ := range w.occurrences(midnight.AddDate(0, 0, -1), midnight.AddDate(0, 0, 8)) {
+			edges = append(edges, occ[0], occ[1])
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].Before(edges[j]) })
+
+	now := s.open(t)
+	for _, edge := range edges {
+		if edge.After(t) && s.open(edge) != now {
+			return edge, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// MaintenanceState describes whether the controller's background work may
+// currently run.
+type MaintenanceState struct {
+	// Windows are the configured maintenance windows. If there are none,
+	// background work may run at any time.
+	Windows []string `json:"windows"`
+
+	// InWindow is true if the current time is within one of Windows, or if
+	// there are no Windows.
+	InWindow bool `json:"in-window"`
+
+	// BackgroundPermitted is true if background work may start now. It's
+	// false outside the maintenance windows, and while the controller is
+	// draining.
+	BackgroundPermitted bool `json:"background-permitted"`
+
+	// SnapshotsInProgress is true if the snapping turtle is taking a round
+	// of snapshots.
+	SnapshotsInProgress bool `json:"snapshots-in-progress"`
+
+	// NextChange, if set, is when InWindow next changes.
+	NextChange *time.Time `json:"next-change,omitempty"`
+}
+
+// MaintenanceState returns the current state of the controller's
+// maintenance windows.
+//
+// Outside a maintenance window, the snapping turtle doesn't start its
+// scheduled rounds of snapshots. If a window closes while a round is in
+// progress, the round stops: snapshots which have already been requested of
+// compute nodes finish, but no more are requested, and the next round, in the
+// next window, starts again from the beginning. Snapshots requested on demand
+// (by SnapshotTable or SnapshotShardData) are taken at any time, with a
+// warning logged if they're taken outside a window.
+func (c *Controller) MaintenanceState() MaintenanceState {
+	now := time.Now()
+	state := MaintenanceState{
+		Windows:  append([]string{}, c.maintenanceWindows...),
+		InWindow: c.maintenance.open(now),
+	}
+	// snapMu is held throughout a round of snapshots, during which
+	// snapDrained can't be set.
+	drained := false
+	if c.snapMu.TryLock() {
+		drained = c.snapDrained
+		c.snapMu.Unlock()
+	} else {
+		state.SnapshotsInProgress = true
+	}
+	state.BackgroundPermitted = state.InWindow && !drained
+	if next, ok := c.maintenance.nextChange(now); ok {
+		state.NextChange = &next
+	}
+	return state
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceSchedule(t *testing.T) {
+	for _, s := range []string{"", "sat", "sat 01:00", "sat 01:00-", "someday 01:00-02:00", "* 1am-2am", "* 25:00-02:00", "* 01:00-01:00"} {
+		_, err := newMaintenanceSchedule([]string{s})
+		assert.Error(t, err, s)
+	}
+
+	// 2026-10-10 is a Saturday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, time.UTC)
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		sched, err := newMaintenanceSchedule(nil)
+		require.NoError(t, err)
+		assert.True(t, sched.open(at(12, 12, 0)))
+		_, ok := sched.nextChange(at(12, 12, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("Windows", func(t *testing.T) {
+		sched, err := newMaintenanceSchedule([]string{"Sat,sun 01:00-05:00", "wed 22:00-02:00"})
+		require.NoError(t, err)
+
+		for _, tc := range []struct {
+			t    time.Time
+			open bool
+			next time.Time
+		}{
+			{at(10, 0, 59), false, at(10, 1, 0)},
+			{at(10, 1, 0), true, at(10, 5, 0)},
+			{at(11, 4, 59), true, at(11, 5, 0)},
+			{at(11, 5, 0), false, at(14, 22, 0)},
+			// Wednesday's window closes on Thursday.
+			{at(15, 1, 0), true, at(15, 2, 0)},
+			{at(15, 2, 0), false, at(17, 1, 0)},
+			// Times in other zones are compared in UTC.
+			{at(10, 2, 0).In(time.FixedZone("PST", -8*60*60)), true, at(10, 5, 0)},
+		} {
+			assert.Equal(t, tc.open, sched.open(tc.t), tc.t)
+			next, ok := sched.nextChange(tc.t)
+			if assert.True(t, ok, tc.t) {
+				assert.Equal(t, tc.next, next, tc.t)
+			}
+		}
+	})
+
+	t.Run("WholeWeek", func(t *testing.T) {
+		sched, err := newMaintenanceSchedule([]string{"* 00:00-12:00", "* 12:00-00:00"})
+		require.NoError(t, err)
+		assert.True(t, sched.open(at(12, 12, 0)))
+		_, ok := sched.nextChange(at(12, 12, 0))
+		assert.False(t, ok)
+	})
+
+	t.Run("State", func(t *testing.T) {
+		c := New(Config{MaintenanceWindows: []string{"* 00:00-00:01"}})
+		sched, err := newMaintenanceSchedule(c.maintenanceWindows)
+		require.NoError(t, err)
+		c.maintenance = sched
+
+		state := c.MaintenanceState()
+		assert.Equal(t, []string{"* 00:00-00:01"}, state.Windows)
+		assert.Equal(t, state.InWindow, state.BackgroundPermitted)
+		require.NotNil(t, state.NextChange)
+
+		c.snapDrained = true
+		assert.False(t, c.MaintenanceState().BackgroundPermitted)
+	})
+}
@@ -0,0 +1,184 @@
+package poller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// Node health statuses.
+const (
+	// NodeHealthy is the status of a node whose last probe succeeded.
+	NodeHealthy = "healthy"
+
+	// NodeDegraded is the status of a node whose last probe succeeded, but
+	// which reported some of its components as degraded.
+	NodeDegraded = "degraded"
+
+	// NodeDown is the status of a node whose last probe failed. The poller
+	// deregisters such nodes, but keeps reporting them for HealthRetention so
+	// that monitoring sees the failure.
+	NodeDown = "down"
+
+	// NodeStale is the status of a node which hasn't been probed within the
+	// staleness threshold, so its last known status can't be relied upon.
+	NodeStale = "stale"
+)
+
+// HealthRetention is the length of time for which a node which isn't being
+// polled continues to be reported by Health: either because it failed its
+// probe and was deregistered, or because it has checked in but hasn't been
+// registered yet.
+const HealthRetention = 5 * time.Minute
+
+// ProbeResult is the result of probing a node.
+type ProbeResult struct {
+	Up bool
+
+	// Degraded lists the components which the node reported as degraded in
+	// its health response, if any.
+	Degraded []string
+
+	// Err is the reason the probe failed, if it did.
+	Err error
+}
+
+// NodeProber is implemented by a NodePoller which can report, in addition to
+// whether a node is up, the details of its health. If the Poller's NodePoller
+// doesn't implement NodeProber, only NodePoller.Poll is used.
+type NodeProber interface {
+	Probe(dax.Address) ProbeResult
+}
+
+// NodeHealth is the poller's view of the health of a single node.
+type NodeHealth struct {
+	Address dax.Address `json:"address"`
+	Status  string      `json:"status"`
+
+	// Stale is set if the node hasn't been probed within the staleness
+	// threshold; Status is then NodeStale, and LastStatus is the status of
+	// the last probe.
+	Stale      bool   `json:"stale,omitempty"`
+	LastStatus string `json:"last-status,omitempty"`
+
+	LastProbe   time.Time     `json:"last-probe"`
+	LastHealthy *time.Time    `json:"last-healthy,omitempty"`
+	LastCheckIn *time.Time    `json:"last-check-in,omitempty"`
+	Latency     time.Duration `json:"latency"`
+
+	// ConsecutiveFailures is the number of probes in a row which have failed.
+	ConsecutiveFailures int `json:"consecutive-failures,omitempty"`
+
+	Degraded []string `json:"degraded,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ClusterHealth is the health of every node known to the poller.
+type ClusterHealth struct {
+	Time       time.Time     `json:"time"`
+	StaleAfter time.Duration `json:"stale-after"`
+
+	// Counts holds the number of nodes with each status.
+	Counts map[string]int `json:"counts"`
+
+	Nodes []NodeHealth `json:"nodes"`
+}
+
+// recordProbe records the result of probing addr, which took latency, at t.
+// The caller must hold p.mu.
+func (p *Poller) recordProbe(addr dax.Address, res ProbeResult, t time.Time, latency time.Duration) {
+	h, ok := p.health[addr]
+	if !ok {
+		h = &NodeHealth{Address: addr}
+		p.health[addr] = h
+	}
+	h.LastProbe = t
+	h.Latency = latency
+	h.Degraded = res.Degraded
+	h.Error = ""
+	switch {
+	case !res.Up:
+		h.Status = NodeDown
+		h.ConsecutiveFailures++
+		if res.Err != nil {
+			h.Error = res.Err.Error()
+		}
+	case len(res.Degraded) > 0:
+		h.Status = NodeDegraded
+		h.ConsecutiveFailures = 0
+		h.LastHealthy = &t
+	default:
+		h.Status = NodeHealthy
+		h.ConsecutiveFailures = 0
+		h.LastHealthy = &t
+	}
+}
+
+// pruneHealth forgets nodes which weren't polled in the last sweep, other than
+// those which went down, or checked in without having been probed, within
+// HealthRetention. The caller must hold p.mu.
+func (p *Poller) pruneHealth(polled map[dax.Address]struct{}, now time.Time) {
+	for addr, h := range p.health {
+		if _, ok := polled[addr]; ok {
+			continue
+		}
+		if h.Status == NodeDown && now.Sub(h.LastProbe) < HealthRetention {
+			continue
+		}
+		if h.LastProbe.IsZero() && h.LastCheckIn != nil && now.Sub(*h.LastCheckIn) < HealthRetention {
+			continue
+		}
+		delete(p.health, addr)
+	}
+}
+
+// RecordCheckIn records that the node at addr has checked in.
+func (p *Poller) RecordCheckIn(addr dax.Address) {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, ok := p.health[addr]
+	if !ok {
+		// The node hasn't been probed yet; it's reported as stale until it
+		// is.
+		h = &NodeHealth{Address: addr}
+		p.health[addr] = h
+	}
+	h.LastCheckIn = &now
+}
+
+// Health returns the health of every node known to the poller, from the
+// results of its most recent probes; it doesn't probe any node itself. A node
+// which hasn't been probed within staleAfter is reported as NodeStale. If
+// staleAfter is 0, it's three times the poll interval.
+func (p *Poller) Health(staleAfter time.Duration) ClusterHealth {
+	if staleAfter <= 0 {
+		staleAfter = 3 * p.pollInterval
+	}
+	now := time.Now()
+
+	ch := ClusterHealth{
+		Time:       now,
+		StaleAfter: staleAfter,
+		Counts:     make(map[string]int),
+	}
+
+	p.mu.RLock()
+	ch.Nodes = make([]NodeHealth, 0, len(p.health))
+	for _, h := range p.health {
+		nh := *h
+		nh.Degraded = append([]string(nil), h.Degraded...)
+		if nh.Status != NodeDown && now.Sub(nh.LastProbe) > staleAfter {
+			nh.Stale = true
+			nh.LastStatus = nh.Status
+			nh.Status = NodeStale
+		}
+		ch.Counts[nh.Status]++
+		ch.Nodes = append(ch.Nodes, nh)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(ch.Nodes, func(i, j int) bool { return ch.Nodes[i].Address < ch.Nodes[j].Address })
+	return ch
+}
@@ -0,0 +1,95 @@
+package poller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/poller"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProber reports the configured result for each address.
+type fakeProber struct {
+	mu      sync.Mutex
+	results map[dax.Address]poller.ProbeResult
+}
+
+func (f *fakeProber) Poll(addr dax.Address) bool {
+	return f.Probe(addr).Up
+}
+
+func (f *fakeProber) Probe(addr dax.Address) poller.ProbeResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.results[addr]
+}
+
+func (f *fakeProber) set(addr dax.Address, res poller.ProbeResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[addr] = res
+}
+
+func TestPollerHealth(t *testing.T) {
+	ctx := context.Background()
+	workerRegistry := newMemWorkerRegistry()
+	for _, addr := range []dax.Address{"a", "b"} {
+		require.NoError(t, workerRegistry.AddWorker(ctx, addr, &dax.Node{Address: addr}))
+	}
+
+	prober := &fakeProber{results: map[dax.Address]poller.ProbeResult{
+		"a": {Up: true},
+		"b": {Up: true, Degraded: []string{"storage"}},
+	}}
+	p := poller.New(poller.Config{
+		WorkerRegistry: workerRegistry,
+		NodePoller:     prober,
+		PollInterval:   10 * time.Millisecond,
+	})
+	go p.Run()
+	defer p.Stop()
+
+	// statuses waits for the next poll, and returns the status of each node.
+	statuses := func(staleAfter time.Duration) (poller.ClusterHealth, map[dax.Address]poller.NodeHealth) {
+		time.Sleep(50 * time.Millisecond)
+		ch := p.Health(staleAfter)
+		m := make(map[dax.Address]poller.NodeHealth)
+		for _, n := range ch.Nodes {
+			m[n.Address] = n
+		}
+		return ch, m
+	}
+
+	ch, nodes := statuses(0)
+	assert.Equal(t, 30*time.Millisecond, ch.StaleAfter)
+	assert.Equal(t, map[string]int{poller.NodeHealthy: 1, poller.NodeDegraded: 1}, ch.Counts)
+	assert.Equal(t, poller.NodeHealthy, nodes["a"].Status)
+	assert.False(t, nodes["a"].LastProbe.IsZero())
+	assert.NotNil(t, nodes["a"].LastHealthy)
+	assert.Equal(t, poller.NodeDegraded, nodes["b"].Status)
+	assert.Equal(t, []string{"storage"}, nodes["b"].Degraded)
+
+	prober.set("b", poller.ProbeResult{Err: errors.New(errors.ErrUncoded, "connection refused")})
+	_, nodes = statuses(0)
+	assert.Equal(t, poller.NodeDown, nodes["b"].Status)
+	assert.Equal(t, "connection refused", nodes["b"].Error)
+	assert.GreaterOrEqual(t, nodes["b"].ConsecutiveFailures, 1)
+	assert.NotNil(t, nodes["b"].LastHealthy)
+
+	// A node which has checked in but not yet been probed is stale, as is
+	// every node probed longer ago than the threshold; down nodes remain down.
+	p.RecordCheckIn("c")
+	ch, nodes = statuses(time.Nanosecond)
+	assert.Equal(t, poller.NodeStale, nodes["a"].Status)
+	assert.True(t, nodes["a"].Stale)
+	assert.Equal(t, poller.NodeHealthy, nodes["a"].LastStatus)
+	assert.Equal(t, poller.NodeDown, nodes["b"].Status)
+	assert.Equal(t, poller.NodeStale, nodes["c"].Status)
+	assert.NotNil(t, nodes["c"].LastCheckIn)
+	assert.Equal(t, 1, ch.Counts[poller.NodeDown])
+}
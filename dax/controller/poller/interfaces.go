@@ -1,11 +1,15 @@
 package poller
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
 )
 
@@ -18,6 +22,7 @@ type NodePoller interface {
 // Ensure type implements interface.
 var _ NodePoller = (*NopNodePoller)(nil)
 var _ NodePoller = (*HTTPNodePoller)(nil)
+var _ NodeProber = (*HTTPNodePoller)(nil)
 
 // NopNodePoller is a no-op implementation of the NodePoller interface.
 type NopNodePoller struct{}
@@ -40,20 +45,47 @@ func NewHTTPNodePoller(logger logger.Logger) *HTTPNodePoller {
 	return &HTTPNodePoller{
 		logger: logger,
 		client: &http.Client{
-			Timeout: time.Second, // short timeout for polling to detect issues quickly. /health endpoints should always respond fast.
+			Transport: dax.ServiceTransport(nil),
+			Timeout:   time.Second, // short timeout for polling to detect issues quickly. /health endpoints should always respond fast.
 		},
 	}
 }
 
 func (p *HTTPNodePoller) Poll(addr dax.Address) bool {
+	return p.Probe(addr).Up
+}
+
+// maxHealthResponseSize is the most of a health response which is read.
+const maxHealthResponseSize = 64 << 10
+
+// healthResponse is the optional JSON body of a node's health response.
+type healthResponse struct {
+	Degraded []string `json:"degraded"`
+}
+
+// Probe requests the node's /health endpoint. The node is up if it responds
+// with a 200. If the response is a JSON object with a "degraded" list of
+// component names, those are reported as degraded.
+func (p *HTTPNodePoller) Probe(addr dax.Address) ProbeResult {
 	url := fmt.Sprintf("%s/health", addr.WithScheme("http"))
 
-	if resp, err := p.client.Get(url); err != nil {
+	resp, err := p.client.Get(url)
+	if err != nil {
 		p.logger.Printf("poll error: %s\n", err)
-		return false
-	} else if resp.StatusCode != http.StatusOK {
-		return false
+		return ProbeResult{Err: err}
 	}
+	defer resp.Body.Close()
 
-	return true
+	if resp.StatusCode != http.StatusOK {
+		return ProbeResult{Err: errors.Errorf("health check returned %s", resp.Status)}
+	}
+
+	res := ProbeResult{Up: true}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var hr healthResponse
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxHealthResponseSize)).Decode(&hr); err == nil {
+			res.Degraded = hr.Degraded
+		}
+	}
+	return res
 }
@@ -21,6 +21,10 @@ type Poller struct {
 	nodePoller   NodePoller
 	pollInterval time.Duration
 
+	// health holds the result of the most recent probe of each node. It's
+	// protected by mu.
+	health map[dax.Address]*NodeHealth
+
 	stopping chan struct{}
 
 	logger logger.Logger
@@ -33,6 +37,7 @@ func New(cfg Config) *Poller {
 		workerRegistry: dax.NewNopWorkerRegistry(),
 		nodePoller:     NewNopNodePoller(),
 		pollInterval:   time.Second,
+		health:         make(map[dax.Address]*NodeHealth),
 		logger:         logger.NopLogger,
 	}
 
@@ -109,14 +114,27 @@ func (p *Poller) pollAll() {
 
 	toRemove := []dax.Address{}
 
+	polled := make(map[dax.Address]struct{}, len(addrs))
 	for _, addr := range addrs {
-		up := p.nodePoller.Poll(addr)
-		if !up {
+		start := time.Now()
+		res := p.probe(addr)
+		latency := time.Since(start)
+
+		p.mu.Lock()
+		p.recordProbe(addr, res, start, latency)
+		p.mu.Unlock()
+		polled[addr] = struct{}{}
+
+		if !res.Up {
 			p.logger.Printf("poller removing %s", addr)
 			toRemove = append(toRemove, addr)
 		}
 	}
 
+	p.mu.Lock()
+	p.pruneHealth(polled, time.Now())
+	p.mu.Unlock()
+
 	if len(toRemove) > 0 {
 		p.logger.Debugf("POLLER: removing addresses: %v", toRemove)
 		start := time.Now()
@@ -128,3 +146,11 @@ func (p *Poller) pollAll() {
 	}
 
 }
+
+// probe probes addr with the NodePoller, using its Probe method if it has one.
+func (p *Poller) probe(addr dax.Address) ProbeResult {
+	if prober, ok := p.nodePoller.(NodeProber); ok {
+		return prober.Probe(addr)
+	}
+	return ProbeResult{Up: p.nodePoller.Poll(addr)}
+}
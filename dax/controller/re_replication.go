@@ -0,0 +1,501 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/poller"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+const (
+	// DefaultReReplicationBatchSize is the default number of under-replicated
+	// shards a forced re-replication reassigns at once.
+	DefaultReReplicationBatchSize = 16
+
+	// DefaultReReplicationInterval is the default length of time a forced
+	// re-replication waits between batches.
+	DefaultReReplicationInterval = 5 * time.Second
+)
+
+// The reasons a shard can be under-replicated.
+const (
+	// UnderReplicatedUnassigned is the reason given for a shard which isn't
+	// assigned to any compute node, for example because the node it was on was
+	// removed when no other node was available to take it.
+	UnderReplicatedUnassigned = "unassigned"
+
+	// UnderReplicatedWorkerDown is the reason given for a shard assigned to a
+	// compute node whose last probe failed, or which hasn't been probed
+	// recently. The controller removes such a node once the poller gives up
+	// on it, at which point its shards are reassigned.
+	UnderReplicatedWorkerDown = "worker-down"
+)
+
+// UnderReplicatedShard is a shard which isn't being served by a live compute
+// node.
+type UnderReplicatedShard struct {
+	Table  dax.QualifiedTableID `json:"table"`
+	Shard  dax.ShardNum         `json:"shard"`
+	Worker dax.Address          `json:"worker,omitempty"`
+	Reason string               `json:"reason"`
+}
+
+// The phases of a ReReplication.
+const (
+	ReReplicationRunning = "running"
+	ReReplicationDone    = "done"
+	ReReplicationFailed  = "failed"
+)
+
+// ReReplication describes the progress of a forced re-replication of the
+// shards which are under-replicated. Shards are reassigned in batches of
+// Config.ReReplicationBatchSize, with Config.ReReplicationInterval between
+// batches; the under-replicated shards are found afresh before each batch, so
+// Remaining accounts for shards which become, or stop being, under-replicated
+// while it runs.
+type ReReplication struct {
+	ID        string `json:"id"`
+	Phase     string `json:"phase"`
+	Total     int    `json:"total"`
+	Done      int    `json:"done"`
+	Remaining int    `json:"remaining"`
+	Batches   int    `json:"batches"`
+	Error     string `json:"error,omitempty"`
+
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+}
+
+// Finished returns true if the re-replication is no longer running.
+func (r ReReplication) Finished() bool {
+	return r.Phase == ReReplicationDone || r.Phase == ReReplicationFailed
+}
+
+// reReplications tracks the most recent re-replication. Only one runs at a
+// time.
+type reReplications struct {
+	mu sync.Mutex
+
+	seq    uint64
+	latest *ReReplication
+
+	now func() time.Time
+}
+
+func newReReplications() *reReplications {
+	return &reReplications{
+		now: time.Now,
+	}
+}
+
+// start registers a new re-replication of total shards. If one is already
+// running, its status is returned instead, with false.
+func (r *reReplications) start(total int) (ReReplication, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.latest != nil && !r.latest.Finished() {
+		return *r.latest, false
+	}
+
+	r.seq++
+	now := r.now().UTC()
+	r.latest = &ReReplication{
+		ID:        fmt.Sprintf("%d", r.seq),
+		Phase:     ReReplicationRunning,
+		Total:     total,
+		Remaining: total,
+		Started:   now,
+		Updated:   now,
+	}
+	return *r.latest, true
+}
+
+// progress records a batch in which done shards were reassigned, leaving
+// remaining.
+func (r *reReplications) progress(done, remaining int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latest.Batches++
+	r.latest.Done += done
+	r.latest.Remaining = remaining
+	r.latest.Updated = r.now().UTC()
+}
+
+// finish moves the running re-replication to a final phase. If remaining is
+// negative, the count of remaining shards is left as it was.
+func (r *reReplications) finish(remaining int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latest.Phase = ReReplicationDone
+	if err != nil {
+		r.latest.Phase = ReReplicationFailed
+		r.latest.Error = err.Error()
+	}
+	if remaining >= 0 {
+		r.latest.Remaining = remaining
+	}
+	r.latest.Updated = r.now().UTC()
+}
+
+func (r *reReplications) get() (ReReplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.latest == nil {
+		return ReReplication{}, NewErrReReplicationNotFound()
+	}
+	return *r.latest, nil
+}
+
+// UnderReplicatedShards returns the shards, in every database, which aren't
+// assigned to a compute node or whose compute node is down or stale (see
+// NodeHealth), ordered by table and shard. It also refreshes the
+// under-replicated shard metric.
+func (c *Controller) UnderReplicatedShards(ctx context.Context) ([]UnderReplicatedShard, error) {
+	tx, err := c.Transactor.BeginTx(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "beginning tx")
+	}
+	defer tx.Rollback()
+
+	shards, err := c.underReplicatedShards(tx, downWorkers(c.NodeHealth(0)))
+	if err != nil {
+		return nil, err
+	}
+	dax.GaugeUnderReplicatedShards.Set(float64(len(shards)))
+	return shards, nil
+}
+
+// downWorkers returns the compute nodes which ch reports as down or stale.
+func downWorkers(ch poller.ClusterHealth) AddressSet {
+	down := NewAddressSet()
+	for _, nh := range ch.Nodes {
+		if nh.Status == poller.NodeDown || nh.Status == poller.NodeStale {
+			down.Add(nh.Address)
+		}
+	}
+	return down
+}
+
+// underReplicatedShards returns the shards which are free, or assigned to one
+// of the down workers.
+func (c *Controller) underReplicatedShards(tx dax.Transaction, down AddressSet) ([]UnderReplicatedShard, error) {
+	qdbs, err := c.Schemar.Databases(tx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting databases")
+	}
+
+	var shards []UnderReplicatedShard
+	add := func(job dax.Job, worker dax.Address, reason string) error {
+		s, err := decodeShard(job)
+		if err != nil {
+			return errors.Wrapf(err, "decoding shard job: %s", job)
+		}
+		shards = append(shards, UnderReplicatedShard{
+			Table:  s.table().QualifiedTableID(),
+			Shard:  s.shardNum(),
+			Worker: worker,
+			Reason: reason,
+		})
+		return nil
+	}
+
+	for _, qdb := range qdbs {
+		qdbid := qdb.QualifiedID()
+
+		free, err := c.Balancer.FreeJobs(tx, dax.RoleTypeCompute, qdbid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting free jobs: %s", qdbid)
+		}
+		for _, job := range free {
+			if err := add(job, "", UnderReplicatedUnassigned); err != nil {
+				return nil, err
+			}
+		}
+
+		state, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting current state: %s", qdbid)
+		}
+		for _, w := range state {
+			if !down.Contains(w.Address) {
+				continue
+			}
+			for _, job := range w.Jobs {
+				if err := add(job, w.Address, UnderReplicatedWorkerDown); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		if shards[i].Table.Key() != shards[j].Table.Key() {
+			return shards[i].Table.Key() < shards[j].Table.Key()
+		}
+		return shards[i].Shard < shards[j].Shard
+	})
+	return shards, nil
+}
+
+// ReReplicate starts reassigning the shards which are currently
+// under-replicated to live compute nodes, rather than waiting for the poller
+// to remove the nodes which are down. Unassigned shards are assigned to the
+// database's compute nodes, and shards on a node which is down or stale are
+// moved to the live node with the fewest shards. It returns once the
+// re-replication has been started, or the status of the one already running;
+// its progress can be followed with ReReplication.
+//
+// Shards are reassigned in throttled batches; see
+// Config.ReReplicationBatchSize and Config.ReReplicationInterval. Shards
+// which are being migrated are left to their migration; while only those
+// remain, the re-replication waits for their migrations to finish. The
+// re-replication fails if a batch can't reassign any shards, for example
+// because no live compute node is available.
+func (c *Controller) ReReplicate(ctx context.Context) (ReReplication, error) {
+	if err := rejectDryRun(ctx, "ReReplicate"); err != nil {
+		return ReReplication{}, err
+	}
+
+	shards, err := c.UnderReplicatedShards(ctx)
+	if err != nil {
+		return ReReplication{}, errors.Wrap(err, "getting under-replicated shards")
+	}
+
+	r, ok := c.reReplications.start(len(shards))
+	if !ok {
+		return r, nil
+	}
+
+	c.logger.Printf("re-replicating %d under-replicated shards (re-replication %s)", len(shards), r.ID)
+	c.backgroundGroup.Go(func() error {
+		c.runReReplication(r.ID)
+		return nil
+	})
+
+	return r, nil
+}
+
+// runReReplication reassigns under-replicated shards, a batch at a time, until
+// there are none left, recording its progress in c.reReplications.
+func (c *Controller) runReReplication(id string) {
+	ctx := context.Background()
+	for {
+		shards, err := c.UnderReplicatedShards(ctx)
+		if err != nil {
+			c.failReReplication(id, -1, errors.Wrap(err, "getting under-replicated shards"))
+			return
+		}
+		if len(shards) == 0 {
+			c.logger.Printf("re-replication %s done", id)
+			c.reReplications.finish(0, nil)
+			return
+		}
+
+		// Batches only hold shards which can be reassigned; if the rest are
+		// all being migrated, wait for their migrations rather than trying,
+		// and failing, to reassign them.
+		remaining := len(shards)
+		if batch := c.notMigrating(shards); len(batch) > 0 {
+			if len(batch) > c.reReplicationBatchSize {
+				batch = batch[:c.reReplicationBatchSize]
+			}
+			n, err := c.reReplicateShards(ctx, batch, downWorkers(c.NodeHealth(0)))
+			if err != nil {
+				c.failReReplication(id, remaining, errors.Wrap(err, "reassigning shards"))
+				return
+			} else if n == 0 {
+				c.failReReplication(id, remaining, NewErrNoAvailableNode())
+				return
+			}
+			remaining -= n
+			c.reReplications.progress(n, remaining)
+
+			// Give the compute nodes time to load the shards they've been
+			// given before giving them more.
+			if len(shards) <= len(batch) {
+				continue
+			}
+		}
+		select {
+		case <-c.stopping:
+			c.failReReplication(id, remaining, NewErrInternal("controller stopped"))
+			return
+		case <-time.After(c.reReplicationInterval):
+		}
+	}
+}
+
+// notMigrating returns the shards which aren't being migrated.
+func (c *Controller) notMigrating(shards []UnderReplicatedShard) []UnderReplicatedShard {
+	var out []UnderReplicatedShard
+	for _, s := range shards {
+		if _, ok := c.shardMigrations.migrating(s.Table.Key(), s.Shard); !ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (c *Controller) failReReplication(id string, remaining int, err error) {
+	c.logger.Printf("re-replication %s failed: %v", id, err)
+	c.reReplications.finish(remaining, err)
+}
+
+// reReplicateShards reassigns the given under-replicated shards to live
+// compute nodes and sends those nodes their new directives, returning the
+// number of shards reassigned. Shards which are being migrated are left to
+// their migration.
+func (c *Controller) reReplicateShards(ctx context.Context, shards []UnderReplicatedShard, down AddressSet) (int, error) {
+	byDB := make(map[dax.QualifiedDatabaseID][]UnderReplicatedShard)
+	var qdbids []dax.QualifiedDatabaseID
+	for _, s := range shards {
+		if _, ok := c.shardMigrations.migrating(s.Table.Key(), s.Shard); ok {
+			continue
+		}
+		qdbid := s.Table.QualifiedDatabaseID
+		if _, ok := byDB[qdbid]; !ok {
+			qdbids = append(qdbids, qdbid)
+		}
+		byDB[qdbid] = append(byDB[qdbid], s)
+	}
+
+	var n int
+	var directives []*dax.Directive
+	fn := func(tx dax.Transaction, writable bool) error {
+		n = 0
+		targets := NewAddressSet()
+		for _, qdbid := range qdbids {
+			moved, err := c.reReplicateDatabaseShards(tx, qdbid, byDB[qdbid], down, targets)
+			if err != nil {
+				return errors.Wrapf(err, "re-replicating shards: %s", qdbid)
+			}
+			n += moved
+		}
+
+		// The nodes which are down aren't sent directives; they'll be sent a
+		// reset directive if they come back.
+		var addrs []dax.Address
+		for _, addr := range targets.SortedSlice() {
+			if !down.Contains(addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+
+		var err error
+		directives, err = c.buildDirectives(ctx, tx, applyAddressMethod(addrs, dax.DirectiveMethodFull))
+		if err != nil {
+			return errors.Wrap(err, "building directives")
+		}
+		return nil
+	}
+	if err := c.writeTx(ctx, fn); err != nil {
+		return 0, errors.Wrap(err, "retry with tx: write")
+	}
+
+	if err := c.sendDirectives(ctx, directives); err != nil {
+		return 0, NewErrDirectiveSendFailure(err.Error())
+	}
+	return n, nil
+}
+
+// reReplicateDatabaseShards reassigns under-replicated shards within a single
+// database, adding the workers given shards to targets.
+func (c *Controller) reReplicateDatabaseShards(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, shards []UnderReplicatedShard, down AddressSet, targets AddressSet) (int, error) {
+	var n int
+
+	var unassigned []dax.Job
+	var onDown []dax.Job
+	for _, s := range shards {
+		job := shard(s.Table.Key(), s.Shard).Job()
+		if s.Reason == UnderReplicatedUnassigned {
+			unassigned = append(unassigned, job)
+		} else {
+			onDown = append(onDown, job)
+		}
+	}
+
+	if len(unassigned) > 0 {
+		diffs, err := c.Balancer.AssignFreeJobs(tx, dax.RoleTypeCompute, qdbid, unassigned...)
+		if err != nil {
+			return 0, errors.Wrap(err, "assigning free jobs")
+		}
+		for _, diff := range diffs {
+			targets.Add(dax.Address(diff.Address))
+			n += len(diff.AddedJobs)
+		}
+	}
+
+	if len(onDown) == 0 {
+		return n, nil
+	}
+
+	state, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
+	if err != nil {
+		return 0, errors.Wrapf(err, "getting current state: %s", qdbid)
+	}
+	counts := make(map[dax.Address]int)
+	var live []dax.Address
+	for _, w := range state {
+		if !down.Contains(w.Address) {
+			live = append(live, w.Address)
+			counts[w.Address] = len(w.Jobs)
+		}
+	}
+	if len(live) == 0 {
+		return n, nil
+	}
+
+	for _, job := range onDown {
+		// Move the shard to the live worker with the fewest jobs.
+		target := live[0]
+		for _, addr := range live[1:] {
+			if counts[addr] < counts[target] {
+				target = addr
+			}
+		}
+
+		diffs, err := c.Balancer.MoveJob(tx, dax.RoleTypeCompute, qdbid, job, target)
+		if err != nil {
+			return 0, errors.Wrapf(err, "moving job: %s", job)
+		}
+		if len(diffs) == 0 {
+			continue
+		}
+		counts[target]++
+		targets.Add(target)
+		n++
+	}
+
+	return n, nil
+}
+
+// ReReplication returns the status of the most recent re-replication.
+func (c *Controller) ReReplication() (ReReplication, error) {
+	return c.reReplications.get()
+}
+
+// underReplicationRoutine refreshes the under-replicated shard metric on the
+// given period.
+func (c *Controller) underReplicationRoutine(period time.Duration) error {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopping:
+			return nil
+		case <-ticker.C:
+			if _, err := c.UnderReplicatedShards(context.Background()); err != nil {
+				c.logger.Debugf("counting under-replicated shards: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/schemar"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jobsBalancer is a Balancer holding the compute jobs of a single database in
+// memory.
+type jobsBalancer struct {
+	NopBalancer
+	workers []dax.Address
+	jobs    map[dax.Address][]dax.Job
+	free    []dax.Job
+}
+
+func (b *jobsBalancer) FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.Job, error) {
+	return b.free, nil
+}
+
+func (b *jobsBalancer) CurrentState(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.WorkerInfo, error) {
+	var state []dax.WorkerInfo
+	for _, addr := range b.workers {
+		state = append(state, dax.WorkerInfo{Address: addr, Jobs: b.jobs[addr]})
+	}
+	return state, nil
+}
+
+// AssignFreeJobs assigns every free job to the first worker.
+func (b *jobsBalancer) AssignFreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, jobs ...dax.Job) ([]dax.WorkerDiff, error) {
+	diff := dax.WorkerDiff{Address: b.workers[0]}
+	for _, job := range jobs {
+		for i, f := range b.free {
+			if f == job {
+				b.free = append(b.free[:i], b.free[i+1:]...)
+				b.jobs[b.workers[0]] = append(b.jobs[b.workers[0]], job)
+				diff.AddedJobs = append(diff.AddedJobs, job)
+				break
+			}
+		}
+	}
+	return []dax.WorkerDiff{diff}, nil
+}
+
+func (b *jobsBalancer) MoveJob(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, job dax.Job, addr dax.Address) ([]dax.WorkerDiff, error) {
+	for from, jobs := range b.jobs {
+		for i, j := range jobs {
+			if j == job {
+				b.jobs[from] = append(jobs[:i:i], jobs[i+1:]...)
+				b.jobs[addr] = append(b.jobs[addr], job)
+				return []dax.WorkerDiff{
+					{Address: from, RemovedJobs: []dax.Job{job}},
+					{Address: addr, AddedJobs: []dax.Job{job}},
+				}, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("job is not assigned to a worker: %s", job)
+}
+
+// oneDatabaseSchemar is a Schemar with a single, empty database.
+type oneDatabaseSchemar struct {
+	schemar.NopSchemar
+	qdb *dax.QualifiedDatabase
+}
+
+func (s *oneDatabaseSchemar) Databases(tx dax.Transaction, orgID dax.OrganizationID, ids ...dax.DatabaseID) ([]*dax.QualifiedDatabase, error) {
+	return []*dax.QualifiedDatabase{s.qdb}, nil
+}
+
+// zeroDirectiveVersion is a dax.DirectiveVersion which is always 0.
+type zeroDirectiveVersion struct{}
+
+func (zeroDirectiveVersion) GetCurrent(tx dax.Transaction, addr dax.Address) (uint64, error) {
+	return 0, nil
+}
+func (zeroDirectiveVersion) SetNext(tx dax.Transaction, addr dax.Address, current, next uint64) error {
+	return nil
+}
+
+// addressDirector records the addresses it's sent directives for.
+type addressDirector struct {
+	NopDirector
+	mu    sync.Mutex
+	addrs []dax.Address
+}
+
+func (d *addressDirector) SendDirective(ctx context.Context, dir *dax.Directive) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.addrs = append(d.addrs, dir.Address)
+	return nil
+}
+
+func TestReReplication(t *testing.T) {
+	qdb := dax.NewQualifiedDatabase("org", &dax.Database{ID: "db", Name: "db"})
+	tkey := dax.NewQualifiedTableID(qdb.QualifiedID(), "tbl").Key()
+	job := func(s dax.ShardNum) dax.Job { return shard(tkey, s).Job() }
+
+	newController := func() (*Controller, *jobsBalancer, *addressDirector) {
+		b := &jobsBalancer{
+			workers: []dax.Address{"a", "b", "c"},
+			jobs: map[dax.Address][]dax.Job{
+				"a": {job(0)},
+				"b": {job(1)},
+				"c": {job(2), job(3), job(4), job(5)},
+			},
+			free: []dax.Job{job(6)},
+		}
+		d := &addressDirector{}
+		c := New(Config{})
+		c.Transactor = &countingTransactor{}
+		c.Balancer = b
+		c.Schemar = &oneDatabaseSchemar{qdb: qdb}
+		c.Director = d
+		c.DirectiveVersion = zeroDirectiveVersion{}
+		return c, b, d
+	}
+	down := NewAddressSet()
+	down.Add("c")
+
+	t.Run("UnderReplicatedShards", func(t *testing.T) {
+		c, _, _ := newController()
+		tx, err := c.Transactor.BeginTx(context.Background(), false)
+		require.NoError(t, err)
+
+		shards, err := c.underReplicatedShards(tx, down)
+		require.NoError(t, err)
+		require.Len(t, shards, 5)
+		for i, s := range shards {
+			assert.Equal(t, dax.ShardNum(i+2), s.Shard)
+			assert.Equal(t, tkey, s.Table.Key())
+		}
+		assert.Equal(t, UnderReplicatedWorkerDown, shards[0].Reason)
+		assert.Equal(t, dax.Address("c"), shards[0].Worker)
+		assert.Equal(t, UnderReplicatedUnassigned, shards[4].Reason)
+		assert.Empty(t, shards[4].Worker)
+	})
+
+	t.Run("ReReplicateShards", func(t *testing.T) {
+		c, b, d := newController()
+		tx, err := c.Transactor.BeginTx(context.Background(), false)
+		require.NoError(t, err)
+		shards, err := c.underReplicatedShards(tx, down)
+		require.NoError(t, err)
+
+		// A batch of the first two shards on the down worker moves them to
+		// the live workers with the fewest jobs.
+		n, err := c.reReplicateShards(context.Background(), shards[:2], down)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []dax.Job{job(0), job(2)}, b.jobs["a"])
+		assert.Equal(t, []dax.Job{job(1), job(3)}, b.jobs["b"])
+		assert.Equal(t, []dax.Job{job(4), job(5)}, b.jobs["c"])
+
+		// Only the live workers are sent directives.
+		assert.ElementsMatch(t, []dax.Address{"a", "b"}, d.addrs)
+
+		shards, err = c.underReplicatedShards(tx, down)
+		require.NoError(t, err)
+		n, err = c.reReplicateShards(context.Background(), shards, down)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Empty(t, b.free)
+		assert.Empty(t, b.jobs["c"])
+
+		shards, err = c.underReplicatedShards(tx, down)
+		require.NoError(t, err)
+		assert.Empty(t, shards)
+	})
+
+	t.Run("NoLiveWorkers", func(t *testing.T) {
+		c, b, _ := newController()
+		b.free = nil
+		all := NewAddressSet()
+		for _, addr := range b.workers {
+			all.Add(addr)
+		}
+		tx, err := c.Transactor.BeginTx(context.Background(), false)
+		require.NoError(t, err)
+		shards, err := c.underReplicatedShards(tx, all)
+		require.NoError(t, err)
+		require.Len(t, shards, 6)
+
+		n, err := c.reReplicateShards(context.Background(), shards, all)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+	})
+
+	// A re-replication waits for the shards being migrated, rather than
+	// failing to reassign them.
+	t.Run("Migrating", func(t *testing.T) {
+		c, b, _ := newController()
+		c.reReplicationInterval = time.Millisecond
+		qtid := dax.NewQualifiedTableID(qdb.QualifiedID(), "tbl")
+		m, err := c.shardMigrations.start(qtid, 6, "a", "b", func() {})
+		require.NoError(t, err)
+
+		_, ok := c.reReplications.start(1)
+		require.True(t, ok)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			c.runReReplication("1")
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		r, err := c.reReplications.get()
+		require.NoError(t, err)
+		assert.Equal(t, ReReplicationRunning, r.Phase)
+		assert.Equal(t, 0, r.Batches)
+
+		c.shardMigrations.finish(m, ShardMigrationAborted, nil)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("re-replication didn't finish")
+		}
+		r, err = c.reReplications.get()
+		require.NoError(t, err)
+		assert.Equal(t, ReReplicationDone, r.Phase, r.Error)
+		assert.Equal(t, 1, r.Done)
+		assert.Empty(t, b.free)
+	})
+}
+
+func TestReReplications(t *testing.T) {
+	r := newReReplications()
+	_, err := r.get()
+	assert.True(t, errors.Is(err, ErrCodeReReplicationNotFound), "expected not found, got %v", err)
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	status, ok := r.start(10)
+	require.True(t, ok)
+	assert.Equal(t, ReReplicationRunning, status.Phase)
+	assert.Equal(t, 10, status.Remaining)
+
+	// Only one runs at a time.
+	again, ok := r.start(3)
+	assert.False(t, ok)
+	assert.Equal(t, status.ID, again.ID)
+
+	r.progress(4, 7)
+	status, err = r.get()
+	require.NoError(t, err)
+	assert.Equal(t, 4, status.Done)
+	assert.Equal(t, 7, status.Remaining)
+	assert.Equal(t, 1, status.Batches)
+
+	r.finish(-1, NewErrNoAvailableNode())
+	status, err = r.get()
+	require.NoError(t, err)
+	assert.True(t, status.Finished())
+	assert.Equal(t, ReReplicationFailed, status.Phase)
+	assert.Equal(t, 7, status.Remaining)
+	assert.NotEmpty(t, status.Error)
+
+	// Once finished, another can be started.
+	next, ok := r.start(7)
+	require.True(t, ok)
+	assert.NotEqual(t, status.ID, next.ID)
+}
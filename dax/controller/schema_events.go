@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+const (
+	// DefaultSchemaEventBufferSize is the default number of recent schema
+	// events kept for replay to reconnecting subscribers.
+	DefaultSchemaEventBufferSize = 1000
+
+	// DefaultSchemaEventRetention is the default length of time for which
+	// schema events are kept for replay to reconnecting subscribers.
+	DefaultSchemaEventRetention = time.Hour
+
+	// schemaEventSubscriberBuffer is the number of events which may be queued
+	// for a subscriber before it's considered to have fallen behind.
+	schemaEventSubscriberBuffer = 64
+)
+
+// The types of SchemaEvent. Dropping a database drops its tables without a
+// table-dropped event for each.
+const (
+	SchemaEventDatabaseCreated   = "database-created"
+	SchemaEventDatabaseDropped   = "database-dropped"
+	SchemaEventDatabaseOptionSet = "database-option-set"
+	SchemaEventTableCreated      = "table-created"
+	SchemaEventTableDropped      = "table-dropped"
+	SchemaEventFieldCreated      = "field-created"
+	SchemaEventFieldDropped      = "field-dropped"
+)
+
+// SchemaEvent describes a change to the schema which has been committed.
+//
+// The ID of an event is of the form "<epoch>-<sequence>". Sequence numbers
+// increase by one with each event; the epoch identifies the controller process
+// which assigned them, so that an ID issued before the controller restarted is
+// never mistaken for one issued after.
+type SchemaEvent struct {
+	ID       string                  `json:"id"`
+	Type     string                  `json:"type"`
+	Time     time.Time               `json:"time"`
+	Database dax.QualifiedDatabaseID `json:"database"`
+	Table    dax.TableID             `json:"table,omitempty"`
+	Name     dax.TableName           `json:"table-name,omitempty"`
+	Field    dax.FieldName           `json:"field,omitempty"`
+
+	seq uint64
+}
+
+// schemaEvents is a broadcaster of SchemaEvents which keeps a bounded window of
+// recent events, so that a subscriber which reconnects can be sent the events
+// it missed.
+type schemaEvents struct {
+	mu sync.Mutex
+
+	epoch string
+	seq   uint64
+
+	// buf holds the retained events, oldest first.
+	buf       []SchemaEvent
+	size      int
+	retention time.Duration
+
+	subs map[chan SchemaEvent]struct{}
+
+	now func() time.Time
+}
+
+func newSchemaEvents(size int, retention time.Duration) *schemaEvents {
+	if size <= 0 {
+		size = DefaultSchemaEventBufferSize
+	}
+	if retention <= 0 {
+		retention = DefaultSchemaEventRetention
+	}
+	return &schemaEvents{
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		size:      size,
+		retention: retention,
+		subs:      make(map[chan SchemaEvent]struct{}),
+		now:       time.Now,
+	}
+}
+
+// publish assigns ev its ID and sends it to all subscribers. A subscriber which
+// has fallen too far behind to receive ev is unsubscribed (its channel is
+// closed); it can reconnect from the last event it received.
+func (e *schemaEvents) publish(ev SchemaEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.seq++
+	ev.seq = e.seq
+	ev.ID = fmt.Sprintf("%s-%d", e.epoch, e.seq)
+	ev.Time = e.now().UTC()
+
+	e.buf = append(e.buf, ev)
+	if len(e.buf) > e.size {
+		e.buf = append(e.buf[:0], e.buf[len(e.buf)-e.size:]...)
+	}
+
+	for ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the retained events which follow the event with ID since,
+// along with a channel on which subsequent events are sent. If since is empty,
+// no events are replayed. If the events following since are no longer
+// retained, an ErrResyncRequired error is returned; the subscriber should
+// re-read the schema and subscribe again without since.
+//
+// The returned function must be called to unsubscribe.
+func (e *schemaEvents) subscribe(since string) ([]SchemaEvent, <-chan SchemaEvent, func(), error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.expire()
+
+	var replay []SchemaEvent
+	if since != "" {
+		seq, err := e.parseID(since)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// The event following since must still be retained, unless since is
+		// the most recent event.
+		if seq < e.seq && (len(e.buf) == 0 || e.buf[0].seq > seq+1) {
+			return nil, nil, nil, NewErrResyncRequired(since, "the events following it are no longer retained")
+		}
+		for _, ev := range e.buf {
+			if ev.seq > seq {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	ch := make(chan SchemaEvent, schemaEventSubscriberBuffer)
+	e.subs[ch] = struct{}{}
+
+	cancel := func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, ok := e.subs[ch]; ok {
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, cancel, nil
+}
+
+// parseID returns the sequence number of the event with the given ID, which
+// must have been issued by this process.
+func (e *schemaEvents) parseID(id string) (uint64, error) {
+	i := strings.LastIndexByte(id, '-')
+	if i < 0 {
+		return 0, NewErrResyncRequired(id, "it is not a valid event id")
+	}
+	if id[:i] != e.epoch {
+		return 0, NewErrResyncRequired(id, "it was issued before the controller restarted")
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil || seq > e.seq {
+		return 0, NewErrResyncRequired(id, "it is not a valid event id")
+	}
+	return seq, nil
+}
+
+// expire drops events older than the retention period. e.mu must be held.
+func (e *schemaEvents) expire() {
+	cutoff := e.now().Add(-e.retention)
+	n := 0
+	for n < len(e.buf) && e.buf[n].Time.Before(cutoff) {
+		n++
+	}
+	if n > 0 {
+		e.buf = append(e.buf[:0], e.buf[n:]...)
+	}
+}
+
+// SubscribeSchemaEvents subscribes to the changes made to the schema. It
+// returns the retained events which follow the event with ID since (which a
+// reconnecting subscriber sets to the ID of the last event it received), and a
+// channel on which subsequent events are sent. If since is empty, no events
+// are replayed.
+//
+// If the events following since are no longer retained (either because too
+// many events have been published since, they are older than the retention
+// period, or the controller has restarted), an ErrResyncRequired error is
+// returned, and the subscriber must re-read the schema before subscribing
+// again without since.
+//
+// The channel is closed if the subscriber falls too far behind; it can then
+// resubscribe from the last event it received. The returned function must be
+// called to unsubscribe.
+func (c *Controller) SubscribeSchemaEvents(since string) ([]SchemaEvent, <-chan SchemaEvent, func(), error) {
+	return c.schemaEvents.subscribe(since)
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaEvents(t *testing.T) {
+	qdbid := dax.NewQualifiedDatabaseID("org", "db")
+	created := func(name dax.TableName) SchemaEvent {
+		return SchemaEvent{Type: SchemaEventTableCreated, Database: qdbid, Name: name}
+	}
+	names := func(evs []SchemaEvent) []dax.TableName {
+		out := make([]dax.TableName, len(evs))
+		for i, ev := range evs {
+			out[i] = ev.Name
+		}
+		return out
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		e := newSchemaEvents(10, time.Hour)
+
+		replay, events, cancel, err := e.subscribe("")
+		require.NoError(t, err)
+		defer cancel()
+		assert.Empty(t, replay)
+
+		e.publish(created("a"))
+		e.publish(created("b"))
+		e.publish(created("c"))
+
+		first := <-events
+		assert.Equal(t, dax.TableName("a"), first.Name)
+
+		// A client which reconnects after the first event is sent the rest.
+		replay, _, cancel2, err := e.subscribe(first.ID)
+		require.NoError(t, err)
+		defer cancel2()
+		assert.Equal(t, []dax.TableName{"b", "c"}, names(replay))
+
+		// A client which has seen every event is sent nothing.
+		replay, _, cancel3, err := e.subscribe(replay[1].ID)
+		require.NoError(t, err)
+		defer cancel3()
+		assert.Empty(t, replay)
+	})
+
+	t.Run("ResyncRequired", func(t *testing.T) {
+		e := newSchemaEvents(2, time.Hour)
+		now := time.Now()
+		e.now = func() time.Time { return now }
+
+		e.publish(created("a"))
+		a := e.buf[0]
+
+		// Once the events following a are overrun, a client can't resume from a.
+		e.publish(created("b"))
+		e.publish(created("c"))
+		e.publish(created("d"))
+		_, _, _, err := e.subscribe(a.ID)
+		assert.True(t, errors.Is(err, ErrCodeResyncRequired), "expected resync required, got %v", err)
+
+		// Nor once they've expired.
+		b := e.buf[0]
+		now = now.Add(2 * time.Hour)
+		_, _, _, err = e.subscribe(b.ID)
+		assert.True(t, errors.Is(err, ErrCodeResyncRequired), "expected resync required, got %v", err)
+
+		// Nor from an id issued by another controller process.
+		_, _, _, err = newSchemaEvents(2, time.Hour).subscribe(b.ID)
+		assert.True(t, errors.Is(err, ErrCodeResyncRequired), "expected resync required, got %v", err)
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		e := newSchemaEvents(10, time.Hour)
+		_, events, cancel, err := e.subscribe("")
+		require.NoError(t, err)
+		defer cancel()
+
+		for i := 0; i <= schemaEventSubscriberBuffer; i++ {
+			e.publish(created("t"))
+		}
+		n := 0
+		for range events {
+			n++
+		}
+		assert.Equal(t, schemaEventSubscriberBuffer, n)
+	})
+}
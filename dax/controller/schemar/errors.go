@@ -15,7 +15,10 @@ const (
 	ErrCodeTableNameInvalid  errors.Code = "TableNameInvalid"
 	ErrCodeInvalidPrimaryKey errors.Code = "InvalidPrimaryKey"
 
-	ErrCodeFieldNameInvalid errors.Code = "FieldNameInvalid"
+	ErrCodeFieldNameInvalid       errors.Code = "FieldNameInvalid"
+	ErrCodeFieldDefaultInvalid    errors.Code = "FieldDefaultInvalid"
+	ErrCodeFieldEncryptionInvalid errors.Code = "FieldEncryptionInvalid"
+	ErrCodeFieldInUse             errors.Code = "FieldInUse"
 )
 
 func NewErrDatabaseIDInvalid(databaseID dax.DatabaseID) error {
@@ -59,3 +62,24 @@ func NewErrFieldNameInvalid(fieldName dax.FieldName) error {
 		fmt.Sprintf("field name '%s' is invalid", fieldName),
 	)
 }
+
+func NewErrFieldDefaultInvalid(fieldName dax.FieldName, reason error) error {
+	return errors.New(
+		ErrCodeFieldDefaultInvalid,
+		fmt.Sprintf("field '%s' has an invalid default: %s", fieldName, reason),
+	)
+}
+
+func NewErrFieldEncryptionInvalid(fieldName dax.FieldName, reason error) error {
+	return errors.New(
+		ErrCodeFieldEncryptionInvalid,
+		fmt.Sprintf("field '%s' has invalid encryption: %s", fieldName, reason),
+	)
+}
+
+func NewErrFieldInUse(fieldName dax.FieldName, computedBy dax.FieldName) error {
+	return errors.New(
+		ErrCodeFieldInUse,
+		fmt.Sprintf("field '%s' can't be dropped: field '%s' is computed from it", fieldName, computedBy),
+	)
+}
@@ -301,6 +301,25 @@ func TestSQLSchemar(t *testing.T) {
 		requireCode(t, err, cschemar.ErrCodeFieldNameInvalid)
 	})
 
+	t.Run("Re-created field changes the table version", func(t *testing.T) {
+		tx, err = trans.BeginTx(context.Background(), true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		fld := &dax.Field{Name: "height", Type: "int"}
+		require.NoError(t, schemar.CreateField(tx, qtbl.QualifiedID(), fld))
+		before, err := schemar.Table(tx, qtbl.QualifiedID())
+		require.NoError(t, err)
+
+		require.NoError(t, schemar.DropField(tx, qtbl.QualifiedID(), fld.Name))
+		require.NoError(t, schemar.CreateField(tx, qtbl.QualifiedID(), fld))
+		after, err := schemar.Table(tx, qtbl.QualifiedID())
+		require.NoError(t, err)
+
+		require.Equal(t, len(before.Fields), len(after.Fields))
+		require.NotEqual(t, before.Version(), after.Version())
+	})
+
 	t.Run("Test create table where table name already exists", func(t *testing.T) {
 		tx, err = trans.BeginTx(context.Background(), true)
 		require.NoError(t, err)
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"net/http"
 	"os"
 
@@ -15,6 +16,7 @@ import (
 
 // Ensure type implements interface.
 var _ dax.Service = (*controllerService)(nil)
+var _ dax.Drainer = (*controllerService)(nil)
 
 type controllerService struct {
 	uri        *fbnet.URI
@@ -79,6 +81,10 @@ func (m *controllerService) Stop() error {
 	return err
 }
 
+func (m *controllerService) Drain(ctx context.Context) error {
+	return m.controller.Drain(ctx)
+}
+
 func (m *controllerService) Address() dax.Address {
 	return dax.Address(m.uri.HostPort() + "/" + dax.ServicePrefixController)
 }
@@ -86,3 +92,8 @@ func (m *controllerService) Address() dax.Address {
 func (m *controllerService) HTTPHandler() http.Handler {
 	return controllerhttp.Handler(m.controller)
 }
+
+// OpenAPIAnnotations describes the controller's routes.
+func (m *controllerService) OpenAPIAnnotations() map[string]dax.OpenAPIAnnotation {
+	return controllerhttp.OpenAPIAnnotations()
+}
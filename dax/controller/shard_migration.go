@@ -0,0 +1,517 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+const (
+	// DefaultShardMigrationQuiesce is the default length of time for which
+	// writes to a migrating shard are held off before cutover, to allow writes
+	// which were already routed to the source compute node to complete.
+	DefaultShardMigrationQuiesce = 2 * time.Second
+
+	// shardMigrationHistory is the number of finished migrations which are
+	// kept for status requests.
+	shardMigrationHistory = 100
+)
+
+// The phases of a ShardMigration. A migration can be aborted while it is
+// snapshotting or quiescing; once it starts cutting over it runs to
+// completion (or fails, in which case the shard is left on the source).
+const (
+	ShardMigrationSnapshotting = "snapshotting"
+	ShardMigrationQuiescing    = "quiescing"
+	ShardMigrationCuttingOver  = "cutting-over"
+	ShardMigrationDone         = "done"
+	ShardMigrationFailed       = "failed"
+	ShardMigrationAborted      = "aborted"
+)
+
+// ShardMigration describes the progress of moving a single shard from one
+// compute node to another.
+//
+// A migration proceeds as follows:
+//
+//  1. snapshotting: the source is told to snapshot the shard. Writes continue
+//     during the snapshot, and are written to the write log following it.
+//  2. quiescing: IngestShard stops routing writes for the shard, returning an
+//     ErrShardMigrating error which writers should retry, and the controller
+//     waits for the writes which were already routed to the source to finish.
+//  3. cutting-over: the shard is reassigned from the source to the target,
+//     and both are sent new directives. The source releases the shard, and
+//     the target loads it from the snapshot and replays the write log which
+//     follows it.
+//
+// Writes to the shard are unavailable from the start of quiescing until
+// cutover completes; WritesPausedAt and WritesResumedAt record that window.
+// Reads are served by the source until its directive is applied, and by the
+// target once its directive is applied.
+type ShardMigration struct {
+	ID     string               `json:"id"`
+	Table  dax.QualifiedTableID `json:"table"`
+	Shard  dax.ShardNum         `json:"shard"`
+	Source dax.Address          `json:"source"`
+	Target dax.Address          `json:"target"`
+	Phase  string               `json:"phase"`
+	Error  string               `json:"error,omitempty"`
+
+	Started         time.Time  `json:"started"`
+	Updated         time.Time  `json:"updated"`
+	WritesPausedAt  *time.Time `json:"writes-paused-at,omitempty"`
+	WritesResumedAt *time.Time `json:"writes-resumed-at,omitempty"`
+}
+
+// Finished returns true if the migration is no longer running.
+func (m ShardMigration) Finished() bool {
+	switch m.Phase {
+	case ShardMigrationDone, ShardMigrationFailed, ShardMigrationAborted:
+		return true
+	}
+	return false
+}
+
+// shardKey identifies a shard within a table.
+type shardKey struct {
+	table dax.TableKey
+	shard dax.ShardNum
+}
+
+type shardMigration struct {
+	ShardMigration
+	seq      uint64
+	cancel   context.CancelFunc
+	quiesced bool
+}
+
+// shardMigrations tracks the shard migrations which are running, and those
+// which have recently finished.
+type shardMigrations struct {
+	mu sync.Mutex
+
+	seq    uint64
+	byID   map[string]*shardMigration
+	active map[shardKey]*shardMigration
+
+	// finished holds the IDs of finished migrations, oldest first.
+	finished []string
+
+	now func() time.Time
+}
+
+func newShardMigrations() *shardMigrations {
+	return &shardMigrations{
+		byID:   make(map[string]*shardMigration),
+		active: make(map[shardKey]*shardMigration),
+		now:    time.Now,
+	}
+}
+
+// start registers a new migration, returning an error if the shard is
+// already being migrated.
+func (s *shardMigrations) start(qtid dax.QualifiedTableID, shard dax.ShardNum, source, target dax.Address, cancel context.CancelFunc) (*shardMigration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := shardKey{table: qtid.Key(), shard: shard}
+	if m, ok := s.active[key]; ok {
+		return nil, NewErrShardMigrating(qtid, shard, m.ID)
+	}
+
+	s.seq++
+	now := s.now().UTC()
+	m := &shardMigration{
+		ShardMigration: ShardMigration{
+			ID:      fmt.Sprintf("%d", s.seq),
+			Table:   qtid,
+			Shard:   shard,
+			Source:  source,
+			Target:  target,
+			Phase:   ShardMigrationSnapshotting,
+			Started: now,
+			Updated: now,
+		},
+		seq:    s.seq,
+		cancel: cancel,
+	}
+	s.byID[m.ID] = m
+	s.active[key] = m
+	return m, nil
+}
+
+// advance moves m to phase, unless it has been aborted, in which case an
+// error is returned. Moving to ShardMigrationQuiescing holds off writes to
+// the shard.
+func (s *shardMigrations) advance(ctx context.Context, m *shardMigration, phase string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Phase = phase
+	m.Updated = s.now().UTC()
+	if phase == ShardMigrationQuiescing {
+		m.quiesced = true
+		t := m.Updated
+		m.WritesPausedAt = &t
+	}
+	return nil
+}
+
+// finish moves m to a final phase, and resumes writes to the shard.
+func (s *shardMigrations) finish(m *shardMigration, phase string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m.Phase = phase
+	m.Updated = s.now().UTC()
+	if err != nil {
+		m.Error = err.Error()
+	}
+	if m.quiesced {
+		m.quiesced = false
+		t := m.Updated
+		m.WritesResumedAt = &t
+	}
+	m.cancel()
+
+	delete(s.active, shardKey{table: m.Table.Key(), shard: m.Shard})
+	s.finished = append(s.finished, m.ID)
+	if len(s.finished) > shardMigrationHistory {
+		delete(s.byID, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+// abort cancels the migration with the given ID, if it hasn't started
+// cutting over.
+func (s *shardMigrations) abort(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.byID[id]
+	if !ok {
+		return NewErrShardMigrationNotFound(id)
+	}
+	switch m.Phase {
+	case ShardMigrationSnapshotting, ShardMigrationQuiescing:
+		m.cancel()
+		return nil
+	case ShardMigrationCuttingOver:
+		return NewErrInvalidRequest(fmt.Sprintf("shard migration '%s' is cutting over, and can no longer be aborted", id))
+	default:
+		return NewErrInvalidRequest(fmt.Sprintf("shard migration '%s' has already finished (%s)", id, m.Phase))
+	}
+}
+
+// abortAll cancels every migration which hasn't started cutting over.
+func (s *shardMigrations) abortAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.active {
+		if m.Phase != ShardMigrationCuttingOver {
+			m.cancel()
+		}
+	}
+}
+
+// quiesced returns the ID of the migration holding off writes to the given
+// shard, if there is one.
+func (s *shardMigrations) quiesced(tkey dax.TableKey, shard dax.ShardNum) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.active[shardKey{table: tkey, shard: shard}]
+	if !ok || !m.quiesced {
+		return "", false
+	}
+	return m.ID, true
+}
+
+// migrating returns the ID of the migration moving the given shard, if there
+// is one.
+func (s *shardMigrations) migrating(tkey dax.TableKey, shard dax.ShardNum) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.active[shardKey{table: tkey, shard: shard}]
+	if !ok {
+		return "", false
+	}
+	return m.ID, true
+}
+
+func (s *shardMigrations) get(id string) (ShardMigration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.byID[id]
+	if !ok {
+		return ShardMigration{}, NewErrShardMigrationNotFound(id)
+	}
+	return m.ShardMigration, nil
+}
+
+// list returns the running and recently finished migrations, oldest first.
+func (s *shardMigrations) list() []ShardMigration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := make([]*shardMigration, 0, len(s.byID))
+	for _, m := range s.byID {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].seq < ms[j].seq })
+
+	out := make([]ShardMigration, len(ms))
+	for i, m := range ms {
+		out[i] = m.ShardMigration
+	}
+	return out
+}
+
+// MigrateShard starts moving a shard from the compute node which currently
+// holds it to the compute node at target, which must already be assigned to
+// the table's database. It returns once the migration has been started; its
+// progress can be followed with ShardMigration. See ShardMigration for the
+// steps involved.
+//
+// MigrateShard supports dry runs (see DryRun). A dry run validates the
+// migration and records the reassignment of the shard and the directives
+// which cutover would send, without snapshotting or moving the shard. The
+// returned ShardMigration has no ID.
+func (c *Controller) MigrateShard(ctx context.Context, qtid dax.QualifiedTableID, shardNum dax.ShardNum, target dax.Address) (ShardMigration, error) {
+	qdbid := qtid.QualifiedDatabaseID
+
+	var source dax.Address
+
+	fn := func(tx dax.Transaction, writable bool) error {
+		if err := c.sanitizeQTID(tx, &qtid); err != nil {
+			return errors.Wrap(err, "sanitizing")
+		}
+		job := shard(qtid.Key(), shardNum).Job()
+		workers, err := c.Balancer.WorkersForJobs(tx, dax.RoleTypeCompute, qdbid, job)
+		if err != nil {
+			return errors.Wrapf(err, "getting workers for jobs: %s", job)
+		}
+		if len(workers) == 0 {
+			return NewErrInvalidRequest(fmt.Sprintf("shard %d of table '%s' isn't assigned to a compute node", shardNum, qtid))
+		}
+		source = workers[0].Address
+
+		state, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
+		if err != nil {
+			return errors.Wrapf(err, "getting current state: %s", qdbid)
+		}
+		for _, w := range state {
+			if w.Address == target {
+				return nil
+			}
+		}
+		return NewErrInvalidRequest(fmt.Sprintf("'%s' isn't a compute node assigned to database '%s'", target, qdbid))
+	}
+
+	if err := dax.RetryWithTx(ctx, c.Transactor, fn, false, 1); err != nil {
+		return ShardMigration{}, errors.Wrap(err, "retry with tx: read")
+	}
+
+	if source == target {
+		return ShardMigration{}, NewErrInvalidRequest(fmt.Sprintf("shard %d of table '%s' is already on '%s'", shardNum, qtid, target))
+	}
+
+	if dryRun := DryRunFromContext(ctx); dryRun != nil {
+		if id, ok := c.shardMigrations.migrating(qtid.Key(), shardNum); ok {
+			return ShardMigration{}, NewErrShardMigrating(qtid, shardNum, id)
+		}
+		directives, err := c.moveShard(ctx, qtid, shardNum, source, target)
+		if err != nil {
+			return ShardMigration{}, err
+		}
+		dryRun.finish(directives, nil)
+		return ShardMigration{Table: qtid, Shard: shardNum, Source: source, Target: target}, nil
+	}
+
+	mctx, cancel := context.WithCancel(context.Background())
+	m, err := c.shardMigrations.start(qtid, shardNum, source, target, cancel)
+	if err != nil {
+		cancel()
+		return ShardMigration{}, err
+	}
+	status := m.ShardMigration
+
+	c.logger.Printf("migrating shard %d of table %s from %s to %s (migration %s)", shardNum, qtid, source, target, m.ID)
+	c.backgroundGroup.Go(func() error {
+		c.runShardMigration(mctx, m)
+		return nil
+	})
+
+	return status, nil
+}
+
+// runShardMigration carries out the migration m, recording its outcome in
+// c.shardMigrations.
+func (c *Controller) runShardMigration(ctx context.Context, m *shardMigration) {
+	aborted := func() bool {
+		if ctx.Err() == nil {
+			return false
+		}
+		c.logger.Printf("shard migration %s aborted", m.ID)
+		c.shardMigrations.finish(m, ShardMigrationAborted, nil)
+		return true
+	}
+	failed := func(err error) {
+		c.logger.Printf("shard migration %s failed: %v", m.ID, err)
+		c.shardMigrations.finish(m, ShardMigrationFailed, err)
+	}
+
+	// Snapshot the shard on the source, so that the target only has to replay
+	// the writes which follow the snapshot.
+	if err := c.migrationSnapshot(ctx, m); err != nil {
+		if !aborted() {
+			failed(errors.Wrap(err, "snapshotting shard"))
+		}
+		return
+	}
+
+	// Stop routing writes to the source, and give those already routed to it
+	// time to complete.
+	if err := c.shardMigrations.advance(ctx, m, ShardMigrationQuiescing); err != nil {
+		aborted()
+		return
+	}
+	select {
+	case <-ctx.Done():
+		aborted()
+		return
+	case <-time.After(c.shardMigrationQuiesce):
+	}
+
+	// From here on the migration can't be aborted.
+	if err := c.shardMigrations.advance(ctx, m, ShardMigrationCuttingOver); err != nil {
+		aborted()
+		return
+	}
+
+	if err := c.cutOverShard(context.Background(), m.Table, m.Shard, m.Source, m.Target); err != nil {
+		failed(errors.Wrap(err, "cutting over"))
+		return
+	}
+
+	c.logger.Printf("shard migration %s done", m.ID)
+	c.shardMigrations.finish(m, ShardMigrationDone, nil)
+}
+
+// migrationSnapshot snapshots the shard being migrated by m, after verifying
+// that it's still held by the migration's source.
+func (c *Controller) migrationSnapshot(ctx context.Context, m *shardMigration) error {
+	tx, err := c.Transactor.BeginTx(ctx, false)
+	if err != nil {
+		return errors.Wrap(err, "beginning tx")
+	}
+	defer tx.Rollback()
+
+	job := shard(m.Table.Key(), m.Shard).Job()
+	workers, err := c.Balancer.WorkersForJobs(tx, dax.RoleTypeCompute, m.Table.QualifiedDatabaseID, job)
+	if err != nil {
+		return errors.Wrapf(err, "getting workers for jobs: %s", job)
+	}
+	if len(workers) == 0 || workers[0].Address != m.Source {
+		return NewErrInvalidRequest(fmt.Sprintf("shard %d of table '%s' is no longer on '%s'", m.Shard, m.Table, m.Source))
+	}
+
+	return c.snapshotShardData(tx, m.Table, m.Shard, dax.SnapshotPriorityUrgent)
+}
+
+// cutOverShard reassigns a shard from source to target and sends both their
+// new directives. The source is sent its directive first, so that it releases
+// the shard before the target loads it. If either directive can't be sent, the
+// shard is reassigned back to source.
+func (c *Controller) cutOverShard(ctx context.Context, qtid dax.QualifiedTableID, shardNum dax.ShardNum, source, target dax.Address) error {
+	directives, err := c.moveShard(ctx, qtid, shardNum, source, target)
+	if err != nil {
+		return err
+	}
+
+	// buildDirectives returns the directives in the order of the addresses
+	// it's given, so the source's directive is sent first.
+	var sendErr error
+	for _, dir := range directives {
+		if err := c.Director.SendDirective(ctx, dir); err != nil {
+			sendErr = NewErrDirectiveSendFailure(err.Error())
+			break
+		}
+	}
+	if sendErr == nil {
+		return nil
+	}
+
+	// Put the shard back on the source.
+	directives, err = c.moveShard(ctx, qtid, shardNum, target, source)
+	if err != nil {
+		return errors.Wrapf(sendErr, "rolling back failed (%v)", err)
+	}
+	if err := c.sendDirectives(ctx, directives); err != nil {
+		return errors.Wrapf(sendErr, "rolling back failed (%v)", err)
+	}
+	return errors.Wrap(sendErr, "rolled back")
+}
+
+// moveShard reassigns a shard from one compute node to another, returning the
+// directives for both, which the caller must send.
+func (c *Controller) moveShard(ctx context.Context, qtid dax.QualifiedTableID, shardNum dax.ShardNum, from, to dax.Address) ([]*dax.Directive, error) {
+	var directives []*dax.Directive
+	fn := func(tx dax.Transaction, writable bool) error {
+		job := shard(qtid.Key(), shardNum).Job()
+		workers, err := c.Balancer.WorkersForJobs(tx, dax.RoleTypeCompute, qtid.QualifiedDatabaseID, job)
+		if err != nil {
+			return errors.Wrapf(err, "getting workers for jobs: %s", job)
+		}
+		if len(workers) == 0 || workers[0].Address != from {
+			return NewErrInvalidRequest(fmt.Sprintf("shard %d of table '%s' is no longer on '%s'", shardNum, qtid, from))
+		}
+
+		diffs, err := c.Balancer.MoveJob(tx, dax.RoleTypeCompute, qtid.QualifiedDatabaseID, job, to)
+		if err != nil {
+			return errors.Wrap(err, "moving job")
+		}
+		DryRunFromContext(ctx).addDiffs(diffs...)
+
+		directives, err = c.buildDirectives(ctx, tx, applyAddressMethod([]dax.Address{from, to}, dax.DirectiveMethodFull))
+		if err != nil {
+			return errors.Wrap(err, "building directives")
+		}
+		return nil
+	}
+	if err := c.writeTx(ctx, fn); err != nil {
+		return nil, errors.Wrap(err, "retry with tx: write")
+	}
+	return directives, nil
+}
+
+// ShardMigration returns the status of the shard migration with the given ID.
+// Finished migrations are kept for a limited time.
+func (c *Controller) ShardMigration(id string) (ShardMigration, error) {
+	return c.shardMigrations.get(id)
+}
+
+// ShardMigrations returns the status of running and recently finished shard
+// migrations.
+func (c *Controller) ShardMigrations() []ShardMigration {
+	return c.shardMigrations.list()
+}
+
+// AbortShardMigration aborts the shard migration with the given ID. Nothing is
+// changed by a migration until it cuts over, so aborting simply stops the
+// migration and resumes writes to the shard on its source; a migration which
+// has started cutting over can't be aborted.
+func (c *Controller) AbortShardMigration(id string) error {
+	return c.shardMigrations.abort(id)
+}
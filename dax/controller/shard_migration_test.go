@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardMigrations(t *testing.T) {
+	qtid := dax.NewQualifiedTableID(dax.NewQualifiedDatabaseID("org", "db"), "tbl")
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		s := newShardMigrations()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		m, err := s.start(qtid, 3, "a", "b", cancel)
+		require.NoError(t, err)
+
+		// A shard can't be migrated twice at once.
+		_, err = s.start(qtid, 3, "a", "c", func() {})
+		assert.True(t, errors.Is(err, ErrCodeShardMigrating), "expected shard migrating, got %v", err)
+
+		// Writes continue while the shard is snapshotted...
+		_, ok := s.quiesced(qtid.Key(), 3)
+		assert.False(t, ok)
+
+		// ...and are held off from the start of quiescing until the
+		// migration finishes.
+		require.NoError(t, s.advance(ctx, m, ShardMigrationQuiescing))
+		id, ok := s.quiesced(qtid.Key(), 3)
+		assert.True(t, ok)
+		assert.Equal(t, m.ID, id)
+		_, ok = s.quiesced(qtid.Key(), 4)
+		assert.False(t, ok)
+
+		require.NoError(t, s.advance(ctx, m, ShardMigrationCuttingOver))
+		err = s.abort(m.ID)
+		assert.True(t, errors.Is(err, ErrCodeInvalidRequest), "expected invalid request, got %v", err)
+
+		s.finish(m, ShardMigrationDone, nil)
+		_, ok = s.quiesced(qtid.Key(), 3)
+		assert.False(t, ok)
+
+		status, err := s.get(m.ID)
+		require.NoError(t, err)
+		assert.Equal(t, ShardMigrationDone, status.Phase)
+		assert.True(t, status.Finished())
+		require.NotNil(t, status.WritesPausedAt)
+		require.NotNil(t, status.WritesResumedAt)
+		assert.False(t, status.WritesResumedAt.Before(*status.WritesPausedAt))
+
+		// Once finished, the shard can be migrated again.
+		_, err = s.start(qtid, 3, "b", "a", func() {})
+		require.NoError(t, err)
+		assert.Len(t, s.list(), 2)
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		s := newShardMigrations()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		m, err := s.start(qtid, 1, "a", "b", cancel)
+		require.NoError(t, err)
+		require.NoError(t, s.advance(ctx, m, ShardMigrationQuiescing))
+
+		require.NoError(t, s.abort(m.ID))
+		assert.Error(t, ctx.Err())
+
+		// An aborted migration doesn't cut over.
+		assert.Error(t, s.advance(ctx, m, ShardMigrationCuttingOver))
+		assert.Equal(t, ShardMigrationQuiescing, m.Phase)
+
+		s.finish(m, ShardMigrationAborted, nil)
+		_, ok := s.quiesced(qtid.Key(), 1)
+		assert.False(t, ok)
+
+		err = s.abort(m.ID)
+		assert.True(t, errors.Is(err, ErrCodeInvalidRequest), "expected invalid request, got %v", err)
+		err = s.abort("nope")
+		assert.True(t, errors.Is(err, ErrCodeShardMigrationNotFound), "expected not found, got %v", err)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		s := newShardMigrations()
+		var first string
+		for i := 0; i <= shardMigrationHistory; i++ {
+			m, err := s.start(qtid, 1, "a", "b", func() {})
+			require.NoError(t, err)
+			if i == 0 {
+				first = m.ID
+			}
+			s.finish(m, ShardMigrationFailed, errors.New(ErrCodeInternal, "boom"))
+		}
+
+		list := s.list()
+		assert.Len(t, list, shardMigrationHistory)
+		assert.Equal(t, "boom", list[0].Error)
+		_, err := s.get(first)
+		assert.True(t, errors.Is(err, ErrCodeShardMigrationNotFound), "expected not found, got %v", err)
+	})
+}
@@ -2,13 +2,14 @@ package controller
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/logger"
 )
 
-func (c *Controller) snappingTurtleRoutine(period time.Duration, control chan struct{}, log logger.Logger) error {
+func (c *Controller) snappingTurtleRoutine(period time.Duration, control chan dax.SnapshotPriority, log logger.Logger) error {
 	if period == 0 {
 		return nil
 	}
@@ -20,14 +21,29 @@ func (c *Controller) snappingTurtleRoutine(period time.Duration, control chan st
 			log.Debugf("Stopping Snapping Turtle")
 			return nil
 		case <-ticker.C:
-			c.snapAll(log)
-		case <-control:
-			c.snapAll(log)
+			if !c.maintenance.open(time.Now()) {
+				log.Debugf("Deferring snapshots until the maintenance window opens")
+				continue
+			}
+			c.snapAll(dax.SnapshotPriorityBackground, log)
+		case priority := <-control:
+			c.warnOutsideMaintenanceWindow(log, "snapshots")
+			c.snapAll(priority, log)
 		}
 	}
 }
 
-func (c *Controller) snapAll(log logger.Logger) {
+// snapAll snapshots every shard, and the keys of every keyed table and field,
+// in every database. Shards are snapshotted with the given priority. A round
+// of background snapshots stops if the maintenance window closes.
+func (c *Controller) snapAll(priority dax.SnapshotPriority, log logger.Logger) {
+	c.snapMu.Lock()
+	defer c.snapMu.Unlock()
+	if c.snapDrained {
+		log.Debugf("Skipping snapshots; controller is draining")
+		return
+	}
+
 	start := time.Now()
 	defer func() {
 		log.Printf("full snapshot took: %v", time.Since(start))
@@ -47,39 +63,25 @@ func (c *Controller) snapAll(log logger.Logger) {
 	}
 
 	for _, qdb := range qdbs {
-		c.snapAllForDatabase(tx, qdb.QualifiedID(), log)
+		if c.windowClosed(priority) || !c.snapAllForDatabase(tx, qdb.QualifiedID(), priority, log) {
+			log.Printf("Maintenance window closed; the rest of this round of snapshots is deferred to the next window")
+			return
+		}
 	}
 }
 
-func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, log logger.Logger) {
+// snapAllForDatabase snapshots the shards and keys of the database. It
+// returns false if it stopped because the maintenance window closed.
+func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, priority dax.SnapshotPriority, log logger.Logger) bool {
 	log.Debugf("snapAllForDatabase: %s", qdbid)
 	computeNodes, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
 	if err != nil {
 		log.Printf("Error getting compute balancer state for snapping turtle: %v", err)
 	}
 
-	// Weird nested loop for snapshotting shard data. The reason for
-	// this is to avoid hotspotting each node in turn and spread the
-	// snapshotting load across all nodes rather than snapshotting all
-	// jobs on one node and then moving onto the next one.
-	i := 0
-	stillWorking := true
-	for stillWorking {
-		stillWorking = false
-		for _, workerInfo := range computeNodes {
-			if len(workerInfo.Jobs) <= i {
-				continue
-			}
-			stillWorking = true
-			j, err := decodeShard(workerInfo.Jobs[i])
-			if err != nil {
-				log.Printf("couldn't decode a shard out of the job: '%s', err: %v", workerInfo.Jobs[i], err)
-			}
-			if err := c.snapshotShardData(tx, j.t.QualifiedTableID(), j.shardNum()); err != nil {
-				log.Printf("Couldn't snapshot table: %s, shard: %d, error: %v", j.t, j.shardNum(), err)
-			}
-		}
-		i++
+	reqs := shardSnapshotRequests(computeNodes, priority, nil, log)
+	if _, sent := c.sendSnapshotShardDataRequests(tx.Context(), reqs, log); !sent || c.windowClosed(priority) {
+		return false
 	}
 
 	// Get all tables across all orgs/dbs so we can snapshot all keyed
@@ -88,7 +90,7 @@ func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedD
 	tables, err := c.Schemar.Tables(tx, dax.QualifiedDatabaseID{})
 	if err != nil {
 		log.Printf("Couldn't get schema for snapshotting keys: %v", err)
-		return
+		return true
 	}
 	// snapshot keyed fields
 	tableMap := make(map[dax.TableKey]*dax.QualifiedTable)
@@ -112,8 +114,8 @@ func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedD
 		log.Printf("Error getting translate balancer state for snapping turtle: %v", err)
 	}
 
-	i = 0
-	stillWorking = true
+	i := 0
+	stillWorking := true
 	for stillWorking {
 		stillWorking = false
 		for _, workerInfo := range translateNodes {
@@ -135,4 +137,92 @@ func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedD
 		i++
 	}
 	log.Debugf("snapAllForDatabase complete: %s", qdbid)
+	return true
+}
+
+// shardSnapshotRequests returns requests to snapshot the shards of the
+// compute nodes at the given priority, limited to the tables for which include
+// returns true unless it's nil.
+func shardSnapshotRequests(computeNodes []dax.WorkerInfo, priority dax.SnapshotPriority, include func(dax.TableKey) bool, log logger.Logger) []*dax.SnapshotShardDataRequest {
+	// Weird nested loop for snapshotting shard data. The reason for
+	// this is to avoid hotspotting each node in turn and spread the
+	// snapshotting load across all nodes rather than snapshotting all
+	// jobs on one node and then moving onto the next one.
+	var reqs []*dax.SnapshotShardDataRequest
+	i := 0
+	stillWorking := true
+	for stillWorking {
+		stillWorking = false
+		for _, workerInfo := range computeNodes {
+			if len(workerInfo.Jobs) <= i {
+				continue
+			}
+			stillWorking = true
+			j, err := decodeShard(workerInfo.Jobs[i])
+			if err != nil {
+				log.Printf("couldn't decode a shard out of the job: '%s', err: %v", workerInfo.Jobs[i], err)
+				continue
+			}
+			if include != nil && !include(j.table()) {
+				continue
+			}
+			reqs = append(reqs, &dax.SnapshotShardDataRequest{
+				Address:  workerInfo.Address,
+				TableKey: j.table(),
+				ShardNum: j.shardNum(),
+				Priority: priority,
+			})
+		}
+		i++
+	}
+	return reqs
+}
+
+// sendSnapshotShardDataRequests sends reqs in order, with up to
+// snapshotConcurrency of them in progress at once, and returns the tables of
+// the requests which failed. Background requests which haven't been sent when
+// the maintenance window closes aren't sent, in which case sent is false.
+func (c *Controller) sendSnapshotShardDataRequests(ctx context.Context, reqs []*dax.SnapshotShardDataRequest, log logger.Logger) (failed TableSet, sent bool) {
+	failed = NewTableSet()
+	sent = true
+	sem := make(chan struct{}, c.snapshotConcurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, req := range reqs {
+		sem <- struct{}{}
+		if c.windowClosed(req.Priority) {
+			<-sem
+			sent = false
+			break
+		}
+		wg.Add(1)
+		go func(req *dax.SnapshotShardDataRequest) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := c.Director.SendSnapshotShardDataRequest(ctx, req); err != nil {
+				log.Printf("Couldn't snapshot table: %s, shard: %d, error: %v", req.TableKey, req.ShardNum, err)
+				mu.Lock()
+				failed.Add(req.TableKey)
+				mu.Unlock()
+			}
+		}(req)
+	}
+	wg.Wait()
+	return failed, sent
+}
+
+// windowClosed reports whether work of the given priority must stop because
+// the maintenance window has closed, which applies only to background work.
+func (c *Controller) windowClosed(priority dax.SnapshotPriority) bool {
+	return priority == dax.SnapshotPriorityBackground && !c.maintenance.open(time.Now())
+}
+
+// warnOutsideMaintenanceWindow logs a warning if work which was requested on
+// demand is about to be done outside the maintenance windows.
+func (c *Controller) warnOutsideMaintenanceWindow(log logger.Logger, what string) {
+	if !c.maintenance.open(time.Now()) {
+		log.Warnf("taking %s requested on demand outside the maintenance window", what)
+	}
 }
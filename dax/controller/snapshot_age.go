@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+// DefaultSnapshotAgeCheckInterval is the period on which the ages of tables'
+// snapshots are checked against their maximums, if none is configured.
+const DefaultSnapshotAgeCheckInterval = time.Minute
+
+// snapshotAges holds the tables which were overdue for a snapshot as of the
+// last check. The ages themselves aren't tracked here: a shard's age is that
+// of its latest snapshot in the snapshotter, whether it was taken by force or
+// by the snapping turtle, so it survives a restart of the Controller.
+type snapshotAges struct {
+	mu sync.Mutex
+
+	// overdue are the tables reported by dax.GaugeSnapshotAgeOverdue.
+	overdue TableSet
+}
+
+func newSnapshotAges() *snapshotAges {
+	return &snapshotAges{
+		overdue: NewTableSet(),
+	}
+}
+
+// report makes overdue the tables reported by dax.GaugeSnapshotAgeOverdue.
+func (a *snapshotAges) report(overdue TableSet) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for tkey := range a.overdue {
+		if !overdue.Contains(tkey) {
+			dax.GaugeSnapshotAgeOverdue.DeleteLabelValues(string(tkey))
+		}
+	}
+	for tkey := range overdue {
+		dax.GaugeSnapshotAgeOverdue.WithLabelValues(string(tkey)).Set(1)
+	}
+	a.overdue = overdue
+}
+
+// OverdueSnapshots returns the tables whose latest snapshots were older than
+// their maximum snapshot age as of the last check, and couldn't be replaced.
+func (c *Controller) OverdueSnapshots() dax.TableKeys {
+	c.snapshotAges.mu.Lock()
+	defer c.snapshotAges.mu.Unlock()
+	return c.snapshotAges.overdue.SortedSlice()
+}
+
+// snapshotMaxAge returns the maximum age of the table's snapshots, which is 0
+// if it has none.
+func (c *Controller) snapshotMaxAge(qtbl *dax.QualifiedTable) time.Duration {
+	if qtbl.SnapshotMaxAge > 0 {
+		return qtbl.SnapshotMaxAge
+	}
+	return c.snapshotMaxAgeDefault
+}
+
+func (c *Controller) snapshotAgeRoutine(period time.Duration, log logger.Logger) error {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopping:
+			return nil
+		case <-ticker.C:
+			if err := c.enforceSnapshotAges(time.Now(), log); err != nil {
+				log.Printf("checking snapshot ages: %v", err)
+			}
+		}
+	}
+}
+
+// enforceSnapshotAges snapshots by force the shards of every table whose
+// snapshots are older than its maximum snapshot age at now, whether or not
+// they've been written to since. The snapshots have background priority, so
+// they wait for the maintenance window; tables which can't be snapshotted are
+// reported as overdue until they are.
+func (c *Controller) enforceSnapshotAges(now time.Time, log logger.Logger) error {
+	c.snapMu.Lock()
+	defer c.snapMu.Unlock()
+	if c.snapDrained {
+		return nil
+	}
+
+	tx, err := c.Transactor.BeginTx(context.Background(), false)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	defer tx.Rollback()
+
+	qdbs, err := c.Schemar.Databases(tx, "")
+	if err != nil {
+		return errors.Wrap(err, "getting databases")
+	}
+
+	overdue := NewTableSet()
+	for _, qdb := range qdbs {
+		if err := c.enforceSnapshotAgesForDatabase(tx, qdb.QualifiedID(), now, overdue, log); err != nil {
+			return errors.Wrapf(err, "database %s", qdb.QualifiedID())
+		}
+	}
+	c.snapshotAges.report(overdue)
+	return nil
+}
+
+// enforceSnapshotAgesForDatabase enforces the maximum snapshot ages of the
+// tables in the database, adding those which are still overdue to overdue.
+// Only the shards whose latest snapshot is older than the table's maximum are
+// snapshotted; a shard which has never been snapshotted is always due, since
+// all of its data is in its write log.
+func (c *Controller) enforceSnapshotAgesForDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, now time.Time, overdue TableSet, log logger.Logger) error {
+	qtbls, err := c.Schemar.Tables(tx, qdbid)
+	if err != nil {
+		return errors.Wrap(err, "getting tables")
+	}
+
+	maxAges := make(map[dax.TableKey]time.Duration)
+	for _, qtbl := range qtbls {
+		if maxAge := c.snapshotMaxAge(qtbl); maxAge > 0 {
+			maxAges[qtbl.Key()] = maxAge
+		}
+	}
+	if len(maxAges) == 0 {
+		return nil
+	}
+
+	computeNodes, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
+	if err != nil {
+		return errors.Wrap(err, "getting compute balancer state")
+	}
+	hasMaxAge := func(tkey dax.TableKey) bool {
+		_, ok := maxAges[tkey]
+		return ok
+	}
+
+	var reqs []*dax.SnapshotShardDataRequest
+	due := NewTableSet()
+	for _, req := range shardSnapshotRequests(computeNodes, dax.SnapshotPriorityBackground, hasMaxAge, log) {
+		taken, ok, err := c.latestShardSnapshot(req.TableKey, req.ShardNum)
+		if err != nil {
+			return errors.Wrapf(err, "getting latest snapshot of table %s, shard %d", req.TableKey, req.ShardNum)
+		}
+		if ok && now.Sub(taken) < maxAges[req.TableKey] {
+			continue
+		}
+		req.Force = true
+		reqs = append(reqs, req)
+		due.Add(req.TableKey)
+	}
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	failed, sent := c.sendSnapshotShardDataRequests(tx.Context(), reqs, log)
+	for tkey := range due {
+		if !sent || failed.Contains(tkey) {
+			overdue.Add(tkey)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// databaseTablesSchemar is a tablesSchemar which lists its database and
+// tables.
+type databaseTablesSchemar struct {
+	*tablesSchemar
+}
+
+func (s *databaseTablesSchemar) Databases(tx dax.Transaction, orgID dax.OrganizationID, ids ...dax.DatabaseID) ([]*dax.QualifiedDatabase, error) {
+	return []*dax.QualifiedDatabase{s.qdb}, nil
+}
+
+func (s *databaseTablesSchemar) Tables(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, ids ...dax.TableID) ([]*dax.QualifiedTable, error) {
+	var qtbls []*dax.QualifiedTable
+	for _, qtbl := range s.tables {
+		qtbls = append(qtbls, qtbl)
+	}
+	return qtbls, nil
+}
+
+// snapshotDirector records the shard snapshot requests it's sent, failing
+// those for the tables in fail. The shards of the others are snapshotted at
+// now, as recorded in snaps.
+type snapshotDirector struct {
+	NopDirector
+	mu    sync.Mutex
+	reqs  []*dax.SnapshotShardDataRequest
+	fail  TableSet
+	now   time.Time
+	snaps map[sUnit]time.Time
+}
+
+func (d *snapshotDirector) SendSnapshotShardDataRequest(ctx context.Context, req *dax.SnapshotShardDataRequest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fail.Contains(req.TableKey) {
+		return errors.New(errors.ErrUncoded, "snapshot failed")
+	}
+	d.reqs = append(d.reqs, req)
+	d.snaps[shard(req.TableKey, req.ShardNum)] = d.now
+	return nil
+}
+
+// latestShardSnapshot returns the time at which the shard was snapshotted.
+func (d *snapshotDirector) latestShardSnapshot(tkey dax.TableKey, s dax.ShardNum) (time.Time, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	taken, ok := d.snaps[shard(tkey, s)]
+	return taken, ok, nil
+}
+
+// enforce advances the director's clock to now, and enforces c's snapshot
+// ages at now.
+func (d *snapshotDirector) enforce(t *testing.T, c *Controller, now time.Time) {
+	d.mu.Lock()
+	d.now = now
+	d.mu.Unlock()
+	require.NoError(t, c.enforceSnapshotAges(now, logger.NopLogger))
+}
+
+// shards returns the shards for which requests were sent, and clears them.
+func (d *snapshotDirector) shards(t *testing.T) []sUnit {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var shards []sUnit
+	for _, req := range d.reqs {
+		assert.True(t, req.Force)
+		assert.Equal(t, dax.SnapshotPriorityBackground, req.Priority)
+		shards = append(shards, shard(req.TableKey, req.ShardNum))
+	}
+	d.reqs = nil
+	return shards
+}
+
+func TestSnapshotAges(t *testing.T) {
+	qdb := dax.NewQualifiedDatabase("org", &dax.Database{ID: "db", Name: "db"})
+	quiet := dax.NewQualifiedTable(qdb.QualifiedID(), &dax.Table{ID: "quiet", Name: "quiet", SnapshotMaxAge: time.Hour})
+	dflt := dax.NewQualifiedTable(qdb.QualifiedID(), &dax.Table{ID: "dflt", Name: "dflt"})
+	job := func(qtbl *dax.QualifiedTable, s dax.ShardNum) dax.Job { return shard(qtbl.Key(), s).Job() }
+
+	newController := func(cfg Config, d *snapshotDirector) (*Controller, *databaseTablesSchemar) {
+		s := &databaseTablesSchemar{&tablesSchemar{qdb: qdb, tables: map[dax.TableKey]*dax.QualifiedTable{
+			quiet.Key(): quiet,
+			dflt.Key():  dflt,
+		}}}
+		c := New(cfg)
+		c.Transactor = &countingTransactor{}
+		c.Schemar = s
+		c.Director = d
+		c.latestShardSnapshot = d.latestShardSnapshot
+		c.Balancer = &jobsBalancer{
+			workers: []dax.Address{"a", "b"},
+			jobs: map[dax.Address][]dax.Job{
+				"a": {job(quiet, 0), job(dflt, 0)},
+				"b": {job(quiet, 1)},
+			},
+		}
+		return c, s
+	}
+	newDirector := func() *snapshotDirector {
+		return &snapshotDirector{fail: NewTableSet(), snaps: make(map[sUnit]time.Time)}
+	}
+	now := time.Now()
+
+	t.Run("PerTable", func(t *testing.T) {
+		d := newDirector()
+		c, _ := newController(Config{}, d)
+
+		// Shards which have never been snapshotted are snapshotted straight
+		// away; tables without a maximum aren't.
+		d.enforce(t, c, now)
+		assert.ElementsMatch(t, []sUnit{shard(quiet.Key(), 0), shard(quiet.Key(), 1)}, d.shards(t))
+		assert.Empty(t, c.OverdueSnapshots())
+
+		// Until the maximum age has passed, nothing else is snapshotted.
+		d.enforce(t, c, now.Add(59*time.Minute))
+		assert.Empty(t, d.shards(t))
+
+		// Routine snapshots count towards the age, so only the shard which
+		// hasn't been snapshotted since is due.
+		d.snaps[shard(quiet.Key(), 1)] = now.Add(30 * time.Minute)
+		d.enforce(t, c, now.Add(time.Hour))
+		assert.Equal(t, []sUnit{shard(quiet.Key(), 0)}, d.shards(t))
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		d := newDirector()
+		c, _ := newController(Config{}, d)
+		d.enforce(t, c, now)
+		assert.Len(t, d.shards(t), 2)
+
+		// The ages come from the snapshots themselves, so a new Controller
+		// doesn't snapshot again before the maximum age has passed.
+		c, _ = newController(Config{}, d)
+		d.enforce(t, c, now.Add(time.Minute))
+		assert.Empty(t, d.shards(t))
+		d.enforce(t, c, now.Add(time.Hour))
+		assert.Len(t, d.shards(t), 2)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		d := newDirector()
+		c, _ := newController(Config{SnapshotMaxAge: 24 * time.Hour}, d)
+
+		d.enforce(t, c, now)
+		assert.ElementsMatch(t, []sUnit{shard(quiet.Key(), 0), shard(quiet.Key(), 1), shard(dflt.Key(), 0)}, d.shards(t))
+
+		// A table's own maximum overrides the default.
+		d.enforce(t, c, now.Add(2*time.Hour))
+		assert.ElementsMatch(t, []sUnit{shard(quiet.Key(), 0), shard(quiet.Key(), 1)}, d.shards(t))
+	})
+
+	t.Run("Overdue", func(t *testing.T) {
+		d := newDirector()
+		c, s := newController(Config{}, d)
+		d.fail.Add(quiet.Key())
+
+		d.enforce(t, c, now)
+		assert.Equal(t, dax.TableKeys{quiet.Key()}, c.OverdueSnapshots())
+
+		// Once snapshotted, the table is back in compliance.
+		d.fail = NewTableSet()
+		d.enforce(t, c, now.Add(time.Minute))
+		assert.Empty(t, c.OverdueSnapshots())
+
+		// Dropped tables are forgotten.
+		d.fail.Add(quiet.Key())
+		d.enforce(t, c, now.Add(2*time.Hour))
+		assert.Equal(t, dax.TableKeys{quiet.Key()}, c.OverdueSnapshots())
+		delete(s.tables, quiet.Key())
+		d.enforce(t, c, now.Add(2*time.Hour))
+		assert.Empty(t, c.OverdueSnapshots())
+	})
+
+	t.Run("MaintenanceWindow", func(t *testing.T) {
+		d := newDirector()
+		c, _ := newController(Config{}, d)
+		// A window which is never open.
+		c.maintenance = maintenanceSchedule{{}}
+
+		d.enforce(t, c, now)
+		assert.Empty(t, d.shards(t))
+		assert.Equal(t, dax.TableKeys{quiet.Key()}, c.OverdueSnapshots())
+	})
+}
@@ -248,6 +248,17 @@ func (s *Schemar) CreateTable(tx dax.Transaction, qtbl *dax.QualifiedTable) erro
 		return schemar.NewErrInvalidPrimaryKey()
 	}
 
+	// Ensure that field defaults, computed values and encryption are
+	// supported.
+	for _, fld := range qtbl.Fields {
+		if err := qtbl.ValidateFieldDefault(fld); err != nil {
+			return schemar.NewErrFieldDefaultInvalid(fld.Name, err)
+		}
+		if err := fld.ValidateEncryption(); err != nil {
+			return schemar.NewErrFieldEncryptionInvalid(fld.Name, err)
+		}
+	}
+
 	dt, ok := tx.(*DaxTransaction)
 	if !ok {
 		return dax.NewErrInvalidTransaction("*sqldb.DaxTransaction")
@@ -285,6 +296,7 @@ func toModelTable(qtbl *dax.QualifiedTable) *models.Table {
 		DatabaseID:     string(qtbl.QualifiedDatabaseID.DatabaseID),
 		Description:    qtbl.Description,
 		PartitionN:     qtbl.PartitionN,
+		SnapshotMaxAge: qtbl.SnapshotMaxAge,
 	}
 }
 
@@ -299,6 +311,7 @@ func toModelColumn(tk dax.TableKey, fld *dax.Field) models.Column {
 		TableID:     string(tk),
 		Constraints: "TODO: unimplemented",
 		Options:     string(optBytes),
+		Revision:    fld.Revision,
 	}
 }
 
@@ -309,9 +322,10 @@ func toField(col models.Column) *dax.Field {
 		panic(err)
 	}
 	return &dax.Field{
-		Name:    col.Name,
-		Type:    col.Type,
-		Options: opts,
+		Name:     col.Name,
+		Type:     col.Type,
+		Options:  opts,
+		Revision: col.Revision,
 	}
 }
 
@@ -326,13 +340,14 @@ func toQualifiedTable(mtbl *models.Table) *dax.QualifiedTable {
 			DatabaseID:     dax.DatabaseID(mtbl.DatabaseID),
 		},
 		Table: dax.Table{
-			ID:          dax.TableKey(mtbl.ID).QualifiedTableID().ID,
-			Name:        mtbl.Name,
-			Fields:      fields,
-			PartitionN:  mtbl.PartitionN,
-			Description: mtbl.Description,
-			Owner:       mtbl.Owner,
-			UpdatedBy:   mtbl.UpdatedBy,
+			ID:             dax.TableKey(mtbl.ID).QualifiedTableID().ID,
+			Name:           mtbl.Name,
+			Fields:         fields,
+			PartitionN:     mtbl.PartitionN,
+			SnapshotMaxAge: mtbl.SnapshotMaxAge,
+			Description:    mtbl.Description,
+			Owner:          mtbl.Owner,
+			UpdatedBy:      mtbl.UpdatedBy,
 		},
 	}
 }
@@ -355,6 +370,18 @@ func (s *Schemar) CreateField(tx dax.Transaction, qtid dax.QualifiedTableID, fie
 	if field.Name == "" {
 		return schemar.NewErrFieldNameInvalid(field.Name)
 	}
+	if err := field.ValidateEncryption(); err != nil {
+		return schemar.NewErrFieldEncryptionInvalid(field.Name, err)
+	}
+	if field.Options.Default != "" || field.Options.Computed != "" {
+		qtbl, err := s.Table(tx, qtid)
+		if err != nil {
+			return errors.Wrap(err, "getting table")
+		}
+		if err := qtbl.ValidateFieldDefault(field); err != nil {
+			return schemar.NewErrFieldDefaultInvalid(field.Name, err)
+		}
+	}
 
 	// we could probably make this a single query with an INSERT WHERE
 	// (subselect), but then would have to construct the whole insert
@@ -369,7 +396,13 @@ func (s *Schemar) CreateField(tx dax.Transaction, qtid dax.QualifiedTableID, fie
 		return dax.NewErrFieldExists(field.Name)
 	}
 
+	rev, err := s.bumpRevision(dt, qtid)
+	if err != nil {
+		return errors.Wrap(err, "bumping table revision")
+	}
+
 	col := toModelColumn(qtid.Key(), field)
+	col.Revision = rev
 	err = dt.C.Create(&col)
 	return errors.Wrap(err, "creating column")
 }
@@ -390,11 +423,42 @@ func (s *Schemar) DropField(tx dax.Transaction, qtid dax.QualifiedTableID, field
 		return errors.Wrap(err, "querying for field")
 	}
 
+	// A field whose values are computed from the dropped field would be left
+	// referring to a field which doesn't exist.
+	cols := &models.Columns{}
+	if err := dt.C.Where("table_id = ?", qtid.Key()).All(cols); err != nil {
+		return errors.Wrap(err, "querying for fields")
+	}
+	for _, c := range *cols {
+		if fld := toField(c); fld.Options.Computed == string(fieldName) {
+			return schemar.NewErrFieldInUse(fieldName, fld.Name)
+		}
+	}
+
+	if _, err := s.bumpRevision(dt, qtid); err != nil {
+		return errors.Wrap(err, "bumping table revision")
+	}
+
 	err = dt.C.Destroy(col)
 
 	return errors.Wrap(err, "destroying col")
 }
 
+// bumpRevision increments the schema revision of the table, returning the new
+// revision. Fields added to the table are given the revision at which they
+// were added, so a field which is dropped and added again is distinguished
+// from the original by dax.Table.Version.
+func (s *Schemar) bumpRevision(dt *DaxTransaction, qtid dax.QualifiedTableID) (int64, error) {
+	tbl := &models.Table{}
+	err := dt.C.RawQuery("UPDATE tables SET revision = revision + 1 WHERE id = ? RETURNING revision", qtid.Key()).First(tbl)
+	if isNoRowsError(err) {
+		return 0, dax.NewErrTableIDDoesNotExist(qtid)
+	} else if err != nil {
+		return 0, err
+	}
+	return tbl.Revision, nil
+}
+
 func (s *Schemar) Table(tx dax.Transaction, qtid dax.QualifiedTableID) (*dax.QualifiedTable, error) {
 	dt, ok := tx.(*DaxTransaction)
 	if !ok {
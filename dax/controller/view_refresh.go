@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// DefaultViewRefreshLease is how long the Controller leaves the refreshing of
+// materialized views to the queryer it last granted it to. The queryer renews
+// the lease each time it checks for views due to be refreshed, and while it's
+// refreshing them, both much more often than this; if it stops renewing it
+// (because it has stopped, or can't reach the Controller), another queryer
+// takes over once the lease expires, and the first cancels any refresh it
+// has in progress.
+const DefaultViewRefreshLease = 30 * time.Second
+
+// viewRefreshLease is held by the one queryer which refreshes the
+// materialized views of every database, so that they aren't refreshed by each
+// queryer in turn.
+type viewRefreshLease struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+
+	now func() time.Time
+}
+
+func newViewRefreshLease() *viewRefreshLease {
+	return &viewRefreshLease{
+		now: time.Now,
+	}
+}
+
+// acquire grants the lease to holder for d, or renews it if holder already
+// has it. It returns false if another holder has the lease.
+func (l *viewRefreshLease) acquire(holder string, d time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.holder != "" && l.holder != holder && now.Before(l.expires) {
+		return false
+	}
+	l.holder = holder
+	l.expires = now.Add(d)
+	return true
+}
+
+// ViewRefreshDatabases returns the databases, in every organization, whose
+// materialized views holder should refresh. Only one holder at a time is
+// given any databases; any other gets none until the first stops asking for
+// DefaultViewRefreshLease.
+func (c *Controller) ViewRefreshDatabases(ctx context.Context, holder string) ([]*dax.QualifiedDatabase, error) {
+	if holder == "" {
+		return nil, errors.New(errors.ErrUncoded, "refreshing views requires a holder")
+	}
+	if !c.viewRefreshLease.acquire(holder, DefaultViewRefreshLease) {
+		return nil, nil
+	}
+
+	tx, err := c.Transactor.BeginTx(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "beginning tx")
+	}
+	defer tx.Rollback()
+
+	// An empty organization gets the databases of every organization.
+	return c.Schemar.Databases(tx, "")
+}
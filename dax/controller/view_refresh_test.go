@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/schemar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orgsSchemar is a Schemar holding databases in several organizations. Like
+// the sqldb Schemar, it lists the databases of every organization when it's
+// given an empty one.
+type orgsSchemar struct {
+	schemar.NopSchemar
+	qdbs []*dax.QualifiedDatabase
+}
+
+func (s *orgsSchemar) Databases(tx dax.Transaction, orgID dax.OrganizationID, ids ...dax.DatabaseID) ([]*dax.QualifiedDatabase, error) {
+	var out []*dax.QualifiedDatabase
+	for _, qdb := range s.qdbs {
+		if orgID == "" || qdb.OrganizationID == orgID {
+			out = append(out, qdb)
+		}
+	}
+	return out, nil
+}
+
+func TestViewRefreshDatabases(t *testing.T) {
+	ctx := context.Background()
+	qdbs := []*dax.QualifiedDatabase{
+		{OrganizationID: "org1", Database: dax.Database{ID: "db1", Name: "db1"}},
+		{OrganizationID: "org2", Database: dax.Database{ID: "db2", Name: "db2"}},
+	}
+
+	c := New(Config{})
+	c.Transactor = &countingTransactor{}
+	c.Schemar = &orgsSchemar{qdbs: qdbs}
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.viewRefreshLease.now = func() time.Time { return now }
+
+	// Databases requires an organization, so it can't be used to find the
+	// views of every organization.
+	_, err := c.Databases(ctx, "")
+	require.Error(t, err)
+
+	// The first queryer to ask refreshes the views of every organization.
+	got, err := c.ViewRefreshDatabases(ctx, "q1")
+	require.NoError(t, err)
+	assert.Equal(t, qdbs, got)
+
+	// Others get no databases while it holds the lease.
+	got, err = c.ViewRefreshDatabases(ctx, "q2")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Asking again renews the lease.
+	now = now.Add(DefaultViewRefreshLease / 2)
+	got, err = c.ViewRefreshDatabases(ctx, "q1")
+	require.NoError(t, err)
+	assert.Equal(t, qdbs, got)
+	now = now.Add(DefaultViewRefreshLease / 2)
+	got, err = c.ViewRefreshDatabases(ctx, "q2")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Once the holder stops asking, another queryer takes over.
+	now = now.Add(DefaultViewRefreshLease)
+	got, err = c.ViewRefreshDatabases(ctx, "q2")
+	require.NoError(t, err)
+	assert.Equal(t, qdbs, got)
+	got, err = c.ViewRefreshDatabases(ctx, "q1")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = c.ViewRefreshDatabases(ctx, "")
+	require.Error(t, err)
+}
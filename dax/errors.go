@@ -2,6 +2,7 @@ package dax
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/errors"
 )
@@ -21,12 +22,41 @@ const (
 	ErrTableKeyDoesNotExist  errors.Code = "TableKeyDoesNotExist"
 	ErrTableNameDoesNotExist errors.Code = "TableNameDoesNotExist"
 
+	// ErrTableDropped is the code of the error for a table name which
+	// doesn't exist because the table was dropped, when it can still be
+	// restored from the auto-snapshot taken before the drop. See
+	// IsTableNotFound.
+	ErrTableDropped errors.Code = "TableDropped"
+
 	ErrFieldExists       errors.Code = "FieldExists"
 	ErrFieldDoesNotExist errors.Code = "FieldDoesNotExist"
 
-	ErrInvalidTransaction errors.Code = "InvalidTransaction"
+	ErrInvalidTransaction   errors.Code = "InvalidTransaction"
+	ErrTransactionConflict  errors.Code = "TransactionConflict"
+	ErrTableVersionConflict errors.Code = "TableVersionConflict"
 
 	ErrUnimplemented errors.Code = "Unimplemented"
+
+	ErrDraining errors.Code = "Draining"
+	ErrReadOnly errors.Code = "ReadOnly"
+
+	ErrInsufficientStorage errors.Code = "InsufficientStorage"
+
+	ErrStatementExists       errors.Code = "StatementExists"
+	ErrStatementDoesNotExist errors.Code = "StatementDoesNotExist"
+	ErrStatementInvalidated  errors.Code = "StatementInvalidated"
+	ErrStatementRegistryFull errors.Code = "StatementRegistryFull"
+	ErrStatementParameter    errors.Code = "StatementParameter"
+
+	ErrCircuitOpen errors.Code = "CircuitOpen"
+
+	ErrQueryTooLarge errors.Code = "QueryTooLarge"
+
+	ErrInvalidQueryLabels errors.Code = "InvalidQueryLabels"
+
+	ErrInvalidSnapshotPriority errors.Code = "InvalidSnapshotPriority"
+
+	ErrEncryptedField errors.Code = "EncryptedField"
 )
 
 // The following are helper functions for constructing coded errors containing
@@ -88,6 +118,22 @@ func NewErrTableNameDoesNotExist(tableName TableName) error {
 	)
 }
 
+func NewErrTableDropped(tableName TableName, dropped time.Time, autoSnapshotID string) error {
+	return errors.New(
+		ErrTableDropped,
+		fmt.Sprintf("table '%s' was dropped at %s, and can be restored from auto-snapshot '%s' until it's deleted", tableName, dropped.UTC().Format(time.RFC3339), autoSnapshotID),
+	)
+}
+
+// IsTableNotFound returns whether err is an error for a table which doesn't
+// exist, whether it never did or it was dropped.
+func IsTableNotFound(err error) bool {
+	return errors.Is(err, ErrTableNameDoesNotExist) ||
+		errors.Is(err, ErrTableIDDoesNotExist) ||
+		errors.Is(err, ErrTableKeyDoesNotExist) ||
+		errors.Is(err, ErrTableDropped)
+}
+
 func NewErrTableIDExists(qtid QualifiedTableID) error {
 	return errors.New(
 		ErrTableIDExists,
@@ -129,3 +175,109 @@ func NewErrInvalidTransaction(txType string) error {
 		fmt.Sprintf("tx is not expected type: '%s'", txType),
 	)
 }
+
+func NewErrTransactionConflict(msg string) error {
+	return errors.New(
+		ErrTransactionConflict,
+		fmt.Sprintf("transaction conflicted with a concurrent transaction: %s", msg),
+	)
+}
+
+func NewErrTableVersionConflict(qtid QualifiedTableID, expected string, current string) error {
+	return errors.New(
+		ErrTableVersionConflict,
+		fmt.Sprintf("table '%s' is at version '%s', not the expected version '%s'", qtid, current, expected),
+	)
+}
+
+func NewErrDraining(svc string) error {
+	return errors.New(
+		ErrDraining,
+		fmt.Sprintf("%s is draining and not accepting new requests", svc),
+	)
+}
+
+func NewErrReadOnly(svc string) error {
+	return errors.New(
+		ErrReadOnly,
+		fmt.Sprintf("%s is in read-only mode and not accepting writes", svc),
+	)
+}
+
+func NewErrInsufficientStorage(dir string, free uint64, headroom uint64) error {
+	return errors.New(
+		ErrInsufficientStorage,
+		fmt.Sprintf("insufficient storage in '%s': %d bytes free, %d bytes reserved", dir, free, headroom),
+	)
+}
+
+func NewErrStatementExists(name string) error {
+	return errors.New(
+		ErrStatementExists,
+		fmt.Sprintf("statement '%s' already exists", name),
+	)
+}
+
+func NewErrStatementDoesNotExist(name string) error {
+	return errors.New(
+		ErrStatementDoesNotExist,
+		fmt.Sprintf("statement '%s' does not exist", name),
+	)
+}
+
+func NewErrStatementInvalidated(name string, tableName TableName) error {
+	return errors.New(
+		ErrStatementInvalidated,
+		fmt.Sprintf("statement '%s' was invalidated by a schema change to table '%s'", name, tableName),
+	)
+}
+
+func NewErrStatementRegistryFull(max int) error {
+	return errors.New(
+		ErrStatementRegistryFull,
+		fmt.Sprintf("statement registry is full (max: %d)", max),
+	)
+}
+
+func NewErrStatementParameter(name string, msg string) error {
+	return errors.New(
+		ErrStatementParameter,
+		fmt.Sprintf("statement parameter '%s': %s", name, msg),
+	)
+}
+
+func NewErrCircuitOpen(addr Address, retryIn time.Duration) error {
+	msg := fmt.Sprintf("circuit breaker for computer '%s' is open", addr)
+	if retryIn > 0 {
+		msg += fmt.Sprintf("; retrying in %s", retryIn.Round(time.Millisecond))
+	}
+	return errors.New(ErrCircuitOpen, msg)
+}
+
+func NewErrQueryTooLarge(max int64) error {
+	return errors.New(
+		ErrQueryTooLarge,
+		fmt.Sprintf("query text exceeds the maximum size of %d bytes; use a named statement with parameters, or BULK INSERT, instead of generating large queries", max),
+	)
+}
+
+func NewErrInvalidQueryLabels(reason string) error {
+	return errors.New(
+		ErrInvalidQueryLabels,
+		fmt.Sprintf("invalid query labels: %s", reason),
+	)
+}
+
+func NewErrInvalidSnapshotPriority(p SnapshotPriority) error {
+	return errors.New(
+		ErrInvalidSnapshotPriority,
+		fmt.Sprintf("invalid snapshot priority '%s': must be '%s' or '%s'", p, SnapshotPriorityUrgent, SnapshotPriorityBackground),
+	)
+}
+
+func NewErrEncryptedField(fieldName FieldName, reason string) error {
+	return errors.New(
+		ErrEncryptedField,
+		fmt.Sprintf("field '%s' is encrypted: %s", fieldName, reason),
+	)
+}
@@ -2,11 +2,14 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"net"
 	"net/http"
 	"runtime/debug"
+	"sync"
 	"time"
 
+	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/controller"
 	"github.com/featurebasedb/featurebase/v3/dax/queryer"
 	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
@@ -27,6 +30,13 @@ type Handler struct {
 
 	closeTimeout time.Duration
 
+	// shutdownOrder is the order in which the drainers are called during
+	// shutdown. Each stage is given shutdownStageTimeout to complete.
+	shutdownOrder        []string
+	shutdownStageTimeout time.Duration
+	drainers             map[string]dax.Drainer
+	drainOnce            sync.Once
+
 	server *http.Server
 
 	controller  *controller.Controller
@@ -36,6 +46,9 @@ type Handler struct {
 
 	computer http.Handler
 
+	// openAPI, if set, generates the document served at /openapi.json.
+	openAPI func() (*dax.OpenAPIDocument, error)
+
 	logger logger.Logger
 }
 
@@ -52,6 +65,7 @@ func OptHandlerBind(b string) HandlerOption {
 func OptHandlerController(c *controller.Controller) HandlerOption {
 	return func(h *Handler) error {
 		h.controller = c
+		h.drainers[dax.ServicePrefixController] = c
 		return nil
 	}
 }
@@ -59,6 +73,7 @@ func OptHandlerController(c *controller.Controller) HandlerOption {
 func OptHandlerWritelogger(w *writelogger.Writelogger) HandlerOption {
 	return func(h *Handler) error {
 		h.writeLogger = w
+		h.drainers[dax.ServicePrefixWritelogger] = w
 		return nil
 	}
 }
@@ -66,6 +81,7 @@ func OptHandlerWritelogger(w *writelogger.Writelogger) HandlerOption {
 func OptHandlerSnapshotter(s *snapshotter.Snapshotter) HandlerOption {
 	return func(h *Handler) error {
 		h.snapshotter = s
+		h.drainers[dax.ServicePrefixSnapshotter] = s
 		return nil
 	}
 }
@@ -73,6 +89,42 @@ func OptHandlerSnapshotter(s *snapshotter.Snapshotter) HandlerOption {
 func OptHandlerQueryer(q *queryer.Queryer) HandlerOption {
 	return func(h *Handler) error {
 		h.queryer = q
+		h.drainers[dax.ServicePrefixQueryer] = q
+		return nil
+	}
+}
+
+// OptHandlerDrainer registers d to be drained during the shutdown stage
+// identified by stage (typically one of the dax.ServicePrefix* values).
+// Registering a drainer for a stage replaces any drainer previously registered
+// for that stage.
+func OptHandlerDrainer(stage string, d dax.Drainer) HandlerOption {
+	return func(h *Handler) error {
+		h.drainers[stage] = d
+		return nil
+	}
+}
+
+// OptHandlerShutdownOrder sets the order in which the shutdown stages are
+// drained. Stages which have no registered drainer are skipped. The default
+// order is DefaultShutdownOrder.
+func OptHandlerShutdownOrder(stages ...string) HandlerOption {
+	return func(h *Handler) error {
+		if len(stages) > 0 {
+			h.shutdownOrder = stages
+		}
+		return nil
+	}
+}
+
+// OptHandlerShutdownStageTimeout controls how long each shutdown stage is
+// given to drain before shutdown moves on to the next stage. Default is 10
+// seconds.
+func OptHandlerShutdownStageTimeout(d time.Duration) HandlerOption {
+	return func(h *Handler) error {
+		if d > 0 {
+			h.shutdownStageTimeout = d
+		}
 		return nil
 	}
 }
@@ -104,6 +156,16 @@ func OptHandlerListener(ln net.Listener, url string) HandlerOption {
 	}
 }
 
+// OptHandlerOpenAPI enables serving an OpenAPI document describing the API at
+// /openapi.json. The document is generated by fn on each request, so that it
+// reflects the services which are running at the time.
+func OptHandlerOpenAPI(fn func() (*dax.OpenAPIDocument, error)) HandlerOption {
+	return func(h *Handler) error {
+		h.openAPI = fn
+		return nil
+	}
+}
+
 func OptHandlerComputer(handler http.Handler) HandlerOption {
 	return func(h *Handler) error {
 		h.computer = handler
@@ -111,11 +173,24 @@ func OptHandlerComputer(handler http.Handler) HandlerOption {
 	}
 }
 
+// DefaultShutdownOrder is the order in which subservices are drained during
+// shutdown: first stop accepting queries, then finish in-flight writes and
+// flush the write log, then finalize any snapshots.
+var DefaultShutdownOrder = []string{
+	dax.ServicePrefixQueryer,
+	dax.ServicePrefixWritelogger,
+	dax.ServicePrefixSnapshotter,
+	dax.ServicePrefixController,
+}
+
 // NewHandler returns a new instance of Handler with a default logger.
 func NewHandler(router http.Handler, opts ...HandlerOption) (*Handler, error) {
 	handler := &Handler{
-		logger:       logger.NopLogger,
-		closeTimeout: time.Second * 30,
+		logger:               logger.NopLogger,
+		closeTimeout:         time.Second * 30,
+		shutdownOrder:        DefaultShutdownOrder,
+		shutdownStageTimeout: time.Second * 10,
+		drainers:             make(map[string]dax.Drainer),
 	}
 
 	for _, opt := range opts {
@@ -141,9 +216,37 @@ func (h *Handler) Serve() error {
 	return nil
 }
 
-// Close tries to cleanly shutdown the HTTP server, and failing that, after a
+// Drain calls Drain on each registered drainer, in shutdown order. Each stage
+// is given the configured stage timeout; a stage which fails or times out is
+// logged, and shutdown moves on to the next stage. Drain only runs once;
+// subsequent calls are no-ops.
+func (h *Handler) Drain() {
+	h.drainOnce.Do(func() {
+		for _, stage := range h.shutdownOrder {
+			d, ok := h.drainers[stage]
+			if !ok {
+				continue
+			}
+
+			start := time.Now()
+			ctx, cancel := context.WithTimeout(context.Background(), h.shutdownStageTimeout)
+			err := d.Drain(ctx)
+			cancel()
+			if err != nil {
+				h.logger.Warnf("shutdown stage %s did not complete after %s: %v", stage, time.Since(start), err)
+				continue
+			}
+			h.logger.Printf("shutdown stage %s complete: %s", stage, time.Since(start))
+		}
+	})
+}
+
+// Close drains all subservices (if that hasn't already been done) and then
+// tries to cleanly shutdown the HTTP server, and failing that, after a
 // timeout, calls Server.Close.
 func (h *Handler) Close() error {
+	h.Drain()
+
 	deadlineCtx, cancelFunc := context.WithDeadline(context.Background(), time.Now().Add(h.closeTimeout))
 	defer cancelFunc()
 	err := h.server.Shutdown(deadlineCtx)
@@ -163,9 +266,28 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	if h.openAPI != nil && r.URL.Path == "/openapi.json" && r.Method == http.MethodGet {
+		h.handleGetOpenAPI(w, r)
+		return
+	}
+
 	h.Handler.ServeHTTP(w, r)
 }
 
+// GET /openapi.json
+func (h *Handler) handleGetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := h.openAPI()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		h.logger.Printf("encoding openapi document: %v", err)
+	}
+}
+
 // GET /health
 func (h *Handler) handleGetHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,304 @@
+package dax
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	MetricWriteloggerDiskUsedBytes     = "writelogger_disk_used_bytes"
+	MetricWriteloggerDiskFreeBytes     = "writelogger_disk_free_bytes"
+	MetricWriteloggerDiskHeadroomBytes = "writelogger_disk_headroom_bytes"
+	MetricWriteloggerRejectedAppends   = "writelogger_rejected_appends_total"
+	MetricWriteloggerRetainedLogs      = "writelogger_retained_logs"
+	MetricWriteloggerTruncatedLogs     = "writelogger_truncated_logs_total"
+	MetricTxConflicts                  = "tx_conflicts_total"
+	MetricTxRetries                    = "tx_retries_total"
+	MetricQueryStageDurationSeconds    = "query_stage_duration_seconds"
+	MetricComputerBreakerState         = "computer_breaker_state"
+	MetricQueryTooLargeRejections      = "query_too_large_rejections_total"
+	MetricSnapshotterStagingBytes      = "snapshotter_staging_bytes"
+	MetricSnapshotterStagingAborts     = "snapshotter_staging_aborts_total"
+	MetricLabeledQueryDurationSeconds  = "labeled_query_duration_seconds"
+	MetricServiceAuthFailures          = "service_auth_failures_total"
+	MetricQueryerExports               = "queryer_exports_total"
+	MetricQueryerExportBytes           = "queryer_export_bytes_total"
+	MetricUnderReplicatedShards        = "under_replicated_shards"
+	MetricComputerOpenConns            = "computer_open_conns"
+	MetricComputerIdleConns            = "computer_idle_conns"
+	MetricPrewarmedConns               = "prewarmed_conns_total"
+	MetricHealthChecksCoalesced        = "health_checks_coalesced_total"
+	MetricControllerFailovers          = "controller_failovers_total"
+	MetricSnapshotAgeOverdue           = "snapshot_age_overdue"
+)
+
+var GaugeWriteloggerDiskUsedBytes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricWriteloggerDiskUsedBytes,
+		Help:      "Bytes used on the disk holding the writelogger data directory.",
+	},
+)
+
+var GaugeWriteloggerDiskFreeBytes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricWriteloggerDiskFreeBytes,
+		Help:      "Bytes available on the disk holding the writelogger data directory.",
+	},
+)
+
+var GaugeWriteloggerDiskHeadroomBytes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricWriteloggerDiskHeadroomBytes,
+		Help:      "Bytes available above the writelogger's reserved headroom; negative when writes are being rejected.",
+	},
+)
+
+var CounterWriteloggerRejectedAppends = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricWriteloggerRejectedAppends,
+		Help:      "Appends rejected by the writelogger due to insufficient disk space.",
+	},
+)
+
+var GaugeWriteloggerRetainedLogs = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricWriteloggerRetainedLogs,
+		Help:      "Write logs kept by the writelogger, as of its latest truncation sweep.",
+	},
+)
+
+var CounterWriteloggerTruncatedLogs = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricWriteloggerTruncatedLogs,
+		Help:      "Write logs deleted by the writelogger once covered by a snapshot.",
+	},
+)
+
+var CounterTxConflicts = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricTxConflicts,
+		Help:      "Transactions which failed due to a conflict with a concurrent transaction.",
+	},
+)
+
+var CounterTxRetries = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricTxRetries,
+		Help:      "Transactions which were retried after a conflict.",
+	},
+)
+
+// HistogramQueryStageDurationSeconds records the time spent in each stage of
+// query processing, labeled by stage (one of the QueryStage* values). The
+// network and compute stages are observed once per request to a compute node;
+// the others once per query (or, for merge, once per fan-out).
+var HistogramQueryStageDurationSeconds = newHistogramQueryStageDurationSeconds(DefaultQueryStageDurationBuckets)
+
+// DefaultQueryStageDurationBuckets are the default buckets of
+// HistogramQueryStageDurationSeconds, from 100µs to about 26s.
+var DefaultQueryStageDurationBuckets = prometheus.ExponentialBuckets(0.0001, 4, 10)
+
+func newHistogramQueryStageDurationSeconds(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "dax",
+			Name:      MetricQueryStageDurationSeconds,
+			Help:      "Time spent in each stage of query processing.",
+			Buckets:   buckets,
+		},
+		[]string{"stage"},
+	)
+}
+
+// GaugeComputerBreakerState is the state of the queryer's circuit breaker for
+// each computer, labeled by computer address: 0 is closed, 1 is half-open, and
+// 2 is open.
+var GaugeComputerBreakerState = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricComputerBreakerState,
+		Help:      "State of the circuit breaker for a computer (0: closed, 1: half-open, 2: open).",
+	},
+	[]string{"computer"},
+)
+
+// GaugeComputerOpenConns and GaugeComputerIdleConns are the numbers of
+// connections from the queryer to each computer which are open, and which of
+// those are idle, labeled by the computer's host and port.
+var GaugeComputerOpenConns = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricComputerOpenConns,
+		Help:      "Open connections from the queryer to a computer.",
+	},
+	[]string{"computer"},
+)
+
+var GaugeComputerIdleConns = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricComputerIdleConns,
+		Help:      "Idle connections from the queryer to a computer.",
+	},
+	[]string{"computer"},
+)
+
+var CounterPrewarmedConns = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricPrewarmedConns,
+		Help:      "Connections to computers opened by the queryer to keep them pre-warmed.",
+	},
+)
+
+var CounterQueryTooLargeRejections = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricQueryTooLargeRejections,
+		Help:      "Queries rejected by the queryer because their text exceeded the maximum size.",
+	},
+)
+
+var GaugeSnapshotterStagingBytes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricSnapshotterStagingBytes,
+		Help:      "Bytes of snapshots currently being written to the snapshotter staging directory.",
+	},
+)
+
+var GaugeUnderReplicatedShards = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricUnderReplicatedShards,
+		Help:      "Shards which aren't assigned to a compute node, or whose compute node is down or hasn't been probed recently.",
+	},
+)
+
+var CounterSnapshotterStagingAborts = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricSnapshotterStagingAborts,
+		Help:      "Snapshots rejected or aborted by the snapshotter due to insufficient disk space.",
+	},
+)
+
+// HistogramLabeledQueryDurationSeconds records the duration of queries which
+// carry labels (see HeaderQueryLabels), once for each label which the queryer's
+// label policy permits to be recorded. The policy bounds the cardinality of the
+// label and value dimensions.
+var HistogramLabeledQueryDurationSeconds = newHistogramLabeledQueryDurationSeconds(DefaultLabeledQueryDurationBuckets)
+
+// DefaultLabeledQueryDurationBuckets are the default buckets of
+// HistogramLabeledQueryDurationSeconds, from 1ms to about 16s.
+var DefaultLabeledQueryDurationBuckets = prometheus.ExponentialBuckets(0.001, 4, 8)
+
+func newHistogramLabeledQueryDurationSeconds(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "dax",
+			Name:      MetricLabeledQueryDurationSeconds,
+			Help:      "Duration of queries, by query label.",
+			Buckets:   buckets,
+		},
+		[]string{"label", "value"},
+	)
+}
+
+// CounterServiceAuthFailures counts the requests from other services which
+// were rejected for want of a valid service token, labeled by reason:
+// "missing", "invalid" or "expired".
+var CounterServiceAuthFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricServiceAuthFailures,
+		Help:      "Number of inter-service requests rejected for want of a valid service token.",
+	},
+	[]string{"reason"},
+)
+
+// CounterQueryerExports counts the query results exported by the queryer to
+// object storage, labeled by format and by result: "success", "query_error"
+// (the query failed, so nothing was written) or "store_error" (writing the
+// object failed, and it was discarded).
+var CounterQueryerExports = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricQueryerExports,
+		Help:      "Number of query results exported to object storage.",
+	},
+	[]string{"format", "result"},
+)
+
+var CounterQueryerExportBytes = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricQueryerExportBytes,
+		Help:      "Bytes of query results successfully exported to object storage.",
+	},
+)
+
+// CounterHealthChecksCoalesced counts the health checks which shared the
+// result of a probe already in progress, rather than probing the services
+// themselves.
+var CounterHealthChecksCoalesced = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricHealthChecksCoalesced,
+		Help:      "Number of health checks which shared the result of a probe already in progress.",
+	},
+)
+
+// CounterControllerFailovers counts the times a client of several Controller
+// replicas has moved from one to another.
+var CounterControllerFailovers = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "dax",
+		Name:      MetricControllerFailovers,
+		Help:      "Number of times a client has failed over from one Controller replica to another.",
+	},
+)
+
+// GaugeSnapshotAgeOverdue is 1 for each table, labeled by table key, whose
+// latest shard snapshots are older than its maximum snapshot age and couldn't
+// be replaced. Tables are removed once they're back in compliance.
+var GaugeSnapshotAgeOverdue = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "dax",
+		Name:      MetricSnapshotAgeOverdue,
+		Help:      "Tables whose latest snapshots are older than their maximum snapshot age (1 for each such table).",
+	},
+	[]string{"table"},
+)
+
+func init() {
+	prometheus.MustRegister(GaugeWriteloggerDiskUsedBytes)
+	prometheus.MustRegister(GaugeWriteloggerDiskFreeBytes)
+	prometheus.MustRegister(GaugeWriteloggerDiskHeadroomBytes)
+	prometheus.MustRegister(CounterWriteloggerRejectedAppends)
+	prometheus.MustRegister(GaugeWriteloggerRetainedLogs)
+	prometheus.MustRegister(CounterWriteloggerTruncatedLogs)
+	prometheus.MustRegister(CounterTxConflicts)
+	prometheus.MustRegister(CounterServiceAuthFailures)
+	prometheus.MustRegister(CounterTxRetries)
+	prometheus.MustRegister(HistogramQueryStageDurationSeconds)
+	prometheus.MustRegister(GaugeComputerBreakerState)
+	prometheus.MustRegister(GaugeComputerOpenConns)
+	prometheus.MustRegister(GaugeComputerIdleConns)
+	prometheus.MustRegister(CounterPrewarmedConns)
+	prometheus.MustRegister(CounterQueryTooLargeRejections)
+	prometheus.MustRegister(GaugeSnapshotterStagingBytes)
+	prometheus.MustRegister(CounterSnapshotterStagingAborts)
+	prometheus.MustRegister(GaugeUnderReplicatedShards)
+	prometheus.MustRegister(HistogramLabeledQueryDurationSeconds)
+	prometheus.MustRegister(CounterQueryerExports)
+	prometheus.MustRegister(CounterQueryerExportBytes)
+	prometheus.MustRegister(CounterHealthChecksCoalesced)
+	prometheus.MustRegister(CounterControllerFailovers)
+	prometheus.MustRegister(GaugeSnapshotAgeOverdue)
+}
@@ -0,0 +1,86 @@
+package dax
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MaxHistogramBuckets is the most buckets which may be configured for a
+// histogram with ConfigureHistogramBuckets.
+//
+// Every bucket is exported as its own time series, for every combination of
+// the histogram's label values, so a histogram with n buckets costs n+2 series
+// (the buckets, plus the sum and count) per label combination. More buckets
+// give more precise percentiles near their boundaries, but multiply the
+// cardinality of the metric: for example, dax_labeled_query_duration_seconds
+// already has a series per permitted label value. It's usually better to
+// place a few buckets tightly around the thresholds which are alerted on (for
+// example, 0.04, 0.05, and 0.06 for a 50ms SLO) than to add buckets uniformly.
+const MaxHistogramBuckets = 30
+
+// ConfigureHistogramBuckets replaces the buckets of DAX latency histograms.
+// Each entry is of the form "<metric>=<upper-bound> <upper-bound> ...", where
+// metric is one of MetricQueryStageDurationSeconds or
+// MetricLabeledQueryDurationSeconds (optionally with its "dax_" namespace),
+// and the upper bounds, in seconds, are strictly increasing. Histograms which
+// aren't named keep their default buckets.
+//
+// Since replacing a histogram discards what it has recorded, this must be
+// called at startup, before any of the histograms are observed.
+func ConfigureHistogramBuckets(entries []string) error {
+	for _, entry := range entries {
+		i := strings.IndexByte(entry, '=')
+		if i <= 0 {
+			return errors.Errorf("invalid histogram buckets '%s': expected <metric>=<upper-bound> <upper-bound> ...", entry)
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(entry[:i]), "dax_")
+		buckets, err := parseHistogramBuckets(entry[i+1:])
+		if err != nil {
+			return errors.Wrapf(err, "invalid histogram buckets for '%s'", name)
+		}
+
+		switch name {
+		case MetricQueryStageDurationSeconds:
+			HistogramQueryStageDurationSeconds = replaceHistogram(HistogramQueryStageDurationSeconds, newHistogramQueryStageDurationSeconds(buckets))
+		case MetricLabeledQueryDurationSeconds:
+			HistogramLabeledQueryDurationSeconds = replaceHistogram(HistogramLabeledQueryDurationSeconds, newHistogramLabeledQueryDurationSeconds(buckets))
+		default:
+			return errors.Errorf("invalid histogram buckets '%s': unknown histogram '%s'", entry, name)
+		}
+	}
+	return nil
+}
+
+// parseHistogramBuckets parses a space-separated list of bucket upper bounds.
+func parseHistogramBuckets(s string) ([]float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.Errorf("no buckets given")
+	} else if len(fields) > MaxHistogramBuckets {
+		return nil, errors.Errorf("%d buckets given, but at most %d are allowed", len(fields), MaxHistogramBuckets)
+	}
+
+	buckets := make([]float64, len(fields))
+	for i, f := range fields {
+		b, err := strconv.ParseFloat(f, 64)
+		if err != nil || b <= 0 || math.IsInf(b, 0) || math.IsNaN(b) {
+			return nil, errors.Errorf("bucket '%s' is not a positive number", f)
+		}
+		if i > 0 && b <= buckets[i-1] {
+			return nil, errors.Errorf("buckets must be strictly increasing, but %v follows %v", b, buckets[i-1])
+		}
+		buckets[i] = b
+	}
+	return buckets, nil
+}
+
+// replaceHistogram registers next in place of old, and returns next.
+func replaceHistogram(old, next *prometheus.HistogramVec) *prometheus.HistogramVec {
+	prometheus.Unregister(old)
+	prometheus.MustRegister(next)
+	return next
+}
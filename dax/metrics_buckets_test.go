@@ -0,0 +1,81 @@
+package dax_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureHistogramBuckets(t *testing.T) {
+	upperBounds := func(vec *prometheus.HistogramVec, labels ...string) []float64 {
+		var m dto.Metric
+		require.NoError(t, vec.WithLabelValues(labels...).(prometheus.Histogram).Write(&m))
+		var bounds []float64
+		for _, b := range m.GetHistogram().GetBucket() {
+			bounds = append(bounds, b.GetUpperBound())
+		}
+		return bounds
+	}
+	defer func() {
+		require.NoError(t, dax.ConfigureHistogramBuckets([]string{
+			dax.MetricQueryStageDurationSeconds + "=" + formatBuckets(dax.DefaultQueryStageDurationBuckets),
+			dax.MetricLabeledQueryDurationSeconds + "=" + formatBuckets(dax.DefaultLabeledQueryDurationBuckets),
+		}))
+	}()
+
+	assert.Equal(t, dax.DefaultQueryStageDurationBuckets, upperBounds(dax.HistogramQueryStageDurationSeconds, dax.QueryStagePlan))
+
+	require.NoError(t, dax.ConfigureHistogramBuckets([]string{
+		"query_stage_duration_seconds=0.04 0.05 0.06 0.2",
+		"dax_labeled_query_duration_seconds= 0.1  1 ",
+	}))
+	assert.Equal(t, []float64{0.04, 0.05, 0.06, 0.2}, upperBounds(dax.HistogramQueryStageDurationSeconds, dax.QueryStagePlan))
+	assert.Equal(t, []float64{0.1, 1}, upperBounds(dax.HistogramLabeledQueryDurationSeconds, "l", "v"))
+
+	// The replacement is what's registered, and what's observed.
+	dax.ObserveQueryStage(context.Background(), dax.QueryStagePlan, 45*time.Millisecond)
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	var found bool
+	for _, f := range families {
+		if f.GetName() != "dax_"+dax.MetricQueryStageDurationSeconds {
+			continue
+		}
+		found = true
+		h := f.GetMetric()[0].GetHistogram()
+		assert.Equal(t, uint64(1), h.GetSampleCount())
+		assert.Equal(t, uint64(0), h.GetBucket()[0].GetCumulativeCount())
+		assert.Equal(t, uint64(1), h.GetBucket()[1].GetCumulativeCount())
+	}
+	assert.True(t, found)
+
+	for _, entry := range []string{
+		"query_stage_duration_seconds",
+		"query_stage_duration_seconds=",
+		"query_stage_duration_seconds=0.2 0.1",
+		"query_stage_duration_seconds=0.1 0.1",
+		"query_stage_duration_seconds=-1",
+		"query_stage_duration_seconds=fast",
+		"tx_conflicts_total=0.1",
+	} {
+		assert.Error(t, dax.ConfigureHistogramBuckets([]string{entry}), entry)
+	}
+}
+
+func formatBuckets(buckets []float64) string {
+	s := ""
+	for i, b := range buckets {
+		if i > 0 {
+			s += " "
+		}
+		s += strconv.FormatFloat(b, 'g', -1, 64)
+	}
+	return s
+}
@@ -19,6 +19,7 @@ type Column struct {
 	TableID     string        `json:"table_id" db:"table_id"`
 	Constraints string        `json:"constraints" db:"constraints"`
 	Options     string        `json:"options" db:"options"`
+	Revision    int64         `json:"revision" db:"revision"`
 	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
 }
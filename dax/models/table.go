@@ -22,6 +22,8 @@ type Table struct {
 	OrganizationID dax.OrganizationID `json:"organization_id" db:"organization_id"`
 	Description    string             `json:"description" db:"description"`
 	PartitionN     int                `json:"partition_n" db:"partition_n"`
+	SnapshotMaxAge time.Duration      `json:"snapshot_max_age" db:"snapshot_max_age"`
+	Revision       int64              `json:"revision" db:"revision"`
 	Columns        Columns            `json:"columns" has_many:"columns" order_by:"created_at asc"`
 	CreatedAt      time.Time          `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at" db:"updated_at"`
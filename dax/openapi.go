@@ -0,0 +1,368 @@
+package dax
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenAPIDocument is a (partial) OpenAPI 3.0 description of the http API
+// served by a process. Paths are discovered by walking the routers of the
+// services which are running; request and response schemas are included for
+// the routes which a service has annotated (see OpenAPIAnnotation).
+type OpenAPIDocument struct {
+	OpenAPI string                     `json:"openapi"`
+	Info    OpenAPIInfo                `json:"info"`
+	Tags    []OpenAPITag               `json:"tags,omitempty"`
+	Paths   map[string]OpenAPIPathItem `json:"paths"`
+}
+
+// OpenAPIInfo is the metadata of an OpenAPIDocument.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPITag groups the operations of a single service. Its name is the
+// service prefix under which the service's routes are mounted.
+type OpenAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem maps a (lower-case) http method to an operation.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes a single method on a path.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a path or query parameter.
+type OpenAPIParameter struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required"`
+	Schema      OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIQueryParameter returns an optional query parameter of the given JSON
+// schema type.
+func OpenAPIQueryParameter(name, typ, description string) OpenAPIParameter {
+	return OpenAPIParameter{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Schema:      OpenAPISchema{"type": typ},
+	}
+}
+
+// OpenAPIRequestBody describes the body of a request.
+type OpenAPIRequestBody struct {
+	Content map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes a response.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema of a request or response body.
+type OpenAPIMediaType struct {
+	Schema OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema is a JSON schema.
+type OpenAPISchema map[string]interface{}
+
+// OpenAPIAnnotation describes a route beyond what can be discovered from the
+// router. Request and Response, if non-nil, are values of the types which are
+// decoded from the request body and encoded to the response body; their
+// schemas are derived from their json struct tags. Parameters are the route's
+// query parameters; its path parameters are discovered from the router.
+type OpenAPIAnnotation struct {
+	Summary    string
+	Parameters []OpenAPIParameter
+	Request    interface{}
+	Response   interface{}
+}
+
+// openAPIAnnotator is implemented by services which annotate their routes.
+// Annotations are keyed by route name.
+type openAPIAnnotator interface {
+	OpenAPIAnnotations() map[string]OpenAPIAnnotation
+}
+
+// NewOpenAPIDocument returns an OpenAPIDocument with no paths.
+func NewOpenAPIDocument(title, version string) *OpenAPIDocument {
+	if version == "" {
+		version = "unknown"
+	}
+	return &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:   title,
+			Version: version,
+		},
+		Tags:  make([]OpenAPITag, 0),
+		Paths: make(map[string]OpenAPIPathItem),
+	}
+}
+
+// AddRoutes adds the routes of handler, mounted under prefix, to the document.
+// Operations are tagged with tag (if not empty). Only routes of a *mux.Router
+// can be discovered; for any other handler, AddRoutes does nothing.
+func (d *OpenAPIDocument) AddRoutes(prefix string, tag string, handler http.Handler, annotations map[string]OpenAPIAnnotation) error {
+	router, ok := handler.(*mux.Router)
+	if !ok {
+		return nil
+	}
+
+	return router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			// PathPrefix-only routes (for example, pprof) have no template.
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+
+		path := prefix + tmpl
+		item, ok := d.Paths[path]
+		if !ok {
+			item = make(OpenAPIPathItem)
+			d.Paths[path] = item
+		}
+
+		name := route.GetName()
+		ann := annotations[name]
+		for _, method := range methods {
+			op := &OpenAPIOperation{
+				OperationID: operationID(tag, name, method, tmpl),
+				Summary:     ann.Summary,
+				Parameters:  append(pathParameters(tmpl), ann.Parameters...),
+				Responses: map[string]OpenAPIResponse{
+					"200": {Description: "OK"},
+				},
+			}
+			if tag != "" {
+				op.Tags = []string{tag}
+			}
+			if ann.Request != nil {
+				op.RequestBody = &OpenAPIRequestBody{
+					Content: map[string]OpenAPIMediaType{
+						"application/json": {Schema: openAPISchemaOf(reflect.TypeOf(ann.Request), nil)},
+					},
+				}
+			}
+			if ann.Response != nil {
+				op.Responses["200"] = OpenAPIResponse{
+					Description: "OK",
+					Content: map[string]OpenAPIMediaType{
+						"application/json": {Schema: openAPISchemaOf(reflect.TypeOf(ann.Response), nil)},
+					},
+				}
+			}
+			item[strings.ToLower(method)] = op
+		}
+		return nil
+	})
+}
+
+// AddTag adds a tag to the document.
+func (d *OpenAPIDocument) AddTag(name, description string) {
+	d.Tags = append(d.Tags, OpenAPITag{Name: name, Description: description})
+	sort.Slice(d.Tags, func(i, j int) bool { return d.Tags[i].Name < d.Tags[j].Name })
+}
+
+// operationID returns an id for the operation which is unique within the
+// document. Route names are not unique (a name can be used for more than one
+// path), so the path is always included.
+func operationID(tag, name, method, tmpl string) string {
+	if name == "" {
+		name = strings.ToLower(method)
+	}
+	id := name + strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_").Replace(tmpl)
+	if tag == "" {
+		return id
+	}
+	return tag + "." + id
+}
+
+// pathParameters returns a parameter for each variable in a mux path template.
+func pathParameters(tmpl string) []OpenAPIParameter {
+	var params []OpenAPIParameter
+	for {
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			return params
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			return params
+		}
+		name := tmpl[start+1 : start+end]
+		// mux allows a pattern after the name, as in {name:[0-9]+}.
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[:i]
+		}
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   OpenAPISchema{"type": "string"},
+		})
+		tmpl = tmpl[start+end+1:]
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// openAPISchemaOf returns the JSON schema of values of type t, as they are
+// encoded by encoding/json. seen holds the struct types currently being
+// described, so that recursive types terminate.
+func openAPISchemaOf(t reflect.Type, seen map[reflect.Type]bool) OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return OpenAPISchema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return OpenAPISchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return OpenAPISchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return OpenAPISchema{"type": "number"}
+	case reflect.String:
+		return OpenAPISchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return OpenAPISchema{"type": "string", "format": "byte"}
+		}
+		return OpenAPISchema{"type": "array", "items": openAPISchemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return OpenAPISchema{"type": "object", "additionalProperties": openAPISchemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return OpenAPISchema{"type": "object"}
+		}
+		if seen == nil {
+			seen = make(map[reflect.Type]bool)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			tag, tagged := f.Tag.Lookup("json")
+			// encoding/json promotes the fields of untagged, embedded structs.
+			if f.Anonymous && !tagged {
+				embedded := openAPISchemaOf(f.Type, seen)
+				if eprops, ok := embedded["properties"].(map[string]interface{}); ok {
+					for k, v := range eprops {
+						props[k] = v
+					}
+					continue
+				}
+			}
+			name := f.Name
+			if tagged {
+				tagName := strings.Split(tag, ",")[0]
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					name = tagName
+				}
+			}
+			props[name] = openAPISchemaOf(f.Type, seen)
+		}
+		return OpenAPISchema{"type": "object", "properties": props}
+	default:
+		// interface{} and anything else can hold any value.
+		return OpenAPISchema{}
+	}
+}
+
+// OpenAPI returns an OpenAPIDocument describing the routes of the
+// ServiceManager and of each service which is currently running. Each
+// service's paths are mounted under its service prefix, and its operations are
+// tagged with that prefix.
+func (s *ServiceManager) OpenAPI(version string) (*OpenAPIDocument, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc := NewOpenAPIDocument("FeatureBase DAX", version)
+
+	doc.Paths["/health"] = OpenAPIPathItem{
+		"get": &OpenAPIOperation{
+			OperationID: "GetHealth",
+			Summary:     "Reports whether all running services are healthy.",
+			Responses: map[string]OpenAPIResponse{
+				"200": {Description: "OK"},
+				"503": {Description: "One or more services are unhealthy."},
+			},
+		},
+	}
+	doc.Paths["/metrics"] = OpenAPIPathItem{
+		"get": &OpenAPIOperation{
+			OperationID: "GetMetrics",
+			Summary:     "Prometheus metrics.",
+			Responses: map[string]OpenAPIResponse{
+				"200": {Description: "OK"},
+			},
+		},
+	}
+
+	add := func(pre string, svc Service) error {
+		var annotations map[string]OpenAPIAnnotation
+		if a, ok := svc.(openAPIAnnotator); ok {
+			annotations = a.OpenAPIAnnotations()
+		}
+		doc.AddTag(pre, fmt.Sprintf("Routes of the %s service, mounted under /%s.", pre, pre))
+		return doc.AddRoutes("/"+pre, pre, svc.HTTPHandler(), annotations)
+	}
+
+	if s.Controller != nil && s.controllerStarted {
+		if err := add(ServicePrefixController, s.Controller); err != nil {
+			return nil, err
+		}
+	}
+	for k, serviceState := range s.computers {
+		if !serviceState.started {
+			continue
+		}
+		if err := add(string(k), serviceState.service); err != nil {
+			return nil, err
+		}
+	}
+	if s.Queryer != nil {
+		if err := add(ServicePrefixQueryer, s.Queryer); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
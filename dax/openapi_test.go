@@ -0,0 +1,93 @@
+package dax_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPI(t *testing.T) {
+	type thing struct {
+		Name    string   `json:"name"`
+		Tags    []string `json:"tags,omitempty"`
+		Ignored string   `json:"-"`
+	}
+
+	svcmgr := dax.NewServiceManager()
+	svcmgr.Queryer = &openAPIService{
+		annotations: map[string]dax.OpenAPIAnnotation{
+			"PostThing": {
+				Summary: "Create a thing.",
+				Parameters: []dax.OpenAPIParameter{
+					dax.OpenAPIQueryParameter("dry-run", "boolean", "Validate the thing without creating it."),
+				},
+				Request:  thing{},
+				Response: &thing{},
+			},
+		},
+	}
+
+	doc, err := svcmgr.OpenAPI("v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", doc.Info.Version)
+	assert.Contains(t, doc.Paths, "/health")
+
+	// Routes are mounted under the service prefix.
+	item, ok := doc.Paths["/"+dax.ServicePrefixQueryer+"/databases/{databaseID}/things"]
+	if !assert.True(t, ok) {
+		return
+	}
+	op := item["post"]
+	if !assert.NotNil(t, op) {
+		return
+	}
+	assert.Equal(t, []string{dax.ServicePrefixQueryer}, op.Tags)
+	assert.Equal(t, "Create a thing.", op.Summary)
+	assert.Equal(t, []dax.OpenAPIParameter{{
+		Name:     "databaseID",
+		In:       "path",
+		Required: true,
+		Schema:   dax.OpenAPISchema{"type": "string"},
+	}, {
+		Name:        "dry-run",
+		In:          "query",
+		Description: "Validate the thing without creating it.",
+		Schema:      dax.OpenAPISchema{"type": "boolean"},
+	}}, op.Parameters)
+
+	schema := op.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, dax.OpenAPISchema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": dax.OpenAPISchema{"type": "string"},
+			"tags": dax.OpenAPISchema{"type": "array", "items": dax.OpenAPISchema{"type": "string"}},
+		},
+	}, schema)
+	assert.Equal(t, schema, op.Responses["200"].Content["application/json"].Schema)
+
+	// Routes without annotations are still described.
+	assert.NotNil(t, doc.Paths["/"+dax.ServicePrefixQueryer+"/health"]["get"])
+}
+
+type openAPIService struct {
+	annotations map[string]dax.OpenAPIAnnotation
+}
+
+func (s *openAPIService) Start() error                    { return nil }
+func (s *openAPIService) Stop() error                     { return nil }
+func (s *openAPIService) Address() dax.Address            { return "localhost:8080" }
+func (s *openAPIService) SetController(dax.Address) error { return nil }
+
+func (s *openAPIService) HTTPHandler() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/health", func(http.ResponseWriter, *http.Request) {}).Methods("GET").Name("GetHealth")
+	router.HandleFunc("/databases/{databaseID}/things", func(http.ResponseWriter, *http.Request) {}).Methods("POST").Name("PostThing")
+	return router
+}
+
+func (s *openAPIService) OpenAPIAnnotations() map[string]dax.OpenAPIAnnotation {
+	return s.annotations
+}
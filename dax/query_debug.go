@@ -0,0 +1,116 @@
+package dax
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// QueryDebug is a breadcrumb of the work performed to answer a query. It's
+// collected when a client requests debug output and is returned along with the
+// query results. All methods are safe to call on a nil *QueryDebug, in which
+// case they do nothing; this allows callers to record breadcrumbs without
+// first checking whether debug output was requested.
+//
+// There are no cache hits to record: the queryer doesn't cache results, and
+// the result cache on compute nodes only serves requests made directly to the
+// node, never the remote requests the queryer sends it.
+type QueryDebug struct {
+	mu sync.Mutex
+
+	Labels    QueryLabels          `json:"labels,omitempty"`
+	Stages    []QueryDebugStage    `json:"stages"`
+	Computers []QueryDebugComputer `json:"computers"`
+}
+
+// QueryDebugStage is the time spent in one stage of query processing (for
+// example, "parse" or "plan").
+type QueryDebugStage struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// QueryDebugComputer describes a request made to a single compute node.
+// Duration is the total time of the request, of which ExecutionTime was spent
+// executing the query on the compute node; the remainder was spent on the
+// network (including serialization).
+type QueryDebugComputer struct {
+	Address       Address       `json:"address"`
+	Table         TableKey      `json:"table"`
+	Shards        []uint64      `json:"shards"`
+	Attempts      int           `json:"attempts"`
+	Duration      time.Duration `json:"duration"`
+	ExecutionTime time.Duration `json:"execution-time"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// The stages of query processing, as recorded by ObserveQueryStage.
+const (
+	QueryStageParse   = "parse"
+	QueryStagePlan    = "plan"
+	QueryStageExecute = "execute"
+	QueryStageNetwork = "network"
+	QueryStageCompute = "compute"
+	QueryStageMerge   = "merge"
+)
+
+// NewQueryDebug returns a new, empty QueryDebug.
+func NewQueryDebug() *QueryDebug {
+	return &QueryDebug{
+		Stages:    make([]QueryDebugStage, 0),
+		Computers: make([]QueryDebugComputer, 0),
+	}
+}
+
+// AddStage records the duration of a stage of query processing.
+func (d *QueryDebug) AddStage(name string, dur time.Duration) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Stages = append(d.Stages, QueryDebugStage{Name: name, Duration: dur})
+}
+
+// ObserveQueryStage records the duration of a stage of query processing in
+// HistogramQueryStageDurationSeconds, and in the QueryDebug carried by ctx (if
+// any).
+func ObserveQueryStage(ctx context.Context, stage string, dur time.Duration) {
+	HistogramQueryStageDurationSeconds.WithLabelValues(stage).Observe(dur.Seconds())
+	QueryDebugFromContext(ctx).AddStage(stage, dur)
+}
+
+// SetLabels records the labels attached to the query.
+func (d *QueryDebug) SetLabels(l QueryLabels) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Labels = l
+}
+
+// AddComputer records a request made to a compute node.
+func (d *QueryDebug) AddComputer(c QueryDebugComputer) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Computers = append(d.Computers, c)
+}
+
+type queryDebugKey struct{}
+
+// WithQueryDebug returns a copy of ctx which carries d. Query processing
+// records its breadcrumbs to the QueryDebug found in the context.
+func WithQueryDebug(ctx context.Context, d *QueryDebug) context.Context {
+	return context.WithValue(ctx, queryDebugKey{}, d)
+}
+
+// QueryDebugFromContext returns the QueryDebug carried by ctx, or nil if ctx
+// does not carry one.
+func QueryDebugFromContext(ctx context.Context) *QueryDebug {
+	d, _ := ctx.Value(queryDebugKey{}).(*QueryDebug)
+	return d
+}
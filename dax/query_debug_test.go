@@ -0,0 +1,55 @@
+package dax_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDebug(t *testing.T) {
+	t.Run("NotRequested", func(t *testing.T) {
+		// Recording to a context without a QueryDebug is a no-op.
+		dbg := dax.QueryDebugFromContext(context.Background())
+		assert.Nil(t, dbg)
+		dbg.AddStage("parse", time.Millisecond)
+		dbg.AddComputer(dax.QueryDebugComputer{Address: "host:8080"})
+	})
+
+	t.Run("Requested", func(t *testing.T) {
+		dbg := dax.NewQueryDebug()
+		ctx := dax.WithQueryDebug(context.Background(), dbg)
+
+		dax.QueryDebugFromContext(ctx).AddStage("parse", time.Millisecond)
+		dax.QueryDebugFromContext(ctx).AddComputer(dax.QueryDebugComputer{
+			Address: "host:8080",
+			Shards:  []uint64{1, 2},
+		})
+
+		assert.Equal(t, []dax.QueryDebugStage{{Name: "parse", Duration: time.Millisecond}}, dbg.Stages)
+		assert.Len(t, dbg.Computers, 1)
+		assert.Equal(t, []uint64{1, 2}, dbg.Computers[0].Shards)
+	})
+	t.Run("ObserveQueryStage", func(t *testing.T) {
+		count := func() uint64 {
+			var m dto.Metric
+			h := dax.HistogramQueryStageDurationSeconds.WithLabelValues(dax.QueryStagePlan).(prometheus.Histogram)
+			assert.NoError(t, h.Write(&m))
+			return m.GetHistogram().GetSampleCount()
+		}
+		before := count()
+
+		dbg := dax.NewQueryDebug()
+		ctx := dax.WithQueryDebug(context.Background(), dbg)
+		dax.ObserveQueryStage(ctx, dax.QueryStagePlan, time.Millisecond)
+		// Without debug output, the stage is still measured.
+		dax.ObserveQueryStage(context.Background(), dax.QueryStagePlan, time.Millisecond)
+
+		assert.Equal(t, before+2, count())
+		assert.Equal(t, []dax.QueryDebugStage{{Name: dax.QueryStagePlan, Duration: time.Millisecond}}, dbg.Stages)
+	})
+}
@@ -0,0 +1,101 @@
+package dax
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HeaderQueryLabels is the request header in which clients attach labels to a
+// query, for attributing load to the application (or user, dashboard, etc.)
+// which issued it. Its value is a comma-separated list of key=value pairs:
+//
+//	X-Query-Labels: app=billing, dashboard=1234
+const HeaderQueryLabels = "X-Query-Labels"
+
+// Limits on the labels which may be attached to a query.
+const (
+	MaxQueryLabels           = 16
+	MaxQueryLabelKeyLength   = 64
+	MaxQueryLabelValueLength = 256
+)
+
+// QueryLabels are the labels attached to a query by the client.
+type QueryLabels map[string]string
+
+// ParseQueryLabels parses the value of HeaderQueryLabels. Keys may contain only
+// letters, digits, '_', '-', and '.'; surrounding whitespace is ignored. An
+// empty header value results in nil QueryLabels.
+func ParseQueryLabels(s string) (QueryLabels, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	pairs := strings.Split(s, ",")
+	if len(pairs) > MaxQueryLabels {
+		return nil, NewErrInvalidQueryLabels(fmt.Sprintf("at most %d labels are allowed", MaxQueryLabels))
+	}
+	labels := make(QueryLabels, len(pairs))
+	for _, pair := range pairs {
+		i := strings.IndexByte(pair, '=')
+		if i < 0 {
+			return nil, NewErrInvalidQueryLabels(fmt.Sprintf("label '%s' is not of the form key=value", strings.TrimSpace(pair)))
+		}
+		k, v := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if err := validateQueryLabel(k, v); err != nil {
+			return nil, err
+		}
+		if _, ok := labels[k]; ok {
+			return nil, NewErrInvalidQueryLabels(fmt.Sprintf("label '%s' is repeated", k))
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+func validateQueryLabel(k, v string) error {
+	if k == "" {
+		return NewErrInvalidQueryLabels("label key is empty")
+	} else if len(k) > MaxQueryLabelKeyLength {
+		return NewErrInvalidQueryLabels(fmt.Sprintf("label key '%s' is longer than %d bytes", k, MaxQueryLabelKeyLength))
+	} else if len(v) > MaxQueryLabelValueLength {
+		return NewErrInvalidQueryLabels(fmt.Sprintf("value of label '%s' is longer than %d bytes", k, MaxQueryLabelValueLength))
+	}
+	for _, c := range k {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-', c == '.':
+		default:
+			return NewErrInvalidQueryLabels(fmt.Sprintf("label key '%s' contains invalid character '%c'", k, c))
+		}
+	}
+	return nil
+}
+
+// String formats l as the value of HeaderQueryLabels, with keys in sorted
+// order.
+func (l QueryLabels) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + l[k]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+type queryLabelsKey struct{}
+
+// WithQueryLabels returns a copy of ctx which carries l.
+func WithQueryLabels(ctx context.Context, l QueryLabels) context.Context {
+	return context.WithValue(ctx, queryLabelsKey{}, l)
+}
+
+// QueryLabelsFromContext returns the QueryLabels carried by ctx, or nil if ctx
+// does not carry any.
+func QueryLabelsFromContext(ctx context.Context) QueryLabels {
+	l, _ := ctx.Value(queryLabelsKey{}).(QueryLabels)
+	return l
+}
@@ -0,0 +1,46 @@
+package dax_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLabels(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		labels, err := dax.ParseQueryLabels(" app=billing,dashboard = 1234, user=")
+		require.NoError(t, err)
+		assert.Equal(t, dax.QueryLabels{"app": "billing", "dashboard": "1234", "user": ""}, labels)
+		assert.Equal(t, "app=billing, dashboard=1234, user=", labels.String())
+
+		labels, err = dax.ParseQueryLabels("")
+		require.NoError(t, err)
+		assert.Nil(t, labels)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []string{
+			"app",
+			"=billing",
+			"app=billing,app=reports",
+			"app name=billing",
+			strings.Repeat("k", dax.MaxQueryLabelKeyLength+1) + "=v",
+			"app=" + strings.Repeat("v", dax.MaxQueryLabelValueLength+1),
+			strings.Repeat("a=b,", dax.MaxQueryLabels) + "a=b",
+		} {
+			_, err := dax.ParseQueryLabels(s)
+			assert.True(t, errors.Is(err, dax.ErrInvalidQueryLabels), "%q: expected invalid labels, got %v", s, err)
+		}
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		assert.Nil(t, dax.QueryLabelsFromContext(context.Background()))
+		ctx := dax.WithQueryLabels(context.Background(), dax.QueryLabels{"app": "billing"})
+		assert.Equal(t, dax.QueryLabels{"app": "billing"}, dax.QueryLabelsFromContext(ctx))
+	})
+}
@@ -0,0 +1,89 @@
+package dax
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HeaderResourceUsage is the response header in which the queryer reports the
+// resources consumed by a query, as formatted by QueryUsage.String.
+const HeaderResourceUsage = "X-Resource-Usage"
+
+// QueryUsage accounts for the resources consumed by a query, for cost
+// attribution. All methods are safe to call on a nil *QueryUsage, in which
+// case they do nothing.
+type QueryUsage struct {
+	mu sync.Mutex
+
+	// ComputeTime is the time compute nodes spent executing the query,
+	// summed across nodes. It's wall-clock time rather than CPU time, so it
+	// includes time spent waiting on disk.
+	ComputeTime time.Duration `json:"compute-time"`
+
+	// Shards is the number of shards scanned, summed across requests to
+	// compute nodes. A shard read by more than one request (for example, by
+	// a query which makes several calls) is counted each time.
+	Shards int64 `json:"shards"`
+
+	// BytesRead is the size of the responses which the queryer read from
+	// compute nodes.
+	BytesRead int64 `json:"bytes-read"`
+
+	// RowsExamined is the number of records (or groups, for aggregates)
+	// returned by compute nodes to the queryer, before any filtering,
+	// aggregation, or limit applied by the queryer. Results which are a
+	// single value, such as a count, count as one.
+	RowsExamined int64 `json:"rows-examined"`
+}
+
+// NewQueryUsage returns a new, empty QueryUsage.
+func NewQueryUsage() *QueryUsage {
+	return &QueryUsage{}
+}
+
+// AddRequest records the resources consumed by a request to a compute node.
+func (u *QueryUsage) AddRequest(computeTime time.Duration, shards int, bytesRead int64, rows int64) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.ComputeTime += computeTime
+	u.Shards += int64(shards)
+	u.BytesRead += bytesRead
+	u.RowsExamined += rows
+}
+
+// String formats u as the value of HeaderResourceUsage: a semicolon-separated
+// list of the fields, with compute time in (fractional) milliseconds and
+// bytes read in bytes. For example:
+//
+//	compute-ms=12.345; shards=4; bytes-read=2048; rows-examined=100
+//
+// Clients should ignore fields they don't recognize, since more may be added.
+func (u *QueryUsage) String() string {
+	if u == nil {
+		return ""
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return fmt.Sprintf("compute-ms=%.3f; shards=%d; bytes-read=%d; rows-examined=%d",
+		float64(u.ComputeTime)/float64(time.Millisecond), u.Shards, u.BytesRead, u.RowsExamined)
+}
+
+type queryUsageKey struct{}
+
+// WithQueryUsage returns a copy of ctx which carries u. Query processing
+// records the resources it consumes to the QueryUsage found in the context.
+func WithQueryUsage(ctx context.Context, u *QueryUsage) context.Context {
+	return context.WithValue(ctx, queryUsageKey{}, u)
+}
+
+// QueryUsageFromContext returns the QueryUsage carried by ctx, or nil if ctx
+// does not carry one.
+func QueryUsageFromContext(ctx context.Context) *QueryUsage {
+	u, _ := ctx.Value(queryUsageKey{}).(*QueryUsage)
+	return u
+}
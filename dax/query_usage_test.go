@@ -0,0 +1,31 @@
+package dax_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryUsage(t *testing.T) {
+	t.Run("NotRequested", func(t *testing.T) {
+		// Recording to a context without a QueryUsage is a no-op.
+		u := dax.QueryUsageFromContext(context.Background())
+		assert.Nil(t, u)
+		u.AddRequest(time.Millisecond, 1, 100, 1)
+		assert.Equal(t, "", u.String())
+	})
+
+	t.Run("Requested", func(t *testing.T) {
+		u := dax.NewQueryUsage()
+		ctx := dax.WithQueryUsage(context.Background(), u)
+
+		dax.QueryUsageFromContext(ctx).AddRequest(1500*time.Microsecond, 2, 1024, 10)
+		dax.QueryUsageFromContext(ctx).AddRequest(10*time.Millisecond, 3, 1024, 1)
+
+		assert.Equal(t, 11500*time.Microsecond, u.ComputeTime)
+		assert.Equal(t, "compute-ms=11.500; shards=5; bytes-read=2048; rows-examined=11", u.String())
+	})
+}
@@ -0,0 +1,205 @@
+package queryer
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultApproximateCountSample is the number of shards sampled by an
+// approximate count when ApproximateCount.SampleShards is 0.
+const DefaultApproximateCountSample = 16
+
+// approximateCountConfidence is the confidence level of the error bound of an
+// approximate count, and approximateCountZ its z-score.
+const (
+	approximateCountConfidence = 0.95
+	approximateCountZ          = 1.96
+)
+
+// ApproximateCount requests that the counts computed by a query be estimated
+// from a sample of the table's shards rather than computed exactly. It applies
+// to the queries of QuerySQL, so to COUNT in SQL, and to Count() in PQL only
+// when the PQL is sent as the sql of QuerySQL; the queryer's HTTP API has no
+// other way to run PQL, and only its sql endpoints accept approximate-count. Each sampled shard is counted exactly,
+// and the count of the table is estimated as the mean count of the sampled
+// shards times the number of shards. The response reports each estimate with
+// a bound on its error at 95% confidence, computed from the variation between
+// the sampled shards.
+//
+// Exact counts remain the default. An approximate count reads only
+// SampleShards of the table's shards, so its cost doesn't grow with the size
+// of the table, but the tradeoff is that the estimate is only as good as the
+// sample is representative. Records are assigned to shards by ID, so when the
+// rows being counted are spread evenly across shards the error bound is
+// small; when they're concentrated in a few shards, such as records imported
+// in ID order and filtered by import time, the bound is wide, and the sample
+// can miss them entirely, in which case the estimate and its bound are both 0.
+// The bound is a statistical one, not a guarantee: about one estimate in 20
+// is further from the exact count than its bound. A table with no more shards
+// than SampleShards is counted exactly. Counts of the results of Distinct and
+// similar calls, which are computed across every shard, are always exact.
+type ApproximateCount struct {
+	// SampleShards is the number of shards counted; 0 uses
+	// DefaultApproximateCountSample.
+	SampleShards int
+}
+
+type approximateCountKey struct{}
+
+// approximateCounts is what a query which requested an ApproximateCount has
+// estimated so far.
+type approximateCounts struct {
+	ApproximateCount
+
+	mu        sync.Mutex
+	estimates []featurebase.WireApproximation
+}
+
+// WithApproximateCount returns a copy of ctx which requests that counts be
+// approximated as described by a.
+func WithApproximateCount(ctx context.Context, a ApproximateCount) context.Context {
+	if a.SampleShards <= 0 {
+		a.SampleShards = DefaultApproximateCountSample
+	}
+	return context.WithValue(ctx, approximateCountKey{}, &approximateCounts{ApproximateCount: a})
+}
+
+// approximateCountsFromContext returns the approximateCounts of ctx, or nil if
+// it didn't request approximate counts.
+func approximateCountsFromContext(ctx context.Context) *approximateCounts {
+	a, _ := ctx.Value(approximateCountKey{}).(*approximateCounts)
+	return a
+}
+
+func (a *approximateCounts) add(est featurebase.WireApproximation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.estimates = append(a.estimates, est)
+}
+
+// applyApproximations sets the Approximations of resp to the estimates made
+// for the query whose context is ctx, if any.
+func applyApproximations(ctx context.Context, resp *featurebase.WireQueryResponse) {
+	a := approximateCountsFromContext(ctx)
+	if a == nil || resp == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.estimates) > 0 {
+		resp.Approximations = append([]featurebase.WireApproximation(nil), a.estimates...)
+	}
+}
+
+// approximateCount estimates the result of the Count() call c from a sample
+// of shards, as described by ApproximateCount, and records the estimate in a.
+func (o *orchestrator) approximateCount(ctx context.Context, tableKeyer dax.TableKeyer, c *pql.Call, shards []uint64, opt *featurebase.ExecOptions, a *approximateCounts) (uint64, error) {
+	if shards == nil {
+		nodes, err := o.topology.ComputeNodes(ctx, string(tableKeyer.Key()), nil)
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting shards for index '%s'", tableKeyer.Key())
+		}
+		for _, n := range nodes {
+			for _, s := range n.Shards {
+				shards = append(shards, uint64(s))
+			}
+		}
+	}
+
+	sample := sampleShards(shards, a.SampleShards)
+	counts := make([]uint64, len(sample))
+	eg, ectx := errgroup.WithContext(ctx)
+	for i, shard := range sample {
+		i, shard := i, shard
+		eg.Go(func() error {
+			result, err := o.mapReduce(ectx, tableKeyer, []uint64{shard}, c, opt, func(ctx context.Context, prev, v interface{}) interface{} {
+				other, _ := prev.(uint64)
+				return other + v.(uint64)
+			})
+			if err != nil {
+				return err
+			}
+			counts[i], _ = result.(uint64)
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return 0, err
+	}
+
+	est := estimateCount(counts, len(shards))
+	est.Call = c.String()
+	a.add(est)
+	return est.Estimate, nil
+}
+
+// sampleShards returns n of shards, chosen at random, or all of them if there
+// are no more than n.
+func sampleShards(shards []uint64, n int) []uint64 {
+	if len(shards) <= n {
+		return shards
+	}
+	sample := make([]uint64, n)
+	for i, j := range rand.Perm(len(shards))[:n] {
+		sample[i] = shards[j]
+	}
+	sort.Slice(sample, func(i, j int) bool { return sample[i] < sample[j] })
+	return sample
+}
+
+// estimateCount estimates the total count of total shards from the exact
+// counts of a simple random sample of them. The error bound is that of the
+// estimate's confidence interval, including the finite population correction
+// for sampling without replacement, which makes it 0 when every shard was
+// counted.
+func estimateCount(counts []uint64, total int) featurebase.WireApproximation {
+	est := featurebase.WireApproximation{
+		Confidence:    approximateCountConfidence,
+		SampledShards: len(counts),
+		TotalShards:   total,
+	}
+	n := len(counts)
+	if n == 0 {
+		return est
+	}
+	if n >= total {
+		var sum uint64
+		for _, c := range counts {
+			sum += c
+		}
+		est.Estimate = sum
+		return est
+	}
+
+	var mean float64
+	for _, c := range counts {
+		mean += float64(c)
+	}
+	mean /= float64(n)
+	est.Estimate = uint64(math.Round(mean * float64(total)))
+	if n < 2 {
+		// A single shard says nothing about the variation between
+		// shards.
+		est.ErrorBound = est.Estimate
+		return est
+	}
+
+	var ss float64
+	for _, c := range counts {
+		d := float64(c) - mean
+		ss += d * d
+	}
+	stddev := math.Sqrt(ss / float64(n-1))
+	fpc := math.Sqrt(float64(total-n) / float64(total-1))
+	est.ErrorBound = uint64(math.Ceil(approximateCountZ * float64(total) * stddev / math.Sqrt(float64(n)) * fpc))
+	return est
+}
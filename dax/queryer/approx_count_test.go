@@ -0,0 +1,64 @@
+package queryer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproximateCount(t *testing.T) {
+	t.Run("Estimate", func(t *testing.T) {
+		est := estimateCount([]uint64{10, 10, 10, 10}, 8)
+		assert.EqualValues(t, 80, est.Estimate)
+		assert.EqualValues(t, 0, est.ErrorBound)
+		assert.Equal(t, 4, est.SampledShards)
+		assert.Equal(t, 8, est.TotalShards)
+		assert.Equal(t, 0.95, est.Confidence)
+
+		// mean 10, stddev 14.14; 1.96 * 4 * 14.14/sqrt(2) * sqrt(2/3) = 64.01
+		est = estimateCount([]uint64{0, 20}, 4)
+		assert.EqualValues(t, 40, est.Estimate)
+		assert.EqualValues(t, 65, est.ErrorBound)
+
+		// Every shard was counted, so the count is exact.
+		est = estimateCount([]uint64{3, 4}, 2)
+		assert.EqualValues(t, 7, est.Estimate)
+		assert.EqualValues(t, 0, est.ErrorBound)
+
+		est = estimateCount([]uint64{5}, 10)
+		assert.EqualValues(t, 50, est.Estimate)
+		assert.EqualValues(t, 50, est.ErrorBound)
+
+		est = estimateCount(nil, 0)
+		assert.EqualValues(t, 0, est.Estimate)
+	})
+
+	t.Run("Sample", func(t *testing.T) {
+		shards := []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+		assert.Equal(t, shards, sampleShards(shards, 10))
+
+		sample := sampleShards(shards, 3)
+		require.Len(t, sample, 3)
+		assert.True(t, sort.SliceIsSorted(sample, func(i, j int) bool { return sample[i] < sample[j] }))
+		assert.True(t, sample[0] < sample[1] && sample[1] < sample[2], "expected distinct shards: %v", sample)
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		resp := &featurebase.WireQueryResponse{}
+		applyApproximations(context.Background(), resp)
+		assert.Nil(t, resp.Approximations)
+
+		ctx := WithApproximateCount(context.Background(), ApproximateCount{})
+		a := approximateCountsFromContext(ctx)
+		require.NotNil(t, a)
+		assert.Equal(t, DefaultApproximateCountSample, a.SampleShards)
+		a.add(estimateCount([]uint64{1, 2}, 4))
+		applyApproximations(ctx, resp)
+		require.Len(t, resp.Approximations, 1)
+		assert.EqualValues(t, 6, resp.Approximations[0].Estimate)
+	})
+}
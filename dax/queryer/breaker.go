@@ -0,0 +1,254 @@
+package queryer
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+const (
+	// DefaultBreakerFailureThreshold is the default number of consecutive
+	// failed requests to a computer after which its circuit breaker opens.
+	DefaultBreakerFailureThreshold = 5
+
+	// DefaultBreakerCooldown is the default time a circuit breaker stays open
+	// before allowing a probe request through.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// BreakerConfig configures the circuit breakers which the queryer keeps for
+// each computer.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed requests to a
+	// computer after which its breaker opens. While open, requests to the
+	// computer fail immediately. If 0, DefaultBreakerFailureThreshold is
+	// used; if negative, breakers are disabled.
+	FailureThreshold int `toml:"failure-threshold"`
+
+	// Cooldown is how long a breaker stays open. After the cooldown, the
+	// breaker is half-open: a single request is let through to probe the
+	// computer, and the breaker closes if it succeeds or re-opens if it fails.
+	// If 0, DefaultBreakerCooldown is used.
+	Cooldown time.Duration `toml:"cooldown"`
+}
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// gaugeValue is the value reported for the state by
+// dax.GaugeComputerBreakerState.
+func (s BreakerState) gaugeValue() float64 {
+	switch s {
+	case BreakerOpen:
+		return 2
+	case BreakerHalfOpen:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ComputerBreaker is a snapshot of the circuit breaker for a single computer.
+type ComputerBreaker struct {
+	Address  dax.Address  `json:"address"`
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"`
+	// OpenedAt is when the breaker last opened; it's only set while the
+	// breaker is open or half-open.
+	OpenedAt *time.Time `json:"opened-at,omitempty"`
+}
+
+// breaker is the state of the circuit breaker for a single computer.
+type breaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	// probing is set while the single half-open probe request is in flight.
+	probing bool
+}
+
+// breakers holds a circuit breaker for each computer the queryer calls. It is
+// safe for concurrent use.
+type breakers struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	byAddr    map[dax.Address]*breaker
+
+	// now is overridden by tests.
+	now func() time.Time
+}
+
+func newBreakers(cfg BreakerConfig) *breakers {
+	b := &breakers{
+		threshold: cfg.FailureThreshold,
+		cooldown:  cfg.Cooldown,
+		byAddr:    make(map[dax.Address]*breaker),
+		now:       time.Now,
+	}
+	if b.threshold == 0 {
+		b.threshold = DefaultBreakerFailureThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = DefaultBreakerCooldown
+	}
+	return b
+}
+
+// allow returns nil if a request to addr may be made, or an ErrCircuitOpen
+// error if it should fail immediately. Every request which is allowed must be
+// followed by a call to done.
+func (b *breakers) allow(addr dax.Address) error {
+	if b == nil || b.threshold < 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.byAddr[addr]
+	if !ok {
+		return nil
+	}
+	switch br.state {
+	case BreakerOpen:
+		if wait := b.cooldown - b.now().Sub(br.openedAt); wait > 0 {
+			return dax.NewErrCircuitOpen(addr, wait)
+		}
+		b.setState(addr, br, BreakerHalfOpen)
+		br.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if br.probing {
+			return dax.NewErrCircuitOpen(addr, 0)
+		}
+		br.probing = true
+		return nil
+	}
+	return nil
+}
+
+// done records the outcome of a request to addr which was allowed, made with
+// ctx. A request which ended because ctx was canceled or timed out says
+// nothing about the computer, so it counts as neither a failure nor a success;
+// if it was a probe, the next request is let through as another one.
+func (b *breakers) done(ctx context.Context, addr dax.Address, err error) {
+	if b == nil || b.threshold < 0 {
+		return
+	}
+	failed := isComputerFailure(ctx, err)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if isContextError(ctx, err) {
+		if br, ok := b.byAddr[addr]; ok {
+			br.probing = false
+		}
+		return
+	}
+
+	br, ok := b.byAddr[addr]
+	if !ok {
+		if !failed {
+			return
+		}
+		br = &breaker{state: BreakerClosed}
+		b.byAddr[addr] = br
+	}
+	br.probing = false
+
+	if !failed {
+		br.failures = 0
+		b.setState(addr, br, BreakerClosed)
+		return
+	}
+	br.failures++
+	if br.state == BreakerHalfOpen || br.failures >= b.threshold {
+		br.openedAt = b.now()
+		b.setState(addr, br, BreakerOpen)
+	}
+}
+
+// setState sets the state of br, the breaker for addr, and updates the
+// corresponding metric. b.mu must be held.
+func (b *breakers) setState(addr dax.Address, br *breaker, state BreakerState) {
+	br.state = state
+	dax.GaugeComputerBreakerState.WithLabelValues(string(addr)).Set(state.gaugeValue())
+}
+
+// snapshot returns the state of the breaker of each computer which has failed
+// at least once, sorted by address.
+func (b *breakers) snapshot() []ComputerBreaker {
+	out := make([]ComputerBreaker, 0)
+	if b == nil {
+		return out
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for addr, br := range b.byAddr {
+		cb := ComputerBreaker{
+			Address:  addr,
+			State:    br.state,
+			Failures: br.failures,
+		}
+		if br.state != BreakerClosed {
+			openedAt := br.openedAt
+			cb.OpenedAt = &openedAt
+		}
+		out = append(out, cb)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// serverErrorRe matches the error returned by InternalClient for a response
+// with a 5xx status.
+var serverErrorRe = regexp.MustCompile(` 5\d\d [A-Za-z ]+: '`)
+
+// isComputerFailure returns true if err, returned by a request made with ctx,
+// indicates that a computer is unhealthy (it couldn't be reached, or it
+// responded with a server error), as opposed to an error with the query
+// itself, or the query being canceled or timing out.
+func isComputerFailure(ctx context.Context, err error) bool {
+	if err == nil || errors.Is(err, dax.ErrCircuitOpen) || isContextError(ctx, err) {
+		return false
+	}
+	msg := err.Error()
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return strings.Contains(msg, errConnectionRefused) ||
+		strings.Contains(msg, "getting response") ||
+		serverErrorRe.MatchString(msg)
+}
+
+// isContextError returns true if err, returned by a request made with ctx, is
+// the result of ctx, or the request's own context, being canceled or timing
+// out. The client's errors don't always wrap the context's, so their messages
+// are checked.
+func isContextError(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if ctx.Err() != nil {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, context.Canceled.Error()) ||
+		strings.Contains(msg, context.DeadlineExceeded.Error())
+}
@@ -0,0 +1,126 @@
+package queryer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakers(t *testing.T) {
+	const addr = dax.Address("computer0:8080")
+	unreachable := errors.Errorf("getting response: dial tcp: connect: connection refused")
+
+	newTestBreakers := func(cfg BreakerConfig) (*breakers, *time.Time) {
+		b := newBreakers(cfg)
+		now := time.Unix(1000, 0)
+		b.now = func() time.Time { return now }
+		return b, &now
+	}
+
+	t.Run("OpenAfterThreshold", func(t *testing.T) {
+		b, now := newTestBreakers(BreakerConfig{FailureThreshold: 3, Cooldown: time.Minute})
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, b.allow(addr))
+			b.done(context.Background(), addr, unreachable)
+		}
+		err := b.allow(addr)
+		assert.True(t, errors.Is(err, dax.ErrCircuitOpen), "expected circuit open, got %v", err)
+		assert.Equal(t, BreakerOpen, b.snapshot()[0].State)
+
+		// After the cooldown, a single probe is let through.
+		*now = now.Add(time.Minute)
+		assert.NoError(t, b.allow(addr))
+		assert.Equal(t, BreakerHalfOpen, b.snapshot()[0].State)
+		assert.True(t, errors.Is(b.allow(addr), dax.ErrCircuitOpen))
+
+		// A failed probe re-opens the breaker.
+		b.done(context.Background(), addr, unreachable)
+		assert.Equal(t, BreakerOpen, b.snapshot()[0].State)
+		assert.True(t, errors.Is(b.allow(addr), dax.ErrCircuitOpen))
+
+		// A successful probe closes it.
+		*now = now.Add(time.Minute)
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, nil)
+		snap := b.snapshot()
+		assert.Equal(t, BreakerClosed, snap[0].State)
+		assert.Equal(t, 0, snap[0].Failures)
+		assert.Nil(t, snap[0].OpenedAt)
+		assert.NoError(t, b.allow(addr))
+	})
+
+	t.Run("SuccessResetsFailures", func(t *testing.T) {
+		b, _ := newTestBreakers(BreakerConfig{FailureThreshold: 2})
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, unreachable)
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, nil)
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, unreachable)
+		assert.NoError(t, b.allow(addr))
+	})
+
+	t.Run("QueryErrorsDontCount", func(t *testing.T) {
+		b, _ := newTestBreakers(BreakerConfig{FailureThreshold: 1})
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, errors.Errorf("against http://computer0:8080/index/t/query 400 Bad Request: 'field not found'"))
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, context.Canceled)
+		assert.NoError(t, b.allow(addr))
+		assert.Len(t, b.snapshot(), 0)
+
+		b.done(context.Background(), addr, errors.Errorf("against http://computer0:8080/index/t/query 503 Service Unavailable: 'draining'"))
+		assert.True(t, errors.Is(b.allow(addr), dax.ErrCircuitOpen))
+	})
+
+	t.Run("ContextErrorsDontCount", func(t *testing.T) {
+		b, now := newTestBreakers(BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+
+		// A timed out request doesn't count, whether the deadline error is
+		// wrapped, or only in the message.
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, context.DeadlineExceeded)
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, errors.Wrap(context.DeadlineExceeded, "getting response"))
+		assert.NoError(t, b.allow(addr))
+		b.done(context.Background(), addr, errors.Errorf("getting response: Post \"http://computer0:8080/index/t/query\": context deadline exceeded"))
+		assert.NoError(t, b.allow(addr))
+		assert.Len(t, b.snapshot(), 0)
+
+		// Nor does a network error once the request's context is done.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		netErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.Errorf("use of closed network connection")}
+		b.done(ctx, addr, netErr)
+		assert.NoError(t, b.allow(addr))
+		assert.Len(t, b.snapshot(), 0)
+
+		// The same error from a live request does.
+		b.done(context.Background(), addr, netErr)
+		assert.True(t, errors.Is(b.allow(addr), dax.ErrCircuitOpen))
+
+		// A probe which is canceled leaves the breaker half-open, and lets
+		// the next request probe instead.
+		*now = now.Add(time.Minute)
+		assert.NoError(t, b.allow(addr))
+		b.done(ctx, addr, context.Canceled)
+		assert.Equal(t, BreakerHalfOpen, b.snapshot()[0].State)
+		assert.NoError(t, b.allow(addr))
+		assert.True(t, errors.Is(b.allow(addr), dax.ErrCircuitOpen))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		b, _ := newTestBreakers(BreakerConfig{FailureThreshold: -1})
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, b.allow(addr))
+			b.done(context.Background(), addr, unreachable)
+		}
+		assert.Len(t, b.snapshot(), 0)
+	})
+}
@@ -33,7 +33,8 @@ func New(address dax.Address, logger logger.Logger) *Client {
 		address: address,
 		logger:  logger,
 		client: &http.Client{
-			Timeout: time.Second * 30,
+			Transport: dax.ServiceTransport(nil),
+			Timeout:   time.Second * 30,
 		},
 	}
 }
@@ -65,6 +66,7 @@ func (c *Client) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, sq
 		return nil, errors.Wrap(err, "creating new post request")
 	}
 	req.Header.Add("Content-Type", "text/plain")
+	req.Header.Add("Accept", "application/json")
 	req.Header.Add("OrganizationID", string(qdbid.OrganizationID))
 
 	var resp *http.Response
@@ -1,7 +1,10 @@
 package queryer
 
 import (
+	"time"
+
 	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner"
 )
 
 // Config defines the configuration parameters for Queryer. At the moment, it's
@@ -12,6 +15,84 @@ import (
 // We initially did that with something called "Injections", but that separation
 // was a bit premature.
 type Config struct {
-	ControllerAddress string        `toml:"controller-address"`
-	Logger            logger.Logger `toml:"-"`
+	ControllerAddress string `toml:"controller-address"`
+
+	// ControllerAddresses are the addresses of replicas of the Controller,
+	// in order of preference, to which the Queryer fails over when the one
+	// it's using can't be reached. ControllerAddress, if set, is preferred
+	// to all of them.
+	ControllerAddresses []string `toml:"controller-addresses"`
+
+	// ControllerCheckInterval is how often the health of the Controller
+	// replicas is checked, so that the Queryer moves back to the most
+	// preferred healthy one. A value of 0 uses
+	// client.DefaultFailoverCheckInterval.
+	ControllerCheckInterval time.Duration `toml:"controller-check-interval"`
+
+	// MaxStatements is the maximum number of named statements which can be
+	// registered, per database, with the Queryer's statement registry. A value
+	// of 0 uses DefaultMaxStatements.
+	MaxStatements int `toml:"max-statements"`
+
+	// MaxQueryTextSize is the maximum size, in bytes, of the text of a query
+	// (or of a named statement). Larger queries are rejected before they are
+	// parsed. A value of 0 uses DefaultMaxQueryTextSize; a negative value
+	// disables the limit.
+	MaxQueryTextSize int64 `toml:"max-query-text-size"`
+
+	// DebugOrganizations are the organizations permitted to request debug
+	// output (the computers and shards a query touched, and the time spent in
+	// each stage) along with their query results. Since debug output exposes
+	// cluster topology, it is disabled for all organizations by default.
+	// These organizations are also the queryer's operators, who may put it
+	// into, or take it out of, read-only mode with POST /read-only.
+	DebugOrganizations []string `toml:"debug-organizations"`
+
+	// Labels configures which query labels are accepted, and which of them
+	// are recorded in metrics.
+	Labels LabelConfig `toml:"labels"`
+
+	// QueryHistorySize is the number of recently completed queries kept in the
+	// query history. A value of 0 uses DefaultQueryHistorySize; a negative
+	// value disables the history.
+	QueryHistorySize int `toml:"query-history-size"`
+
+	// SlowQueryThreshold is the duration at or above which a completed query
+	// is logged, along with its labels. A value of 0 uses
+	// DefaultSlowQueryThreshold; a negative value disables the log.
+	SlowQueryThreshold time.Duration `toml:"slow-query-threshold"`
+
+	// ImportSampleSize is the number of rows sampled to infer the schema of
+	// an import, when the import doesn't specify one. A value of 0 uses
+	// DefaultImportSampleSize.
+	ImportSampleSize int `toml:"import-sample-size"`
+
+	// Distinct limits the memory used by each DISTINCT before it spills to
+	// disk, and the number of rows it may produce.
+	Distinct planner.DistinctConfig `toml:"distinct"`
+
+	// DefaultOutputFormat is the format (json, csv or arrow) in which query
+	// results are returned to clients whose Accept header doesn't ask for
+	// one. An empty value uses DefaultOutputFormat.
+	DefaultOutputFormat OutputFormat `toml:"default-output-format"`
+
+	// Export configures the object stores to which query results may be
+	// exported.
+	Export ExportConfig `toml:"export"`
+
+	// Encryption configures the key with which the values of encrypted
+	// fields are encrypted.
+	Encryption EncryptionConfig `toml:"encryption"`
+
+	// Breaker configures the circuit breaker kept for each computer.
+	Breaker BreakerConfig `toml:"breaker"`
+
+	// Prewarm configures the idle connections kept open to each computer.
+	Prewarm PrewarmConfig `toml:"prewarm"`
+
+	// ReadOnly starts the Queryer in read-only mode, in which it rejects
+	// every write and DDL statement; see Queryer.SetReadOnly.
+	ReadOnly bool `toml:"read-only"`
+
+	Logger logger.Logger `toml:"-"`
 }
@@ -0,0 +1,486 @@
+package queryer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// EncryptionConfig configures the key with which the Queryer encrypts the
+// values of encrypted fields (see dax.FieldEncryptionDeterministic), and who
+// may read them. Unless DataKey is set, queries which read or write encrypted
+// fields fail.
+type EncryptionConfig struct {
+	// DataKey is a 256-bit data key, encrypted with a KMS key and base64
+	// encoded, as returned in the CiphertextBlob of KMS's GenerateDataKey.
+	// The Queryer decrypts it with KMS when it starts, so the Queryer's
+	// credentials must permit kms:Decrypt with the key which encrypted it.
+	DataKey string `toml:"data-key"`
+
+	// KMSRegion is the region of the KMS key. If empty, the region is taken
+	// from the environment, as by the AWS CLI.
+	KMSRegion string `toml:"kms-region"`
+
+	// KMSEndpoint, if set, is the endpoint of a KMS-compatible service to
+	// use instead of AWS KMS.
+	KMSEndpoint string `toml:"kms-endpoint"`
+
+	// DecryptOrganizations are the organizations permitted to read the
+	// values of encrypted fields. The Queryer identifies a caller by the
+	// organization named in its request (as it does for debug output), not by
+	// the organization which owns the table being read, so queries from any
+	// other organization read the values' ciphertexts instead. Any
+	// organization may write values, and filter on them for equality.
+	DecryptOrganizations []string `toml:"decrypt-organizations"`
+}
+
+// dataKeySize is the size, in bytes, of the data key from which the keys of
+// encrypted fields are derived.
+const dataKeySize = 32
+
+// newKMSFieldCipher decrypts cfg.DataKey with KMS, and returns a fieldCipher
+// using the result.
+func newKMSFieldCipher(ctx context.Context, cfg EncryptionConfig) (*fieldCipher, error) {
+	blob, err := base64.StdEncoding.DecodeString(cfg.DataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding data key")
+	}
+	config := &aws.Config{}
+	if cfg.KMSRegion != "" {
+		config.Region = aws.String(cfg.KMSRegion)
+	}
+	if cfg.KMSEndpoint != "" {
+		config.Endpoint = aws.String(cfg.KMSEndpoint)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating KMS session")
+	}
+	out, err := kms.New(sess).DecryptWithContext(ctx, &kms.DecryptInput{CiphertextBlob: blob})
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data key")
+	}
+	return newFieldCipher(out.Plaintext)
+}
+
+// SetEncryptionKey sets the data key from which the keys of encrypted fields
+// are derived, in place of the one the Queryer would decrypt from its
+// EncryptionConfig. It must be called before the Queryer serves queries.
+func (q *Queryer) SetEncryptionKey(key []byte) error {
+	c, err := newFieldCipher(key)
+	if err != nil {
+		return err
+	}
+	q.cipher = c
+	return nil
+}
+
+// fieldCipher encrypts and decrypts the values of encrypted fields. Each
+// field's values are encrypted with keys derived for that field from the data
+// key, so that equal values of different fields don't have equal ciphertexts.
+type fieldCipher struct {
+	key []byte
+}
+
+func newFieldCipher(key []byte) (*fieldCipher, error) {
+	if len(key) != dataKeySize {
+		return nil, errors.Errorf("data key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	return &fieldCipher{key: append([]byte(nil), key...)}, nil
+}
+
+// fieldKeys are the keys with which the values of one field are encrypted.
+type fieldKeys struct {
+	mode  string
+	block cipher.Block
+	aead  cipher.AEAD
+	mac   []byte
+}
+
+// forField returns the keys with which the values of fld, in the table
+// qtid, are encrypted. They're derived from the field's revision as well as
+// its name, so a field which is dropped and added again doesn't share keys,
+// or deterministic ciphertexts, with the field it replaces.
+func (c *fieldCipher) forField(qtid dax.QualifiedTableID, fld *dax.Field) (*fieldKeys, error) {
+	derive := func(purpose string) []byte {
+		h := hmac.New(sha256.New, c.key)
+		h.Write([]byte(purpose + "\x00" + string(qtid.Key()) + "\x00" + string(fld.Name) + "\x00" + strconv.FormatInt(fld.Revision, 10)))
+		return h.Sum(nil)
+	}
+	block, err := aes.NewCipher(derive("encrypt"))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating GCM")
+	}
+	return &fieldKeys{
+		mode:  fld.Options.Encryption,
+		block: block,
+		aead:  aead,
+		mac:   derive("mac"),
+	}, nil
+}
+
+// encrypt returns the ciphertext of value. With deterministic encryption, the
+// IV is a MAC of the value (as in SIV mode), so that equal values have equal
+// ciphertexts, and the IV authenticates the value on decryption. With
+// randomized encryption, the value is sealed with AES-GCM under a random
+// nonce.
+func (k *fieldKeys) encrypt(value string) (string, error) {
+	var out []byte
+	if k.mode == dax.FieldEncryptionDeterministic {
+		iv := k.siv([]byte(value))
+		out = make([]byte, len(iv)+len(value))
+		copy(out, iv)
+		cipher.NewCTR(k.block, iv).XORKeyStream(out[len(iv):], []byte(value))
+	} else {
+		nonce := make([]byte, k.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", errors.Wrap(err, "generating nonce")
+		}
+		out = k.aead.Seal(nonce, nonce, []byte(value), nil)
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// decrypt returns the value whose ciphertext is s. The empty string, which
+// is what's returned for an ID with no key, decrypts to itself.
+func (k *fieldKeys) decrypt(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return "", errors.Wrap(err, "decoding ciphertext")
+	}
+	if k.mode == dax.FieldEncryptionDeterministic {
+		if len(b) < aes.BlockSize {
+			return "", errors.New(errors.ErrUncoded, "ciphertext too short")
+		}
+		iv, ct := b[:aes.BlockSize], b[aes.BlockSize:]
+		value := make([]byte, len(ct))
+		cipher.NewCTR(k.block, iv).XORKeyStream(value, ct)
+		if !hmac.Equal(iv, k.siv(value)) {
+			return "", errors.New(errors.ErrUncoded, "ciphertext failed authentication")
+		}
+		return string(value), nil
+	}
+	n := k.aead.NonceSize()
+	if len(b) < n {
+		return "", errors.New(errors.ErrUncoded, "ciphertext too short")
+	}
+	value, err := k.aead.Open(nil, b[:n], b[n:], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "opening ciphertext")
+	}
+	return string(value), nil
+}
+
+// siv returns the synthetic IV of value.
+func (k *fieldKeys) siv(value []byte) []byte {
+	h := hmac.New(sha256.New, k.mac)
+	h.Write(value)
+	return h.Sum(nil)[:aes.BlockSize]
+}
+
+// fieldKeyCache holds the keys of the encrypted fields of each table, derived
+// for the version of the table's schema they were looked up with, so that
+// they're only derived again once the schema changes. The version covers each
+// field's options (including its encryption mode) and revision, so a field
+// which is dropped and added again has its keys derived again, for its new
+// revision (see fieldCipher.forField).
+type fieldKeyCache struct {
+	mu     sync.Mutex
+	tables map[dax.TableKey]*tableFieldKeys
+}
+
+type tableFieldKeys struct {
+	version string
+	fields  map[dax.FieldName]*fieldKeys
+}
+
+func newFieldKeyCache() *fieldKeyCache {
+	return &fieldKeyCache{
+		tables: make(map[dax.TableKey]*tableFieldKeys),
+	}
+}
+
+// get returns the keys of fld, in qtbl, derived by c.
+func (k *fieldKeyCache) get(c *fieldCipher, qtbl *dax.QualifiedTable, fld *dax.Field) (*fieldKeys, error) {
+	version := qtbl.Version()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	tk, ok := k.tables[qtbl.Key()]
+	if !ok || tk.version != version {
+		tk = &tableFieldKeys{version: version, fields: make(map[dax.FieldName]*fieldKeys)}
+		k.tables[qtbl.Key()] = tk
+	}
+	if fk, ok := tk.fields[fld.Name]; ok {
+		return fk, nil
+	}
+	fk, err := c.forField(qtbl.QualifiedID(), fld)
+	if err != nil {
+		return nil, err
+	}
+	tk.fields[fld.Name] = fk
+	return fk, nil
+}
+
+type callerKey struct{}
+
+// withCaller returns a copy of ctx which holds the organization on whose
+// behalf the query is made.
+func withCaller(ctx context.Context, orgID dax.OrganizationID) context.Context {
+	return context.WithValue(ctx, callerKey{}, orgID)
+}
+
+// callerFromContext returns the organization held by ctx, if any.
+func callerFromContext(ctx context.Context) (dax.OrganizationID, bool) {
+	orgID, ok := ctx.Value(callerKey{}).(dax.OrganizationID)
+	return orgID, ok
+}
+
+type queryTablesKey struct{}
+
+// queryTables holds the tables looked up during a query, so that each is only
+// looked up from the Controller once per query however many times its keys
+// are translated.
+type queryTables struct {
+	mu     sync.Mutex
+	tables map[dax.QualifiedTableID]*dax.QualifiedTable
+}
+
+// withQueryTables returns a copy of ctx which holds the tables looked up
+// with it.
+func withQueryTables(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryTablesKey{}, &queryTables{tables: make(map[dax.QualifiedTableID]*dax.QualifiedTable)})
+}
+
+// queryTable returns the table qtid, looking it up from controller unless ctx
+// already holds it.
+func queryTable(ctx context.Context, controller dax.Controller, qtid dax.QualifiedTableID) (*dax.QualifiedTable, error) {
+	t, _ := ctx.Value(queryTablesKey{}).(*queryTables)
+	if t == nil {
+		return controller.TableByID(ctx, qtid)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if qtbl, ok := t.tables[qtid]; ok {
+		return qtbl, nil
+	}
+	qtbl, err := controller.TableByID(ctx, qtid)
+	if err != nil {
+		return nil, err
+	}
+	t.tables[qtid] = qtbl
+	return qtbl, nil
+}
+
+// encryptedFields looks up whether fields are encrypted, and encrypts and
+// decrypts their values.
+type encryptedFields struct {
+	controller dax.Controller
+	cipher     *fieldCipher
+	cache      *fieldKeyCache
+
+	// decryptOrgs are the organizations permitted to read the values of
+	// encrypted fields.
+	decryptOrgs map[dax.OrganizationID]struct{}
+}
+
+func (q *Queryer) encryptedFields() *encryptedFields {
+	return &encryptedFields{
+		controller:  q.controller,
+		cipher:      q.cipher,
+		cache:       q.fieldKeys,
+		decryptOrgs: q.decryptOrgs,
+	}
+}
+
+// keys returns the keys for the field named fname in qtid, or nil if the field
+// isn't encrypted. filter is true if the values are being looked up for a
+// filter rather than being written; it's an error to filter on a field with
+// randomized encryption.
+func (e *encryptedFields) keys(ctx context.Context, qtid dax.QualifiedTableID, fname string, filter bool) (*fieldKeys, error) {
+	qtbl, err := queryTable(ctx, e.controller, qtid)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting table")
+	}
+	fld, ok := qtbl.Field(dax.FieldName(fname))
+	if !ok || fld.Options.Encryption == "" {
+		return nil, nil
+	}
+	if filter && fld.Options.Encryption == dax.FieldEncryptionRandomized {
+		return nil, dax.NewErrEncryptedField(fld.Name, "filtering on the values of a field with randomized encryption is not supported")
+	}
+	if e.cipher == nil {
+		return nil, dax.NewErrEncryptedField(fld.Name, "the queryer has no encryption key configured")
+	}
+	if e.cache == nil {
+		return e.cipher.forField(qtid, fld)
+	}
+	return e.cache.get(e.cipher, qtbl, fld)
+}
+
+// encrypt returns the ciphertexts of values, in the same order, or nil if the
+// field isn't encrypted.
+func (e *encryptedFields) encrypt(ctx context.Context, qtid dax.QualifiedTableID, fname string, values []string, filter bool) ([]string, error) {
+	k, err := e.keys(ctx, qtid, fname, filter)
+	if err != nil || k == nil {
+		return nil, err
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		if out[i], err = k.encrypt(v); err != nil {
+			return nil, errors.Wrapf(err, "encrypting value of field: %s", fname)
+		}
+	}
+	return out, nil
+}
+
+// decrypt decrypts values, in place, if the field is encrypted and the caller
+// held by ctx is permitted to read its values; otherwise, including when ctx
+// holds no caller, the values are left as they are.
+func (e *encryptedFields) decrypt(ctx context.Context, qtid dax.QualifiedTableID, fname string, values []string) error {
+	caller, ok := callerFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if _, ok := e.decryptOrgs[caller]; !ok {
+		return nil
+	}
+	k, err := e.keys(ctx, qtid, fname, false)
+	if err != nil || k == nil {
+		return err
+	}
+	for i, v := range values {
+		if values[i], err = k.decrypt(v); err != nil {
+			return errors.Wrapf(err, "decrypting value of field: %s", fname)
+		}
+	}
+	return nil
+}
+
+// rekey returns the IDs in m, which are keyed by the ciphertexts in enc,
+// keyed instead by the corresponding values.
+func rekey(m map[string]uint64, values, enc []string) map[string]uint64 {
+	out := make(map[string]uint64, len(m))
+	for i, v := range values {
+		if id, ok := m[enc[i]]; ok {
+			out[v] = id
+		}
+	}
+	return out
+}
+
+// encryptingTranslator is a Translator which encrypts the keys of encrypted
+// fields before they're translated, and decrypts the keys it translates IDs
+// to, so that the translation stores only hold ciphertexts.
+type encryptingTranslator struct {
+	Translator
+	fields *encryptedFields
+}
+
+func (t *encryptingTranslator) CreateFieldKeys(ctx context.Context, index string, field string, keys []string) (map[string]uint64, error) {
+	return t.translateKeys(ctx, index, field, keys, false, t.Translator.CreateFieldKeys)
+}
+
+func (t *encryptingTranslator) FindFieldKeys(ctx context.Context, index, field string, keys []string) (map[string]uint64, error) {
+	return t.translateKeys(ctx, index, field, keys, true, t.Translator.FindFieldKeys)
+}
+
+func (t *encryptingTranslator) translateKeys(ctx context.Context, index, field string, keys []string, filter bool, translate func(context.Context, string, string, []string) (map[string]uint64, error)) (map[string]uint64, error) {
+	enc, err := t.fields.encrypt(ctx, dax.TableKey(index).QualifiedTableID(), field, keys, filter)
+	if err != nil {
+		return nil, err
+	} else if enc == nil {
+		return translate(ctx, index, field, keys)
+	}
+	m, err := translate(ctx, index, field, enc)
+	if err != nil {
+		return nil, err
+	}
+	return rekey(m, keys, enc), nil
+}
+
+func (t *encryptingTranslator) TranslateFieldIDs(ctx context.Context, tableKeyer dax.TableKeyer, field string, ids map[uint64]struct{}) (map[uint64]string, error) {
+	m, err := t.Translator.TranslateFieldIDs(ctx, tableKeyer, field, ids)
+	if err != nil {
+		return nil, err
+	}
+	idList := make([]uint64, 0, len(m))
+	keys := make([]string, 0, len(m))
+	for id, key := range m {
+		idList = append(idList, id)
+		keys = append(keys, key)
+	}
+	if err := t.fields.decrypt(ctx, tableKeyer.Key().QualifiedTableID(), field, keys); err != nil {
+		return nil, err
+	}
+	for i, id := range idList {
+		m[id] = keys[i]
+	}
+	return m, nil
+}
+
+func (t *encryptingTranslator) TranslateFieldListIDs(ctx context.Context, index, field string, ids []uint64) ([]string, error) {
+	keys, err := t.Translator.TranslateFieldListIDs(ctx, index, field, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.fields.decrypt(ctx, dax.TableKey(index).QualifiedTableID(), field, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// encryptingImporter is an Importer which encrypts the keys of encrypted
+// fields before they're translated.
+type encryptingImporter struct {
+	featurebase.Importer
+	qdbid  dax.QualifiedDatabaseID
+	fields *encryptedFields
+}
+
+func (m *encryptingImporter) CreateFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	enc, err := m.fields.encrypt(ctx, dax.NewQualifiedTableID(m.qdbid, tid), string(fname), keys, false)
+	if err != nil {
+		return nil, err
+	} else if enc == nil {
+		return m.Importer.CreateFieldKeys(ctx, tid, fname, keys...)
+	}
+	ids, err := m.Importer.CreateFieldKeys(ctx, tid, fname, enc...)
+	if err != nil {
+		return nil, err
+	}
+	return rekey(ids, keys, enc), nil
+}
+
+func (m *encryptingImporter) FindFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	enc, err := m.fields.encrypt(ctx, dax.NewQualifiedTableID(m.qdbid, tid), string(fname), keys, false)
+	if err != nil {
+		return nil, err
+	} else if enc == nil {
+		return m.Importer.FindFieldKeys(ctx, tid, fname, keys...)
+	}
+	ids, err := m.Importer.FindFieldKeys(ctx, tid, fname, enc...)
+	if err != nil {
+		return nil, err
+	}
+	return rekey(ids, keys, enc), nil
+}
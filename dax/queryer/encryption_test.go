@@ -0,0 +1,271 @@
+package queryer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tableController is a Controller which holds a single table.
+type tableController struct {
+	dax.Controller
+	qtbl *dax.QualifiedTable
+}
+
+func (c *tableController) TableByID(ctx context.Context, qtid dax.QualifiedTableID) (*dax.QualifiedTable, error) {
+	return c.qtbl, nil
+}
+
+// keyStore is a Translator which translates the keys of fields in memory.
+type keyStore struct {
+	Translator
+	ids map[string]uint64
+}
+
+func (s *keyStore) CreateFieldKeys(ctx context.Context, index, field string, keys []string) (map[string]uint64, error) {
+	out := make(map[string]uint64)
+	for _, k := range keys {
+		if _, ok := s.ids[k]; !ok {
+			s.ids[k] = uint64(len(s.ids) + 1)
+		}
+		out[k] = s.ids[k]
+	}
+	return out, nil
+}
+
+func (s *keyStore) FindFieldKeys(ctx context.Context, index, field string, keys []string) (map[string]uint64, error) {
+	out := make(map[string]uint64)
+	for _, k := range keys {
+		if id, ok := s.ids[k]; ok {
+			out[k] = id
+		}
+	}
+	return out, nil
+}
+
+func (s *keyStore) TranslateFieldListIDs(ctx context.Context, index, field string, ids []uint64) ([]string, error) {
+	out := make([]string, len(ids))
+	for k, id := range s.ids {
+		for i := range ids {
+			if ids[i] == id {
+				out[i] = k
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, dataKeySize)
+	c, err := newFieldCipher(key)
+	require.NoError(t, err)
+	_, err = newFieldCipher(key[1:])
+	assert.Error(t, err)
+
+	qtid := dax.NewQualifiedTableID(dax.NewQualifiedDatabaseID("org", "db"), "tbl")
+	qtbl := dax.NewQualifiedTable(qtid.QualifiedDatabaseID, &dax.Table{
+		ID:   qtid.ID,
+		Name: "tbl",
+		Fields: []*dax.Field{
+			{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID},
+			{Name: "ssn", Type: dax.BaseTypeString, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionDeterministic}},
+			{Name: "tin", Type: dax.BaseTypeString, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionDeterministic}},
+			{Name: "notes", Type: dax.BaseTypeStringSet, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionRandomized}},
+			{Name: "region", Type: dax.BaseTypeString},
+		},
+	})
+	field := func(name dax.FieldName) *dax.Field {
+		fld, ok := qtbl.Field(name)
+		require.True(t, ok)
+		return fld
+	}
+
+	t.Run("Cipher", func(t *testing.T) {
+		ssn, err := c.forField(qtid, field("ssn"))
+		require.NoError(t, err)
+		tin, err := c.forField(qtid, field("tin"))
+		require.NoError(t, err)
+		notes, err := c.forField(qtid, field("notes"))
+		require.NoError(t, err)
+
+		a, err := ssn.encrypt("123-45-6789")
+		require.NoError(t, err)
+		b, err := ssn.encrypt("123-45-6789")
+		require.NoError(t, err)
+		assert.Equal(t, a, b)
+		assert.NotContains(t, a, "6789")
+		v, err := ssn.decrypt(a)
+		require.NoError(t, err)
+		assert.Equal(t, "123-45-6789", v)
+
+		// Equal values of different fields have different ciphertexts.
+		b, err = tin.encrypt("123-45-6789")
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+		_, err = tin.decrypt(a)
+		assert.Error(t, err)
+
+		// So do those of a field dropped and added again.
+		readded := *field("ssn")
+		readded.Revision++
+		ssn2, err := c.forField(qtid, &readded)
+		require.NoError(t, err)
+		b, err = ssn2.encrypt("123-45-6789")
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+
+		// Randomized ciphertexts differ for every write.
+		a, err = notes.encrypt("private")
+		require.NoError(t, err)
+		b, err = notes.encrypt("private")
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+		for _, s := range []string{a, b} {
+			v, err := notes.decrypt(s)
+			require.NoError(t, err)
+			assert.Equal(t, "private", v)
+		}
+
+		// Tampered ciphertexts are rejected.
+		a, err = ssn.encrypt("x")
+		require.NoError(t, err)
+		_, err = ssn.decrypt(strings.ToUpper(a))
+		assert.Error(t, err)
+		v, err = ssn.decrypt("")
+		require.NoError(t, err)
+		assert.Equal(t, "", v)
+	})
+
+	t.Run("Translator", func(t *testing.T) {
+		ctx := withCaller(context.Background(), "org")
+		store := &keyStore{ids: make(map[string]uint64)}
+		fields := &encryptedFields{
+			controller:  &tableController{qtbl: qtbl},
+			cipher:      c,
+			cache:       newFieldKeyCache(),
+			decryptOrgs: map[dax.OrganizationID]struct{}{"org": {}},
+		}
+		trans := &encryptingTranslator{
+			Translator: store,
+			fields:     fields,
+		}
+		index := string(qtid.Key())
+
+		ids, err := trans.CreateFieldKeys(ctx, index, "ssn", []string{"111", "222"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]uint64{"111": 1, "222": 2}, ids)
+		for k := range store.ids {
+			assert.NotEqual(t, "111", k)
+			assert.NotEqual(t, "222", k)
+		}
+
+		ids, err = trans.FindFieldKeys(ctx, index, "ssn", []string{"222", "333"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]uint64{"222": 2}, ids)
+
+		keys, err := trans.TranslateFieldListIDs(ctx, index, "ssn", []uint64{2, 1, 9})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"222", "111", ""}, keys)
+
+		// Unencrypted fields are translated as they are.
+		ids, err = trans.CreateFieldKeys(ctx, index, "region", []string{"east"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]uint64{"east": 3}, ids)
+		assert.Contains(t, store.ids, "east")
+
+		// Fields with randomized encryption can be written, but not filtered.
+		ids, err = trans.CreateFieldKeys(ctx, index, "notes", []string{"private"})
+		require.NoError(t, err)
+		assert.Len(t, ids, 1)
+		_, err = trans.FindFieldKeys(ctx, index, "notes", []string{"private"})
+		assert.True(t, errors.Is(err, dax.ErrEncryptedField), "got %v", err)
+
+		// Callers from organizations not permitted to decrypt read the
+		// ciphertexts, even of a permitted organization's table, as do
+		// callers which aren't known.
+		for _, ctx := range []context.Context{withCaller(ctx, "other"), context.Background()} {
+			keys, err = trans.TranslateFieldListIDs(ctx, index, "ssn", []uint64{2})
+			require.NoError(t, err)
+			assert.Len(t, keys, 1)
+			assert.NotEqual(t, "222", keys[0])
+			assert.Contains(t, store.ids, keys[0])
+		}
+
+		// Without a key, encrypted fields can be neither written nor read.
+		trans.fields = &encryptedFields{controller: &tableController{qtbl: qtbl}, decryptOrgs: fields.decryptOrgs}
+		_, err = trans.CreateFieldKeys(ctx, index, "ssn", []string{"444"})
+		assert.True(t, errors.Is(err, dax.ErrEncryptedField), "got %v", err)
+		_, err = trans.TranslateFieldListIDs(ctx, index, "ssn", []uint64{1})
+		assert.True(t, errors.Is(err, dax.ErrEncryptedField), "got %v", err)
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		ctrl := &countingTableController{tableController: tableController{qtbl: qtbl}}
+		fields := &encryptedFields{controller: ctrl, cipher: c, cache: newFieldKeyCache()}
+
+		// Within a query, the table is looked up once.
+		ctx := withQueryTables(context.Background())
+		a, err := fields.keys(ctx, qtid, "ssn", false)
+		require.NoError(t, err)
+		b, err := fields.keys(ctx, qtid, "ssn", true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, ctrl.n)
+		assert.Same(t, a, b)
+
+		// Keys are reused across queries until the table's schema changes.
+		b, err = fields.keys(withQueryTables(context.Background()), qtid, "ssn", false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, ctrl.n)
+		assert.Same(t, a, b)
+
+		changed := qtbl.Table
+		changed.Fields = append(append([]*dax.Field(nil), qtbl.Fields...), &dax.Field{Name: "zip", Type: dax.BaseTypeString})
+		ctrl.qtbl = dax.NewQualifiedTable(qtid.QualifiedDatabaseID, &changed)
+		b, err = fields.keys(withQueryTables(context.Background()), qtid, "ssn", false)
+		require.NoError(t, err)
+		assert.NotSame(t, a, b)
+
+		// A field dropped and added again, with the same name and type but
+		// another encryption mode, changes the table's version, and gets
+		// keys for its new mode.
+		readded := changed
+		readded.Fields = nil
+		for _, fld := range changed.Fields {
+			if fld.Name == "ssn" {
+				f := *fld
+				f.Options.Encryption = dax.FieldEncryptionRandomized
+				f.Revision = fld.Revision + 1
+				fld = &f
+			}
+			readded.Fields = append(readded.Fields, fld)
+		}
+		require.NotEqual(t, changed.Version(), readded.Version())
+		ctrl.qtbl = dax.NewQualifiedTable(qtid.QualifiedDatabaseID, &readded)
+		b, err = fields.keys(withQueryTables(context.Background()), qtid, "ssn", false)
+		require.NoError(t, err)
+		assert.Equal(t, dax.FieldEncryptionRandomized, b.mode)
+		ct1, err := b.encrypt("222")
+		require.NoError(t, err)
+		ct2, err := b.encrypt("222")
+		require.NoError(t, err)
+		assert.NotEqual(t, ct1, ct2)
+	})
+}
+
+// countingTableController is a tableController which counts the tables looked
+// up from it.
+type countingTableController struct {
+	tableController
+	n int
+}
+
+func (c *countingTableController) TableByID(ctx context.Context, qtid dax.QualifiedTableID) (*dax.QualifiedTable, error) {
+	c.n++
+	return c.qtbl, nil
+}
@@ -0,0 +1,219 @@
+package queryer
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+// Export destination schemes.
+const (
+	ExportSchemeS3   = "s3"
+	ExportSchemeGCS  = "gs"
+	ExportSchemeFile = "file"
+)
+
+// DefaultGCSEndpoint is the endpoint of Google Cloud Storage's S3-compatible
+// API, through which gs:// destinations are written.
+const DefaultGCSEndpoint = "https://storage.googleapis.com"
+
+// DefaultExportFormat is the format of exported results when the request
+// doesn't name one.
+const DefaultExportFormat = OutputFormatCSV
+
+// ExportFormats are the formats in which query results may be exported.
+var ExportFormats = []OutputFormat{OutputFormatCSV, OutputFormatArrow, OutputFormatParquet}
+
+// ExportConfig configures the object stores to which query results may be
+// exported, instead of being returned to the client. Exports are disabled
+// unless Buckets or Dir is set.
+type ExportConfig struct {
+	// Buckets are the S3 and GCS buckets to which results may be exported.
+	// Since results are written with the Queryer's credentials, not the
+	// client's, no other bucket is written.
+	Buckets []string `toml:"buckets"`
+
+	// S3Region is the region of the S3 buckets. If empty, the region is
+	// taken from the environment, as by the AWS CLI.
+	S3Region string `toml:"s3-region"`
+
+	// S3Endpoint, if set, is the endpoint of an S3-compatible store to use
+	// for s3:// destinations instead of Amazon S3.
+	S3Endpoint string `toml:"s3-endpoint"`
+
+	// GCSEndpoint is the endpoint through which gs:// destinations are
+	// written. An empty value uses DefaultGCSEndpoint.
+	GCSEndpoint string `toml:"gcs-endpoint"`
+
+	// GCSProfile is the profile, in the AWS shared credentials file, which
+	// holds the HMAC key used for gs:// destinations. If empty, the key is
+	// taken from the environment, as for S3.
+	GCSProfile string `toml:"gcs-profile"`
+
+	// Dir is the directory to which file:// destinations are written, for
+	// exports to a volume shared with the Queryer. A destination's host
+	// names a subdirectory of Dir, and its path a file within that. If
+	// empty, file:// destinations are rejected.
+	Dir string `toml:"dir"`
+}
+
+// ExportDestination is the object to which query results are exported, given
+// as a URL of the form <scheme>://<bucket>/<key>.
+type ExportDestination struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// ParseExportDestination parses an export destination URL.
+func ParseExportDestination(s string) (ExportDestination, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return ExportDestination{}, errors.Wrapf(err, "parsing export destination: %s", s)
+	}
+	switch u.Scheme {
+	case ExportSchemeS3, ExportSchemeGCS, ExportSchemeFile:
+	default:
+		return ExportDestination{}, errors.Errorf("export destination '%s' must be an %s://, %s:// or %s:// URL", s, ExportSchemeS3, ExportSchemeGCS, ExportSchemeFile)
+	}
+	dest := ExportDestination{
+		Scheme: u.Scheme,
+		Bucket: u.Host,
+		Key:    strings.TrimPrefix(u.Path, "/"),
+	}
+	if dest.Bucket == "" || dest.Key == "" || strings.HasSuffix(dest.Key, "/") {
+		return ExportDestination{}, errors.Errorf("export destination '%s' must name a bucket and an object", s)
+	}
+	return dest, nil
+}
+
+// String returns the URL of d.
+func (d ExportDestination) String() string {
+	return d.Scheme + "://" + d.Bucket + "/" + d.Key
+}
+
+// ParseExportFormat returns the export format named by s. The empty string is
+// DefaultExportFormat.
+func ParseExportFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(strings.ToLower(s)); f {
+	case "":
+		return DefaultExportFormat, nil
+	case OutputFormatCSV, OutputFormatArrow, OutputFormatParquet:
+		return f, nil
+	default:
+		return "", errors.Errorf("invalid export format '%s': expected '%s', '%s' or '%s'", s, OutputFormatCSV, OutputFormatArrow, OutputFormatParquet)
+	}
+}
+
+// exportStores creates, on first use, and holds the ObjectStore for each
+// export destination scheme.
+type exportStores struct {
+	cfg     ExportConfig
+	buckets map[string]struct{}
+	logger  logger.Logger
+
+	mu     sync.Mutex
+	stores map[string]snapshotter.ObjectStore
+}
+
+func newExportStores(cfg ExportConfig, log logger.Logger) *exportStores {
+	e := &exportStores{
+		cfg:     cfg,
+		buckets: make(map[string]struct{}, len(cfg.Buckets)),
+		logger:  log,
+		stores:  make(map[string]snapshotter.ObjectStore),
+	}
+	for _, b := range cfg.Buckets {
+		e.buckets[b] = struct{}{}
+	}
+	return e
+}
+
+// SetExportStore sets the ObjectStore to which results are exported for
+// destinations with the given scheme, in place of the one the Queryer would
+// create from its ExportConfig. Unless the scheme is file, the destination's
+// bucket must still be one of the configured buckets.
+func (q *Queryer) SetExportStore(scheme string, store snapshotter.ObjectStore) {
+	q.exports.mu.Lock()
+	defer q.exports.mu.Unlock()
+	q.exports.stores[scheme] = store
+}
+
+// ExportStore returns the ObjectStore to which results exported to dest are
+// written. It returns an error if the Queryer isn't permitted to write to
+// dest.
+func (q *Queryer) ExportStore(dest ExportDestination) (snapshotter.ObjectStore, error) {
+	e := q.exports
+	if dest.Scheme == ExportSchemeFile {
+		if e.cfg.Dir == "" {
+			return nil, errors.Errorf("exports to %s:// destinations are not enabled", ExportSchemeFile)
+		}
+	} else if _, ok := e.buckets[dest.Bucket]; !ok {
+		return nil, errors.Errorf("exports to bucket '%s' are not permitted", dest.Bucket)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if store, ok := e.stores[dest.Scheme]; ok {
+		return store, nil
+	}
+	store, err := e.newStore(dest.Scheme)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s:// export store", dest.Scheme)
+	}
+	e.stores[dest.Scheme] = store
+	return store, nil
+}
+
+// newStore creates the ObjectStore for scheme.
+func (e *exportStores) newStore(scheme string) (snapshotter.ObjectStore, error) {
+	switch scheme {
+	case ExportSchemeFile:
+		dir, err := filepath.Abs(e.cfg.Dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "resolving export directory")
+		}
+		return snapshotter.New(dir, e.logger), nil
+	case ExportSchemeGCS:
+		endpoint := e.cfg.GCSEndpoint
+		if endpoint == "" {
+			endpoint = DefaultGCSEndpoint
+		}
+		config := &aws.Config{
+			// GCS ignores the region, but the SDK requires one.
+			Region:   aws.String("auto"),
+			Endpoint: aws.String(endpoint),
+		}
+		if e.cfg.GCSProfile != "" {
+			config.Credentials = credentials.NewSharedCredentials("", e.cfg.GCSProfile)
+		}
+		sess, err := session.NewSession(config)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating GCS session")
+		}
+		return snapshotter.NewS3Store(s3.New(sess)), nil
+	default:
+		config := &aws.Config{}
+		if e.cfg.S3Region != "" {
+			config.Region = aws.String(e.cfg.S3Region)
+		}
+		if e.cfg.S3Endpoint != "" {
+			config.Endpoint = aws.String(e.cfg.S3Endpoint)
+			config.S3ForcePathStyle = aws.Bool(true)
+		}
+		sess, err := session.NewSession(config)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating S3 session")
+		}
+		return snapshotter.NewS3Store(s3.New(sess)), nil
+	}
+}
@@ -0,0 +1,71 @@
+package queryer
+
+import (
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// OutputFormat is an encoding in which the Queryer's HTTP handler can return
+// the results of a query.
+type OutputFormat string
+
+const (
+	// OutputFormatJSON encodes a featurebase.WireQueryResponse as JSON. It's
+	// the only format which carries everything in the response, such as
+	// warnings, paging and debug output, besides the schema and rows.
+	OutputFormatJSON OutputFormat = "json"
+
+	// OutputFormatCSV encodes the rows as CSV, with a header row of column
+	// names.
+	OutputFormatCSV OutputFormat = "csv"
+
+	// OutputFormatArrow encodes the schema and rows as an Apache Arrow IPC
+	// stream.
+	OutputFormatArrow OutputFormat = "arrow"
+
+	// OutputFormatParquet encodes the schema and rows as an Apache Parquet
+	// file. Since a Parquet file can't be read until it's complete, it's
+	// only used for exports (see ExportFormats), not returned to clients.
+	OutputFormatParquet OutputFormat = "parquet"
+)
+
+// DefaultOutputFormat is the OutputFormat used when neither the client nor the
+// configuration specify one.
+const DefaultOutputFormat = OutputFormatJSON
+
+// OutputFormats are the supported output formats, in order of preference.
+var OutputFormats = []OutputFormat{OutputFormatJSON, OutputFormatCSV, OutputFormatArrow}
+
+// ParseOutputFormat returns the OutputFormat named by s. The empty string is
+// DefaultOutputFormat.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(strings.ToLower(s)); f {
+	case "":
+		return DefaultOutputFormat, nil
+	case OutputFormatJSON, OutputFormatCSV, OutputFormatArrow:
+		return f, nil
+	default:
+		return "", errors.Errorf("invalid output format '%s': expected '%s', '%s' or '%s'", s, OutputFormatJSON, OutputFormatCSV, OutputFormatArrow)
+	}
+}
+
+// ContentType returns the media type of f.
+func (f OutputFormat) ContentType() string {
+	switch f {
+	case OutputFormatCSV:
+		return "text/csv"
+	case OutputFormatArrow:
+		return "application/vnd.apache.arrow.stream"
+	case OutputFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/json"
+	}
+}
+
+// DefaultOutputFormat returns the format in which query results are returned
+// to a client which doesn't ask for one.
+func (q *Queryer) DefaultOutputFormat() OutputFormat {
+	return q.outputFormat
+}
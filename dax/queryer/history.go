@@ -0,0 +1,113 @@
+package queryer
+
+import (
+	"sync"
+	"time"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// DefaultQueryHistorySize is the default number of recent queries kept in the
+// query history.
+const DefaultQueryHistorySize = 100
+
+// DefaultSlowQueryThreshold is the default duration above which a query is
+// logged as slow.
+const DefaultSlowQueryThreshold = 10 * time.Second
+
+// maxRecordedSQL is the length beyond which the text of a query is truncated
+// in the query history.
+const maxRecordedSQL = 4096
+
+// QueryRecord describes a query which the queryer has completed, as kept in the
+// query history.
+type QueryRecord struct {
+	Database  dax.QualifiedDatabaseID `json:"database"`
+	SQL       string                  `json:"sql,omitempty"`
+	Statement string                  `json:"statement,omitempty"`
+	Labels    dax.QueryLabels         `json:"labels,omitempty"`
+	Start     time.Time               `json:"start"`
+	Duration  time.Duration           `json:"duration"`
+	Error     string                  `json:"error,omitempty"`
+}
+
+// queryHistory holds the most recent QueryRecords, up to a fixed number.
+type queryHistory struct {
+	mu      sync.Mutex
+	records []QueryRecord
+	next    int // index in records at which the next record is written
+	full    bool
+}
+
+func newQueryHistory(size int) *queryHistory {
+	return &queryHistory{
+		records: make([]QueryRecord, size),
+	}
+}
+
+func (h *queryHistory) add(rec QueryRecord) {
+	if len(h.records) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records[h.next] = rec
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the records for the given organization, most recent first.
+func (h *queryHistory) list(orgID dax.OrganizationID) []QueryRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.records)
+	}
+	out := make([]QueryRecord, 0)
+	for i := 1; i <= n; i++ {
+		rec := h.records[(h.next-i+len(h.records))%len(h.records)]
+		if rec.Database.OrganizationID == orgID {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// QueryHistory returns the most recent queries made against the given
+// organization's databases, most recent first.
+func (q *Queryer) QueryHistory(orgID dax.OrganizationID) []QueryRecord {
+	return q.history.list(orgID)
+}
+
+// recordQuery records a completed query in the query history, in the slow query
+// log (if it was slow), and, by label, in metrics. resp and err are the
+// results of the query.
+func (q *Queryer) recordQuery(rec QueryRecord, resp *featurebase.WireQueryResponse, err error) {
+	if err != nil {
+		rec.Error = err.Error()
+	} else if resp != nil {
+		rec.Error = resp.Error
+	}
+	if len(rec.SQL) > maxRecordedSQL {
+		rec.SQL = rec.SQL[:maxRecordedSQL] + "..."
+	}
+	q.history.add(rec)
+
+	if q.slowQueryThreshold >= 0 && rec.Duration >= q.slowQueryThreshold {
+		query := rec.SQL
+		if rec.Statement != "" {
+			query = "statement " + rec.Statement
+		}
+		q.logger.Warnf("slow query: database=%s labels=[%s] duration=%s query=%q", rec.Database, rec.Labels, rec.Duration, query)
+	}
+
+	for k, v := range rec.Labels {
+		if mv, ok := q.labelPolicy.MetricValue(k, v); ok {
+			dax.HistogramLabeledQueryDurationSeconds.WithLabelValues(k, mv).Observe(rec.Duration.Seconds())
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
+)
+
+// exportRequest is a request, made with ?export=<url>&export-format=<format>,
+// that the results of a query be written to an object store instead of being
+// returned to the client.
+type exportRequest struct {
+	dest   queryer.ExportDestination
+	format queryer.OutputFormat
+	store  snapshotter.ObjectStore
+}
+
+// ExportResponse is the response to a query whose results were exported. If
+// Error is set, the export failed, and nothing was written to Location.
+type ExportResponse struct {
+	Location      string               `json:"location"`
+	Format        queryer.OutputFormat `json:"format"`
+	Rows          int                  `json:"rows"`
+	Bytes         int64                `json:"bytes"`
+	Warnings      []string             `json:"warnings,omitempty"`
+	ExecutionTime int64                `json:"execution-time"`
+	Error         string               `json:"error,omitempty"`
+	ErrorCode     string               `json:"error-code,omitempty"`
+}
+
+// queryExport returns the export requested by r, if any, and whether one was
+// requested. The destination is checked before the query runs, so that a
+// query isn't wasted on results which can't be written.
+func (s *server) queryExport(r *http.Request) (exportRequest, bool, error) {
+	q := r.URL.Query()
+	if q.Get("export") == "" {
+		return exportRequest{}, false, nil
+	}
+	dest, err := queryer.ParseExportDestination(q.Get("export"))
+	if err != nil {
+		return exportRequest{}, false, err
+	}
+	format, err := queryer.ParseExportFormat(q.Get("export-format"))
+	if err != nil {
+		return exportRequest{}, false, err
+	}
+	store, err := s.queryer.ExportStore(dest)
+	if err != nil {
+		return exportRequest{}, false, err
+	}
+	return exportRequest{dest: dest, format: format, store: store}, true, nil
+}
+
+// writeExport writes the results in resp to the export's destination, and
+// writes an ExportResponse to w. If the query failed, nothing is written to
+// the destination, and the response is a 400 (as for any other failed query).
+// If writing the object fails, it's discarded by the store (see
+// snapshotter.ObjectStore), so that no partial object is left at the
+// destination, and the response is a 502.
+func writeExport(ctx context.Context, w http.ResponseWriter, exp exportRequest, resp *featurebase.WireQueryResponse) {
+	out := ExportResponse{
+		Location:      exp.dest.String(),
+		Format:        exp.format,
+		Rows:          len(resp.Data),
+		Warnings:      resp.Warnings,
+		ExecutionTime: resp.ExecutionTime,
+	}
+	status := http.StatusOK
+	if resp.Error != "" {
+		out.Rows = 0
+		out.Error, out.ErrorCode = resp.Error, resp.ErrorCode
+		status = http.StatusBadRequest
+		dax.CounterQueryerExports.WithLabelValues(string(exp.format), "query_error").Inc()
+	} else if n, err := putExport(ctx, exp, resp); err != nil {
+		out.Error = err.Error()
+		status = http.StatusBadGateway
+		dax.CounterQueryerExports.WithLabelValues(string(exp.format), "store_error").Inc()
+	} else {
+		out.Bytes = n
+		dax.CounterQueryerExports.WithLabelValues(string(exp.format), "success").Inc()
+		dax.CounterQueryerExportBytes.Add(float64(n))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// putExport encodes resp in the export's format and streams it to the
+// export's store, returning the size of the object written. If encoding
+// fails, the store sees a read error, and discards the object.
+func putExport(ctx context.Context, exp exportRequest, resp *featurebase.WireQueryResponse) (int64, error) {
+	pr, pw := io.Pipe()
+	cw := &countingWriter{w: pw}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(encodeExport(cw, exp.format, resp))
+	}()
+	err := exp.store.Put(ctx, exp.dest.Bucket, exp.dest.Key, pr)
+	// If the store gave up before reading everything, unblock the encoder.
+	pr.Close()
+	<-done
+	return cw.n, err
+}
+
+// encodeExport writes the schema and rows of resp to w in format.
+func encodeExport(w io.Writer, format queryer.OutputFormat, resp *featurebase.WireQueryResponse) error {
+	switch format {
+	case queryer.OutputFormatArrow:
+		return writeArrow(w, resp)
+	case queryer.OutputFormatParquet:
+		return writeParquet(w, resp)
+	default:
+		return writeCSV(w, resp)
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
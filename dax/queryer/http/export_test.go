@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is an ObjectStore whose writes fail after reading part of the
+// object.
+type failingStore struct {
+	read int
+}
+
+func (s *failingStore) Put(ctx context.Context, bucket, key string, r io.Reader) error {
+	buf := make([]byte, 4)
+	n, err := io.ReadFull(r, buf)
+	s.read += n
+	if err != nil {
+		return err
+	}
+	return errors.New(errors.ErrUncoded, "connection reset")
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	s := &server{queryer: queryer.New(queryer.Config{
+		Export: queryer.ExportConfig{Buckets: []string{"etl"}, Dir: dir},
+	})}
+	newResp := func() *featurebase.WireQueryResponse {
+		return &featurebase.WireQueryResponse{
+			Schema: featurebase.WireQuerySchema{
+				Fields: []*featurebase.WireQueryField{
+					{Name: "_id", BaseType: dax.BaseTypeID},
+					{Name: "name", BaseType: dax.BaseTypeString},
+				},
+			},
+			Data: [][]interface{}{
+				{int64(1), "a"},
+				{int64(2), nil},
+			},
+			ExecutionTime: 42,
+		}
+	}
+	export := func(t *testing.T, args string, resp *featurebase.WireQueryResponse) (int, ExportResponse) {
+		t.Helper()
+		exp, ok, err := s.queryExport(httptest.NewRequest("POST", "/sql?"+args, nil))
+		require.NoError(t, err)
+		require.True(t, ok)
+		w := httptest.NewRecorder()
+		writeExport(context.Background(), w, exp, resp)
+		var out ExportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &out))
+		return w.Code, out
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		code, out := export(t, "export=file://daily/2022/results.csv", newResp())
+		assert.Equal(t, 200, code)
+		assert.Equal(t, ExportResponse{
+			Location:      "file://daily/2022/results.csv",
+			Format:        queryer.OutputFormatCSV,
+			Rows:          2,
+			Bytes:         16,
+			ExecutionTime: 42,
+		}, out)
+		b, err := os.ReadFile(filepath.Join(dir, "daily", "2022", "results.csv"))
+		require.NoError(t, err)
+		assert.Equal(t, "_id,name\n1,a\n2,\n", string(b))
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		code, out := export(t, "export=file://daily/results.parquet&export-format=parquet", newResp())
+		require.Equal(t, 200, code, out.Error)
+		b, err := os.ReadFile(filepath.Join(dir, "daily", "results.parquet"))
+		require.NoError(t, err)
+		assert.EqualValues(t, len(b), out.Bytes)
+
+		tbl, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(b), parquet.NewReaderProperties(nil), pqarrow.ArrowReadProperties{}, memory.NewGoAllocator())
+		require.NoError(t, err)
+		defer tbl.Release()
+		require.EqualValues(t, 2, tbl.NumRows())
+		names := tbl.Column(1).Data().Chunk(0).(*array.String)
+		assert.Equal(t, "a", names.Value(0))
+		assert.True(t, names.IsNull(1))
+	})
+
+	t.Run("QueryError", func(t *testing.T) {
+		resp := newResp()
+		resp.Error, resp.ErrorCode = "table not found", "ErrTableNotFound"
+		code, out := export(t, "export=file://daily/failed.csv", resp)
+		assert.Equal(t, 400, code)
+		assert.Equal(t, "table not found", out.Error)
+		assert.Equal(t, "ErrTableNotFound", out.ErrorCode)
+		_, err := os.Stat(filepath.Join(dir, "daily", "failed.csv"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("StoreError", func(t *testing.T) {
+		store := &failingStore{}
+		s.queryer.SetExportStore(queryer.ExportSchemeS3, store)
+		code, out := export(t, "export=s3://etl/results.arrow&export-format=arrow", newResp())
+		assert.Equal(t, 502, code)
+		assert.Equal(t, "s3://etl/results.arrow", out.Location)
+		assert.Contains(t, out.Error, "connection reset")
+		assert.Equal(t, 4, store.read)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, args := range []string{
+			"export=s3://other/results.csv",
+			"export=s3://etl/",
+			"export=http://etl/results.csv",
+			"export=s3://etl/results.json&export-format=json",
+			"export=gs://etl",
+		} {
+			_, _, err := s.queryExport(httptest.NewRequest("POST", "/sql?"+args, nil))
+			assert.Error(t, err, args)
+		}
+
+		_, ok, err := s.queryExport(httptest.NewRequest("POST", "/sql", nil))
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
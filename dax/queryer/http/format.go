@@ -0,0 +1,360 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/decimal128"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet"
+	"github.com/apache/arrow/go/v10/parquet/compress"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/pql"
+)
+
+// negotiateOutputFormat returns the output format for a request with the
+// given Accept header. The acceptable format with the highest quality wins,
+// the earliest one listed breaking ties; a header which is empty, or which
+// accepts any type (*/*) at least as much as any supported one, gets def. It
+// returns an error if the header accepts none of the supported formats.
+func negotiateOutputFormat(accept string, def queryer.OutputFormat) (queryer.OutputFormat, error) {
+	if strings.TrimSpace(accept) == "" {
+		return def, nil
+	}
+	var best queryer.OutputFormat
+	bestQ := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		var f queryer.OutputFormat
+		switch mediaType {
+		case "*/*":
+			f = def
+		case "application/*":
+			f = queryer.OutputFormatJSON
+			if def == queryer.OutputFormatArrow {
+				f = def
+			}
+		case "text/*":
+			f = queryer.OutputFormatCSV
+		default:
+			for _, of := range queryer.OutputFormats {
+				if of.ContentType() == mediaType {
+					f = of
+				}
+			}
+		}
+		if f != "" {
+			best, bestQ = f, q
+		}
+	}
+	if best == "" {
+		return "", errors.Errorf("none of the accepted types '%s' are supported: expected %s", accept, supportedContentTypes())
+	}
+	return best, nil
+}
+
+// supportedContentTypes lists the media types of the supported output formats.
+func supportedContentTypes() string {
+	types := make([]string, len(queryer.OutputFormats))
+	for i, f := range queryer.OutputFormats {
+		types[i] = f.ContentType()
+	}
+	return strings.Join(types, ", ")
+}
+
+// writeQueryResponse writes resp to w in the given format. Only JSON carries
+// the whole response, and the null mode; the other formats carry only the
+// schema and the rows.
+func writeQueryResponse(w http.ResponseWriter, format queryer.OutputFormat, nulls featurebase.NullMode, resp *featurebase.WireQueryResponse) error {
+	if format != queryer.OutputFormatJSON && resp.Error != "" {
+		http.Error(w, resp.Error, http.StatusBadRequest)
+		return nil
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	switch format {
+	case queryer.OutputFormatCSV:
+		return writeCSV(w, resp)
+	case queryer.OutputFormatArrow:
+		return writeArrow(w, resp)
+	default:
+		resp.ApplyNullMode(nulls)
+		return json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// writeCSV writes the rows of resp as CSV, with a header row of column names.
+// A column with no value is empty.
+func writeCSV(w io.Writer, resp *featurebase.WireQueryResponse) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(resp.Schema.Fields))
+	for i, fld := range resp.Schema.Fields {
+		record[i] = string(fld.Name)
+	}
+	if err := cw.Write(record); err != nil {
+		return errors.Wrap(err, "writing csv header")
+	}
+	for _, row := range resp.Data {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = formatCSVValue(row[i])
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.Wrap(err, "writing csv row")
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatCSVValue formats a value in a CSV cell. Sets are formatted as JSON
+// arrays.
+func formatCSVValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case int64, uint64, int, bool, float64:
+		return fmt.Sprint(v)
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(buf)
+	}
+}
+
+// writeArrow writes the schema and rows of resp as an Arrow IPC stream of a
+// single record batch.
+func writeArrow(w io.Writer, resp *featurebase.WireQueryResponse) error {
+	mem := memory.NewGoAllocator()
+	rec, err := arrowRecord(mem, resp)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+
+	aw := ipc.NewWriter(w, ipc.WithSchema(rec.Schema()), ipc.WithAllocator(mem))
+	if err := aw.Write(rec); err != nil {
+		aw.Close()
+		return errors.Wrap(err, "writing arrow record")
+	}
+	return aw.Close()
+}
+
+// writeParquet writes the schema and rows of resp as a Parquet file of a
+// single, snappy-compressed row group, with the same column types as
+// writeArrow.
+func writeParquet(w io.Writer, resp *featurebase.WireQueryResponse) error {
+	mem := memory.NewGoAllocator()
+	rec, err := arrowRecord(mem, resp)
+	if err != nil {
+		return err
+	}
+	defer rec.Release()
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy), parquet.WithAllocator(mem))
+	pw, err := pqarrow.NewFileWriter(rec.Schema(), w, props, pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(mem)))
+	if err != nil {
+		return errors.Wrap(err, "creating parquet writer")
+	}
+	if err := pw.Write(rec); err != nil {
+		pw.Close()
+		return errors.Wrap(err, "writing parquet row group")
+	}
+	return errors.Wrap(pw.Close(), "closing parquet writer")
+}
+
+// arrowRecord returns the schema and rows of resp as an Arrow record.
+func arrowRecord(mem memory.Allocator, resp *featurebase.WireQueryResponse) (arrow.Record, error) {
+	schema := arrowSchema(resp.Schema)
+	b := array.NewRecordBuilder(mem, schema)
+	defer b.Release()
+
+	for _, row := range resp.Data {
+		for i := range schema.Fields() {
+			var v interface{}
+			if i < len(row) {
+				v = row[i]
+			}
+			if err := appendArrowValue(b.Field(i), v); err != nil {
+				return nil, errors.Wrapf(err, "column %s", schema.Field(i).Name)
+			}
+		}
+	}
+	return b.NewRecord(), nil
+}
+
+// arrowSchema returns the Arrow schema of a WireQuerySchema. Columns of a type
+// with no Arrow equivalent are strings.
+func arrowSchema(s featurebase.WireQuerySchema) *arrow.Schema {
+	fields := make([]arrow.Field, len(s.Fields))
+	for i, fld := range s.Fields {
+		var dt arrow.DataType
+		switch fld.BaseType {
+		case dax.BaseTypeBool:
+			dt = arrow.FixedWidthTypes.Boolean
+		case dax.BaseTypeID, dax.BaseTypeInt:
+			dt = arrow.PrimitiveTypes.Int64
+		case dax.BaseTypeDecimal:
+			dt = &arrow.Decimal128Type{Precision: 38, Scale: int32(decimalScale(fld))}
+		case dax.BaseTypeTimestamp:
+			dt = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		case dax.BaseTypeIDSet, dax.BaseTypeIDSetQ:
+			dt = arrow.ListOf(arrow.PrimitiveTypes.Int64)
+		case dax.BaseTypeStringSet, dax.BaseTypeStringSetQ:
+			dt = arrow.ListOf(arrow.BinaryTypes.String)
+		default:
+			dt = arrow.BinaryTypes.String
+		}
+		fields[i] = arrow.Field{Name: string(fld.Name), Type: dt, Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// decimalScale returns the scale of a decimal column.
+func decimalScale(fld *featurebase.WireQueryField) int64 {
+	switch v := fld.TypeInfo["scale"].(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// appendArrowValue appends v to b, converting it to the builder's type.
+func appendArrowValue(b array.Builder, v interface{}) error {
+	if v == nil {
+		b.AppendNull()
+		return nil
+	}
+	switch b := b.(type) {
+	case *array.BooleanBuilder:
+		x, ok := v.(bool)
+		if !ok {
+			return errors.Errorf("can't convert %T to bool", v)
+		}
+		b.Append(x)
+	case *array.Int64Builder:
+		switch x := v.(type) {
+		case int64:
+			b.Append(x)
+		case uint64:
+			b.Append(int64(x))
+		case int:
+			b.Append(int64(x))
+		default:
+			return errors.Errorf("can't convert %T to int", v)
+		}
+	case *array.Decimal128Builder:
+		scale := b.Type().(*arrow.Decimal128Type).Scale
+		switch x := v.(type) {
+		case pql.Decimal:
+			b.Append(decimal128.FromI64(x.ToInt64(int64(scale))))
+		case *pql.Decimal:
+			b.Append(decimal128.FromI64(x.ToInt64(int64(scale))))
+		case float64:
+			n, err := decimal128.FromFloat64(x, 38, scale)
+			if err != nil {
+				return err
+			}
+			b.Append(n)
+		default:
+			return errors.Errorf("can't convert %T to decimal", v)
+		}
+	case *array.TimestampBuilder:
+		switch x := v.(type) {
+		case time.Time:
+			b.Append(arrow.Timestamp(x.UnixMicro()))
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, x)
+			if err != nil {
+				return errors.Wrap(err, "parsing timestamp")
+			}
+			b.Append(arrow.Timestamp(t.UnixMicro()))
+		default:
+			return errors.Errorf("can't convert %T to timestamp", v)
+		}
+	case *array.ListBuilder:
+		return appendArrowList(b, v)
+	case *array.StringBuilder:
+		b.Append(formatCSVValue(v))
+	default:
+		return errors.Errorf("unsupported arrow builder %T", b)
+	}
+	return nil
+}
+
+// appendArrowList appends the set v to b.
+func appendArrowList(b *array.ListBuilder, v interface{}) error {
+	var vals []interface{}
+	switch x := v.(type) {
+	case []int64:
+		for _, e := range x {
+			vals = append(vals, e)
+		}
+	case featurebase.IDSet:
+		for _, e := range x {
+			vals = append(vals, e)
+		}
+	case []uint64:
+		for _, e := range x {
+			vals = append(vals, e)
+		}
+	case []string:
+		for _, e := range x {
+			vals = append(vals, e)
+		}
+	case featurebase.StringSet:
+		for _, e := range x {
+			vals = append(vals, e)
+		}
+	case []interface{}:
+		vals = x
+	default:
+		return errors.Errorf("can't convert %T to a set", v)
+	}
+	b.Append(true)
+	for _, e := range vals {
+		if err := appendArrowValue(b.ValueBuilder(), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateOutputFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		def    queryer.OutputFormat
+		exp    queryer.OutputFormat
+	}{
+		{"", queryer.OutputFormatArrow, queryer.OutputFormatArrow},
+		{"*/*", queryer.OutputFormatCSV, queryer.OutputFormatCSV},
+		{"application/json", queryer.OutputFormatCSV, queryer.OutputFormatJSON},
+		{"text/csv", queryer.OutputFormatJSON, queryer.OutputFormatCSV},
+		{"application/vnd.apache.arrow.stream", queryer.OutputFormatJSON, queryer.OutputFormatArrow},
+		{"text/html, text/csv;q=0.5, */*;q=0.1", queryer.OutputFormatJSON, queryer.OutputFormatCSV},
+		{"application/json;q=0.5, text/csv", queryer.OutputFormatJSON, queryer.OutputFormatCSV},
+		{"text/csv, application/json", queryer.OutputFormatJSON, queryer.OutputFormatCSV},
+	}
+	for _, tt := range tests {
+		f, err := negotiateOutputFormat(tt.accept, tt.def)
+		if assert.NoError(t, err, tt.accept) {
+			assert.Equal(t, tt.exp, f, tt.accept)
+		}
+	}
+
+	_, err := negotiateOutputFormat("text/html, image/png", queryer.OutputFormatJSON)
+	assert.Error(t, err)
+}
+
+func TestWriteQueryResponse(t *testing.T) {
+	ts := time.Date(2022, 11, 1, 12, 30, 0, 0, time.UTC)
+	newResp := func() *featurebase.WireQueryResponse {
+		return &featurebase.WireQueryResponse{
+			Schema: featurebase.WireQuerySchema{
+				Fields: []*featurebase.WireQueryField{
+					{Name: "_id", BaseType: dax.BaseTypeID},
+					{Name: "name", BaseType: dax.BaseTypeString},
+					{Name: "price", BaseType: dax.BaseTypeDecimal, TypeInfo: map[string]interface{}{"scale": int64(2)}},
+					{Name: "tags", BaseType: dax.BaseTypeStringSet},
+					{Name: "at", BaseType: dax.BaseTypeTimestamp},
+				},
+			},
+			Data: [][]interface{}{
+				{int64(1), "a, b", pql.NewDecimal(1250, 2), []string{"x", "y"}, ts},
+				{int64(2), nil, nil, nil, nil},
+			},
+		}
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, writeQueryResponse(w, queryer.OutputFormatCSV, featurebase.NullModeEmit, newResp()))
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, "_id,name,price,tags,at\n"+
+			"1,\"a, b\",12.50,\"[\"\"x\"\",\"\"y\"\"]\",2022-11-01T12:30:00Z\n"+
+			"2,,,,\n", w.Body.String())
+	})
+
+	t.Run("Arrow", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, writeQueryResponse(w, queryer.OutputFormatArrow, featurebase.NullModeEmit, newResp()))
+		assert.Equal(t, "application/vnd.apache.arrow.stream", w.Header().Get("Content-Type"))
+
+		r, err := ipc.NewReader(w.Body)
+		require.NoError(t, err)
+		defer r.Release()
+		require.True(t, r.Next())
+		rec := r.Record()
+		require.EqualValues(t, 2, rec.NumRows())
+		assert.Equal(t, []int64{1, 2}, rec.Column(0).(*array.Int64).Int64Values())
+		assert.Equal(t, "a, b", rec.Column(1).(*array.String).Value(0))
+		assert.True(t, rec.Column(1).IsNull(1))
+		assert.EqualValues(t, 1250, rec.Column(2).(*array.Decimal128).Value(0).LowBits())
+		assert.Equal(t, arrow.Timestamp(ts.UnixMicro()), rec.Column(4).(*array.Timestamp).Value(0))
+		tags := rec.Column(3).(*array.List)
+		assert.EqualValues(t, 2, tags.ListValues().Len())
+		assert.True(t, tags.IsNull(1))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		resp := newResp()
+		resp.Error = "boom"
+		w := httptest.NewRecorder()
+		require.NoError(t, writeQueryResponse(w, queryer.OutputFormatCSV, featurebase.NullModeEmit, resp))
+		assert.Equal(t, 400, w.Code)
+	})
+}
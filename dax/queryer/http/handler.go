@@ -1,13 +1,18 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
+	featurebase "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/gorilla/mux"
 )
 
@@ -28,8 +33,18 @@ func Handler(q *queryer.Queryer) http.Handler {
 	router := mux.NewRouter()
 	router.Use(logRequestMiddleWare)
 	router.HandleFunc("/health", svr.getHealth).Methods("GET").Name("GetHealth")
+	router.HandleFunc("/config", svr.getConfig).Methods("GET").Name("GetConfig")
+	router.HandleFunc("/computers", svr.getComputers).Methods("GET").Name("GetComputers")
+	router.HandleFunc("/queries", svr.getQueries).Methods("GET").Name("GetQueries")
+	router.HandleFunc("/read-only", svr.getReadOnly).Methods("GET").Name("GetReadOnly")
+	router.HandleFunc("/read-only", svr.postReadOnly).Methods("POST").Name("PostReadOnly")
 	router.HandleFunc("/sql", svr.postSQL).Methods("POST").Name("PostSQL")
 	router.HandleFunc("/databases/{databaseID}/sql", svr.postSQL).Methods("POST").Name("PostDatabaseSQL")
+	router.HandleFunc("/databases/{databaseID}/import", svr.postImport).Methods("POST").Name("PostImport")
+	router.HandleFunc("/databases/{databaseID}/statements", svr.getStatements).Methods("GET").Name("GetStatements")
+	router.HandleFunc("/databases/{databaseID}/statements", svr.postStatement).Methods("POST").Name("PostStatement")
+	router.HandleFunc("/databases/{databaseID}/statements/{name}", svr.deleteStatement).Methods("DELETE").Name("DeleteStatement")
+	router.HandleFunc("/databases/{databaseID}/statements/{name}/invoke", svr.postInvokeStatement).Methods("POST").Name("PostInvokeStatement")
 
 	return router
 }
@@ -43,26 +58,125 @@ func (s *server) getHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// POST /sql
+type configResponse struct {
+	DefaultOutputFormat queryer.OutputFormat   `json:"defaultOutputFormat"`
+	OutputFormats       []queryer.OutputFormat `json:"outputFormats"`
+
+	// Controllers are the Controller replicas among which the queryer fails
+	// over, if it's configured with more than one.
+	Controllers []dax.ControllerEndpoint `json:"controllers,omitempty"`
+}
+
+// GET /config
+// getConfig reports the queryer's configuration which affects its clients.
+func (s *server) getConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	resp := configResponse{
+		DefaultOutputFormat: s.queryer.DefaultOutputFormat(),
+		OutputFormats:       queryer.OutputFormats,
+		Controllers:         s.queryer.ControllerEndpoints(),
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.queryer.Logger().Printf("encoding config response: %v", err)
+	}
+}
+
+// GET /computers
+// getComputers reports the queryer's view of the computers it has failed to
+// reach, including the state of the circuit breaker for each.
+func (s *server) getComputers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.queryer.ComputerBreakers()); err != nil {
+		s.queryer.Logger().Printf("encoding computers response: %v", err)
+	}
+}
+
+// GET /queries
+// getQueries returns the organization's most recently completed queries, most
+// recent first, along with their labels.
+func (s *server) getQueries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.queryer.QueryHistory(getOrganizationID(r))); err != nil {
+		s.queryer.Logger().Printf("encoding queries response: %v", err)
+	}
+}
+
+type readOnlyResponse struct {
+	ReadOnly bool `json:"readOnly"`
+}
+
+// GET /read-only
+// getReadOnly reports whether the queryer is in read-only mode.
+func (s *server) getReadOnly(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(readOnlyResponse{ReadOnly: s.queryer.ReadOnly()}); err != nil {
+		s.queryer.Logger().Printf("encoding read-only response: %v", err)
+	}
+}
+
+// POST /read-only?enabled=<bool>
+// postReadOnly puts the queryer into, or takes it out of, read-only mode. Since
+// the mode applies to every organization, only operators may change it; see
+// adminCaller.
+func (s *server) postReadOnly(w http.ResponseWriter, r *http.Request) {
+	caller, err := s.adminCaller(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+		return
+	}
+	if s.queryer.SetReadOnly(enabled) {
+		mode := "read-write"
+		if enabled {
+			mode = "read-only"
+		}
+		from := r.RemoteAddr
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			from = fwd + " via " + from
+		}
+		s.queryer.Logger().Warnf("admin action: queryer switched to %s mode by %s, from %s", mode, caller, from)
+	}
+	s.getReadOnly(w, r)
+}
+
+// adminCaller returns a description of the operator who made r, or an error
+// if r wasn't made by an operator. An operator is either a DAX service, whose
+// request carries a valid service token (see dax.ServiceAuthConfig), or an
+// organization in the queryer's DebugOrganizations. If neither service auth
+// nor debug organizations are configured, no one is an operator.
+func (s *server) adminCaller(r *http.Request) (string, error) {
+	if r.Header.Get(dax.HeaderServiceToken) != "" {
+		if a := dax.CurrentServiceAuth(); a != nil {
+			if err := a.Verify(r); err != nil {
+				return "", errors.Wrap(err, "verifying service token")
+			}
+			return "service token", nil
+		}
+	}
+	if orgID := getOrganizationID(r); orgID != "" && s.queryer.DebugAllowed(orgID) {
+		return fmt.Sprintf("organization %s", orgID), nil
+	}
+	return "", errors.Errorf("changing the queryer's mode requires a service token or a debug organization")
+}
+
+// POST /sql?nulls=<emit|omit>&has-more=<bool>&offset=<n>&approximate-count=<bool|n>&priority=<interactive|batch>&export=<url>&export-format=<csv|arrow|parquet>&stream-aggregates=<bool|n>
 func (s *server) postSQL(w http.ResponseWriter, r *http.Request) {
 	orgID := getOrganizationID(r)
 	dbID := dax.DatabaseID(mux.Vars(r)["databaseID"])
+	opts, status, err := s.sqlOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
 
 	contentType := r.Header.Get("Content-Type")
 	switch contentType {
 	case "text/plain":
-		qdbid := dax.NewQualifiedDatabaseID(orgID, dbID)
-		resp, err := s.queryer.QuerySQL(r.Context(), qdbid, r.Body)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+		s.serveSQL(w, r, dax.NewQualifiedDatabaseID(orgID, dbID), r.Body, opts)
 
 	case "application/json":
 		body := r.Body
@@ -74,7 +188,6 @@ func (s *server) postSQL(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		ctx := r.Context()
 		if orgID == "" {
 			orgID = req.OrganizationID
 		}
@@ -82,18 +195,7 @@ func (s *server) postSQL(w http.ResponseWriter, r *http.Request) {
 			dbID = req.DatabaseID
 		}
 
-		qdbid := dax.NewQualifiedDatabaseID(orgID, dbID)
-		resp, err := s.queryer.QuerySQL(ctx, qdbid, strings.NewReader(req.SQL))
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+		s.serveSQL(w, r, dax.NewQualifiedDatabaseID(orgID, dbID), strings.NewReader(req.SQL), opts)
 
 	default:
 		err := fmt.Errorf("unsupported request content-type '%s'", contentType)
@@ -102,6 +204,432 @@ func (s *server) postSQL(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// sqlRequestOptions are the options of a POST /sql given by its query
+// parameters and headers.
+type sqlRequestOptions struct {
+	nulls       featurebase.NullMode
+	page        queryer.Page
+	paged       bool
+	approx      queryer.ApproximateCount
+	approximate bool
+	priority    featurebase.QueryPriority
+	format      queryer.OutputFormat
+	exp         exportRequest
+	exporting   bool
+	stream      queryer.StreamAggregates
+	streaming   bool
+}
+
+// sqlOptions returns the options of the POST /sql r, or an error and the
+// status to respond with if they're invalid.
+func (s *server) sqlOptions(r *http.Request) (opts sqlRequestOptions, status int, err error) {
+	opts.nulls, err = featurebase.ParseNullMode(r.URL.Query().Get("nulls"))
+	if err != nil {
+		return opts, http.StatusBadRequest, err
+	}
+	opts.page, opts.paged, err = queryPage(r)
+	if err != nil {
+		return opts, http.StatusBadRequest, err
+	}
+	opts.approx, opts.approximate, err = queryApproximateCount(r)
+	if err != nil {
+		return opts, http.StatusBadRequest, err
+	}
+	opts.priority, err = featurebase.ParseQueryPriority(r.URL.Query().Get("priority"))
+	if err != nil {
+		return opts, http.StatusBadRequest, err
+	}
+	opts.format, err = negotiateOutputFormat(r.Header.Get("Accept"), s.queryer.DefaultOutputFormat())
+	if err != nil {
+		return opts, http.StatusNotAcceptable, err
+	}
+	opts.exp, opts.exporting, err = s.queryExport(r)
+	if err != nil {
+		return opts, http.StatusBadRequest, err
+	}
+	opts.stream, opts.streaming, err = queryStreamAggregates(r)
+	if err != nil {
+		return opts, http.StatusBadRequest, err
+	} else if opts.streaming && (opts.paged || opts.approximate || opts.exporting) {
+		return opts, http.StatusBadRequest, errors.New(errors.ErrUncoded, "stream-aggregates can't be combined with paging, approximate-count or export")
+	}
+	return opts, http.StatusOK, nil
+}
+
+// serveSQL executes sql against qdbid with opts, and writes the response of
+// the POST /sql r to w.
+func (s *server) serveSQL(w http.ResponseWriter, r *http.Request, qdbid dax.QualifiedDatabaseID, sql io.Reader, opts sqlRequestOptions) {
+	ctx, err := queryLabels(r.Context(), r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.paged {
+		ctx = queryer.WithPage(ctx, opts.page)
+	}
+	if opts.approximate {
+		ctx = queryer.WithApproximateCount(ctx, opts.approx)
+	}
+	ctx = featurebase.WithQueryPriority(ctx, opts.priority)
+	if opts.streaming {
+		s.streamAggregates(ctx, w, qdbid, sql, opts.stream)
+		return
+	}
+	ctx, dbg := s.queryDebug(ctx, r, qdbid.OrganizationID)
+	usage := dax.NewQueryUsage()
+	resp, err := s.queryer.QuerySQL(dax.WithQueryUsage(ctx, usage), qdbid, sql)
+	if err != nil {
+		http.Error(w, err.Error(), sqlErrorStatus(err))
+		return
+	}
+	s.applyDebug(r, qdbid.OrganizationID, resp, dbg)
+	w.Header().Set(dax.HeaderResourceUsage, usage.String())
+
+	if opts.exporting {
+		writeExport(ctx, w, opts.exp, resp)
+		return
+	}
+	if err := writeQueryResponse(w, opts.format, opts.nulls, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// GET /databases/{databaseID}/statements
+func (s *server) getStatements(w http.ResponseWriter, r *http.Request) {
+	qdbid := dax.NewQualifiedDatabaseID(getOrganizationID(r), dax.DatabaseID(mux.Vars(r)["databaseID"]))
+
+	stmts := s.queryer.Statements(r.Context(), qdbid)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stmts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// POST /databases/{databaseID}/statements
+func (s *server) postStatement(w http.ResponseWriter, r *http.Request) {
+	qdbid := dax.NewQualifiedDatabaseID(getOrganizationID(r), dax.DatabaseID(mux.Vars(r)["databaseID"]))
+
+	body := r.Body
+	defer body.Close()
+
+	req := StatementRequest{}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stmt, err := s.queryer.RegisterStatement(r.Context(), qdbid, req.Name, req.SQL)
+	if err != nil {
+		http.Error(w, err.Error(), statementErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stmt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// DELETE /databases/{databaseID}/statements/{name}
+func (s *server) deleteStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	qdbid := dax.NewQualifiedDatabaseID(getOrganizationID(r), dax.DatabaseID(vars["databaseID"]))
+
+	if err := s.queryer.DeregisterStatement(r.Context(), qdbid, vars["name"]); err != nil {
+		http.Error(w, err.Error(), statementErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// POST /databases/{databaseID}/statements/{name}/invoke?nulls=<emit|omit>
+func (s *server) postInvokeStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	qdbid := dax.NewQualifiedDatabaseID(getOrganizationID(r), dax.DatabaseID(vars["databaseID"]))
+	nulls, err := featurebase.ParseNullMode(r.URL.Query().Get("nulls"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := negotiateOutputFormat(r.Header.Get("Accept"), s.queryer.DefaultOutputFormat())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+
+	body := r.Body
+	defer body.Close()
+
+	req := InvokeStatementRequest{}
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, err := queryLabels(r.Context(), r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, dbg := s.queryDebug(ctx, r, qdbid.OrganizationID)
+	usage := dax.NewQueryUsage()
+	resp, err := s.queryer.InvokeStatement(dax.WithQueryUsage(ctx, usage), qdbid, vars["name"], req.Parameters)
+	if err != nil {
+		http.Error(w, err.Error(), statementErrorStatus(err))
+		return
+	}
+	s.applyDebug(r, qdbid.OrganizationID, resp, dbg)
+	w.Header().Set(dax.HeaderResourceUsage, usage.String())
+
+	if err := writeQueryResponse(w, format, nulls, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// POST /databases/{databaseID}/import?table=<name>&format=<csv|ndjson>&header=<bool>&sample=<n>&id=<column>&type=<column>:<type>&dry-run=<bool>
+func (s *server) postImport(w http.ResponseWriter, r *http.Request) {
+	qdbid := dax.NewQualifiedDatabaseID(getOrganizationID(r), dax.DatabaseID(mux.Vars(r)["databaseID"]))
+	req, err := importRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body := r.Body
+	defer body.Close()
+
+	resp, err := s.queryer.Import(r.Context(), qdbid, req, body)
+	if err != nil {
+		http.Error(w, err.Error(), sqlErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// importRequest returns the ImportRequest described by the query parameters
+// of r. CSV data is assumed to have a header row unless header=false.
+func importRequest(r *http.Request) (queryer.ImportRequest, error) {
+	q := r.URL.Query()
+	req := queryer.ImportRequest{
+		Table:     dax.TableName(q.Get("table")),
+		Format:    strings.ToUpper(q.Get("format")),
+		HeaderRow: true,
+		ID:        q.Get("id"),
+	}
+	if req.Table == "" {
+		return req, errors.Errorf("a table name is required")
+	}
+	if v := q.Get("header"); v != "" {
+		header, err := strconv.ParseBool(v)
+		if err != nil {
+			return req, errors.Errorf("invalid header '%s'", v)
+		}
+		req.HeaderRow = header
+	}
+	if v := q.Get("sample"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return req, errors.Errorf("invalid sample '%s': must be a positive integer", v)
+		}
+		req.SampleSize = n
+	}
+	if v := q.Get("dry-run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return req, errors.Errorf("invalid dry-run '%s'", v)
+		}
+		req.DryRun = dryRun
+	}
+	for _, v := range q["type"] {
+		col, typ, ok := strings.Cut(v, ":")
+		if !ok || col == "" || typ == "" {
+			return req, errors.Errorf("invalid type '%s': expected <column>:<type>", v)
+		}
+		if req.Types == nil {
+			req.Types = make(map[string]string)
+		}
+		req.Types[col] = typ
+	}
+	return req, nil
+}
+
+// sqlErrorStatus returns the http status code appropriate for an error
+// returned by QuerySQL.
+func sqlErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dax.ErrQueryTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, dax.ErrReadOnly):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// statementErrorStatus returns the http status code appropriate for an error
+// returned by one of the statement registry methods.
+func statementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, dax.ErrStatementDoesNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, dax.ErrStatementExists):
+		return http.StatusConflict
+	case errors.Is(err, dax.ErrStatementInvalidated):
+		return http.StatusGone
+	case errors.Is(err, dax.ErrQueryTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, dax.ErrReadOnly):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// queryDebug returns a copy of ctx which collects debug output for the query,
+// along with that output, if the request includes `?debug=true` and the
+// organization is permitted to receive debug output. Otherwise, ctx is
+// returned unchanged along with a nil QueryDebug.
+func (s *server) queryDebug(ctx context.Context, r *http.Request, orgID dax.OrganizationID) (context.Context, *dax.QueryDebug) {
+	if !debugRequested(r) || !s.queryer.DebugAllowed(orgID) {
+		return ctx, nil
+	}
+	dbg := dax.NewQueryDebug()
+	return dax.WithQueryDebug(ctx, dbg), dbg
+}
+
+// applyDebug attaches dbg to resp. If debug output was requested but not
+// permitted, a warning is added to resp instead.
+func (s *server) applyDebug(r *http.Request, orgID dax.OrganizationID, resp *featurebase.WireQueryResponse, dbg *dax.QueryDebug) {
+	if dbg != nil {
+		resp.Debug = dbg
+	} else if debugRequested(r) && !s.queryer.DebugAllowed(orgID) {
+		resp.Warnings = append(resp.Warnings, "debug output is not permitted for this organization")
+	}
+}
+
+// queryLabels returns a copy of ctx which carries the labels attached to the
+// request's query in the HeaderQueryLabels header, if any.
+func queryLabels(ctx context.Context, r *http.Request) (context.Context, error) {
+	labels, err := dax.ParseQueryLabels(r.Header.Get(dax.HeaderQueryLabels))
+	if err != nil || labels == nil {
+		return ctx, err
+	}
+	return dax.WithQueryLabels(ctx, labels), nil
+}
+
+// queryPage returns the page of results requested by r, if any. A page is
+// requested with has-more=true, or with an offset, which implies it.
+func queryPage(r *http.Request) (queryer.Page, bool, error) {
+	var page queryer.Page
+	q := r.URL.Query()
+	paged := false
+	if v := q.Get("has-more"); v != "" {
+		var err error
+		if paged, err = strconv.ParseBool(v); err != nil {
+			return page, false, errors.Errorf("invalid has-more '%s'", v)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || offset < 0 {
+			return page, false, errors.Errorf("invalid offset '%s': must be a non-negative integer", v)
+		}
+		page.Offset = offset
+		paged = true
+	}
+	return page, paged, nil
+}
+
+// queryApproximateCount returns the approximate counts requested by r, if
+// any. Approximate counts are requested with approximate-count=true, which
+// samples the default number of shards, or with approximate-count=<n>, which
+// samples n. They apply to the counts of the SQL, or of the PQL if the body
+// is PQL, of a POST /sql.
+func queryApproximateCount(r *http.Request) (queryer.ApproximateCount, bool, error) {
+	var approx queryer.ApproximateCount
+	v := r.URL.Query().Get("approximate-count")
+	if v == "" {
+		return approx, false, nil
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if n <= 0 {
+			return approx, false, errors.Errorf("invalid approximate-count '%s': must be a positive number of shards", v)
+		}
+		approx.SampleShards = n
+		return approx, true, nil
+	}
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		return approx, false, errors.Errorf("invalid approximate-count '%s'", v)
+	}
+	return approx, on, nil
+}
+
+// queryStreamAggregates returns the streamed aggregates requested by r, if
+// any. They're requested with stream-aggregates=true, which emits the default
+// number of snapshots, or with stream-aggregates=<n>, which emits n.
+func queryStreamAggregates(r *http.Request) (queryer.StreamAggregates, bool, error) {
+	var stream queryer.StreamAggregates
+	v := r.URL.Query().Get("stream-aggregates")
+	if v == "" {
+		return stream, false, nil
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if n <= 0 {
+			return stream, false, errors.Errorf("invalid stream-aggregates '%s': must be a positive number of snapshots", v)
+		}
+		stream.Snapshots = n
+		return stream, true, nil
+	}
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		return stream, false, errors.Errorf("invalid stream-aggregates '%s'", v)
+	}
+	return stream, on, nil
+}
+
+// streamAggregates writes the snapshots of a streamed aggregation to w as
+// newline-delimited JSON, flushing each as it's emitted.
+func (s *server) streamAggregates(ctx context.Context, w http.ResponseWriter, qdbid dax.QualifiedDatabaseID, sql io.Reader, stream queryer.StreamAggregates) {
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	started := false
+	err := s.queryer.StreamAggregateSQL(ctx, qdbid, sql, stream, func(snap queryer.AggregateSnapshot) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			started = true
+		}
+		if err := enc.Encode(snap); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		http.Error(w, err.Error(), sqlErrorStatus(err))
+	}
+}
+
+func debugRequested(r *http.Request) bool {
+	debug, _ := strconv.ParseBool(r.URL.Query().Get("debug"))
+	return debug
+}
+
 func getOrganizationID(r *http.Request) dax.OrganizationID {
 	return dax.OrganizationID(r.Header.Get("OrganizationID"))
 }
@@ -111,3 +639,14 @@ type SQLRequest struct {
 	DatabaseID     dax.DatabaseID     `json:"db-id"`
 	SQL            string             `json:"sql"`
 }
+
+// StatementRequest is the request body used to register a named statement.
+type StatementRequest struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql"`
+}
+
+// InvokeStatementRequest is the request body used to invoke a named statement.
+type InvokeStatementRequest struct {
+	Parameters map[string]interface{} `json:"parameters"`
+}
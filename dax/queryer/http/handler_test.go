@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostReadOnly(t *testing.T) {
+	q := queryer.New(queryer.Config{DebugOrganizations: []string{"ops"}})
+	h := Handler(q)
+
+	post := func(t *testing.T, org string, sign *dax.ServiceAuth) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/read-only?enabled=true", nil)
+		if org != "" {
+			req.Header.Set("OrganizationID", org)
+		}
+		require.NoError(t, sign.Sign(req))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Tenants can't change the mode of the queryer they share.
+	assert.Equal(t, http.StatusForbidden, post(t, "", nil))
+	assert.Equal(t, http.StatusForbidden, post(t, "tenant", nil))
+	assert.False(t, q.ReadOnly())
+
+	assert.Equal(t, http.StatusOK, post(t, "ops", nil))
+	assert.True(t, q.ReadOnly())
+	q.SetReadOnly(false)
+
+	// With service auth, a DAX service can change the mode, but a token
+	// signed with another key is rejected.
+	a, err := dax.NewServiceAuth(dax.ServiceAuthConfig{Keys: []string{"0123456789abcdef"}})
+	require.NoError(t, err)
+	other, err := dax.NewServiceAuth(dax.ServiceAuthConfig{Keys: []string{"fedcba9876543210"}})
+	require.NoError(t, err)
+	dax.SetServiceAuth(a)
+	defer dax.SetServiceAuth(nil)
+
+	assert.Equal(t, http.StatusForbidden, post(t, "tenant", other))
+	assert.False(t, q.ReadOnly())
+	assert.Equal(t, http.StatusOK, post(t, "tenant", a))
+	assert.True(t, q.ReadOnly())
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/queryer"
+)
+
+// OpenAPIAnnotations describes the request and response bodies of the
+// queryer's routes, keyed by route name.
+func OpenAPIAnnotations() map[string]dax.OpenAPIAnnotation {
+	return map[string]dax.OpenAPIAnnotation{
+		"GetConfig": {
+			Summary:  "Report the queryer's configuration which affects its clients, such as the format of query results for clients which don't send an Accept header, and the Controller replicas among which it fails over, with the active one.",
+			Response: configResponse{},
+		},
+		"GetComputers": {
+			Summary:  "List the computers the queryer has failed to reach, and the state of the circuit breaker for each.",
+			Response: []queryer.ComputerBreaker{},
+		},
+		"GetQueries": {
+			Summary:  "List the most recent queries made against the organization's databases, most recent first.",
+			Response: []queryer.QueryRecord{},
+		},
+		"GetReadOnly": {
+			Summary:  "Report whether the queryer is in read-only mode.",
+			Response: readOnlyResponse{},
+		},
+		"PostReadOnly": {
+			Summary: "Put the queryer into, or take it out of, read-only mode, in which writes and DDL are rejected with a 503. Only a DAX service, with a service token, or a debug organization may change the mode; anyone else gets a 403.",
+			Parameters: []dax.OpenAPIParameter{
+				required(dax.OpenAPIQueryParameter("enabled", "boolean", "Whether the queryer is to be read-only.")),
+			},
+			Response: readOnlyResponse{},
+		},
+		"PostSQL": {
+			Summary:    "Execute sql. The body is a SQLRequest, or plain text sql (or PQL) when the content type is text/plain; the results are returned in the format of the Accept header.",
+			Parameters: sqlParameters,
+			Request:    SQLRequest{},
+			Response:   featurebase.WireQueryResponse{},
+		},
+		"PostDatabaseSQL": {
+			Summary:    "Execute sql against a database, as for POST /sql.",
+			Parameters: sqlParameters,
+			Request:    SQLRequest{},
+			Response:   featurebase.WireQueryResponse{},
+		},
+		"PostImport": {
+			Summary: "Create a table whose schema is inferred from a sample of the CSV or NDJSON data in the body, and load the data into it.",
+			Parameters: []dax.OpenAPIParameter{
+				required(dax.OpenAPIQueryParameter("table", "string", "The name of the table to create.")),
+				dax.OpenAPIQueryParameter("format", "string", "The format of the body: csv or ndjson."),
+				dax.OpenAPIQueryParameter("header", "boolean", "Whether CSV data has a header row; true by default."),
+				dax.OpenAPIQueryParameter("id", "string", "The column the _id is taken from; by default, a column whose sampled values are distinct."),
+				dax.OpenAPIQueryParameter("sample", "integer", "The number of rows sampled to infer the schema."),
+				dax.OpenAPIQueryParameter("type", "string", "<column>:<type>, overriding the inferred type of a column. May be repeated."),
+				dax.OpenAPIQueryParameter("dry-run", "boolean", "Return the inferred schema without creating the table."),
+			},
+			Response: queryer.ImportResult{},
+		},
+		"GetStatements": {
+			Summary:  "List the named statements registered for a database.",
+			Response: []*queryer.Statement{},
+		},
+		"PostStatement": {
+			Summary:  "Register a named statement.",
+			Request:  StatementRequest{},
+			Response: queryer.Statement{},
+		},
+		"DeleteStatement": {
+			Summary: "Deregister a named statement.",
+		},
+		"PostInvokeStatement": {
+			Summary: "Invoke a named statement with the given parameters. The results are returned in the format of the Accept header, as for POST /sql.",
+			Parameters: []dax.OpenAPIParameter{
+				nullsParameter,
+			},
+			Request:  InvokeStatementRequest{},
+			Response: featurebase.WireQueryResponse{},
+		},
+	}
+}
+
+// nullsParameter is the query parameter of the routes which return rows
+// which controls how null values are returned.
+var nullsParameter = dax.OpenAPIQueryParameter("nulls", "string", "With omit, rows are returned as records which leave out the columns with no value; emit, the default, returns every column.")
+
+// sqlParameters are the query parameters of POST /sql.
+var sqlParameters = []dax.OpenAPIParameter{
+	nullsParameter,
+	dax.OpenAPIQueryParameter("has-more", "boolean", "With true, a SELECT with a LIMIT returns has-more, and next-offset when there are more rows."),
+	dax.OpenAPIQueryParameter("offset", "integer", "Return the page of rows starting at this row; implies has-more."),
+	dax.OpenAPIQueryParameter("approximate-count", "string", "true, or a number of shards to sample: the counts of the query, by COUNT in sql or Count() in a PQL body, are estimated from a sample of shards and returned with an error bound in approximations. Counts are exact by default."),
+	dax.OpenAPIQueryParameter("priority", "string", "interactive, the default, or batch, with which the query yields to interactive queries on the computers, pausing between shards."),
+	dax.OpenAPIQueryParameter("export", "string", "An s3://<bucket>/<key>, gs://<bucket>/<key> or file://<dir>/<path> url to which the results are written, in full or not at all, instead of being returned; the response is an ExportResponse, and a failure to write the object is a 502."),
+	dax.OpenAPIQueryParameter("export-format", "string", "The format of an export: csv, the default, arrow or parquet."),
+	dax.OpenAPIQueryParameter("stream-aggregates", "string", "true, or a number of snapshots: a SELECT of COUNT, SUM, MIN and MAX (optionally grouped, with no HAVING, ORDER BY or LIMIT) returns newline-delimited JSON AggregateSnapshots as the table's shards are aggregated; the final snapshot is the exact result. Can't be combined with paging, approximate-count or export."),
+	dax.OpenAPIQueryParameter("debug", "boolean", "Return the query's debug information, for organizations permitted to see it."),
+}
+
+// required returns p, marked as required.
+func required(p dax.OpenAPIParameter) dax.OpenAPIParameter {
+	p.Required = true
+	return p
+}
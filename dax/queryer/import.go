@@ -0,0 +1,713 @@
+package queryer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+)
+
+// DefaultImportSampleSize is the default number of rows sampled to infer the
+// schema of an import.
+const DefaultImportSampleSize = 1000
+
+// Import formats.
+const (
+	ImportFormatCSV    = "CSV"
+	ImportFormatNDJSON = "NDJSON"
+)
+
+// ImportRequest describes how data is imported into a new table whose schema
+// is inferred from a sample of the data.
+type ImportRequest struct {
+	Table dax.TableName
+
+	// Format is ImportFormatCSV or ImportFormatNDJSON.
+	Format string
+
+	// HeaderRow is true if the first row of CSV data names its columns.
+	// Without one, the columns are named c0, c1, and so on. It's ignored
+	// for NDJSON, whose columns are named by its keys.
+	HeaderRow bool
+
+	// SampleSize is the number of rows from which the schema is inferred. A
+	// value of 0 uses the Queryer's configured sample size.
+	SampleSize int
+
+	// ID names the column whose values become the table's _id. If it's
+	// empty, a key candidate is chosen from the sample.
+	ID string
+
+	// Types overrides the inferred types of columns, by column name. Each
+	// type is a type name such as "STRINGSET" or "DECIMAL(2)".
+	Types map[string]string
+
+	// DryRun infers and returns the schema without creating the table or
+	// importing any data.
+	DryRun bool
+}
+
+// InferredColumn is a column of an inferred schema.
+type InferredColumn struct {
+	// Name is the name of the column in the table; Source is the name of
+	// the CSV column or JSON key from which it's loaded. Offset is the
+	// offset of a CSV column.
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Offset *int   `json:"offset,omitempty"`
+	Type   string `json:"type"`
+
+	// Overridden is true if Type was given in the request rather than
+	// inferred.
+	Overridden bool `json:"overridden,omitempty"`
+}
+
+// InferredSchema is the schema inferred for an import.
+type InferredSchema struct {
+	Table dax.TableName `json:"table"`
+
+	// Columns are the columns of the table, starting with _id.
+	Columns []InferredColumn `json:"columns"`
+
+	// KeyCandidates are the columns whose values were present and distinct
+	// in every sampled row, and which could therefore be the _id.
+	KeyCandidates []string `json:"key-candidates"`
+
+	// Skipped are the JSON keys which aren't imported because their values
+	// are objects.
+	Skipped []string `json:"skipped,omitempty"`
+
+	SampledRows int `json:"sampled-rows"`
+
+	// CreateTable is the statement which creates the table.
+	CreateTable string `json:"create-table"`
+}
+
+// ImportResult is the result of an import.
+type ImportResult struct {
+	Schema *InferredSchema `json:"schema"`
+
+	// Created is true if the table was created. If the data then failed to
+	// load, the table is left in place, and Result holds the error.
+	Created bool `json:"created"`
+
+	// Result is the result of the BULK INSERT which loaded the data.
+	Result *featurebase.WireQueryResponse `json:"result,omitempty"`
+}
+
+// Import creates a table whose schema is inferred from the data read from r,
+// and loads the data into it. The data can be no larger than the maximum
+// query text size.
+//
+// The schema is inferred from the first req.SampleSize rows. The type of each
+// column is the narrowest type which holds all of its sampled values, tried
+// in the order BOOL, INT, DECIMAL, TIMESTAMP and STRING; a value is a BOOL only
+// if it's true or false, not 0 or 1, and a TIMESTAMP only if it's an RFC 3339
+// time or a date, not a number. When the sampled values of a column have
+// different types, the column is widened: an INT and a DECIMAL to a DECIMAL,
+// with the largest scale sampled, and anything else to a STRING. In NDJSON,
+// arrays of strings are STRINGSETs and arrays of non-negative integers
+// IDSETs; since neither can be widened to a STRING, a column mixing them with
+// other values must have its type given in req.Types. Columns with no values
+// in the sample are STRINGs. NDJSON keys which appear only after the sample
+// aren't imported. Since the sample may not be representative, the inferred
+// schema is returned so that it can be checked (with req.DryRun) and
+// corrected (with req.Types) before any data is loaded.
+func (q *Queryer) Import(ctx context.Context, qdbid dax.QualifiedDatabaseID, req ImportRequest, r io.Reader) (*ImportResult, error) {
+	if err := q.beginQuery(); err != nil {
+		return nil, err
+	}
+	defer q.inflight.Done()
+
+	data, err := q.readQueryText(r)
+	if err != nil {
+		return nil, err
+	}
+	if req.SampleSize == 0 {
+		req.SampleSize = q.importSampleSize
+	}
+
+	schema, err := InferSchema(req, data)
+	if err != nil {
+		return nil, err
+	}
+	ret := &ImportResult{Schema: schema}
+	if req.DryRun {
+		return ret, nil
+	}
+
+	create, err := parser.NewParser(strings.NewReader(schema.CreateTable)).ParseStatement()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing inferred schema")
+	}
+	insert, err := parser.NewParser(strings.NewReader(bulkInsertSQL(schema, req))).ParseStatement()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing bulk insert")
+	}
+	if err := q.checkReadOnly(create); err != nil {
+		return nil, err
+	}
+	// The data is put in place after parsing, so that it needn't be quoted.
+	insert.(*parser.BulkInsertStatement).DataSource = &parser.StringLit{Value: string(data)}
+
+	if _, err := q.queryStatement(ctx, qdbid, create); err != nil {
+		return nil, errors.Wrap(err, "creating table")
+	}
+	ret.Created = true
+
+	start := time.Now()
+	resp, err := q.queryStatement(ctx, qdbid, insert)
+	if err != nil {
+		resp = &featurebase.WireQueryResponse{Error: err.Error()}
+	}
+	resp.ExecutionTime = time.Since(start).Microseconds()
+	ret.Result = resp
+	return ret, nil
+}
+
+// bulkInsertSQL returns a BULK INSERT statement which loads data with the
+// given schema from an empty STREAM.
+func bulkInsertSQL(schema *InferredSchema, req ImportRequest) string {
+	names := make([]string, len(schema.Columns))
+	maps := make([]string, len(schema.Columns))
+	for i, col := range schema.Columns {
+		names[i] = quoteIdent(col.Name)
+		if col.Offset != nil {
+			maps[i] = fmt.Sprintf("%d %s", *col.Offset, col.Type)
+		} else {
+			maps[i] = "'$." + strings.ReplaceAll(col.Source, "'", "''") + "' " + col.Type
+		}
+	}
+
+	var with string
+	if req.Format == ImportFormatCSV && req.HeaderRow {
+		with = " HEADER_ROW"
+	}
+	return fmt.Sprintf("BULK INSERT INTO %s (%s) MAP (%s) FROM '' WITH FORMAT '%s' INPUT 'STREAM' ALLOW_MISSING_VALUES%s",
+		quoteIdent(string(schema.Table)), strings.Join(names, ", "), strings.Join(maps, ", "), req.Format, with)
+}
+
+// InferSchema infers the schema of data to be imported from a sample of its
+// rows, as described by Queryer.Import.
+func InferSchema(req ImportRequest, data []byte) (*InferredSchema, error) {
+	sampleSize := req.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = DefaultImportSampleSize
+	}
+
+	var cols []*sampledColumn
+	var skipped []string
+	var rows int
+	switch req.Format {
+	case ImportFormatCSV:
+		var err error
+		if cols, rows, err = sampleCSV(data, req.HeaderRow, sampleSize); err != nil {
+			return nil, err
+		}
+	case ImportFormatNDJSON:
+		var err error
+		if cols, skipped, rows, err = sampleNDJSON(data, sampleSize); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("invalid import format '%s': expected %s or %s", req.Format, ImportFormatCSV, ImportFormatNDJSON)
+	}
+	if rows == 0 {
+		return nil, errors.Errorf("no rows to sample")
+	}
+
+	schema := &InferredSchema{
+		Table:         req.Table,
+		KeyCandidates: []string{},
+		Skipped:       skipped,
+		SampledRows:   rows,
+	}
+
+	byName := make(map[string]*sampledColumn, len(cols))
+	for _, col := range cols {
+		name := columnName(col.source)
+		if other, ok := byName[name]; ok {
+			return nil, errors.Errorf("columns '%s' and '%s' would both be named '%s'", other.source, col.source, name)
+		}
+		col.name = name
+		byName[name] = col
+		if col.keyCandidate(rows) {
+			schema.KeyCandidates = append(schema.KeyCandidates, name)
+		}
+	}
+	for name := range req.Types {
+		if _, ok := byName[name]; !ok && name != "_id" {
+			return nil, errors.Errorf("can't override the type of unknown column '%s'", name)
+		}
+	}
+
+	key, err := chooseKey(req.ID, byName, schema.KeyCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	keyType := "STRING"
+	if key.kind == kindInt && !key.negative {
+		keyType = "ID"
+	}
+	keyCol := key.inferredColumn("_id")
+	keyCol.Type = keyType
+	if typ, ok := req.Types[key.name]; ok {
+		keyCol.Type, keyCol.Overridden = typ, true
+	} else if typ, ok := req.Types["_id"]; ok {
+		keyCol.Type, keyCol.Overridden = typ, true
+	}
+	if t := strings.ToUpper(keyCol.Type); t != "ID" && t != "STRING" {
+		return nil, errors.Errorf("invalid type '%s' for _id: must be ID or STRING", keyCol.Type)
+	}
+	schema.Columns = append(schema.Columns, keyCol)
+
+	for _, col := range cols {
+		if col == key {
+			continue
+		}
+		ic := col.inferredColumn(col.name)
+		if typ, ok := req.Types[col.name]; ok {
+			ic.Type, ic.Overridden = typ, true
+		} else if col.conflict != "" {
+			return nil, errors.Errorf("column '%s' has %s in the sample; its type must be given", col.name, col.conflict)
+		} else {
+			ic.Type = col.typeName()
+		}
+		if err := validateImportType(ic.Type); err != nil {
+			return nil, errors.Wrapf(err, "column '%s'", col.name)
+		}
+		schema.Columns = append(schema.Columns, ic)
+	}
+
+	defs := make([]string, len(schema.Columns))
+	for i, col := range schema.Columns {
+		defs[i] = quoteIdent(col.Name) + " " + col.Type
+	}
+	schema.CreateTable = fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(string(req.Table)), strings.Join(defs, ", "))
+	return schema, nil
+}
+
+// chooseKey returns the column which becomes the _id: the column named by id,
+// if given, otherwise a candidate named _id or id, otherwise the first
+// candidate.
+func chooseKey(id string, byName map[string]*sampledColumn, candidates []string) (*sampledColumn, error) {
+	if id != "" {
+		col, ok := byName[id]
+		if !ok {
+			return nil, errors.Errorf("unknown id column '%s'", id)
+		}
+		return col, nil
+	}
+	for _, name := range candidates {
+		if name == "_id" || name == "id" {
+			return byName[name], nil
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.Errorf("no column has a distinct value in every sampled row, so none can be the _id; the id column must be given")
+	}
+	return byName[candidates[0]], nil
+}
+
+var importTypeRE = regexp.MustCompile(`^([A-Za-z]+)(\([0-9]+\))?$`)
+
+// validateImportType returns an error unless typ is a type which BULK INSERT
+// can map values to.
+func validateImportType(typ string) error {
+	m := importTypeRE.FindStringSubmatch(typ)
+	if m == nil || !parser.IsValidTypeName(m[1]) {
+		return errors.Errorf("invalid type '%s'", typ)
+	}
+	switch base := strings.ToLower(m[1]); base {
+	case dax.BaseTypeIDSetQ, dax.BaseTypeStringSetQ:
+		return errors.Errorf("type '%s' can't be imported", typ)
+	case dax.BaseTypeDecimal:
+	default:
+		if m[2] != "" {
+			return errors.Errorf("invalid type '%s'", typ)
+		}
+	}
+	return nil
+}
+
+// quoteIdent returns name as a quoted identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// columnName returns the table column name for a CSV column or JSON key:
+// lower case, with anything other than letters, digits and underscores
+// replaced by underscores.
+func columnName(source string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(source)), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "c" + name
+	}
+	return name
+}
+
+// valueKind is the type of one sampled value.
+type valueKind int
+
+const (
+	kindNull valueKind = iota
+	kindBool
+	kindInt
+	kindDecimal
+	kindTimestamp
+	kindString
+	kindIDSet
+	kindStringSet
+)
+
+var kindNames = map[valueKind]string{
+	kindBool:      "BOOL",
+	kindInt:       "INT",
+	kindDecimal:   "DECIMAL",
+	kindTimestamp: "TIMESTAMP",
+	kindString:    "STRING",
+	kindIDSet:     "IDSET",
+	kindStringSet: "STRINGSET",
+}
+
+// sampledColumn accumulates what the sample says about one column.
+type sampledColumn struct {
+	source string
+	offset int // of a CSV column
+	name   string
+	ndjson bool
+
+	kind     valueKind
+	scale    int
+	negative bool
+
+	// conflict describes the types of values which couldn't be widened to
+	// one type.
+	conflict string
+
+	// values holds the distinct values seen, until a value is repeated or
+	// missing, at which point the column can't be a key and it's nil.
+	values map[string]struct{}
+}
+
+func newSampledColumn(source string, offset int, ndjson bool, rowsBefore int) *sampledColumn {
+	c := &sampledColumn{source: source, offset: offset, ndjson: ndjson}
+	if rowsBefore == 0 {
+		c.values = make(map[string]struct{})
+	}
+	return c
+}
+
+// add records a sampled value of the column. raw is the value's text, used to
+// check whether the column's values are distinct.
+func (c *sampledColumn) add(kind valueKind, scale int, negative bool, raw string) {
+	if kind == kindNull {
+		c.values = nil
+		return
+	}
+	if c.values != nil {
+		if _, ok := c.values[raw]; ok {
+			c.values = nil
+		} else {
+			c.values[raw] = struct{}{}
+		}
+	}
+	if scale > c.scale {
+		c.scale = scale
+	}
+	c.negative = c.negative || negative
+	if c.conflict != "" || kind == c.kind {
+		return
+	}
+	if c.kind == kindNull {
+		c.kind = kind
+		return
+	}
+	c.kind, c.conflict = widen(c.kind, kind, c.ndjson)
+}
+
+// widen returns the kind which holds values of kinds a and b, or a
+// description of the conflict if there is none.
+func widen(a, b valueKind, ndjson bool) (valueKind, string) {
+	if (a == kindInt && b == kindDecimal) || (a == kindDecimal && b == kindInt) {
+		return kindDecimal, ""
+	}
+	if !ndjson {
+		return kindString, ""
+	}
+	// BULK INSERT loads JSON strings and numbers, but not booleans or
+	// arrays, into STRING columns.
+	stringish := func(k valueKind) bool {
+		return k == kindInt || k == kindDecimal || k == kindTimestamp || k == kindString
+	}
+	if stringish(a) && stringish(b) {
+		return kindString, ""
+	}
+	return kindNull, fmt.Sprintf("values of conflicting types (%s and %s)", kindNames[a], kindNames[b])
+}
+
+func (c *sampledColumn) keyCandidate(rows int) bool {
+	return len(c.values) == rows && c.conflict == "" && (c.kind == kindInt || c.kind == kindString)
+}
+
+func (c *sampledColumn) typeName() string {
+	switch c.kind {
+	case kindNull:
+		return "STRING"
+	case kindDecimal:
+		return fmt.Sprintf("DECIMAL(%d)", c.scale)
+	default:
+		return kindNames[c.kind]
+	}
+}
+
+// inferredColumn returns an InferredColumn, without its type, which loads the
+// column into a column with the given name.
+func (c *sampledColumn) inferredColumn(name string) InferredColumn {
+	ic := InferredColumn{Name: name, Source: c.source}
+	if !c.ndjson {
+		offset := c.offset
+		ic.Offset = &offset
+	}
+	return ic
+}
+
+var decimalRE = regexp.MustCompile(`^-?[0-9]*\.([0-9]+)$`)
+
+// classify returns the kind of a CSV value, or of a JSON string or number,
+// as BULK INSERT would load it.
+func classify(s string, number bool) (kind valueKind, scale int, negative bool) {
+	if s == "" && !number {
+		return kindNull, 0, false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return kindInt, 0, i < 0
+	}
+	if m := decimalRE.FindStringSubmatch(s); m != nil {
+		return kindDecimal, len(m[1]), false
+	}
+	if number {
+		// A large or exponential number, which is only loaded faithfully
+		// as a string.
+		return kindString, 0, false
+	}
+	if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+		return kindBool, 0, false
+	}
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return kindTimestamp, 0, false
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return kindTimestamp, 0, false
+	}
+	return kindString, 0, false
+}
+
+// sampleCSV samples up to n rows of CSV data, returning its columns and the
+// number of rows sampled. It reads the data as BULK INSERT does.
+func sampleCSV(data []byte, headerRow bool, n int) ([]*sampledColumn, int, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	var cols []*sampledColumn
+	if headerRow {
+		header, err := r.Read()
+		if err == io.EOF {
+			return nil, 0, nil
+		} else if err != nil {
+			return nil, 0, errors.Wrap(err, "reading header row")
+		}
+		for i, name := range header {
+			cols = append(cols, newSampledColumn(name, i, false, 0))
+		}
+	}
+
+	rows := 0
+	for ; rows < n; rows++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, errors.Wrapf(err, "reading row %d", rows+1)
+		}
+		if headerRow && len(rec) > len(cols) {
+			return nil, 0, errors.Errorf("row %d has %d values, but the header row names %d columns", rows+1, len(rec), len(cols))
+		}
+		for i := len(cols); i < len(rec); i++ {
+			cols = append(cols, newSampledColumn(fmt.Sprintf("c%d", i), i, false, rows))
+		}
+		for i, col := range cols {
+			if i >= len(rec) {
+				col.add(kindNull, 0, false, "")
+				continue
+			}
+			kind, scale, neg := classify(rec[i], false)
+			col.add(kind, scale, neg, rec[i])
+		}
+	}
+	return cols, rows, nil
+}
+
+// sampleNDJSON samples up to n rows of NDJSON data, returning its columns, in
+// the order in which their keys first appear, the keys which are skipped,
+// and the number of rows sampled.
+func sampleNDJSON(data []byte, n int) ([]*sampledColumn, []string, int, error) {
+	var cols []*sampledColumn
+	byKey := make(map[string]*sampledColumn)
+	skipped := make(map[string]struct{})
+	var skippedKeys []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	rows := 0
+	for rows < n && scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		keys, vals, err := decodeObject(line)
+		if err != nil {
+			return nil, nil, 0, errors.Wrapf(err, "parsing row %d", rows+1)
+		}
+
+		seen := make(map[string]struct{}, len(keys))
+		for i, key := range keys {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if _, ok := vals[i].(map[string]interface{}); ok {
+				if _, ok := skipped[key]; !ok {
+					skipped[key] = struct{}{}
+					skippedKeys = append(skippedKeys, key)
+				}
+				continue
+			}
+			if _, ok := skipped[key]; ok {
+				continue
+			}
+			col, ok := byKey[key]
+			if !ok {
+				col = newSampledColumn(key, 0, true, rows)
+				byKey[key] = col
+				cols = append(cols, col)
+			}
+			kind, scale, neg, raw, err := classifyJSON(vals[i])
+			if err != nil {
+				return nil, nil, 0, errors.Wrapf(err, "row %d, key '%s'", rows+1, key)
+			}
+			col.add(kind, scale, neg, raw)
+		}
+		for _, col := range cols {
+			if _, ok := seen[col.source]; !ok {
+				col.add(kindNull, 0, false, "")
+			}
+		}
+
+		rows++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, 0, errors.Wrap(err, "reading data")
+	}
+
+	// A key which held an object in one row is skipped in every row.
+	kept := cols[:0]
+	for _, col := range cols {
+		if _, ok := skipped[col.source]; !ok {
+			kept = append(kept, col)
+		}
+	}
+	return kept, skippedKeys, rows, nil
+}
+
+// decodeObject decodes a JSON object, returning its keys in the order in
+// which they appear, and their values.
+func decodeObject(line []byte) ([]string, []interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('{') {
+		return nil, nil, errors.Errorf("expected an object")
+	}
+	var keys []string
+	var vals []interface{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, tok.(string))
+		vals = append(vals, v)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, vals, nil
+}
+
+// classifyJSON returns the kind of a JSON value, and its text.
+func classifyJSON(v interface{}) (kind valueKind, scale int, negative bool, raw string, err error) {
+	switch v := v.(type) {
+	case nil:
+		return kindNull, 0, false, "", nil
+	case bool:
+		return kindBool, 0, false, strconv.FormatBool(v), nil
+	case json.Number:
+		kind, scale, negative = classify(v.String(), true)
+		return kind, scale, negative, v.String(), nil
+	case string:
+		kind, scale, negative = classify(v, false)
+		if kind != kindTimestamp {
+			// Numbers in strings are left as strings.
+			kind, scale, negative = kindString, 0, false
+		}
+		return kind, scale, negative, v, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return kindNull, 0, false, "", nil
+		}
+		kind = kindStringSet
+		if _, ok := v[0].(json.Number); ok {
+			kind = kindIDSet
+		}
+		for _, elem := range v {
+			switch elem := elem.(type) {
+			case string:
+				if kind == kindStringSet {
+					continue
+				}
+			case json.Number:
+				if i, err := elem.Int64(); err == nil && i >= 0 && kind == kindIDSet {
+					continue
+				}
+			}
+			return kindNull, 0, false, "", errors.Errorf("arrays must hold only strings or only non-negative integers")
+		}
+		raw, _ := json.Marshal(v)
+		return kind, 0, false, string(raw), nil
+	default:
+		return kindNull, 0, false, "", errors.Errorf("unexpected value %v", v)
+	}
+}
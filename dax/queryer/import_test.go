@@ -0,0 +1,142 @@
+package queryer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	columns := func(schema *InferredSchema) map[string]string {
+		ret := make(map[string]string)
+		for _, col := range schema.Columns {
+			ret[col.Name] = col.Type
+		}
+		return ret
+	}
+	parses := func(sql string) {
+		t.Helper()
+		_, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+		assert.NoError(t, err, sql)
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		req := ImportRequest{
+			Table:     "people",
+			Format:    ImportFormatCSV,
+			HeaderRow: true,
+		}
+		data := []byte(`Name,Age,Score,Active,Joined,Code,Note
+alice,30,1.5,true,2022-01-02,1,
+bob,41,2,false,2022-01-03T04:05:06Z,x,hello
+carol,,3.25,TRUE,2022-01-04,2,
+`)
+		schema, err := InferSchema(req, data)
+		require.NoError(t, err)
+		assert.Equal(t, 3, schema.SampledRows)
+		assert.Equal(t, []string{"name", "code"}, schema.KeyCandidates)
+		assert.Equal(t, map[string]string{
+			"_id":    "STRING",
+			"age":    "INT",
+			"score":  "DECIMAL(2)",
+			"active": "BOOL",
+			"joined": "TIMESTAMP",
+			"code":   "STRING",
+			"note":   "STRING",
+		}, columns(schema))
+		assert.Equal(t, "Name", schema.Columns[0].Source)
+		assert.Equal(t, 0, *schema.Columns[0].Offset)
+		parses(schema.CreateTable)
+		parses(bulkInsertSQL(schema, req))
+
+		// A smaller sample doesn't see bob's code.
+		req.SampleSize = 1
+		schema, err = InferSchema(req, data)
+		require.NoError(t, err)
+		assert.Equal(t, "INT", columns(schema)["code"])
+
+		// Overrides.
+		req.SampleSize = 0
+		req.ID = "age"
+		req.Types = map[string]string{"code": "STRINGSET", "_id": "STRING"}
+		schema, err = InferSchema(req, data)
+		require.NoError(t, err)
+		assert.Equal(t, "Age", schema.Columns[0].Source)
+		assert.Equal(t, "STRING", columns(schema)["_id"])
+		assert.Equal(t, "STRINGSET", columns(schema)["code"])
+		assert.NotContains(t, columns(schema), "age")
+
+		for _, types := range []map[string]string{{"nope": "INT"}, {"code": "INT(2)"}, {"code": "IDSETQ"}, {"_id": "INT"}} {
+			req.Types = types
+			_, err = InferSchema(req, data)
+			assert.Error(t, err, types)
+		}
+	})
+
+	t.Run("CSVWithoutHeader", func(t *testing.T) {
+		schema, err := InferSchema(ImportRequest{Table: "t", Format: ImportFormatCSV}, []byte("5,a\n7,b\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c0", "c1"}, schema.KeyCandidates)
+		assert.Equal(t, map[string]string{"_id": "ID", "c1": "STRING"}, columns(schema))
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		_, err := InferSchema(ImportRequest{Table: "t", Format: ImportFormatCSV, HeaderRow: true}, []byte("a,b\n1,x\n1,\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		req := ImportRequest{
+			Table:  "events",
+			Format: ImportFormatNDJSON,
+		}
+		data := []byte(`{"id": 1, "kind": "click", "tags": ["a", "b"], "ids": [1, 2], "at": "2022-01-02T03:04:05Z", "n": 1, "meta": {"x": 1}}
+{"id": 2, "kind": 3, "tags": [], "ids": [3], "at": "2022-01-02", "n": 1.25, "ok": true, "meta": null}
+
+{"id": 3, "kind": null, "n": 2}
+`)
+		schema, err := InferSchema(req, data)
+		require.NoError(t, err)
+		assert.Equal(t, 3, schema.SampledRows)
+		assert.Equal(t, []string{"id"}, schema.KeyCandidates)
+		assert.Equal(t, []string{"meta"}, schema.Skipped)
+		assert.Equal(t, "ID", schema.Columns[0].Type)
+		assert.Nil(t, schema.Columns[0].Offset)
+		assert.Equal(t, map[string]string{
+			"_id":  "ID",
+			"kind": "STRING",
+			"tags": "STRINGSET",
+			"ids":  "IDSET",
+			"at":   "TIMESTAMP",
+			"n":    "DECIMAL(2)",
+			"ok":   "BOOL",
+		}, columns(schema))
+		parses(schema.CreateTable)
+		parses(bulkInsertSQL(schema, req))
+
+		// Booleans can't be widened.
+		data = []byte("{\"id\": 1, \"v\": true}\n{\"id\": 2, \"v\": \"x\"}\n")
+		_, err = InferSchema(req, data)
+		assert.Error(t, err)
+		req.Types = map[string]string{"v": "STRING"}
+		_, err = InferSchema(req, data)
+		assert.NoError(t, err)
+	})
+
+	for _, tc := range []struct {
+		req  ImportRequest
+		data string
+	}{
+		{ImportRequest{Format: "XML"}, "<a/>"},
+		{ImportRequest{Format: ImportFormatCSV}, ""},
+		{ImportRequest{Format: ImportFormatNDJSON}, "[1, 2]\n"},
+		{ImportRequest{Format: ImportFormatNDJSON}, "{\"id\": 1, \"s\": [1, \"a\"]}\n"},
+		{ImportRequest{Format: ImportFormatCSV, HeaderRow: true}, "A b,a-b\n1,2\n"},
+	} {
+		_, err := InferSchema(tc.req, []byte(tc.data))
+		assert.Error(t, err, tc.data)
+	}
+}
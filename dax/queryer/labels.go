@@ -0,0 +1,110 @@
+package queryer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// DefaultLabelMaxMetricValues is the default maximum number of distinct
+// values recorded in metrics for each label.
+const DefaultLabelMaxMetricValues = 50
+
+// labelValueOther is the value under which a label is recorded in metrics once
+// the maximum number of distinct values for that label has been reached.
+const labelValueOther = "other"
+
+// LabelConfig configures which query labels the queryer accepts, and which of
+// them it records in metrics.
+type LabelConfig struct {
+	// AllowedKeys are the label keys which queries may carry. Queries carrying
+	// any other label are rejected. If empty, any key is allowed.
+	AllowedKeys []string `toml:"allowed-keys"`
+
+	// MetricKeys are the label keys recorded in metrics. Labels are always
+	// recorded in the query history and slow query log, but since each
+	// distinct label value is a separate time series, only these keys are
+	// recorded in metrics.
+	MetricKeys []string `toml:"metric-keys"`
+
+	// MaxMetricValues is the maximum number of distinct values recorded in
+	// metrics for each of the MetricKeys. Once the maximum is reached, further
+	// values are recorded as "other". A value of 0 uses
+	// DefaultLabelMaxMetricValues.
+	MaxMetricValues int `toml:"max-metric-values"`
+}
+
+// LabelPolicy decides which query labels the queryer accepts, and how they're
+// recorded in metrics. Implementations must be safe for concurrent use.
+type LabelPolicy interface {
+	// Validate returns an error if a query carrying labels should be
+	// rejected. It's called for every query, including those without labels.
+	Validate(labels dax.QueryLabels) error
+
+	// MetricValue returns the value under which the label key=value should be
+	// recorded in metrics, or false if it should not be recorded in metrics.
+	MetricValue(key, value string) (string, bool)
+}
+
+// NewLabelPolicy returns the LabelPolicy described by cfg.
+func NewLabelPolicy(cfg LabelConfig) LabelPolicy {
+	p := &allowlistLabelPolicy{
+		maxValues: cfg.MaxMetricValues,
+		values:    make(map[string]map[string]struct{}, len(cfg.MetricKeys)),
+	}
+	if p.maxValues <= 0 {
+		p.maxValues = DefaultLabelMaxMetricValues
+	}
+	if len(cfg.AllowedKeys) > 0 {
+		p.allowed = make(map[string]struct{}, len(cfg.AllowedKeys))
+		for _, k := range cfg.AllowedKeys {
+			p.allowed[k] = struct{}{}
+		}
+	}
+	for _, k := range cfg.MetricKeys {
+		p.values[k] = make(map[string]struct{})
+	}
+	return p
+}
+
+// allowlistLabelPolicy is the LabelPolicy returned by NewLabelPolicy.
+type allowlistLabelPolicy struct {
+	// allowed is the set of keys which queries may carry; nil allows any.
+	allowed map[string]struct{}
+
+	mu sync.Mutex
+	// values holds, for each key recorded in metrics, the values which have
+	// been recorded so far.
+	values    map[string]map[string]struct{}
+	maxValues int
+}
+
+func (p *allowlistLabelPolicy) Validate(labels dax.QueryLabels) error {
+	if p.allowed == nil {
+		return nil
+	}
+	for k := range labels {
+		if _, ok := p.allowed[k]; !ok {
+			return dax.NewErrInvalidQueryLabels(fmt.Sprintf("label '%s' is not allowed", k))
+		}
+	}
+	return nil
+}
+
+func (p *allowlistLabelPolicy) MetricValue(key, value string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seen, ok := p.values[key]
+	if !ok {
+		return "", false
+	}
+	if _, ok := seen[value]; ok {
+		return value, true
+	}
+	if len(seen) >= p.maxValues {
+		return labelValueOther, true
+	}
+	seen[value] = struct{}{}
+	return value, true
+}
@@ -0,0 +1,67 @@
+package queryer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelPolicy(t *testing.T) {
+	t.Run("AllowedKeys", func(t *testing.T) {
+		p := NewLabelPolicy(LabelConfig{AllowedKeys: []string{"app"}})
+		assert.NoError(t, p.Validate(nil))
+		assert.NoError(t, p.Validate(dax.QueryLabels{"app": "billing"}))
+		err := p.Validate(dax.QueryLabels{"app": "billing", "user": "bob"})
+		assert.True(t, errors.Is(err, dax.ErrInvalidQueryLabels), "expected invalid labels, got %v", err)
+
+		// Without AllowedKeys, any label is accepted.
+		assert.NoError(t, NewLabelPolicy(LabelConfig{}).Validate(dax.QueryLabels{"user": "bob"}))
+	})
+
+	t.Run("MetricValue", func(t *testing.T) {
+		p := NewLabelPolicy(LabelConfig{MetricKeys: []string{"app"}, MaxMetricValues: 2})
+
+		_, ok := p.MetricValue("user", "bob")
+		assert.False(t, ok)
+
+		for _, tc := range []struct{ value, exp string }{
+			{"billing", "billing"},
+			{"reports", "reports"},
+			{"search", labelValueOther},
+			{"billing", "billing"},
+		} {
+			v, ok := p.MetricValue("app", tc.value)
+			assert.True(t, ok)
+			assert.Equal(t, tc.exp, v)
+		}
+	})
+}
+
+func TestQueryHistory(t *testing.T) {
+	q := New(Config{QueryHistorySize: 2})
+	org1 := dax.NewQualifiedDatabaseID("org1", "db")
+	org2 := dax.NewQualifiedDatabaseID("org2", "db")
+
+	q.recordQuery(QueryRecord{Database: org1, SQL: "SELECT 1", Labels: dax.QueryLabels{"app": "billing"}}, nil, nil)
+	q.recordQuery(QueryRecord{Database: org2, SQL: "SELECT 2", Duration: time.Second}, nil, errors.New(errors.ErrUncoded, "failed"))
+	q.recordQuery(QueryRecord{Database: org1, Statement: "stmt"}, nil, nil)
+
+	// The oldest query has been dropped, and queries from other organizations
+	// aren't returned.
+	recs := q.QueryHistory("org1")
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, "stmt", recs[0].Statement)
+	}
+	recs = q.QueryHistory("org2")
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, "failed", recs[0].Error)
+	}
+
+	// A negative size disables the history.
+	q = New(Config{QueryHistorySize: -1})
+	q.recordQuery(QueryRecord{Database: org1, SQL: "SELECT 1"}, nil, nil)
+	assert.Empty(t, q.QueryHistory("org1"))
+}
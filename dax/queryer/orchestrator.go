@@ -49,6 +49,12 @@ type ServerlessTopology struct {
 }
 
 func (m *ServerlessTopology) ComputeNodes(ctx context.Context, index string, shards []uint64) ([]dax.ComputeNode, error) {
+	// A query of all shards of a table whose shards are restricted (see
+	// StreamAggregates) reads only the shards it's restricted to.
+	if shards == nil {
+		shards, _ = restrictedShards(ctx, index)
+	}
+
 	var daxShards = make(dax.ShardNums, len(shards))
 	for i, s := range shards {
 		daxShards[i] = dax.ShardNum(s)
@@ -88,6 +94,9 @@ type orchestrator struct {
 	// Client used for remote requests.
 	client *featurebase.InternalClient
 
+	// breakers are the circuit breakers for the computers called by client.
+	breakers *breakers
+
 	logger logger.Logger
 }
 
@@ -544,6 +553,10 @@ func (o *orchestrator) executeCall(ctx context.Context, tableKeyer dax.TableKeye
 		statFn(featurebase.CounterQueryPercentileTotal)
 		res, err := o.executePercentile(ctx, tableKeyer, c, shards, opt)
 		return res, errors.Wrap(err, "executePercentile")
+	case "Sketch":
+		statFn(featurebase.CounterQuerySketchTotal)
+		res, err := o.executeSketch(ctx, tableKeyer, c, shards, opt)
+		return res, errors.Wrap(err, "executeSketch")
 	// case "Delete":
 	// 	statFn(featurebase.CounterQueryDeleteTotal)
 	// 	res, err := o.executeDeleteRecords(ctx, index, c, shards, opt)
@@ -784,6 +797,42 @@ func (o *orchestrator) executeSum(ctx context.Context, tableKeyer dax.TableKeyer
 	return other, nil
 }
 
+// executeSketch executes a Sketch() call. Each computer builds the sketch
+// for its shards, and the computers' sketches are merged here.
+func (o *orchestrator) executeSketch(ctx context.Context, tableKeyer dax.TableKeyer, c *pql.Call, shards []uint64, opt *featurebase.ExecOptions) (*featurebase.Sketch, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "Executor.executeSketch")
+	defer span.Finish()
+
+	sketch, err := featurebase.NewSketchFromCall(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating sketch")
+	}
+	if len(c.Children) > 1 {
+		return nil, errors.New(errors.ErrUncoded, "Sketch() only accepts a single bitmap input")
+	}
+
+	// Merge returned results at coordinating node.
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		other, _ := prev.(*featurebase.Sketch)
+		if other == nil {
+			return v
+		}
+		if err := other.Merge(v.(*featurebase.Sketch)); err != nil {
+			return err
+		}
+		return other
+	}
+
+	result, err := o.mapReduce(ctx, tableKeyer, shards, c, opt, reduceFn)
+	if err != nil {
+		return nil, err
+	}
+	if other, _ := result.(*featurebase.Sketch); other != nil {
+		sketch = other
+	}
+	return sketch, nil
+}
+
 // executeDistinct executes a Distinct call on a field. It returns a
 // SignedRow for int fields and a *Row for set/mutex/time fields.
 func (o *orchestrator) executeDistinct(ctx context.Context, tableKeyer dax.TableKeyer, c *pql.Call, shards []uint64, opt *featurebase.ExecOptions) (interface{}, error) {
@@ -1995,6 +2044,19 @@ func (o *orchestrator) executeExtract(ctx context.Context, tableKeyer dax.TableK
 	}
 	// TODO(tlt): is `fields` used?
 
+	// With a limit, each computer returns only the rows within the limit, and
+	// so does each merge of their results.
+	limit, err := featurebase.ParseExtractLimit(c, func(name string) (featurebase.FieldOptions, error) {
+		fld, err := o.schemaFieldInfo(ctx, tableKeyer, name)
+		if err != nil {
+			return featurebase.FieldOptions{}, errors.Wrapf(err, "getting field: %s", name)
+		}
+		return fld.Options, nil
+	})
+	if err != nil {
+		return featurebase.ExtractedIDMatrix{}, err
+	}
+
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
 		other, _ := prev.(featurebase.ExtractedIDMatrix)
@@ -2002,6 +2064,13 @@ func (o *orchestrator) executeExtract(ctx context.Context, tableKeyer dax.TableK
 			return err
 		}
 		other.Append(v.(featurebase.ExtractedIDMatrix))
+		if limit != nil {
+			limited, err := limit.Apply(other)
+			if err != nil {
+				return err
+			}
+			return limited
+		}
 		return other
 	}
 
@@ -2014,6 +2083,9 @@ func (o *orchestrator) executeExtract(ctx context.Context, tableKeyer dax.TableK
 	sort.Slice(results.Columns, func(i, j int) bool {
 		return results.Columns[i].ColumnID < results.Columns[j].ColumnID
 	})
+	if limit != nil {
+		return limit.Apply(results)
+	}
 	return results, nil
 }
 
@@ -2142,6 +2214,10 @@ func (o *orchestrator) executeCount(ctx context.Context, tableKeyer dax.TableKey
 		}
 	}
 
+	if a := approximateCountsFromContext(ctx); a != nil {
+		return o.approximateCount(ctx, tableKeyer, c, shards, opt, a)
+	}
+
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
 		other, _ := prev.(uint64)
@@ -2158,7 +2234,10 @@ func (o *orchestrator) executeCount(ctx context.Context, tableKeyer dax.TableKey
 }
 
 // remoteExec executes a PQL query remotely for a set of shards on a node.
-func (o *orchestrator) remoteExec(ctx context.Context, node dax.Address, index string, q *pql.Query, shards []uint64, embed []*featurebase.Row) (results []interface{}, err error) { // nolint: interfacer
+//
+// remoteExec also returns the time the node reports having spent executing the
+// query.
+func (o *orchestrator) remoteExec(ctx context.Context, node dax.Address, index string, q *pql.Query, shards []uint64, embed []*featurebase.Row) (*featurebase.QueryResponse, error) { // nolint: interfacer
 	span, ctx := tracing.StartSpanFromContext(ctx, "Executor.executeExec")
 	defer span.Finish()
 
@@ -2175,7 +2254,7 @@ func (o *orchestrator) remoteExec(ctx context.Context, node dax.Address, index s
 		return nil, err
 	}
 
-	return resp.Results, resp.Err
+	return resp, resp.Err
 }
 
 // mapReduce maps and reduces data across the cluster.
@@ -2226,6 +2305,14 @@ func (o *orchestrator) mapReduce(ctx context.Context, tableKeyer dax.TableKeyer,
 	for _, n := range nodes {
 		expected += len(n.Shards)
 	}
+	// mergeTime is the time spent reducing responses, as opposed to waiting
+	// for them.
+	var mergeTime time.Duration
+	defer func() {
+		if err == nil {
+			dax.ObserveQueryStage(ctx, dax.QueryStageMerge, mergeTime)
+		}
+	}()
 	done := ctx.Done()
 	for expected > 0 {
 		select {
@@ -2241,7 +2328,9 @@ func (o *orchestrator) mapReduce(ctx context.Context, tableKeyer dax.TableKeyer,
 			expected -= len(resp.shards)
 
 			// Reduce value.
+			mergeStart := time.Now()
 			result = reduceFn(ctx, result, resp.result)
+			mergeTime += time.Since(mergeStart)
 			var ok bool
 			// note *not* shadowed.
 			if err, ok = result.(error); ok {
@@ -2320,6 +2409,9 @@ func (o *orchestrator) mapper(ctx context.Context, eg *errgroup.Group, ch chan m
 				embeddedRowsForNode = makeEmbeddedDataForShards(opt.EmbeddedData, shards)
 			}
 
+			start := time.Now()
+			var execTime time.Duration
+			var bytesRead int64
 			attempts := 0
 			for ; attempts == 0 || (resp.err != nil && strings.Contains(resp.err.Error(), errConnectionRefused) && attempts < 3); attempts++ {
 				// On error retry against remaining nodes. If an error returns then
@@ -2331,12 +2423,44 @@ func (o *orchestrator) mapper(ctx context.Context, eg *errgroup.Group, ch chan m
 				// the latter, there's no need to retry a replica, we should trust
 				// the error from the healthy node and return that immediately.
 				// TODO(jaffee) retries should contact Controller and find out who is up and has access to shards needed
-				results, err := o.remoteExec(ctx, node.Address, index, &pql.Query{Calls: []*pql.Call{c}}, shards, embeddedRowsForNode)
-				if len(results) > 0 {
-					resp.result = results[0]
+				//
+				// A request to a computer whose circuit breaker is open fails
+				// immediately, without retrying, since there are no replicas
+				// to fail over to.
+				var qresp *featurebase.QueryResponse
+				err := o.breakers.allow(node.Address)
+				if err == nil {
+					qresp, err = o.remoteExec(ctx, node.Address, index, &pql.Query{Calls: []*pql.Call{c}}, shards, embeddedRowsForNode)
+					o.breakers.done(ctx, node.Address, err)
+				}
+				if qresp != nil {
+					if len(qresp.Results) > 0 {
+						resp.result = qresp.Results[0]
+					}
+					execTime += qresp.ExecutionTime
+					bytesRead += qresp.Size
 				}
 				resp.err = err
 			}
+			dc := dax.QueryDebugComputer{
+				Address:       node.Address,
+				Table:         dax.TableKey(index),
+				Shards:        shards,
+				Attempts:      attempts,
+				Duration:      time.Since(start),
+				ExecutionTime: execTime,
+			}
+			if resp.err != nil {
+				dc.Error = resp.err.Error()
+			} else {
+				// Stage metrics are per request, rather than per query,
+				// since requests to computers run concurrently.
+				dax.HistogramQueryStageDurationSeconds.WithLabelValues(dax.QueryStageCompute).Observe(execTime.Seconds())
+				dax.HistogramQueryStageDurationSeconds.WithLabelValues(dax.QueryStageNetwork).Observe((dc.Duration - execTime).Seconds())
+				dax.QueryUsageFromContext(ctx).AddRequest(execTime, len(shards), bytesRead, resultRows(resp.result))
+			}
+			dax.QueryDebugFromContext(ctx).AddComputer(dc)
+
 			// Return response to the channel.
 			select {
 			case <-done:
@@ -2958,10 +3082,13 @@ func (o *orchestrator) translateCall(ctx context.Context, c *pql.Call, tableKeye
 			if err != nil || fieldName == "" {
 				return nil, fmt.Errorf("cannot read field name for Rows call")
 			}
-			if f, err := o.schemaFieldInfo(ctx, tableKeyer, fieldName); err != nil {
+			if f, err := o.schemaField(ctx, tableKeyer, fieldName); err != nil {
 				return nil, errors.Wrapf(err, "getting field %q", fieldName)
-			} else if !f.Options.Keys {
+			} else if !featurebase.FieldToFieldInfo(f).Options.Keys {
 				return nil, fmt.Errorf("'%s' is not a set/mutex/time field with a string key", fieldName)
+			} else if f.Options.Encryption != "" {
+				// The pattern would be matched against ciphertexts.
+				return nil, dax.NewErrEncryptedField(f.Name, "like is not supported")
 			}
 		}
 
@@ -3286,6 +3413,33 @@ func (o *orchestrator) translateResult(ctx context.Context, qtbl *dax.QualifiedT
 			}
 		}
 
+	case *featurebase.Sketch:
+		if result.TopK == nil {
+			break
+		}
+		field, err := o.schemaFieldInfo(ctx, qtbl, result.Field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field '%q'", result.Field)
+		}
+		if field.Options.Keys {
+			items := result.TopK.Items()
+			ids := make([]uint64, len(items))
+			for i := range items {
+				ids[i] = uint64(items[i].Value.(int64))
+			}
+			keys, err := o.trans.TranslateFieldListIDs(ctx, idx.Name, result.Field, ids)
+			if err != nil {
+				return nil, err
+			}
+			for i := range items {
+				items[i].Value = keys[i]
+			}
+			return &featurebase.Sketch{
+				Field: result.Field,
+				TopK:  featurebase.NewTopKSketchFromItems(result.TopK.Capacity(), result.TopK.N(), items),
+			}, nil
+		}
+
 	case *featurebase.PairsField:
 		if fieldName := callArgString(call, "_field"); fieldName != "" {
 			field, err := o.schemaFieldInfo(ctx, qtbl, fieldName)
@@ -3646,6 +3800,34 @@ func validateQueryContext(ctx context.Context) error {
 
 type reduceFunc func(ctx context.Context, prev, v interface{}) interface{}
 
+// resultRows returns the number of rows (records, groups, or pairs) in a
+// result returned by a compute node, for QueryUsage.RowsExamined. Results
+// which are a single value count as one row.
+func resultRows(result interface{}) int64 {
+	switch r := result.(type) {
+	case nil:
+		return 0
+	case *featurebase.Row:
+		return int64(r.Count())
+	case featurebase.SignedRow:
+		return int64(r.Pos.Count() + r.Neg.Count())
+	case featurebase.RowIDs:
+		return int64(len(r))
+	case *featurebase.PairsField:
+		return int64(len(r.Pairs))
+	case *featurebase.GroupCounts:
+		return int64(len(r.Groups()))
+	case []featurebase.GroupCount:
+		return int64(len(r))
+	case featurebase.ExtractedIDMatrix:
+		return int64(len(r.Columns))
+	case featurebase.DistinctTimestamp:
+		return int64(len(r.Values))
+	default:
+		return 1
+	}
+}
+
 type mapResponse struct {
 	node   dax.Address
 	shards []uint64
@@ -3699,6 +3881,16 @@ func (o *qualifiedOrchestrator) Execute(ctx context.Context, tableKeyer dax.Tabl
 // instead use dax.Table and dax.Field, this helper function can be factored
 // out.
 func (o *orchestrator) schemaFieldInfo(ctx context.Context, tableKeyer dax.TableKeyer, fieldName string) (*featurebase.FieldInfo, error) {
+	fld, err := o.schemaField(ctx, tableKeyer, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	return featurebase.FieldToFieldInfo(fld), nil
+}
+
+// schemaField returns the dax.Field named fieldName in the table identified by
+// tableKeyer.
+func (o *orchestrator) schemaField(ctx context.Context, tableKeyer dax.TableKeyer, fieldName string) (*dax.Field, error) {
 	var tbl *dax.Table
 	var err error
 
@@ -3732,7 +3924,7 @@ func (o *orchestrator) schemaFieldInfo(ctx context.Context, tableKeyer dax.Table
 		return nil, errors.Errorf("field not found: %s", fieldName)
 	}
 
-	return featurebase.FieldToFieldInfo(fld), nil
+	return fld, nil
 }
 
 // schemaIndexInfo - see comment on schemaFieldInfo.
@@ -0,0 +1,98 @@
+package queryer
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+)
+
+// Page requests a single page of the results of a SELECT statement with a
+// LIMIT (or TOP), so that a client can tell whether there are more rows
+// beyond it without a separate count query. The page is the LIMIT rows
+// starting at Offset. To find out whether any rows follow, the statement is
+// executed with its limit raised to Offset+LIMIT+1, and the rows outside the
+// page are discarded rather than returned. The response's HasMore is set, as
+// is its NextOffset when HasMore is true.
+//
+// Each page is a separate execution of the statement, so pages are only
+// consistent with one another if the statement orders its rows completely:
+// with an ORDER BY whose terms end with a unique column, such as _id, the
+// pages partition the results as long as the data doesn't change between
+// requests. Without an ORDER BY, rows are returned in the order they're
+// scanned, which is usually, but not necessarily, the same from one execution
+// to the next. Since the rows before Offset are still read and discarded, the
+// cost of a page grows with its offset.
+type Page struct {
+	Offset int64
+}
+
+type pageKey struct{}
+
+// WithPage returns a copy of ctx which requests a single page of the results
+// of a query, as described by p.
+func WithPage(ctx context.Context, p Page) context.Context {
+	return context.WithValue(ctx, pageKey{}, p)
+}
+
+// pageFromContext returns the Page requested by ctx, if any.
+func pageFromContext(ctx context.Context) (Page, bool) {
+	p, ok := ctx.Value(pageKey{}).(Page)
+	return p, ok
+}
+
+// widen raises the limit of st so that it returns the rows of the page and
+// the row after it, and returns the page size: st's original limit.
+func (p Page) widen(st parser.Statement) (int64, error) {
+	if p.Offset < 0 {
+		return 0, errors.Errorf("invalid page offset %d: can't be negative", p.Offset)
+	}
+	sel, ok := st.(*parser.SelectStatement)
+	if !ok {
+		return 0, errors.Errorf("paged results require a SELECT statement with a LIMIT")
+	}
+	expr := &sel.LimitExpr
+	if sel.TopExpr != nil {
+		expr = &sel.TopExpr
+	}
+	lit, ok := (*expr).(*parser.IntegerLit)
+	if !ok {
+		return 0, errors.Errorf("paged results require a SELECT statement with an integer LIMIT")
+	}
+	limit, err := strconv.ParseInt(lit.Value, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, errors.Errorf("invalid limit '%s'", lit.Value)
+	}
+	if limit > math.MaxInt64-p.Offset-1 {
+		return 0, errors.Errorf("page offset %d and limit %d are too large", p.Offset, limit)
+	}
+
+	*expr = &parser.IntegerLit{
+		ValuePos: lit.ValuePos,
+		Value:    strconv.FormatInt(p.Offset+limit+1, 10),
+	}
+	return limit, nil
+}
+
+// trim removes the rows outside the page from resp, the result of a statement
+// widened by widen, and sets its HasMore and NextOffset.
+func (p Page) trim(resp *featurebase.WireQueryResponse, limit int64) {
+	data := resp.Data
+	if int64(len(data)) <= p.Offset {
+		data = data[:0]
+	} else {
+		data = data[p.Offset:]
+	}
+
+	hasMore := int64(len(data)) > limit
+	if hasMore {
+		data = data[:limit]
+		next := p.Offset + limit
+		resp.NextOffset = &next
+	}
+	resp.Data = data
+	resp.HasMore = &hasMore
+}
@@ -0,0 +1,78 @@
+package queryer
+
+import (
+	"strings"
+	"testing"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage(t *testing.T) {
+	parse := func(sql string) parser.Statement {
+		st, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+		require.NoError(t, err, sql)
+		return st
+	}
+
+	t.Run("Widen", func(t *testing.T) {
+		st := parse("SELECT _id FROM t ORDER BY _id LIMIT 10")
+		limit, err := Page{Offset: 20}.widen(st)
+		require.NoError(t, err)
+		assert.EqualValues(t, 10, limit)
+		assert.Equal(t, "31", st.(*parser.SelectStatement).LimitExpr.(*parser.IntegerLit).Value)
+
+		st = parse("SELECT TOP(5) _id FROM t")
+		limit, err = Page{}.widen(st)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, limit)
+		assert.Equal(t, "6", st.(*parser.SelectStatement).TopExpr.(*parser.IntegerLit).Value)
+
+		for _, sql := range []string{"SELECT _id FROM t", "SHOW TABLES"} {
+			_, err := Page{}.widen(parse(sql))
+			assert.Error(t, err, sql)
+		}
+		_, err = Page{Offset: -1}.widen(parse("SELECT _id FROM t LIMIT 1"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Trim", func(t *testing.T) {
+		rows := func(n int) [][]interface{} {
+			data := make([][]interface{}, n)
+			for i := range data {
+				data[i] = []interface{}{int64(i)}
+			}
+			return data
+		}
+
+		for _, tc := range []struct {
+			name    string
+			offset  int64
+			rows    int
+			data    [][]interface{}
+			hasMore bool
+			next    int64
+		}{
+			{"FirstPage", 0, 4, [][]interface{}{{int64(0)}, {int64(1)}, {int64(2)}}, true, 3},
+			{"LastPage", 3, 6, [][]interface{}{{int64(3)}, {int64(4)}, {int64(5)}}, false, 0},
+			{"PartialPage", 3, 4, [][]interface{}{{int64(3)}}, false, 0},
+			{"BeyondEnd", 10, 4, [][]interface{}{}, false, 0},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				resp := &featurebase.WireQueryResponse{Data: rows(tc.rows)}
+				Page{Offset: tc.offset}.trim(resp, 3)
+				assert.Equal(t, tc.data, resp.Data)
+				require.NotNil(t, resp.HasMore)
+				assert.Equal(t, tc.hasMore, *resp.HasMore)
+				if tc.hasMore {
+					require.NotNil(t, resp.NextOffset)
+					assert.Equal(t, tc.next, *resp.NextOffset)
+				} else {
+					assert.Nil(t, resp.NextOffset)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,346 @@
+package queryer
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+// DefaultPrewarmRefreshInterval is the default time between refreshes of the
+// connections pre-warmed to each computer. It's well below the idle timeout of
+// the connections, so that a refresh reuses (and keeps alive) the idle
+// connections opened by the previous one.
+const DefaultPrewarmRefreshInterval = 30 * time.Second
+
+// PrewarmConfig configures the connections which the queryer keeps open to
+// each computer, so that the first queries sent to a computer don't pay for
+// opening new connections.
+type PrewarmConfig struct {
+	// MinIdleConns is the number of idle connections kept open to each
+	// computer known to the controller. If 0, connections aren't pre-warmed.
+	MinIdleConns int `toml:"min-idle-conns"`
+
+	// RefreshInterval is how often the queryer gets the computers from the
+	// controller and tops up the idle connections to each of them. If 0,
+	// DefaultPrewarmRefreshInterval is used.
+	RefreshInterval time.Duration `toml:"refresh-interval"`
+}
+
+// connPool is the http.RoundTripper through which the queryer sends requests
+// to computers. It keeps track of the connections to each computer which are
+// open, and which of those are idle, and pre-warms the connections to the
+// computers known to the controller.
+type connPool struct {
+	transport *http.Transport
+	client    *http.Client
+
+	minIdle  int
+	interval time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostConns
+	// known are the computers last returned by the controller, keyed by
+	// host:port.
+	known map[string]dax.Address
+
+	logger logger.Logger
+}
+
+// hostConns counts the connections to a single computer.
+type hostConns struct {
+	open int
+	idle int
+}
+
+func newConnPool(cfg PrewarmConfig, log logger.Logger) *connPool {
+	p := &connPool{
+		minIdle:  cfg.MinIdleConns,
+		interval: cfg.RefreshInterval,
+		hosts:    make(map[string]*hostConns),
+		known:    make(map[string]dax.Address),
+		logger:   log,
+	}
+	if p.minIdle < 0 {
+		p.minIdle = 0
+	}
+	if p.interval <= 0 {
+		p.interval = DefaultPrewarmRefreshInterval
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return p.track(conn, addr), nil
+	}
+	if p.minIdle > 0 {
+		// The idle connections to every computer have to fit in the pool.
+		transport.MaxIdleConns = 0
+		if transport.MaxIdleConnsPerHost < p.minIdle {
+			transport.MaxIdleConnsPerHost = p.minIdle
+		}
+	}
+	p.transport = transport
+	p.client = &http.Client{Transport: dax.ServiceTransport(p)}
+
+	return p
+}
+
+// RoundTrip implements http.RoundTripper. It traces the request to learn when
+// its connection is taken from, and put back into, the idle pool.
+func (p *connPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var conn *trackedConn
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if c, ok := info.Conn.(*trackedConn); ok {
+				conn = c
+				p.setIdle(c, false)
+			}
+		},
+		PutIdleConn: func(err error) {
+			if err == nil && conn != nil {
+				p.setIdle(conn, true)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return p.transport.RoundTrip(req)
+}
+
+// trackedConn is a connection counted by a connPool.
+type trackedConn struct {
+	net.Conn
+	pool *connPool
+	host string
+
+	// idle and closed are protected by pool.mu.
+	idle   bool
+	closed bool
+}
+
+func (c *trackedConn) Close() error {
+	c.pool.untrack(c)
+	return c.Conn.Close()
+}
+
+func (p *connPool) track(conn net.Conn, host string) *trackedConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.host(host).open++
+	p.updateGauges(host)
+	return &trackedConn{Conn: conn, pool: p, host: host}
+}
+
+func (p *connPool) untrack(c *trackedConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	h := p.host(c.host)
+	h.open--
+	if c.idle {
+		h.idle--
+	}
+	p.updateGauges(c.host)
+}
+
+func (p *connPool) setIdle(c *trackedConn, idle bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c.closed || c.idle == idle {
+		return
+	}
+	c.idle = idle
+	if idle {
+		p.host(c.host).idle++
+	} else {
+		p.host(c.host).idle--
+	}
+	p.updateGauges(c.host)
+}
+
+// host returns the counts for host, adding them if necessary. The caller must
+// hold p.mu.
+func (p *connPool) host(host string) *hostConns {
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &hostConns{}
+		p.hosts[host] = h
+	}
+	return h
+}
+
+// updateGauges reports the counts for host. Once a host which is no longer
+// known has no open connections, it's forgotten. The caller must hold p.mu.
+func (p *connPool) updateGauges(host string) {
+	h := p.hosts[host]
+	if _, ok := p.known[host]; !ok && h.open <= 0 {
+		delete(p.hosts, host)
+		dax.GaugeComputerOpenConns.DeleteLabelValues(host)
+		dax.GaugeComputerIdleConns.DeleteLabelValues(host)
+		return
+	}
+	dax.GaugeComputerOpenConns.WithLabelValues(host).Set(float64(h.open))
+	dax.GaugeComputerIdleConns.WithLabelValues(host).Set(float64(h.idle))
+}
+
+// setKnown replaces the computers to pre-warm connections to.
+func (p *connPool) setKnown(addrs []dax.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	known := make(map[string]dax.Address, len(addrs))
+	for _, addr := range addrs {
+		known[addr.HostPort()] = addr
+	}
+	old := p.known
+	p.known = known
+	for host := range old {
+		if _, ok := known[host]; !ok {
+			if _, ok := p.hosts[host]; ok {
+				p.updateGauges(host)
+			}
+		}
+	}
+}
+
+// run refreshes the pre-warmed connections every interval until ctx is
+// done. It does nothing if pre-warming is disabled.
+func (p *connPool) run(ctx context.Context, controller dax.Controller) {
+	if p.minIdle == 0 {
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.refresh(ctx, controller); err != nil && ctx.Err() == nil {
+			p.logger.Warnf("pre-warming connections to computers: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh gets the computers known to the controller, and sends minIdle
+// concurrent requests to each of them. Idle connections are reused by the
+// requests, keeping them alive, and the missing ones are opened.
+func (p *connPool) refresh(ctx context.Context, controller dax.Controller) error {
+	addrs, err := computerAddresses(ctx, controller)
+	if err != nil {
+		return errors.Wrap(err, "getting computers")
+	}
+	p.setKnown(addrs)
+
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr dax.Address) {
+			defer wg.Done()
+			p.warm(ctx, addr)
+		}(addr)
+	}
+	wg.Wait()
+	return nil
+}
+
+// warm sends minIdle concurrent requests to the computer at addr, and counts
+// the connections opened to do so.
+func (p *connPool) warm(ctx context.Context, addr dax.Address) {
+	host := addr.HostPort()
+	before := p.openConns(host)
+
+	url := addr.WithScheme("http") + "/health"
+	var wg sync.WaitGroup
+	for i := 0; i < p.minIdle; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return
+			}
+			resp, err := p.client.Do(req)
+			if err != nil {
+				p.logger.Debugf("pre-warming connection to %s: %v", addr, err)
+				return
+			}
+			// The body has to be read to the end for the connection to be
+			// reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if opened := p.openConns(host) - before; opened > 0 {
+		dax.CounterPrewarmedConns.Add(float64(opened))
+	}
+}
+
+// openConns returns the number of open connections to host.
+func (p *connPool) openConns(host string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if h, ok := p.hosts[host]; ok {
+		return h.open
+	}
+	return 0
+}
+
+// idleConns returns the number of idle connections to host.
+func (p *connPool) idleConns(host string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if h, ok := p.hosts[host]; ok {
+		return h.idle
+	}
+	return 0
+}
+
+// close closes the idle connections.
+func (p *connPool) close() {
+	p.transport.CloseIdleConnections()
+}
+
+// computerAddresses returns the address of every computer which holds shards
+// of any table.
+func computerAddresses(ctx context.Context, controller dax.Controller) ([]dax.Address, error) {
+	qtbls, err := controller.Tables(ctx, dax.QualifiedDatabaseID{})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting tables")
+	}
+	seen := make(map[dax.Address]struct{})
+	var addrs []dax.Address
+	for _, qtbl := range qtbls {
+		nodes, err := controller.ComputeNodes(ctx, qtbl.QualifiedID())
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting computers for table %s", qtbl.Key())
+		}
+		for _, node := range nodes {
+			if _, ok := seen[node.Address]; ok {
+				continue
+			}
+			seen[node.Address] = struct{}{}
+			addrs = append(addrs, node.Address)
+		}
+	}
+	return addrs, nil
+}
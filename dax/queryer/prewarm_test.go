@@ -0,0 +1,90 @@
+package queryer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topologyController is a dax.Controller with a single table, whose compute
+// nodes can be changed.
+type topologyController struct {
+	dax.Controller
+	mu    sync.Mutex
+	addrs []dax.Address
+}
+
+func (c *topologyController) Tables(ctx context.Context, qdbid dax.QualifiedDatabaseID, tids ...dax.TableID) ([]*dax.QualifiedTable, error) {
+	qdbid = dax.NewQualifiedDatabaseID("org", "db")
+	return []*dax.QualifiedTable{dax.NewQualifiedTable(qdbid, &dax.Table{ID: "tbl", Name: "tbl"})}, nil
+}
+
+func (c *topologyController) ComputeNodes(ctx context.Context, qtid dax.QualifiedTableID, shards ...dax.ShardNum) ([]dax.ComputeNode, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var nodes []dax.ComputeNode
+	for _, addr := range c.addrs {
+		nodes = append(nodes, dax.ComputeNode{Address: addr, Table: qtid.Key()})
+	}
+	return nodes, nil
+}
+
+func (c *topologyController) setAddrs(addrs ...dax.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addrs = addrs
+}
+
+func TestConnPool(t *testing.T) {
+	newComputer := func() (*httptest.Server, dax.Address) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, dax.Address(srv.Listener.Addr().String() + "/" + dax.ServicePrefixComputer)
+	}
+	_, addr0 := newComputer()
+	_, addr1 := newComputer()
+
+	controller := &topologyController{Controller: dax.NewNopController()}
+	controller.setAddrs(addr0, addr1)
+
+	p := newConnPool(PrewarmConfig{MinIdleConns: 3}, logger.NopLogger)
+	defer p.close()
+
+	idle := func(addr dax.Address, n int) func() bool {
+		return func() bool { return p.idleConns(addr.HostPort()) == n }
+	}
+
+	require.NoError(t, p.refresh(context.Background(), controller))
+	for _, addr := range []dax.Address{addr0, addr1} {
+		assert.Eventually(t, idle(addr, 3), time.Second, 10*time.Millisecond, "idle connections to %s", addr)
+		assert.LessOrEqual(t, p.openConns(addr.HostPort()), 3)
+	}
+
+	// A refresh reuses the idle connections.
+	require.NoError(t, p.refresh(context.Background(), controller))
+	assert.Eventually(t, idle(addr0, 3), time.Second, 10*time.Millisecond)
+	assert.LessOrEqual(t, p.openConns(addr0.HostPort()), 3)
+
+	// A computer which leaves the topology is forgotten once its connections
+	// are closed.
+	controller.setAddrs(addr0)
+	require.NoError(t, p.refresh(context.Background(), controller))
+	p.close()
+	assert.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		_, ok := p.hosts[addr1.HostPort()]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, p.idleConns(addr0.HostPort()))
+}
@@ -14,6 +14,7 @@ import (
 	featurebase "github.com/featurebasedb/featurebase/v3"
 	fbcontext "github.com/featurebasedb/featurebase/v3/context"
 	"github.com/featurebasedb/featurebase/v3/dax"
+	controllerclient "github.com/featurebasedb/featurebase/v3/dax/controller/client"
 	"github.com/featurebasedb/featurebase/v3/encoding/proto"
 	"github.com/featurebasedb/featurebase/v3/errors"
 	idkserverless "github.com/featurebasedb/featurebase/v3/idk/serverless"
@@ -21,6 +22,7 @@ import (
 	featurebase_pql "github.com/featurebasedb/featurebase/v3/pql"
 	fbproto "github.com/featurebasedb/featurebase/v3/proto"
 	"github.com/featurebasedb/featurebase/v3/server"
+	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner"
 	plannertypes "github.com/featurebasedb/featurebase/v3/sql3/planner/types"
@@ -28,6 +30,10 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
+// DefaultMaxQueryTextSize is the default maximum size, in bytes, of the text
+// of a query.
+const DefaultMaxQueryTextSize = 16 << 20
+
 // Queryer represents the query layer in a Molecula implementation. The idea is
 // that the externally-facing Molecula API would proxy query requests to a pool
 // of "Queryer" nodes, which handle incoming query requests.
@@ -35,12 +41,92 @@ type Queryer struct {
 	mu            sync.RWMutex
 	orchestrators map[dax.QualifiedDatabaseID]*qualifiedOrchestrator
 
+	// statements holds the named statement registry for each database.
+	statements    map[dax.QualifiedDatabaseID]*statementRegistry
+	maxStatements int
+
+	// maxQueryTextSize is the maximum size of query text; if negative, there
+	// is no limit.
+	maxQueryTextSize int64
+
+	// debugOrgs are the organizations permitted to receive debug output.
+	debugOrgs map[dax.OrganizationID]struct{}
+
+	// decryptOrgs are the organizations permitted to read the values of
+	// encrypted fields, whose keys are cached in fieldKeys.
+	decryptOrgs map[dax.OrganizationID]struct{}
+	fieldKeys   *fieldKeyCache
+
+	// labelPolicy decides which query labels are accepted and how they're
+	// recorded in metrics.
+	labelPolicy LabelPolicy
+
+	// history holds the most recently completed queries. Queries taking at
+	// least slowQueryThreshold are logged; if negative, none are.
+	history            *queryHistory
+	slowQueryThreshold time.Duration
+
+	// importSampleSize is the default number of rows sampled to infer the
+	// schema of an import.
+	importSampleSize int
+
+	// distinct limits the resources used by each DISTINCT.
+	distinct planner.DistinctConfig
+
+	// views deduplicates the refreshes of materialized views. The views of
+	// every database are refreshed by only one queryer at a time: the one
+	// the Controller grants it to, which asks for it as id. refreshViews
+	// refreshes the views of a database which are due at now. While views
+	// are being refreshed, the grant is renewed every viewLeaseRenewal.
+	views            *planner.ViewRefresher
+	id               string
+	refreshViews     func(ctx context.Context, qdbid dax.QualifiedDatabaseID, now time.Time) error
+	viewLeaseRenewal time.Duration
+
+	// outputFormat is the format of query results for clients which don't
+	// ask for one.
+	outputFormat OutputFormat
+
+	// exports holds the object stores to which query results are exported.
+	exports *exportStores
+
+	// encryption configures the data key from which cipher is created when
+	// the Queryer starts, unless SetEncryptionKey has set one already.
+	encryption EncryptionConfig
+	cipher     *fieldCipher
+
 	fbClient *featurebase.InternalClient
 
+	// conns is the transport of fbClient. It tracks, and pre-warms, the
+	// connections to each computer. stopPrewarm stops the pre-warming, and
+	// the checking of Controller replicas, and the refreshing of
+	// materialized views.
+	conns       *connPool
+	stopPrewarm context.CancelFunc
+
+	// breakers holds the circuit breaker for each computer, shared by all of
+	// the orchestrators.
+	breakers *breakers
+
 	controller dax.Controller
 
+	// controllerReplicas are the Controller replicas to which
+	// ConnectController fails over, checked every controllerCheckInterval.
+	controllerReplicas      []dax.Address
+	controllerCheckInterval time.Duration
+
 	systemLayer *systemlayer.SystemLayer
 
+	// draining is set once Drain has been called; after that, no new queries
+	// are accepted. inflight tracks the queries which were started before
+	// draining was set.
+	draining bool
+	inflight sync.WaitGroup
+
+	// readOnly is set while the Queryer rejects writes. It's protected by
+	// mu.
+	readOnly bool
+
 	logger logger.Logger
 }
 
@@ -49,17 +135,103 @@ func New(cfg Config) *Queryer {
 	q := &Queryer{
 		controller:    dax.NewNopController(),
 		orchestrators: make(map[dax.QualifiedDatabaseID]*qualifiedOrchestrator),
+		statements:    make(map[dax.QualifiedDatabaseID]*statementRegistry),
+		maxStatements: cfg.MaxStatements,
 		systemLayer:   systemlayer.NewSystemLayer(),
+		breakers:      newBreakers(cfg.Breaker),
+		views:         planner.NewViewRefresher(),
+		readOnly:      cfg.ReadOnly,
 		logger:        logger.NopLogger,
+
+		viewLeaseRenewal: sql3.ViewRefreshCheckInterval,
+	}
+
+	q.maxQueryTextSize = cfg.MaxQueryTextSize
+	if q.maxQueryTextSize == 0 {
+		q.maxQueryTextSize = DefaultMaxQueryTextSize
+	}
+
+	q.labelPolicy = NewLabelPolicy(cfg.Labels)
+
+	historySize := cfg.QueryHistorySize
+	if historySize == 0 {
+		historySize = DefaultQueryHistorySize
+	} else if historySize < 0 {
+		historySize = 0
+	}
+	q.history = newQueryHistory(historySize)
+
+	q.slowQueryThreshold = cfg.SlowQueryThreshold
+	if q.slowQueryThreshold == 0 {
+		q.slowQueryThreshold = DefaultSlowQueryThreshold
+	}
+
+	q.importSampleSize = cfg.ImportSampleSize
+	if q.importSampleSize <= 0 {
+		q.importSampleSize = DefaultImportSampleSize
+	}
+
+	q.distinct = cfg.Distinct
+
+	q.id = uuid.Must(uuid.NewV4()).String()
+	q.refreshViews = func(ctx context.Context, qdbid dax.QualifiedDatabaseID, now time.Time) error {
+		return q.planner(qdbid).RefreshMaterializedViews(ctx, now)
 	}
 
 	if cfg.Logger != nil {
 		q.logger = cfg.Logger
 	}
 
+	format, err := ParseOutputFormat(string(cfg.DefaultOutputFormat))
+	if err != nil {
+		q.logger.Warnf("%v; using %s", err, DefaultOutputFormat)
+		format = DefaultOutputFormat
+	}
+	q.outputFormat = format
+
+	q.exports = newExportStores(cfg.Export, q.logger)
+
+	q.encryption = cfg.Encryption
+	q.decryptOrgs = make(map[dax.OrganizationID]struct{}, len(cfg.Encryption.DecryptOrganizations))
+	for _, org := range cfg.Encryption.DecryptOrganizations {
+		q.decryptOrgs[dax.OrganizationID(org)] = struct{}{}
+	}
+	q.fieldKeys = newFieldKeyCache()
+
+	for _, addr := range cfg.ControllerAddresses {
+		q.controllerReplicas = append(q.controllerReplicas, dax.Address(addr))
+	}
+	q.controllerCheckInterval = cfg.ControllerCheckInterval
+
+	q.conns = newConnPool(cfg.Prewarm, q.logger)
+
+	q.debugOrgs = make(map[dax.OrganizationID]struct{}, len(cfg.DebugOrganizations))
+	for _, org := range cfg.DebugOrganizations {
+		q.debugOrgs[dax.OrganizationID(org)] = struct{}{}
+	}
+
 	return q
 }
 
+// DebugAllowed returns true if the organization is permitted to receive debug
+// output (which exposes cluster topology) along with its query results.
+func (q *Queryer) DebugAllowed(orgID dax.OrganizationID) bool {
+	_, ok := q.debugOrgs[orgID]
+	return ok
+}
+
+// SetLabelPolicy replaces the policy which decides which query labels are
+// accepted and how they're recorded in metrics.
+func (q *Queryer) SetLabelPolicy(p LabelPolicy) {
+	q.labelPolicy = p
+}
+
+// ComputerBreakers returns the state of the circuit breaker for each computer
+// which the queryer has failed to reach at least once.
+func (q *Queryer) ComputerBreakers() []ComputerBreaker {
+	return q.breakers.snapshot()
+}
+
 func (q *Queryer) Logger() logger.Logger {
 	return q.logger
 }
@@ -91,11 +263,12 @@ func (q *Queryer) Orchestrator(qdbid dax.QualifiedDatabaseID) *qualifiedOrchestr
 
 	orch := &orchestrator{
 		schema:   sapi,
-		trans:    NewServerlessTranslator(q.controller),
+		trans:    &encryptingTranslator{Translator: NewServerlessTranslator(q.controller), fields: q.encryptedFields()},
 		topology: &ServerlessTopology{controller: q.controller},
 		// TODO(jaffee) using default http.Client probably bad... need to set some timeouts.
-		client: q.fbClient,
-		logger: q.logger,
+		client:   q.fbClient,
+		breakers: q.breakers,
+		logger:   q.logger,
 	}
 
 	qorch := newQualifiedOrchestrator(orch, qdbid)
@@ -109,6 +282,32 @@ func (q *Queryer) SetController(controller dax.Controller) error {
 	return nil
 }
 
+// ConnectController sets the Queryer's Controller to a client of the
+// Controller at addr. If the Queryer is configured with ControllerAddresses,
+// the client fails over among addr and them.
+func (q *Queryer) ConnectController(addr dax.Address) {
+	addrs := []dax.Address{addr}
+	for _, replica := range q.controllerReplicas {
+		if replica != addr {
+			addrs = append(addrs, replica)
+		}
+	}
+	if len(addrs) == 1 {
+		q.controller = controllerclient.New(addr, q.logger)
+		return
+	}
+	q.controller = controllerclient.NewFailover(addrs, q.controllerCheckInterval, q.logger)
+}
+
+// ControllerEndpoints returns the state of each of the Controller replicas
+// among which the Queryer fails over, or nil if it uses a single Controller.
+func (q *Queryer) ControllerEndpoints() []dax.ControllerEndpoint {
+	if f, ok := q.controller.(*controllerclient.Failover); ok {
+		return f.Endpoints()
+	}
+	return nil
+}
+
 func (q *Queryer) Start() error {
 	if q.controller == nil {
 		return errors.New(errors.ErrUncoded, "queryer requires controller to be configured")
@@ -119,7 +318,7 @@ func (q *Queryer) Start() error {
 	// replaces with the actual host (another computer node) to connect to.
 	// That's why we set it up with a dummy host here.
 	fbClient, err := featurebase.NewInternalClient("fakehostname:8080",
-		&http.Client{},
+		&http.Client{Transport: dax.ServiceTransport(q.conns)},
 		featurebase.WithSerializer(proto.Serializer{}),
 		featurebase.WithPathPrefix("should-not-be-used"),
 	)
@@ -128,12 +327,190 @@ func (q *Queryer) Start() error {
 	}
 	q.fbClient = fbClient
 
+	if q.cipher == nil && q.encryption.DataKey != "" {
+		c, err := newKMSFieldCipher(context.Background(), q.encryption)
+		if err != nil {
+			return errors.Wrap(err, "setting up field encryption")
+		}
+		q.cipher = c
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.stopPrewarm = cancel
+	go q.conns.run(ctx, q.controller)
+	if f, ok := q.controller.(*controllerclient.Failover); ok {
+		go f.Run(ctx)
+	}
+	go q.refreshMaterializedViews(ctx, sql3.ViewRefreshCheckInterval)
+
+	return nil
+}
+
+// viewRefreshController is implemented by the Controller clients which grant
+// the refreshing of materialized views to one queryer at a time.
+type viewRefreshController interface {
+	ViewRefreshDatabases(ctx context.Context, holder string) ([]*dax.QualifiedDatabase, error)
+}
+
+// refreshMaterializedViews refreshes, every interval until ctx is done, the
+// materialized views which are due to be refreshed, in the databases the
+// Controller has the Queryer refresh.
+func (q *Queryer) refreshMaterializedViews(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		q.refreshDueViews(ctx, time.Now().UTC())
+	}
+}
+
+// refreshDueViews refreshes the materialized views due to be refreshed at
+// now, if the Controller has the Queryer refresh them. Errors are logged.
+//
+// A refresh can take longer than the Controller's grant lasts, so the grant
+// is renewed while views are being refreshed. If it can't be, the refreshing
+// is cancelled, since another queryer may take it over once the grant
+// expires, and two refreshes of the same view would race to replace its
+// result.
+func (q *Queryer) refreshDueViews(ctx context.Context, now time.Time) {
+	vrc, ok := q.controller.(viewRefreshController)
+	if !ok {
+		return
+	}
+	qdbs, err := vrc.ViewRefreshDatabases(ctx, q.id)
+	if err != nil {
+		q.logger.Printf("refreshing materialized views: getting databases: %v", err)
+		return
+	}
+	if len(qdbs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go q.renewViewRefresh(ctx, vrc, cancel)
+
+	for _, qdb := range qdbs {
+		qdbid := qdb.QualifiedID()
+		if err := q.refreshViews(ctx, qdbid, now); err != nil {
+			q.logger.Printf("refreshing materialized views of database %s: %v", qdbid, err)
+		}
+	}
+}
+
+// renewViewRefresh renews, every q.viewLeaseRenewal until ctx is done, the
+// Controller's grant of the refreshing of materialized views to the Queryer.
+// If the grant can't be renewed, or has been given to another queryer, it
+// calls cancel.
+func (q *Queryer) renewViewRefresh(ctx context.Context, vrc viewRefreshController, cancel context.CancelFunc) {
+	ticker := time.NewTicker(q.viewLeaseRenewal)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// Any databases at all mean the grant is still the Queryer's.
+		qdbs, err := vrc.ViewRefreshDatabases(ctx, q.id)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			q.logger.Printf("refreshing materialized views: renewing grant, cancelling: %v", err)
+			cancel()
+			return
+		}
+		if len(qdbs) == 0 {
+			q.logger.Printf("refreshing materialized views: grant was given to another queryer, cancelling")
+			cancel()
+			return
+		}
+	}
+}
+
+// Stop stops pre-warming connections to the computers, and closes the idle
+// ones.
+func (q *Queryer) Stop() {
+	if q.stopPrewarm != nil {
+		q.stopPrewarm()
+	}
+	q.conns.close()
+}
+
+// Drain stops the Queryer from accepting new queries and waits for any
+// in-flight queries to complete.
+func (q *Queryer) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	q.draining = true
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for in-flight queries")
+	case <-done:
+		return nil
+	}
+}
+
+// beginQuery registers an in-flight query. It returns an error if the Queryer
+// is draining. Every successful call must be followed by a call to
+// q.inflight.Done().
+func (q *Queryer) beginQuery() error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.draining {
+		return dax.NewErrDraining(dax.ServicePrefixQueryer)
+	}
+	q.inflight.Add(1)
 	return nil
 }
 
 func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, sql io.Reader) (*featurebase.WireQueryResponse, error) {
+	if err := q.beginQuery(); err != nil {
+		return nil, err
+	}
+	defer q.inflight.Done()
+
 	start := time.Now()
 
+	// Read the query text up front, so that oversized queries are rejected
+	// before any of them reaches the parser.
+	text, err := q.readQueryText(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := dax.QueryLabelsFromContext(ctx)
+	if err := q.labelPolicy.Validate(labels); err != nil {
+		return nil, err
+	}
+	dax.QueryDebugFromContext(ctx).SetLabels(labels)
+
+	ret, err := q.querySQL(ctx, qdbid, bytes.NewReader(text), start)
+	applyApproximations(ctx, ret)
+	q.recordQuery(QueryRecord{
+		Database: qdbid,
+		SQL:      string(text),
+		Labels:   labels,
+		Start:    start,
+		Duration: time.Since(start),
+	}, ret, err)
+	return ret, err
+}
+
+// querySQL does the work of QuerySQL once the query text has been read.
+func (q *Queryer) querySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, sql io.Reader, start time.Time) (*featurebase.WireQueryResponse, error) {
 	ret := &featurebase.WireQueryResponse{}
 
 	applyExecutionTime := func() {
@@ -142,6 +519,7 @@ func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, s
 
 	applyError := func(e error) {
 		ret.Error = e.Error()
+		ret.ErrorCode = featurebase.WireErrorCode(e)
 		applyExecutionTime()
 	}
 
@@ -169,7 +547,9 @@ func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, s
 			applyError(errors.Wrap(err, "reading pql"))
 			return ret, nil
 		}
-		if pqlResp, err := q.parseAndQueryPQL(ctx, qdbid, string(pql)); err != nil {
+		if pqlResp, err := q.parseAndQueryPQL(ctx, qdbid, string(pql)); errors.Is(err, dax.ErrReadOnly) {
+			return nil, err
+		} else if err != nil {
 			applyError(errors.Wrap(err, "querying pql"))
 			return ret, nil
 		} else {
@@ -180,26 +560,79 @@ func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, s
 		return ret, nil
 	}
 
-	// Create a requestID and add it to the context.
-	requestID, err := uuid.NewV4()
+	parseStart := time.Now()
+	st, err := parser.NewParser(multiReader).ParseStatement()
 	if err != nil {
-		applyError(errors.Wrap(err, "creating requestID"))
+		applyError(errors.Wrap(err, "parsing sql"))
 		return ret, nil
 	}
-	// put the requestId in the context
-	ctx = fbcontext.WithRequestID(ctx, requestID.String())
+	dax.ObserveQueryStage(ctx, dax.QueryStageParse, time.Since(parseStart))
+	if err := q.checkReadOnly(st); err != nil {
+		return nil, err
+	}
 
-	st, err := parser.NewParser(multiReader).ParseStatement()
-	if err != nil {
-		applyError(errors.Wrap(err, "parsing sql"))
+	page, paged := pageFromContext(ctx)
+	var limit int64
+	if paged {
+		if limit, err = page.widen(st); err != nil {
+			applyError(err)
+			return ret, nil
+		}
+	}
+
+	if resp, err := q.queryStatement(ctx, qdbid, st); err != nil {
+		applyError(err)
 		return ret, nil
+	} else {
+		ret = resp
+	}
+	if paged {
+		page.trim(ret, limit)
 	}
+	applyExecutionTime()
 
+	return ret, nil
+}
+
+// readQueryText reads the text of a query from r, returning an
+// ErrQueryTooLarge error if it exceeds the maximum query text size. No more
+// than one byte beyond the maximum is read from r.
+func (q *Queryer) readQueryText(r io.Reader) ([]byte, error) {
+	if q.maxQueryTextSize < 0 {
+		text, err := io.ReadAll(r)
+		return text, errors.Wrap(err, "reading query")
+	}
+	text, err := io.ReadAll(io.LimitReader(r, q.maxQueryTextSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading query")
+	}
+	if err := q.checkQueryTextSize(len(text)); err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+// checkQueryTextSize returns an ErrQueryTooLarge error, and records the
+// rejection, if size exceeds the maximum query text size.
+func (q *Queryer) checkQueryTextSize(size int) error {
+	if q.maxQueryTextSize < 0 || int64(size) <= q.maxQueryTextSize {
+		return nil
+	}
+	dax.CounterQueryTooLargeRejections.Inc()
+	return dax.NewErrQueryTooLarge(q.maxQueryTextSize)
+}
+
+// planner returns a planner of statements against the given database.
+func (q *Queryer) planner(qdbid dax.QualifiedDatabaseID) *planner.ExecutionPlanner {
 	// SchemaAPI
 	sapi := newQualifiedSchemaAPI(qdbid, q.controller)
 
 	// Importer
-	imp := idkserverless.NewImporter(q.controller, qdbid, nil)
+	imp := &encryptingImporter{
+		Importer: idkserverless.NewImporter(q.controller, qdbid, nil),
+		qdbid:    qdbid,
+		fields:   q.encryptedFields(),
+	}
 
 	// SystemAPI.
 	sysapi := newSystemAPI(q.controller, qdbid)
@@ -209,18 +642,79 @@ func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, s
 	// send it as a string to this method. Also, what happens if the sql is a
 	// large BULK INSERT?
 	pl := planner.NewExecutionPlanner(q.Orchestrator(qdbid), sapi, sysapi, q.systemLayer, imp, q.logger, "")
+	pl.SetDistinctConfig(q.distinct)
+	pl.SetViewRefresher(q.views, string(qdbid.OrganizationID)+"/"+string(qdbid.DatabaseID))
+	return pl
+}
+
+// queryStatement compiles and executes an already-parsed sql statement
+// against the given database.
+func (q *Queryer) queryStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, st parser.Statement) (*featurebase.WireQueryResponse, error) {
+	ctx, err := queryContext(ctx, qdbid)
+	if err != nil {
+		return nil, err
+	}
+	planOp, err := q.planStatement(ctx, qdbid, st)
+	if err != nil {
+		return nil, err
+	}
+	return q.executePlan(ctx, planOp)
+}
+
+// queryContext returns ctx with what planning and executing a statement
+// against qdbid need added.
+func queryContext(ctx context.Context, qdbid dax.QualifiedDatabaseID) (context.Context, error) {
+	// Create a requestID and add it to the context.
+	requestID, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating requestID")
+	}
+	// put the requestId in the context
+	ctx = fbcontext.WithRequestID(ctx, requestID.String())
+	ctx = withCaller(ctx, qdbid.OrganizationID)
+	return withQueryTables(ctx), nil
+}
+
+// planStatement compiles an already-parsed sql statement against the given
+// database. ctx is as returned by queryContext.
+func (q *Queryer) planStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, st parser.Statement) (plannertypes.PlanOperator, error) {
+	pl := q.planner(qdbid)
 
+	planStart := time.Now()
 	planOp, err := pl.CompilePlan(ctx, st)
 	if err != nil {
-		applyError(errors.Wrap(err, "compiling plan"))
-		return ret, nil
+		return nil, errors.Wrap(err, "compiling plan")
 	}
+	dax.ObserveQueryStage(ctx, dax.QueryStagePlan, time.Since(planStart))
+	return planOp, nil
+}
+
+// analyzeStatement does the analysis, in place, with which planStatement
+// begins. Unlike planStatement, it doesn't observe the plan stage. ctx is as
+// returned by queryContext.
+func (q *Queryer) analyzeStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, st parser.Statement) error {
+	return errors.Wrap(q.planner(qdbid).AnalyzePlan(ctx, st), "compiling plan")
+}
+
+// compileAnalyzedStatement compiles a statement analyzed by
+// analyzeStatement. Unlike planStatement, it doesn't observe the plan stage.
+// ctx is as returned by queryContext.
+func (q *Queryer) compileAnalyzedStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, st parser.Statement) (plannertypes.PlanOperator, error) {
+	planOp, err := q.planner(qdbid).CompileAnalyzedPlan(ctx, st)
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling plan")
+	}
+	return planOp, nil
+}
+
+// executePlan executes a compiled plan. ctx is as returned by queryContext.
+func (q *Queryer) executePlan(ctx context.Context, planOp plannertypes.PlanOperator) (*featurebase.WireQueryResponse, error) {
+	execStart := time.Now()
 
 	// Get a query iterator.
 	iter, err := planOp.Iterator(ctx, nil)
 	if err != nil {
-		applyError(errors.Wrap(err, "getting iterator"))
-		return ret, nil
+		return nil, errors.Wrap(err, "getting iterator")
 	}
 
 	// Read schema.
@@ -231,8 +725,7 @@ func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, s
 	for i, col := range columns {
 		btype, err := dax.BaseTypeFromString(col.Type.BaseTypeName())
 		if err != nil {
-			applyError(errors.Wrap(err, "getting fieldtype from string"))
-			return ret, nil
+			return nil, errors.Wrap(err, "getting fieldtype from string")
 		}
 		schema.Fields[i] = &featurebase.WireQueryField{
 			Name:     dax.FieldName(col.ColumnName),
@@ -249,15 +742,14 @@ func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, s
 		data = append(data, currentRow)
 	}
 	if err != nil && err != plannertypes.ErrNoMoreRows {
-		applyError(errors.Wrap(err, "getting row"))
-		return ret, nil
+		return nil, errors.Wrap(err, "getting row")
 	}
+	dax.ObserveQueryStage(ctx, dax.QueryStageExecute, time.Since(execStart))
 
-	ret.Schema = schema
-	ret.Data = data
-	applyExecutionTime()
-
-	return ret, nil
+	return &featurebase.WireQueryResponse{
+		Schema: schema,
+		Data:   data,
+	}, nil
 }
 
 func (q *Queryer) parseAndQueryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, sql string) (*featurebase.WireQueryResponse, error) {
@@ -296,6 +788,11 @@ func (q *Queryer) convertIndex(ctx context.Context, qdbid dax.QualifiedDatabaseI
 }
 
 func (q *Queryer) QueryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, table dax.TableName, pql string) (*featurebase.WireQueryResponse, error) {
+	if err := q.beginQuery(); err != nil {
+		return nil, err
+	}
+	defer q.inflight.Done()
+
 	start := time.Now()
 
 	ret := &featurebase.WireQueryResponse{}
@@ -306,6 +803,7 @@ func (q *Queryer) QueryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, t
 
 	applyError := func(e error) {
 		ret.Error = e.Error()
+		ret.ErrorCode = featurebase.WireErrorCode(e)
 		applyExecutionTime()
 	}
 
@@ -316,12 +814,16 @@ func (q *Queryer) QueryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, t
 		ret = pqlResp
 	}
 
+	applyApproximations(ctx, ret)
 	applyExecutionTime()
 
 	return ret, nil
 }
 
 func (q *Queryer) queryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, table dax.TableName, pql string) (*featurebase.WireQueryResponse, error) {
+	ctx = withCaller(ctx, qdbid.OrganizationID)
+	ctx = withQueryTables(ctx)
+
 	// Parse the pql into a pql.Query containing []pql.Call.
 	qry, err := featurebase_pql.NewParser(strings.NewReader(pql)).Parse()
 	if err != nil {
@@ -330,6 +832,9 @@ func (q *Queryer) queryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, t
 	if len(qry.Calls) != 1 {
 		return nil, errors.Errorf("must have exactly 1 query, but got: %+v", qry.Calls)
 	}
+	if q.ReadOnly() && (qry.WriteCallN() > 0 || qry.HasCall("Delete")) {
+		return nil, dax.NewErrReadOnly(dax.ServicePrefixQueryer)
+	}
 
 	// Replace any "index" arguments within the PQL with a TableKey.
 	q.convertIndex(ctx, qdbid, qry.Calls[0])
@@ -339,10 +844,12 @@ func (q *Queryer) queryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, t
 		return nil, errors.Wrap(err, "converting index to qualified table")
 	}
 
+	execStart := time.Now()
 	results, err := q.Orchestrator(qdbid).Execute(ctx, qtbl, qry, nil, &featurebase.ExecOptions{})
 	if err != nil {
 		return nil, errors.Wrap(err, "orchestrator.Execute")
 	}
+	dax.ObserveQueryStage(ctx, dax.QueryStageExecute, time.Since(execStart))
 	if len(results.Results) != 1 {
 		return nil, errors.Errorf("expected single result but got %+v", results.Results)
 	}
@@ -0,0 +1,151 @@
+package queryer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTextSize(t *testing.T) {
+	ctx := context.Background()
+	qdbid := dax.NewQualifiedDatabaseID("org", "db")
+
+	t.Run("Limited", func(t *testing.T) {
+		q := New(Config{MaxQueryTextSize: 16})
+
+		text, err := q.readQueryText(strings.NewReader("SELECT 1"))
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT 1", string(text))
+
+		_, err = q.QuerySQL(ctx, qdbid, strings.NewReader("SELECT _id FROM tbl WHERE a IN (1, 2, 3)"))
+		assert.True(t, errors.Is(err, dax.ErrQueryTooLarge), "expected query too large, got %v", err)
+
+		_, err = q.RegisterStatement(ctx, qdbid, "stmt", "SELECT _id FROM tbl WHERE a = @a")
+		assert.True(t, errors.Is(err, dax.ErrQueryTooLarge), "expected query too large, got %v", err)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		q := New(Config{MaxQueryTextSize: -1})
+		sql := strings.Repeat(" ", DefaultMaxQueryTextSize+1)
+		text, err := q.readQueryText(strings.NewReader(sql))
+		require.NoError(t, err)
+		assert.Len(t, text, len(sql))
+	})
+}
+
+// viewController is a Controller holding databases in several organizations.
+// Like the Controller, it requires an organization to list databases; the
+// databases of every organization are given only to the first queryer to ask
+// for the views to refresh.
+type viewController struct {
+	dax.Controller
+	qdbs []*dax.QualifiedDatabase
+
+	mu     sync.Mutex
+	holder string
+	asked  int
+}
+
+func (c *viewController) Databases(ctx context.Context, orgID dax.OrganizationID, ids ...dax.DatabaseID) ([]*dax.QualifiedDatabase, error) {
+	if orgID == "" {
+		return nil, dax.NewErrOrganizationIDDoesNotExist(orgID)
+	}
+	var out []*dax.QualifiedDatabase
+	for _, qdb := range c.qdbs {
+		if qdb.OrganizationID == orgID {
+			out = append(out, qdb)
+		}
+	}
+	return out, nil
+}
+
+func (c *viewController) ViewRefreshDatabases(ctx context.Context, holder string) ([]*dax.QualifiedDatabase, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.asked++
+	if c.holder == "" {
+		c.holder = holder
+	}
+	if c.holder != holder {
+		return nil, nil
+	}
+	return c.qdbs, nil
+}
+
+func TestRefreshDueViews(t *testing.T) {
+	ctrl := &viewController{
+		Controller: dax.NewNopController(),
+		qdbs: []*dax.QualifiedDatabase{
+			{OrganizationID: "org1", Database: dax.Database{ID: "db1"}},
+			{OrganizationID: "org2", Database: dax.Database{ID: "db2"}},
+		},
+	}
+
+	var refreshed []dax.QualifiedDatabaseID
+	newQueryer := func() *Queryer {
+		q := New(Config{})
+		require.NoError(t, q.SetController(ctrl))
+		q.refreshViews = func(ctx context.Context, qdbid dax.QualifiedDatabaseID, now time.Time) error {
+			refreshed = append(refreshed, qdbid)
+			return nil
+		}
+		return q
+	}
+	q1, q2 := newQueryer(), newQueryer()
+
+	// The views of every organization are refreshed, by one queryer.
+	now := time.Now().UTC()
+	q1.refreshDueViews(context.Background(), now)
+	q2.refreshDueViews(context.Background(), now)
+	assert.Equal(t, []dax.QualifiedDatabaseID{
+		dax.NewQualifiedDatabaseID("org1", "db1"),
+		dax.NewQualifiedDatabaseID("org2", "db2"),
+	}, refreshed)
+
+	// The grant is renewed while views are being refreshed, and the refresh
+	// is cancelled once it's given to another queryer.
+	t.Run("Renewal", func(t *testing.T) {
+		ctrl := &viewController{
+			Controller: dax.NewNopController(),
+			qdbs:       ctrl.qdbs[:1],
+		}
+		q := New(Config{})
+		require.NoError(t, q.SetController(ctrl))
+		q.viewLeaseRenewal = time.Millisecond
+		q.refreshViews = func(ctx context.Context, qdbid dax.QualifiedDatabaseID, now time.Time) error {
+			for {
+				ctrl.mu.Lock()
+				if ctrl.asked >= 3 {
+					ctrl.holder = "other"
+				}
+				ctrl.mu.Unlock()
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			q.refreshDueViews(context.Background(), time.Now().UTC())
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("refresh wasn't cancelled")
+		}
+		ctrl.mu.Lock()
+		defer ctrl.mu.Unlock()
+		assert.GreaterOrEqual(t, ctrl.asked, 3)
+	})
+}
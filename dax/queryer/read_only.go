@@ -0,0 +1,34 @@
+package queryer
+
+import (
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+)
+
+// SetReadOnly puts the Queryer into, or takes it out of, read-only mode. While
+// read-only, the Queryer rejects every SQL statement other than queries, SHOW,
+// and EXPLAIN (see parser.IsReadOnly), and every PQL query containing a write
+// call, with an ErrReadOnly error. It returns true if the mode changed.
+func (q *Queryer) SetReadOnly(readOnly bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	changed := q.readOnly != readOnly
+	q.readOnly = readOnly
+	return changed
+}
+
+// ReadOnly returns true if the Queryer is in read-only mode.
+func (q *Queryer) ReadOnly() bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.readOnly
+}
+
+// checkReadOnly returns an ErrReadOnly error if the Queryer is read-only and
+// st is a write.
+func (q *Queryer) checkReadOnly(st parser.Statement) error {
+	if q.ReadOnly() && !parser.IsReadOnly(st) {
+		return dax.NewErrReadOnly(dax.ServicePrefixQueryer)
+	}
+	return nil
+}
@@ -1,10 +1,10 @@
 package service
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
-	controllerclient "github.com/featurebasedb/featurebase/v3/dax/controller/client"
 	"github.com/featurebasedb/featurebase/v3/dax/queryer"
 	queryerhttp "github.com/featurebasedb/featurebase/v3/dax/queryer/http"
 	"github.com/featurebasedb/featurebase/v3/errors"
@@ -14,6 +14,7 @@ import (
 
 // Ensure type implements interface.
 var _ dax.Service = (*queryerService)(nil)
+var _ dax.Drainer = (*queryerService)(nil)
 
 type queryerService struct {
 	uri     *fbnet.URI
@@ -38,9 +39,14 @@ func (q *queryerService) Start() error {
 }
 
 func (q *queryerService) Stop() error {
+	q.queryer.Stop()
 	return nil
 }
 
+func (q *queryerService) Drain(ctx context.Context) error {
+	return q.queryer.Drain(ctx)
+}
+
 func (q *queryerService) Address() dax.Address {
 	return dax.Address(q.uri.HostPort() + "/" + dax.ServicePrefixQueryer)
 }
@@ -49,8 +55,12 @@ func (q *queryerService) HTTPHandler() http.Handler {
 	return queryerhttp.Handler(q.queryer)
 }
 
+// OpenAPIAnnotations describes the queryer's routes.
+func (q *queryerService) OpenAPIAnnotations() map[string]dax.OpenAPIAnnotation {
+	return queryerhttp.OpenAPIAnnotations()
+}
+
 func (q *queryerService) SetController(addr dax.Address) error {
-	controllercli := controllerclient.New(addr, q.logger)
-	q.queryer.SetController(controllercli)
+	q.queryer.ConnectController(addr)
 	return nil
 }
@@ -0,0 +1,487 @@
+package queryer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+)
+
+// DefaultMaxStatements is the default number of named statements which can be
+// registered per database.
+const DefaultMaxStatements = 256
+
+// Statement is a named sql statement registered with the Queryer. Parameters
+// are referenced in the sql with the `@name` syntax and are bound to values
+// when the statement is invoked.
+//
+// A statement is parsed once, at registration, and its analyzed ASTs are
+// cached. A statement without parameters is analyzed at registration; one
+// with parameters is analyzed on the first invocation with each set of
+// values, since the values are part of the analysis. Each invocation compiles
+// its own plan from a copy of the analyzed AST, since a plan can't be
+// executed by more than one query at once. The analyzed ASTs are reused until
+// the schema of a referenced table changes, at which point the statement is
+// analyzed again. If it no longer plans against the new schema, it's
+// invalidated, and removed from the registry.
+//
+// The statement registry is scoped to a QualifiedDatabaseID; any caller with
+// access to the database can register, list, invoke, and deregister its
+// statements. Registries are held in memory by each Queryer and are not shared
+// between Queryer processes.
+type Statement struct {
+	Name       string          `json:"name"`
+	SQL        string          `json:"sql"`
+	Parameters []string        `json:"parameters"`
+	Tables     []dax.TableName `json:"tables"`
+	CreatedAt  time.Time       `json:"created-at"`
+
+	// ast is the statement as parsed at registration. The planner annotates
+	// the AST it analyzes, so each analysis is of a copy.
+	ast parser.Statement
+
+	// mu protects schemas and plans. schemas holds a fingerprint of the
+	// schema of each referenced table as of when plans were analyzed. plans
+	// holds at most maxStatementPlans analyzed ASTs, keyed by the statement
+	// with its parameters bound. They're only read once they're cached.
+	mu      sync.Mutex
+	schemas map[dax.TableName]string
+	plans   map[string]parser.Statement
+}
+
+// maxStatementPlans is the number of analyzed ASTs cached for each
+// statement, for different parameter values. Once a statement has that many,
+// an arbitrary one is replaced by each new one.
+const maxStatementPlans = 16
+
+// plan returns the cached analyzed AST for key, analyzed against the
+// schemas, or nil if there isn't one. If schemas differ from those the cached
+// ASTs were analyzed against, they're discarded, and changed is the first
+// table whose schema differs.
+func (stmt *Statement) plan(key string, schemas map[dax.TableName]string) (st parser.Statement, changed dax.TableName) {
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
+	for _, tname := range stmt.Tables {
+		if schemas[tname] != stmt.schemas[tname] {
+			stmt.schemas = schemas
+			stmt.plans = make(map[string]parser.Statement)
+			return nil, tname
+		}
+	}
+	return stmt.plans[key], ""
+}
+
+// addPlan caches st as the analyzed AST for key, if it was analyzed against
+// the same schemas as the cached ASTs.
+func (stmt *Statement) addPlan(key string, schemas map[dax.TableName]string, st parser.Statement) {
+	stmt.mu.Lock()
+	defer stmt.mu.Unlock()
+	for _, tname := range stmt.Tables {
+		if schemas[tname] != stmt.schemas[tname] {
+			return
+		}
+	}
+	if _, ok := stmt.plans[key]; !ok && len(stmt.plans) >= maxStatementPlans {
+		for k := range stmt.plans {
+			delete(stmt.plans, k)
+			break
+		}
+	}
+	stmt.plans[key] = st
+}
+
+// statementRegistry holds the named statements for a single database.
+type statementRegistry struct {
+	mu         sync.RWMutex
+	max        int
+	statements map[string]*Statement
+}
+
+func newStatementRegistry(max int) *statementRegistry {
+	if max <= 0 {
+		max = DefaultMaxStatements
+	}
+	return &statementRegistry{
+		max:        max,
+		statements: make(map[string]*Statement),
+	}
+}
+
+// lookupStatementRegistry returns the statementRegistry for qdbid, or nil if
+// no statement has been registered for it.
+func (q *Queryer) lookupStatementRegistry(qdbid dax.QualifiedDatabaseID) *statementRegistry {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.statements[qdbid]
+}
+
+// statementRegistry gets (or creates) the statementRegistry for qdbid.
+func (q *Queryer) statementRegistry(qdbid dax.QualifiedDatabaseID) *statementRegistry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if reg, ok := q.statements[qdbid]; ok {
+		return reg
+	}
+	reg := newStatementRegistry(q.maxStatements)
+	q.statements[qdbid] = reg
+	return reg
+}
+
+// RegisterStatement parses sql and registers it under name. The statement's
+// parameters and referenced tables are determined at registration, and the
+// schema of each referenced table is recorded so that a later schema change
+// has the statement analyzed again. A statement without parameters is planned
+// at registration, so a statement which doesn't plan isn't registered.
+func (q *Queryer) RegisterStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, name string, sql string) (*Statement, error) {
+	if name == "" {
+		return nil, errors.New(errors.ErrUncoded, "statement name is required")
+	}
+	if err := q.checkQueryTextSize(len(sql)); err != nil {
+		return nil, err
+	}
+
+	st, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing sql")
+	}
+
+	params, tables, err := inspectStatement(st)
+	if err != nil {
+		return nil, errors.Wrap(err, "inspecting statement")
+	}
+
+	schemas := make(map[dax.TableName]string, len(tables))
+	for _, tname := range tables {
+		fp, err := q.tableFingerprint(ctx, qdbid, tname)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting schema for table: %s", tname)
+		}
+		schemas[tname] = fp
+	}
+
+	stmt := &Statement{
+		Name:       name,
+		SQL:        sql,
+		Parameters: params,
+		Tables:     tables,
+		CreatedAt:  time.Now().UTC(),
+		ast:        st,
+		schemas:    schemas,
+		plans:      make(map[string]parser.Statement),
+	}
+
+	if len(params) == 0 {
+		bound := parser.CloneStatement(st)
+		qctx, err := queryContext(ctx, qdbid)
+		if err != nil {
+			return nil, err
+		}
+		if err := q.analyzeStatement(qctx, qdbid, bound); err != nil {
+			return nil, errors.Wrap(err, "planning statement")
+		}
+		if _, err := q.compileAnalyzedStatement(qctx, qdbid, parser.CloneStatement(bound)); err != nil {
+			return nil, errors.Wrap(err, "planning statement")
+		}
+		stmt.addPlan(bound.String(), schemas, bound)
+	}
+
+	reg := q.statementRegistry(qdbid)
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, ok := reg.statements[name]; ok {
+		return nil, dax.NewErrStatementExists(name)
+	}
+	if len(reg.statements) >= reg.max {
+		return nil, dax.NewErrStatementRegistryFull(reg.max)
+	}
+	reg.statements[name] = stmt
+
+	return stmt, nil
+}
+
+// DeregisterStatement removes the named statement from the registry.
+func (q *Queryer) DeregisterStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, name string) error {
+	reg := q.lookupStatementRegistry(qdbid)
+	if reg == nil {
+		return dax.NewErrStatementDoesNotExist(name)
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, ok := reg.statements[name]; !ok {
+		return dax.NewErrStatementDoesNotExist(name)
+	}
+	delete(reg.statements, name)
+
+	return nil
+}
+
+// Statements returns the statements registered for qdbid, sorted by name.
+func (q *Queryer) Statements(ctx context.Context, qdbid dax.QualifiedDatabaseID) []*Statement {
+	reg := q.lookupStatementRegistry(qdbid)
+	if reg == nil {
+		return []*Statement{}
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	stmts := make([]*Statement, 0, len(reg.statements))
+	for _, stmt := range reg.statements {
+		stmts = append(stmts, stmt)
+	}
+	sort.Slice(stmts, func(i, j int) bool { return stmts[i].Name < stmts[j].Name })
+
+	return stmts
+}
+
+// InvokeStatement binds params to the named statement and executes it, with
+// its cached analysis for params if it has one. If the schema of any table
+// referenced by the statement has changed since it was planned, and it no
+// longer plans against the new schema (or the table is gone), the statement is
+// removed from the registry and an ErrStatementInvalidated error is returned.
+//
+// As with QuerySQL, errors encountered while executing the statement are
+// returned in the Error field of the response.
+func (q *Queryer) InvokeStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, name string, params map[string]interface{}) (*featurebase.WireQueryResponse, error) {
+	if err := q.beginQuery(); err != nil {
+		return nil, err
+	}
+	defer q.inflight.Done()
+
+	start := time.Now()
+
+	labels := dax.QueryLabelsFromContext(ctx)
+	if err := q.labelPolicy.Validate(labels); err != nil {
+		return nil, err
+	}
+	dax.QueryDebugFromContext(ctx).SetLabels(labels)
+
+	ret, err := q.invokeStatement(ctx, qdbid, name, params, start)
+	q.recordQuery(QueryRecord{
+		Database:  qdbid,
+		Statement: name,
+		Labels:    labels,
+		Start:     start,
+		Duration:  time.Since(start),
+	}, ret, err)
+	return ret, err
+}
+
+// invokeStatement does the work of InvokeStatement.
+func (q *Queryer) invokeStatement(ctx context.Context, qdbid dax.QualifiedDatabaseID, name string, params map[string]interface{}, start time.Time) (*featurebase.WireQueryResponse, error) {
+	reg := q.lookupStatementRegistry(qdbid)
+	if reg == nil {
+		return nil, dax.NewErrStatementDoesNotExist(name)
+	}
+	reg.mu.RLock()
+	stmt, ok := reg.statements[name]
+	reg.mu.RUnlock()
+	if !ok {
+		return nil, dax.NewErrStatementDoesNotExist(name)
+	}
+
+	invalidate := func(tname dax.TableName) error {
+		reg.mu.Lock()
+		if reg.statements[name] == stmt {
+			delete(reg.statements, name)
+		}
+		reg.mu.Unlock()
+		return dax.NewErrStatementInvalidated(name, tname)
+	}
+
+	schemas := make(map[dax.TableName]string, len(stmt.Tables))
+	for _, tname := range stmt.Tables {
+		fp, err := q.tableFingerprint(ctx, qdbid, tname)
+		if err != nil {
+			return nil, invalidate(tname)
+		}
+		schemas[tname] = fp
+	}
+
+	ret := &featurebase.WireQueryResponse{}
+
+	applyError := func(e error) {
+		ret.Error = e.Error()
+		ret.ExecutionTime = time.Since(start).Microseconds()
+	}
+
+	bindStart := time.Now()
+	st := parser.CloneStatement(stmt.ast)
+	if err := bindParameters(st, params); err != nil {
+		return nil, err
+	}
+	if err := q.checkReadOnly(st); err != nil {
+		return nil, err
+	}
+	dax.ObserveQueryStage(ctx, dax.QueryStageParse, time.Since(bindStart))
+
+	ctx, err := queryContext(ctx, qdbid)
+	if err != nil {
+		return nil, err
+	}
+	planStart := time.Now()
+	key := st.String()
+	analyzed, changed := stmt.plan(key, schemas)
+	if analyzed == nil {
+		if err := q.analyzeStatement(ctx, qdbid, st); err != nil {
+			if changed != "" {
+				return nil, invalidate(changed)
+			}
+			applyError(err)
+			return ret, nil
+		}
+		stmt.addPlan(key, schemas, st)
+		analyzed = st
+	}
+
+	// The cached AST is shared by concurrent invocations, so each compiles
+	// its own plan from a copy.
+	op, err := q.compileAnalyzedStatement(ctx, qdbid, parser.CloneStatement(analyzed))
+	if err != nil {
+		applyError(err)
+		return ret, nil
+	}
+	dax.ObserveQueryStage(ctx, dax.QueryStagePlan, time.Since(planStart))
+
+	if resp, err := q.executePlan(ctx, op); err != nil {
+		applyError(err)
+		return ret, nil
+	} else {
+		ret = resp
+	}
+	ret.ExecutionTime = time.Since(start).Microseconds()
+
+	return ret, nil
+}
+
+// tableFingerprint returns the schema version of the given table.
+func (q *Queryer) tableFingerprint(ctx context.Context, qdbid dax.QualifiedDatabaseID, tname dax.TableName) (string, error) {
+	qtbl, err := q.controller.TableByName(ctx, qdbid, tname)
+	if err != nil {
+		return "", err
+	}
+	return qtbl.Version(), nil
+}
+
+// inspectStatement returns the (sorted, distinct) parameter names and table
+// names referenced by st.
+func inspectStatement(st parser.Statement) ([]string, []dax.TableName, error) {
+	v := &statementInspector{
+		params: make(map[string]struct{}),
+		tables: make(map[dax.TableName]struct{}),
+	}
+	if _, err := parser.Walk(v, st); err != nil {
+		return nil, nil, err
+	}
+
+	params := make([]string, 0, len(v.params))
+	for p := range v.params {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+
+	tables := make([]dax.TableName, 0, len(v.tables))
+	for t := range v.tables {
+		tables = append(tables, t)
+	}
+	sort.Sort(dax.TableNames(tables))
+
+	return params, tables, nil
+}
+
+// statementInspector is a parser.Visitor which collects the variables and
+// tables referenced in a statement.
+type statementInspector struct {
+	params map[string]struct{}
+	tables map[dax.TableName]struct{}
+}
+
+func (v *statementInspector) Visit(node parser.Node) (parser.Visitor, parser.Node, error) {
+	switch n := node.(type) {
+	case *parser.Variable:
+		v.params[strings.ToLower(n.VarName())] = struct{}{}
+	case *parser.QualifiedTableName:
+		v.tables[dax.TableName(strings.ToLower(parser.IdentName(n.Name)))] = struct{}{}
+	}
+	return v, node, nil
+}
+
+func (v *statementInspector) VisitEnd(node parser.Node) (parser.Node, error) {
+	return node, nil
+}
+
+// bindParameters replaces each variable in st with a literal holding the
+// corresponding value from params.
+func bindParameters(st parser.Statement, params map[string]interface{}) error {
+	lowered := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		lowered[strings.ToLower(strings.TrimPrefix(k, "@"))] = v
+	}
+	_, err := parser.Walk(&parameterBinder{params: lowered}, st)
+	return err
+}
+
+// parameterBinder is a parser.Visitor which replaces variables with literals.
+type parameterBinder struct {
+	params map[string]interface{}
+}
+
+func (b *parameterBinder) Visit(node parser.Node) (parser.Visitor, parser.Node, error) {
+	n, ok := node.(*parser.Variable)
+	if !ok {
+		return b, node, nil
+	}
+
+	name := strings.ToLower(n.VarName())
+	val, ok := b.params[name]
+	if !ok {
+		return nil, node, dax.NewErrStatementParameter(name, "no value provided")
+	}
+
+	lit, err := parameterLiteral(name, n.NamePos, val)
+	if err != nil {
+		return nil, node, err
+	}
+	return nil, lit, nil
+}
+
+func (b *parameterBinder) VisitEnd(node parser.Node) (parser.Node, error) {
+	return node, nil
+}
+
+// parameterLiteral converts a parameter value, as decoded from json, to a
+// literal expression.
+func parameterLiteral(name string, pos parser.Pos, val interface{}) (parser.Expr, error) {
+	switch v := val.(type) {
+	case nil:
+		return &parser.NullLit{ValuePos: pos}, nil
+	case bool:
+		return &parser.BoolLit{ValuePos: pos, Value: v}, nil
+	case string:
+		return &parser.StringLit{ValuePos: pos, Value: v}, nil
+	case json.Number:
+		s := v.String()
+		if strings.ContainsAny(s, ".eE") {
+			return &parser.FloatLit{ValuePos: pos, Value: s}, nil
+		}
+		return &parser.IntegerLit{ValuePos: pos, Value: s}, nil
+	case int, int64, uint64:
+		return &parser.IntegerLit{ValuePos: pos, Value: fmt.Sprintf("%d", v)}, nil
+	case float64:
+		if v == float64(int64(v)) {
+			return &parser.IntegerLit{ValuePos: pos, Value: fmt.Sprintf("%d", int64(v))}, nil
+		}
+		return &parser.FloatLit{ValuePos: pos, Value: fmt.Sprintf("%v", v)}, nil
+	default:
+		return nil, dax.NewErrStatementParameter(name, fmt.Sprintf("unsupported value type: %T", val))
+	}
+}
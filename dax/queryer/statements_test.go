@@ -0,0 +1,127 @@
+package queryer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatements(t *testing.T) {
+	parse := func(t *testing.T, sql string) parser.Statement {
+		t.Helper()
+		st, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+		require.NoError(t, err)
+		return st
+	}
+
+	t.Run("Inspect", func(t *testing.T) {
+		st := parse(t, "SELECT _id FROM tbl WHERE a = @A AND b > @b AND c = @a")
+		params, tables, err := inspectStatement(st)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, params)
+		assert.Equal(t, []dax.TableName{"tbl"}, tables)
+	})
+
+	t.Run("Bind", func(t *testing.T) {
+		st := parse(t, "SELECT _id FROM tbl WHERE a = @a AND b > @b")
+		err := bindParameters(st, map[string]interface{}{
+			"a":  "foo",
+			"@B": json.Number("12"),
+		})
+		require.NoError(t, err)
+
+		params, _, err := inspectStatement(st)
+		require.NoError(t, err)
+		assert.Empty(t, params)
+		assert.Contains(t, st.String(), "'foo'")
+		assert.Contains(t, st.String(), "12")
+	})
+
+	t.Run("BindMissing", func(t *testing.T) {
+		st := parse(t, "SELECT _id FROM tbl WHERE a = @a")
+		err := bindParameters(st, nil)
+		assert.True(t, errors.Is(err, dax.ErrStatementParameter))
+	})
+
+	t.Run("Plans", func(t *testing.T) {
+		op := parse(t, "SELECT _id FROM tbl")
+		stmt := &Statement{
+			Tables:  []dax.TableName{"tbl"},
+			schemas: map[dax.TableName]string{"tbl": "1"},
+			plans:   make(map[string]parser.Statement),
+		}
+		v1 := map[dax.TableName]string{"tbl": "1"}
+		v2 := map[dax.TableName]string{"tbl": "2"}
+
+		got, changed := stmt.plan("a", v1)
+		assert.Nil(t, got)
+		assert.Empty(t, changed)
+		stmt.addPlan("a", v1, op)
+		got, _ = stmt.plan("a", v1)
+		assert.Equal(t, op, got)
+
+		// A schema change discards the cached plans.
+		got, changed = stmt.plan("a", v2)
+		assert.Nil(t, got)
+		assert.Equal(t, dax.TableName("tbl"), changed)
+		stmt.addPlan("a", v2, op)
+		got, changed = stmt.plan("a", v2)
+		assert.Equal(t, op, got)
+		assert.Empty(t, changed)
+
+		// A plan for an old schema isn't cached.
+		stmt.addPlan("b", v1, op)
+		got, _ = stmt.plan("b", v2)
+		assert.Nil(t, got)
+
+		for i := 0; i < 2*maxStatementPlans; i++ {
+			stmt.addPlan(fmt.Sprint(i), v2, op)
+		}
+		assert.Len(t, stmt.plans, maxStatementPlans)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		q := New(Config{})
+		qdbid := dax.NewQualifiedDatabaseID("org", "db")
+		_, err := q.RegisterStatement(context.Background(), qdbid, "stmt", "SELECT @a + 1 AS n")
+		require.NoError(t, err)
+
+		// Invocations with the same values share a cached plan, and run at
+		// the same time.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					resp, err := q.InvokeStatement(context.Background(), qdbid, "stmt", map[string]interface{}{"a": json.Number("1")})
+					if !assert.NoError(t, err) || !assert.Empty(t, resp.Error) {
+						return
+					}
+					assert.Equal(t, [][]interface{}{{int64(2)}}, resp.Data)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("NoRegistry", func(t *testing.T) {
+		q := New(Config{})
+		qdbid := dax.NewQualifiedDatabaseID("org", "db")
+		assert.Empty(t, q.Statements(context.Background(), qdbid))
+		err := q.DeregisterStatement(context.Background(), qdbid, "stmt")
+		assert.True(t, errors.Is(err, dax.ErrStatementDoesNotExist))
+		_, err = q.InvokeStatement(context.Background(), qdbid, "stmt", nil)
+		assert.True(t, errors.Is(err, dax.ErrStatementDoesNotExist))
+		assert.Nil(t, q.lookupStatementRegistry(qdbid))
+	})
+}
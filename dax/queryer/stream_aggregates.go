@@ -0,0 +1,408 @@
+package queryer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+)
+
+// DefaultStreamAggregateSnapshots is the number of snapshots a streamed
+// aggregation emits when StreamAggregates.Snapshots is 0.
+const DefaultStreamAggregateSnapshots = 10
+
+// StreamAggregates requests that the results of an aggregation be streamed as
+// a series of snapshots which converge on the exact result, rather than
+// returned once every shard has been aggregated. The table's shards are split
+// into Snapshots batches, and after each batch is aggregated a snapshot of the
+// results of all the batches so far is emitted, along with how many of the
+// table's shards they cover. Each shard is still read only once, so the whole
+// stream costs about as much as the aggregation would on its own, but the
+// shards of a batch are read together, so smaller batches take longer overall.
+//
+// Only aggregates whose results for disjoint sets of shards can be merged
+// into their result for the union are supported: COUNT (but not COUNT
+// DISTINCT), SUM, MIN and MAX, over a single table, optionally with a WHERE
+// and a GROUP BY whose columns are selected. The columns selected may not be
+// expressions of aggregates, such as SUM(a)/COUNT(*), and the statement may
+// not have a HAVING, ORDER BY, LIMIT or DISTINCT, since none of those can be
+// applied until every shard has been aggregated. Approximations which don't
+// merge, such as APPROX_COUNT_DISTINCT, are rejected too.
+type StreamAggregates struct {
+	// Snapshots is the number of batches the table's shards are aggregated
+	// in; a table with fewer shards is aggregated a shard at a time. If 0,
+	// DefaultStreamAggregateSnapshots is used.
+	Snapshots int
+}
+
+// AggregateSnapshot is one of the snapshots of a streamed aggregation. Data
+// is the result of the aggregation over AggregatedShards of the table's
+// TotalShards; Complete is the percentage of the shards aggregated. The final
+// snapshot is the exact result. If the aggregation fails, the final snapshot
+// has Error set and no Data.
+type AggregateSnapshot struct {
+	Schema           featurebase.WireQuerySchema `json:"schema"`
+	Data             [][]interface{}             `json:"data"`
+	AggregatedShards int                         `json:"aggregated-shards"`
+	TotalShards      int                         `json:"total-shards"`
+	Complete         float64                     `json:"complete"`
+	Final            bool                        `json:"final"`
+	Error            string                      `json:"error,omitempty"`
+	ErrorCode        string                      `json:"error-code,omitempty"`
+	ExecutionTime    int64                       `json:"execution-time"`
+}
+
+// StreamAggregateSQL executes the aggregation in sql as described by s,
+// passing each snapshot of its results to emit, which ends the stream early
+// if it returns an error. It returns an error without emitting anything if the
+// statement isn't an aggregation which can be streamed. Otherwise, errors are
+// reported in the final snapshot.
+func (q *Queryer) StreamAggregateSQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, sql io.Reader, s StreamAggregates, emit func(AggregateSnapshot) error) error {
+	if err := q.beginQuery(); err != nil {
+		return err
+	}
+	defer q.inflight.Done()
+
+	start := time.Now()
+	text, err := q.readQueryText(sql)
+	if err != nil {
+		return err
+	}
+	labels := dax.QueryLabelsFromContext(ctx)
+	if err := q.labelPolicy.Validate(labels); err != nil {
+		return err
+	}
+
+	st, err := parser.NewParser(bytes.NewReader(text)).ParseStatement()
+	if err != nil {
+		return errors.Wrap(err, "parsing sql")
+	}
+	sel, ok := st.(*parser.SelectStatement)
+	if !ok {
+		return errors.Errorf("streamed aggregates require a SELECT statement")
+	}
+	tname, merges, err := mergeableAggregates(sel)
+	if err != nil {
+		return err
+	}
+
+	rec := QueryRecord{
+		Database: qdbid,
+		SQL:      string(text),
+		Labels:   labels,
+		Start:    start,
+	}
+	snap := AggregateSnapshot{}
+	fail := func(err error) error {
+		snap.Data = nil
+		snap.Error = err.Error()
+		snap.ErrorCode = featurebase.WireErrorCode(err)
+		snap.Final = true
+		snap.ExecutionTime = time.Since(start).Microseconds()
+		rec.Duration = time.Since(start)
+		q.recordQuery(rec, nil, err)
+		return emit(snap)
+	}
+
+	qtbl, err := q.controller.TableByName(ctx, qdbid, dax.TableName(tname))
+	if err != nil {
+		return fail(err)
+	}
+	shards, err := q.tableShards(ctx, qtbl.QualifiedID())
+	if err != nil {
+		return fail(err)
+	}
+	batches := shardBatches(shards, s.Snapshots)
+	snap.TotalShards = len(shards)
+
+	merged := newAggregateMerger(merges)
+	for i, batch := range batches {
+		// The statement is parsed afresh for each batch, since the planner
+		// rewrites the statement it compiles.
+		st, err := parser.NewParser(bytes.NewReader(text)).ParseStatement()
+		if err != nil {
+			return fail(errors.Wrap(err, "parsing sql"))
+		}
+		bctx := ctx
+		if batch != nil {
+			bctx = withShardRestriction(ctx, qtbl.Key(), batch)
+		}
+		resp, err := q.queryStatement(bctx, qdbid, st)
+		if err != nil {
+			return fail(err)
+		}
+		if err := merged.add(resp.Data); err != nil {
+			return fail(err)
+		}
+
+		snap.Schema = resp.Schema
+		snap.Data = merged.rows()
+		snap.AggregatedShards += len(batch)
+		snap.Complete = 100
+		if snap.TotalShards > 0 {
+			snap.Complete = 100 * float64(snap.AggregatedShards) / float64(snap.TotalShards)
+		}
+		snap.Final = i == len(batches)-1
+		snap.ExecutionTime = time.Since(start).Microseconds()
+		if snap.Final {
+			rec.Duration = time.Since(start)
+			q.recordQuery(rec, &featurebase.WireQueryResponse{Data: snap.Data}, nil)
+		}
+		if err := emit(snap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableShards returns the shards of the table, in order.
+func (q *Queryer) tableShards(ctx context.Context, qtid dax.QualifiedTableID) ([]uint64, error) {
+	nodes, err := q.controller.ComputeNodes(ctx, qtid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting shards for table: %s", qtid)
+	}
+	var shards []uint64
+	for _, n := range nodes {
+		for _, s := range n.Shards {
+			shards = append(shards, uint64(s))
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	return shards, nil
+}
+
+// shardBatches splits shards into n batches of about the same size, or into
+// single shards if there are fewer than n. A table with no shards is a single,
+// nil, batch, which is aggregated without restricting its shards.
+func shardBatches(shards []uint64, n int) [][]uint64 {
+	if n <= 0 {
+		n = DefaultStreamAggregateSnapshots
+	}
+	if len(shards) == 0 {
+		return [][]uint64{nil}
+	}
+	if n > len(shards) {
+		n = len(shards)
+	}
+	batches := make([][]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		batches = append(batches, shards[i*len(shards)/n:(i+1)*len(shards)/n])
+	}
+	return batches
+}
+
+type shardRestrictionKey struct{}
+
+// shardRestriction limits the shards of a table read by a query to shards.
+type shardRestriction struct {
+	table  dax.TableKey
+	shards []uint64
+}
+
+// withShardRestriction returns a copy of ctx in which queries of the table
+// read only the given shards.
+func withShardRestriction(ctx context.Context, table dax.TableKey, shards []uint64) context.Context {
+	return context.WithValue(ctx, shardRestrictionKey{}, shardRestriction{table: table, shards: shards})
+}
+
+// restrictedShards returns the shards of index to which ctx restricts
+// queries, if any.
+func restrictedShards(ctx context.Context, index string) ([]uint64, bool) {
+	r, ok := ctx.Value(shardRestrictionKey{}).(shardRestriction)
+	if !ok || string(r.table) != index {
+		return nil, false
+	}
+	return r.shards, true
+}
+
+// aggregateMerge is how a column of a streamed aggregation is merged across
+// batches.
+type aggregateMerge int
+
+const (
+	// mergeGroup is a GROUP BY column, which is part of the key of a row.
+	mergeGroup aggregateMerge = iota
+	mergeSum
+	mergeMin
+	mergeMax
+)
+
+// mergeableAggregates returns the table aggregated by sel, and how each of
+// its columns is merged, or an error if sel isn't an aggregation which can be
+// streamed; see StreamAggregates.
+func mergeableAggregates(sel *parser.SelectStatement) (string, []aggregateMerge, error) {
+	switch {
+	case sel.WithClause != nil, sel.Compound != nil, len(sel.Windows) > 0:
+		return "", nil, errors.Errorf("streamed aggregates don't support WITH, WINDOW or compound statements")
+	case sel.Distinct.IsValid(), sel.TopExpr != nil, sel.LimitExpr != nil, sel.HavingExpr != nil, len(sel.OrderingTerms) > 0:
+		return "", nil, errors.Errorf("streamed aggregates don't support DISTINCT, TOP, LIMIT, HAVING or ORDER BY")
+	}
+	src, ok := sel.Source.(*parser.QualifiedTableName)
+	if !ok {
+		return "", nil, errors.Errorf("streamed aggregates require a single table")
+	}
+
+	groupBy := make(map[string]struct{}, len(sel.GroupByExprs))
+	for _, expr := range sel.GroupByExprs {
+		groupBy[expr.String()] = struct{}{}
+	}
+	merges := make([]aggregateMerge, len(sel.Columns))
+	var aggregates int
+	for i, col := range sel.Columns {
+		if col.Star.IsValid() {
+			return "", nil, errors.Errorf("streamed aggregates can't select *")
+		}
+		call, ok := col.Expr.(*parser.Call)
+		if !ok {
+			if _, ok := groupBy[col.Expr.String()]; !ok {
+				return "", nil, errors.Errorf("column '%s' is neither an aggregate nor grouped by", col.Expr)
+			}
+			merges[i] = mergeGroup
+			continue
+		}
+		if call.Distinct.IsValid() || call.Filter != nil || call.Over != nil {
+			return "", nil, errors.Errorf("aggregate '%s' can't be streamed", call)
+		}
+		switch strings.ToUpper(call.Name.Name) {
+		case "COUNT", "SUM":
+			merges[i] = mergeSum
+		case "MIN":
+			merges[i] = mergeMin
+		case "MAX":
+			merges[i] = mergeMax
+		default:
+			return "", nil, errors.Errorf("aggregate '%s' can't be streamed: only COUNT, SUM, MIN and MAX can be merged across shards", call)
+		}
+		aggregates++
+	}
+	if aggregates == 0 {
+		return "", nil, errors.Errorf("streamed aggregates require an aggregate")
+	}
+	return parser.IdentName(src.Name), merges, nil
+}
+
+// aggregateMerger merges the rows of the batches of a streamed aggregation.
+// Rows are kept in the order their groups were first seen.
+type aggregateMerger struct {
+	merges []aggregateMerge
+	keys   []string
+	groups map[string][]interface{}
+}
+
+func newAggregateMerger(merges []aggregateMerge) *aggregateMerger {
+	return &aggregateMerger{
+		merges: merges,
+		groups: make(map[string][]interface{}),
+	}
+}
+
+// add merges the rows of a batch.
+func (m *aggregateMerger) add(data [][]interface{}) error {
+	for _, row := range data {
+		if len(row) != len(m.merges) {
+			return errors.Errorf("expected %d columns, got %d", len(m.merges), len(row))
+		}
+		key := m.key(row)
+		group, ok := m.groups[key]
+		if !ok {
+			m.keys = append(m.keys, key)
+			m.groups[key] = append([]interface{}(nil), row...)
+			continue
+		}
+		for i, merge := range m.merges {
+			v, err := mergeAggregate(merge, group[i], row[i])
+			if err != nil {
+				return errors.Wrapf(err, "merging column %d", i)
+			}
+			group[i] = v
+		}
+	}
+	return nil
+}
+
+// key returns the key of the group of row.
+func (m *aggregateMerger) key(row []interface{}) string {
+	var b strings.Builder
+	for i, merge := range m.merges {
+		if merge == mergeGroup {
+			fmt.Fprintf(&b, "%T:%v\x00", row[i], row[i])
+		}
+	}
+	return b.String()
+}
+
+// rows returns a copy of the merged rows.
+func (m *aggregateMerger) rows() [][]interface{} {
+	rows := make([][]interface{}, len(m.keys))
+	for i, key := range m.keys {
+		rows[i] = append([]interface{}(nil), m.groups[key]...)
+	}
+	return rows
+}
+
+// mergeAggregate merges a and b, the values of a column in two batches. A
+// nil value, as from a SUM, MIN or MAX over no rows, has no effect.
+func mergeAggregate(merge aggregateMerge, a, b interface{}) (interface{}, error) {
+	if merge == mergeGroup || b == nil {
+		return a, nil
+	} else if a == nil {
+		return b, nil
+	}
+	switch merge {
+	case mergeSum:
+		switch x := a.(type) {
+		case int64:
+			if y, ok := b.(int64); ok {
+				return x + y, nil
+			}
+		case float64:
+			if y, ok := b.(float64); ok {
+				return x + y, nil
+			}
+		case pql.Decimal:
+			if y, ok := b.(pql.Decimal); ok {
+				return pql.AddDecimal(x, y), nil
+			}
+		}
+	case mergeMin, mergeMax:
+		if less, ok := lessAggregate(a, b); ok {
+			if less == (merge == mergeMin) {
+				return a, nil
+			}
+			return b, nil
+		}
+	}
+	return nil, errors.Errorf("can't merge %T with %T", a, b)
+}
+
+// lessAggregate returns whether a is less than b, and whether they could be
+// compared.
+func lessAggregate(a, b interface{}) (bool, bool) {
+	switch x := a.(type) {
+	case int64:
+		y, ok := b.(int64)
+		return x < y, ok
+	case float64:
+		y, ok := b.(float64)
+		return x < y, ok
+	case pql.Decimal:
+		y, ok := b.(pql.Decimal)
+		return x.LessThan(y), ok
+	case time.Time:
+		y, ok := b.(time.Time)
+		return x.Before(y), ok
+	case string:
+		y, ok := b.(string)
+		return x < y, ok
+	}
+	return false, false
+}
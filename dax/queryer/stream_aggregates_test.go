@@ -0,0 +1,115 @@
+package queryer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shardsController is a Controller which records the shards it's asked for.
+type shardsController struct {
+	dax.Controller
+	shards dax.ShardNums
+}
+
+func (c *shardsController) ComputeNodes(ctx context.Context, qtid dax.QualifiedTableID, shards ...dax.ShardNum) ([]dax.ComputeNode, error) {
+	c.shards = shards
+	return nil, nil
+}
+
+func TestStreamAggregates(t *testing.T) {
+	parse := func(sql string) *parser.SelectStatement {
+		st, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+		require.NoError(t, err, sql)
+		return st.(*parser.SelectStatement)
+	}
+
+	t.Run("Mergeable", func(t *testing.T) {
+		tname, merges, err := mergeableAggregates(parse("SELECT count(*), sum(a), min(b), max(c) FROM t WHERE a > 1"))
+		require.NoError(t, err)
+		assert.Equal(t, "t", tname)
+		assert.Equal(t, []aggregateMerge{mergeSum, mergeSum, mergeMin, mergeMax}, merges)
+
+		_, merges, err = mergeableAggregates(parse("SELECT region, count(*) FROM t GROUP BY region"))
+		require.NoError(t, err)
+		assert.Equal(t, []aggregateMerge{mergeGroup, mergeSum}, merges)
+
+		for _, sql := range []string{
+			"SELECT a FROM t",
+			"SELECT * FROM t",
+			"SELECT avg(a) FROM t",
+			"SELECT count(distinct a) FROM t",
+			"SELECT approx_count_distinct(a) FROM t",
+			"SELECT sum(a) / count(*) FROM t",
+			"SELECT region, count(*) FROM t",
+			"SELECT region, count(*) FROM t GROUP BY region HAVING count(*) > 1",
+			"SELECT region, count(*) FROM t GROUP BY region ORDER BY region",
+			"SELECT count(*) FROM t LIMIT 1",
+			"SELECT count(*) FROM (SELECT a FROM t)",
+		} {
+			_, _, err := mergeableAggregates(parse(sql))
+			assert.Error(t, err, sql)
+		}
+	})
+
+	t.Run("Batches", func(t *testing.T) {
+		shards := []uint64{0, 1, 2, 3, 4, 5, 6}
+		assert.Equal(t, [][]uint64{{0, 1}, {2, 3}, {4, 5, 6}}, shardBatches(shards, 3))
+		assert.Len(t, shardBatches(shards, 10), 7)
+		assert.Len(t, shardBatches(shards, 0), 7)
+		assert.Equal(t, [][]uint64{nil}, shardBatches(nil, 3))
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		t0 := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+		m := newAggregateMerger([]aggregateMerge{mergeGroup, mergeSum, mergeSum, mergeMin, mergeMax})
+		require.NoError(t, m.add([][]interface{}{
+			{"east", int64(2), pql.NewDecimal(150, 2), t0, nil},
+			{"west", int64(1), nil, nil, nil},
+		}))
+		require.NoError(t, m.add([][]interface{}{
+			{"west", int64(3), pql.NewDecimal(25, 1), t0.Add(time.Hour), int64(7)},
+			{"east", int64(4), pql.NewDecimal(50, 2), t0.Add(-time.Hour), int64(3)},
+			{"north", int64(1), nil, nil, nil},
+		}))
+		rows := m.rows()
+		require.Len(t, rows, 3)
+		assert.Equal(t, "east", rows[0][0])
+		assert.Equal(t, int64(6), rows[0][1])
+		assert.True(t, pql.NewDecimal(200, 2).EqualTo(rows[0][2].(pql.Decimal)), "got %v", rows[0][2])
+		assert.Equal(t, t0.Add(-time.Hour), rows[0][3])
+		assert.Equal(t, int64(3), rows[0][4])
+		assert.Equal(t, []interface{}{"west", int64(4), pql.NewDecimal(25, 1), t0.Add(time.Hour), int64(7)}, rows[1])
+		assert.Equal(t, "north", rows[2][0])
+
+		// Values of different types can't be merged.
+		assert.Error(t, m.add([][]interface{}{{"east", "x", nil, nil, nil}}))
+	})
+
+	t.Run("Restriction", func(t *testing.T) {
+		c := &shardsController{Controller: dax.NewNopController()}
+		topo := &ServerlessTopology{controller: c}
+		qtid := dax.NewQualifiedTableID(dax.NewQualifiedDatabaseID("org", "db"), "tbl")
+		ctx := withShardRestriction(context.Background(), qtid.Key(), []uint64{4, 5})
+
+		_, err := topo.ComputeNodes(ctx, string(qtid.Key()), nil)
+		require.NoError(t, err)
+		assert.Equal(t, dax.ShardNums{4, 5}, c.shards)
+
+		// Shards asked for explicitly, and other tables, aren't restricted.
+		_, err = topo.ComputeNodes(ctx, string(qtid.Key()), []uint64{1})
+		require.NoError(t, err)
+		assert.Equal(t, dax.ShardNums{1}, c.shards)
+		other := dax.NewQualifiedTableID(qtid.QualifiedDatabaseID, "other")
+		_, err = topo.ComputeNodes(ctx, string(other.Key()), nil)
+		require.NoError(t, err)
+		assert.Empty(t, c.shards)
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/controller"
 	"github.com/featurebasedb/featurebase/v3/dax/queryer"
 	"github.com/featurebasedb/featurebase/v3/errors"
@@ -43,6 +44,33 @@ type Config struct {
 	// LogPath configures where Pilosa will write logs.
 	LogPath string `toml:"log-path"`
 
+	// ShutdownOrder is the order in which subservices are drained when the
+	// server shuts down. Valid stages are "queryer", "writelogger",
+	// "snapshotter", and "controller". If empty, the default order is used.
+	ShutdownOrder []string `toml:"shutdown-order"`
+
+	// ShutdownStageTimeout is the maximum amount of time each shutdown stage
+	// is given to drain before shutdown moves on to the next stage.
+	ShutdownStageTimeout time.Duration `toml:"shutdown-stage-timeout"`
+
+	// OpenAPI enables serving an OpenAPI document, describing the endpoints
+	// of the services running in this process, at /openapi.json.
+	OpenAPI bool `toml:"openapi"`
+
+	// HistogramBuckets overrides the buckets of latency histograms, so that
+	// they can be aligned with SLO thresholds. Each entry is of the form
+	// "<metric>=<upper-bound> <upper-bound> ...", with upper bounds in
+	// seconds; see dax.ConfigureHistogramBuckets.
+	HistogramBuckets []string `toml:"histogram-buckets"`
+
+	// ServiceAuth configures the authentication of requests between the
+	// services; see dax.ServiceAuthConfig.
+	ServiceAuth dax.ServiceAuthConfig `toml:"service-auth"`
+
+	// CoalesceHealthChecks has concurrent requests to /health share a single
+	// probe of the services, rather than each probing them.
+	CoalesceHealthChecks bool `toml:"coalesce-health-checks"`
+
 	Controller ControllerOptions `toml:"controller"`
 	Queryer    QueryerOptions    `toml:"queryer"`
 	Computer   ComputerOptions   `toml:"computer"`
@@ -75,7 +103,8 @@ func NewConfig() *Config {
 				SnappingTurtleTimeout:    time.Minute * 3,
 			},
 		},
-		Bind: ":" + defaultBindPort,
+		Bind:                 ":" + defaultBindPort,
+		CoalesceHealthChecks: true,
 		Computer: ComputerOptions{
 			Config: *fbserver.NewConfig(),
 		},
@@ -159,9 +159,24 @@ func (m *Command) Close() error {
 	case <-m.done:
 		return nil
 	default:
+		// Drain the subservices, in order, before stopping them so that
+		// in-flight work is completed and flushed.
+		if d, ok := m.Handler.(interface{ Drain() }); ok {
+			d.Drain()
+		}
+
+		// Stop accepting HTTP requests before stopping the subservices,
+		// so that no request reaches a service which has been stopped.
+		var err error
+		if m.Handler != nil {
+			err = m.Handler.Close()
+		}
+
 		eg := errgroup.Group{}
 		eg.Go(m.svcmgr.StopAll)
-		err := eg.Wait()
+		if serr := eg.Wait(); serr != nil && err == nil {
+			err = serr
+		}
 		//_ = testhook.Closed(pilosa.NewAuditor(), m, nil)
 		close(m.done)
 
@@ -209,6 +224,7 @@ func (m *Command) setupServer() error {
 
 	if m.svcmgr != nil {
 		m.svcmgr.Logger = m.logger
+		m.svcmgr.CoalesceHealthChecks = m.Config.CoalesceHealthChecks
 	}
 
 	conf, err := json.MarshalIndent(m.Config, "", "\t")
@@ -217,6 +233,19 @@ func (m *Command) setupServer() error {
 	}
 	m.logger.Debugf("Config: %s", conf)
 
+	if err := dax.ConfigureHistogramBuckets(m.Config.HistogramBuckets); err != nil {
+		return errors.Wrap(err, "configuring histogram buckets")
+	}
+
+	serviceAuth, err := dax.NewServiceAuth(m.Config.ServiceAuth)
+	if err != nil {
+		return errors.Wrap(err, "configuring service auth")
+	}
+	dax.SetServiceAuth(serviceAuth)
+	if m.svcmgr != nil {
+		m.svcmgr.ServiceAuth = serviceAuth
+	}
+
 	// validateAddrs sets the appropriate values for Bind and Advertise
 	// based on the inputs. It is not responsible for applying defaults, although
 	// it does provide a non-zero port (10101) in the case where no port is specified.
@@ -259,6 +288,25 @@ func (m *Command) setupServer() error {
 		daxhttp.OptHandlerBind(m.Config.Bind),
 		daxhttp.OptHandlerListener(m.ln, m.advertiseURI.String()),
 		daxhttp.OptHandlerLogger(m.logger),
+		daxhttp.OptHandlerShutdownOrder(m.Config.ShutdownOrder...),
+		daxhttp.OptHandlerShutdownStageTimeout(m.Config.ShutdownStageTimeout),
+	}
+
+	// Register a drainer for each shutdown stage. These are resolved against
+	// the ServiceManager at shutdown, so the services don't need to exist yet.
+	for _, stage := range []string{
+		dax.ServicePrefixQueryer,
+		dax.ServicePrefixWritelogger,
+		dax.ServicePrefixSnapshotter,
+		dax.ServicePrefixController,
+	} {
+		handlerOpts = append(handlerOpts, daxhttp.OptHandlerDrainer(stage, m.svcmgr.Drainer(stage)))
+	}
+
+	if m.Config.OpenAPI {
+		handlerOpts = append(handlerOpts, daxhttp.OptHandlerOpenAPI(func() (*dax.OpenAPIDocument, error) {
+			return m.svcmgr.OpenAPI(featurebase.Version)
+		}))
 	}
 
 	drouter := m.svcmgr.HTTPHandler()
@@ -293,8 +341,27 @@ func (m *Command) setupServices() error {
 
 	// Set up Queryer.
 	if m.Config.Queryer.Run {
+		if _, err := queryer.ParseOutputFormat(string(m.Config.Queryer.Config.DefaultOutputFormat)); err != nil {
+			return errors.Wrap(err, "validating queryer config")
+		}
 		qryrCfg := queryer.Config{
-			Logger: m.logger,
+			MaxStatements:           m.Config.Queryer.Config.MaxStatements,
+			MaxQueryTextSize:        m.Config.Queryer.Config.MaxQueryTextSize,
+			DebugOrganizations:      m.Config.Queryer.Config.DebugOrganizations,
+			Labels:                  m.Config.Queryer.Config.Labels,
+			QueryHistorySize:        m.Config.Queryer.Config.QueryHistorySize,
+			SlowQueryThreshold:      m.Config.Queryer.Config.SlowQueryThreshold,
+			ImportSampleSize:        m.Config.Queryer.Config.ImportSampleSize,
+			Breaker:                 m.Config.Queryer.Config.Breaker,
+			Prewarm:                 m.Config.Queryer.Config.Prewarm,
+			Distinct:                m.Config.Queryer.Config.Distinct,
+			ReadOnly:                m.Config.Queryer.Config.ReadOnly,
+			DefaultOutputFormat:     m.Config.Queryer.Config.DefaultOutputFormat,
+			Export:                  m.Config.Queryer.Config.Export,
+			Encryption:              m.Config.Queryer.Config.Encryption,
+			ControllerAddresses:     m.Config.Queryer.Config.ControllerAddresses,
+			ControllerCheckInterval: m.Config.Queryer.Config.ControllerCheckInterval,
+			Logger:                  m.logger,
 		}
 
 		m.svcmgr.Queryer = queryersvc.New(m.advertiseURI, queryer.New(qryrCfg), m.logger)
@@ -304,6 +371,8 @@ func (m *Command) setupServices() error {
 			controllerAddr = dax.Address(m.Config.Queryer.Config.ControllerAddress)
 		} else if m.svcmgr.Controller != nil {
 			controllerAddr = m.svcmgr.Controller.Address()
+		} else if len(m.Config.Queryer.Config.ControllerAddresses) > 0 {
+			controllerAddr = dax.Address(m.Config.Queryer.Config.ControllerAddresses[0])
 		} else {
 			return errors.Errorf("queryer requires Controller")
 		}
@@ -0,0 +1,310 @@
+package dax
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// HeaderServiceToken is the header in which one DAX service sends the token
+// authenticating it to another.
+const HeaderServiceToken = "X-Dax-Service-Token"
+
+// DefaultServiceTokenMaxAge is the default age beyond which a service token is
+// rejected.
+const DefaultServiceTokenMaxAge = 5 * time.Minute
+
+// DefaultServiceMaxBodySize is the default size, in bytes, of the largest
+// request body read to verify a service token.
+const DefaultServiceMaxBodySize = 256 << 20
+
+// serviceTokenMaxSkew is how far in the future a token may be dated, to allow
+// for the clock skew between services.
+const serviceTokenMaxSkew = 30 * time.Second
+
+// minServiceAuthKeyLen is the length, in bytes, of the shortest key accepted.
+const minServiceAuthKeyLen = 16
+
+// Reasons for which a service token is rejected, as recorded in
+// CounterServiceAuthFailures.
+const (
+	serviceAuthMissing = "missing"
+	serviceAuthInvalid = "invalid"
+	serviceAuthExpired = "expired"
+)
+
+// ServiceAuthConfig configures the authentication of requests from one DAX
+// service to another. When Keys are set, every request a DAX client (of the
+// controller, the queryer or a computer) sends carries a token signed with
+// the first key, and requests to the controller and computer endpoints are
+// rejected, with 401 Unauthorized, unless they carry a token signed with one
+// of the keys. The queryer's endpoints, which serve clients, and the top
+// level /health and /metrics, aren't affected.
+//
+// A token is an HMAC-SHA256, by a shared key, of the time it was made and of
+// the request's method, path, query string and a SHA-256 of its body, so a
+// token which is captured can only be replayed for the same request, and only
+// until it's older than MaxAge. Tokens dated more than 30 seconds in the
+// future are rejected too, before the body is read. Since the body is signed,
+// it's read into memory on both sides, and requests with a body larger than
+// MaxBodySize are rejected.
+// Every service must have a key in common with every other, so the keys are
+// rotated in three steps, each of which can be rolled out one process at a
+// time without rejecting any requests:
+//
+//  1. Add the new key to the end of Keys, so that it's accepted.
+//  2. Move the new key to the front of Keys, so that it's used to sign.
+//  3. Remove the old key from Keys.
+//
+// After step 2, the old key is accepted only for Grace, if set, so that it
+// stops being accepted even if step 3 is never rolled out.
+type ServiceAuthConfig struct {
+	// Keys are the shared keys. The first signs outgoing requests; any of
+	// them is accepted on incoming ones.
+	Keys []string `toml:"keys" json:"-"`
+
+	// Grace is how long, after the process starts, keys other than the
+	// first are accepted. 0 accepts them for as long as they're configured.
+	Grace time.Duration `toml:"grace"`
+
+	// MaxAge is the age beyond which a token is rejected. It must exceed the
+	// clock skew between services. 0 uses DefaultServiceTokenMaxAge.
+	MaxAge time.Duration `toml:"max-age"`
+
+	// MaxBodySize is the size, in bytes, of the largest request body which
+	// is read to verify a token. 0 uses DefaultServiceMaxBodySize.
+	MaxBodySize int64 `toml:"max-body-size"`
+}
+
+// ServiceAuth signs and verifies service tokens, as configured by a
+// ServiceAuthConfig. A nil *ServiceAuth signs nothing and accepts every
+// request.
+type ServiceAuth struct {
+	keys      [][]byte
+	graceEnds time.Time // zero if there's no grace window
+	maxAge    time.Duration
+	maxBody   int64
+	now       func() time.Time
+}
+
+// NewServiceAuth returns a ServiceAuth for cfg, or nil if cfg has no keys.
+func NewServiceAuth(cfg ServiceAuthConfig) (*ServiceAuth, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+	if cfg.Grace < 0 || cfg.MaxAge < 0 || cfg.MaxBodySize < 0 {
+		return nil, errors.Errorf("service auth durations and sizes can't be negative")
+	}
+	a := &ServiceAuth{
+		keys:    make([][]byte, len(cfg.Keys)),
+		maxAge:  cfg.MaxAge,
+		maxBody: cfg.MaxBodySize,
+		now:     time.Now,
+	}
+	for i, k := range cfg.Keys {
+		if len(k) < minServiceAuthKeyLen {
+			return nil, errors.Errorf("service auth key %d is too short: must be at least %d bytes", i, minServiceAuthKeyLen)
+		}
+		a.keys[i] = []byte(k)
+	}
+	if a.maxAge == 0 {
+		a.maxAge = DefaultServiceTokenMaxAge
+	}
+	if a.maxBody == 0 {
+		a.maxBody = DefaultServiceMaxBodySize
+	}
+	if cfg.Grace > 0 {
+		a.graceEnds = a.now().Add(cfg.Grace)
+	}
+	return a, nil
+}
+
+// serviceToken returns the token for a request with the given method, path,
+// query string and body hash, made at ts, signed with key.
+func serviceToken(key []byte, ts int64, method, path, query string, bodySum []byte) string {
+	stamp := strconv.FormatInt(ts, 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stamp + "\n" + method + "\n" + path + "\n" + query + "\n"))
+	mac.Write(bodySum)
+	return stamp + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// bodySum returns the SHA-256 of the body of r, replacing the body with a
+// copy so that it can still be read. If useGetBody is true and r has a
+// GetBody, as an outgoing request may, the body is read from a copy it returns
+// instead. If limit is positive, a body longer than limit bytes is an error.
+func bodySum(r *http.Request, useGetBody bool, limit int64) ([]byte, error) {
+	h := sha256.New()
+	switch {
+	case r.Body == nil || r.Body == http.NoBody:
+	case useGetBody && r.GetBody != nil:
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting body")
+		}
+		defer body.Close()
+		if _, err := io.Copy(h, body); err != nil {
+			return nil, errors.Wrap(err, "reading body")
+		}
+	default:
+		body := r.Body
+		if limit > 0 {
+			body = http.MaxBytesReader(nil, body, limit)
+		}
+		data, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading body")
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		h.Write(data)
+	}
+	return h.Sum(nil), nil
+}
+
+// Sign sets the token of req.
+func (a *ServiceAuth) Sign(req *http.Request) error {
+	if a == nil {
+		return nil
+	}
+	sum, err := bodySum(req, true, 0)
+	if err != nil {
+		return errors.Wrap(err, "signing request")
+	}
+	req.Header.Set(HeaderServiceToken, serviceToken(a.keys[0], a.now().Unix(), req.Method, req.URL.EscapedPath(), req.URL.RawQuery, sum))
+	return nil
+}
+
+// Verify returns an error if r doesn't carry a valid token.
+func (a *ServiceAuth) Verify(r *http.Request) error {
+	if a == nil {
+		return nil
+	}
+	reason, err := a.verify(r)
+	if err != nil {
+		CounterServiceAuthFailures.WithLabelValues(reason).Inc()
+	}
+	return err
+}
+
+func (a *ServiceAuth) verify(r *http.Request) (string, error) {
+	tok := r.Header.Get(HeaderServiceToken)
+	if tok == "" {
+		return serviceAuthMissing, errors.Errorf("missing service token")
+	}
+	stamp, _, ok := strings.Cut(tok, ".")
+	if !ok {
+		return serviceAuthInvalid, errors.Errorf("malformed service token")
+	}
+	ts, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return serviceAuthInvalid, errors.Errorf("malformed service token")
+	}
+
+	// Reject a token by its date before reading the body, which the
+	// signature covers.
+	now := a.now()
+	if age := now.Sub(time.Unix(ts, 0)); age > a.maxAge {
+		return serviceAuthExpired, errors.Errorf("service token is %v old, beyond the maximum of %v; check the clocks of both services", age, a.maxAge)
+	} else if -age > serviceTokenMaxSkew {
+		return serviceAuthExpired, errors.Errorf("service token is dated %v in the future, beyond the allowed clock skew of %v; check the clocks of both services", -age, serviceTokenMaxSkew)
+	}
+
+	sum, err := bodySum(r, false, a.maxBody)
+	if err != nil {
+		return serviceAuthInvalid, err
+	}
+
+	keys := a.keys
+	if !a.graceEnds.IsZero() && !now.Before(a.graceEnds) {
+		keys = keys[:1]
+	}
+	for _, key := range keys {
+		if hmac.Equal([]byte(tok), []byte(serviceToken(key, ts, r.Method, r.URL.EscapedPath(), r.URL.RawQuery, sum))) {
+			return "", nil
+		}
+	}
+	return serviceAuthInvalid, errors.Errorf("service token isn't signed with an accepted key")
+}
+
+// Handler returns a handler which rejects requests which don't carry a valid
+// token, and passes the rest to next.
+func (a *ServiceAuth) Handler(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := a.Verify(r); err != nil {
+			http.Error(w, "unauthenticated service request: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var serviceAuth struct {
+	mu sync.RWMutex
+	a  *ServiceAuth
+}
+
+// SetServiceAuth sets the ServiceAuth with which ServiceTransport signs the
+// requests of this process.
+func SetServiceAuth(a *ServiceAuth) {
+	serviceAuth.mu.Lock()
+	defer serviceAuth.mu.Unlock()
+	serviceAuth.a = a
+}
+
+// CurrentServiceAuth returns the ServiceAuth set by SetServiceAuth, or nil if
+// none is set.
+func CurrentServiceAuth() *ServiceAuth {
+	serviceAuth.mu.RLock()
+	defer serviceAuth.mu.RUnlock()
+	return serviceAuth.a
+}
+
+// ServiceTransport returns a RoundTripper which signs each request with the
+// ServiceAuth set by SetServiceAuth, if any, before sending it with base, or
+// with http.DefaultTransport if base is nil. It's the transport of the DAX
+// service clients; since the ServiceAuth is looked up on each request, it
+// applies to clients made before it was set.
+func ServiceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &serviceTransport{base: base}
+}
+
+type serviceTransport struct {
+	base http.RoundTripper
+}
+
+func (t *serviceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	serviceAuth.mu.RLock()
+	a := serviceAuth.a
+	serviceAuth.mu.RUnlock()
+	if a == nil {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper mustn't modify the request it's given.
+	req = req.Clone(req.Context())
+	if err := a.Sign(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
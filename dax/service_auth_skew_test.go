@@ -0,0 +1,32 @@
+package dax
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAuthClockSkew(t *testing.T) {
+	a, err := NewServiceAuth(ServiceAuthConfig{Keys: []string{"key-0123456789abcdef"}})
+	require.NoError(t, err)
+	now := time.Now()
+	signedAt := func(ts time.Time) error {
+		a.now = func() time.Time { return ts }
+		req := httptest.NewRequest("GET", "/controller/nodes", nil)
+		require.NoError(t, a.Sign(req))
+		a.now = func() time.Time { return now }
+		return a.Verify(req)
+	}
+
+	assert.NoError(t, signedAt(now.Add(-time.Minute)))
+	assert.NoError(t, signedAt(now.Add(serviceTokenMaxSkew-time.Second)))
+
+	// Tokens dated in the future are only accepted within the allowed skew,
+	// even though that's less than the maximum age.
+	assert.Error(t, signedAt(now.Add(serviceTokenMaxSkew+time.Second)))
+	assert.Error(t, signedAt(now.Add(time.Minute)))
+	assert.Error(t, signedAt(now.Add(-DefaultServiceTokenMaxAge-time.Second)))
+}
@@ -0,0 +1,157 @@
+package dax_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAuth(t *testing.T) {
+	const (
+		oldKey = "old-key-0123456789"
+		newKey = "new-key-0123456789"
+	)
+	newAuth := func(cfg dax.ServiceAuthConfig) *dax.ServiceAuth {
+		a, err := dax.NewServiceAuth(cfg)
+		require.NoError(t, err)
+		return a
+	}
+	signed := func(a *dax.ServiceAuth, method, path string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		require.NoError(t, a.Sign(req))
+		return req
+	}
+
+	t.Run("Config", func(t *testing.T) {
+		a, err := dax.NewServiceAuth(dax.ServiceAuthConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, a)
+		assert.NoError(t, a.Verify(httptest.NewRequest("GET", "/", nil)))
+
+		_, err = dax.NewServiceAuth(dax.ServiceAuthConfig{Keys: []string{"short"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		a := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}})
+		assert.NoError(t, a.Verify(signed(a, "POST", "/controller/register-node")))
+
+		assert.Error(t, a.Verify(httptest.NewRequest("POST", "/controller/register-node", nil)))
+
+		req := signed(a, "POST", "/controller/register-node")
+		req.URL.Path = "/controller/deregister-nodes"
+		assert.Error(t, a.Verify(req), "expected a token for another path to be rejected")
+
+		req = signed(a, "GET", "/controller/nodes?role=compute")
+		req.URL.RawQuery = "role=translate"
+		assert.Error(t, a.Verify(req), "expected a token for another query string to be rejected")
+
+		other := newAuth(dax.ServiceAuthConfig{Keys: []string{newKey}})
+		assert.Error(t, a.Verify(signed(other, "POST", "/controller/register-node")))
+
+		short := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}, MaxAge: time.Nanosecond})
+		assert.Error(t, short.Verify(signed(a, "POST", "/controller/register-node")))
+	})
+
+	t.Run("Body", func(t *testing.T) {
+		a := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}})
+		withBody := func(body string) *http.Request {
+			req := httptest.NewRequest("POST", "/controller/register-node", strings.NewReader(body))
+			require.NoError(t, a.Sign(req))
+			return req
+		}
+
+		// The body can still be read once it's been signed and verified.
+		req := withBody(`{"address":"a"}`)
+		require.NoError(t, a.Verify(req))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"address":"a"}`, string(body))
+
+		req = withBody(`{"address":"a"}`)
+		req.Body = io.NopCloser(strings.NewReader(`{"address":"b"}`))
+		assert.Error(t, a.Verify(req), "expected a token for another body to be rejected")
+
+		small := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}, MaxBodySize: 8})
+		assert.NoError(t, small.Verify(withBody(`{"a":1}`)))
+		assert.Error(t, small.Verify(withBody(`{"address":"a"}`)), "expected a body beyond the maximum size to be rejected")
+
+		// An expired token is rejected without reading the body.
+		short := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}, MaxAge: time.Nanosecond})
+		req = withBody(`{"address":"a"}`)
+		req.Body = io.NopCloser(unreadable{t})
+		assert.Error(t, short.Verify(req))
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		before := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}})
+		accepting := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey, newKey}})
+		signing := newAuth(dax.ServiceAuthConfig{Keys: []string{newKey, oldKey}})
+
+		// Every step of the rotation accepts the requests of the steps on
+		// either side of it.
+		assert.NoError(t, accepting.Verify(signed(before, "GET", "/")))
+		assert.NoError(t, accepting.Verify(signed(signing, "GET", "/")))
+		assert.NoError(t, signing.Verify(signed(accepting, "GET", "/")))
+		assert.Error(t, before.Verify(signed(signing, "GET", "/")))
+
+		expired := newAuth(dax.ServiceAuthConfig{Keys: []string{newKey, oldKey}, Grace: time.Nanosecond})
+		time.Sleep(time.Millisecond)
+		assert.Error(t, expired.Verify(signed(before, "GET", "/")))
+		assert.NoError(t, expired.Verify(signed(signing, "GET", "/")))
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		a := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}})
+		h := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/computer0/status", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, signed(a, "GET", "/computer0/status"))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Transport", func(t *testing.T) {
+		a := newAuth(dax.ServiceAuthConfig{Keys: []string{oldKey}})
+		srv := httptest.NewServer(a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+		defer srv.Close()
+
+		client := &http.Client{Transport: dax.ServiceTransport(nil)}
+		get := func() int {
+			resp, err := client.Get(srv.URL + "/controller/nodes")
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		post := func() int {
+			resp, err := client.Post(srv.URL+"/controller/register-node", "application/json", strings.NewReader(`{"address":"a"}`))
+			require.NoError(t, err)
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+		assert.Equal(t, http.StatusUnauthorized, get())
+
+		dax.SetServiceAuth(a)
+		defer dax.SetServiceAuth(nil)
+		assert.Equal(t, http.StatusOK, get())
+		assert.Equal(t, http.StatusOK, post())
+	})
+}
+
+// unreadable is a request body which fails the test if it's read.
+type unreadable struct{ t *testing.T }
+
+func (u unreadable) Read(p []byte) (int, error) {
+	u.t.Fatal("unexpected read of the request body")
+	return 0, io.EOF
+}
@@ -1,6 +1,7 @@
 package dax
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -11,6 +12,8 @@ import (
 	"github.com/featurebasedb/featurebase/v3/logger"
 	"github.com/felixge/fgprof"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/singleflight"
 )
 
 // ServiceKey is a unique key used to identify one service managed by the
@@ -37,6 +40,16 @@ type ServiceManager struct {
 
 	drouter *dynamicRouter
 
+	// ServiceAuth, if set, authenticates the requests to the Controller and
+	// the Computers; the Queryer serves clients, so it isn't covered.
+	ServiceAuth *ServiceAuth
+
+	// CoalesceHealthChecks, if set, has health checks which arrive while
+	// another is probing the services wait for, and share, that probe's
+	// result, rather than each probing the services.
+	CoalesceHealthChecks bool
+	healthProbes         singleflight.Group
+
 	Logger logger.Logger
 }
 
@@ -50,7 +63,10 @@ func NewServiceManager() *ServiceManager {
 	return &ServiceManager{
 		computers: map[ServiceKey]*computerServiceState{},
 		drouter:   &dynamicRouter{},
-		Logger:    logger.NopLogger,
+
+		CoalesceHealthChecks: true,
+
+		Logger: logger.NopLogger,
 	}
 }
 
@@ -313,10 +329,112 @@ func (s *ServiceManager) RemoveComputer(key ServiceKey) bool {
 	return false
 }
 
-func getHealth(w http.ResponseWriter, req *http.Request) {
+// Drainer returns a Drainer for the shutdown stage identified by prefix (one of
+// the ServicePrefix* values). The returned Drainer is resolved lazily, at drain
+// time, so it can be handed to the http Handler before any services have been
+// added to ServiceManager. Services of the given type which do not implement
+// Drainer are skipped.
+func (s *ServiceManager) Drainer(prefix string) Drainer {
+	return drainerFunc(func(ctx context.Context) error {
+		for _, d := range s.drainers(prefix) {
+			if err := d.Drain(ctx); err != nil {
+				return errors.Wrapf(err, "draining %s", prefix)
+			}
+		}
+		return nil
+	})
+}
+
+// drainers returns the Drainers, among the running services, which
+// participate in the shutdown stage identified by prefix.
+func (s *ServiceManager) drainers(prefix string) []Drainer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ds []Drainer
+
+	switch prefix {
+	case ServicePrefixController:
+		if d, ok := s.Controller.(Drainer); ok && s.controllerStarted {
+			ds = append(ds, d)
+		}
+	case ServicePrefixQueryer:
+		if d, ok := s.Queryer.(Drainer); ok && s.queryerStarted {
+			ds = append(ds, d)
+		}
+	default:
+		// Computers, along with the writelogger and snapshotter which are
+		// embedded in each computer.
+		for _, serviceState := range s.computers {
+			if !serviceState.started {
+				continue
+			}
+			sd, ok := serviceState.service.(stageDrainer)
+			if !ok {
+				continue
+			}
+			if d := sd.Drainer(prefix); d != nil {
+				ds = append(ds, d)
+			}
+		}
+	}
+
+	return ds
+}
+
+// healther is implemented by services which can report that they are running
+// but degraded.
+type healther interface {
+	Health() error
+}
+
+// getHealth responds with StatusOK unless one of the running services reports
+// that it is degraded, in which case it responds with
+// StatusServiceUnavailable along with the reason.
+func (s *ServiceManager) getHealth(w http.ResponseWriter, req *http.Request) {
+	if err := s.coalescedHealth(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// coalescedHealth returns the result of health. If CoalesceHealthChecks is
+// set and another call is already probing the services, it waits for that
+// probe and returns its result instead of starting another.
+func (s *ServiceManager) coalescedHealth() error {
+	if !s.CoalesceHealthChecks {
+		return s.health()
+	}
+	var probed bool
+	_, err, _ := s.healthProbes.Do("health", func() (interface{}, error) {
+		probed = true
+		return nil, s.health()
+	})
+	if !probed {
+		CounterHealthChecksCoalesced.Inc()
+	}
+	return err
+}
+
+// health returns an error describing the first degraded service found.
+func (s *ServiceManager) health() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for key, serviceState := range s.computers {
+		if !serviceState.started {
+			continue
+		}
+		if h, ok := serviceState.service.(healther); ok {
+			if err := h.Health(); err != nil {
+				return errors.Wrapf(err, "%s degraded", key)
+			}
+		}
+	}
+	return nil
+}
+
 // Must be called with at least a read lock held (because that's required of buildRouter).
 func (s *ServiceManager) resetRouter() {
 	s.drouter.Swap(s.buildRouter())
@@ -325,7 +443,8 @@ func (s *ServiceManager) resetRouter() {
 // Must be called with at least a read lock held?
 func (s *ServiceManager) buildRouter() *mux.Router {
 	router := mux.NewRouter()
-	router.HandleFunc("/health", getHealth).Methods("GET").Name("GetHealth")
+	router.HandleFunc("/health", s.getHealth).Methods("GET").Name("GetHealth")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET").Name("GetMetrics")
 	router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux).Methods("GET")
 	router.PathPrefix("/debug/fgprof").Handler(fgprof.Handler()).Methods("GET")
 
@@ -333,7 +452,7 @@ func (s *ServiceManager) buildRouter() *mux.Router {
 	if s.Controller != nil && s.controllerStarted {
 		pre := "/" + ServicePrefixController
 		router.PathPrefix(pre + "/").Handler(
-			http.StripPrefix(pre, s.Controller.HTTPHandler()))
+			s.ServiceAuth.Handler(http.StripPrefix(pre, s.Controller.HTTPHandler())))
 	}
 
 	// Computers.
@@ -345,7 +464,7 @@ func (s *ServiceManager) buildRouter() *mux.Router {
 
 		pre := "/" + string(k)
 		router.PathPrefix(pre + "/").Handler(
-			http.StripPrefix(pre, serviceState.service.HTTPHandler()))
+			s.ServiceAuth.Handler(http.StripPrefix(pre, serviceState.service.HTTPHandler())))
 	}
 
 	// Queryer.
@@ -378,6 +497,27 @@ type MultiService interface {
 	Key() ServiceKey
 }
 
+// Drainer is implemented by any service (or service component) which has
+// outstanding work that must be completed before it is stopped. Drain should
+// stop accepting new work, wait for in-flight work to finish (or for ctx to be
+// done), and flush anything which has been buffered.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// stageDrainer is implemented by services which contain components
+// participating in more than one shutdown stage. For example, a computer
+// service contains its own writelogger and snapshotter. Drainer returns nil if
+// the service has nothing to drain for the given stage.
+type stageDrainer interface {
+	Drainer(prefix string) Drainer
+}
+
+// drainerFunc is a function which implements the Drainer interface.
+type drainerFunc func(ctx context.Context) error
+
+func (f drainerFunc) Drain(ctx context.Context) error { return f(ctx) }
+
 type ControllerService interface {
 	Service
 }
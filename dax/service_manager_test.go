@@ -0,0 +1,83 @@
+package dax_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probedComputer is a ComputerService whose Health blocks until release is
+// closed, counting the probes made.
+type probedComputer struct {
+	key     dax.ServiceKey
+	probes  int32
+	release chan struct{}
+}
+
+func (c *probedComputer) Start() error                    { return nil }
+func (c *probedComputer) Stop() error                     { return nil }
+func (c *probedComputer) Address() dax.Address            { return "" }
+func (c *probedComputer) HTTPHandler() http.Handler       { return http.NotFoundHandler() }
+func (c *probedComputer) SetKey(key dax.ServiceKey)       { c.key = key }
+func (c *probedComputer) Key() dax.ServiceKey             { return c.key }
+func (c *probedComputer) SetController(dax.Address) error { return nil }
+
+func (c *probedComputer) Health() error {
+	atomic.AddInt32(&c.probes, 1)
+	<-c.release
+	return errors.New(errors.ErrUncoded, "disk full")
+}
+
+func TestServiceManagerHealth(t *testing.T) {
+	const n = 10
+	check := func(t *testing.T, coalesce bool) (int32, []int) {
+		t.Helper()
+		c := &probedComputer{release: make(chan struct{})}
+		s := dax.NewServiceManager()
+		s.CoalesceHealthChecks = coalesce
+		require.NoError(t, s.ComputerStart(s.AddComputer(c)))
+		h := s.HTTPHandler()
+
+		codes := make([]int, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+				codes[i] = w.Code
+			}(i)
+		}
+		// Give every check time to arrive before the probe finishes.
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&c.probes) > 0 }, time.Second, time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		close(c.release)
+		wg.Wait()
+		return atomic.LoadInt32(&c.probes), codes
+	}
+
+	t.Run("Coalesced", func(t *testing.T) {
+		probes, codes := check(t, true)
+		assert.EqualValues(t, 1, probes)
+		for _, code := range codes {
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+		}
+	})
+
+	t.Run("NotCoalesced", func(t *testing.T) {
+		probes, codes := check(t, false)
+		assert.EqualValues(t, n, probes)
+		for _, code := range codes {
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+		}
+	})
+}
@@ -1,10 +1,42 @@
 package dax
 
+// SnapshotPriority determines which resource budget a shard snapshot is taken
+// under.
+type SnapshotPriority string
+
+const (
+	// SnapshotPriorityUrgent snapshots are taken as fast as possible. This
+	// is the priority of snapshots requested explicitly, and of requests
+	// which don't specify a priority.
+	SnapshotPriorityUrgent SnapshotPriority = "urgent"
+
+	// SnapshotPriorityBackground snapshots are taken within the compute
+	// node's snapshot budget, so that they don't compete with queries for
+	// disk and CPU. This is the priority of scheduled snapshots.
+	SnapshotPriorityBackground SnapshotPriority = "background"
+)
+
+// Valid returns an error if p isn't a known priority. The empty priority is
+// valid, and means SnapshotPriorityUrgent.
+func (p SnapshotPriority) Valid() error {
+	switch p {
+	case "", SnapshotPriorityUrgent, SnapshotPriorityBackground:
+		return nil
+	}
+	return NewErrInvalidSnapshotPriority(p)
+}
+
 type SnapshotShardDataRequest struct {
 	Address Address `json:"address"`
 
 	TableKey TableKey `json:"table-key"`
 	ShardNum ShardNum `json:"shard"`
+
+	Priority SnapshotPriority `json:"priority,omitempty"`
+
+	// Force has the shard snapshotted even if it hasn't been written to
+	// since its latest snapshot, replacing that snapshot with a new one.
+	Force bool `json:"force,omitempty"`
 }
 
 type SnapshotTableKeysRequest struct {
@@ -0,0 +1,60 @@
+package snapshotter
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// ObjectStore is a store of whole objects, each named by a bucket and a key.
+// Besides holding snapshots, it's where the Queryer exports query results.
+type ObjectStore interface {
+	// Put writes the object read from r to bucket and key, replacing any
+	// object already there. An object is written in full or not at all: if
+	// Put fails, including because reading r fails, nothing is left at
+	// bucket and key, and any object which was already there is unchanged.
+	Put(ctx context.Context, bucket, key string, r io.Reader) error
+}
+
+// Put writes the object read from r to the file at bucket and key, relative
+// to the Snapshotter's data directory. Like a snapshot, the object is staged
+// in full before it's moved into place.
+func (s *Snapshotter) Put(ctx context.Context, bucket, key string, r io.Reader) error {
+	name := path.Join(bucket, key)
+	if !fs.ValidPath(name) || strings.HasPrefix(name, ".") || bucket == "" || key == "" {
+		return errors.Errorf("invalid object name: %s/%s", bucket, key)
+	}
+	return s.put(name, r, -1)
+}
+
+// S3Store is an ObjectStore backed by Amazon S3, or by any store with an
+// S3-compatible API, such as Google Cloud Storage. Large objects are uploaded
+// in parts; if any part fails, the upload is aborted, so that S3 discards the
+// parts already uploaded.
+type S3Store struct {
+	uploader *s3manager.Uploader
+}
+
+// NewS3Store returns an S3Store which uploads objects with client.
+func NewS3Store(client s3iface.S3API) *S3Store {
+	return &S3Store{
+		uploader: s3manager.NewUploaderWithClient(client),
+	}
+}
+
+// Put uploads the object read from r to bucket and key.
+func (s *S3Store) Put(ctx context.Context, bucket, key string, r io.Reader) error {
+	_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	})
+	return errors.Wrapf(err, "uploading object: %s/%s", bucket, key)
+}
@@ -3,6 +3,7 @@ package snapshotter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
@@ -26,6 +27,16 @@ type Snapshotter struct {
 
 	dataDir string
 
+	// draining is set once Drain has been called; after that, no new
+	// snapshots are accepted. inflight tracks the snapshot writes which were
+	// started before draining was set.
+	draining bool
+	inflight sync.WaitGroup
+
+	// staging configures the directory in which snapshots are written
+	// before being moved into dataDir.
+	staging Staging
+
 	logger logger.Logger
 }
 
@@ -42,19 +53,62 @@ func (s *Snapshotter) SetLogger(l logger.Logger) {
 }
 
 func (s *Snapshotter) Write(bucket string, key string, version int, rc io.ReadCloser) error {
-	fKey := fullKey(bucket, key, version)
-	snapshotFile, err := s.snapshotFileByKey(fKey)
+	defer rc.Close()
+	return s.write(bucket, key, version, rc, s.estimateSize(bucket, key))
+}
+
+// write stages the snapshot read from r and then moves it into place. size is
+// the expected size of the snapshot, or -1 if it's unknown.
+func (s *Snapshotter) write(bucket string, key string, version int, r io.Reader, size int64) error {
+	return s.put(fullKey(bucket, key, version), r, size)
+}
+
+// put stages the file read from r and then moves it into place at key,
+// relative to the data directory. size is the expected size of the file, or
+// -1 if it's unknown.
+func (s *Snapshotter) put(fKey string, r io.Reader, size int64) error {
+	s.mu.RLock()
+	if s.draining {
+		s.mu.RUnlock()
+		return dax.NewErrDraining(dax.ServicePrefixSnapshotter)
+	}
+	s.inflight.Add(1)
+	s.mu.RUnlock()
+	defer s.inflight.Done()
+
+	staged, err := s.stage(s.stagingConfig(), r, size)
 	if err != nil {
-		return errors.Wrapf(err, "shapshotting file by key: %s", fKey)
+		return errors.Wrap(err, "staging snapshot")
 	}
-	defer snapshotFile.Close()
 
-	defer rc.Close()
-	if _, err := snapshotFile.ReadFrom(rc); err != nil {
-		return errors.Wrap(err, "reading from shapshot file")
+	filePath, err := s.snapshotPathByKey(fKey)
+	if err != nil {
+		os.Remove(staged)
+		return errors.Wrapf(err, "shapshotting file by key: %s", fKey)
 	}
 
-	return snapshotFile.Sync()
+	return install(staged, filePath)
+}
+
+// Drain stops the Snapshotter from accepting new snapshots and waits for any
+// in-flight snapshot writes to be finalized.
+func (s *Snapshotter) Drain(ctx context.Context) error {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for in-flight snapshots")
+	case <-done:
+		return nil
+	}
 }
 
 func (s *Snapshotter) List(bucket, key string) ([]computer.SnapInfo, error) {
@@ -73,8 +127,13 @@ func (s *Snapshotter) List(bucket, key string) ([]computer.SnapInfo, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "filename '%s' could not be parsed to version number", entry.Name())
 		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting info of snapshot '%s'", entry.Name())
+		}
 		snaps[i] = computer.SnapInfo{
 			Version: int(version),
+			Date:    info.ModTime(),
 		}
 	}
 	return snaps, nil
@@ -101,7 +160,7 @@ func (s *Snapshotter) WriteTo(bucket string, key string, version int, wrTo io.Wr
 	if _, err := wrTo.WriteTo(buf); err != nil {
 		return errors.Wrap(err, "writing to buffer")
 	}
-	return s.Write(bucket, key, version, io.NopCloser(buf))
+	return s.write(bucket, key, version, buf, int64(buf.Len()))
 }
 
 // paths takes a key and returns the full file path (including the root data
@@ -113,10 +172,9 @@ func (s *Snapshotter) paths(key string) (string, string) {
 	return dirPath, filePath
 }
 
-// snapshotFileByKey returns a pointer to the file specified by key. If the file
-// does not exist, the file is created (along with any directories in which the
-// file is nested).
-func (s *Snapshotter) snapshotFileByKey(key string) (*os.File, error) {
+// snapshotPathByKey returns the path of the file specified by key, creating
+// any directories in which the file is nested.
+func (s *Snapshotter) snapshotPathByKey(key string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -124,16 +182,10 @@ func (s *Snapshotter) snapshotFileByKey(key string) (*os.File, error) {
 
 	// make directories
 	if err := os.MkdirAll(dirPath, 0777); err != nil {
-		return nil, errors.Wrapf(err, "making directory: %s", dirPath)
+		return "", errors.Wrapf(err, "making directory: %s", dirPath)
 	}
 
-	// open snapshot file
-	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, errors.Wrapf(err, "opening shapshot file: %s", filePath)
-	}
-
-	return f, nil
+	return filePath, nil
 }
 
 func (s *Snapshotter) DeleteTable(qtid dax.QualifiedTableID) error {
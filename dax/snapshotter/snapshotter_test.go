@@ -0,0 +1,93 @@
+package snapshotter_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotterStaging(t *testing.T) {
+	dataDir := t.TempDir()
+	stagingDir := t.TempDir()
+
+	ss := snapshotter.New(dataDir, logger.NopLogger)
+	ss.SetStaging(snapshotter.Staging{Dir: stagingDir})
+
+	t.Run("Write", func(t *testing.T) {
+		require.NoError(t, ss.Write("bucket", "shard/0", 1, io.NopCloser(strings.NewReader("snapshot data"))))
+
+		rc, err := ss.Read("bucket", "shard/0", 1)
+		require.NoError(t, err)
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "snapshot data", string(b))
+
+		assertEmpty(t, stagingDir)
+	})
+
+	t.Run("InsufficientStorage", func(t *testing.T) {
+		ss.SetStaging(snapshotter.Staging{Dir: stagingDir, MinFreeBytes: math.MaxUint64 / 2})
+
+		// The previous version gives an estimate for the pre-flight check.
+		err := ss.Write("bucket", "shard/0", 2, io.NopCloser(strings.NewReader("more snapshot data")))
+		assert.True(t, errors.Is(err, dax.ErrInsufficientStorage), "expected insufficient storage, got %v", err)
+
+		// Without a previous version, the write is aborted once it starts.
+		err = ss.Write("bucket", "shard/1", 1, io.NopCloser(strings.NewReader("more snapshot data")))
+		assert.True(t, errors.Is(err, dax.ErrInsufficientStorage), "expected insufficient storage, got %v", err)
+
+		// Nothing is left behind.
+		assertEmpty(t, stagingDir)
+		_, err = os.Stat(path.Join(dataDir, "bucket", "shard/0", "2"))
+		assert.True(t, os.IsNotExist(err))
+		snaps, err := ss.List("bucket", "shard/1")
+		require.NoError(t, err)
+		assert.Empty(t, snaps)
+	})
+}
+
+func TestSnapshotterPut(t *testing.T) {
+	dataDir := t.TempDir()
+	stagingDir := t.TempDir()
+
+	ss := snapshotter.New(dataDir, logger.NopLogger)
+	ss.SetStaging(snapshotter.Staging{Dir: stagingDir})
+	ctx := context.Background()
+
+	require.NoError(t, ss.Put(ctx, "exports", "daily/results.csv", strings.NewReader("a,b\n1,2\n")))
+	b, err := os.ReadFile(path.Join(dataDir, "exports", "daily", "results.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(b))
+
+	// A failed write leaves the object already there unchanged.
+	r := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New(errors.ErrUncoded, "connection reset")))
+	assert.Error(t, ss.Put(ctx, "exports", "daily/results.csv", r))
+	b, err = os.ReadFile(path.Join(dataDir, "exports", "daily", "results.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(b))
+	assertEmpty(t, stagingDir)
+
+	for _, name := range [][2]string{{"exports", "../../escape"}, {"..", "escape"}, {".staging", "x"}, {"exports", ""}, {"", "x"}} {
+		assert.Error(t, ss.Put(ctx, name[0], name[1], strings.NewReader("x")), "%s/%s", name[0], name[1])
+	}
+}
+
+func assertEmpty(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,209 @@
+package snapshotter
+
+import (
+	"io"
+	"os"
+	"path"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// defaultStagingDir is the staging directory, within the data directory, used
+// when Staging.Dir is not set.
+const defaultStagingDir = ".staging"
+
+// stagingCheckBytes is the amount written to a staged snapshot between checks
+// of the free space on the staging disk.
+const stagingCheckBytes = 16 << 20
+
+// Staging configures how the Snapshotter stages snapshots. Every snapshot is
+// written in full to a file in the staging directory, and is only moved into
+// the data directory once it has been synced, so that a failed write never
+// leaves a partial snapshot in place of a good one.
+type Staging struct {
+	// Dir is the staging directory. It may be on a different disk than the
+	// data directory. If empty, a directory named ".staging" within the
+	// data directory is used.
+	Dir string
+
+	// MinFreeBytes is the amount of headroom to reserve on the staging disk.
+	// Before a snapshot is staged, its size is estimated (from the size of
+	// the previous version of the same snapshot), and the snapshot is
+	// rejected if staging it would leave less than MinFreeBytes free. While
+	// the snapshot is written, it's aborted (and the staged file removed) as
+	// soon as free space falls below MinFreeBytes. A value of 0 disables
+	// these checks.
+	MinFreeBytes uint64
+}
+
+// stagingBytes is the number of bytes currently held in staging, across all
+// Snapshotters, as reported by dax.GaugeSnapshotterStagingBytes.
+var stagingBytes int64
+
+func addStagingBytes(n int64) {
+	dax.GaugeSnapshotterStagingBytes.Set(float64(atomic.AddInt64(&stagingBytes, n)))
+}
+
+// SetStaging sets the snapshot staging behavior of the Snapshotter.
+func (s *Snapshotter) SetStaging(g Staging) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staging = g
+}
+
+// stagingConfig returns the staging configuration, with the default
+// directory applied.
+func (s *Snapshotter) stagingConfig() Staging {
+	s.mu.RLock()
+	g := s.staging
+	s.mu.RUnlock()
+	if g.Dir == "" {
+		g.Dir = path.Join(s.dataDir, defaultStagingDir)
+	}
+	return g
+}
+
+// stage writes the contents of r to a new file in the staging directory and
+// syncs it, returning the file's path. size is the expected size of the
+// snapshot, or -1 if it's unknown. If staging fails, nothing is left in the
+// staging directory.
+func (s *Snapshotter) stage(g Staging, r io.Reader, size int64) (_ string, err error) {
+	if err := os.MkdirAll(g.Dir, 0777); err != nil {
+		return "", errors.Wrapf(err, "making staging directory: %s", g.Dir)
+	}
+
+	// Fail early if the snapshot clearly won't fit.
+	if g.MinFreeBytes > 0 && size > 0 {
+		if free, err := freeBytes(g.Dir); err == nil && free < uint64(size)+g.MinFreeBytes {
+			dax.CounterSnapshotterStagingAborts.Inc()
+			return "", dax.NewErrInsufficientStorage(g.Dir, free, uint64(size)+g.MinFreeBytes)
+		}
+	}
+
+	f, err := os.CreateTemp(g.Dir, "snapshot-*")
+	if err != nil {
+		return "", errors.Wrap(err, "creating staging file")
+	}
+	w := &stagingWriter{f: f, dir: g.Dir, minFree: g.MinFreeBytes}
+	defer func() {
+		addStagingBytes(-w.n)
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := io.Copy(w, r); err != nil {
+		return "", errors.Wrap(err, "writing staging file")
+	}
+	if err := f.Sync(); err != nil {
+		return "", errors.Wrap(err, "syncing staging file")
+	}
+	if err := f.Close(); err != nil {
+		return "", errors.Wrap(err, "closing staging file")
+	}
+	return f.Name(), nil
+}
+
+// stagingWriter writes to a staging file, returning an ErrInsufficientStorage
+// error if free space on the staging disk falls below minFree.
+type stagingWriter struct {
+	f       *os.File
+	dir     string
+	minFree uint64
+
+	n         int64 // bytes written
+	checkedAt int64 // value of n at the last check of free space
+}
+
+func (w *stagingWriter) Write(p []byte) (int, error) {
+	if w.minFree > 0 && (w.n == 0 || w.n-w.checkedAt >= stagingCheckBytes) {
+		w.checkedAt = w.n
+		// If free space can't be determined, carry on; the write itself will
+		// fail if the disk is actually full.
+		if free, err := freeBytes(w.dir); err == nil && free < w.minFree {
+			dax.CounterSnapshotterStagingAborts.Inc()
+			return 0, dax.NewErrInsufficientStorage(w.dir, free, w.minFree)
+		}
+	}
+	n, err := w.f.Write(p)
+	w.n += int64(n)
+	addStagingBytes(int64(n))
+	return n, err
+}
+
+// install moves the staged file at staged to filePath, replacing any file
+// already there. If the staging directory is on a different filesystem than
+// filePath, the staged file is first copied alongside filePath. The staged
+// file is removed in either case.
+func install(staged, filePath string) error {
+	err := os.Rename(staged, filePath)
+	if err == nil {
+		return nil
+	}
+	defer os.Remove(staged)
+	if le, ok := err.(*os.LinkError); !ok || le.Err != syscall.EXDEV {
+		return errors.Wrap(err, "moving staged snapshot")
+	}
+
+	src, err := os.Open(staged)
+	if err != nil {
+		return errors.Wrap(err, "opening staged snapshot")
+	}
+	defer src.Close()
+
+	tmpPath := filePath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "creating snapshot file")
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "copying staged snapshot")
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "syncing snapshot file")
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "closing snapshot file")
+	}
+	return errors.Wrap(os.Rename(tmpPath, filePath), "moving snapshot file")
+}
+
+// estimateSize returns the size of the most recent version of the snapshot
+// for bucket and key, or -1 if there isn't one.
+func (s *Snapshotter) estimateSize(bucket, key string) int64 {
+	snaps, err := s.List(bucket, key)
+	if err != nil || len(snaps) == 0 {
+		return -1
+	}
+	latest := snaps[0].Version
+	for _, snap := range snaps[1:] {
+		if snap.Version > latest {
+			latest = snap.Version
+		}
+	}
+	_, filePath := s.paths(fullKey(bucket, key, latest))
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return -1
+	}
+	return fi.Size()
+}
+
+// freeBytes returns the number of bytes free (available to unprivileged
+// users) on the filesystem containing dir.
+func freeBytes(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, errors.Wrapf(err, "statfs: %s", dir)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
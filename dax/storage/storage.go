@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/computer"
+	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
 )
@@ -192,6 +195,30 @@ func (mm *ResourceManager) RemoveAll() error {
 	return nil
 }
 
+// Tables returns the tables for which this ResourceManager holds any
+// resources, ordered by key.
+func (mm *ResourceManager) Tables() []dax.QualifiedTableID {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	seen := make(map[dax.QualifiedTableID]struct{})
+	for k := range mm.shardResources {
+		seen[k.qtid] = struct{}{}
+	}
+	for k := range mm.tableKeyResources {
+		seen[k.qtid] = struct{}{}
+	}
+	for k := range mm.fieldKeyResources {
+		seen[k.qtid] = struct{}{}
+	}
+	qtids := make([]dax.QualifiedTableID, 0, len(seen))
+	for qtid := range seen {
+		qtids = append(qtids, qtid)
+	}
+	sort.Slice(qtids, func(i, j int) bool { return qtids[i].Key() < qtids[j].Key() })
+	return qtids
+}
+
 // RemoveTable, unlocks and removes all resources related to the given
 // table from this ResourceManager. The underlying files are not
 // deleted. (If the table is being dropped, deleting the files is
@@ -409,6 +436,13 @@ func (m *Resource) Append(msg []byte) error {
 	return m.writelogger.AppendMessage(m.bucket, m.key, m.latestWLVersion, msg)
 }
 
+// MarkDirty has the next call to IncrementWLVersion return true even if there
+// have been no writes since the latest snapshot, so that a new snapshot of
+// the resource is taken regardless.
+func (m *Resource) MarkDirty() {
+	m.dirty = true
+}
+
 // IncrementWLVersion should be called during snapshotting with a
 // write Tx held on the local resource. This ensures that any writes
 // which completed prior to the snapshot are in the prior WL and any
@@ -488,6 +522,26 @@ const (
 	keysFileName = "keys"
 )
 
+// LatestShardSnapshot returns the time at which the latest snapshot of the
+// shard in s was written, or false if the shard has none.
+func LatestShardSnapshot(s computer.SnapshotService, table dax.TableKey, shard dax.ShardNum) (time.Time, bool, error) {
+	partition := dax.PartitionNum(disco.ShardToShardPartition(string(table), uint64(shard), disco.DefaultPartitionN))
+	snaps, err := s.List(partitionBucket(table, partition), shardKey(shard))
+	if err != nil {
+		return time.Time{}, false, errors.Wrap(err, "listing snapshots")
+	}
+	if len(snaps) == 0 {
+		return time.Time{}, false, nil
+	}
+	latest := snaps[0]
+	for _, snap := range snaps[1:] {
+		if snap.Version > latest.Version {
+			latest = snap
+		}
+	}
+	return latest.Date, true, nil
+}
+
 func partitionBucket(table dax.TableKey, partition dax.PartitionNum) string {
 	return path.Join(string(table), "partition", fmt.Sprintf("%d", partition))
 }
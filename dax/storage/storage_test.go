@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path"
+	"strconv"
+	"time"
 
 	"testing"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
 	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
+	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/logger"
 	"github.com/stretchr/testify/assert"
 )
@@ -158,3 +162,100 @@ func TestResourceManager(t *testing.T) {
 	assert.Equal(t, 0, n)
 	assert.Equal(t, io.EOF, err)
 }
+
+func TestResourceMarkDirty(t *testing.T) {
+	sdd, err := os.MkdirTemp("", "snaptest*")
+	assert.NoError(t, err)
+	wdd, err := os.MkdirTemp("", "wltest*")
+	assert.NoError(t, err)
+	defer func() {
+		os.RemoveAll(sdd)
+		os.RemoveAll(wdd)
+	}()
+
+	log := logger.NewStandardLogger(os.Stderr)
+	sn := snapshotter.New(sdd, log)
+	wl := writelogger.New(wdd, log)
+
+	qtid := dax.NewQualifiedTableID(dax.NewQualifiedDatabaseID("org1", "db1"), "blah")
+	resource := NewResourceManager(sn, wl, log).GetShardResource(qtid, dax.PartitionNum(1), dax.ShardNum(1))
+
+	_, err = resource.LoadLatestSnapshot()
+	assert.NoError(t, err)
+	_, err = resource.LoadWriteLog()
+	assert.NoError(t, err)
+	assert.NoError(t, resource.Lock())
+	defer func() {
+		assert.NoError(t, resource.Unlock())
+	}()
+
+	// with no writes, there's nothing to snapshot...
+	ok, err := resource.IncrementWLVersion()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// ...unless the resource is marked dirty.
+	resource.MarkDirty()
+	ok, err = resource.IncrementWLVersion()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, resource.Snapshot(io.NopCloser(bytes.NewBufferString("hahaha"))))
+
+	resource2 := NewResourceManager(sn, wl, log).GetShardResource(qtid, dax.PartitionNum(1), dax.ShardNum(1))
+	d, err := resource2.LoadLatestSnapshot()
+	assert.NoError(t, err)
+	buf, err := io.ReadAll(d)
+	assert.NoError(t, err)
+	assert.Equal(t, "hahaha", string(buf))
+	assert.NoError(t, d.Close())
+}
+
+func TestLatestShardSnapshot(t *testing.T) {
+	sdd, err := os.MkdirTemp("", "snaptest*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(sdd)
+
+	sn := snapshotter.New(sdd, logger.NopLogger)
+	tkey := dax.NewQualifiedTableID(dax.NewQualifiedDatabaseID("org1", "db1"), "blah").Key()
+	shard := dax.ShardNum(3)
+
+	_, ok, err := LatestShardSnapshot(sn, tkey, shard)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	partition := dax.PartitionNum(disco.ShardToShardPartition(string(tkey), uint64(shard), disco.DefaultPartitionN))
+	bucket, key := partitionBucket(tkey, partition), shardKey(shard)
+	taken := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	for version, mtime := range map[int]time.Time{1: taken.Add(-time.Hour), 2: taken} {
+		assert.NoError(t, sn.Write(bucket, key, version, io.NopCloser(bytes.NewBufferString("snap"))))
+		assert.NoError(t, os.Chtimes(path.Join(sdd, fullKey(bucket, key, version)), mtime, mtime))
+	}
+
+	got, ok, err := LatestShardSnapshot(sn, tkey, shard)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, taken.Equal(got), "got %v, want %v", got, taken)
+}
+
+func TestResourceManagerTables(t *testing.T) {
+	mm := NewResourceManager(nil, nil, logger.NopLogger)
+	assert.Empty(t, mm.Tables())
+
+	qdbid := dax.NewQualifiedDatabaseID("org1", "db1")
+	a := dax.NewQualifiedTableID(qdbid, "a")
+	b := dax.NewQualifiedTableID(qdbid, "b")
+	c := dax.NewQualifiedTableID(qdbid, "c")
+	mm.GetShardResource(b, 1, 1)
+	mm.GetShardResource(b, 1, 2)
+	mm.GetTableKeyResource(a, 1)
+	mm.GetFieldKeyResource(c, "f")
+	assert.Equal(t, []dax.QualifiedTableID{a, b, c}, mm.Tables())
+
+	mm.RemoveShardResource(b, 1, 1)
+	mm.RemoveShardResource(b, 1, 2)
+	assert.Equal(t, []dax.QualifiedTableID{a, c}, mm.Tables())
+}
+
+func fullKey(bucket, key string, version int) string {
+	return path.Join(bucket, key, strconv.Itoa(version))
+}
@@ -2,6 +2,9 @@ package dax
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -378,6 +381,11 @@ type Table struct {
 	Fields     []*Field  `json:"fields"`
 	PartitionN int       `json:"partitionN"`
 
+	// SnapshotMaxAge is the age beyond which the Controller replaces the
+	// latest snapshot of the table's shards, whether or not they've been
+	// written to since. If it's zero, the Controller's default applies.
+	SnapshotMaxAge time.Duration `json:"snapshotMaxAge,omitempty"`
+
 	Description string `json:"description,omitempty"`
 	Owner       string `json:"owner,omitempty"`
 	UpdatedBy   string `json:"updatedBy,omitempty"`
@@ -387,6 +395,21 @@ func (t *Table) Key() TableKey {
 	return TableKey(t.ID)
 }
 
+// Version returns a string which identifies the current schema of the table.
+// It covers the name, type, options and revision of each field, so it changes
+// whenever a field is added to, or removed from, the table (including when a
+// field is dropped and added again just as it was), and can be used to detect
+// concurrent schema changes.
+func (t *Table) Version() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", t.ID)
+	for _, fld := range t.Fields {
+		opts, _ := json.Marshal(fld.Options) //nolint:errchkjson
+		fmt.Fprintf(h, "%s %s %d %s\n", fld.Name, fld.FullType(), fld.Revision, opts)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
 // CreateID generates a unique identifier for Table. If Table has already been
 // assigned an ID, then an error is returned.
 func (t *Table) CreateID() (TableID, error) {
@@ -498,6 +521,9 @@ func (t *Table) CreateSQL() string {
 	sql += strings.Join(cols, ", ")
 
 	sql += fmt.Sprintf(") KEYPARTITIONS %d", t.PartitionN)
+	if t.SnapshotMaxAge > 0 {
+		sql += fmt.Sprintf(" MAXSNAPSHOTAGE '%s'", t.SnapshotMaxAge)
+	}
 
 	return sql
 }
@@ -733,6 +759,12 @@ type Field struct {
 	Type    BaseType     `json:"type"`
 	Options FieldOptions `json:"options"`
 
+	// Revision is the revision of the table's schema at which the field was
+	// added. A table's revision increases with every field added to or
+	// dropped from it, so a field which is dropped and added again gets a
+	// new revision.
+	Revision int64 `json:"revision,omitempty"`
+
 	CreatedAt int64 `json:"createdAt,omitempty"`
 }
 
@@ -824,4 +856,187 @@ type FieldOptions struct {
 	TTL            time.Duration `json:"ttl,omitempty"`
 	ForeignIndex   string        `json:"foreign-index,omitempty"`
 	TrackExistence bool          `json:"track-existence"`
+
+	// Default, Computed, and NotNull are applied by the queryer to new records
+	// written with SQL (INSERT, BULK INSERT, and UPSERT of a record which
+	// doesn't exist yet) which omit the field. They're SQL-only: records
+	// written by PQL, the import endpoints, or idk are stored as given. At
+	// most one of Default and Computed may be set; see
+	// Table.ValidateFieldDefault for the values each supports.
+	Default  string `json:"default,omitempty"`
+	Computed string `json:"computed,omitempty"`
+	// NotNull rejects records which, after defaults and computed values have
+	// been applied, have no value for the field.
+	NotNull bool `json:"not-null,omitempty"`
+
+	// Encryption, if set, is the mode (FieldEncryptionDeterministic or
+	// FieldEncryptionRandomized) in which the queryer encrypts the field's
+	// values before they're stored, and decrypts them when they're read by an
+	// organization permitted to read them; see Field.ValidateEncryption.
+	Encryption string `json:"encryption,omitempty"`
+}
+
+// Field encryption modes. The values of an encrypted field are encrypted by
+// the queryer, with a key derived for the field from the queryer's data key,
+// before they're sent to the computers, so neither the translation stores, the
+// write logs, nor the snapshots hold them in plaintext.
+//
+// With FieldEncryptionDeterministic, equal values have equal ciphertexts, so
+// equality (=, IN, SETCONTAINS and friends), GROUP BY, DISTINCT and COUNT
+// DISTINCT work as they do on an unencrypted field; the stored form reveals
+// which records share a value, but not the value. Filters which compare the
+// stored form of a value in any other way (PQL's Rows(like=...)) are not
+// supported. Filters evaluated by the queryer after values are read
+// (LIKE, string functions) work, but can't be pushed down to the computers.
+//
+// With FieldEncryptionRandomized, each value written has a different
+// ciphertext, so the stored form reveals nothing about the values, but they
+// can only be read: filtering on the field's values is not supported, and
+// GROUP BY and DISTINCT produce one group for each record written rather
+// than for each value. Since no two writes share a stored value, the field's
+// translation store grows with every record written.
+//
+// Only callers from the organizations configured in the queryer's
+// DecryptOrganizations read the values of encrypted fields, whichever
+// organization owns the table; others read their ciphertexts.
+//
+// Both modes add a schema lookup per table to each query, and a cipher
+// operation per distinct value to each translation of the field's values.
+// Reads of many distinct values of an encrypted field are therefore bound by
+// the queryer's CPU rather than by the computers.
+const (
+	FieldEncryptionDeterministic = "deterministic"
+	FieldEncryptionRandomized    = "randomized"
+)
+
+// ComputedCurrentTimestamp is the computed expression which populates a
+// timestamp field with the time at which the record was ingested.
+const ComputedCurrentTimestamp = "CURRENT_TIMESTAMP"
+
+// ValidateFieldDefault returns an error if the Default or Computed options of
+// fld, which is (or is being added as) a field in t, are not supported.
+//
+// Default is a literal value, written as it would be in a CSV file: an
+// integer for int and id fields, a decimal number for decimal fields, "true"
+// or "false" for bool fields, an RFC 3339 time for timestamp fields, and a
+// comma-separated list of members for idset and stringset fields. Any string
+// is a valid default for a string field. Defaults are not supported on fields
+// with a time quantum.
+//
+// Computed is one of the following expressions:
+//
+//   - CURRENT_TIMESTAMP, on a timestamp field: the time at which the record is
+//     ingested.
+//   - The name of another field of the same type (which is not itself
+//     computed): a copy of that field's value in the same record.
+func (t *Table) ValidateFieldDefault(fld *Field) error {
+	opts := fld.Options
+	if opts.Default == "" && opts.Computed == "" {
+		return nil
+	}
+	if opts.Default != "" && opts.Computed != "" {
+		return errors.Errorf("field cannot have both a default and a computed value")
+	}
+	if fld.IsPrimaryKey() {
+		return errors.Errorf("primary key cannot have a default or computed value")
+	}
+
+	if opts.Default != "" {
+		_, err := fld.DefaultValue()
+		return err
+	}
+
+	if strings.EqualFold(opts.Computed, ComputedCurrentTimestamp) {
+		if fld.Type != BaseTypeTimestamp {
+			return errors.Errorf("%s is only supported on timestamp fields", ComputedCurrentTimestamp)
+		}
+		return nil
+	}
+	src, ok := t.Field(FieldName(opts.Computed))
+	switch {
+	case !ok:
+		return errors.Errorf("computed value refers to unknown field: %s", opts.Computed)
+	case src.Name == fld.Name:
+		return errors.Errorf("computed value cannot refer to its own field")
+	case src.Type != fld.Type:
+		return errors.Errorf("computed value refers to field %s of type %s, expected %s", src.Name, src.Type, fld.Type)
+	case src.Options.Computed != "":
+		return errors.Errorf("computed value refers to computed field: %s", src.Name)
+	}
+	return nil
+}
+
+// ValidateEncryption returns an error if the Encryption option of f is not
+// supported. Encryption is supported on string and stringset fields, other
+// than the primary key.
+func (f *Field) ValidateEncryption() error {
+	switch f.Options.Encryption {
+	case "":
+		return nil
+	case FieldEncryptionDeterministic, FieldEncryptionRandomized:
+	default:
+		return errors.Errorf("invalid encryption mode '%s': expected '%s' or '%s'", f.Options.Encryption, FieldEncryptionDeterministic, FieldEncryptionRandomized)
+	}
+	if f.IsPrimaryKey() {
+		return errors.Errorf("primary key cannot be encrypted")
+	}
+	switch f.Type {
+	case BaseTypeString, BaseTypeStringSet, BaseTypeStringSetQ:
+	default:
+		return errors.Errorf("encryption is not supported on %s fields", f.Type)
+	}
+	return nil
+}
+
+// DefaultValue parses the field's Default option. The value returned is an
+// int64 (for int and id fields), pql.Decimal, bool, string, time.Time, []int64
+// (for idset fields), or []string (for stringset fields). It returns nil if
+// the field has no default.
+func (f *Field) DefaultValue() (interface{}, error) {
+	v := f.Options.Default
+	if v == "" {
+		return nil, nil
+	}
+
+	var val interface{}
+	var err error
+	switch f.Type {
+	case BaseTypeInt, BaseTypeID:
+		val, err = strconv.ParseInt(v, 10, 64)
+		if err == nil && f.Type == BaseTypeID && val.(int64) < 0 {
+			err = errors.Errorf("id cannot be negative")
+		}
+	case BaseTypeDecimal:
+		val, err = pql.ParseDecimal(v)
+	case BaseTypeBool:
+		val, err = strconv.ParseBool(v)
+	case BaseTypeString:
+		val = v
+	case BaseTypeTimestamp:
+		val, err = time.Parse(time.RFC3339Nano, v)
+	case BaseTypeIDSet:
+		members := strings.Split(v, ",")
+		ids := make([]int64, len(members))
+		for i, m := range members {
+			if ids[i], err = strconv.ParseInt(strings.TrimSpace(m), 10, 64); err != nil {
+				break
+			} else if ids[i] < 0 {
+				err = errors.Errorf("id cannot be negative")
+				break
+			}
+		}
+		val = ids
+	case BaseTypeStringSet:
+		members := strings.Split(v, ",")
+		for i := range members {
+			members[i] = strings.TrimSpace(members[i])
+		}
+		val = members
+	default:
+		return nil, errors.Errorf("defaults are not supported on %s fields", f.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid default for %s field: %s", f.Type, v)
+	}
+	return val, nil
 }
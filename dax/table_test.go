@@ -264,6 +264,47 @@ func TestTable(t *testing.T) {
 			assert.Equal(t, tableName, n.Name)
 		})
 
+		t.Run("Version", func(t *testing.T) {
+			n := dax.NewTable(tableName)
+			n.CreateID()
+			v0 := n.Version()
+
+			n.Fields = append(n.Fields, &dax.Field{Name: "a", Type: dax.BaseTypeInt})
+			v1 := n.Version()
+			assert.NotEqual(t, v0, v1)
+
+			// Removing the field returns the table to its original version.
+			n.Fields = n.Fields[:0]
+			assert.Equal(t, v0, n.Version())
+		})
+
+		t.Run("VersionOptions", func(t *testing.T) {
+			n := dax.NewTable(tableName)
+			n.CreateID()
+			n.Fields = append(n.Fields, &dax.Field{Name: "a", Type: dax.BaseTypeInt})
+			v0 := n.Version()
+
+			// Changing any of a field's options changes the version.
+			n.Fields[0].Options.Max = pql.NewDecimal(100, 0)
+			v1 := n.Version()
+			assert.NotEqual(t, v0, v1)
+
+			n.Fields[0].Options.NotNull = true
+			assert.NotEqual(t, v1, n.Version())
+		})
+
+		t.Run("VersionRevision", func(t *testing.T) {
+			n := dax.NewTable(tableName)
+			n.CreateID()
+			n.Fields = append(n.Fields, &dax.Field{Name: "a", Type: dax.BaseTypeInt, Revision: 1})
+			v0 := n.Version()
+
+			// A field dropped and added again, with the same name, type and
+			// options, has a new revision, so the table has a new version.
+			n.Fields[0] = &dax.Field{Name: "a", Type: dax.BaseTypeInt, Revision: 3}
+			assert.NotEqual(t, v0, n.Version())
+		})
+
 		t.Run("ToJSON", func(t *testing.T) {
 			t.Run("WithID", func(t *testing.T) {
 				n := dax.NewTable(tableName)
@@ -370,6 +411,65 @@ func TestTable(t *testing.T) {
 			assert.Equal(t, dbID, qtbl.Qualifier().DatabaseID)
 		})
 	})
+
+	t.Run("FieldDefaults", func(t *testing.T) {
+		tbl := &dax.Table{
+			Name: tableName,
+			Fields: []*dax.Field{
+				{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID},
+				{Name: "region", Type: dax.BaseTypeString, Options: dax.FieldOptions{Default: "us-east"}},
+				{Name: "tags", Type: dax.BaseTypeIDSet, Options: dax.FieldOptions{Default: "1, 2,3"}},
+				{Name: "ingested", Type: dax.BaseTypeTimestamp, Options: dax.FieldOptions{Computed: "current_timestamp"}},
+				{Name: "home", Type: dax.BaseTypeString, Options: dax.FieldOptions{Computed: "region"}},
+				{Name: "score", Type: dax.BaseTypeInt},
+			},
+		}
+		for _, fld := range tbl.Fields {
+			assert.NoError(t, tbl.ValidateFieldDefault(fld), "field %s", fld.Name)
+		}
+
+		val, err := tbl.Fields[2].DefaultValue()
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, val)
+
+		val, err = tbl.Fields[5].DefaultValue()
+		assert.NoError(t, err)
+		assert.Nil(t, val)
+
+		for _, fld := range []*dax.Field{
+			{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID, Options: dax.FieldOptions{Default: "1"}},
+			{Name: "a", Type: dax.BaseTypeInt, Options: dax.FieldOptions{Default: "one"}},
+			{Name: "a", Type: dax.BaseTypeIDSet, Options: dax.FieldOptions{Default: "1,-2"}},
+			{Name: "a", Type: dax.BaseTypeTimestamp, Options: dax.FieldOptions{Default: "yesterday"}},
+			{Name: "a", Type: dax.BaseTypeStringSetQ, Options: dax.FieldOptions{Default: "x"}},
+			{Name: "a", Type: dax.BaseTypeInt, Options: dax.FieldOptions{Default: "1", Computed: "score"}},
+			{Name: "a", Type: dax.BaseTypeInt, Options: dax.FieldOptions{Computed: "current_timestamp"}},
+			{Name: "a", Type: dax.BaseTypeInt, Options: dax.FieldOptions{Computed: "region"}},
+			{Name: "a", Type: dax.BaseTypeString, Options: dax.FieldOptions{Computed: "home"}},
+			{Name: "a", Type: dax.BaseTypeString, Options: dax.FieldOptions{Computed: "missing"}},
+			{Name: "a", Type: dax.BaseTypeString, Options: dax.FieldOptions{Computed: "a"}},
+		} {
+			assert.Error(t, tbl.ValidateFieldDefault(fld), "options %+v", fld.Options)
+		}
+	})
+
+	t.Run("FieldEncryption", func(t *testing.T) {
+		for _, fld := range []*dax.Field{
+			{Name: "a", Type: dax.BaseTypeInt},
+			{Name: "a", Type: dax.BaseTypeString, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionDeterministic}},
+			{Name: "a", Type: dax.BaseTypeStringSet, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionRandomized}},
+			{Name: "a", Type: dax.BaseTypeStringSetQ, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionDeterministic}},
+		} {
+			assert.NoError(t, fld.ValidateEncryption(), "field %+v", fld)
+		}
+		for _, fld := range []*dax.Field{
+			{Name: "a", Type: dax.BaseTypeString, Options: dax.FieldOptions{Encryption: "rot13"}},
+			{Name: "a", Type: dax.BaseTypeInt, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionDeterministic}},
+			{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeString, Options: dax.FieldOptions{Encryption: dax.FieldEncryptionDeterministic}},
+		} {
+			assert.Error(t, fld.ValidateEncryption(), "field %+v", fld)
+		}
+	})
 }
 
 func TestDatabase(t *testing.T) {
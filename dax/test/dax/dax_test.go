@@ -23,6 +23,7 @@ import (
 	"github.com/featurebasedb/featurebase/v3/dax/server/test"
 	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/test/defs"
 	goerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -762,6 +763,50 @@ func TestDAXIntegration(t *testing.T) {
 	// These tests are to test the traversal of CodedErrors across HTTP
 	// The client is also wrapped to maintain the integrity of the interface to prevent any
 	// additional methods added to the client without the appropriate tests for CodedErrors
+	t.Run("MaterializedViewRefresh", func(t *testing.T) {
+		mc := test.MustRunManagedCommand(t)
+		defer mc.Close()
+
+		computerKey0 := dax.ServiceKey(dax.ServicePrefixComputer + "0")
+		mc.WaitForApplied(t, computerKey0, 60, time.Second)
+
+		svcmgr := mc.Manage()
+
+		// Set up Controller client.
+		controllerClient := controllerclient.New(svcmgr.Controller.Address(), svcmgr.Logger)
+
+		// Create database.
+		qdb.Options.WorkersMin = 1
+		qdb.Options.WorkersMax = 1
+		assert.NoError(t, controllerClient.CreateDatabase(context.Background(), qdb))
+
+		queryerAddr := svcmgr.Queryer.Address()
+		mustSQL := func(sql string) *featurebase.WireQueryResponse {
+			t.Helper()
+			resp := runSQL(t, queryerAddr, qdbid, sql)
+			require.Empty(t, resp.Error)
+			return resp
+		}
+		count := func() string {
+			resp, err := getClient(queryerAddr).QuerySQL(context.Background(), qdbid, strings.NewReader("select count(*) from mvrefresh_view"))
+			if err != nil || resp.Error != "" || len(resp.Data) != 1 {
+				return ""
+			}
+			return fmt.Sprint(resp.Data[0][0])
+		}
+
+		mustSQL("create table mvrefresh (_id id, a int)")
+		mustSQL("insert into mvrefresh (_id, a) values (1, 10), (2, 20)")
+		mustSQL("create materialized view mvrefresh_view with refresh '1s' as select _id, a from mvrefresh")
+		require.Equal(t, "2", count())
+
+		// The queryer refreshes the view in the background once it's due.
+		mustSQL("insert into mvrefresh (_id, a) values (3, 30)")
+		require.Eventually(t, func() bool {
+			return count() == "3"
+		}, 3*sql3.ViewRefreshCheckInterval, time.Second)
+	})
+
 	t.Run("HTTPError", func(t *testing.T) {
 		mc := test.MustRunManagedCommand(t)
 		defer mc.Close()
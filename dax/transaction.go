@@ -42,8 +42,29 @@ type txFunc func(tx Transaction, writable bool) error
 // whichever comes first. If writable is set to true, RetryWithTx will use a
 // writable transaction for each try, and attempt to Commit the transaction. If
 // the transaction fails with an error related to invalid serialization, and
-// there are still tries remaining, the transaction will be retried.
+// there are still tries remaining, the transaction will be retried. If no
+// tries remain, an ErrTransactionConflict error is returned.
+//
+// Because each try calls fn with a new transaction, fn must read any state it
+// depends on from the transaction it's given; it must not rely on values read
+// in a previous try. Under that condition, retrying is safe: fn is re-applied
+// to the current state as if it had run alone.
 func RetryWithTx(ctx context.Context, trans Transactor, fn txFunc, writable bool, maxTries int) error {
+	return retryWithTx(ctx, trans, fn, writable, writable, maxTries)
+}
+
+// DryRunWithTx is like RetryWithTx with a writable transaction, except that
+// the transaction is always rolled back, never committed, so that fn can make
+// changes to see their effect without those changes being applied. Any side
+// effects of fn outside of the transaction are not undone; fn must avoid
+// them.
+func DryRunWithTx(ctx context.Context, trans Transactor, fn txFunc, maxTries int) error {
+	return retryWithTx(ctx, trans, fn, true, false, maxTries)
+}
+
+// retryWithTx implements RetryWithTx and DryRunWithTx. The transaction is
+// committed only if commit is true.
+func retryWithTx(ctx context.Context, trans Transactor, fn txFunc, writable bool, commit bool, maxTries int) error {
 	// stopRetry can be set to true to abort the retry loop. This is useful when
 	// a transaction completes successfully, but maxTries has not been reached;
 	// i.e, because the transaction succeeded, there's no reason to keep trying.
@@ -67,7 +88,7 @@ func RetryWithTx(ctx context.Context, trans Transactor, fn txFunc, writable bool
 				return errors.Wrapf(err, "calling function with tx, writable: %v", writable)
 			}
 
-			if writable {
+			if commit {
 				if err := tx.Commit(); err != nil {
 					return errors.Wrap(err, "committing tx")
 				}
@@ -78,11 +99,13 @@ func RetryWithTx(ctx context.Context, trans Transactor, fn txFunc, writable bool
 		}(); err != nil {
 			// If we get a serialization error, and we still have some write
 			// attempts remaining, then continue trying.
-			if maxTries > 0 && containsAny(err.Error(), []string{
-				postgresTxConflictError,
-				postgresDuplicateKeyContraint,
-			}) {
-				continue
+			if isTxConflict(err) {
+				CounterTxConflicts.Inc()
+				if maxTries > 0 {
+					CounterTxRetries.Inc()
+					continue
+				}
+				return NewErrTransactionConflict(err.Error())
 			}
 			return err
 		}
@@ -91,6 +114,14 @@ func RetryWithTx(ctx context.Context, trans Transactor, fn txFunc, writable bool
 	return nil
 }
 
+// isTxConflict returns true if err was caused by a concurrent transaction.
+func isTxConflict(err error) bool {
+	return containsAny(err.Error(), []string{
+		postgresTxConflictError,
+		postgresDuplicateKeyContraint,
+	})
+}
+
 // containsAny returns true if s contains at least one of the strings in
 // substrs.
 func containsAny(s string, substrs []string) bool {
@@ -0,0 +1,138 @@
+package writelogger
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// diskCheckInterval is the minimum amount of time between checks of the disk
+// usage of the Writelogger's data directory.
+const diskCheckInterval = time.Second
+
+// DiskGuard configures how the Writelogger behaves as the disk holding its
+// data directory fills up.
+type DiskGuard struct {
+	// MinFreeBytes is the amount of headroom to reserve on disk. When free
+	// space falls below this value, appends are rejected before they reach the
+	// disk. A value of 0 disables the guard.
+	MinFreeBytes uint64
+
+	// Block, when true, causes appends to wait up to BlockTimeout for space
+	// to be reclaimed rather than failing immediately.
+	Block        bool
+	BlockTimeout time.Duration
+
+	// OnLowDisk, if set, is called when free space falls below MinFreeBytes.
+	// It's called in its own goroutine, and not more than once per
+	// diskCheckInterval. It's typically used to trigger an emergency snapshot,
+	// which allows the write logs incorporated into the snapshot to be
+	// removed.
+	OnLowDisk func()
+}
+
+// diskState holds the most recent disk usage measurement.
+type diskState struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	free      uint64
+	used      uint64
+	err       error
+}
+
+// SetDiskGuard sets the disk-full handling behavior of the Writelogger.
+func (w *Writelogger) SetDiskGuard(g DiskGuard) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.diskGuard = g
+}
+
+// Health returns an error if the Writelogger is not able to accept writes
+// because the disk's free space is below the configured headroom.
+func (w *Writelogger) Health() error {
+	return w.checkDisk(false)
+}
+
+// checkDisk returns an ErrInsufficientStorage error if free space on the disk
+// is below the configured headroom. If the DiskGuard is configured to block,
+// and block is true, checkDisk waits for space to become available before
+// returning the error.
+func (w *Writelogger) checkDisk(block bool) error {
+	w.mu.RLock()
+	g := w.diskGuard
+	w.mu.RUnlock()
+
+	if g.MinFreeBytes == 0 {
+		return nil
+	}
+
+	err := w.checkDiskOnce(g)
+	if err == nil || !block || !g.Block {
+		return err
+	}
+
+	timer := time.NewTimer(g.BlockTimeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return err
+		case <-ticker.C:
+			if err = w.checkDiskOnce(g); err == nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (w *Writelogger) checkDiskOnce(g DiskGuard) error {
+	w.disk.mu.Lock()
+	defer w.disk.mu.Unlock()
+
+	// Only call statfs once per interval; appends in between use the cached
+	// values.
+	if time.Since(w.disk.checkedAt) >= diskCheckInterval {
+		w.disk.checkedAt = time.Now()
+		w.disk.free, w.disk.used, w.disk.err = diskUsage(w.dataDir)
+		if w.disk.err == nil {
+			dax.GaugeWriteloggerDiskFreeBytes.Set(float64(w.disk.free))
+			dax.GaugeWriteloggerDiskUsedBytes.Set(float64(w.disk.used))
+			dax.GaugeWriteloggerDiskHeadroomBytes.Set(float64(w.disk.free) - float64(g.MinFreeBytes))
+			if w.disk.free < g.MinFreeBytes {
+				w.logger.Warnf("writelogger free disk space (%d bytes) is below headroom (%d bytes)", w.disk.free, g.MinFreeBytes)
+				if g.OnLowDisk != nil {
+					go g.OnLowDisk()
+				}
+			}
+		}
+	}
+
+	if w.disk.err != nil {
+		// If we can't determine disk usage, don't reject writes; the write
+		// itself will fail if the disk is actually full.
+		w.logger.Debugf("writelogger checking disk usage: %v", w.disk.err)
+		return nil
+	}
+	if w.disk.free < g.MinFreeBytes {
+		return dax.NewErrInsufficientStorage(w.dataDir, w.disk.free, g.MinFreeBytes)
+	}
+	return nil
+}
+
+// diskUsage returns the number of bytes free (available to unprivileged
+// users) and used on the filesystem containing dir.
+func diskUsage(dir string) (free uint64, used uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, errors.Wrapf(err, "statfs: %s", dir)
+	}
+	bsize := uint64(st.Bsize)
+	free = uint64(st.Bavail) * bsize
+	used = (uint64(st.Blocks) - uint64(st.Bfree)) * bsize
+	return free, used, nil
+}
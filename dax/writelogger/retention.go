@@ -0,0 +1,356 @@
+package writelogger
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/computer"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// DefaultRetentionInterval is the default time between sweeps of every write
+// log for those which can be truncated.
+const DefaultRetentionInterval = 5 * time.Minute
+
+// SnapshotLister lists the snapshots of a resource; it's implemented by the
+// Snapshotter.
+type SnapshotLister interface {
+	List(bucket, key string) ([]computer.SnapInfo, error)
+}
+
+// Retention configures the automatic truncation of write logs. Once a
+// snapshot of a resource has been written, the write logs up to and including
+// the snapshot's version are incorporated in it, and are said to be covered.
+// Without a Retention, the covered write log is deleted by DeleteLog, which
+// is called once the snapshot has been written, but only if this Writelogger
+// has that log open; logs left behind by a restart, or by a previous owner of
+// the resource, are never deleted.
+//
+// With a Retention, covered write logs are deleted both when DeleteLog is
+// called and by a sweep of every write log on disk, every Interval. A log is
+// only considered covered once the snapshot is listed by Snapshots, which is
+// after it has been synced and moved into place. The Keep most recent
+// covered logs of each resource are kept as a margin against a snapshot
+// which turns out to be unreadable, and a covered log which is still being
+// read, by a computer loading the resource, is kept until a later sweep.
+type Retention struct {
+	// Snapshots lists the snapshots of each resource. If nil, automatic
+	// truncation is disabled.
+	Snapshots SnapshotLister
+
+	// Keep is the number of covered write logs kept for each resource.
+	Keep int
+
+	// Interval is the time between sweeps. 0 uses DefaultRetentionInterval.
+	Interval time.Duration
+}
+
+// LogRetention describes the write logs of a resource as of their most
+// recent truncation.
+type LogRetention struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+
+	// SnapshotVersion is the version of the latest snapshot, or -1 if there
+	// is none.
+	SnapshotVersion int `json:"snapshotVersion"`
+
+	// TruncatedThrough is the highest version of write log which has been
+	// deleted, or -1 if none has. Every covered version up to and including
+	// it has been deleted.
+	TruncatedThrough int `json:"truncatedThrough"`
+
+	// Retained is the number of write logs kept, of which Covered are
+	// covered by the latest snapshot, and InUse were kept only because they
+	// were being read.
+	Retained int `json:"retained"`
+	Covered  int `json:"covered"`
+	InUse    int `json:"inUse"`
+
+	TruncatedAt time.Time `json:"truncatedAt"`
+}
+
+// retentionState holds the Writelogger's Retention and what it has done.
+// It's protected by the Writelogger's mu.
+type retentionState struct {
+	cfg    Retention
+	stop   chan struct{} // closed to stop the sweep
+	status map[string]LogRetention
+}
+
+// SetRetention sets the automatic truncation behavior of the Writelogger, and
+// starts (or stops) its sweep accordingly.
+func (w *Writelogger) SetRetention(r Retention) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopSweepLocked()
+	if r.Keep < 0 {
+		r.Keep = 0
+	}
+	if r.Interval <= 0 {
+		r.Interval = DefaultRetentionInterval
+	}
+	w.retention.cfg = r
+	if r.Snapshots == nil || w.draining {
+		return
+	}
+	stop := make(chan struct{})
+	w.retention.stop = stop
+	go w.sweepEvery(r.Interval, stop)
+}
+
+func (w *Writelogger) stopSweepLocked() {
+	if w.retention.stop != nil {
+		close(w.retention.stop)
+		w.retention.stop = nil
+	}
+}
+
+func (w *Writelogger) sweepEvery(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		w.Sweep()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetentionStatus returns the state of the write logs of each resource, as of
+// their most recent truncation, ordered by bucket and key.
+func (w *Writelogger) RetentionStatus() []LogRetention {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	out := make([]LogRetention, 0, len(w.retention.status))
+	for _, st := range w.retention.status {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Bucket != out[j].Bucket {
+			return out[i].Bucket < out[j].Bucket
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// Sweep truncates the write logs of every resource on disk. It does nothing
+// unless a Retention with Snapshots has been set.
+func (w *Writelogger) Sweep() {
+	w.mu.RLock()
+	enabled := w.retention.cfg.Snapshots != nil
+	w.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	resources, err := w.resources()
+	if err != nil {
+		w.logger.Errorf("writelogger sweep: listing write logs: %v", err)
+		return
+	}
+
+	var retained int
+	seen := make(map[string]struct{}, len(resources))
+	for _, res := range resources {
+		st, err := w.Truncate(res[0], res[1])
+		if err != nil {
+			w.logger.Warnf("writelogger sweep: truncating %s: %v", path.Join(res[0], res[1]), err)
+			continue
+		}
+		retained += st.Retained
+		seen[path.Join(res[0], res[1])] = struct{}{}
+	}
+	dax.GaugeWriteloggerRetainedLogs.Set(float64(retained))
+
+	// Forget the resources which no longer have write logs, such as those of
+	// dropped tables.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for k := range w.retention.status {
+		if _, ok := seen[k]; !ok {
+			delete(w.retention.status, k)
+		}
+	}
+}
+
+// Truncate deletes the covered write logs of the resource identified by
+// bucket and key, other than those kept by the Retention, and returns their
+// state afterward. It returns an error if no Retention with Snapshots has been
+// set.
+func (w *Writelogger) Truncate(bucket, key string) (LogRetention, error) {
+	w.mu.RLock()
+	cfg := w.retention.cfg
+	w.mu.RUnlock()
+	if cfg.Snapshots == nil {
+		return LogRetention{}, errors.Errorf("write log truncation is not configured")
+	}
+
+	snaps, err := cfg.Snapshots.List(bucket, key)
+	if err != nil {
+		return LogRetention{}, errors.Wrap(err, "listing snapshots")
+	}
+	snapVersion := -1
+	for _, s := range snaps {
+		if s.Version > snapVersion {
+			snapVersion = s.Version
+		}
+	}
+
+	logs, err := w.List(bucket, key)
+	if err != nil {
+		return LogRetention{}, errors.Wrap(err, "listing write logs")
+	}
+	versions := make([]int, len(logs))
+	for i, l := range logs {
+		versions[i] = l.Version
+	}
+	sort.Ints(versions)
+
+	var covered []int
+	for _, v := range versions {
+		if v <= snapVersion {
+			covered = append(covered, v)
+		}
+	}
+	deletable := len(covered) - cfg.Keep
+	if deletable < 0 {
+		deletable = 0
+	}
+
+	rk := path.Join(bucket, key)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st, ok := w.retention.status[rk]
+	if !ok {
+		st = LogRetention{Bucket: bucket, Key: key, TruncatedThrough: -1}
+	}
+	st.SnapshotVersion = snapVersion
+	st.InUse = 0
+
+	var deleted int
+	for _, v := range covered[:deletable] {
+		fKey := fullKey(bucket, key, v)
+		// Deleting in order keeps the truncated logs a prefix of the
+		// resource's logs, so stop at the first which is being read.
+		if w.readers[fKey] > 0 {
+			st.InUse = deletable - deleted
+			break
+		}
+		if err := w.removeLogLocked(fKey); err != nil {
+			w.logger.Warnf("writelogger truncating %s: %v", fKey, err)
+			break
+		}
+		deleted++
+		st.TruncatedThrough = v
+	}
+	if deleted > 0 {
+		dax.CounterWriteloggerTruncatedLogs.Add(float64(deleted))
+		w.logger.Debugf("writelogger truncated %d write logs of %s through version %d", deleted, rk, st.TruncatedThrough)
+	}
+	st.Retained = len(versions) - deleted
+	st.Covered = len(covered) - deleted
+	st.TruncatedAt = time.Now()
+
+	if w.retention.status == nil {
+		w.retention.status = make(map[string]LogRetention)
+	}
+	w.retention.status[rk] = st
+	return st, nil
+}
+
+// removeLogLocked closes the log file identified by fKey, if it's open, and
+// removes it. w.mu must be held.
+func (w *Writelogger) removeLogLocked(fKey string) error {
+	if f, ok := w.logFiles[fKey]; ok {
+		if err := f.Close(); err != nil {
+			return errors.Wrap(err, "closing log file")
+		}
+		delete(w.logFiles, fKey)
+	}
+	_, filePath := w.paths(fKey)
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing log file")
+	}
+	return nil
+}
+
+// resources returns the bucket and key of every resource which has write logs
+// on disk.
+func (w *Writelogger) resources() ([][2]string, error) {
+	seen := make(map[string]struct{})
+	var out [][2]string
+	err := filepath.WalkDir(w.dataDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), "_lock_") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, err := strconv.Atoi(d.Name()); err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(w.dataDir, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := seen[rel]; ok {
+			return nil
+		}
+		seen[rel] = struct{}{}
+		if bucket, key, ok := splitResource(rel); ok {
+			out = append(out, [2]string{bucket, key})
+		}
+		return nil
+	})
+	return out, errors.Wrap(err, "walking data directory")
+}
+
+// splitResource splits the path of a resource's write log directory, relative
+// to the data directory, into its bucket and key. The key of a resource is
+// either "keys" or "shard/<num>".
+func splitResource(rel string) (bucket, key string, ok bool) {
+	dir, last := path.Split(rel)
+	dir = strings.TrimSuffix(dir, "/")
+	if last == "keys" && dir != "" {
+		return dir, last, true
+	}
+	parent, shard := path.Split(dir)
+	parent = strings.TrimSuffix(parent, "/")
+	if shard == "shard" && parent != "" {
+		return parent, path.Join(shard, last), true
+	}
+	return "", "", false
+}
+
+// logReader is a write log being read. The Writelogger doesn't truncate a log
+// while it has readers.
+type logReader struct {
+	*os.File
+	once    sync.Once
+	release func()
+}
+
+func (r *logReader) Close() error {
+	r.once.Do(r.release)
+	return r.File.Close()
+}
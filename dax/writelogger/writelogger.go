@@ -2,6 +2,7 @@
 package writelogger
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/fs"
@@ -26,6 +27,22 @@ type Writelogger struct {
 	logFiles  map[string]*os.File
 	lockFiles map[string]*os.File
 
+	// draining is set once Drain has been called; after that, no new messages
+	// are accepted. inflight tracks the appends which were started before
+	// draining was set.
+	draining bool
+	inflight sync.WaitGroup
+
+	// diskGuard configures the handling of a (nearly) full disk. disk holds
+	// the most recent disk usage measurement.
+	diskGuard DiskGuard
+	disk      diskState
+
+	// readers counts the open readers of each log file, by key; retention
+	// configures the truncation of the log files which snapshots cover.
+	readers   map[string]int
+	retention retentionState
+
 	logger logger.Logger
 }
 
@@ -34,6 +51,7 @@ func New(dir string, log logger.Logger) *Writelogger {
 		dataDir:   dir,
 		logFiles:  make(map[string]*os.File),
 		lockFiles: make(map[string]*os.File),
+		readers:   make(map[string]int),
 		logger:    log,
 	}
 }
@@ -45,20 +63,84 @@ func (w *Writelogger) SetLogger(l logger.Logger) {
 }
 
 func (w *Writelogger) AppendMessage(bucket string, key string, version int, message []byte) error {
+	w.mu.RLock()
+	if w.draining {
+		w.mu.RUnlock()
+		return dax.NewErrDraining(dax.ServicePrefixWritelogger)
+	}
+	w.inflight.Add(1)
+	w.mu.RUnlock()
+	defer w.inflight.Done()
+
+	if err := w.checkDisk(true); err != nil {
+		dax.CounterWriteloggerRejectedAppends.Inc()
+		return err
+	}
+
 	fKey := fullKey(bucket, key, version)
 	logFile, err := w.logFileByKey(fKey)
 	if err != nil {
 		return errors.Wrapf(err, "getting log file by key: %s", fKey)
 	}
 
+	fi, err := logFile.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "getting stat of log file %s", logFile.Name())
+	}
+
 	_, err = logFile.Write(append(message, "\n"...))
 	if err != nil {
+		// Don't leave a partially written message in the log; a reader would
+		// fail to decode it.
+		if terr := logFile.Truncate(fi.Size()); terr != nil {
+			w.logger.Errorf("truncating log file %s after failed write: %v", logFile.Name(), terr)
+		}
+		if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.ENOSPC {
+			dax.CounterWriteloggerRejectedAppends.Inc()
+			w.mu.RLock()
+			g := w.diskGuard
+			w.mu.RUnlock()
+			if g.OnLowDisk != nil {
+				go g.OnLowDisk()
+			}
+			return dax.NewErrInsufficientStorage(w.dataDir, 0, g.MinFreeBytes)
+		}
 		return errors.Wrapf(err, "writing to log file %s", logFile.Name())
 	}
 	err = logFile.Sync()
 	return errors.Wrapf(err, "syncing log file %s", logFile.Name())
 }
 
+// Drain stops the Writelogger from accepting new messages, waits for any
+// in-flight appends to complete, and then syncs all open log files to disk.
+func (w *Writelogger) Drain(ctx context.Context) error {
+	w.mu.Lock()
+	w.draining = true
+	w.stopSweepLocked()
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for in-flight appends")
+	case <-done:
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for key, f := range w.logFiles {
+		if err := f.Sync(); err != nil {
+			return errors.Wrapf(err, "syncing log file: %s", key)
+		}
+	}
+	return nil
+}
+
 func (w *Writelogger) List(bucket, key string) ([]computer.WriteLogInfo, error) {
 	dirpath := path.Join(w.dataDir, bucket, key)
 
@@ -88,7 +170,8 @@ func (w *Writelogger) LogReader(bucket, key string, version int) (io.ReadCloser,
 }
 
 func (w *Writelogger) LogReaderFrom(bucket string, key string, version int, offset int) (io.ReadCloser, error) {
-	_, filePath := w.paths(fullKey(bucket, key, version))
+	fKey := fullKey(bucket, key, version)
+	_, filePath := w.paths(fKey)
 
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -102,10 +185,34 @@ func (w *Writelogger) LogReaderFrom(bucket string, key string, version int, offs
 	}
 	w.logger.Debugf("Writelogger LogReader file: %s", f.Name())
 
-	return f, nil
+	w.mu.Lock()
+	w.readers[fKey]++
+	w.mu.Unlock()
+	return &logReader{
+		File: f,
+		release: func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if w.readers[fKey]--; w.readers[fKey] <= 0 {
+				delete(w.readers, fKey)
+			}
+		},
+	}, nil
 }
 
+// DeleteLog is called once the log file for version has been incorporated in
+// a snapshot. If a Retention is set, the log files which the snapshot covers
+// are truncated according to it, and otherwise the log file for version is
+// deleted (if it's open).
 func (w *Writelogger) DeleteLog(bucket string, key string, version int) error {
+	w.mu.RLock()
+	retain := w.retention.cfg.Snapshots != nil
+	w.mu.RUnlock()
+	if retain {
+		_, err := w.Truncate(bucket, key)
+		return err
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -1,14 +1,20 @@
 package writelogger_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path"
 	"testing"
+	"time"
 
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/computer"
 	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
+	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
 	"github.com/stretchr/testify/assert"
 )
@@ -63,6 +69,153 @@ func TestWritelogger(t *testing.T) {
 		assert.Equal(t, msg1.Foo, out.Foo)
 		assert.Equal(t, msg1.Bar, out.Bar)
 	})
+
+	t.Run("Drain", func(t *testing.T) {
+		wl := writelogger.New(tmpDir, logger.NopLogger)
+
+		table := "drain"
+		partition := 0
+		version := 0
+		key := "keys"
+
+		err := wl.AppendMessage(bucket(table, partition), key, version, []byte("before"))
+		assert.NoError(t, err)
+
+		err = wl.Drain(context.Background())
+		assert.NoError(t, err)
+
+		// Appends are rejected once the Writelogger has been drained.
+		err = wl.AppendMessage(bucket(table, partition), key, version, []byte("after"))
+		assert.Error(t, err)
+
+		readcloser, err := wl.LogReader(bucket(table, partition), key, version)
+		assert.NoError(t, err)
+		defer readcloser.Close()
+
+		buf, err := io.ReadAll(readcloser)
+		assert.NoError(t, err)
+		assert.Equal(t, "before\n", string(buf))
+	})
+
+	t.Run("DiskGuard", func(t *testing.T) {
+		wl := writelogger.New(tmpDir, logger.NopLogger)
+
+		lowDisk := make(chan struct{}, 1)
+		wl.SetDiskGuard(writelogger.DiskGuard{
+			// No disk has this much headroom, so every append is rejected.
+			MinFreeBytes: math.MaxUint64,
+			OnLowDisk: func() {
+				lowDisk <- struct{}{}
+			},
+		})
+
+		err := wl.AppendMessage(bucket("full", 0), "keys", 0, []byte("msg"))
+		assert.True(t, errors.Is(err, dax.ErrInsufficientStorage))
+		assert.True(t, errors.Is(wl.Health(), dax.ErrInsufficientStorage))
+
+		select {
+		case <-lowDisk:
+		case <-time.After(time.Second):
+			t.Fatal("expected OnLowDisk to be called")
+		}
+
+		// Disabling the guard allows appends again.
+		wl.SetDiskGuard(writelogger.DiskGuard{})
+		assert.NoError(t, wl.AppendMessage(bucket("full", 0), "keys", 0, []byte("msg")))
+		assert.NoError(t, wl.Health())
+
+		// Low disk is logged whether or not there's an OnLowDisk.
+		logs := logger.NewBufferLogger()
+		wl = writelogger.New(tmpDir, logs)
+		wl.SetDiskGuard(writelogger.DiskGuard{MinFreeBytes: math.MaxUint64})
+		err = wl.AppendMessage(bucket("full", 0), "keys", 0, []byte("msg"))
+		assert.True(t, errors.Is(err, dax.ErrInsufficientStorage))
+		buf, err := logs.ReadAll()
+		assert.NoError(t, err)
+		assert.Contains(t, string(buf), "is below headroom")
+	})
+
+	t.Run("Retention", func(t *testing.T) {
+		dir := path.Join(tmpDir, "retention")
+		wl := writelogger.New(dir, logger.NopLogger)
+		snaps := snapshotList{}
+
+		shard, keys := bucket("ret", 0), bucket("ret", 1)
+		for v := 0; v < 5; v++ {
+			assert.NoError(t, wl.AppendMessage(shard, "shard/3", v, []byte("msg")))
+		}
+		assert.NoError(t, wl.AppendMessage(keys, "keys", 0, []byte("msg")))
+
+		// Without a Retention, a log which isn't open isn't deleted.
+		wl2 := writelogger.New(dir, logger.NopLogger)
+		assert.NoError(t, wl2.DeleteLog(keys, "keys", 0))
+		assert.Len(t, versions(t, wl, keys, "keys"), 1)
+
+		wl.SetRetention(writelogger.Retention{Snapshots: snaps, Keep: 1, Interval: time.Hour})
+		defer func() { assert.NoError(t, wl.Drain(context.Background())) }()
+
+		// Nothing is covered until there's a snapshot.
+		st, err := wl.Truncate(shard, "shard/3")
+		assert.NoError(t, err)
+		assert.Equal(t, -1, st.SnapshotVersion)
+		assert.Equal(t, -1, st.TruncatedThrough)
+		assert.Equal(t, 5, st.Retained)
+
+		// Versions 0 through 3 are covered; one of them is kept, and one is
+		// kept while it's being read.
+		snaps[path.Join(shard, "shard/3")] = 3
+		snaps[path.Join(keys, "keys")] = 0
+		rc, err := wl.LogReader(shard, "shard/3", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, wl.DeleteLog(shard, "shard/3", 3))
+		assert.Equal(t, []int{1, 2, 3, 4}, versions(t, wl, shard, "shard/3"))
+
+		assert.NoError(t, rc.Close())
+		wl.Sweep()
+		assert.Equal(t, []int{3, 4}, versions(t, wl, shard, "shard/3"))
+		assert.Equal(t, []int{0}, versions(t, wl, keys, "keys"))
+
+		status := wl.RetentionStatus()
+		if assert.Len(t, status, 2) {
+			assert.Equal(t, writelogger.LogRetention{
+				Bucket:           shard,
+				Key:              "shard/3",
+				SnapshotVersion:  3,
+				TruncatedThrough: 2,
+				Retained:         2,
+				Covered:          1,
+				TruncatedAt:      status[0].TruncatedAt,
+			}, status[0])
+			assert.Equal(t, "keys", status[1].Key)
+			assert.Equal(t, 1, status[1].Covered)
+		}
+
+		// Appends continue to the uncovered log.
+		assert.NoError(t, wl.AppendMessage(shard, "shard/3", 4, []byte("msg")))
+	})
+}
+
+// snapshotList is a writelogger.SnapshotLister holding the latest snapshot
+// version of each resource.
+type snapshotList map[string]int
+
+func (s snapshotList) List(bucket, key string) ([]computer.SnapInfo, error) {
+	v, ok := s[path.Join(bucket, key)]
+	if !ok {
+		return nil, nil
+	}
+	return []computer.SnapInfo{{Version: v}}, nil
+}
+
+func versions(t *testing.T, wl *writelogger.Writelogger, bucket, key string) []int {
+	t.Helper()
+	logs, err := wl.List(bucket, key)
+	assert.NoError(t, err)
+	out := make([]int, len(logs))
+	for i, l := range logs {
+		out[i] = l.Version
+	}
+	return out
 }
 
 func bucket(table string, partition int) string {
@@ -192,8 +192,7 @@ func (s Serializer) Unmarshal(buf []byte, m pilosa.Message) error {
 		if err != nil {
 			return errors.Wrap(err, "unmarshaling QueryResponse")
 		}
-		s.decodeQueryResponse(msg, mt)
-		return nil
+		return s.decodeQueryResponse(msg, mt)
 	case *pilosa.ImportRequest:
 		msg := &pb.ImportRequest{}
 		err := proto.Unmarshal(buf, msg)
@@ -462,9 +461,24 @@ func (s Serializer) encodeImportRoaringShardRequest(m *pilosa.ImportRoaringShard
 	for i, view := range m.Views {
 		views[i] = s.encodeRoaringUpdate(view)
 	}
+	conditions := make([]*pb.WriteCondition, len(m.Conditions))
+	for i, cond := range m.Conditions {
+		conditions[i] = s.encodeWriteCondition(cond)
+	}
 	return &pb.ImportRoaringShardRequest{
-		Remote: m.Remote,
-		Views:  views,
+		Remote:     m.Remote,
+		Views:      views,
+		Conditions: conditions,
+	}
+}
+
+func (s Serializer) encodeWriteCondition(m pilosa.WriteCondition) *pb.WriteCondition {
+	return &pb.WriteCondition{
+		Field:  m.Field,
+		Column: m.Column,
+		Absent: m.Absent,
+		Value:  m.Value,
+		Rows:   m.Rows,
 	}
 }
 
@@ -550,6 +564,9 @@ func (s Serializer) encodeQueryResponse(m *pilosa.QueryResponse) *pb.QueryRespon
 		case pilosa.DistinctTimestamp:
 			resp.Results[i].Type = queryResultTypeDistinctTimestamp
 			resp.Results[i].DistinctTimestamp = s.encodeDistinctTimestamp(result)
+		case *pilosa.Sketch:
+			resp.Results[i].Type = queryResultTypeSketch
+			resp.Results[i].Sketch = s.encodeSketch(result)
 		case nil:
 			resp.Results[i].Type = queryResultTypeNil
 		case *dataframe.DataFrame:
@@ -1208,6 +1225,15 @@ func (s Serializer) decodeImportRoaringShardRequest(pb *pb.ImportRoaringShardReq
 		s.decodeRoaringUpdate(viewUpdate, pru)
 		m.Views = append(m.Views, *pru)
 	}
+	for _, cond := range pb.Conditions {
+		m.Conditions = append(m.Conditions, pilosa.WriteCondition{
+			Field:  cond.Field,
+			Column: cond.Column,
+			Absent: cond.Absent,
+			Value:  cond.Value,
+			Rows:   cond.Rows,
+		})
+	}
 }
 
 func (s Serializer) decodeRoaringUpdate(pb *pb.RoaringUpdate, m *pilosa.RoaringUpdate) {
@@ -1235,20 +1261,25 @@ func (s Serializer) decodeBlockDataResponse(pb *pb.BlockDataResponse, m *pilosa.
 	m.ColumnIDs = pb.ColumnIDs
 }
 
-func (s Serializer) decodeQueryResponse(pb *pb.QueryResponse, m *pilosa.QueryResponse) {
+func (s Serializer) decodeQueryResponse(pb *pb.QueryResponse, m *pilosa.QueryResponse) error {
 	if pb.Err == "" {
 		m.Err = nil
 	} else {
 		m.Err = errors.New(pb.Err)
 	}
 	m.Results = make([]interface{}, len(pb.Results))
-	s.decodeQueryResults(pb.Results, m.Results)
+	return s.decodeQueryResults(pb.Results, m.Results)
 }
 
-func (s Serializer) decodeQueryResults(pb []*pb.QueryResult, m []interface{}) {
+func (s Serializer) decodeQueryResults(pb []*pb.QueryResult, m []interface{}) error {
 	for i := range pb {
-		m[i] = s.decodeQueryResult(pb[i])
+		result, err := s.decodeQueryResult(pb[i])
+		if err != nil {
+			return errors.Wrapf(err, "decoding result %d", i)
+		}
+		m[i] = result
 	}
+	return nil
 }
 
 func (s Serializer) decodeTranslateKeysRequest(pb *pb.TranslateKeysRequest, m *pilosa.TranslateKeysRequest) {
@@ -1314,6 +1345,34 @@ func (s Serializer) decodeDistinctTimestamp(pb *pb.DistinctTimestamp) pilosa.Dis
 	}
 }
 
+func (s Serializer) decodeSketch(pb *pb.Sketch) (*pilosa.Sketch, error) {
+	m := &pilosa.Sketch{Field: pb.Field}
+	switch pb.Type {
+	case pilosa.SketchTypeHLL:
+		hll, err := pilosa.NewHLLSketchFromRegisters(pb.Registers)
+		if err != nil {
+			return nil, err
+		}
+		m.HLL = hll
+	case pilosa.SketchTypeTDigest:
+		centroids := make([]pilosa.Centroid, len(pb.Centroids))
+		for i, c := range pb.Centroids {
+			centroids[i] = pilosa.Centroid{Mean: c.Mean, Count: c.Count}
+		}
+		m.TDigest = pilosa.NewTDigestFromCentroids(pb.Compression, centroids, pb.Min, pb.Max)
+	case pilosa.SketchTypeTopK:
+		items := make([]pilosa.TopKItem, len(pb.Items))
+		for i, item := range pb.Items {
+			items[i] = pilosa.TopKItem{Value: item.ID, Count: item.Count}
+			if pb.Keys {
+				items[i].Value = item.Key
+			}
+		}
+		m.TopK = pilosa.NewTopKSketchFromItems(int(pb.Capacity), pb.N, items)
+	}
+	return m, nil
+}
+
 func decodeTransaction(pb *pb.Transaction, trns *pilosa.Transaction) {
 	trns.ID = pb.ID
 	trns.Active = pb.Active
@@ -1345,50 +1404,53 @@ const (
 	queryResultTypeDataFrame
 	queryResultTypeArrowTable
 	queryResultTypeExtractedIDMatrixSorted
+	queryResultTypeSketch
 )
 
-func (s Serializer) decodeQueryResult(pb *pb.QueryResult) interface{} {
+func (s Serializer) decodeQueryResult(pb *pb.QueryResult) (interface{}, error) {
 	switch pb.Type {
 	case queryResultTypeSignedRow:
-		return s.decodeSignedRow(pb.SignedRow)
+		return s.decodeSignedRow(pb.SignedRow), nil
 	case queryResultTypeRow:
-		return s.decodeRow(pb.Row)
+		return s.decodeRow(pb.Row), nil
 	case queryResultTypePairs:
-		return s.decodePairs(pb.Pairs)
+		return s.decodePairs(pb.Pairs), nil
 	case queryResultTypePairsField:
-		return s.decodePairsField(pb.PairsField)
+		return s.decodePairsField(pb.PairsField), nil
 	case queryResultTypeValCount:
-		return s.decodeValCount(pb.ValCount)
+		return s.decodeValCount(pb.ValCount), nil
 	case queryResultTypeUint64:
-		return pb.N
+		return pb.N, nil
 	case queryResultTypeBool:
-		return pb.Changed
+		return pb.Changed, nil
 	case queryResultTypeNil:
-		return nil
+		return nil, nil
 	case queryResultTypeRowIDs:
-		return pilosa.RowIDs(pb.RowIDs)
+		return pilosa.RowIDs(pb.RowIDs), nil
 	case queryResultTypeRowIdentifiers:
-		return s.decodeRowIdentifiers(pb.RowIdentifiers)
+		return s.decodeRowIdentifiers(pb.RowIdentifiers), nil
 	case queryResultTypeGroupCounts:
-		return s.decodeGroupCounts(pb.GroupCounts, pb.OldGroupCounts)
+		return s.decodeGroupCounts(pb.GroupCounts, pb.OldGroupCounts), nil
 	case queryResultTypePair:
-		return s.decodePair(pb.Pairs[0])
+		return s.decodePair(pb.Pairs[0]), nil
 	case queryResultTypePairField:
-		return s.decodePairField(pb.PairField)
+		return s.decodePairField(pb.PairField), nil
 	case queryResultTypeExtractedIDMatrix:
-		return s.decodeExtractedIDMatrix(pb.ExtractedIDMatrix)
+		return s.decodeExtractedIDMatrix(pb.ExtractedIDMatrix), nil
 	case queryResultTypeExtractedTable:
-		return s.decodeExtractedTable(pb.ExtractedTable)
+		return s.decodeExtractedTable(pb.ExtractedTable), nil
 	case queryResultTypeRowMatrix:
-		return s.decodeRowMatrix(pb.RowMatrix)
+		return s.decodeRowMatrix(pb.RowMatrix), nil
 	case queryResultTypeDistinctTimestamp:
-		return s.decodeDistinctTimestamp(pb.DistinctTimestamp)
+		return s.decodeDistinctTimestamp(pb.DistinctTimestamp), nil
 	case queryResultTypeDataFrame:
-		return s.decodeDataFrame(pb.DataFrame)
+		return s.decodeDataFrame(pb.DataFrame), nil
 	case queryResultTypeArrowTable:
-		return s.decodeArrowTable(pb.ArrowTable)
+		return s.decodeArrowTable(pb.ArrowTable), nil
 	case queryResultTypeExtractedIDMatrixSorted:
-		return s.decodeExtractedIDMatrixSorted(pb.ExtractedIDMatrixSorted)
+		return s.decodeExtractedIDMatrixSorted(pb.ExtractedIDMatrixSorted), nil
+	case queryResultTypeSketch:
+		return s.decodeSketch(pb.Sketch)
 	}
 	panic(fmt.Sprintf("unknown type: %d", pb.Type))
 }
@@ -1688,6 +1750,43 @@ func (s Serializer) encodeDistinctTimestamp(d pilosa.DistinctTimestamp) *pb.Dist
 	}
 }
 
+func (s Serializer) encodeSketch(m *pilosa.Sketch) *pb.Sketch {
+	result := &pb.Sketch{
+		Field: m.Field,
+		Type:  m.Type(),
+	}
+	switch {
+	case m.HLL != nil:
+		result.Registers = m.HLL.Registers()
+	case m.TDigest != nil:
+		result.Compression = m.TDigest.Compression()
+		centroids := m.TDigest.Centroids()
+		result.Centroids = make([]*pb.SketchCentroid, len(centroids))
+		for i, c := range centroids {
+			result.Centroids[i] = &pb.SketchCentroid{Mean: c.Mean, Count: c.Count}
+		}
+		if len(centroids) > 0 {
+			result.Min, result.Max = m.TDigest.Min(), m.TDigest.Max()
+		}
+	case m.TopK != nil:
+		result.Capacity = int64(m.TopK.Capacity())
+		result.N = m.TopK.N()
+		items := m.TopK.Items()
+		result.Items = make([]*pb.SketchItem, len(items))
+		for i, item := range items {
+			result.Items[i] = &pb.SketchItem{Count: item.Count}
+			switch v := item.Value.(type) {
+			case int64:
+				result.Items[i].ID = v
+			case string:
+				result.Items[i].Key = v
+				result.Keys = true
+			}
+		}
+	}
+	return result
+}
+
 func (s Serializer) encodeGroupCounts(counts *pilosa.GroupCounts) *pb.GroupCounts {
 	groups := counts.Groups()
 	result := &pb.GroupCounts{
@@ -11,6 +11,7 @@ import (
 	"github.com/apache/arrow/go/v10/arrow/memory"
 	pilosa "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/pb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gomem/gomem/pkg/dataframe"
 )
 
@@ -80,7 +81,10 @@ func TestDecodeQueryResult(t *testing.T) {
 		}
 		q := &pb.QueryResult{Type: queryResultTypeDistinctTimestamp, DistinctTimestamp: &pbTime}
 		s := Serializer{}
-		decoded := s.decodeQueryResult(q)
+		decoded, err := s.decodeQueryResult(q)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if !reflect.DeepEqual(decoded, piloTime) {
 			t.Errorf("failed to decode DistinctTimestamp. expected %v got %v", piloTime, decoded)
 		}
@@ -98,7 +102,11 @@ func TestDataFrameQueryResult(t *testing.T) {
 		s := Serializer{}
 		pdf := s.encodeDataFrame(df)
 		q := &pb.QueryResult{Type: queryResultTypeDataFrame, DataFrame: pdf}
-		decoded := s.decodeQueryResult(q).(*dataframe.DataFrame)
+		result, err := s.decodeQueryResult(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := result.(*dataframe.DataFrame)
 
 		if !df.Equals(decoded) {
 			t.Errorf("failed to decode Dataframe. expected\n %#v\n got\n %#v", df, decoded)
@@ -115,7 +123,11 @@ func TestArrowResult(t *testing.T) {
 		s := Serializer{}
 		art := s.encodeArrowTable(table)
 		q := &pb.QueryResult{Type: queryResultTypeArrowTable, ArrowTable: art}
-		decoded := s.decodeQueryResult(q).(arrow.Table)
+		result, err := s.decodeQueryResult(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := result.(arrow.Table)
 
 		if table.NumRows() != decoded.NumRows() {
 			t.Errorf("failed to decode Dataframe. expected\n %#v\n got\n %#v", table.NumRows(), decoded.NumRows())
@@ -214,7 +226,11 @@ func TestExtractedIDMatrixSorted(t *testing.T) {
 		s := Serializer{}
 		before := s.endcodeExtractedIDMatrixSorted(table)
 		q := &pb.QueryResult{Type: queryResultTypeExtractedIDMatrixSorted, ExtractedIDMatrixSorted: before}
-		decoded := s.decodeQueryResult(q).(pilosa.ExtractedIDMatrixSorted)
+		result, err := s.decodeQueryResult(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := result.(pilosa.ExtractedIDMatrixSorted)
 
 		if !compare(table, decoded) {
 			t.Errorf("failed to decode ExtractedIDMatrixSorted. expected\n %#v\n got\n %#v", table, decoded)
@@ -381,3 +397,107 @@ func TestSerializer_ExtractedTable(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializer_ImportRoaringShardRequestConditions(t *testing.T) {
+	testOneRoundTrip(t, Serializer{}, &pilosa.ImportRoaringShardRequest{
+		Remote: true,
+		Views: []pilosa.RoaringUpdate{
+			{Field: "a", View: "bsig_a", Set: []byte{1, 2}},
+		},
+		Conditions: []pilosa.WriteCondition{
+			{Field: "a", Column: 7, Value: -3},
+			{Field: "b", Column: 7, Rows: []uint64{1, 4}},
+			{Field: "c", Column: 7, Absent: true},
+		},
+	}, nil, nil, nil)
+}
+
+func TestSerializer_Sketch(t *testing.T) {
+	hll := pilosa.NewHLLSketch()
+	digest := pilosa.NewTDigest(pilosa.TDigestCompression)
+	ids, keys := pilosa.NewTopKSketch(10), pilosa.NewTopKSketch(10)
+	for i := int64(0); i < 1000; i++ {
+		hll.AddHash(pilosa.HashSketchValue(i))
+		digest.Add(float64(i))
+		ids.Add(i % 7)
+		keys.Add(string(rune('a' + i%5)))
+	}
+	in := []*pilosa.Sketch{
+		{Field: "f", HLL: hll},
+		{Field: "f", TDigest: digest},
+		{Field: "f", TDigest: pilosa.NewTDigest(pilosa.TDigestCompression)},
+		{Field: "f", TopK: ids},
+		{Field: "f", TopK: keys},
+	}
+	resp := &pilosa.QueryResponse{}
+	for _, sketch := range in {
+		resp.Results = append(resp.Results, sketch)
+	}
+
+	s := Serializer{}
+	buf, err := s.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out pilosa.QueryResponse
+	if err := s.Unmarshal(buf, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Results) != len(in) {
+		t.Fatalf("expected %d results, got %d", len(in), len(out.Results))
+	}
+	for i, exp := range in {
+		got, ok := out.Results[i].(*pilosa.Sketch)
+		if !ok {
+			t.Fatalf("result %d: expected a sketch, got %T", i, out.Results[i])
+		}
+		if got.Field != exp.Field || got.Type() != exp.Type() {
+			t.Fatalf("result %d: expected a %s sketch of %s, got a %s sketch of %s", i, exp.Type(), exp.Field, got.Type(), got.Field)
+		}
+		switch {
+		case exp.HLL != nil:
+			if got.HLL.Estimate() != exp.HLL.Estimate() {
+				t.Errorf("result %d: expected estimate %d, got %d", i, exp.HLL.Estimate(), got.HLL.Estimate())
+			}
+		case exp.TDigest != nil:
+			if got.TDigest.Count() != exp.TDigest.Count() {
+				t.Fatalf("result %d: expected count %v, got %v", i, exp.TDigest.Count(), got.TDigest.Count())
+			}
+			if exp.TDigest.Count() == 0 {
+				continue
+			}
+			for _, q := range []float64{0, 0.5, 0.99, 1} {
+				if e, g := exp.TDigest.Quantile(q), got.TDigest.Quantile(q); e != g {
+					t.Errorf("result %d: quantile %v: expected %v, got %v", i, q, e, g)
+				}
+			}
+		case exp.TopK != nil:
+			if got.TopK.Capacity() != exp.TopK.Capacity() || got.TopK.N() != exp.TopK.N() {
+				t.Errorf("result %d: expected capacity %d and n %d, got %d and %d", i, exp.TopK.Capacity(), exp.TopK.N(), got.TopK.Capacity(), got.TopK.N())
+			}
+			if !reflect.DeepEqual(got.TopK.Items(), exp.TopK.Items()) {
+				t.Errorf("result %d: expected items %v, got %v", i, exp.TopK.Items(), got.TopK.Items())
+			}
+		}
+	}
+}
+
+func TestSerializer_TruncatedSketch(t *testing.T) {
+	hll := pilosa.NewHLLSketch()
+	hll.AddHash(pilosa.HashSketchValue(int64(1)))
+	for _, registers := range [][]uint8{nil, hll.Registers()[:100]} {
+		q := &pb.QueryResult{
+			Type:   queryResultTypeSketch,
+			Sketch: &pb.Sketch{Field: "f", Type: pilosa.SketchTypeHLL, Registers: registers},
+		}
+		buf, err := proto.Marshal(&pb.QueryResponse{Results: []*pb.QueryResult{q}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out pilosa.QueryResponse
+		if err := (Serializer{}).Unmarshal(buf, &out); err == nil {
+			t.Fatalf("expected an error decoding a sketch with %d registers", len(registers))
+		}
+	}
+}
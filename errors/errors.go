@@ -41,6 +41,39 @@ func Is(err error, target Code) bool {
 	return errors.Is(err, match)
 }
 
+// CodeOf returns the code of err, or of the coded error it wraps, or "" if
+// it's not coded.
+func CodeOf(err error) Code {
+	if ce, ok := asCoded(err); ok {
+		return ce.Code
+	}
+	return ""
+}
+
+// Message returns the message err, or the coded error it wraps, was created
+// with, without the messages it has been wrapped with since. If err isn't
+// coded, it returns err.Error().
+func Message(err error) string {
+	if ce, ok := asCoded(err); ok {
+		return ce.Message
+	}
+	return err.Error()
+}
+
+// asCoded returns the coded error in err's chain, if any. An error decoded by
+// UnmarshalJSON is a *codedError rather than a codedError.
+func asCoded(err error) (codedError, bool) {
+	var ce codedError
+	if errors.As(err, &ce) {
+		return ce, true
+	}
+	var pce *codedError
+	if errors.As(err, &pce) && pce != nil {
+		return *pce, true
+	}
+	return codedError{}, false
+}
+
 func Unwrap(err error) error {
 	return errors.Unwrap(err)
 }
@@ -2,6 +2,7 @@ package errors_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/featurebasedb/featurebase/v3/errors"
@@ -61,6 +62,22 @@ func TestErrors(t *testing.T) {
 	})
 }
 
+func TestCodeOf(t *testing.T) {
+	tnf := errors.Wrap(newErrTableNotFound("tbl"), "with message")
+	assert.Equal(t, errTableNotFound, errors.CodeOf(tnf))
+	assert.Equal(t, "table not found: tbl", errors.Message(tnf))
+
+	// A coded error keeps its code and message across json.
+	tnf = errors.UnmarshalJSON(strings.NewReader(errors.MarshalJSON(tnf)))
+	assert.Equal(t, errTableNotFound, errors.CodeOf(tnf))
+	assert.Equal(t, "table not found: tbl", errors.Message(tnf))
+	assert.Equal(t, "with message: table not found: tbl", tnf.Error())
+
+	uncoded := errors.Wrap(fmt.Errorf("plain"), "with message")
+	assert.Equal(t, errors.Code(""), errors.CodeOf(uncoded))
+	assert.Equal(t, "with message: plain", errors.Message(uncoded))
+}
+
 // Test error codes.
 
 const (
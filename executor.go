@@ -77,6 +77,20 @@ type executor struct {
 	// Maximum per-request memory usage (Extract() only)
 	maxMemory int64
 
+	// Limits on the number of shards a query may touch.
+	shardLimits shardLimits
+
+	// router chooses which replica of each shard a query is sent to.
+	router *replicaRouter
+
+	// scheduler pauses batch queries between shards while interactive
+	// queries are being processed.
+	scheduler *queryScheduler
+
+	// queriesInFlight is the number of calls to Execute which haven't
+	// returned. It's reported to other nodes as this node's load.
+	queriesInFlight int64
+
 	// Temporary flag to be removed when stablized
 	dataframeEnabled   bool
 	datafameUseParquet bool
@@ -106,6 +120,27 @@ func optExecutorMaxMemory(v int64) executorOption {
 	}
 }
 
+func optExecutorShardLimits(l shardLimits) executorOption {
+	return func(e *executor) error {
+		e.shardLimits = l
+		return nil
+	}
+}
+
+func optExecutorReplicaRouter(r *replicaRouter) executorOption {
+	return func(e *executor) error {
+		e.router = r
+		return nil
+	}
+}
+
+func optExecutorQueryScheduler(s *queryScheduler) executorOption {
+	return func(e *executor) error {
+		e.scheduler = s
+		return nil
+	}
+}
+
 func emptyResult(c *pql.Call) interface{} {
 	switch c.Name {
 	case "Clear", "ClearRow":
@@ -187,6 +222,9 @@ func (e *executor) Execute(ctx context.Context, tableKeyer dax.TableKeyer, q *pq
 	span.LogKV("pql", q.String())
 	defer span.Finish()
 
+	atomic.AddInt64(&e.queriesInFlight, 1)
+	defer atomic.AddInt64(&e.queriesInFlight, -1)
+
 	resp := QueryResponse{}
 
 	// Check for query cancellation.
@@ -342,6 +380,8 @@ func safeCopy(resp QueryResponse) (out QueryResponse) {
 			out.Results = append(out.Results, safe)
 		case DistinctTimestamp:
 			out.Results = append(out.Results, x)
+		case *Sketch:
+			out.Results = append(out.Results, x)
 		case *SortedRow:
 			out.Results = append(out.Results, x)
 		case *dataframe.DataFrame:
@@ -713,6 +753,13 @@ func (e *executor) executeCall(ctx context.Context, qcx *Qcx, index string, c *p
 			shards = []uint64{0}
 		}
 	}
+	// Options() may restrict the shards its child touches, so it's the child
+	// which is checked.
+	if c.Name != "Options" && needsShards(c) {
+		if err := e.checkShardLimit(ctx, index, c, len(shards), opt); err != nil {
+			return nil, err
+		}
+	}
 	// Preprocess the query.
 	c, err := e.preprocessQuery(ctx, qcx, index, c, shards, opt)
 	if err != nil {
@@ -820,6 +867,10 @@ func (e *executor) executeCall(ctx context.Context, qcx *Qcx, index string, c *p
 		statFn(CounterQueryPercentileTotal)
 		res, err := e.executePercentile(ctx, qcx, index, c, shards, opt)
 		return res, errors.Wrap(err, "executePercentile")
+	case "Sketch":
+		statFn(CounterQuerySketchTotal)
+		res, err := e.executeSketch(ctx, qcx, index, c, shards, opt)
+		return res, errors.Wrap(err, "executeSketch")
 	case "Delete":
 		statFn(CounterQueryDeleteTotal)
 		res, err := e.executeDeleteRecords(ctx, qcx, index, c, shards, opt)
@@ -1600,6 +1651,119 @@ func (e *executor) executePercentile(ctx context.Context, qcx *Qcx, index string
 	}
 }
 
+// executeSketch executes a Sketch() call, which summarizes the values a field
+// has in the columns of its filter (or in every column, without one). Each
+// shard's sketch is built where the shard is, and the sketches are merged.
+func (e *executor) executeSketch(ctx context.Context, qcx *Qcx, index string, c *pql.Call, shards []uint64, opt *ExecOptions) (*Sketch, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeSketch")
+	defer span.Finish()
+
+	result, err := NewSketchFromCall(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Children) > 1 {
+		return nil, errors.New("Sketch() only accepts a single bitmap input")
+	}
+	field := e.Holder.Field(index, result.Field)
+	if field == nil {
+		return nil, newNotFoundError(ErrFieldNotFound, result.Field)
+	}
+	if err := checkSketchField(result.Type(), field); err != nil {
+		return nil, err
+	}
+
+	filter := &pql.Call{Name: "All"}
+	if len(c.Children) == 1 {
+		filter = c.Children[0]
+	}
+
+	// Execute calls in bulk on each remote node and merge.
+	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
+		return e.executeSketchShard(ctx, qcx, index, c, field, filter, shard, mopt)
+	}
+
+	// Merge returned results at coordinating node.
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		other, _ := prev.(*Sketch)
+		if other == nil {
+			return v
+		}
+		if err := other.Merge(v.(*Sketch)); err != nil {
+			return err
+		}
+		return other
+	}
+
+	merged, err := e.mapReduce(ctx, index, shards, c, opt, mapFn, reduceFn)
+	if err != nil {
+		return nil, err
+	}
+	if other, _ := merged.(*Sketch); other != nil {
+		result = other
+	}
+	return result, nil
+}
+
+// checkSketchField returns an error if a sketch of type typ can't be built
+// from the values of field. Distinct counts can be estimated for any value; a
+// t-digest needs numbers, and a top-k sketch needs values which can be equal.
+func checkSketchField(typ string, field *Field) error {
+	ok := false
+	switch field.Type() {
+	case FieldTypeSet, FieldTypeMutex:
+		ok = typ != SketchTypeTDigest || !field.Keys()
+	case FieldTypeInt:
+		ok = true
+	case FieldTypeDecimal, FieldTypeTimestamp:
+		ok = typ == SketchTypeHLL || (typ == SketchTypeTDigest && field.Type() == FieldTypeDecimal)
+	}
+	if !ok {
+		return errors.Errorf("Sketch(): can't build a %s sketch of %s field %q", typ, field.Type(), field.Name())
+	}
+	return nil
+}
+
+// executeSketchShard builds the sketch of a Sketch() call for a local shard.
+func (e *executor) executeSketchShard(ctx context.Context, qcx *Qcx, index string, c *pql.Call, field *Field, filter *pql.Call, shard uint64, mopt *mapOptions) (*Sketch, error) {
+	sketch, err := NewSketchFromCall(c)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := e.executeExtractShard(ctx, qcx, index, []string{field.Name()}, filter, shard, mopt, nil)
+	if err != nil {
+		return nil, err
+	}
+	matrix, ok := res.(ExtractedIDMatrix)
+	if !ok {
+		return nil, errors.Errorf("Sketch(): unexpected filter result %T", res)
+	}
+	// The matrix is only needed until its values are in the sketch, so give
+	// its memory back. An empty matrix was never charged for.
+	if len(matrix.Columns) > 0 {
+		defer atomic.AddInt64(mopt.memoryAvailable, calcResultMemory(matrix))
+	}
+
+	scale := 1.0
+	if field.Type() == FieldTypeDecimal {
+		scale = math.Pow10(int(field.Options().Scale))
+	}
+	for _, col := range matrix.Columns {
+		for _, v := range col.Rows[0] {
+			switch {
+			case sketch.HLL != nil:
+				sketch.HLL.AddHash(HashSketchValue(int64(v)))
+			case sketch.TDigest != nil:
+				sketch.TDigest.Add(float64(int64(v)) / scale)
+			case sketch.TopK != nil:
+				sketch.TopK.Add(int64(v))
+			}
+		}
+	}
+	return sketch, nil
+}
+
 // executeMinRow executes a MinRow() call.
 func (e *executor) executeMinRow(ctx context.Context, qcx *Qcx, index string, c *pql.Call, shards []uint64, opt *ExecOptions) (_ interface{}, err error) {
 	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeMinRow")
@@ -4729,19 +4893,60 @@ func (e *executor) executeExtract(ctx context.Context, qcx *Qcx, index string, c
 		return ExtractedIDMatrix{}, errors.Wrap(err, "sort field error")
 	}
 
-	// Execute calls in bulk on each remote node and merge.
+	limit, err := ParseExtractLimit(c, func(name string) (FieldOptions, error) {
+		idx := e.Holder.Index(index)
+		if idx == nil {
+			return FieldOptions{}, newNotFoundError(ErrIndexNotFound, index)
+		}
+		field := idx.Field(name)
+		if field == nil {
+			return FieldOptions{}, newNotFoundError(ErrFieldNotFound, name)
+		}
+		return field.Options(), nil
+	})
+	if err != nil {
+		return ExtractedIDMatrix{}, err
+	}
+	if limit != nil && filter.Name == "Sort" {
+		return ExtractedIDMatrix{}, errors.New("Extract() limit can't be combined with a Sort() filter")
+	}
+
+	// Execute calls in bulk on each remote node and merge. With a limit, each
+	// shard, and each merge of shards, keeps only the rows within the limit.
 	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
-		return e.executeExtractShard(ctx, qcx, index, fields, filter, shard, mopt, timeArgs)
+		res, err := e.executeExtractShard(ctx, qcx, index, fields, filter, shard, mopt, timeArgs)
+		if m, ok := res.(ExtractedIDMatrix); ok && err == nil && limit != nil {
+			return limit.Apply(m)
+		}
+		return res, err
 	}
 
 	// Merge returned results at coordinating node.
 	reduceFn := makeReduceFunc(sort_desc)
+	if limit != nil {
+		merge := reduceFn
+		reduceFn = func(ctx context.Context, prev, v interface{}) interface{} {
+			out := merge(ctx, prev, v)
+			if m, ok := out.(ExtractedIDMatrix); ok {
+				m, err := limit.Apply(m)
+				if err != nil {
+					return err
+				}
+				return m
+			}
+			return out
+		}
+	}
 	// Get full result set.
 	other, err := e.mapReduce(ctx, index, shards, c, opt, mapFn, reduceFn)
 	if err != nil {
 		return ExtractedIDMatrix{}, err
 	}
-	return handleExtractResults(other, filter, opt)
+	res, err := handleExtractResults(other, filter, opt)
+	if m, ok := res.(ExtractedIDMatrix); ok && err == nil && limit != nil {
+		return limit.Apply(m)
+	}
+	return res, err
 }
 
 func mergeBits(bits *Row, mask uint64, out map[uint64]uint64) {
@@ -6403,17 +6608,23 @@ func (e *executor) remoteExec(ctx context.Context, node *disco.Node, index strin
 	}
 
 	addr := dax.Address(node.URI.String())
+	done := e.router.start(node.ID)
 	resp, err := e.client.QueryNode(ctx, addr, index, pbreq)
+	done()
 	if err != nil {
 		return nil, err
 	}
+	e.router.report(node.ID, resp.Load)
 
 	return resp.Results, resp.Err
 }
 
-// shardsByNode returns a mapping of nodes to shards.
+// shardsByNode returns a mapping of nodes to shards. A shard pinned by route
+// is sent to the node it's pinned to, if that node holds an available replica
+// of it; otherwise, it's routed as if it weren't pinned, and the fallback is
+// logged.
 // Returns errShardUnavailable if a shard cannot be allocated to a node.
-func (e *executor) shardsByNode(nodes []*disco.Node, index string, shards []uint64) (map[*disco.Node][]uint64, error) {
+func (e *executor) shardsByNode(nodes []*disco.Node, index string, shards []uint64, route *QueryRoute) (map[*disco.Node][]uint64, error) {
 	m := make(map[*disco.Node][]uint64)
 
 	// Create a snapshot of the cluster to use for node/partition calculations.
@@ -6422,18 +6633,51 @@ func (e *executor) shardsByNode(nodes []*disco.Node, index string, shards []uint
 	// node in the map of nodes to which we distribute the query.
 	snap := disco.NewClusterSnapshot(disco.NewLocalNoder(e.Cluster.Nodes()), e.Cluster.Hasher, e.Cluster.partitionAssigner, e.Cluster.ReplicaN)
 
-loop:
+	// Nodes report their load once a query has returned, so this node's
+	// load doesn't count the query being routed either.
+	localLoad := atomic.LoadInt64(&e.queriesInFlight) - 1
+	if localLoad < 0 {
+		localLoad = 0
+	}
+	choose := e.router.query(e.Node, localLoad)
+	var replicas []*disco.Node
+	fallbacks := make(map[string][]uint64)
 	for _, shard := range shards {
+		replicas = replicas[:0]
 		for _, node := range snap.ShardNodes(index, shard) {
 			// If the node being considered is in any state other than STARTED,
 			// then exclude it from the map. This way, one of that node's
 			// healthy replicas will be included instead.
 			if disco.Nodes(nodes).ContainsID(node.ID) && (node.State == disco.NodeStateStarted || node.State == disco.NodeStateUnknown) {
-				m[node] = append(m[node], shard)
-				continue loop
+				replicas = append(replicas, node)
+			}
+		}
+		if len(replicas) == 0 {
+			return nil, errors.Wrapf(errShardUnavailable, "%s:%d:%v", index, shard, nodes)
+		}
+		var node *disco.Node
+		requested, pinned := route.pin(shard)
+		if pinned {
+			for _, n := range replicas {
+				if n.ID == requested {
+					node = n
+					break
+				}
 			}
+			if node == nil {
+				fallbacks[requested] = append(fallbacks[requested], shard)
+			}
+		}
+		if node == nil {
+			node = choose(shard, replicas, m)
 		}
-		return nil, errors.Wrapf(errShardUnavailable, "%s:%d:%v", index, shard, nodes)
+		if pinned {
+			route.record(index, shard, requested, node.ID)
+		}
+		m[node] = append(m[node], shard)
+	}
+	for requested, shards := range fallbacks {
+		e.Holder.Logger.Warnf("query route: node '%s' does not hold an available replica of shards %v of index '%s'; routing them by the %s strategy instead", requested, shards, index, e.router.name())
 	}
 	return m, nil
 }
@@ -6581,7 +6825,7 @@ func (e *executor) mapper(ctx context.Context, eg *errgroup.Group, ch chan mapRe
 	defer span.Finish()
 
 	// Group shards together by nodes.
-	m, err := e.shardsByNode(nodes, index, shards)
+	m, err := e.shardsByNode(nodes, index, shards, QueryRouteFromContext(ctx))
 	if err != nil {
 		return errors.Wrapf(err, "shards by node")
 	}
@@ -6601,6 +6845,7 @@ func (e *executor) mapper(ctx context.Context, eg *errgroup.Group, ch chan mapRe
 			}
 
 			resp := mapResponse{node: n, shards: nodeShards}
+			CounterQueryNodeRequests.WithLabelValues(n.ID).Inc()
 
 			// Calculate remaining memory. This applies to Extract() only.
 			// Default to a high number if we are not tracking memory.
@@ -6611,7 +6856,9 @@ func (e *executor) mapper(ctx context.Context, eg *errgroup.Group, ch chan mapRe
 
 			// Send local shards to mapper, otherwise remote exec.
 			if n.ID == e.Node.ID {
+				done := e.router.start(n.ID)
 				resp.result, resp.err = e.mapperLocal(ctx, nodeShards, mapFn, reduceFn, memoryAvailable)
+				done()
 			} else if !opt.Remote {
 				var embeddedRowsForNode []*Row
 				if opt.EmbeddedData != nil {
@@ -6760,11 +7007,19 @@ func (e *executor) mapperLocal(ctx context.Context, shards []uint64, mapFn mapFu
 	default:
 	}
 
+	priority := QueryPriorityFromContext(ctx)
+	defer e.scheduler.begin(priority)()
+
 	ch := make(chan mapResponse, len(shards))
 
 	expected := 0
 shardLoop:
 	for _, shard := range shards {
+		if priority == QueryPriorityBatch {
+			if e.scheduler.yield(ctx) != nil {
+				break shardLoop
+			}
+		}
 		j := job{
 			shard:           shard,
 			mapFn:           mapFn,
@@ -7791,6 +8046,33 @@ func (e *executor) translateResult(ctx context.Context, index string, idx *Index
 			}
 		}
 
+	case *Sketch:
+		if result.TopK == nil {
+			break
+		}
+		field := idx.Field(result.Field)
+		if field == nil {
+			return nil, fmt.Errorf("field %q not found", result.Field)
+		}
+		if field.Keys() {
+			items := result.TopK.Items()
+			ids := make([]uint64, len(items))
+			for i := range items {
+				ids[i] = uint64(items[i].Value.(int64))
+			}
+			keys, err := e.Cluster.translateFieldListIDs(ctx, field, ids)
+			if err != nil {
+				return nil, err
+			}
+			for i := range items {
+				items[i].Value = keys[i]
+			}
+			return &Sketch{
+				Field: result.Field,
+				TopK:  NewTopKSketchFromItems(result.TopK.Capacity(), result.TopK.N(), items),
+			}, nil
+		}
+
 	case *GroupCounts:
 		fieldIDs := make(map[*Field]map[uint64]struct{})
 		foreignIDs := make(map[*Field]map[uint64]struct{})
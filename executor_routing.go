@@ -0,0 +1,394 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/disco"
+	"github.com/pkg/errors"
+)
+
+// Strategies for choosing which replica of a shard a query is sent to.
+const (
+	// RoutingPrimary sends each shard to its primary, or, if the primary is
+	// unavailable, to the first available replica. This is the default.
+	RoutingPrimary = "primary"
+
+	// RoutingRoundRobin rotates each shard through its available replicas,
+	// from one query to the next.
+	RoutingRoundRobin = "round-robin"
+
+	// RoutingLeastConnections sends each shard to the replica with the fewest
+	// requests in flight from this node.
+	RoutingLeastConnections = "least-connections"
+
+	// RoutingLeastLoad sends each shard to the replica which most recently
+	// reported executing the fewest queries. Where any replica's report is
+	// older than the staleness threshold, it falls back to
+	// RoutingLeastConnections.
+	RoutingLeastLoad = "least-load"
+)
+
+// DefaultRoutingLoadStaleAfter is the age after which a node's reported load
+// is no longer trusted by RoutingLeastLoad.
+const DefaultRoutingLoadStaleAfter = 10 * time.Second
+
+// QueryRouting configures how queries choose among the replicas of a shard.
+// It only has an effect when shards have more than one replica.
+type QueryRouting struct {
+	// Strategy is one of RoutingPrimary, RoutingRoundRobin,
+	// RoutingLeastConnections, or RoutingLeastLoad. If empty,
+	// RoutingPrimary is used.
+	Strategy string
+
+	// LoadStaleAfter is the age after which a node's reported load is
+	// ignored by RoutingLeastLoad. If 0, DefaultRoutingLoadStaleAfter is
+	// used.
+	LoadStaleAfter time.Duration
+}
+
+// replicaRouter chooses a replica for each shard of a query according to a
+// routing strategy, and keeps the per-node state which the strategies need.
+type replicaRouter struct {
+	strategy   string
+	staleAfter time.Duration
+
+	// next is advanced once per query by RoutingRoundRobin.
+	next uint64
+
+	mu    sync.Mutex
+	nodes map[string]*nodeLoad
+}
+
+// nodeLoad is what a replicaRouter knows about a node's load.
+type nodeLoad struct {
+	// inFlight is the number of requests this node has sent it, or for this
+	// node, the number of local executions, which haven't returned.
+	inFlight int64
+
+	// reported is the number of queries the node reported executing in its
+	// most recent response, at reportedAt.
+	reported   int64
+	reportedAt time.Time
+}
+
+func newReplicaRouter(r QueryRouting) (*replicaRouter, error) {
+	switch r.Strategy {
+	case "":
+		r.Strategy = RoutingPrimary
+	case RoutingPrimary, RoutingRoundRobin, RoutingLeastConnections, RoutingLeastLoad:
+	default:
+		return nil, errors.Errorf("unknown query routing strategy '%s': must be %s, %s, %s, or %s",
+			r.Strategy, RoutingPrimary, RoutingRoundRobin, RoutingLeastConnections, RoutingLeastLoad)
+	}
+	if r.LoadStaleAfter < 0 {
+		return nil, errors.Errorf("query routing load staleness can't be negative: %s", r.LoadStaleAfter)
+	} else if r.LoadStaleAfter == 0 {
+		r.LoadStaleAfter = DefaultRoutingLoadStaleAfter
+	}
+	return &replicaRouter{
+		strategy:   r.Strategy,
+		staleAfter: r.LoadStaleAfter,
+		nodes:      make(map[string]*nodeLoad),
+	}, nil
+}
+
+// query returns a func which chooses, for each shard of a query, one of the
+// shard's available replicas, given in order of preference. assigned holds the
+// shards of the query which have been assigned to each node so far; each of
+// them counts as one unit of load, so that a query's shards are spread
+// across replicas rather than all sent to whichever is least loaded. local is
+// this node, whose load, not counting the query being routed, is localLoad.
+func (r *replicaRouter) query(local *disco.Node, localLoad int64) func(shard uint64, replicas []*disco.Node, assigned map[*disco.Node][]uint64) *disco.Node {
+	if r == nil || r.strategy == RoutingPrimary {
+		return func(_ uint64, replicas []*disco.Node, _ map[*disco.Node][]uint64) *disco.Node {
+			return replicas[0]
+		}
+	}
+	if r.strategy == RoutingRoundRobin {
+		offset := atomic.AddUint64(&r.next, 1)
+		return func(shard uint64, replicas []*disco.Node, _ map[*disco.Node][]uint64) *disco.Node {
+			return replicas[(shard+offset)%uint64(len(replicas))]
+		}
+	}
+
+	// Snapshot the loads once per query, rather than once per shard.
+	now := time.Now()
+	inFlight := make(map[string]int64)
+	reported := make(map[string]int64)
+	r.mu.Lock()
+	for id, l := range r.nodes {
+		inFlight[id] = l.inFlight
+		if !l.reportedAt.IsZero() && now.Sub(l.reportedAt) <= r.staleAfter {
+			reported[id] = l.reported
+		}
+	}
+	r.mu.Unlock()
+	if local != nil {
+		reported[local.ID] = localLoad
+	}
+
+	return func(_ uint64, replicas []*disco.Node, assigned map[*disco.Node][]uint64) *disco.Node {
+		loads := inFlight
+		if r.strategy == RoutingLeastLoad {
+			loads = reported
+			for _, n := range replicas {
+				if _, ok := reported[n.ID]; !ok {
+					loads = inFlight
+					break
+				}
+			}
+		}
+
+		best, bestLoad := replicas[0], int64(-1)
+		for _, n := range replicas {
+			load := loads[n.ID] + int64(len(assigned[n]))
+			if bestLoad < 0 || load < bestLoad {
+				best, bestLoad = n, load
+			}
+		}
+		return best
+	}
+}
+
+// name returns the name of the router's strategy.
+func (r *replicaRouter) name() string {
+	if r == nil {
+		return RoutingPrimary
+	}
+	return r.strategy
+}
+
+// start records that a request is being sent to the node with the given ID,
+// and returns a func to call once it has returned.
+func (r *replicaRouter) start(id string) func() {
+	if r == nil {
+		return func() {}
+	}
+	r.mu.Lock()
+	r.load(id).inFlight++
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		r.load(id).inFlight--
+		r.mu.Unlock()
+	}
+}
+
+// report records the number of queries the node with the given ID reported
+// executing.
+func (r *replicaRouter) report(id string, queries int64) {
+	if r == nil || queries < 0 {
+		return
+	}
+	r.mu.Lock()
+	l := r.load(id)
+	l.reported, l.reportedAt = queries, time.Now()
+	r.mu.Unlock()
+}
+
+// load returns the nodeLoad for id, creating it if needed. The caller must
+// hold r.mu.
+func (r *replicaRouter) load(id string) *nodeLoad {
+	l, ok := r.nodes[id]
+	if !ok {
+		l = &nodeLoad{}
+		r.nodes[id] = l
+	}
+	return l
+}
+
+// HeaderQueryRoute is the request header in which an administrator pins the
+// execution of a query's shards to named nodes, overriding the routing
+// strategy; for example, to compare a shard's results across its replicas, or
+// to isolate a misbehaving node. Its value is a comma-separated list of
+// shard=node pairs, naming nodes by ID, in which a shard of "*" pins every
+// shard not otherwise named:
+//
+//	X-Pilosa-Query-Route: 3=node1, *=node0
+const HeaderQueryRoute = "X-Pilosa-Query-Route"
+
+// QueryRoute is the routing requested for a query in HeaderQueryRoute, along
+// with the routing decisions made for the query's pinned shards. A pin is
+// honored only if the named node holds an available replica of the shard;
+// otherwise the shard falls back to the routing strategy, and the fallback is
+// logged and recorded. All methods are safe to call on a nil *QueryRoute, in
+// which case no shards are pinned.
+type QueryRoute struct {
+	shards map[uint64]string
+	all    string
+
+	mu        sync.Mutex
+	decisions map[queryRouteShard]queryRouteNodes
+}
+
+type queryRouteShard struct {
+	index string
+	shard uint64
+}
+
+type queryRouteNodes struct {
+	requested, node string
+}
+
+// QueryRouteDecision is the routing of pinned shards of an index. Requested
+// is the node named by the pin, and Node the node to which the shards were
+// sent; they differ if the requested node doesn't hold an available replica
+// of the shards.
+type QueryRouteDecision struct {
+	Index     string   `json:"index"`
+	Shards    []uint64 `json:"shards"`
+	Requested string   `json:"requested"`
+	Node      string   `json:"node"`
+}
+
+// Honored returns true if the shards were sent to the requested node.
+func (d QueryRouteDecision) Honored() bool {
+	return d.Requested == d.Node
+}
+
+// Warning describes the fallback from the requested node, if the pin wasn't
+// honored.
+func (d QueryRouteDecision) Warning() string {
+	if d.Honored() {
+		return ""
+	}
+	return fmt.Sprintf("node '%s' does not hold an available replica of shards %v of index '%s'; they were routed to '%s'", d.Requested, d.Shards, d.Index, d.Node)
+}
+
+// ParseQueryRoute parses the value of HeaderQueryRoute. An empty value results
+// in a nil QueryRoute.
+func ParseQueryRoute(s string) (*QueryRoute, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	r := &QueryRoute{
+		shards:    make(map[uint64]string),
+		decisions: make(map[queryRouteShard]queryRouteNodes),
+	}
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.IndexByte(pair, '=')
+		if i < 0 {
+			return nil, errors.Errorf("invalid query route: pin '%s' is not of the form shard=node", strings.TrimSpace(pair))
+		}
+		k, v := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if v == "" {
+			return nil, errors.Errorf("invalid query route: pin of shard '%s' has no node", k)
+		}
+		if k == "*" {
+			if r.all != "" {
+				return nil, errors.New("invalid query route: shard '*' is repeated")
+			}
+			r.all = v
+			continue
+		}
+		shard, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid query route: shard '%s' is not a shard number or '*'", k)
+		}
+		if _, ok := r.shards[shard]; ok {
+			return nil, errors.Errorf("invalid query route: shard '%s' is repeated", k)
+		}
+		r.shards[shard] = v
+	}
+	return r, nil
+}
+
+// pin returns the ID of the node to which the shard is pinned, if any.
+func (r *QueryRoute) pin(shard uint64) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	if id, ok := r.shards[shard]; ok {
+		return id, true
+	}
+	return r.all, r.all != ""
+}
+
+// record records that the pinned shard of index was sent to the node with ID
+// node. A shard which is retried on another replica, or read again by a later
+// call of the query, is recorded where it was last sent.
+func (r *QueryRoute) record(index string, shard uint64, requested, node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions[queryRouteShard{index: index, shard: shard}] = queryRouteNodes{requested: requested, node: node}
+}
+
+// Decisions returns the routing decisions made for the query's pinned shards,
+// with the shards sent from the same requested node to the same node grouped
+// together.
+func (r *QueryRoute) Decisions() []QueryRouteDecision {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type group struct {
+		index string
+		queryRouteNodes
+	}
+	groups := make(map[group][]uint64)
+	for k, v := range r.decisions {
+		g := group{index: k.index, queryRouteNodes: v}
+		groups[g] = append(groups[g], k.shard)
+	}
+	decisions := make([]QueryRouteDecision, 0, len(groups))
+	for g, shards := range groups {
+		sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+		decisions = append(decisions, QueryRouteDecision{
+			Index:     g.index,
+			Shards:    shards,
+			Requested: g.requested,
+			Node:      g.node,
+		})
+	}
+	sort.Slice(decisions, func(i, j int) bool {
+		if decisions[i].Index != decisions[j].Index {
+			return decisions[i].Index < decisions[j].Index
+		}
+		return decisions[i].Shards[0] < decisions[j].Shards[0]
+	})
+	return decisions
+}
+
+// Warnings describes each pin which wasn't honored.
+func (r *QueryRoute) Warnings() []string {
+	var warnings []string
+	for _, d := range r.Decisions() {
+		if !d.Honored() {
+			warnings = append(warnings, d.Warning())
+		}
+	}
+	return warnings
+}
+
+type queryRouteKey struct{}
+
+// WithQueryRoute returns a copy of ctx which carries r. The executor routes
+// the shards of the queries executed with the returned context as r requests.
+// Queries sent to other nodes don't carry r, since those nodes only execute
+// the shards they're sent.
+func WithQueryRoute(ctx context.Context, r *QueryRoute) context.Context {
+	return context.WithValue(ctx, queryRouteKey{}, r)
+}
+
+// QueryRouteFromContext returns the QueryRoute carried by ctx, or nil if ctx
+// does not carry one.
+func QueryRouteFromContext(ctx context.Context) *QueryRoute {
+	r, _ := ctx.Value(queryRouteKey{}).(*QueryRoute)
+	return r
+}
+
+// queryLoad returns the number of queries the node is executing.
+func (api *API) queryLoad() int64 {
+	return atomic.LoadInt64(&api.server.executor.queriesInFlight)
+}
@@ -0,0 +1,266 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/disco"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/pql"
+)
+
+func TestReplicaRouter(t *testing.T) {
+	a, b, c := &disco.Node{ID: "a"}, &disco.Node{ID: "b"}, &disco.Node{ID: "c"}
+	replicas := []*disco.Node{a, b, c}
+
+	// route assigns shards 0 through n-1, each held by every replica, and
+	// returns the number assigned to each node.
+	route := func(r *replicaRouter, local *disco.Node, localLoad int64, n int) map[string]int {
+		choose := r.query(local, localLoad)
+		assigned := make(map[*disco.Node][]uint64)
+		for shard := uint64(0); shard < uint64(n); shard++ {
+			node := choose(shard, replicas, assigned)
+			assigned[node] = append(assigned[node], shard)
+		}
+		counts := make(map[string]int)
+		for node, shards := range assigned {
+			counts[node.ID] = len(shards)
+		}
+		return counts
+	}
+	newRouter := func(t *testing.T, r QueryRouting) *replicaRouter {
+		t.Helper()
+		router, err := newReplicaRouter(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return router
+	}
+
+	if _, err := newReplicaRouter(QueryRouting{Strategy: "fastest"}); err == nil {
+		t.Fatal("expected an error for an unknown strategy")
+	}
+
+	t.Run("Primary", func(t *testing.T) {
+		for _, r := range []*replicaRouter{nil, newRouter(t, QueryRouting{})} {
+			if got := route(r, nil, 0, 6); got["a"] != 6 {
+				t.Fatalf("expected every shard on the primary, got %v", got)
+			}
+		}
+	})
+
+	t.Run("RoundRobin", func(t *testing.T) {
+		r := newRouter(t, QueryRouting{Strategy: RoutingRoundRobin})
+		if got := route(r, nil, 0, 6); got["a"] != 2 || got["b"] != 2 || got["c"] != 2 {
+			t.Fatalf("expected shards spread evenly, got %v", got)
+		}
+		// The same shard goes to a different replica in the next query.
+		first := r.query(nil, 0)(0, replicas, nil)
+		if next := r.query(nil, 0)(0, replicas, nil); next == first {
+			t.Fatalf("expected shard 0 to move on from %s", first.ID)
+		}
+	})
+
+	t.Run("LeastConnections", func(t *testing.T) {
+		r := newRouter(t, QueryRouting{Strategy: RoutingLeastConnections})
+		doneA := r.start("a")
+		doneA2 := r.start("a")
+		doneB := r.start("b")
+		if got := route(r, nil, 0, 3); got["c"] != 2 || got["b"] != 1 || got["a"] != 0 {
+			t.Fatalf("expected shards to favor the least busy replicas, got %v", got)
+		}
+		doneA()
+		doneA2()
+		doneB()
+		if got := route(r, nil, 0, 3); got["a"] != 1 || got["b"] != 1 || got["c"] != 1 {
+			t.Fatalf("expected shards spread evenly once idle, got %v", got)
+		}
+	})
+
+	t.Run("LeastLoad", func(t *testing.T) {
+		r := newRouter(t, QueryRouting{Strategy: RoutingLeastLoad, LoadStaleAfter: time.Hour})
+		r.report("b", 5)
+		r.report("c", 1)
+
+		// a is this node, so its load is always known. Each shard assigned
+		// adds to its replica's load.
+		if got := route(r, a, 3, 3); got["a"] != 1 || got["b"] != 0 || got["c"] != 2 {
+			t.Fatalf("expected shards to favor the least loaded replicas, got %v", got)
+		}
+
+		// Without a report from every replica, least-connections is used.
+		done := r.start("a")
+		if got := route(r, nil, 0, 2); got["a"] != 0 || got["b"] != 1 || got["c"] != 1 {
+			t.Fatalf("expected fallback to least-connections, got %v", got)
+		}
+		done()
+
+		// Stale reports are ignored.
+		r.staleAfter = time.Nanosecond
+		time.Sleep(time.Millisecond)
+		if got := route(r, a, 0, 2); got["a"] != 1 || got["b"] != 1 {
+			t.Fatalf("expected fallback to least-connections with stale loads, got %v", got)
+		}
+	})
+}
+
+func TestExecutor_RoutingCountsLocalExecutions(t *testing.T) {
+	r, err := newReplicaRouter(QueryRouting{Strategy: RoutingLeastConnections})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newExecutor(optExecutorReplicaRouter(r))
+	defer e.Close()
+	e.Cluster = NewTestCluster(t, 1)
+	e.Cluster.Node.State = disco.NodeStateStarted
+	e.Node = e.Cluster.Node
+
+	inFlight := func() int64 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.load(e.Node.ID).inFlight
+	}
+	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (interface{}, error) {
+		return inFlight(), nil
+	}
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		return v
+	}
+	got, err := e.mapReduce(context.Background(), "i", []uint64{0}, &pql.Call{Name: "Count"}, &ExecOptions{}, mapFn, reduceFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != int64(1) {
+		t.Fatalf("expected the local execution to be in flight while it ran, got %v", got)
+	}
+	if n := inFlight(); n != 0 {
+		t.Fatalf("expected nothing in flight once the query returned, got %d", n)
+	}
+}
+
+func TestParseQueryRoute(t *testing.T) {
+	r, err := ParseQueryRoute(" 3=node1, *=node0 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for shard, exp := range map[uint64]string{3: "node1", 0: "node0", 7: "node0"} {
+		if id, ok := r.pin(shard); !ok || id != exp {
+			t.Fatalf("expected shard %d to be pinned to %s, got %q (%v)", shard, exp, id, ok)
+		}
+	}
+
+	r, err = ParseQueryRoute("3=node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.pin(4); ok {
+		t.Fatal("expected shard 4 not to be pinned")
+	}
+
+	if r, err := ParseQueryRoute(""); err != nil || r != nil {
+		t.Fatalf("expected no route for an empty header, got %v, %v", r, err)
+	}
+	for _, s := range []string{"node1", "3=", "x=node1", "3=node1,3=node2", "*=node1,*=node2"} {
+		if _, err := ParseQueryRoute(s); err == nil {
+			t.Fatalf("expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestExecutor_ShardsByNodeRoute(t *testing.T) {
+	e := newExecutor()
+	defer e.Close()
+	log := logger.NewBufferLogger()
+	e.Holder = &Holder{Logger: log}
+
+	nodes := make([]*disco.Node, 3)
+	for i := range nodes {
+		nodes[i] = &disco.Node{ID: fmt.Sprintf("node%d", i), State: disco.NodeStateStarted}
+	}
+	e.Cluster = newCluster()
+	e.Cluster.ReplicaN = 2
+	e.Cluster.noder = disco.NewLocalNoder(nodes)
+	e.Cluster.Node = nodes[0]
+	e.Node = nodes[0]
+
+	// Find a shard, and the nodes which do and don't hold it.
+	const shard = 0
+	snap := e.Cluster.NewSnapshot()
+	owners := snap.ShardNodes("i", shard)
+	if len(owners) != 2 {
+		t.Fatalf("expected 2 replicas of the shard, got %d", len(owners))
+	}
+	replica := owners[1].ID
+	var other string
+	for _, n := range nodes {
+		if !disco.Nodes(owners).ContainsID(n.ID) {
+			other = n.ID
+		}
+	}
+
+	route := func(header string) (map[string][]uint64, *QueryRoute) {
+		t.Helper()
+		r, err := ParseQueryRoute(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := e.shardsByNode(e.Cluster.Nodes(), "i", []uint64{shard}, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[string][]uint64)
+		for n, shards := range m {
+			ids[n.ID] = shards
+		}
+		return ids, r
+	}
+
+	// A pin to a replica other than the one the strategy would choose is
+	// honored.
+	m, r := route(fmt.Sprintf("%d=%s", shard, replica))
+	if len(m[replica]) != 1 {
+		t.Fatalf("expected the shard to be sent to %s, got %v", replica, m)
+	}
+	if d := r.Decisions(); len(d) != 1 || !d[0].Honored() || d[0].Node != replica {
+		t.Fatalf("unexpected decisions: %+v", d)
+	}
+	if w := r.Warnings(); len(w) != 0 {
+		t.Fatalf("unexpected warnings: %v", w)
+	}
+
+	// A pin to a node which doesn't hold the shard falls back to the
+	// strategy, which is logged.
+	m, r = route("*=" + other)
+	if len(m[owners[0].ID]) != 1 {
+		t.Fatalf("expected the shard to fall back to %s, got %v", owners[0].ID, m)
+	}
+	if d := r.Decisions(); len(d) != 1 || d[0].Honored() || d[0].Requested != other || d[0].Node != owners[0].ID {
+		t.Fatalf("unexpected decisions: %+v", d)
+	}
+	if w := r.Warnings(); len(w) != 1 {
+		t.Fatalf("expected a warning, got %v", w)
+	}
+	b, err := log.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "node '"+other+"' does not hold") {
+		t.Fatalf("expected the fallback to be logged, got %q", b)
+	}
+
+	// An unavailable node can't be pinned either.
+	for _, n := range nodes {
+		if n.ID == replica {
+			n.State = disco.NodeStateStarting
+		}
+	}
+	m, _ = route("*=" + replica)
+	if len(m[replica]) != 0 {
+		t.Fatalf("expected the shard not to be sent to an unavailable node, got %v", m)
+	}
+}
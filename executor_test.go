@@ -15,6 +15,7 @@ import (
 	"math/rand"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strconv"
@@ -3887,6 +3888,31 @@ func TestExecutor_Execute_ErrMaxWritesPerRequest(t *testing.T) {
 	}
 }
 
+// Ensure executor returns an error if a query touches too many shards.
+func TestExecutor_Execute_ErrTooManyShards(t *testing.T) {
+	c := test.MustUnsharedCluster(t, 1)
+	defer c.Close()
+	c.GetIdleNode(0).Config.QueryShardLimits.Default = 2
+	err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "f")
+	c.Query(t, c.Idx(), fmt.Sprintf(`Set(0, f=1) Set(%d, f=1) Set(%d, f=1)`, ShardWidth, 2*ShardWidth))
+
+	if _, err := c.GetNode(0).API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: `Count(Row(f=1))`}); errors.Cause(err) != pilosa.ErrTooManyShards {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Restricting the query to fewer shards brings it within the limit.
+	resp, err := c.GetNode(0).API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: `Options(Count(Row(f=1)), shards=[0, 1])`})
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Results[0] != uint64(2) {
+		t.Fatalf("unexpected count: %v", resp.Results[0])
+	}
+}
+
 func TestExecutor_Time_Clear_Quantums(t *testing.T) {
 	c := test.MustRunCluster(t, 1)
 	defer c.Close()
@@ -5241,6 +5267,74 @@ func TestExecutor_Execute_Extract_Keyed(t *testing.T) {
 	assert.ElementsMatch(t, expect.Columns, res.Columns)
 }
 
+func TestExecutor_Execute_ExtractLimit(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "n", pilosa.OptFieldTypeInt(-1000, 1000))
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "m", pilosa.OptFieldTypeMutex(pilosa.CacheTypeNone, 0))
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "s")
+
+	// The columns are spread over shards, so over the nodes; column 3 has
+	// no n.
+	c.Query(t, c.Idx(), fmt.Sprintf(`
+		Set(1, n=5)
+		Set(2, n=-7)
+		Set(3, m=1)
+		Set(%d, n=9)
+		Set(%d, n=5)
+		Set(%d, n=-3)
+		Set(1, m=4)
+		Set(2, m=2)
+		Set(%[1]d, m=4)
+		Set(%[2]d, m=3)
+		Set(%[3]d, m=1)
+		Set(1, s=1)
+	`, ShardWidth+1, 2*ShardWidth+1, 3*ShardWidth+1))
+
+	columnIDs := func(t *testing.T, q string) []uint64 {
+		t.Helper()
+		resp := c.Query(t, c.Idx(), q)
+		tbl, ok := resp.Results[0].(pilosa.ExtractedTable)
+		if !ok {
+			t.Fatalf("expected a table result but got %T", resp.Results[0])
+		}
+		ids := []uint64{}
+		for _, col := range tbl.Columns {
+			ids = append(ids, col.Column.ID)
+		}
+		return ids
+	}
+
+	for _, tt := range []struct {
+		query  string
+		expect []uint64
+	}{
+		{`Extract(All(), Rows(n), limit=3)`, []uint64{1, 2, 3}},
+		{`Extract(All(), Rows(n), limit=2, sort=["-n"])`, []uint64{ShardWidth + 1, 1}},
+		// Ties are in column order, and NULL is the lowest value.
+		{`Extract(All(), Rows(n), limit=4, sort=["n"])`, []uint64{3, 2, 3*ShardWidth + 1, 1}},
+		{`Extract(All(), Rows(n), limit=5, sort=["-n"])`, []uint64{ShardWidth + 1, 1, 2*ShardWidth + 1, 3*ShardWidth + 1, 2}},
+		{`Extract(All(), Rows(n), Rows(m), limit=3, sort=["-m", "n"])`, []uint64{1, ShardWidth + 1, 2*ShardWidth + 1}},
+		{`Extract(Row(m=4), Rows(n), limit=10, sort=["-_id"])`, []uint64{ShardWidth + 1, 1}},
+		{`Extract(All(), Rows(n), limit=0, sort=["n"])`, []uint64{}},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.expect, columnIDs(t, tt.query))
+		})
+	}
+
+	for _, q := range []string{
+		`Extract(All(), Rows(n), sort=["n"])`,
+		`Extract(All(), Rows(s), limit=1, sort=["s"])`,
+		`Extract(All(), Rows(n), limit=1, sort=["m"])`,
+	} {
+		if _, err := c.GetNode(0).API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: q}); err == nil {
+			t.Errorf("%s: expected an error", q)
+		}
+	}
+}
+
 func TestExecutor_Execute_MaxMemory(t *testing.T) {
 	c := test.MustRunCluster(t, 3)
 	defer c.Close()
@@ -7138,6 +7232,69 @@ func TestExecutor_Execute_CountDistinct(t *testing.T) {
 	})
 }
 
+func TestExecutor_Execute_Sketch(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "n", pilosa.OptFieldTypeInt(0, 1000))
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "s", pilosa.OptFieldKeys())
+
+	// spread the values over several shards so every node builds a partial
+	// sketch
+	var b strings.Builder
+	for i := 0; i < 6; i++ {
+		col := uint64(i) * ShardWidth
+		fmt.Fprintf(&b, "Set(%d, n=%d)\n", col, 10+i/2)
+		fmt.Fprintf(&b, "Set(%d, s=%q)\n", col, []string{"foo", "foo", "foo", "bar", "bar", "baz"}[i])
+	}
+	c.Query(t, c.Idx(), b.String())
+
+	query := func(t *testing.T, q string) *pilosa.Sketch {
+		t.Helper()
+		resp := c.Query(t, c.Idx(), q)
+		s, ok := resp.Results[0].(*pilosa.Sketch)
+		if !ok {
+			t.Fatalf("unexpected result type %T", resp.Results[0])
+		}
+		return s
+	}
+
+	t.Run("HLL", func(t *testing.T) {
+		s := query(t, `Sketch(field=n, type="hll")`)
+		if got := s.HLL.Estimate(); got != 3 {
+			t.Fatalf("expected estimate 3, got %d", got)
+		}
+		s = query(t, `Sketch(Row(s="foo"), field=n, type="hll")`)
+		if got := s.HLL.Estimate(); got != 2 {
+			t.Fatalf("expected estimate 2, got %d", got)
+		}
+	})
+
+	t.Run("TDigest", func(t *testing.T) {
+		s := query(t, `Sketch(field=n, type="tdigest")`)
+		if s.TDigest.Count() != 6 || s.TDigest.Min() != 10 || s.TDigest.Max() != 12 {
+			t.Fatalf("unexpected digest: count %v, min %v, max %v", s.TDigest.Count(), s.TDigest.Min(), s.TDigest.Max())
+		}
+	})
+
+	t.Run("TopK", func(t *testing.T) {
+		s := query(t, `Sketch(field=s, type="topk", capacity=100)`)
+		top := s.TopK.Top(2)
+		if len(top) != 2 || top[0].Value != "foo" || top[0].Count != 3 || top[1].Value != "bar" || top[1].Count != 2 {
+			t.Fatalf("unexpected top values: %v", top)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := c.GetNode(0).API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: `Sketch(field=s, type="tdigest")`}); err == nil {
+			t.Fatal("expected error for a t-digest of a keyed field")
+		}
+		if _, err := c.GetNode(0).API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: `Sketch(field=s, type="topk")`}); err == nil {
+			t.Fatal("expected error for a top-k sketch without a capacity")
+		}
+	})
+}
+
 func variousQueriesCountDistinctTimestamp(t *testing.T, c *test.Cluster) {
 	index := c.Idx("tsidx")
 	field := "ts"
@@ -7503,6 +7660,68 @@ func backupTarTest(t *testing.T, c *test.Cluster, index string) {
 	}
 }
 
+func TestRestoreCorruptShard(t *testing.T) {
+	c := test.MustRunUnsharedCluster(t, 1)
+	defer c.Close()
+	c.CreateField(t, "corrupt", pilosa.IndexOptions{}, "f")
+	c.ImportBits(t, "corrupt", "f", [][2]uint64{
+		{1, 1},
+		{1, ShardWidth + 1},
+		{1, 2*ShardWidth + 1},
+	})
+	backupDir := backupCluster(t, c, "corrupt")
+
+	// Corrupt the backup of shard 1.
+	filenames, err := filepath.Glob(filepath.Join(backupDir, "indexes", "corrupt", "shards", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var corrupted string
+	for _, filename := range filenames {
+		if shard, err := strconv.ParseUint(filepath.Base(filename), 10, 64); err == nil && shard == 1 {
+			corrupted = filename
+		}
+	}
+	if corrupted == "" {
+		t.Fatalf("no backup of shard 1 in %v", filenames)
+	} else if err := os.WriteFile(corrupted, bytes.Repeat([]byte("garbage!"), 2048), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	newRestore := func(c *test.Cluster, skipCorrupt bool) *ctl.RestoreCommand {
+		restore := ctl.NewRestoreCommand(logger.NewStandardLogger(io.Discard))
+		restore.Host = c.Nodes[0].URL()
+		restore.Path = backupDir
+		restore.SkipCorrupt = skipCorrupt
+		return restore
+	}
+
+	// By default, a corrupt shard aborts the restore.
+	cstrict := test.MustRunUnsharedCluster(t, 1)
+	defer cstrict.Close()
+	if err := newRestore(cstrict, false).Run(context.Background()); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("expected a corrupt shard error, got %v", err)
+	}
+
+	cnew := test.MustRunUnsharedCluster(t, 1)
+	defer cnew.Close()
+	restore := newRestore(cnew, true)
+	if err := restore.Run(context.Background()); err != nil {
+		t.Fatalf("restoring: %v", err)
+	}
+	if len(restore.SkippedShards) != 1 {
+		t.Fatalf("expected 1 skipped shard, got %+v", restore.SkippedShards)
+	} else if s := restore.SkippedShards[0]; s.Index != "corrupt" || s.Shard != 1 || s.Path != corrupted {
+		t.Fatalf("unexpected skipped shard: %+v", s)
+	}
+
+	// The other shards are restored.
+	resp := cnew.Query(t, "corrupt", `Row(f=1)`)
+	if got, exp := resp.Results[0].(*pilosa.Row).Columns(), []uint64{1, 2*ShardWidth + 1}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("expected columns %v, got %v", exp, got)
+	}
+}
+
 func chkSumCluster(t *testing.T, c *test.Cluster) string {
 	t.Helper()
 	errBuf := &bytes.Buffer{}
@@ -11,14 +11,11 @@ type Hashable interface {
 // use the same seed all the time - this is not for crypto
 var protoSeed uint64 = 20041973
 
-var hasher = xxh3.NewSeed(protoSeed)
-
 type Key []byte
 
-// BEWARE - not concurrent!!
+// Hash returns the hash of k. It's safe to call concurrently, so that
+// separate hash tables, such as those of concurrent queries, can be used at
+// once.
 func (k Key) Hash() uint64 {
-	hasher.Reset()
-	hasher.Write(k)
-	hash := hasher.Sum64()
-	return hash
+	return xxh3.HashSeed(k, protoSeed)
 }
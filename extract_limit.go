@@ -0,0 +1,182 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+// ExtractLimit is the row limit of an Extract() call. It's given by the
+// call's "limit" argument and, optionally, its "sort" argument, which lists
+// the fields the rows are ordered by before the limit is applied, each
+// prefixed with "-" to sort descending. "_id" sorts by column ID. For example:
+//
+//	Extract(All(), Rows(price), Rows(name), limit=10, sort=["-price"])
+//
+// is the ten rows with the highest price. NULLs sort before every value,
+// so they come first in ascending order and last in descending order. Ties,
+// and rows when there's no sort, are ordered by column ID.
+//
+// Since the extracted rows are kept in that order, and every shard, node and
+// reduce keeps only the first Limit of them, the rows of an Extract() with a
+// limit are always the first Limit rows of the full result, however the
+// shards are spread out.
+type ExtractLimit struct {
+	Limit uint64
+	Sort  []ExtractSortKey
+}
+
+// ExtractSortKey is one of the fields an Extract() is sorted by.
+type ExtractSortKey struct {
+	Field string
+	Desc  bool
+	// Signed is true if the extracted values of the field are int64 values
+	// (held as uint64s), rather than row IDs.
+	Signed bool
+}
+
+// ParseExtractLimit returns the limit of the Extract() call c, or nil if it
+// has none. fieldOptions returns the options of the named field; only int,
+// decimal, timestamp and unkeyed mutex fields can be sorted by, since their
+// extracted values are in the same order as the values they stand for.
+func ParseExtractLimit(c *pql.Call, fieldOptions func(name string) (FieldOptions, error)) (*ExtractLimit, error) {
+	limit, hasLimit, err := c.UintArg("limit")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting limit")
+	}
+	sortArg, hasSort := c.Args["sort"]
+	if !hasLimit {
+		if hasSort {
+			return nil, errors.New("Extract() sort requires a limit")
+		}
+		return nil, nil
+	}
+
+	l := &ExtractLimit{Limit: limit}
+	if !hasSort {
+		return l, nil
+	}
+	keys, ok := sortArg.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("Extract() sort must be a list of field names, got %T", sortArg)
+	}
+	for _, k := range keys {
+		name, ok := k.(string)
+		if !ok {
+			return nil, errors.Errorf("Extract() sort must be a list of field names, got %v", k)
+		}
+		key := ExtractSortKey{Field: name}
+		if strings.HasPrefix(name, "-") {
+			key.Field, key.Desc = name[1:], true
+		}
+		if key.Field != "_id" {
+			opts, err := fieldOptions(key.Field)
+			if err != nil {
+				return nil, err
+			}
+			switch opts.Type {
+			case FieldTypeInt, FieldTypeDecimal, FieldTypeTimestamp:
+				key.Signed = true
+			case FieldTypeMutex:
+				if opts.Keys {
+					return nil, errors.Errorf("Extract() can't sort by keyed field: %s", key.Field)
+				}
+			default:
+				return nil, errors.Errorf("Extract() can't sort by %s field: %s", opts.Type, key.Field)
+			}
+		}
+		l.Sort = append(l.Sort, key)
+	}
+	return l, nil
+}
+
+// Apply orders the columns of m, and keeps the first Limit of them. Every
+// field sorted by must be one of the fields of m.
+func (l *ExtractLimit) Apply(m ExtractedIDMatrix) (ExtractedIDMatrix, error) {
+	if len(l.Sort) == 0 {
+		// With no sort, only the columns with the lowest IDs are kept, which
+		// doesn't need them all sorted if there are few enough.
+		if uint64(len(m.Columns)) <= l.Limit {
+			return m, nil
+		}
+	}
+
+	idx := make([]int, len(l.Sort))
+	for i, key := range l.Sort {
+		idx[i] = -1
+		for j, f := range m.Fields {
+			if f == key.Field {
+				idx[i] = j
+				break
+			}
+		}
+		if idx[i] < 0 && key.Field != "_id" {
+			if len(m.Columns) == 0 {
+				// An empty result may not have any fields.
+				return m, nil
+			}
+			return m, errors.Errorf("Extract() sort field is not extracted: %s", key.Field)
+		}
+	}
+
+	sort.Slice(m.Columns, func(a, b int) bool {
+		ca, cb := &m.Columns[a], &m.Columns[b]
+		for i, key := range l.Sort {
+			var c int
+			if idx[i] < 0 {
+				c = compareUint64(ca.ColumnID, cb.ColumnID)
+			} else {
+				c = compareExtractedValues(ca.Rows[idx[i]], cb.Rows[idx[i]], key.Signed)
+			}
+			if key.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return ca.ColumnID < cb.ColumnID
+	})
+	if uint64(len(m.Columns)) > l.Limit {
+		m.Columns = m.Columns[:l.Limit]
+	}
+	return m, nil
+}
+
+// compareExtractedValues compares the extracted values of a field which holds
+// at most one value per column. A missing value is NULL, which is less than
+// every value.
+func compareExtractedValues(a, b []uint64, signed bool) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return -1
+	case len(b) == 0:
+		return 1
+	}
+	if signed {
+		switch x, y := int64(a[0]), int64(b[0]); {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return compareUint64(a[0], b[0])
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// Fault injection makes a node misbehave on demand, so that the failover
+// handling of its clients and peers can be tested against real failures. It's
+// only for staging and test clusters: the /internal/faults routes exist only
+// if the node is started with handler.unsafe-fault-injection, and otherwise
+// respond with a 404 like any other unknown route.
+//
+// A fault is set with
+//
+//	POST /internal/faults?duration=<duration>[&errorPercent=<0-100>][&latency=<duration>][&unavailable=<bool>]
+//
+// after which, until the duration has passed, every other HTTP request to the
+// node is delayed by latency, and then fails with a 503 if unavailable is set,
+// or with a 500 errorPercent percent of the time. Since requests between
+// nodes go over HTTP, they fail too. Faults always expire, after at most
+// MaxFaultDuration, so a forgotten experiment can't linger; they can be
+// cleared sooner with DELETE /internal/faults, and are never persisted across
+// restarts. gRPC requests aren't affected.
+
+// MaxFaultDuration is the longest a fault may be injected for.
+const MaxFaultDuration = time.Hour
+
+var (
+	// ErrInjectedFault is the error of the requests failed by an injected
+	// fault.
+	ErrInjectedFault = errors.New("injected fault")
+
+	// ErrInjectedUnavailable is the error of the requests rejected by an
+	// injected fault while the node is unavailable.
+	ErrInjectedUnavailable = errors.New("node is unavailable (injected fault)")
+)
+
+// faultRoutes are the names of the HTTP routes which manage faults, and so
+// aren't affected by them.
+var faultRoutes = map[string]struct{}{
+	"GetFaults":    {},
+	"PostFaults":   {},
+	"DeleteFaults": {},
+}
+
+// Fault describes the misbehaviour injected into a node's HTTP requests.
+type Fault struct {
+	// ErrorPercent is the percentage of requests which fail with
+	// ErrInjectedFault.
+	ErrorPercent float64
+
+	// Latency is added to every request.
+	Latency time.Duration
+
+	// Unavailable rejects every request with ErrInjectedUnavailable.
+	Unavailable bool
+
+	// Expires is when the fault is cleared.
+	Expires time.Time
+}
+
+// faultJSON is the JSON representation of a Fault.
+type faultJSON struct {
+	ErrorPercent float64   `json:"errorPercent"`
+	Latency      string    `json:"latency"`
+	Unavailable  bool      `json:"unavailable"`
+	Expires      time.Time `json:"expires"`
+}
+
+// faultInjector holds the fault, if any, currently injected into a node.
+type faultInjector struct {
+	logger logger.Logger
+	now    func() time.Time
+	// random returns a random number in [0, 1).
+	random func() float64
+
+	mu    sync.Mutex
+	fault *Fault
+}
+
+func newFaultInjector(log logger.Logger) *faultInjector {
+	return &faultInjector{
+		logger: log,
+		now:    time.Now,
+		random: rand.Float64,
+	}
+}
+
+// current returns the fault being injected, or nil if there isn't one. An
+// expired fault is cleared.
+func (f *faultInjector) current() *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fault != nil && !f.now().Before(f.fault.Expires) {
+		f.logger.Warnf("fault injection: fault expired")
+		f.fault = nil
+	}
+	return f.fault
+}
+
+func (f *faultInjector) set(fault *Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fault = fault
+}
+
+// injectFaults is middleware which applies the current fault, if any, to
+// every request but those for the faultRoutes.
+func (h *Handler) injectFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := faultRoutes[mux.CurrentRoute(r).GetName()]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		fault := h.faults.current()
+		if fault == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if fault.Latency > 0 {
+			t := time.NewTimer(fault.Latency)
+			select {
+			case <-t.C:
+			case <-r.Context().Done():
+				t.Stop()
+				return
+			}
+		}
+		switch {
+		case fault.Unavailable:
+			writeFaultError(w, ErrInjectedUnavailable, http.StatusServiceUnavailable)
+		case fault.ErrorPercent > 0 && h.faults.random()*100 < fault.ErrorPercent:
+			writeFaultError(w, ErrInjectedFault, http.StatusInternalServerError)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func writeFaultError(w http.ResponseWriter, err error, status int) {
+	data, _ := json.Marshal(errorResponse{Error: err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+type faultsResponse struct {
+	Fault *faultJSON `json:"fault"`
+}
+
+func (h *Handler) writeFaults(w http.ResponseWriter) {
+	var resp faultsResponse
+	if fault := h.faults.current(); fault != nil {
+		resp.Fault = &faultJSON{
+			ErrorPercent: fault.ErrorPercent,
+			Latency:      fault.Latency.String(),
+			Unavailable:  fault.Unavailable,
+			Expires:      fault.Expires.UTC(),
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("write faults response error: %s", err)
+	}
+}
+
+// handleGetFaults handles GET /internal/faults requests, which return the
+// fault currently injected, if any.
+func (h *Handler) handleGetFaults(w http.ResponseWriter, r *http.Request) {
+	h.writeFaults(w)
+}
+
+// handlePostFaults handles POST /internal/faults requests, which replace the
+// fault injected into the node.
+func (h *Handler) handlePostFaults(w http.ResponseWriter, r *http.Request) {
+	fault, duration, err := parseFault(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fault.Expires = h.faults.now().Add(duration)
+	h.faults.set(fault)
+
+	user, _ := fbcontext.UserID(r.Context())
+	h.logger.Warnf("admin action: fault injected into node %s by user '%s' from %s for %s: error percent %v, latency %s, unavailable %t",
+		h.api.NodeID(), user, GetIP(r), duration, fault.ErrorPercent, fault.Latency, fault.Unavailable)
+	h.writeFaults(w)
+}
+
+// handleDeleteFaults handles DELETE /internal/faults requests, which clear
+// the fault injected into the node.
+func (h *Handler) handleDeleteFaults(w http.ResponseWriter, r *http.Request) {
+	if h.faults.current() != nil {
+		user, _ := fbcontext.UserID(r.Context())
+		h.logger.Warnf("admin action: fault cleared from node %s by user '%s' from %s", h.api.NodeID(), user, GetIP(r))
+	}
+	h.faults.set(nil)
+	h.writeFaults(w)
+}
+
+// parseFault returns the fault given by the arguments of a POST
+// /internal/faults request, and how long it's to be injected for.
+func parseFault(r *http.Request) (*Fault, time.Duration, error) {
+	q := r.URL.Query()
+	duration, err := time.ParseDuration(q.Get("duration"))
+	if err != nil {
+		return nil, 0, errors.Errorf("duration must be a duration: %s", q.Get("duration"))
+	} else if duration <= 0 || duration > MaxFaultDuration {
+		return nil, 0, errors.Errorf("duration must be positive, and at most %s", MaxFaultDuration)
+	}
+
+	fault := &Fault{}
+	if s := q.Get("errorPercent"); s != "" {
+		fault.ErrorPercent, err = strconv.ParseFloat(s, 64)
+		if err != nil || fault.ErrorPercent < 0 || fault.ErrorPercent > 100 {
+			return nil, 0, errors.Errorf("errorPercent must be between 0 and 100: %s", s)
+		}
+	}
+	if s := q.Get("latency"); s != "" {
+		fault.Latency, err = time.ParseDuration(s)
+		if err != nil || fault.Latency < 0 {
+			return nil, 0, errors.Errorf("latency must be a non-negative duration: %s", s)
+		}
+	}
+	if s := q.Get("unavailable"); s != "" {
+		fault.Unavailable, err = strconv.ParseBool(s)
+		if err != nil {
+			return nil, 0, errors.Errorf("unavailable must be true or false: %s", s)
+		}
+	}
+	if fault.ErrorPercent == 0 && fault.Latency == 0 && !fault.Unavailable {
+		return nil, 0, errors.New("one of errorPercent, latency, or unavailable is required")
+	}
+	return fault, duration, nil
+}
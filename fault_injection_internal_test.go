@@ -0,0 +1,103 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/gorilla/mux"
+)
+
+func TestFaultInjection(t *testing.T) {
+	h := &Handler{
+		api:    &API{server: &Server{}},
+		logger: logger.NopLogger,
+		faults: newFaultInjector(logger.NopLogger),
+	}
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.faults.now = func() time.Time { return now }
+	random := 0.5
+	h.faults.random = func() float64 { return random }
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	router.HandleFunc("/status", ok).Methods("GET").Name("GetStatus")
+	router.HandleFunc("/internal/faults", h.handleGetFaults).Methods("GET").Name("GetFaults")
+	router.HandleFunc("/internal/faults", h.handlePostFaults).Methods("POST").Name("PostFaults")
+	router.HandleFunc("/internal/faults", h.handleDeleteFaults).Methods("DELETE").Name("DeleteFaults")
+	router.Use(h.injectFaults)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	expect := func(t *testing.T, w *httptest.ResponseRecorder, status int, body string) {
+		t.Helper()
+		if w.Code != status {
+			t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+		} else if body != "" && strings.TrimSpace(w.Body.String()) != body {
+			t.Fatalf("unexpected body: %s", w.Body.String())
+		}
+	}
+
+	expect(t, do("GET", "/status"), http.StatusOK, "")
+	expect(t, do("GET", "/internal/faults"), http.StatusOK, `{"fault":null}`)
+
+	for _, args := range []string{
+		"",
+		"duration=2h",
+		"duration=-1m",
+		"duration=1m",
+		"duration=1m&errorPercent=101",
+		"duration=1m&latency=soon",
+		"duration=1m&unavailable=maybe",
+	} {
+		expect(t, do("POST", "/internal/faults?"+args), http.StatusBadRequest, "")
+	}
+
+	expect(t, do("POST", "/internal/faults?duration=1m&errorPercent=40"), http.StatusOK,
+		`{"fault":{"errorPercent":40,"latency":"0s","unavailable":false,"expires":"2023-01-01T00:01:00Z"}}`)
+	expect(t, do("GET", "/status"), http.StatusOK, "")
+	random = 0.3
+	expect(t, do("GET", "/status"), http.StatusInternalServerError, `{"error":"injected fault"}`)
+	// The fault routes themselves are never affected.
+	expect(t, do("GET", "/internal/faults"), http.StatusOK, "")
+
+	expect(t, do("POST", "/internal/faults?duration=1m&unavailable=true"), http.StatusOK, "")
+	expect(t, do("GET", "/status"), http.StatusServiceUnavailable, `{"error":"node is unavailable (injected fault)"}`)
+
+	// Faults expire.
+	now = now.Add(time.Minute)
+	expect(t, do("GET", "/status"), http.StatusOK, "")
+	expect(t, do("GET", "/internal/faults"), http.StatusOK, `{"fault":null}`)
+
+	expect(t, do("POST", "/internal/faults?duration=1m&unavailable=true"), http.StatusOK, "")
+	expect(t, do("DELETE", "/internal/faults"), http.StatusOK, `{"fault":null}`)
+	expect(t, do("GET", "/status"), http.StatusOK, "")
+
+	t.Run("Latency", func(t *testing.T) {
+		expect(t, do("POST", "/internal/faults?duration=1m&latency=20ms"), http.StatusOK, "")
+		start := time.Now()
+		expect(t, do("GET", "/status"), http.StatusOK, "")
+		if d := time.Since(start); d < 20*time.Millisecond {
+			t.Fatalf("expected a delay of at least 20ms, got %s", d)
+		}
+
+		// A request which is cancelled while it's delayed isn't served.
+		expect(t, do("POST", "/internal/faults?duration=1m&latency=1h"), http.StatusOK, "")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil).WithContext(ctx))
+		if w.Body.Len() != 0 {
+			t.Fatalf("unexpected body: %s", w.Body.String())
+		}
+	})
+}
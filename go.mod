@@ -38,6 +38,7 @@ require (
 	github.com/hashicorp/go-retryablehttp v0.7.1
 	github.com/improbable-eng/grpc-web v0.15.0
 	github.com/jedib0t/go-pretty v4.3.0+incompatible
+	github.com/klauspost/compress v1.15.9
 	github.com/lib/pq v1.10.7
 	github.com/molecula/apophenia v0.0.0-20190827192002-68b7a14a478b
 	github.com/opentracing/opentracing-go v1.2.0
@@ -180,7 +181,6 @@ require (
 	github.com/jonboulle/clockwork v0.2.2 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/klauspost/asmfmt v1.3.2 // indirect
-	github.com/klauspost/compress v1.15.9 // indirect
 	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
 	github.com/magiconair/properties v1.8.5 // indirect
@@ -60,6 +60,20 @@ type QueryResponse struct {
 
 	// Profiling data, if any
 	Profile *tracing.Profile
+
+	// ExecutionTime is the time the responding node spent executing the
+	// query, as reported in its Server-Timing header. It is only set on
+	// responses returned by InternalClient.QueryNode, and is not serialized.
+	ExecutionTime time.Duration `json:"-"`
+
+	// Size is the size in bytes of the serialized response. Like
+	// ExecutionTime, it is only set by InternalClient.QueryNode.
+	Size int64 `json:"-"`
+
+	// Load is the number of queries the responding node was executing, as
+	// reported in its X-Pilosa-Query-Load header, or -1 if it didn't report
+	// it. Like ExecutionTime, it is only set by InternalClient.QueryNode.
+	Load int64 `json:"-"`
 }
 
 // MarshalJSON marshals QueryResponse into a JSON-encoded byte slice
@@ -464,6 +478,11 @@ type ImportRoaringShardRequest struct {
 	Remote bool
 	Views  []RoaringUpdate
 
+	// Conditions, if any, must all hold before the import is applied; if
+	// any doesn't, nothing is imported and the import fails with a
+	// ConflictError. See WriteCondition.
+	Conditions []WriteCondition
+
 	// SuppressLog requests we not write to the write log. Typically
 	// that would be because this request is being replayed from a
 	// write log.
@@ -0,0 +1,357 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCompressionMinSize is the size, in bytes, below which responses are
+// not compressed by default.
+const DefaultCompressionMinSize = 1024
+
+// defaultIncompressibleTypes are the media types of responses which are never
+// compressed, because they are already compressed or are binary formats which
+// compress poorly. An entry ending in "/" matches every subtype.
+var defaultIncompressibleTypes = []string{
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/zstd",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/octet-stream",
+	"application/vnd.apache.arrow.stream",
+	"application/vnd.apache.arrow.file",
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"font/woff2",
+}
+
+// ResponseCompression configures gzip compression of HTTP responses to
+// clients which accept it.
+type ResponseCompression struct {
+	// Enabled turns on response compression.
+	Enabled bool `toml:"enabled"`
+
+	// MinSize is the size, in bytes, below which responses aren't
+	// compressed. If 0, DefaultCompressionMinSize is used.
+	MinSize int `toml:"min-size"`
+
+	// ContentTypes, if not empty, limits compression to responses with
+	// these media types (e.g. "application/json", "text/csv"). An entry
+	// ending in "/" or "/*" matches every subtype.
+	ContentTypes []string `toml:"content-types"`
+
+	// ExcludeContentTypes are media types which are never compressed, in
+	// addition to common already-compressed types such as gzip, Arrow
+	// streams, and application/octet-stream (which snapshots and exports
+	// are served as).
+	ExcludeContentTypes []string `toml:"exclude-content-types"`
+
+	// Prefixes overrides MinSize for requests whose path starts with a
+	// given prefix. Each entry is of the form "<path-prefix>=<min-size>", or
+	// "<path-prefix>=off" to disable compression under the prefix. Where
+	// prefixes overlap, the longest wins.
+	Prefixes []string `toml:"prefixes"`
+}
+
+// compressionPrefix is a path prefix with its own minimum size. minSize is -1
+// if compression is disabled under the prefix.
+type compressionPrefix struct {
+	prefix  string
+	minSize int
+}
+
+// responseCompressor is the parsed form of ResponseCompression.
+type responseCompressor struct {
+	minSize  int
+	allow    []string
+	deny     []string
+	prefixes []compressionPrefix
+}
+
+func newResponseCompressor(cfg ResponseCompression) (*responseCompressor, error) {
+	c := &responseCompressor{
+		minSize: cfg.MinSize,
+		deny:    append(append([]string{}, defaultIncompressibleTypes...), normalizeMediaTypes(cfg.ExcludeContentTypes)...),
+		allow:   normalizeMediaTypes(cfg.ContentTypes),
+	}
+	if c.minSize < 0 {
+		return nil, errors.New("compression min size can't be negative")
+	} else if c.minSize == 0 {
+		c.minSize = DefaultCompressionMinSize
+	}
+
+	for _, entry := range cfg.Prefixes {
+		i := strings.LastIndexByte(entry, '=')
+		if i <= 0 {
+			return nil, errors.Errorf("invalid compression prefix '%s': expected <path-prefix>=<min-size>", entry)
+		}
+		p := compressionPrefix{prefix: strings.TrimSpace(entry[:i])}
+		if v := strings.TrimSpace(entry[i+1:]); v == "off" {
+			p.minSize = -1
+		} else if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			return nil, errors.Errorf("invalid compression prefix '%s': min size must be a non-negative integer or \"off\"", entry)
+		} else {
+			p.minSize = n
+		}
+		c.prefixes = append(c.prefixes, p)
+	}
+
+	return c, nil
+}
+
+// normalizeMediaTypes lower-cases types, and turns a trailing "/*" into "/".
+func normalizeMediaTypes(types []string) []string {
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if strings.HasSuffix(t, "/*") {
+			t = t[:len(t)-1]
+		}
+		if t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// minSizeFor returns the minimum size of a compressed response to a request
+// for path, or -1 if responses to it aren't compressed.
+func (c *responseCompressor) minSizeFor(path string) int {
+	minSize, longest := c.minSize, -1
+	for _, p := range c.prefixes {
+		if len(p.prefix) > longest && strings.HasPrefix(path, p.prefix) {
+			minSize, longest = p.minSize, len(p.prefix)
+		}
+	}
+	return minSize
+}
+
+// compressible returns true if responses with the given Content-Type may be
+// compressed.
+func (c *responseCompressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if len(c.allow) > 0 && !matchMediaType(c.allow, mediaType) {
+		return false
+	}
+	return !matchMediaType(c.deny, mediaType)
+}
+
+func matchMediaType(types []string, mediaType string) bool {
+	for _, t := range types {
+		if t == mediaType || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip returns true if r's Accept-Encoding header allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(io.Discard) },
+}
+
+// Middleware returns middleware which compresses the responses of next.
+func (c *responseCompressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		minSize := c.minSizeFor(r.URL.Path)
+		if minSize < 0 || r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			compressor:     c,
+			minSize:        minSize,
+		}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressResponseWriter buffers the start of a response until it knows
+// whether the response should be compressed: once minSize bytes have been
+// written, when the response is flushed, or when it's complete.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	compressor *responseCompressor
+	minSize    int
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+
+	// gz is set if the response is being compressed. written counts the
+	// compressed bytes written, and in counts the bytes given to gz.
+	gz      *gzip.Writer
+	written countingWriter
+	in      int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buf.Write(p)
+		if w.buf.Len() < w.minSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.gz != nil {
+		w.in += int64(len(p))
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide determines whether to compress the response, writes the header, and
+// writes out anything buffered. If large is false, the response is smaller
+// than minSize.
+func (w *compressResponseWriter) decide(large bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && w.buf.Len() > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+
+	if large && h.Get("Content-Encoding") == "" && bodyAllowed(w.status) && w.compressor.compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.written.w = w.ResponseWriter
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(&w.written)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		w.in += int64(w.buf.Len())
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// bodyAllowed returns true if a response with status may have a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// Flush sends any buffered data to the client. A response which is flushed
+// before minSize bytes have been written is compressed if its content type
+// allows, since it's presumably being streamed.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack allows protocols which take over the connection to bypass
+// compression.
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	w.decided = true
+	return h.Hijack()
+}
+
+// Close completes the response, recording the bytes saved by compression.
+func (w *compressResponseWriter) Close() {
+	if !w.decided {
+		if w.status == 0 {
+			// Nothing was written; leave the response to the server.
+			w.decided = true
+			return
+		}
+		_ = w.decide(false)
+	}
+	if w.gz == nil {
+		return
+	}
+	_ = w.gz.Close()
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+	if saved := w.in - w.written.n; saved > 0 {
+		CounterHTTPCompressionBytesSaved.Add(float64(saved))
+	}
+}
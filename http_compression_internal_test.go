@@ -0,0 +1,132 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseCompressor(t *testing.T) {
+	big := strings.Repeat(`{"a":1}`, 1000)
+
+	serve := func(c *responseCompressor, path, acceptEncoding, contentType, body string) *httptest.ResponseRecorder {
+		h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			_, _ = io.WriteString(w, body)
+		}))
+		r := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	gunzip := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+			t.Fatalf("expected gzip content encoding, got %q", enc)
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	uncompressed := func(t *testing.T, w *httptest.ResponseRecorder, body string) {
+		t.Helper()
+		if enc := w.Header().Get("Content-Encoding"); enc != "" {
+			t.Fatalf("expected no content encoding, got %q", enc)
+		} else if w.Body.String() != body {
+			t.Fatalf("unexpected body: %q", w.Body.String())
+		}
+	}
+
+	c, err := newResponseCompressor(ResponseCompression{
+		Enabled:  true,
+		Prefixes: []string{"/export=off", "/internal=0", "/internal/big=100000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Compressed", func(t *testing.T) {
+		if got := gunzip(t, serve(c, "/sql", "gzip, deflate", "application/json", big)); got != big {
+			t.Fatalf("unexpected body after decompression")
+		}
+	})
+
+	t.Run("NotAccepted", func(t *testing.T) {
+		uncompressed(t, serve(c, "/sql", "", "application/json", big), big)
+		uncompressed(t, serve(c, "/sql", "gzip;q=0", "application/json", big), big)
+	})
+
+	t.Run("Small", func(t *testing.T) {
+		uncompressed(t, serve(c, "/sql", "gzip", "application/json", `{"a":1}`), `{"a":1}`)
+	})
+
+	t.Run("Incompressible", func(t *testing.T) {
+		uncompressed(t, serve(c, "/sql", "gzip", "application/octet-stream", big), big)
+		uncompressed(t, serve(c, "/sql", "gzip", "application/vnd.apache.arrow.stream", big), big)
+		uncompressed(t, serve(c, "/sql", "gzip", "image/png", big), big)
+	})
+
+	t.Run("Prefixes", func(t *testing.T) {
+		uncompressed(t, serve(c, "/export/csv", "gzip", "text/csv", big), big)
+		gunzip(t, serve(c, "/internal/small", "gzip", "application/json", `{"a":1}`))
+		uncompressed(t, serve(c, "/internal/big", "gzip", "application/json", big), big)
+	})
+
+	t.Run("ContentTypes", func(t *testing.T) {
+		c, err := newResponseCompressor(ResponseCompression{
+			Enabled:             true,
+			ContentTypes:        []string{"application/json", "text/*"},
+			ExcludeContentTypes: []string{"text/html"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gunzip(t, serve(c, "/sql", "gzip", "application/json; charset=utf-8", big))
+		gunzip(t, serve(c, "/sql", "gzip", "text/csv", big))
+		uncompressed(t, serve(c, "/sql", "gzip", "text/html", big), big)
+		uncompressed(t, serve(c, "/sql", "gzip", "application/xml", big), big)
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			_, _ = io.WriteString(w, `{"a":1}`)
+			w.(http.Flusher).Flush()
+		}))
+		r := httptest.NewRequest("GET", "/stream", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := gunzip(t, w); got != `{"a":1}` {
+			t.Fatalf("unexpected body: %q", got)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, cfg := range []ResponseCompression{
+			{MinSize: -1},
+			{Prefixes: []string{"/query"}},
+			{Prefixes: []string{"/query=big"}},
+		} {
+			if _, err := newResponseCompressor(cfg); err == nil {
+				t.Fatalf("expected error for %+v", cfg)
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// DefaultDecompressionMaxSize is the size, in bytes, to which a request body
+// may decompress by default.
+const DefaultDecompressionMaxSize = 256 << 20
+
+// minZstdWindow is the smallest window which the zstd decoder will accept,
+// whatever the maximum size, since encoders may declare a window larger
+// than the content. It's the 8MiB which the zstd format recommends decoders
+// support.
+const minZstdWindow = 8 << 20
+
+// maxContentEncodings is the number of codings a request body may have been
+// encoded with. Each coding is decoded with its own state, and a zstd
+// decoder's window may be as large as MaxSize, so limiting the codings bounds
+// the memory used to decode a request. For the same reason, only one of them
+// may be zstd.
+const maxContentEncodings = 2
+
+// RequestDecompression configures the decompression of request bodies which
+// clients send with a Content-Encoding of gzip or zstd.
+type RequestDecompression struct {
+	// Enabled turns on request decompression. While it's off, request
+	// bodies are passed to handlers as they're received, whatever their
+	// Content-Encoding.
+	Enabled bool `toml:"enabled"`
+
+	// MaxSize is the size, in bytes, to which a request body may
+	// decompress. It limits the decompressed size, however small the
+	// compressed body, so that a small request can't expand to exhaust
+	// memory. Requests which exceed it are rejected with a 413. If 0,
+	// DefaultDecompressionMaxSize is used.
+	MaxSize int64 `toml:"max-size"`
+}
+
+// ErrDecompressedBodyTooLarge is returned when reading a request body which
+// decompresses to more than the configured maximum size.
+var ErrDecompressedBodyTooLarge = errors.New("decompressed request body too large")
+
+type requestDecompressor struct {
+	maxSize int64
+}
+
+func newRequestDecompressor(cfg RequestDecompression) (*requestDecompressor, error) {
+	d := &requestDecompressor{maxSize: cfg.MaxSize}
+	if d.maxSize < 0 {
+		return nil, errors.New("decompression max size can't be negative")
+	} else if d.maxSize == 0 {
+		d.maxSize = DefaultDecompressionMaxSize
+	}
+	return d, nil
+}
+
+// Middleware returns middleware which decompresses the bodies of requests to
+// next according to their Content-Encoding. Requests with an unsupported
+// encoding, or with more codings than maxContentEncodings, are rejected with
+// a 415.
+func (d *requestDecompressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings := contentEncodings(r.Header.Get("Content-Encoding"))
+		if len(encodings) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		zstds := 0
+		for _, e := range encodings {
+			if e == "zstd" {
+				zstds++
+			}
+		}
+		if len(encodings) > maxContentEncodings || zstds > 1 {
+			http.Error(w, fmt.Sprintf("unsupported Content-Encoding '%s': a request body may have at most %d codings, of which one may be zstd", r.Header.Get("Content-Encoding"), maxContentEncodings), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		// Encodings are listed in the order in which they were applied, so
+		// they're undone in reverse.
+		var body io.Reader = r.Body
+		var closers []io.Closer
+		defer func() {
+			for _, c := range closers {
+				c.Close()
+			}
+		}()
+		for i := len(encodings) - 1; i >= 0; i-- {
+			switch encodings[i] {
+			case "gzip", "x-gzip":
+				zr, err := gzip.NewReader(body)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid gzip request body: %s", err), http.StatusBadRequest)
+					return
+				}
+				closers = append(closers, zr)
+				body = zr
+			case "zstd":
+				maxMemory := d.maxSize
+				if maxMemory < minZstdWindow {
+					maxMemory = minZstdWindow
+				}
+				zr, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxMemory)))
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid zstd request body: %s", err), http.StatusBadRequest)
+					return
+				}
+				closers = append(closers, zr.IOReadCloser())
+				body = zr
+			default:
+				w.Header().Set("Accept-Encoding", "gzip, zstd")
+				http.Error(w, fmt.Sprintf("unsupported Content-Encoding '%s': supported encodings are gzip and zstd", encodings[i]), http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+
+		lr := &limitedBodyReader{r: body, remaining: d.maxSize}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{lr, r.Body}
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+
+		next.ServeHTTP(&decompressResponseWriter{ResponseWriter: w, body: lr}, r)
+	})
+}
+
+// contentEncodings returns the lower-cased codings listed in a
+// Content-Encoding header, other than identity.
+func contentEncodings(header string) []string {
+	var encodings []string
+	for _, e := range strings.Split(header, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e != "" && e != "identity" {
+			encodings = append(encodings, e)
+		}
+	}
+	return encodings
+}
+
+// limitedBodyReader reads from r until it has read remaining bytes, and
+// then fails with ErrDecompressedBodyTooLarge if there's more.
+type limitedBodyReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  int32
+}
+
+func (l *limitedBodyReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Check whether the body ends exactly at the limit.
+		var b [1]byte
+		if n, err := l.r.Read(b[:]); n == 0 {
+			return 0, err
+		}
+		atomic.StoreInt32(&l.exceeded, 1)
+		return 0, ErrDecompressedBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// decompressResponseWriter turns the status of a response into a 413 if the
+// handler failed because its request body decompressed to more than the
+// maximum size, whatever status the handler chose for the read error.
+type decompressResponseWriter struct {
+	http.ResponseWriter
+	body *limitedBodyReader
+}
+
+func (w *decompressResponseWriter) WriteHeader(status int) {
+	if status >= 400 && atomic.LoadInt32(&w.body.exceeded) == 1 {
+		status = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *decompressResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *decompressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	return h.Hijack()
+}
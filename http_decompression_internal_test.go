@@ -0,0 +1,92 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestRequestDecompression(t *testing.T) {
+	if _, err := newRequestDecompressor(RequestDecompression{MaxSize: -1}); err == nil {
+		t.Fatal("expected an error for a negative max size")
+	}
+	d, err := newRequestDecompressor(RequestDecompression{MaxSize: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The handler echoes the request body, failing with a 400 if it can't be
+	// read, as most handlers do.
+	h := d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	do := func(encoding string, body []byte) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/import", bytes.NewReader(body))
+		if encoding != "" {
+			r.Header.Set("Content-Encoding", encoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	gzipped := func(b []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write(b)
+		zw.Close()
+		return buf.Bytes()
+	}
+	zstded := func(b []byte) []byte {
+		zw, err := zstd.NewWriter(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zw.Close()
+		return zw.EncodeAll(b, nil)
+	}
+
+	data := []byte(strings.Repeat("abc", 300))
+	bomb := bytes.Repeat([]byte{0}, 100000)
+	for _, tc := range []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+		response []byte
+	}{
+		{"Identity", "", data, http.StatusOK, data},
+		{"Gzip", "gzip", gzipped(data), http.StatusOK, data},
+		{"Zstd", "zstd", zstded(data), http.StatusOK, data},
+		{"Layered", "zstd, gzip", gzipped(zstded(data)), http.StatusOK, data},
+		{"AtLimit", "gzip", gzipped(bomb[:1000]), http.StatusOK, bomb[:1000]},
+		{"GzipBomb", "gzip", gzipped(bomb), http.StatusRequestEntityTooLarge, nil},
+		{"ZstdBomb", "zstd", zstded(bomb), http.StatusRequestEntityTooLarge, nil},
+		{"Invalid", "gzip", data, http.StatusBadRequest, nil},
+		{"Unsupported", "br", data, http.StatusUnsupportedMediaType, nil},
+		{"TooManyCodings", "gzip, gzip, gzip", gzipped(gzipped(gzipped(data))), http.StatusUnsupportedMediaType, nil},
+		{"StackedZstd", "zstd, zstd", zstded(zstded(data)), http.StatusUnsupportedMediaType, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.encoding, tc.body)
+			if w.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			if tc.response != nil && !bytes.Equal(w.Body.Bytes(), tc.response) {
+				t.Fatalf("unexpected response body of %d bytes", w.Body.Len())
+			}
+		})
+	}
+}
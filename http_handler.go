@@ -33,7 +33,9 @@ import (
 	"github.com/featurebasedb/featurebase/v3/authz"
 	fbcontext "github.com/featurebasedb/featurebase/v3/context"
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
 	"github.com/featurebasedb/featurebase/v3/disco"
+	fberrors "github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
 	"github.com/featurebasedb/featurebase/v3/monitor"
 	"github.com/featurebasedb/featurebase/v3/pql"
@@ -57,6 +59,16 @@ import (
 const (
 	// HeaderRequestUserID is request userid header
 	HeaderRequestUserID = "X-Request-Userid"
+
+	// HeaderServerTiming is the standard Server-Timing header, in which query
+	// responses report the time spent executing the query (as the "exec"
+	// metric), so that callers can tell execution time from network time.
+	HeaderServerTiming = "Server-Timing"
+
+	// HeaderQueryLoad is the header in which query responses report the
+	// number of queries the node is executing, which other nodes use to
+	// route queries to the least loaded replica.
+	HeaderQueryLoad = "X-Pilosa-Query-Load"
 )
 
 // Handler represents an HTTP handler.
@@ -78,7 +90,18 @@ type Handler struct {
 	// url is used to hold the advertise bind address for printing a log during startup.
 	url string
 
-	closeTimeout time.Duration
+	// proxyProtocol enables parsing of PROXY protocol headers sent by the
+	// proxyProtocolUpstreams.
+	proxyProtocol          bool
+	proxyProtocolUpstreams []string
+
+	// shutdownTimeouts bounds each phase of Close. Every request's context
+	// is derived from requestCtx, which Close cancels once the drain phase
+	// times out.
+	shutdownTimeouts ShutdownTimeouts
+	requestCtx       context.Context
+	cancelRequests   context.CancelFunc
+	active           *activeRequests
 
 	serializer        Serializer
 	roaringSerializer Serializer
@@ -87,6 +110,33 @@ type Handler struct {
 
 	middleware []func(http.Handler) http.Handler
 
+	// compressor, if set, compresses responses.
+	compressor *responseCompressor
+
+	// decompressor, if set, decompresses request bodies.
+	decompressor *requestDecompressor
+
+	// queryLimiter, if set, limits the number of queries each user, and the
+	// node, may have executing at once.
+	queryLimiter *queryConcurrencyLimiter
+
+	// readOnly puts the node into read-only mode when the handler is created.
+	readOnly bool
+
+	// clockSkew records the clock skew of the nodes which send requests to
+	// this one; differences beyond clockSkewTolerance are reported.
+	clockSkew          *clockSkewMonitor
+	clockSkewTolerance time.Duration
+
+	// resultFlush is the default policy by which streamed results are
+	// flushed to clients.
+	resultFlush ResultFlush
+
+	// unsafeFaultInjection enables the /internal/faults routes; faults holds
+	// the fault they inject, if they're enabled.
+	unsafeFaultInjection bool
+	faults               *faultInjector
+
 	pprofCPUProfileBuffer *bytes.Buffer
 
 	auth *authn.Auth
@@ -215,11 +265,126 @@ func OptHandlerListener(ln net.Listener, url string) handlerOption {
 	}
 }
 
+// OptHandlerProxyProtocol enables support for the PROXY protocol (v1 and v2),
+// which L4 load balancers use to pass on the address of the client. When
+// enabled, the listener is wrapped so that each request's RemoteAddr (and so
+// the address used for IP-based authentication and logging) is the client's
+// address rather than the load balancer's. Headers are only trusted from the
+// upstreams set with OptHandlerProxyProtocolUpstreams, which are required.
+func OptHandlerProxyProtocol(enabled bool) handlerOption {
+	return func(h *Handler) error {
+		h.proxyProtocol = enabled
+		return nil
+	}
+}
+
+// OptHandlerProxyProtocolUpstreams sets the IP addresses or CIDR networks of
+// the load balancers which are trusted to send PROXY protocol headers.
+func OptHandlerProxyProtocolUpstreams(upstreams []string) handlerOption {
+	return func(h *Handler) error {
+		h.proxyProtocolUpstreams = upstreams
+		return nil
+	}
+}
+
 // OptHandlerCloseTimeout controls how long to wait for the http Server to
-// shutdown cleanly before forcibly destroying it. Default is 30 seconds.
+// shutdown cleanly before forcibly destroying it, replacing the staged
+// shutdown configured with OptHandlerShutdownTimeouts: requests are given d
+// to finish, and then their contexts are cancelled and their connections
+// closed at once. If d is 0, the shutdown timeouts are left as they are.
 func OptHandlerCloseTimeout(d time.Duration) handlerOption {
 	return func(h *Handler) error {
-		h.closeTimeout = d
+		if d != 0 {
+			h.shutdownTimeouts = ShutdownTimeouts{Drain: d}
+		}
+		return nil
+	}
+}
+
+// OptHandlerResponseCompression configures the compression of responses to
+// clients which accept it.
+func OptHandlerResponseCompression(cfg ResponseCompression) handlerOption {
+	return func(h *Handler) error {
+		if !cfg.Enabled {
+			return nil
+		}
+		c, err := newResponseCompressor(cfg)
+		if err != nil {
+			return errors.Wrap(err, "configuring response compression")
+		}
+		h.compressor = c
+		return nil
+	}
+}
+
+// OptHandlerRequestDecompression configures the decompression of request
+// bodies sent with a Content-Encoding.
+func OptHandlerRequestDecompression(cfg RequestDecompression) handlerOption {
+	return func(h *Handler) error {
+		if !cfg.Enabled {
+			return nil
+		}
+		d, err := newRequestDecompressor(cfg)
+		if err != nil {
+			return errors.Wrap(err, "configuring request decompression")
+		}
+		h.decompressor = d
+		return nil
+	}
+}
+
+// OptHandlerQueryConcurrencyLimits limits the number of queries each user, and
+// the node as a whole, may have executing at once, and reserves some of the
+// node's capacity for particular users.
+func OptHandlerQueryConcurrencyLimits(cfg QueryConcurrencyLimits) handlerOption {
+	return func(h *Handler) error {
+		if cfg.Default == 0 && len(cfg.Users) == 0 && cfg.Total == 0 && cfg.Memory == 0 {
+			return nil
+		}
+		l, err := newQueryConcurrencyLimiter(cfg)
+		if err != nil {
+			return errors.Wrap(err, "configuring query concurrency limits")
+		}
+		h.queryLimiter = l
+		return nil
+	}
+}
+
+// OptHandlerReadOnly starts the node in read-only mode, in which it rejects
+// every write with ErrReadOnly. The mode can be changed at runtime through
+// POST /read-only.
+func OptHandlerReadOnly(readOnly bool) handlerOption {
+	return func(h *Handler) error {
+		h.readOnly = readOnly
+		return nil
+	}
+}
+
+// OptHandlerClockSkewTolerance sets the difference between another node's
+// clock and this one's beyond which the skew is logged and counted. A value
+// of 0 uses DefaultClockSkewTolerance.
+func OptHandlerClockSkewTolerance(d time.Duration) handlerOption {
+	return func(h *Handler) error {
+		h.clockSkewTolerance = d
+		return nil
+	}
+}
+
+// OptHandlerResultFlush sets the default policy by which the rows of
+// streamed results are flushed to clients.
+func OptHandlerResultFlush(p ResultFlush) handlerOption {
+	return func(h *Handler) error {
+		h.resultFlush = p
+		return nil
+	}
+}
+
+// OptHandlerUnsafeFaultInjection enables the /internal/faults routes, through
+// which an admin can make the node fail requests on demand. It must never be
+// enabled in production.
+func OptHandlerUnsafeFaultInjection(enabled bool) handlerOption {
+	return func(h *Handler) error {
+		h.unsafeFaultInjection = enabled
 		return nil
 	}
 }
@@ -232,10 +397,12 @@ var (
 // NewHandler returns a new instance of Handler with a default logger.
 func NewHandler(opts ...handlerOption) (*Handler, error) {
 	handler := &Handler{
-		fileSystem:   NopFileSystem,
-		logger:       logger.NopLogger,
-		closeTimeout: time.Second * 30,
+		fileSystem:       NopFileSystem,
+		logger:           logger.NopLogger,
+		shutdownTimeouts: ShutdownTimeouts{}.withDefaults(),
+		active:           newActiveRequests(),
 	}
+	handler.requestCtx, handler.cancelRequests = context.WithCancel(context.Background())
 
 	for _, opt := range opts {
 		err := opt(handler)
@@ -254,6 +421,11 @@ func NewHandler(opts ...handlerOption) (*Handler, error) {
 		}
 	})
 
+	handler.clockSkew = newClockSkewMonitor(handler.clockSkewTolerance, handler.logger)
+	if handler.unsafeFaultInjection {
+		handler.faults = newFaultInjector(handler.logger)
+	}
+
 	// if OptHandlerFileSystem is used, it must be before newRouter is called
 	handler.Handler = newRouter(handler)
 	handler.populateValidators()
@@ -261,12 +433,26 @@ func NewHandler(opts ...handlerOption) (*Handler, error) {
 	if handler.api == nil {
 		return nil, errors.New("must pass OptHandlerAPI")
 	}
+	if handler.readOnly && handler.api.SetReadOnly(true) {
+		handler.logger.Warnf("node %s is starting in read-only mode", handler.api.NodeID())
+	}
+	if handler.faults != nil {
+		handler.logger.Warnf("UNSAFE: fault injection is enabled on node %s; it must not be used in production", handler.api.NodeID())
+	}
 
 	if handler.ln == nil {
 		return nil, errors.New("must pass OptHandlerListener")
 	}
 
-	handler.server = &http.Server{Handler: handler}
+	if handler.proxyProtocol {
+		ln, err := NewProxyProtocolListener(handler.ln, handler.proxyProtocolUpstreams)
+		if err != nil {
+			return nil, errors.Wrap(err, "enabling proxy protocol")
+		}
+		handler.ln = ln
+	}
+
+	handler.server = &http.Server{Handler: handler, BaseContext: handler.baseContext}
 
 	return handler, nil
 }
@@ -280,18 +466,6 @@ func (h *Handler) Serve() error {
 	return nil
 }
 
-// Close tries to cleanly shutdown the HTTP server, and failing that, after a
-// timeout, calls Server.Close.
-func (h *Handler) Close() error {
-	deadlineCtx, cancelFunc := context.WithDeadline(context.Background(), time.Now().Add(h.closeTimeout))
-	defer cancelFunc()
-	err := h.server.Shutdown(deadlineCtx)
-	if err != nil {
-		err = h.server.Close()
-	}
-	return errors.Wrap(err, "shutdown/close http server")
-}
-
 func (h *Handler) populateValidators() {
 	h.validators = map[string]*queryValidationSpec{}
 	h.validators["GetExport"] = queryValidationSpecRequired("index", "field", "shard")
@@ -312,6 +486,11 @@ func (h *Handler) populateValidators() {
 	h.validators["GetSchema"] = queryValidationSpecRequired().Optional("views")
 	h.validators["PostSchema"] = queryValidationSpecRequired().Optional("remote")
 	h.validators["GetStatus"] = queryValidationSpecRequired()
+	h.validators["GetReadOnly"] = queryValidationSpecRequired()
+	h.validators["PostReadOnly"] = queryValidationSpecRequired("enabled")
+	h.validators["GetFaults"] = queryValidationSpecRequired()
+	h.validators["PostFaults"] = queryValidationSpecRequired("duration").Optional("errorPercent", "latency", "unavailable")
+	h.validators["DeleteFaults"] = queryValidationSpecRequired()
 	h.validators["GetVersion"] = queryValidationSpecRequired()
 	h.validators["PostClusterMessage"] = queryValidationSpecRequired()
 	h.validators["GetFragmentBlockData"] = queryValidationSpecRequired()
@@ -327,6 +506,11 @@ func (h *Handler) populateValidators() {
 	h.validators["PostTransaction"] = queryValidationSpecRequired()
 	h.validators["PostFinishTransaction"] = queryValidationSpecRequired()
 	h.validators["DeleteDataframe"] = queryValidationSpecRequired()
+	h.validators["GetVerifyShards"] = queryValidationSpecRequired().Optional("shards")
+	h.validators["InternalGetShardChecksums"] = queryValidationSpecRequired("shards")
+	h.validators["GetResultCache"] = queryValidationSpecRequired().Optional("index")
+	h.validators["DeleteResultCache"] = queryValidationSpecRequired().Optional("index")
+	h.validators["DeleteResultCacheEntry"] = queryValidationSpecRequired()
 }
 
 type contextKeyQuery int
@@ -516,11 +700,17 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/index/{index}/field/{field}", handler.chkAuthZ(handler.handleDeleteField, authz.Write)).Methods("DELETE").Name("DeleteField")
 	router.HandleFunc("/index/{index}/field/{field}/import", handler.chkAuthZ(handler.handlePostImport, authz.Write)).Methods("POST").Name("PostImport")
 	router.HandleFunc("/index/{index}/field/{field}/mutex-check", handler.chkAuthZ(handler.handleGetMutexCheck, authz.Read)).Methods("GET").Name("GetMutexCheck")
+	router.HandleFunc("/index/{index}/verify-shards", handler.chkAuthZ(handler.handleGetVerifyShards, authz.Admin)).Methods("GET").Name("GetVerifyShards")
 	router.HandleFunc("/index/{index}/field/{field}/import-roaring/{shard}", handler.chkAuthZ(handler.handlePostImportRoaring, authz.Write)).Methods("POST").Name("PostImportRoaring")
 	router.HandleFunc("/index/{index}/shard/{shard}/import-roaring", handler.chkAuthZ(handler.handlePostShardImportRoaring, authz.Write)).Methods("POST").Name("PostImportRoaring")
 	router.HandleFunc("/index/{index}/query", handler.chkAuthZ(handler.handlePostQuery, authz.Read)).Methods("POST").Name("PostQuery")
 	router.HandleFunc("/info", handler.chkAuthZ(handler.handleGetInfo, authz.Admin)).Methods("GET").Name("GetInfo")
+	router.HandleFunc("/read-only", handler.chkAuthZ(handler.handleGetReadOnly, authz.Read)).Methods("GET").Name("GetReadOnly")
+	router.HandleFunc("/read-only", handler.chkAuthZ(handler.handlePostReadOnly, authz.Admin)).Methods("POST").Name("PostReadOnly")
 	router.HandleFunc("/recalculate-caches", handler.chkAuthZ(handler.handleRecalculateCaches, authz.Admin)).Methods("POST").Name("RecalculateCaches")
+	router.HandleFunc("/result-cache", handler.chkAuthZ(handler.handleGetResultCache, authz.Admin)).Methods("GET").Name("GetResultCache")
+	router.HandleFunc("/result-cache", handler.chkAuthZ(handler.handleDeleteResultCache, authz.Admin)).Methods("DELETE").Name("DeleteResultCache")
+	router.HandleFunc("/result-cache/{id}", handler.chkAuthZ(handler.handleDeleteResultCacheEntry, authz.Admin)).Methods("DELETE").Name("DeleteResultCacheEntry")
 	router.HandleFunc("/schema", handler.chkAuthZ(handler.handleGetSchema, authz.Read)).Methods("GET").Name("GetSchema")
 	router.HandleFunc("/schema/details", handler.chkAuthZ(handler.handleGetSchemaDetails, authz.Read)).Methods("GET").Name("GetSchemaDetails")
 	router.HandleFunc("/schema", handler.chkAuthZ(handler.handlePostSchema, authz.Admin)).Methods("POST").Name("PostSchema")
@@ -557,6 +747,7 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/internal/mem-usage", handler.chkAuthZ(handler.handleGetMemUsage, authz.Read)).Methods("GET").Name("GetUsage")
 	router.HandleFunc("/internal/disk-usage", handler.chkAuthZ(handler.handleGetDiskUsage, authz.Read)).Methods("GET").Name("GetUsage")
 	router.HandleFunc("/internal/disk-usage/{index}", handler.chkAuthZ(handler.handleGetDiskUsage, authz.Read)).Methods("GET").Name("GetUsage")
+	router.HandleFunc("/internal/writelogger/retention", handler.chkAuthZ(handler.handleGetWriteloggerRetention, authz.Admin)).Methods("GET").Name("GetWriteloggerRetention")
 	router.HandleFunc("/internal/fragment/block/data", handler.chkAuthN(handler.handleGetFragmentBlockData)).Methods("GET").Name("GetFragmentBlockData")
 	router.HandleFunc("/internal/fragment/blocks", handler.chkAuthN(handler.handleGetFragmentBlocks)).Methods("GET").Name("GetFragmentBlocks")
 	router.HandleFunc("/internal/fragment/data", handler.chkAuthN(handler.handleGetFragmentData)).Methods("GET").Name("GetFragmentData")
@@ -565,7 +756,8 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/internal/translate/keys", handler.chkAuthN(handler.handlePostTranslateKeys)).Methods("POST").Name("PostTranslateKeys")
 	router.HandleFunc("/internal/translate/ids", handler.chkAuthN(handler.handlePostTranslateIDs)).Methods("POST").Name("PostTranslateIDs")
 	router.HandleFunc("/internal/index/{index}/field/{field}/mutex-check", handler.chkAuthZ(handler.handleInternalGetMutexCheck, authz.Read)).Methods("GET").Name("InternalGetMutexCheck")
-	router.HandleFunc("/internal/index/{index}/field/{field}/remote-available-shards/{shardID}", handler.chkAuthZ(handler.handleDeleteRemoteAvailableShard, authz.Admin)).Methods("DELETE")
+	router.HandleFunc("/internal/index/{index}/shard-checksums", handler.chkAuthN(handler.handleInternalGetShardChecksums)).Methods("GET").Name("InternalGetShardChecksums")
+	router.HandleFunc("/internal/index/{index}/field/{field}/remote-available-shards/{shardID}", handler.chkAuthZ(handler.handleDeleteRemoteAvailableShard, authz.Admin)).Methods("DELETE").Name("DeleteRemoteAvailableShard")
 	router.HandleFunc("/internal/index/{index}/shard/{shard}/snapshot", handler.chkAuthZ(handler.handleGetIndexShardSnapshot, authz.Read)).Methods("GET").Name("GetIndexShardSnapshot")
 	router.HandleFunc("/internal/index/{index}/shards", handler.chkAuthZ(handler.handleGetIndexAvailableShards, authz.Read)).Methods("GET").Name("GetIndexAvailableShards")
 	router.HandleFunc("/internal/nodes", handler.chkAuthN(handler.handleGetNodes)).Methods("GET").Name("GetNodes")
@@ -590,6 +782,14 @@ func newRouter(handler *Handler) http.Handler {
 
 	router.HandleFunc("/internal/debug/rbf", handler.chkAuthZ(handler.handleGetInternalDebugRBFJSON, authz.Admin)).Methods("GET").Name("GetInternalDebugRBFJSON")
 
+	// Fault injection, for chaos testing, is only routed when it's
+	// explicitly enabled.
+	if handler.faults != nil {
+		router.HandleFunc("/internal/faults", handler.chkAuthZ(handler.handleGetFaults, authz.Admin)).Methods("GET").Name("GetFaults")
+		router.HandleFunc("/internal/faults", handler.chkAuthZ(handler.handlePostFaults, authz.Admin)).Methods("POST").Name("PostFaults")
+		router.HandleFunc("/internal/faults", handler.chkAuthZ(handler.handleDeleteFaults, authz.Admin)).Methods("DELETE").Name("DeleteFaults")
+	}
+
 	// endpoints for collecting cpu profiles from a chosen begin point to
 	// when the client wants to stop. Used for profiling imports that
 	// could be long or short.
@@ -623,6 +823,18 @@ func newRouter(handler *Handler) http.Handler {
 		router.Path(route).Handler(latticeHandler)
 	}
 
+	if handler.compressor != nil {
+		router.Use(handler.compressor.Middleware)
+	}
+	if handler.decompressor != nil {
+		router.Use(handler.decompressor.Middleware)
+	}
+	router.Use(handler.applyRequestTimeout)
+	if handler.faults != nil {
+		router.Use(handler.injectFaults)
+	}
+	router.Use(handler.applyQueryPriority)
+	router.Use(handler.rejectWritesWhenReadOnly)
 	router.Use(handler.queryArgValidator)
 	router.Use(handler.addQueryContext)
 	router.Use(handler.extractTracing)
@@ -644,6 +856,7 @@ func newRouter(handler *Handler) http.Handler {
 
 // ServeHTTP handles an HTTP request.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer h.active.start(r)()
 	defer func() {
 		if err := recover(); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -972,6 +1185,11 @@ func (r *successResponse) check(err error) (statusCode int) {
 		statusCode = http.StatusInternalServerError
 	}
 
+	// Coded errors are matched by their code rather than their type.
+	if fberrors.Is(err, dax.ErrInsufficientStorage) {
+		statusCode = http.StatusInsufficientStorage
+	}
+
 	r.Success = false
 	r.Error = &HTTPError{Message: err.Error()}
 
@@ -1223,6 +1441,28 @@ func (h *Handler) handleGetDiskUsage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleGetWriteloggerRetention handles GET /internal/writelogger/retention
+// requests, which report the truncation of the write logs of each resource,
+// on a compute node whose writelogger truncates automatically.
+func (h *Handler) handleGetWriteloggerRetention(w http.ResponseWriter, r *http.Request) {
+	var wl interface{}
+	if h.api.serverlessStorage != nil {
+		wl = h.api.serverlessStorage.Writelogger
+	}
+	rs, ok := wl.(interface {
+		RetentionStatus() []writelogger.LogRetention
+	})
+	if !ok {
+		http.Error(w, "this node has no local writelogger", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rs.RetentionStatus()); err != nil {
+		h.logger.Errorf("write writelogger retention response error: %s", err)
+	}
+}
+
 // handleGetShardDistribution handles GET /ui/shard-distribution requests.
 func (h *Handler) handleGetShardDistribution(w http.ResponseWriter, r *http.Request) {
 	dist := h.api.ShardDistribution(r.Context())
@@ -1250,6 +1490,7 @@ func (h *Handler) handleGetStatus(w http.ResponseWriter, r *http.Request) {
 		Nodes:       h.api.Hosts(r.Context()),
 		LocalID:     h.api.Node().ID,
 		ClusterName: h.api.ClusterName(),
+		ReadOnly:    h.api.ReadOnly(),
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
@@ -1278,6 +1519,7 @@ type getStatusResponse struct {
 	Nodes       []*disco.Node `json:"nodes"`
 	LocalID     string        `json:"localID"`
 	ClusterName string        `json:"clusterName"`
+	ReadOnly    bool          `json:"readOnly"`
 }
 
 func httpHash(s string) string {
@@ -1331,11 +1573,43 @@ func (h *Handler) handlePostQuery(w http.ResponseWriter, r *http.Request) {
 	// TODO: Remove
 	req.Index = mux.Vars(r)["index"]
 
-	resp, err := h.api.Query(r.Context(), req)
+	// Queries forwarded from other nodes were admitted by the node which
+	// received them.
+	if !req.Remote {
+		release, err := h.queryLimiter.acquire(queryUser(r.Context()), req.MaxMemory)
+		if err != nil {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			if e := h.writeQueryResponse(w, r, &QueryResponse{Err: err}); e != nil {
+				h.logger.Errorf("write query response error: %v (while trying to write another error: %v)", e, err)
+			}
+			return
+		}
+		defer release()
+	}
+
+	route, err := h.queryRoute(r)
+	if err != nil {
+		h.writeQueryRouteError(w, r, err)
+		return
+	}
+	ctx := r.Context()
+	if route != nil {
+		ctx = WithQueryRoute(ctx, route)
+	}
+
+	start := time.Now()
+	resp, err := h.api.Query(ctx, req)
+	w.Header().Set(HeaderServerTiming, formatServerTimingExec(time.Since(start)))
+	w.Header().Set(HeaderQueryLoad, strconv.FormatInt(h.api.queryLoad(), 10))
 	if err != nil {
 		switch errors.Cause(err) {
 		case ErrTooManyWrites:
 			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		case ErrTooManyShards:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		case ErrReadOnly:
+			w.WriteHeader(http.StatusServiceUnavailable)
 		case ErrTranslateStoreReadOnly:
 			u := h.api.PrimaryReplicaNodeURL()
 			u.Path, u.RawQuery = r.URL.Path, r.URL.RawQuery
@@ -1369,6 +1643,83 @@ func (h *Handler) handlePostQuery(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// formatServerTimingExec returns a Server-Timing header value reporting d as
+// the query execution time.
+func formatServerTimingExec(d time.Duration) string {
+	return "exec;dur=" + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// parseServerTimingExec returns the query execution time reported in a
+// Server-Timing header value, or 0 if it doesn't report one.
+func parseServerTimingExec(header string) time.Duration {
+	for _, metric := range strings.Split(header, ",") {
+		params := strings.Split(strings.TrimSpace(metric), ";")
+		if params[0] != "exec" {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "dur=") {
+				continue
+			}
+			ms, err := strconv.ParseFloat(strings.TrimPrefix(param, "dur="), 64)
+			if err != nil {
+				return 0
+			}
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return 0
+}
+
+// queryRoute returns the QueryRoute requested in the HeaderQueryRoute header
+// of r, if any. Since pinning a query's shards to nodes exposes and overrides
+// the cluster's routing, it's reserved for administrators; a request from
+// anyone else which carries the header is rejected with errQueryRouteDenied.
+func (h *Handler) queryRoute(r *http.Request) (*QueryRoute, error) {
+	v := r.Header.Get(HeaderQueryRoute)
+	if v == "" {
+		return nil, nil
+	}
+	if !h.isAdmin(r.Context()) {
+		return nil, errQueryRouteDenied
+	}
+	return ParseQueryRoute(v)
+}
+
+// errQueryRouteDenied is returned by Handler.queryRoute for a request which
+// may not pin its routing.
+var errQueryRouteDenied = errors.New("query routing may only be requested by an administrator")
+
+// isAdmin returns true if the authenticated user making a request, whose
+// context is ctx, is an administrator. Everyone is, if authentication is off.
+func (h *Handler) isAdmin(ctx context.Context) bool {
+	if h.auth == nil {
+		return true
+	}
+	if h.permissions == nil {
+		return false
+	}
+	for _, g := range queryGroups(ctx) {
+		if g == h.permissions.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// writeQueryRouteError writes the error returned by Handler.queryRoute.
+func (h *Handler) writeQueryRouteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == errQueryRouteDenied {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	if e := h.writeQueryResponse(w, r, &QueryResponse{Err: err}); e != nil {
+		h.logger.Errorf("write query response error: %v (while trying to write another error: %v)", e, err)
+	}
+}
+
 func (h *Handler) writeBadRequest(w http.ResponseWriter, r *http.Request, err error) {
 	w.WriteHeader(http.StatusBadRequest)
 	e := h.writeQueryResponse(w, r, &QueryResponse{Err: err})
@@ -1436,7 +1787,8 @@ func (h *Handler) handlePostSQLPlanOperator(w http.ResponseWriter, r *http.Reque
 
 // handlePostSQL handles /sql requests
 // supports a ?plan=true|false parameter to send back the plan in the
-// query response
+// query response, which includes the routing of any shards pinned by the
+// HeaderQueryRoute header
 func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 	includePlan := false
 	includePlanValue := r.URL.Query().Get("plan")
@@ -1448,6 +1800,16 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	flushPolicy, err := h.resultFlush.withRequest(r)
+	if err != nil {
+		h.writeBadRequest(w, r, err)
+		return
+	}
+	route, err := h.queryRoute(r)
+	if err != nil {
+		h.writeQueryRouteError(w, r, err)
+		return
+	}
 
 	// get the body
 	b, err := io.ReadAll(r.Body)
@@ -1462,6 +1824,9 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 	}
 	// put the requestId in the context
 	ctx := fbcontext.WithRequestID(r.Context(), requestID.String())
+	if route != nil {
+		ctx = WithQueryRoute(ctx, route)
+	}
 
 	// update the counter for requests
 	PerfCounterSQLRequestSec.Add(1)
@@ -1469,6 +1834,23 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 	// Write response back to client.
 	w.Header().Set("Content-Type", "application/json")
 
+	// A write to a read-only node is rejected by CompilePlan below, which
+	// reports the error in the body; the status has to be set up front.
+	if errors.Cause(h.api.checkReadOnlySQL(string(b))) == ErrReadOnly {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	// Likewise, a query beyond its user's or the node's concurrency limit is
+	// rejected below,
+	// once the response has been opened.
+	release, limitErr := h.queryLimiter.acquire(queryUser(ctx), 0)
+	if limitErr != nil {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	} else {
+		defer release()
+	}
+
 	// the pandas data frame format in json
 
 	// Opening bracket.
@@ -1497,6 +1879,7 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 	// output handling to insert an error into the json output.
 	writeError := func(err error, withComma bool) {
 		if err != nil {
+			code := WireErrorCode(err)
 			errMsg, err := json.Marshal(err.Error())
 			if err != nil {
 				errMsg = []byte(`"PROBLEM ENCODING ERROR MESSAGE"`)
@@ -1507,6 +1890,11 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 				w.Write([]byte(`"error":`))
 			}
 			w.Write(errMsg)
+			if code != "" {
+				w.Write([]byte(`,"error-code":`))
+				codeMsg, _ := json.Marshal(code)
+				w.Write(codeMsg)
+			}
 		}
 	}
 
@@ -1531,6 +1919,9 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 
 	writePlan := func(plan map[string]interface{}) {
 		if plan != nil && includePlan {
+			if route != nil {
+				plan["routes"] = route.Decisions()
+			}
 			planBytes, err := json.Marshal(plan)
 			if err != nil {
 				planBytes = []byte(`"PROBLEM ENCODING QUERY PLAN"`)
@@ -1540,6 +1931,11 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if limitErr != nil {
+		writeError(limitErr, false)
+		return
+	}
+
 	sql := string(b)
 	rootOperator, err := h.api.CompilePlan(ctx, sql)
 	if err != nil {
@@ -1586,8 +1982,10 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(jsonSchema)
 
-	// Write the data (rows).
+	// Write the data (rows), in batches flushed according to the flush
+	// policy.
 	w.Write([]byte(`,"data":[`))
+	flusher := newResultFlusher(w, flushPolicy)
 
 	var rowErr error
 	var currentRow types.Row
@@ -1604,20 +2002,22 @@ func (h *Handler) handlePostSQL(w http.ResponseWriter, r *http.Request) {
 
 		if rowCounter > 1 {
 			// Include a comma between data rows.
-			w.Write([]byte(","))
+			flusher.writeRow([]byte(","), jsonRow)
+		} else {
+			flusher.writeRow(jsonRow)
 		}
-		w.Write(jsonRow)
 
 		rowCounter++
 	}
 	if nextErr != nil && nextErr != types.ErrNoMoreRows {
 		rowErr = nextErr
 	}
+	flusher.close()
 
 	w.Write([]byte("]"))
 
 	writeError(rowErr, true)
-	writeWarnings(rootOperator.Warnings())
+	writeWarnings(append(rootOperator.Warnings(), route.Warnings()...))
 	writePlan(rootOperator.Plan())
 }
 
@@ -3333,6 +3733,67 @@ func (h *Handler) handleInternalGetMutexCheck(w http.ResponseWriter, r *http.Req
 	}
 }
 
+// handleGetVerifyShards handles /verify-shards requests, which compare the
+// checksums of each replica of an index's shards.
+func (h *Handler) handleGetVerifyShards(w http.ResponseWriter, r *http.Request) {
+	if !validHeaderAcceptJSON(r.Header) {
+		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
+		return
+	}
+	indexName := mux.Vars(r)["index"]
+	shards, err := parseUint64Slice(r.URL.Query().Get("shards"))
+	if err != nil {
+		http.Error(w, "shards must be a comma-separated list of unsigned integers", http.StatusBadRequest)
+		return
+	}
+	out, err := h.api.VerifyShards(r.Context(), indexName, shards)
+	if err != nil {
+		http.Error(w, err.Error(), shardChecksumErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		h.logger.Errorf("writing verify-shards response: %v", err)
+	}
+}
+
+// handleInternalGetShardChecksums handles internal (non-forwarding)
+// /shard-checksums requests.
+func (h *Handler) handleInternalGetShardChecksums(w http.ResponseWriter, r *http.Request) {
+	if !validHeaderAcceptJSON(r.Header) {
+		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
+		return
+	}
+	indexName := mux.Vars(r)["index"]
+	shards, err := parseUint64Slice(r.URL.Query().Get("shards"))
+	if err != nil {
+		http.Error(w, "shards must be a comma-separated list of unsigned integers", http.StatusBadRequest)
+		return
+	}
+	out, err := h.api.ShardChecksumsNode(r.Context(), indexName, shards)
+	if err != nil {
+		http.Error(w, err.Error(), shardChecksumErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		h.logger.Errorf("writing shard-checksums response: %v", err)
+	}
+}
+
+// shardChecksumErrorStatus returns the http status for an error returned by
+// VerifyShards or ShardChecksumsNode.
+func shardChecksumErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrIndexNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &BadRequestError{}):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // handlePostImportRoaring
 func (h *Handler) handlePostImportRoaring(w http.ResponseWriter, r *http.Request) {
 	// Verify that request is only communicating over protobufs.
@@ -3462,6 +3923,8 @@ func (h *Handler) handlePostShardImportRoaring(w http.ResponseWriter, r *http.Re
 			w.WriteHeader(http.StatusNotFound)
 		} else if errors.As(err, &BadRequestError{}) {
 			w.WriteHeader(http.StatusBadRequest)
+		} else if errors.As(err, &ConflictError{}) {
+			w.WriteHeader(http.StatusConflict)
 		} else if _, ok := errors.Cause(err).(NotFoundError); ok {
 			w.WriteHeader(http.StatusNotFound)
 		} else if errors.As(err, &PreconditionFailedError{}) {
@@ -3872,7 +4335,11 @@ func (h *Handler) handlePostRestore(w http.ResponseWriter, r *http.Request) {
 	// validate shard for this node
 	err = h.api.RestoreShard(ctx, indexName, shard, r.Body)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to restore shard %v %v err:%v", indexName, shard, err), http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrCorruptShardSnapshot) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, fmt.Sprintf("failed to restore shard %v %v err:%v", indexName, shard, err), status)
 		return
 	}
 
@@ -1058,3 +1058,23 @@ func Test_scrubPath(t *testing.T) {
 		})
 	}
 }
+
+func TestServerTimingExec(t *testing.T) {
+	for _, d := range []time.Duration{0, time.Microsecond, 1234567 * time.Microsecond} {
+		if got := parseServerTimingExec(formatServerTimingExec(d)); got != d {
+			t.Errorf("round trip of %s: got %s", d, got)
+		}
+	}
+	for header, expected := range map[string]time.Duration{
+		"":                            0,
+		"db;dur=53":                   0,
+		"db;dur=53, exec;dur=1.5":     1500 * time.Microsecond,
+		`exec;desc="query";dur=2`:     2 * time.Millisecond,
+		"exec;dur=notanumber":         0,
+		"cache;desc=hit, exec; dur=4": 4 * time.Millisecond,
+	} {
+		if got := parseServerTimingExec(header); got != expected {
+			t.Errorf("parsing %q: expected %s, got %s", header, expected, got)
+		}
+	}
+}
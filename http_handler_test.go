@@ -562,6 +562,7 @@ func TestHandlerSQL(t *testing.T) {
 		url     string
 		sql     string
 		expKeys []string
+		expCode string
 	}{
 		{
 			name:    "sql",
@@ -575,12 +576,25 @@ func TestHandlerSQL(t *testing.T) {
 			sql:     "show tables",
 			expKeys: []string{"schema", "data", "query-plan", "execution-time"},
 		},
+		{
+			name:    "sql-with-flush-policy",
+			url:     "/sql?flushRows=1&flushInterval=10",
+			sql:     "show tables",
+			expKeys: []string{"schema", "data", "execution-time"},
+		},
 		{
 			name:    "invalid-sql",
 			url:     "/sql",
 			sql:     "invalid sql",
 			expKeys: []string{"error", "execution-time"},
 		},
+		{
+			name:    "missing-table",
+			url:     "/sql",
+			sql:     "select * from no_such_table",
+			expKeys: []string{"error", "error-code", "execution-time"},
+			expCode: "ErrTableNotFound",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -599,10 +613,70 @@ func TestHandlerSQL(t *testing.T) {
 			}
 
 			assert.ElementsMatch(t, tt.expKeys, keys)
+			if tt.expCode != "" {
+				assert.Equal(t, tt.expCode, out["error-code"])
+			}
 		})
 	}
 }
 
+// TestHandlerSQLQueryRoute tests that the routing of shards pinned with the
+// query route header is reported in the query plan.
+func TestHandlerSQLQueryRoute(t *testing.T) {
+	c := test.MustRunCluster(t, 1)
+	defer c.Close()
+	m := c.GetPrimary()
+
+	tbl := c.Idx("r")
+	for _, sql := range []string{
+		fmt.Sprintf("create table %s (_id id, n int)", tbl),
+		fmt.Sprintf("insert into %s values (1, 10), (2, 20)", tbl),
+	} {
+		if resp := test.Do(t, "POST", m.URL()+"/sql", sql); resp.StatusCode != http.StatusOK {
+			t.Fatalf("post sql %q, status: %d, body=%s", sql, resp.StatusCode, resp.Body)
+		}
+	}
+
+	query := func(route string) (int, map[string]interface{}) {
+		t.Helper()
+		req, err := http.NewRequest("POST", m.URL()+"/sql?plan=1", strings.NewReader(fmt.Sprintf("select count(*) from %s", tbl)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(pilosa.HeaderQueryRoute, route)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out := make(map[string]interface{})
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out
+	}
+
+	id := m.API.Node().ID
+	code, out := query("*=" + id)
+	assert.Equal(t, http.StatusOK, code)
+	plan, _ := out["query-plan"].(map[string]interface{})
+	routes, _ := plan["routes"].([]interface{})
+	// Tables read while planning the query, such as the views table, are
+	// routed too.
+	assert.Contains(t, routes, map[string]interface{}{
+		"index":     tbl,
+		"shards":    []interface{}{0.0},
+		"requested": id,
+		"node":      id,
+	})
+	assert.Nil(t, out["warnings"])
+
+	code, out = query("*=no-such-node")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, out["warnings"], fmt.Sprintf("node 'no-such-node' does not hold an available replica of shards [0] of index '%s'; they were routed to '%s'", tbl, id))
+
+	code, _ = query("0")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
 func TestTranslationHandlers(t *testing.T) {
 	// reusable data for the tests
 	nameBytes, err := json.Marshal([]string{"a", "b", "c"})
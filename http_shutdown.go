@@ -0,0 +1,221 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Default shutdown phase timeouts. They sum to the 30 seconds which Close
+// used to wait before forcibly closing the server.
+const (
+	DefaultShutdownDrainTimeout      = 20 * time.Second
+	DefaultShutdownCancelTimeout     = 8 * time.Second
+	DefaultShutdownForceCloseTimeout = 2 * time.Second
+)
+
+// maxLoggedRequests is the most in-flight requests which are listed
+// individually when logging a shutdown phase transition.
+const maxLoggedRequests = 10
+
+// ShutdownTimeouts bounds each phase of the HTTP server's shutdown. Close
+// escalates through the phases in order, moving on to the next as soon as
+// the current one's timeout expires, and returning as soon as no requests
+// remain:
+//
+//  1. Drain: the listener is closed, so that no new connections are
+//     accepted, and in-flight requests are given Drain to finish.
+//  2. Cancel: the contexts of the requests still in flight are cancelled,
+//     which interrupts long-running queries, and they're given Cancel to
+//     return.
+//  3. Force close: every connection is closed, and handlers which are still
+//     running are given ForceClose to return before Close gives up on them.
+type ShutdownTimeouts struct {
+	Drain      time.Duration
+	Cancel     time.Duration
+	ForceClose time.Duration
+}
+
+// withDefaults returns t with each zero timeout replaced by its default.
+func (t ShutdownTimeouts) withDefaults() ShutdownTimeouts {
+	if t.Drain == 0 {
+		t.Drain = DefaultShutdownDrainTimeout
+	}
+	if t.Cancel == 0 {
+		t.Cancel = DefaultShutdownCancelTimeout
+	}
+	if t.ForceClose == 0 {
+		t.ForceClose = DefaultShutdownForceCloseTimeout
+	}
+	return t
+}
+
+// Total returns the longest that a shutdown with these timeouts can take.
+func (t ShutdownTimeouts) Total() time.Duration {
+	return t.Drain + t.Cancel + t.ForceClose
+}
+
+// OptHandlerShutdownTimeouts sets the timeout of each phase of the HTTP
+// server's shutdown. Zero timeouts keep their defaults.
+func OptHandlerShutdownTimeouts(t ShutdownTimeouts) handlerOption {
+	return func(h *Handler) error {
+		if t.Drain < 0 || t.Cancel < 0 || t.ForceClose < 0 {
+			return errors.New("shutdown timeouts can't be negative")
+		}
+		h.shutdownTimeouts = t.withDefaults()
+		return nil
+	}
+}
+
+// activeRequests tracks the requests which the handler is serving, so that
+// shutdown can report what it's waiting for.
+type activeRequests struct {
+	mu       sync.Mutex
+	requests map[*http.Request]time.Time
+	idle     *sync.Cond
+}
+
+func newActiveRequests() *activeRequests {
+	a := &activeRequests{requests: make(map[*http.Request]time.Time)}
+	a.idle = sync.NewCond(&a.mu)
+	return a
+}
+
+// start records that r is being served, and returns a func to call when it's
+// done.
+func (a *activeRequests) start(r *http.Request) func() {
+	a.mu.Lock()
+	a.requests[r] = time.Now()
+	a.mu.Unlock()
+	return func() {
+		a.mu.Lock()
+		delete(a.requests, r)
+		if len(a.requests) == 0 {
+			a.idle.Broadcast()
+		}
+		a.mu.Unlock()
+	}
+}
+
+// len returns the number of requests in flight.
+func (a *activeRequests) len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.requests)
+}
+
+// wait returns a channel which is closed once no requests are in flight.
+func (a *activeRequests) wait() <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		a.mu.Lock()
+		for len(a.requests) > 0 {
+			a.idle.Wait()
+		}
+		a.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// String describes the requests in flight, longest-running first.
+func (a *activeRequests) String() string {
+	now := time.Now()
+	a.mu.Lock()
+	type request struct {
+		desc string
+		age  time.Duration
+	}
+	descs := make([]request, 0, len(a.requests))
+	for r, started := range a.requests {
+		descs = append(descs, request{r.Method + " " + r.URL.Path, now.Sub(started)})
+	}
+	a.mu.Unlock()
+	if len(descs) == 0 {
+		return "none"
+	}
+
+	sort.Slice(descs, func(i, j int) bool { return descs[i].age > descs[j].age })
+	parts := make([]string, 0, maxLoggedRequests+1)
+	for i, d := range descs {
+		if i == maxLoggedRequests {
+			parts = append(parts, fmt.Sprintf("and %d more", len(descs)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", d.desc, d.age.Round(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// baseContext returns the context from which every request's context is
+// derived, which is cancelled in the second phase of shutdown.
+func (h *Handler) baseContext(net.Listener) context.Context {
+	return h.requestCtx
+}
+
+// Close shuts down the HTTP server, escalating through the phases described
+// by ShutdownTimeouts.
+func (h *Handler) Close() error {
+	t := h.shutdownTimeouts
+	start := time.Now()
+
+	shutdownCtx, cancelShutdown := context.WithCancel(context.Background())
+	defer cancelShutdown()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- h.server.Shutdown(shutdownCtx) }()
+
+	// Shutdown returns once every connection is idle, but a handler may
+	// still be running after its connection has been hijacked or closed, so
+	// both are waited for.
+	idle := h.active.wait()
+	var shutdownErr error
+	wait := func(timeout time.Duration) bool {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for shutdown != nil || idle != nil {
+			select {
+			case shutdownErr = <-shutdown:
+				shutdown = nil
+			case <-idle:
+				idle = nil
+			case <-timer.C:
+				return false
+			}
+		}
+		return true
+	}
+
+	h.logger.Infof("http shutdown: draining %d in-flight requests for up to %s", h.active.len(), t.Drain)
+	if wait(t.Drain) {
+		h.cancelRequests()
+		return errors.Wrap(shutdownErr, "shutdown http server")
+	}
+
+	h.logger.Warnf("http shutdown: requests still active after draining for %s, cancelling them: %s", time.Since(start).Round(time.Millisecond), h.active)
+	h.cancelRequests()
+	if wait(t.Cancel) {
+		return errors.Wrap(shutdownErr, "shutdown http server")
+	}
+
+	h.logger.Warnf("http shutdown: requests still active after cancelling for %s, closing connections: %s", time.Since(start).Round(time.Millisecond), h.active)
+	cancelShutdown()
+	err := h.server.Close()
+	if shutdown != nil {
+		// Shutdown returns as soon as its context is cancelled.
+		<-shutdown
+		shutdown = nil
+	}
+	if !wait(t.ForceClose) {
+		h.logger.Errorf("http shutdown: gave up after %s with requests still active: %s", time.Since(start).Round(time.Millisecond), h.active)
+	}
+	return errors.Wrap(err, "close http server")
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+func TestHandlerClose(t *testing.T) {
+	// serve starts a Handler which serves every request with fn, and returns
+	// it once a request to it is in flight.
+	serve := func(t *testing.T, timeouts ShutdownTimeouts, fn http.HandlerFunc) *Handler {
+		t.Helper()
+		h := &Handler{
+			logger:           logger.NopLogger,
+			shutdownTimeouts: timeouts,
+			active:           newActiveRequests(),
+		}
+		h.requestCtx, h.cancelRequests = context.WithCancel(context.Background())
+		started := make(chan struct{})
+		h.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			fn(w, r)
+		})
+		h.server = &http.Server{Handler: h, BaseContext: h.baseContext}
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { _ = h.server.Serve(ln) }()
+		go func() {
+			resp, err := http.Get("http://" + ln.Addr().String() + "/")
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		<-started
+		return h
+	}
+
+	t.Run("Drain", func(t *testing.T) {
+		h := serve(t, ShutdownTimeouts{Drain: 10 * time.Second, Cancel: 10 * time.Second, ForceClose: 10 * time.Second},
+			func(w http.ResponseWriter, r *http.Request) { time.Sleep(50 * time.Millisecond) })
+		start := time.Now()
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Fatalf("expected close to return once the request finished, took %s", d)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		cancelled := make(chan struct{})
+		h := serve(t, ShutdownTimeouts{Drain: 50 * time.Millisecond, Cancel: 10 * time.Second, ForceClose: 10 * time.Second},
+			func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				close(cancelled)
+			})
+		start := time.Now()
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(start); d < 50*time.Millisecond || d > 5*time.Second {
+			t.Fatalf("expected close to return once the request was cancelled, took %s", d)
+		}
+		select {
+		case <-cancelled:
+		default:
+			t.Fatal("expected the request's context to be cancelled")
+		}
+	})
+
+	t.Run("ForceClose", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		h := serve(t, ShutdownTimeouts{Drain: 20 * time.Millisecond, Cancel: 20 * time.Millisecond, ForceClose: 20 * time.Millisecond},
+			func(w http.ResponseWriter, r *http.Request) { <-release })
+		start := time.Now()
+		_ = h.Close()
+		if d := time.Since(start); d < 60*time.Millisecond || d > 5*time.Second {
+			t.Fatalf("expected close to give up after every phase timed out, took %s", d)
+		}
+		if n := h.active.len(); n != 1 {
+			t.Fatalf("expected the stuck request to still be active, got %d", n)
+		}
+	})
+
+	t.Run("CloseTimeout", func(t *testing.T) {
+		h := &Handler{shutdownTimeouts: ShutdownTimeouts{}.withDefaults()}
+		if err := OptHandlerCloseTimeout(0)(h); err != nil {
+			t.Fatal(err)
+		} else if h.shutdownTimeouts.Total() != 30*time.Second {
+			t.Fatalf("expected default timeouts to total 30s, got %s", h.shutdownTimeouts.Total())
+		}
+		if err := OptHandlerCloseTimeout(time.Second)(h); err != nil {
+			t.Fatal(err)
+		} else if h.shutdownTimeouts != (ShutdownTimeouts{Drain: time.Second}) {
+			t.Fatalf("unexpected timeouts: %+v", h.shutdownTimeouts)
+		}
+	})
+}
@@ -109,9 +109,27 @@ func (m *importer) CreateTableKeys(ctx context.Context, tid dax.TableID, keys ..
 }
 
 func (m *importer) CreateFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	fbClient, cfld, err := m.fieldKeysClient(ctx, tid, fname)
+	if err != nil {
+		return nil, err
+	}
+	return fbClient.CreateFieldKeys(cfld, keys...)
+}
+
+func (m *importer) FindFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	fbClient, cfld, err := m.fieldKeysClient(ctx, tid, fname)
+	if err != nil {
+		return nil, err
+	}
+	return fbClient.FindFieldKeys(cfld, keys...)
+}
+
+// fieldKeysClient returns a client of the node which translates the keys of
+// the field of the table, along with the client's field.
+func (m *importer) fieldKeysClient(ctx context.Context, tid dax.TableID, fname dax.FieldName) (*fbclient.Client, *fbclient.Field, error) {
 	qtbl, err := m.getQtbl(ctx, tid)
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting qtbl")
+		return nil, nil, errors.Wrapf(err, "getting qtbl")
 	}
 
 	// For now, we are going to direct all field key translation to the same
@@ -123,21 +141,21 @@ func (m *importer) CreateFieldKeys(ctx context.Context, tid dax.TableID, fname d
 
 	address, err := m.controller.IngestPartition(context.Background(), qtbl.QualifiedID(), partition)
 	if err != nil {
-		return nil, errors.Wrapf(err, "calling ingest-partition on table: %s, partition: %d", qtbl, partition)
+		return nil, nil, errors.Wrapf(err, "calling ingest-partition on table: %s, partition: %d", qtbl, partition)
 	}
 
 	// Set up a FeatureBase client with address.
 	fbClient, err := m.fbClient(address)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting featurebase client")
+		return nil, nil, errors.Wrap(err, "getting featurebase client")
 	}
 
 	cfld, err := fbclient.TableFieldToClientField(qtbl, fname)
 	if err != nil {
-		return nil, errors.Wrap(err, "converting fieldinfo to client field")
+		return nil, nil, errors.Wrap(err, "converting fieldinfo to client field")
 	}
 
-	return fbClient.CreateFieldKeys(cfld, keys...)
+	return fbClient, cfld, nil
 }
 
 func (m *importer) ImportRoaringBitmap(ctx context.Context, tid dax.TableID, fld *dax.Field, shard uint64, views map[string]*roaring.Bitmap, clear bool) error {
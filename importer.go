@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/roaring"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -15,6 +16,9 @@ type Importer interface {
 	FinishTransaction(ctx context.Context, id string) (*Transaction, error)
 	CreateTableKeys(ctx context.Context, tid dax.TableID, keys ...string) (map[string]uint64, error)
 	CreateFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error)
+	// FindFieldKeys is like CreateFieldKeys, but keys which don't exist are
+	// omitted from the result rather than created.
+	FindFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error)
 	ImportRoaringBitmap(ctx context.Context, tid dax.TableID, fld *dax.Field, shard uint64, views map[string]*roaring.Bitmap, clear bool) error
 	ImportRoaringShard(ctx context.Context, tid dax.TableID, shard uint64, request *ImportRoaringShardRequest) error
 	EncodeImportValues(ctx context.Context, tid dax.TableID, fld *dax.Field, shard uint64, vals []int64, ids []uint64, clear bool) (path string, data []byte, err error)
@@ -59,6 +63,10 @@ func (i *onPremImporter) CreateFieldKeys(ctx context.Context, tid dax.TableID, f
 	return i.api.CreateFieldKeys(ctx, string(tid), string(fname), keys...)
 }
 
+func (i *onPremImporter) FindFieldKeys(ctx context.Context, tid dax.TableID, fname dax.FieldName, keys ...string) (map[string]uint64, error) {
+	return i.api.FindFieldKeys(ctx, string(tid), string(fname), keys...)
+}
+
 func (i *onPremImporter) ImportRoaringBitmap(ctx context.Context, tid dax.TableID, fld *dax.Field, shard uint64, views map[string]*roaring.Bitmap, clear bool) error {
 	// This intentionally no-ops. See comment on struct.
 	return nil
@@ -69,23 +77,38 @@ func (i *onPremImporter) ImportRoaringShard(ctx context.Context, tid dax.TableID
 	if err != nil {
 		return err
 	}
+	if len(request.Conditions) > 0 && len(nodes) > 0 {
+		// A conditional write is checked and applied on the primary
+		// first, so that concurrent conditional writes to the same
+		// record are decided in one place. The replicas are then sent
+		// only the writes which were applied.
+		if err := i.importRoaringShardOn(ctx, nodes[0], tid, shard, request); err != nil {
+			return errors.Wrap(err, "importing")
+		}
+		replicated := *request
+		replicated.Conditions = nil
+		request, nodes = &replicated, nodes[1:]
+	}
 	eg := errgroup.Group{}
 	for _, node := range nodes {
 		node := node
-		if node.ID == i.api.NodeID() { // local
-			eg.Go(func() error {
-				return i.api.ImportRoaringShard(ctx, string(tid), shard, request)
-			})
-		} else {
-			eg.Go(func() error { // forward on
-				return i.client.ImportRoaringShard(ctx, &node.URI, string(tid), shard, true, request)
-			})
-		}
+		eg.Go(func() error {
+			return i.importRoaringShardOn(ctx, node, tid, shard, request)
+		})
 	}
 	err = eg.Wait()
 	return errors.Wrap(err, "importing")
 }
 
+// importRoaringShardOn imports request on node, which may be this one.
+func (i *onPremImporter) importRoaringShardOn(ctx context.Context, node *disco.Node, tid dax.TableID, shard uint64, request *ImportRoaringShardRequest) error {
+	if node.ID == i.api.NodeID() { // local
+		return i.api.ImportRoaringShard(ctx, string(tid), shard, request)
+	}
+	// forward on
+	return i.client.ImportRoaringShard(ctx, &node.URI, string(tid), shard, true, request)
+}
+
 func (i *onPremImporter) EncodeImportValues(ctx context.Context, tid dax.TableID, fld *dax.Field, shard uint64, vals []int64, ids []uint64, clear bool) (path string, data []byte, err error) {
 	// This intentionally no-ops. See comment on struct.
 	return "", nil, nil
@@ -111,6 +111,7 @@ func WithClientRetryPeriod(period time.Duration) InternalClientOption {
 		rc.RetryMax = int(attempts)
 		rc.CheckRetry = retryWith400Policy
 		rc.Logger = logger.NopLogger
+		rc.RequestLogHook = requestTimeoutHook
 		c.retryableClient = rc
 	}
 }
@@ -190,6 +191,10 @@ func retryWith400Policy(ctx context.Context, resp *http.Response, err error) (bo
 			}
 		}
 	}
+	// A conflict would only happen again.
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		return false, nil
+	}
 	if resp != nil && resp.StatusCode >= 400 {
 		return true, nil
 	}
@@ -212,6 +217,7 @@ func NewInternalClientFromURI(defaultURI *pnet.URI, remoteClient *http.Client, o
 		rc.HTTPClient = ic.httpClient
 		rc.CheckRetry = noRetryPolicy
 		rc.Logger = logger.NopLogger
+		rc.RequestLogHook = requestTimeoutHook
 		ic.retryableClient = rc
 	}
 
@@ -232,6 +238,7 @@ func NewInternalClientFromURI(defaultURI *pnet.URI, remoteClient *http.Client, o
 	rc.RetryMax = ic.retryableClient.RetryMax
 	rc.CheckRetry = retryWith400Policy
 	rc.Logger = logger.NopLogger
+	rc.RequestLogHook = requestTimeoutHook
 	ic.authRetryableClient = rc
 	ic.authHttpClient = &authClient
 	return ic
@@ -450,6 +457,40 @@ func (c *InternalClient) MutexCheck(ctx context.Context, uri *pnet.URI, indexNam
 	return out, err
 }
 
+// ShardChecksums requests the checksums of the given shards, as held by a
+// single node.
+func (c *InternalClient) ShardChecksums(ctx context.Context, uri *pnet.URI, indexName string, shards []uint64) ([]ShardChecksum, error) {
+	if uri == nil {
+		uri = c.defaultURI
+	}
+	shardStrs := make([]string, len(shards))
+	for i, shard := range shards {
+		shardStrs[i] = strconv.FormatUint(shard, 10)
+	}
+	u := uri.Path(fmt.Sprintf("%s/internal/index/%s/shard-checksums?shards=%s", c.prefix(), indexName, strings.Join(shardStrs, ",")))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "pilosa/"+Version)
+	AddAuthToken(ctx, &req.Header)
+
+	resp, err := c.executeRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "executing request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status code: %s", resp.Status)
+	}
+	var out []ShardChecksum
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, errors.Wrap(err, "decoding response")
+	}
+	return out, nil
+}
+
 func (c *InternalClient) PostSchema(ctx context.Context, uri *pnet.URI, s *Schema, remote bool) error {
 	u := uri.Path(fmt.Sprintf("%s/schema?remote=%v", c.prefix(), remote))
 	buf, err := json.Marshal(s)
@@ -647,6 +688,12 @@ func (c *InternalClient) QueryNode(ctx context.Context, addr dax.Address, index
 	} else if qresp.Err != nil {
 		return nil, qresp.Err
 	}
+	qresp.ExecutionTime = parseServerTimingExec(resp.Header.Get(HeaderServerTiming))
+	qresp.Size = int64(len(body))
+	qresp.Load = -1
+	if load, err := strconv.ParseInt(resp.Header.Get(HeaderQueryLoad), 10, 64); err == nil {
+		qresp.Load = load
+	}
 
 	return qresp, nil
 }
@@ -1719,6 +1766,14 @@ type executeOpts struct {
 	forwardAuthHeader bool
 }
 
+// requestTimeoutHook is called before each attempt to send a request, so
+// that the timeout it carries is as of that attempt, rather than the first.
+// It also sets the request's query priority.
+func requestTimeoutHook(_ retryablehttp.Logger, req *http.Request, _ int) {
+	setRequestTimeoutHeaders(req)
+	setQueryPriorityHeader(req)
+}
+
 type executeRequestOption func(*executeOpts)
 
 func giveRawResponse(b bool) executeRequestOption {
@@ -2453,6 +2508,9 @@ func (c *InternalClient) executeProtobufRequest(ctx context.Context, url string,
 	// Execute request against the host.
 	resp, err := c.executeRequest(httpReq.WithContext(ctx))
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusConflict {
+			return newConflictError(err)
+		}
 		return err
 	}
 	defer resp.Body.Close()
@@ -54,6 +54,25 @@ const (
 	MetricPqlQueries                      = "pql_queries_total"
 	MetricSqlQueries                      = "sql_queries_total"
 	MetricDeleteDataframe                 = "delete_dataframe"
+	MetricQueryShardLimitExceeded         = "query_shard_limit_exceeded_total"
+	MetricQueryConcurrencyLimitExceeded   = "query_concurrency_limit_exceeded_total"
+	MetricQueriesInFlightByUser           = "queries_in_flight_by_user"
+	MetricQueryReservationSlots           = "query_reservation_slots"
+	MetricQueryReservationSlotsInUse      = "query_reservation_slots_in_use"
+	MetricQueryReservationSlotsLent       = "query_reservation_slots_lent"
+	MetricQueryReservationMemory          = "query_reservation_memory_bytes"
+	MetricQueryReservationMemoryInUse     = "query_reservation_memory_in_use_bytes"
+	MetricQueryReservationMemoryLent      = "query_reservation_memory_lent_bytes"
+	MetricHTTPCompressionBytesSaved       = "http_compression_bytes_saved_total"
+	MetricSnapshotsInProgress             = "snapshots_in_progress"
+	MetricSnapshotBytes                   = "snapshot_bytes_total"
+	MetricSnapshotThrottledSeconds        = "snapshot_throttled_seconds_total"
+	MetricQueryNodeRequests               = "query_node_requests_total"
+	MetricClockSkewSeconds                = "clock_skew_seconds"
+	MetricClockSkewExceeded               = "clock_skew_exceeded_total"
+	MetricQueryPreemptions                = "query_preemptions_total"
+	MetricQueryPreemptedSeconds           = "query_preempted_seconds_total"
+	MetricWriteConflicts                  = "write_conflicts_total"
 )
 
 const (
@@ -115,6 +134,212 @@ var CounterSQLQueries = prometheus.NewCounter(
 	},
 )
 
+var CounterQueryShardLimitExceeded = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryShardLimitExceeded,
+		Help:      "Number of queries rejected for touching more shards than their limit.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterQueryConcurrencyLimitExceeded = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryConcurrencyLimitExceeded,
+		Help:      "Number of queries rejected because their user already had as many queries executing as their limit.",
+	},
+	[]string{
+		"user",
+	},
+)
+
+var GaugeQueriesInFlightByUser = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueriesInFlightByUser,
+		Help:      "Number of queries executing for each user with at least one. Only tracked while query concurrency limits are configured.",
+	},
+	[]string{
+		"user",
+	},
+)
+
+var GaugeQueryReservationSlots = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryReservationSlots,
+		Help:      "Number of query slots reserved for each user with a reservation.",
+	},
+	[]string{
+		"user",
+	},
+)
+
+var GaugeQueryReservationSlotsInUse = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryReservationSlotsInUse,
+		Help:      "Number of reserved query slots held by each user's executing queries.",
+	},
+	[]string{
+		"user",
+	},
+)
+
+var GaugeQueryReservationSlotsLent = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryReservationSlotsLent,
+		Help:      "Number of unused reserved query slots held by queries from outside the reservations.",
+	},
+)
+
+var GaugeQueryReservationMemory = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryReservationMemory,
+		Help:      "Query memory reserved for each user with a reservation.",
+	},
+	[]string{
+		"user",
+	},
+)
+
+var GaugeQueryReservationMemoryInUse = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryReservationMemoryInUse,
+		Help:      "Reserved query memory held by each user's executing queries.",
+	},
+	[]string{
+		"user",
+	},
+)
+
+var GaugeQueryReservationMemoryLent = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryReservationMemoryLent,
+		Help:      "Unused reserved query memory held by queries from outside the reservations.",
+	},
+)
+
+var CounterHTTPCompressionBytesSaved = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricHTTPCompressionBytesSaved,
+		Help:      "Number of bytes by which compression reduced the size of HTTP responses.",
+	},
+)
+
+var CounterQueryNodeRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryNodeRequests,
+		Help:      "Number of requests for the shards of a query which were routed to each node, including this one.",
+	},
+	[]string{
+		"node",
+	},
+)
+
+var GaugeClockSkewSeconds = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricClockSkewSeconds,
+		Help:      "Apparent clock skew of each peer which has sent this node a request: how far its clock is behind this node's, including the request's time in transit. Negative if it's ahead.",
+	},
+	[]string{
+		"peer",
+	},
+)
+
+var CounterClockSkewExceeded = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricClockSkewExceeded,
+		Help:      "Number of requests from each peer whose apparent clock skew was beyond the clock skew tolerance.",
+	},
+	[]string{
+		"peer",
+	},
+)
+
+var CounterQueryPreemptions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryPreemptions,
+		Help:      "Number of times a batch query waited for interactive queries before processing a shard, by how the wait ended: resumed, max-wait or canceled.",
+	},
+	[]string{
+		"outcome",
+	},
+)
+
+var CounterWriteConflicts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricWriteConflicts,
+		Help:      "Number of conditional writes rejected because a record's values didn't match their conditions.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterQueryPreemptedSeconds = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryPreemptedSeconds,
+		Help:      "Total time batch queries spent waiting for interactive queries.",
+	},
+)
+
+var HistogramSnapshotDurationSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "pilosa",
+		Name:      MetricSnapshotDurationSeconds,
+		Help:      "Time taken to snapshot shard data, by priority.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 9),
+	},
+	[]string{
+		"priority",
+	},
+)
+
+var GaugeSnapshotsInProgress = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricSnapshotsInProgress,
+		Help:      "Number of shard snapshots being taken, by priority.",
+	},
+	[]string{
+		"priority",
+	},
+)
+
+var CounterSnapshotBytes = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricSnapshotBytes,
+		Help:      "Number of bytes of shard data written to snapshots, by priority.",
+	},
+	[]string{
+		"priority",
+	},
+)
+
+var CounterSnapshotThrottledSeconds = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricSnapshotThrottledSeconds,
+		Help:      "Time background snapshots spent waiting for the snapshot budget.",
+	},
+)
+
 var CounterGarbageCollection = prometheus.NewCounter(
 	prometheus.CounterOpts{
 		Namespace: "pilosa",
@@ -763,6 +988,17 @@ var CounterQueryPercentileTotal = prometheus.NewCounterVec(
 	},
 )
 
+var CounterQuerySketchTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      "query_sketch_total",
+		Help:      "Total number of Sketch() queries.",
+	},
+	[]string{
+		"index",
+	},
+)
+
 var CounterQueryDeleteTotal = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Namespace: "pilosa",
@@ -959,6 +1195,26 @@ func init() {
 	prometheus.MustRegister(GaugeWorkerTotal)
 	prometheus.MustRegister(CounterPQLQueries)
 	prometheus.MustRegister(CounterSQLQueries)
+	prometheus.MustRegister(CounterQueryShardLimitExceeded)
+	prometheus.MustRegister(CounterQueryConcurrencyLimitExceeded)
+	prometheus.MustRegister(GaugeQueriesInFlightByUser)
+	prometheus.MustRegister(GaugeQueryReservationSlots)
+	prometheus.MustRegister(GaugeQueryReservationSlotsInUse)
+	prometheus.MustRegister(GaugeQueryReservationSlotsLent)
+	prometheus.MustRegister(GaugeQueryReservationMemory)
+	prometheus.MustRegister(GaugeQueryReservationMemoryInUse)
+	prometheus.MustRegister(GaugeQueryReservationMemoryLent)
+	prometheus.MustRegister(CounterHTTPCompressionBytesSaved)
+	prometheus.MustRegister(CounterQueryNodeRequests)
+	prometheus.MustRegister(GaugeClockSkewSeconds)
+	prometheus.MustRegister(CounterClockSkewExceeded)
+	prometheus.MustRegister(CounterQueryPreemptions)
+	prometheus.MustRegister(CounterQueryPreemptedSeconds)
+	prometheus.MustRegister(CounterWriteConflicts)
+	prometheus.MustRegister(HistogramSnapshotDurationSeconds)
+	prometheus.MustRegister(GaugeSnapshotsInProgress)
+	prometheus.MustRegister(CounterSnapshotBytes)
+	prometheus.MustRegister(CounterSnapshotThrottledSeconds)
 	prometheus.MustRegister(CounterGarbageCollection)
 	prometheus.MustRegister(GaugeGoroutines)
 	prometheus.MustRegister(GaugeOpenFiles)
@@ -1029,6 +1285,7 @@ func init() {
 	prometheus.MustRegister(CounterQueryConstRowTotal)
 	prometheus.MustRegister(CounterQueryLimitTotal)
 	prometheus.MustRegister(CounterQueryPercentileTotal)
+	prometheus.MustRegister(CounterQuerySketchTotal)
 	prometheus.MustRegister(CounterQueryDeleteTotal)
 	prometheus.MustRegister(CounterQuerySortTotal)
 	prometheus.MustRegister(CounterQueryApplyTotal)
@@ -1663,6 +1663,7 @@ type QueryResult struct {
 	DataFrame               *DataFrame               `protobuf:"bytes,18,opt,name=DataFrame,proto3" json:"DataFrame,omitempty"`
 	ArrowTable              *ArrowTable              `protobuf:"bytes,19,opt,name=ArrowTable,proto3" json:"ArrowTable,omitempty"`
 	ExtractedIDMatrixSorted *ExtractedIDMatrixSorted `protobuf:"bytes,20,opt,name=ExtractedIDMatrixSorted,proto3" json:"ExtractedIDMatrixSorted,omitempty"`
+	Sketch                  *Sketch                  `protobuf:"bytes,21,opt,name=Sketch,proto3" json:"Sketch,omitempty"`
 	XXX_NoUnkeyedLiteral    struct{}                 `json:"-"`
 	XXX_unrecognized        []byte                   `json:"-"`
 	XXX_sizecache           int32                    `json:"-"`
@@ -1841,6 +1842,13 @@ func (m *QueryResult) GetExtractedIDMatrixSorted() *ExtractedIDMatrixSorted {
 	return nil
 }
 
+func (m *QueryResult) GetSketch() *Sketch {
+	if m != nil {
+		return m.Sketch
+	}
+	return nil
+}
+
 type ImportRequest struct {
 	Index                string   `protobuf:"bytes,1,opt,name=Index,proto3" json:"Index,omitempty"`
 	Field                string   `protobuf:"bytes,2,opt,name=Field,proto3" json:"Field,omitempty"`
@@ -2671,11 +2679,12 @@ func (m *RoaringUpdate) GetClearRecords() bool {
 }
 
 type ImportRoaringShardRequest struct {
-	Remote               bool             `protobuf:"varint,1,opt,name=Remote,proto3" json:"Remote,omitempty"`
-	Views                []*RoaringUpdate `protobuf:"bytes,2,rep,name=Views,proto3" json:"Views,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	Remote               bool              `protobuf:"varint,1,opt,name=Remote,proto3" json:"Remote,omitempty"`
+	Views                []*RoaringUpdate  `protobuf:"bytes,2,rep,name=Views,proto3" json:"Views,omitempty"`
+	Conditions           []*WriteCondition `protobuf:"bytes,3,rep,name=Conditions,proto3" json:"Conditions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *ImportRoaringShardRequest) Reset()         { *m = ImportRoaringShardRequest{} }
@@ -2725,6 +2734,92 @@ func (m *ImportRoaringShardRequest) GetViews() []*RoaringUpdate {
 	return nil
 }
 
+func (m *ImportRoaringShardRequest) GetConditions() []*WriteCondition {
+	if m != nil {
+		return m.Conditions
+	}
+	return nil
+}
+
+type WriteCondition struct {
+	Field                string   `protobuf:"bytes,1,opt,name=Field,proto3" json:"Field,omitempty"`
+	Column               uint64   `protobuf:"varint,2,opt,name=Column,proto3" json:"Column,omitempty"`
+	Absent               bool     `protobuf:"varint,3,opt,name=Absent,proto3" json:"Absent,omitempty"`
+	Value                int64    `protobuf:"varint,4,opt,name=Value,proto3" json:"Value,omitempty"`
+	Rows                 []uint64 `protobuf:"varint,5,rep,packed,name=Rows,proto3" json:"Rows,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WriteCondition) Reset()         { *m = WriteCondition{} }
+func (m *WriteCondition) String() string { return proto.CompactTextString(m) }
+func (*WriteCondition) ProtoMessage()    {}
+func (*WriteCondition) Descriptor() ([]byte, []int) {
+	return fileDescriptor_413a91106d7bcce8, []int{38}
+}
+func (m *WriteCondition) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WriteCondition) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WriteCondition.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WriteCondition) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteCondition.Merge(m, src)
+}
+func (m *WriteCondition) XXX_Size() int {
+	return m.Size()
+}
+func (m *WriteCondition) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteCondition.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteCondition proto.InternalMessageInfo
+
+func (m *WriteCondition) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *WriteCondition) GetColumn() uint64 {
+	if m != nil {
+		return m.Column
+	}
+	return 0
+}
+
+func (m *WriteCondition) GetAbsent() bool {
+	if m != nil {
+		return m.Absent
+	}
+	return false
+}
+
+func (m *WriteCondition) GetValue() int64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *WriteCondition) GetRows() []uint64 {
+	if m != nil {
+		return m.Rows
+	}
+	return nil
+}
+
 type GroupCounts struct {
 	Aggregate            string        `protobuf:"bytes,1,opt,name=Aggregate,proto3" json:"Aggregate,omitempty"`
 	Groups               []*GroupCount `protobuf:"bytes,2,rep,name=Groups,proto3" json:"Groups,omitempty"`
@@ -2737,7 +2832,7 @@ func (m *GroupCounts) Reset()         { *m = GroupCounts{} }
 func (m *GroupCounts) String() string { return proto.CompactTextString(m) }
 func (*GroupCounts) ProtoMessage()    {}
 func (*GroupCounts) Descriptor() ([]byte, []int) {
-	return fileDescriptor_413a91106d7bcce8, []int{38}
+	return fileDescriptor_413a91106d7bcce8, []int{39}
 }
 func (m *GroupCounts) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2791,7 +2886,7 @@ func (m *DataFrame) Reset()         { *m = DataFrame{} }
 func (m *DataFrame) String() string { return proto.CompactTextString(m) }
 func (*DataFrame) ProtoMessage()    {}
 func (*DataFrame) Descriptor() ([]byte, []int) {
-	return fileDescriptor_413a91106d7bcce8, []int{39}
+	return fileDescriptor_413a91106d7bcce8, []int{40}
 }
 func (m *DataFrame) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2838,7 +2933,7 @@ func (m *ArrowTable) Reset()         { *m = ArrowTable{} }
 func (m *ArrowTable) String() string { return proto.CompactTextString(m) }
 func (*ArrowTable) ProtoMessage()    {}
 func (*ArrowTable) Descriptor() ([]byte, []int) {
-	return fileDescriptor_413a91106d7bcce8, []int{40}
+	return fileDescriptor_413a91106d7bcce8, []int{41}
 }
 func (m *ArrowTable) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2874,6 +2969,251 @@ func (m *ArrowTable) GetData() []byte {
 	return nil
 }
 
+type Sketch struct {
+	Field                string            `protobuf:"bytes,1,opt,name=Field,proto3" json:"Field,omitempty"`
+	Type                 string            `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	Registers            []byte            `protobuf:"bytes,3,opt,name=Registers,proto3" json:"Registers,omitempty"`
+	Compression          float64           `protobuf:"fixed64,4,opt,name=Compression,proto3" json:"Compression,omitempty"`
+	Centroids            []*SketchCentroid `protobuf:"bytes,5,rep,name=Centroids,proto3" json:"Centroids,omitempty"`
+	Min                  float64           `protobuf:"fixed64,6,opt,name=Min,proto3" json:"Min,omitempty"`
+	Max                  float64           `protobuf:"fixed64,7,opt,name=Max,proto3" json:"Max,omitempty"`
+	Capacity             int64             `protobuf:"varint,8,opt,name=Capacity,proto3" json:"Capacity,omitempty"`
+	N                    int64             `protobuf:"varint,9,opt,name=N,proto3" json:"N,omitempty"`
+	Items                []*SketchItem     `protobuf:"bytes,10,rep,name=Items,proto3" json:"Items,omitempty"`
+	Keys                 bool              `protobuf:"varint,11,opt,name=Keys,proto3" json:"Keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Sketch) Reset()         { *m = Sketch{} }
+func (m *Sketch) String() string { return proto.CompactTextString(m) }
+func (*Sketch) ProtoMessage()    {}
+func (*Sketch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_413a91106d7bcce8, []int{42}
+}
+func (m *Sketch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Sketch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Sketch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Sketch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Sketch.Merge(m, src)
+}
+func (m *Sketch) XXX_Size() int {
+	return m.Size()
+}
+func (m *Sketch) XXX_DiscardUnknown() {
+	xxx_messageInfo_Sketch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Sketch proto.InternalMessageInfo
+
+func (m *Sketch) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *Sketch) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Sketch) GetRegisters() []byte {
+	if m != nil {
+		return m.Registers
+	}
+	return nil
+}
+
+func (m *Sketch) GetCompression() float64 {
+	if m != nil {
+		return m.Compression
+	}
+	return 0
+}
+
+func (m *Sketch) GetCentroids() []*SketchCentroid {
+	if m != nil {
+		return m.Centroids
+	}
+	return nil
+}
+
+func (m *Sketch) GetMin() float64 {
+	if m != nil {
+		return m.Min
+	}
+	return 0
+}
+
+func (m *Sketch) GetMax() float64 {
+	if m != nil {
+		return m.Max
+	}
+	return 0
+}
+
+func (m *Sketch) GetCapacity() int64 {
+	if m != nil {
+		return m.Capacity
+	}
+	return 0
+}
+
+func (m *Sketch) GetN() int64 {
+	if m != nil {
+		return m.N
+	}
+	return 0
+}
+
+func (m *Sketch) GetItems() []*SketchItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+func (m *Sketch) GetKeys() bool {
+	if m != nil {
+		return m.Keys
+	}
+	return false
+}
+
+type SketchCentroid struct {
+	Mean                 float64  `protobuf:"fixed64,1,opt,name=Mean,proto3" json:"Mean,omitempty"`
+	Count                float64  `protobuf:"fixed64,2,opt,name=Count,proto3" json:"Count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SketchCentroid) Reset()         { *m = SketchCentroid{} }
+func (m *SketchCentroid) String() string { return proto.CompactTextString(m) }
+func (*SketchCentroid) ProtoMessage()    {}
+func (*SketchCentroid) Descriptor() ([]byte, []int) {
+	return fileDescriptor_413a91106d7bcce8, []int{43}
+}
+func (m *SketchCentroid) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SketchCentroid) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SketchCentroid.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SketchCentroid) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SketchCentroid.Merge(m, src)
+}
+func (m *SketchCentroid) XXX_Size() int {
+	return m.Size()
+}
+func (m *SketchCentroid) XXX_DiscardUnknown() {
+	xxx_messageInfo_SketchCentroid.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SketchCentroid proto.InternalMessageInfo
+
+func (m *SketchCentroid) GetMean() float64 {
+	if m != nil {
+		return m.Mean
+	}
+	return 0
+}
+
+func (m *SketchCentroid) GetCount() float64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type SketchItem struct {
+	ID                   int64    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=Key,proto3" json:"Key,omitempty"`
+	Count                int64    `protobuf:"varint,3,opt,name=Count,proto3" json:"Count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SketchItem) Reset()         { *m = SketchItem{} }
+func (m *SketchItem) String() string { return proto.CompactTextString(m) }
+func (*SketchItem) ProtoMessage()    {}
+func (*SketchItem) Descriptor() ([]byte, []int) {
+	return fileDescriptor_413a91106d7bcce8, []int{44}
+}
+func (m *SketchItem) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SketchItem) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SketchItem.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SketchItem) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SketchItem.Merge(m, src)
+}
+func (m *SketchItem) XXX_Size() int {
+	return m.Size()
+}
+func (m *SketchItem) XXX_DiscardUnknown() {
+	xxx_messageInfo_SketchItem.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SketchItem proto.InternalMessageInfo
+
+func (m *SketchItem) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *SketchItem) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *SketchItem) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Row)(nil), "pb.Row")
 	proto.RegisterType((*RowMatrix)(nil), "pb.RowMatrix")
@@ -2913,131 +3253,149 @@ func init() {
 	proto.RegisterType((*ImportRoaringRequest)(nil), "pb.ImportRoaringRequest")
 	proto.RegisterType((*RoaringUpdate)(nil), "pb.RoaringUpdate")
 	proto.RegisterType((*ImportRoaringShardRequest)(nil), "pb.ImportRoaringShardRequest")
+	proto.RegisterType((*WriteCondition)(nil), "pb.WriteCondition")
 	proto.RegisterType((*GroupCounts)(nil), "pb.GroupCounts")
 	proto.RegisterType((*DataFrame)(nil), "pb.DataFrame")
 	proto.RegisterType((*ArrowTable)(nil), "pb.ArrowTable")
+	proto.RegisterType((*Sketch)(nil), "pb.Sketch")
+	proto.RegisterType((*SketchCentroid)(nil), "pb.SketchCentroid")
+	proto.RegisterType((*SketchItem)(nil), "pb.SketchItem")
 }
 
 func init() { proto.RegisterFile("public.proto", fileDescriptor_413a91106d7bcce8) }
 
 var fileDescriptor_413a91106d7bcce8 = []byte{
-	// 1844 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x18, 0x4d, 0x73, 0x23, 0x47,
-	0xd5, 0xa3, 0xd1, 0xe7, 0x93, 0xec, 0xb5, 0x7b, 0x9d, 0xcd, 0x64, 0xe3, 0x38, 0xda, 0x29, 0x2a,
-	0x28, 0x18, 0x36, 0x85, 0xa1, 0x52, 0x54, 0xaa, 0x20, 0x65, 0xaf, 0xbc, 0xac, 0xca, 0xbb, 0xce,
-	0xd2, 0xda, 0x88, 0x4b, 0x2e, 0x63, 0xa9, 0x51, 0xa6, 0x18, 0x69, 0xc4, 0xcc, 0x28, 0xb2, 0x8f,
-	0x1c, 0x28, 0xf8, 0x09, 0x9c, 0xe0, 0xd7, 0x50, 0x70, 0x83, 0x23, 0x47, 0x6a, 0xf9, 0x23, 0xd4,
-	0x7b, 0xaf, 0x7b, 0xa6, 0x47, 0x92, 0xb7, 0xc2, 0x56, 0x6e, 0xfd, 0x3e, 0xfa, 0xf5, 0xfb, 0x7e,
-	0x6f, 0x06, 0x3a, 0x8b, 0xe5, 0x75, 0x14, 0x8e, 0x1f, 0x2f, 0x92, 0x38, 0x8b, 0x45, 0x65, 0x71,
-	0xed, 0xdf, 0x82, 0x2b, 0xe3, 0x95, 0xf0, 0xa0, 0xf1, 0x24, 0x8e, 0x96, 0xb3, 0x79, 0xea, 0x39,
-	0x5d, 0xb7, 0x57, 0x95, 0x06, 0x14, 0x02, 0xaa, 0x97, 0xea, 0x36, 0xf5, 0xdc, 0xae, 0xdb, 0x6b,
-	0x49, 0x3a, 0x23, 0xb7, 0x8c, 0x83, 0x24, 0x9c, 0x4f, 0xbd, 0x6a, 0xd7, 0xe9, 0x75, 0xa4, 0x01,
-	0xc5, 0x21, 0xd4, 0x06, 0xf3, 0x89, 0xba, 0xf1, 0x6a, 0x5d, 0xa7, 0xd7, 0x92, 0x0c, 0x20, 0xf6,
-	0x69, 0xa8, 0xa2, 0x89, 0x57, 0x67, 0x2c, 0x01, 0x7e, 0x0f, 0x5a, 0x32, 0x5e, 0xbd, 0x08, 0xb2,
-	0x24, 0xbc, 0x11, 0xef, 0x43, 0x55, 0xc6, 0x2b, 0x7e, 0xbd, 0x7d, 0xda, 0x78, 0xbc, 0xb8, 0x7e,
-	0x2c, 0xe3, 0x95, 0x24, 0xa4, 0x7f, 0x06, 0xad, 0x61, 0x38, 0x9d, 0xab, 0x09, 0xaa, 0xfa, 0x1e,
-	0xb8, 0x2f, 0x63, 0x64, 0x74, 0x6c, 0x46, 0xc4, 0x21, 0xe9, 0x4a, 0x4d, 0xbd, 0xca, 0x1a, 0xe9,
-	0x4a, 0x4d, 0xfd, 0x9f, 0xc1, 0x9e, 0x8c, 0x57, 0x83, 0x89, 0x9a, 0x67, 0xe1, 0x6f, 0x42, 0x95,
-	0x90, 0x61, 0xf9, 0x8b, 0x55, 0x7e, 0x28, 0x37, 0xb6, 0x52, 0x18, 0xeb, 0x3f, 0x84, 0xfa, 0xa0,
-	0xff, 0x3c, 0x4c, 0x33, 0xb1, 0x0f, 0xee, 0xa0, 0x6f, 0x2e, 0xe0, 0xd1, 0x7f, 0x02, 0x07, 0x17,
-	0x37, 0x59, 0x12, 0x8c, 0x33, 0x35, 0x19, 0xf4, 0xd9, 0x65, 0x62, 0x0f, 0x2a, 0x83, 0x3e, 0xe9,
-	0x57, 0x95, 0x95, 0x41, 0x5f, 0x1c, 0x43, 0x75, 0x14, 0x44, 0x2c, 0xb4, 0x7d, 0x0a, 0xa8, 0x16,
-	0x0b, 0x94, 0x84, 0xf7, 0x7f, 0xef, 0xc0, 0xbb, 0x96, 0x14, 0x76, 0xc8, 0x30, 0x4e, 0x32, 0x35,
-	0x11, 0xe5, 0x07, 0x98, 0xa4, 0x4d, 0x7f, 0x07, 0x05, 0x6d, 0x10, 0xe5, 0x26, 0xbf, 0x78, 0x04,
-	0x75, 0x19, 0xaf, 0x2e, 0x47, 0x46, 0x85, 0x96, 0xf6, 0xcc, 0xe5, 0x48, 0x6a, 0x82, 0xff, 0x1c,
-	0x6a, 0x74, 0xc2, 0x50, 0xa1, 0x9f, 0x8c, 0xfe, 0x0c, 0x88, 0x1f, 0x41, 0x6d, 0x14, 0x44, 0x4b,
-	0xa5, 0x5d, 0xfb, 0x6e, 0xe9, 0xe9, 0x57, 0xc1, 0x75, 0xa4, 0x88, 0x2c, 0x99, 0xcb, 0xff, 0x6a,
-	0x8b, 0xd6, 0xe2, 0x01, 0xd4, 0x29, 0xee, 0xec, 0xc0, 0x96, 0xd4, 0x90, 0xf8, 0xa4, 0x48, 0x3d,
-	0x56, 0x6f, 0xdd, 0x30, 0xa6, 0xe6, 0x19, 0xe9, 0x7f, 0x00, 0x8d, 0x4b, 0x75, 0x4b, 0x11, 0x31,
-	0xf1, 0x72, 0xac, 0x78, 0xfd, 0xd3, 0x81, 0xfb, 0x5b, 0x74, 0x13, 0xc7, 0x26, 0x7a, 0x4e, 0x39,
-	0x0a, 0xcf, 0x76, 0x28, 0x96, 0xe2, 0x51, 0x1e, 0x7b, 0x64, 0x68, 0x23, 0x83, 0x7e, 0xe6, 0xd9,
-	0x8e, 0xce, 0xfb, 0x23, 0x68, 0x9e, 0x0f, 0x07, 0xec, 0x09, 0xb7, 0xeb, 0xf4, 0xdc, 0x67, 0x3b,
-	0x32, 0xc7, 0x88, 0x87, 0xd0, 0x78, 0xb1, 0xcc, 0xd4, 0xcd, 0xa0, 0x4f, 0x55, 0x51, 0x7d, 0xb6,
-	0x23, 0x0d, 0x02, 0x6f, 0xd2, 0xf1, 0x52, 0xdd, 0x72, 0x69, 0xe0, 0x4d, 0x83, 0x11, 0x87, 0x50,
-	0x3d, 0x8f, 0xe3, 0x88, 0xca, 0xa3, 0x89, 0xaf, 0x21, 0x74, 0xde, 0xd0, 0x4e, 0xf7, 0x6f, 0xe0,
-	0xb0, 0x6c, 0x90, 0x4e, 0x34, 0x01, 0x2e, 0xca, 0x73, 0xb4, 0x3c, 0x04, 0xc4, 0x3e, 0x25, 0x5f,
-	0x45, 0xbf, 0x8f, 0xe9, 0xf7, 0x09, 0xd4, 0x49, 0x0c, 0x97, 0xf0, 0x1b, 0x82, 0xa7, 0xd9, 0xce,
-	0x5b, 0xe4, 0xdf, 0x2f, 0x92, 0x41, 0xdf, 0xff, 0xf9, 0xba, 0x2b, 0x29, 0x66, 0xe8, 0xf6, 0xab,
-	0x60, 0xa6, 0xf8, 0x65, 0x49, 0x67, 0xc4, 0xbd, 0xba, 0x5d, 0x70, 0x86, 0xb4, 0x24, 0x9d, 0xfd,
-	0x25, 0xec, 0x95, 0xaf, 0xa3, 0x32, 0x56, 0x12, 0x6c, 0x55, 0x86, 0xe8, 0x79, 0x76, 0x9c, 0xae,
-	0x67, 0x87, 0xb7, 0x79, 0x63, 0x3d, 0x41, 0x7e, 0x01, 0xd5, 0x97, 0x41, 0x98, 0x6c, 0x14, 0xe2,
-	0x3e, 0xfb, 0xcb, 0x25, 0x0d, 0x5d, 0x76, 0x7c, 0xed, 0x49, 0xbc, 0x9c, 0x67, 0xec, 0x30, 0xc9,
-	0x80, 0xff, 0x39, 0xb4, 0xf0, 0x3e, 0xdb, 0x7a, 0xc4, 0xc2, 0x74, 0xde, 0x34, 0xf1, 0x75, 0x84,
-	0x25, 0x3f, 0x91, 0x77, 0xb6, 0x8a, 0xdd, 0xd9, 0xce, 0x01, 0x90, 0x9a, 0xb2, 0x84, 0x63, 0xa8,
-	0x11, 0xa4, 0x4d, 0x2e, 0x44, 0x30, 0xfa, 0x0e, 0x19, 0x1f, 0x60, 0x27, 0xcd, 0x3e, 0xfd, 0x29,
-	0x92, 0x39, 0xe3, 0x50, 0x03, 0xd7, 0x94, 0x58, 0x0c, 0x4d, 0x76, 0x54, 0xbc, 0x2a, 0x04, 0x38,
-	0x96, 0x80, 0xa2, 0x92, 0x2b, 0x76, 0x25, 0x3f, 0xe0, 0x5e, 0x90, 0xbb, 0x41, 0x43, 0xe2, 0x43,
-	0xf3, 0x4a, 0x95, 0xec, 0xa4, 0x16, 0x41, 0xef, 0x9b, 0x07, 0xff, 0xe0, 0x00, 0xfc, 0x32, 0x89,
-	0x97, 0x0b, 0xf2, 0x91, 0xf0, 0xa1, 0x46, 0x90, 0x36, 0xaa, 0x83, 0xfc, 0x46, 0x21, 0xc9, 0xa4,
-	0xed, 0xde, 0xc5, 0x28, 0x9c, 0x4d, 0xa7, 0x5c, 0x3f, 0x12, 0x8f, 0xe2, 0x04, 0xa0, 0xaf, 0xc6,
-	0xe1, 0x2c, 0x88, 0x90, 0x50, 0x2d, 0xea, 0x4f, 0x63, 0xa5, 0x45, 0xf6, 0xff, 0xea, 0x40, 0x73,
-	0x14, 0x44, 0xb9, 0xac, 0x51, 0x10, 0x69, 0xcf, 0xe0, 0xb1, 0xfc, 0xa6, 0x6b, 0xde, 0x7c, 0x08,
-	0xcd, 0xa7, 0x51, 0x1c, 0x64, 0xc8, 0x8c, 0x0f, 0x3b, 0x32, 0x87, 0xad, 0xd7, 0x91, 0xfa, 0x86,
-	0xd7, 0x91, 0xd9, 0x87, 0xce, 0xab, 0x70, 0xa6, 0xd2, 0x2c, 0x98, 0x2d, 0x90, 0x9d, 0xc7, 0x5c,
-	0x09, 0x87, 0x9e, 0x6a, 0xe8, 0x2b, 0xdb, 0x83, 0x87, 0xd8, 0xe1, 0x38, 0x88, 0x94, 0x51, 0x92,
-	0x00, 0x71, 0x0c, 0x70, 0xa5, 0x56, 0x23, 0x95, 0xa4, 0x61, 0x3c, 0x27, 0x35, 0x9b, 0xd2, 0xc2,
-	0x60, 0xe8, 0x46, 0x41, 0x74, 0x76, 0x9d, 0xea, 0xa1, 0xab, 0x21, 0x8d, 0xc7, 0xc1, 0x57, 0xa3,
-	0x3b, 0x1a, 0xf2, 0x3f, 0x87, 0x83, 0x7e, 0x98, 0x66, 0xe1, 0x7c, 0x9c, 0xe5, 0xfa, 0x69, 0x66,
-	0xec, 0x06, 0xba, 0x0b, 0x33, 0x94, 0x97, 0x74, 0xa5, 0x28, 0x69, 0xff, 0x6f, 0x0e, 0x74, 0x7e,
-	0xb5, 0x54, 0xc9, 0xad, 0x54, 0xbf, 0x5b, 0xaa, 0x34, 0x43, 0xbd, 0x09, 0x36, 0x89, 0x46, 0x00,
-	0x8a, 0x1c, 0x7e, 0x1d, 0x24, 0x13, 0xae, 0xd0, 0xaa, 0xd4, 0x10, 0xa5, 0x9a, 0x9a, 0xc5, 0x99,
-	0x32, 0x7a, 0x31, 0x24, 0x4e, 0xa0, 0x73, 0x31, 0xbb, 0x56, 0x93, 0x89, 0x9a, 0xf4, 0x83, 0x2c,
-	0xf0, 0x9a, 0xe5, 0x91, 0x5f, 0x22, 0x8a, 0xef, 0xc1, 0xee, 0xcb, 0x44, 0xbd, 0x4a, 0x82, 0x79,
-	0x1a, 0x05, 0x99, 0x9a, 0x78, 0x2d, 0x92, 0x55, 0x46, 0x8a, 0x23, 0x68, 0xbd, 0x08, 0x6e, 0x5e,
-	0xa8, 0x59, 0x9c, 0xdc, 0x7a, 0x40, 0x4e, 0x2d, 0x10, 0xfe, 0x73, 0xd8, 0xd5, 0x66, 0xa4, 0x8b,
-	0x78, 0x9e, 0x2a, 0x4c, 0x9b, 0x8b, 0x24, 0xd1, 0x56, 0xe0, 0x51, 0x7c, 0x0c, 0x0d, 0xa9, 0xd2,
-	0x65, 0x94, 0x99, 0x36, 0x73, 0x0f, 0xd5, 0x31, 0xb7, 0x96, 0x51, 0x26, 0x0d, 0xdd, 0xff, 0x4b,
-	0x03, 0xda, 0x16, 0x21, 0x6f, 0x7c, 0xd8, 0xbc, 0x77, 0xb9, 0xf1, 0xe1, 0x22, 0x22, 0xe3, 0xd5,
-	0xc6, 0x8e, 0x82, 0xc5, 0xda, 0x01, 0xe7, 0x4a, 0x17, 0x84, 0x73, 0x55, 0xf4, 0x06, 0x77, 0x7b,
-	0x6f, 0xc0, 0xbd, 0xec, 0xeb, 0x60, 0x3e, 0x55, 0x13, 0x0a, 0x7a, 0x53, 0x1a, 0x50, 0xf4, 0x8a,
-	0x32, 0x20, 0xff, 0xea, 0x1a, 0x34, 0x38, 0x59, 0x14, 0x09, 0x97, 0x3c, 0xce, 0xbe, 0x06, 0xc7,
-	0x87, 0x21, 0xf1, 0x29, 0xec, 0x7d, 0x11, 0x4d, 0x8a, 0x9a, 0x4e, 0x75, 0x24, 0xf6, 0x50, 0x4e,
-	0x81, 0x96, 0x6b, 0x5c, 0xe2, 0xb3, 0xf5, 0x55, 0x8a, 0x62, 0xd2, 0x3e, 0x15, 0xda, 0x4e, 0x8b,
-	0x22, 0xd7, 0x97, 0xae, 0x13, 0x6b, 0x93, 0xa3, 0x40, 0xb5, 0x4f, 0x77, 0xf1, 0x5a, 0x8e, 0x94,
-	0xd6, 0xa6, 0xf7, 0xd8, 0x6e, 0xa3, 0x5e, 0x9b, 0xb8, 0xf7, 0x8c, 0x87, 0x18, 0x2b, 0xed, 0x46,
-	0x7b, 0x62, 0xf5, 0x6d, 0xaf, 0x53, 0x08, 0xcf, 0x91, 0xd2, 0xea, 0xeb, 0x5b, 0x37, 0xab, 0xdd,
-	0xff, 0x73, 0xb3, 0xfa, 0x6c, 0x7d, 0xc0, 0x79, 0x7b, 0x85, 0x2b, 0xca, 0x14, 0xb9, 0x3e, 0x0a,
-	0x4f, 0xac, 0xf5, 0xd7, 0xbb, 0x57, 0x68, 0x9b, 0x23, 0xa5, 0xb5, 0x1e, 0xff, 0x18, 0xda, 0x76,
-	0xa0, 0xf6, 0x89, 0xfd, 0x5e, 0x39, 0x50, 0xa9, 0xb4, 0x79, 0xd0, 0xc0, 0x8d, 0xf2, 0xf7, 0x0e,
-	0x0a, 0x03, 0x37, 0x88, 0x72, 0x4b, 0xbb, 0x38, 0x81, 0x16, 0x96, 0xe1, 0xd3, 0x04, 0x7b, 0x83,
-	0x28, 0x94, 0xcc, 0x91, 0xb2, 0xa0, 0x63, 0xbc, 0xce, 0x92, 0x24, 0x5e, 0xb1, 0x27, 0xee, 0x17,
-	0xf1, 0x2a, 0xb0, 0xd2, 0xe2, 0x10, 0x5f, 0xde, 0xb9, 0xf7, 0x7a, 0x87, 0x74, 0xf9, 0xfd, 0xad,
-	0x81, 0x60, 0x16, 0x79, 0xd7, 0x5d, 0xff, 0xef, 0x15, 0xd8, 0x1d, 0xcc, 0x16, 0x71, 0x92, 0x59,
-	0x7d, 0x8b, 0xbf, 0x4a, 0x9c, 0xad, 0x5f, 0x25, 0x95, 0xb5, 0xb1, 0x49, 0xfd, 0x8b, 0x1a, 0x70,
-	0x55, 0x32, 0x60, 0xd5, 0x50, 0xb5, 0x54, 0x43, 0x47, 0xd0, 0xe2, 0xad, 0x03, 0x49, 0x35, 0x22,
-	0x15, 0x08, 0xfe, 0x4e, 0x5a, 0xd1, 0x56, 0xd9, 0xa0, 0x6e, 0x6b, 0x40, 0xec, 0xf5, 0xcc, 0x46,
-	0xc4, 0x26, 0x11, 0x2d, 0x0c, 0xd2, 0xf3, 0x20, 0xa4, 0x5e, 0xbd, 0xeb, 0xf6, 0x5c, 0x69, 0x61,
-	0xc4, 0x47, 0xb0, 0x47, 0x46, 0x3c, 0x49, 0x14, 0x36, 0xc0, 0xb3, 0x8c, 0x6a, 0xd0, 0x95, 0x6b,
-	0x58, 0xe4, 0x23, 0xb3, 0x0a, 0x3e, 0xee, 0x8e, 0x6b, 0x58, 0x1a, 0x9b, 0x91, 0x0a, 0x12, 0xaa,
-	0xb2, 0xa6, 0x64, 0xc0, 0xff, 0x77, 0x05, 0x04, 0x7b, 0x92, 0x37, 0xc4, 0xef, 0xcc, 0x9d, 0x6f,
-	0x76, 0x5b, 0xd9, 0x39, 0x8d, 0x0d, 0xe7, 0x14, 0x33, 0x8c, 0x1d, 0x63, 0x66, 0x58, 0x17, 0xda,
-	0x66, 0xaa, 0x23, 0x11, 0xbd, 0xea, 0x48, 0x1b, 0x85, 0xe3, 0x7b, 0x98, 0xe1, 0x87, 0xaa, 0x66,
-	0x69, 0x91, 0xec, 0x12, 0x6e, 0x8b, 0x6b, 0xe1, 0x5b, 0xba, 0xb6, 0xfd, 0x66, 0xd7, 0x76, 0x6c,
-	0xd7, 0xfe, 0xd1, 0x81, 0xce, 0x59, 0x16, 0xcf, 0xc2, 0xb1, 0x54, 0xe3, 0x38, 0x99, 0xdc, 0xed,
-	0x54, 0x76, 0x5f, 0xc5, 0x76, 0x5f, 0x0f, 0xdc, 0xc1, 0x37, 0x89, 0x9e, 0x19, 0x0f, 0x68, 0x55,
-	0xdb, 0x88, 0x92, 0x44, 0x16, 0xf1, 0x08, 0x2a, 0x83, 0x84, 0x72, 0xb6, 0x7d, 0x7a, 0x50, 0x30,
-	0x1a, 0x9e, 0xca, 0x20, 0xf1, 0x7f, 0x08, 0x87, 0xac, 0x88, 0x21, 0xe9, 0x21, 0x79, 0x08, 0xb5,
-	0x8b, 0x24, 0x89, 0xcd, 0x98, 0x64, 0x00, 0xbf, 0x45, 0xf2, 0xb9, 0x8b, 0xc1, 0x78, 0x9b, 0x9c,
-	0xd8, 0xf6, 0x4b, 0xa1, 0x0b, 0xed, 0xab, 0x38, 0xfb, 0x75, 0x12, 0x66, 0xd4, 0x3c, 0x78, 0xd8,
-	0xd9, 0x28, 0xff, 0x63, 0x78, 0x67, 0xed, 0xe5, 0x62, 0x9a, 0x63, 0x1a, 0xb9, 0xc5, 0x67, 0xf9,
-	0x10, 0xee, 0xe7, 0xac, 0x83, 0xfe, 0x5b, 0xe9, 0xb8, 0x29, 0xf4, 0x07, 0x96, 0xe5, 0x24, 0x54,
-	0x3f, 0xbf, 0xc5, 0x1a, 0xff, 0x1c, 0x3c, 0xed, 0x4d, 0xfe, 0x2f, 0xa2, 0x35, 0x18, 0x85, 0x6a,
-	0x75, 0xd7, 0xc7, 0x13, 0xad, 0x42, 0x15, 0x5a, 0xec, 0xe8, 0xec, 0xff, 0xa9, 0x02, 0x87, 0xdb,
-	0x84, 0x14, 0x09, 0xe5, 0x58, 0x09, 0x25, 0x4e, 0xa1, 0xf6, 0x4d, 0xa8, 0x56, 0x66, 0x7f, 0x39,
-	0xb2, 0x82, 0xbd, 0xa1, 0x83, 0x64, 0x56, 0x2c, 0xa4, 0xb3, 0x71, 0x66, 0xb6, 0xcd, 0x96, 0xd4,
-	0x10, 0xbe, 0x70, 0x1e, 0xc5, 0xe3, 0xdf, 0xf2, 0x77, 0xac, 0x64, 0x60, 0x4b, 0x61, 0xd4, 0xbe,
-	0x65, 0x61, 0xd4, 0xb7, 0x16, 0x46, 0x0f, 0xee, 0x7d, 0xb9, 0x98, 0x04, 0x99, 0xba, 0xb8, 0x09,
-	0xd3, 0x4c, 0xcd, 0xc7, 0xca, 0x6b, 0x90, 0x45, 0xeb, 0x68, 0xdc, 0xa8, 0x77, 0xb5, 0x15, 0x4c,
-	0xba, 0xe3, 0x93, 0x47, 0x40, 0x15, 0xcd, 0x33, 0x4b, 0x2c, 0xb9, 0x3b, 0xf7, 0x96, 0x4b, 0xbe,
-	0xd5, 0xde, 0xda, 0x07, 0x77, 0xa8, 0x32, 0xbd, 0x48, 0xe3, 0x11, 0x5b, 0x03, 0x91, 0xb8, 0x1c,
-	0x53, 0xbd, 0xb3, 0x96, 0x70, 0xfe, 0x57, 0xf0, 0x5e, 0xc9, 0xa5, 0x54, 0x8d, 0x26, 0x2c, 0xc5,
-	0xba, 0xeb, 0x94, 0xd6, 0xdd, 0xef, 0x43, 0x6d, 0x64, 0x05, 0xe6, 0x80, 0x67, 0xbc, 0x65, 0x8c,
-	0x64, 0xba, 0x3f, 0x2c, 0xcd, 0x78, 0xec, 0x91, 0x67, 0xd3, 0x69, 0xa2, 0xa6, 0x41, 0x66, 0x92,
-	0xa5, 0x40, 0x88, 0x8f, 0xa0, 0x4e, 0xcc, 0x46, 0xec, 0xfa, 0xd2, 0xa6, 0xa9, 0xfe, 0x87, 0xd6,
-	0x00, 0xcf, 0xd3, 0xcc, 0xb1, 0xd2, 0xac, 0x6b, 0x0f, 0xed, 0x6d, 0x1c, 0xe7, 0xfb, 0xff, 0x78,
-	0x7d, 0xec, 0xfc, 0xeb, 0xf5, 0xb1, 0xf3, 0x9f, 0xd7, 0xc7, 0xce, 0x9f, 0xff, 0x7b, 0xbc, 0x73,
-	0x5d, 0xa7, 0xff, 0x87, 0x3f, 0xf9, 0x5f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x49, 0x89, 0x63, 0x75,
-	0x4f, 0x14, 0x00, 0x00,
+	// 2072 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x4f, 0x6f, 0x23, 0x49,
+	0x15, 0x4f, 0x77, 0xfb, 0xef, 0xb3, 0x93, 0x49, 0x6a, 0x32, 0xd9, 0xde, 0xd9, 0x6c, 0xd6, 0xd3,
+	0x5a, 0x2d, 0x5e, 0x06, 0x66, 0x21, 0xa0, 0x15, 0x1a, 0x09, 0x56, 0x49, 0x9c, 0x61, 0xac, 0x4c,
+	0xb2, 0x43, 0x79, 0xd6, 0x5c, 0xb8, 0x74, 0xec, 0xc2, 0xd3, 0xda, 0xb6, 0xdb, 0x74, 0xb7, 0xd7,
+	0xc9, 0x0d, 0x0e, 0x08, 0x8e, 0x1c, 0xb9, 0xf1, 0x05, 0xf8, 0x14, 0x48, 0x08, 0x6e, 0x70, 0xe4,
+	0x88, 0x86, 0x2f, 0x82, 0x5e, 0xbd, 0xaa, 0xae, 0x6a, 0xdb, 0x19, 0x2d, 0x2b, 0x6e, 0xf5, 0xfe,
+	0xf4, 0xab, 0x57, 0xbf, 0xf7, 0xa7, 0x5e, 0x35, 0xb4, 0xe7, 0x8b, 0xeb, 0x38, 0x1a, 0x3d, 0x99,
+	0xa7, 0x49, 0x9e, 0x30, 0x77, 0x7e, 0x1d, 0xdc, 0x82, 0xc7, 0x93, 0x25, 0xf3, 0xa1, 0x7e, 0x96,
+	0xc4, 0x8b, 0xe9, 0x2c, 0xf3, 0x9d, 0x8e, 0xd7, 0xad, 0x70, 0x4d, 0x32, 0x06, 0x95, 0x0b, 0x71,
+	0x9b, 0xf9, 0x5e, 0xc7, 0xeb, 0x36, 0xb9, 0x5c, 0xa3, 0x36, 0x4f, 0xc2, 0x34, 0x9a, 0x4d, 0xfc,
+	0x4a, 0xc7, 0xe9, 0xb6, 0xb9, 0x26, 0xd9, 0x3e, 0x54, 0xfb, 0xb3, 0xb1, 0xb8, 0xf1, 0xab, 0x1d,
+	0xa7, 0xdb, 0xe4, 0x44, 0x20, 0xf7, 0x59, 0x24, 0xe2, 0xb1, 0x5f, 0x23, 0xae, 0x24, 0x82, 0x2e,
+	0x34, 0x79, 0xb2, 0xbc, 0x0c, 0xf3, 0x34, 0xba, 0x61, 0xef, 0x41, 0x85, 0x27, 0x4b, 0xda, 0xbd,
+	0x75, 0x5c, 0x7f, 0x32, 0xbf, 0x7e, 0xc2, 0x93, 0x25, 0x97, 0xcc, 0xe0, 0x04, 0x9a, 0x83, 0x68,
+	0x32, 0x13, 0x63, 0x74, 0xf5, 0x5d, 0xf0, 0x5e, 0x26, 0xa8, 0xe8, 0xd8, 0x8a, 0xc8, 0x43, 0xd1,
+	0x95, 0x98, 0xf8, 0xee, 0x8a, 0xe8, 0x4a, 0x4c, 0x82, 0x1f, 0xc1, 0x0e, 0x4f, 0x96, 0xfd, 0xb1,
+	0x98, 0xe5, 0xd1, 0x2f, 0x23, 0x91, 0xca, 0x83, 0x15, 0x3b, 0x56, 0x68, 0xa3, 0xe2, 0xb0, 0xae,
+	0x39, 0x6c, 0xf0, 0x10, 0x6a, 0xfd, 0xde, 0x8b, 0x28, 0xcb, 0xd9, 0x2e, 0x78, 0xfd, 0x9e, 0xfe,
+	0x00, 0x97, 0xc1, 0x19, 0xec, 0x9d, 0xdf, 0xe4, 0x69, 0x38, 0xca, 0xc5, 0xb8, 0xdf, 0x23, 0xc8,
+	0xd8, 0x0e, 0xb8, 0xfd, 0x9e, 0xf4, 0xaf, 0xc2, 0xdd, 0x7e, 0x8f, 0x1d, 0x41, 0x65, 0x18, 0xc6,
+	0x64, 0xb4, 0x75, 0x0c, 0xe8, 0x16, 0x19, 0xe4, 0x92, 0x1f, 0xfc, 0xc6, 0x81, 0x77, 0x2c, 0x2b,
+	0x04, 0xc8, 0x20, 0x49, 0x73, 0x31, 0x66, 0xe5, 0x0d, 0x48, 0xa4, 0x8e, 0xfe, 0x00, 0x0d, 0xad,
+	0x09, 0xf9, 0xba, 0x3e, 0x7b, 0x04, 0x35, 0x9e, 0x2c, 0x2f, 0x86, 0xda, 0x85, 0xa6, 0x42, 0xe6,
+	0x62, 0xc8, 0x95, 0x20, 0x78, 0x01, 0x55, 0xb9, 0xc2, 0x50, 0x21, 0x4e, 0xda, 0x7f, 0x22, 0xd8,
+	0x77, 0xa1, 0x3a, 0x0c, 0xe3, 0x85, 0x50, 0xd0, 0xbe, 0x53, 0xda, 0xfa, 0x55, 0x78, 0x1d, 0x0b,
+	0x29, 0xe6, 0xa4, 0x15, 0xfc, 0x62, 0x83, 0xd7, 0xec, 0x00, 0x6a, 0x32, 0xee, 0x04, 0x60, 0x93,
+	0x2b, 0x8a, 0x7d, 0x62, 0x52, 0x8f, 0xdc, 0x5b, 0x3d, 0x18, 0x49, 0x8b, 0x8c, 0x0c, 0xde, 0x87,
+	0xfa, 0x85, 0xb8, 0x95, 0x11, 0xd1, 0xf1, 0x72, 0xac, 0x78, 0xfd, 0xc3, 0x81, 0xfb, 0x1b, 0x7c,
+	0x63, 0x47, 0x3a, 0x7a, 0x4e, 0x39, 0x0a, 0xcf, 0xb7, 0x64, 0x2c, 0xd9, 0xa3, 0x22, 0xf6, 0xa8,
+	0xd0, 0x42, 0x05, 0xb5, 0xcd, 0xf3, 0x2d, 0x95, 0xf7, 0x87, 0xd0, 0x38, 0x1d, 0xf4, 0x09, 0x09,
+	0xaf, 0xe3, 0x74, 0xbd, 0xe7, 0x5b, 0xbc, 0xe0, 0xb0, 0x87, 0x50, 0xbf, 0x5c, 0xe4, 0xe2, 0xa6,
+	0xdf, 0x93, 0x55, 0x51, 0x79, 0xbe, 0xc5, 0x35, 0x03, 0xbf, 0x94, 0xcb, 0x0b, 0x71, 0x4b, 0xa5,
+	0x81, 0x5f, 0x6a, 0x0e, 0xdb, 0x87, 0xca, 0x69, 0x92, 0xc4, 0xb2, 0x3c, 0x1a, 0xb8, 0x1b, 0x52,
+	0xa7, 0x75, 0x05, 0x7a, 0x70, 0x03, 0xfb, 0xe5, 0x03, 0xa9, 0x44, 0x63, 0xe0, 0xa1, 0x3d, 0x47,
+	0xd9, 0x43, 0x82, 0xed, 0xca, 0xe4, 0x73, 0xd5, 0xfe, 0x98, 0x7e, 0x9f, 0x40, 0x4d, 0x9a, 0xa1,
+	0x12, 0x7e, 0x4b, 0xf0, 0x94, 0xda, 0x69, 0x53, 0xe2, 0xfb, 0x79, 0xda, 0xef, 0x05, 0x3f, 0x5e,
+	0x85, 0x52, 0xc6, 0x0c, 0x61, 0xbf, 0x0a, 0xa7, 0x82, 0x76, 0xe6, 0x72, 0x8d, 0xbc, 0x57, 0xb7,
+	0x73, 0xca, 0x90, 0x26, 0x97, 0xeb, 0x60, 0x01, 0x3b, 0xe5, 0xcf, 0xd1, 0x19, 0x2b, 0x09, 0x36,
+	0x3a, 0x23, 0xe5, 0x45, 0x76, 0x1c, 0xaf, 0x66, 0x87, 0xbf, 0xfe, 0xc5, 0x6a, 0x82, 0xfc, 0x04,
+	0x2a, 0x2f, 0xc3, 0x28, 0x5d, 0x2b, 0xc4, 0x5d, 0xc2, 0xcb, 0x93, 0x1e, 0x7a, 0x04, 0x7c, 0xf5,
+	0x2c, 0x59, 0xcc, 0x72, 0x02, 0x8c, 0x13, 0x11, 0x7c, 0x06, 0x4d, 0xfc, 0x9e, 0xce, 0x7a, 0x48,
+	0xc6, 0x54, 0xde, 0x34, 0x70, 0x77, 0xa4, 0x39, 0x6d, 0x51, 0x74, 0x36, 0xd7, 0xee, 0x6c, 0xa7,
+	0x00, 0x28, 0xcd, 0xc8, 0xc2, 0x11, 0x54, 0x25, 0xa5, 0x8e, 0x6c, 0x4c, 0x10, 0xfb, 0x0e, 0x1b,
+	0xef, 0x63, 0x27, 0xcd, 0x3f, 0xfd, 0x21, 0x8a, 0x29, 0xe3, 0xd0, 0x03, 0x4f, 0x97, 0x58, 0x02,
+	0x0d, 0x02, 0x2a, 0x59, 0x1a, 0x03, 0x8e, 0x65, 0xc0, 0x54, 0xb2, 0x6b, 0x57, 0xf2, 0x01, 0xf5,
+	0x82, 0x02, 0x06, 0x45, 0xb1, 0x0f, 0xf4, 0x2e, 0x95, 0x8e, 0xa3, 0x5b, 0x84, 0xdc, 0x5f, 0x6f,
+	0xf8, 0x5b, 0x07, 0xe0, 0xa7, 0x69, 0xb2, 0x98, 0x4b, 0x8c, 0x58, 0x00, 0x55, 0x49, 0xa9, 0x43,
+	0xb5, 0x51, 0x5f, 0x3b, 0xc4, 0x49, 0xb4, 0x19, 0x5d, 0x8c, 0xc2, 0xc9, 0x64, 0x42, 0xf5, 0xc3,
+	0x71, 0xc9, 0x1e, 0x03, 0xf4, 0xc4, 0x28, 0x9a, 0x86, 0x31, 0x0a, 0x2a, 0xa6, 0xfe, 0x14, 0x97,
+	0x5b, 0xe2, 0xe0, 0x4f, 0x0e, 0x34, 0x86, 0x61, 0x5c, 0xd8, 0x1a, 0x86, 0xb1, 0x42, 0x06, 0x97,
+	0xe5, 0x3d, 0x3d, 0xbd, 0xe7, 0x43, 0x68, 0x3c, 0x8b, 0x93, 0x30, 0x47, 0x65, 0xdc, 0xd8, 0xe1,
+	0x05, 0x6d, 0xed, 0x8e, 0xd2, 0xb7, 0xec, 0x8e, 0xca, 0x01, 0xb4, 0x5f, 0x45, 0x53, 0x91, 0xe5,
+	0xe1, 0x74, 0x8e, 0xea, 0x74, 0xcd, 0x95, 0x78, 0x88, 0x54, 0x5d, 0x7d, 0xb2, 0x39, 0x78, 0xc8,
+	0x1d, 0x8c, 0xc2, 0x58, 0x68, 0x27, 0x25, 0xc1, 0x8e, 0x00, 0xae, 0xc4, 0x72, 0x28, 0xd2, 0x2c,
+	0x4a, 0x66, 0xd2, 0xcd, 0x06, 0xb7, 0x38, 0x18, 0xba, 0x61, 0x18, 0x9f, 0x5c, 0x67, 0xea, 0xd2,
+	0x55, 0x94, 0xe2, 0xe3, 0xc5, 0x57, 0x95, 0xdf, 0x28, 0x2a, 0xf8, 0x0c, 0xf6, 0x7a, 0x51, 0x96,
+	0x47, 0xb3, 0x51, 0x5e, 0xf8, 0xc7, 0x0e, 0x8a, 0x6e, 0xa0, 0xba, 0x30, 0x51, 0x45, 0x49, 0xbb,
+	0xa6, 0xa4, 0x83, 0xbf, 0x3a, 0xd0, 0xfe, 0xd9, 0x42, 0xa4, 0xb7, 0x5c, 0xfc, 0x6a, 0x21, 0xb2,
+	0x1c, 0xfd, 0x96, 0xb4, 0x4e, 0x34, 0x49, 0xa0, 0xc9, 0xc1, 0xeb, 0x30, 0x1d, 0x53, 0x85, 0x56,
+	0xb8, 0xa2, 0x90, 0xcf, 0xc5, 0x34, 0xc9, 0x85, 0xf6, 0x8b, 0x28, 0xf6, 0x18, 0xda, 0xe7, 0xd3,
+	0x6b, 0x31, 0x1e, 0x8b, 0x71, 0x2f, 0xcc, 0x43, 0xbf, 0x51, 0xbe, 0xf2, 0x4b, 0x42, 0xf6, 0x21,
+	0x6c, 0xbf, 0x4c, 0xc5, 0xab, 0x34, 0x9c, 0x65, 0x71, 0x98, 0x8b, 0xb1, 0xdf, 0x94, 0xb6, 0xca,
+	0x4c, 0x76, 0x08, 0xcd, 0xcb, 0xf0, 0xe6, 0x52, 0x4c, 0x93, 0xf4, 0xd6, 0x07, 0x09, 0xaa, 0x61,
+	0x04, 0x2f, 0x60, 0x5b, 0x1d, 0x23, 0x9b, 0x27, 0xb3, 0x4c, 0x60, 0xda, 0x9c, 0xa7, 0xa9, 0x3a,
+	0x05, 0x2e, 0xd9, 0xc7, 0x50, 0xe7, 0x22, 0x5b, 0xc4, 0xb9, 0x6e, 0x33, 0xf7, 0xd0, 0x1d, 0xfd,
+	0xd5, 0x22, 0xce, 0xb9, 0x96, 0x07, 0x7f, 0xa9, 0x43, 0xcb, 0x12, 0x14, 0x8d, 0x0f, 0x9b, 0xf7,
+	0x36, 0x35, 0x3e, 0x1c, 0x44, 0x78, 0xb2, 0x5c, 0x9b, 0x51, 0xb0, 0x58, 0xdb, 0xe0, 0x5c, 0xa9,
+	0x82, 0x70, 0xae, 0x4c, 0x6f, 0xf0, 0x36, 0xf7, 0x06, 0x9c, 0xcb, 0x5e, 0x87, 0xb3, 0x89, 0x18,
+	0xcb, 0xa0, 0x37, 0xb8, 0x26, 0x59, 0xd7, 0x94, 0x81, 0xc4, 0x57, 0xd5, 0xa0, 0xe6, 0xf1, 0x42,
+	0xaa, 0x4a, 0x1e, 0xef, 0xbe, 0x3a, 0xc5, 0x87, 0x28, 0xf6, 0x29, 0xec, 0x7c, 0x1e, 0x8f, 0x4d,
+	0x4d, 0x67, 0x2a, 0x12, 0x3b, 0x68, 0xc7, 0xb0, 0xf9, 0x8a, 0x16, 0x7b, 0xba, 0x3a, 0x4a, 0xc9,
+	0x98, 0xb4, 0x8e, 0x99, 0x3a, 0xa7, 0x25, 0xe1, 0x2b, 0x9a, 0xec, 0xb1, 0x35, 0xc9, 0xc9, 0x40,
+	0xb5, 0x8e, 0xb7, 0xf1, 0xb3, 0x82, 0xc9, 0x8d, 0x9c, 0x3d, 0xb1, 0xdb, 0xa8, 0xdf, 0xea, 0x38,
+	0xda, 0x39, 0xc3, 0xe5, 0x96, 0x06, 0x1a, 0x2f, 0xfa, 0xb6, 0xdf, 0x36, 0xc6, 0x0b, 0x26, 0x37,
+	0xf2, 0xcd, 0x93, 0xd5, 0xf6, 0xff, 0x38, 0x59, 0x3d, 0x5d, 0xbd, 0xe0, 0xfc, 0x1d, 0x03, 0x45,
+	0x59, 0xc2, 0x57, 0x34, 0xd9, 0x63, 0x6b, 0xfc, 0xf5, 0xef, 0x19, 0x6f, 0x0b, 0x26, 0x37, 0x72,
+	0xf6, 0x7d, 0x68, 0xd9, 0x81, 0xda, 0xed, 0x38, 0x3a, 0x47, 0x2d, 0x36, 0xb7, 0x75, 0xd8, 0xd9,
+	0x86, 0xf2, 0xf7, 0xf7, 0xcc, 0x01, 0xd7, 0x84, 0x7c, 0x5d, 0x1f, 0x9d, 0xc4, 0x32, 0x7c, 0x96,
+	0x62, 0x6f, 0x60, 0xc6, 0xc9, 0x82, 0xc9, 0x8d, 0x1c, 0xe3, 0x75, 0x92, 0xa6, 0xc9, 0x92, 0x90,
+	0xb8, 0x6f, 0xe2, 0x65, 0xb8, 0xdc, 0xd2, 0x60, 0x5f, 0xdc, 0x39, 0xf7, 0xfa, 0xfb, 0xf2, 0xe3,
+	0xf7, 0x36, 0x06, 0x82, 0x54, 0xf8, 0x5d, 0xdf, 0xb2, 0x00, 0x6a, 0x83, 0x2f, 0x45, 0x3e, 0x7a,
+	0xed, 0x3f, 0x30, 0xb3, 0x1e, 0x71, 0xb8, 0x92, 0x04, 0x7f, 0x73, 0x61, 0xbb, 0x3f, 0x9d, 0x27,
+	0x69, 0x6e, 0xf5, 0x36, 0x7a, 0xb9, 0x38, 0x1b, 0x5f, 0x2e, 0xee, 0xca, 0xd5, 0x2a, 0x7b, 0x9c,
+	0x6c, 0xd2, 0x15, 0x4e, 0x84, 0x55, 0x67, 0x95, 0x52, 0x9d, 0x1d, 0x42, 0x93, 0x26, 0x13, 0x14,
+	0x55, 0xa5, 0xc8, 0x30, 0xe8, 0x2d, 0xb5, 0x94, 0x93, 0x67, 0x5d, 0x76, 0x64, 0x4d, 0xe2, 0x7d,
+	0x40, 0x6a, 0x52, 0xd8, 0x90, 0x42, 0x8b, 0x83, 0xf2, 0x22, 0x50, 0x99, 0x5f, 0xeb, 0x78, 0x5d,
+	0x8f, 0x5b, 0x1c, 0xf6, 0x11, 0xec, 0xc8, 0x43, 0x9c, 0xa5, 0x02, 0x9b, 0xe4, 0x49, 0x2e, 0xeb,
+	0xd4, 0xe3, 0x2b, 0x5c, 0xd4, 0x93, 0xc7, 0x32, 0x7a, 0xd4, 0x41, 0x57, 0xb8, 0xf2, 0x6a, 0x8d,
+	0x45, 0x98, 0xca, 0x4a, 0x6c, 0x70, 0x22, 0x82, 0x7f, 0xb9, 0xc0, 0x08, 0x49, 0x9a, 0x22, 0xff,
+	0x6f, 0x70, 0xbe, 0x1d, 0xb6, 0x32, 0x38, 0xf5, 0x35, 0x70, 0xcc, 0x3d, 0x47, 0xc0, 0x28, 0x8a,
+	0x75, 0xa0, 0xa5, 0x6f, 0xfe, 0x85, 0x20, 0x54, 0x1d, 0x6e, 0xb3, 0xf0, 0x8a, 0x1f, 0xe4, 0xf8,
+	0x98, 0x55, 0x2a, 0x4d, 0x69, 0xbb, 0xc4, 0xdb, 0x00, 0x2d, 0x7c, 0x4d, 0x68, 0x5b, 0x6f, 0x87,
+	0xb6, 0x6d, 0x43, 0xfb, 0x3b, 0x07, 0xda, 0x27, 0x79, 0x32, 0x8d, 0x46, 0x5c, 0x8c, 0x92, 0x74,
+	0x7c, 0x37, 0xa8, 0x04, 0x9f, 0x6b, 0xc3, 0xd7, 0x05, 0xaf, 0xff, 0x55, 0xaa, 0xee, 0x95, 0x03,
+	0x39, 0xce, 0xad, 0x45, 0x89, 0xa3, 0x0a, 0x7b, 0x04, 0x6e, 0x3f, 0x95, 0x39, 0xdb, 0x3a, 0xde,
+	0x33, 0x8a, 0x5a, 0xc7, 0xed, 0xa7, 0xc1, 0x77, 0x60, 0x9f, 0x1c, 0xd1, 0x22, 0x75, 0x91, 0xee,
+	0x43, 0xf5, 0x3c, 0x4d, 0x13, 0x7d, 0x95, 0x12, 0x81, 0xef, 0x95, 0xe2, 0x6e, 0xc6, 0x60, 0x7c,
+	0x93, 0x9c, 0xd8, 0xf4, 0xdb, 0xa1, 0x03, 0xad, 0xab, 0x24, 0xff, 0x79, 0x1a, 0xe5, 0xb2, 0xc1,
+	0xd0, 0x85, 0x68, 0xb3, 0x82, 0x8f, 0xe1, 0xc1, 0xca, 0xce, 0xe6, 0xc6, 0xef, 0xf7, 0xc8, 0x9a,
+	0x7a, 0xba, 0x0f, 0xe0, 0x7e, 0xa1, 0xda, 0xef, 0x7d, 0x23, 0x1f, 0xd7, 0x8d, 0x7e, 0x1b, 0xf6,
+	0xcb, 0x46, 0xd5, 0xf6, 0x1b, 0x4e, 0x13, 0x9c, 0x82, 0xaf, 0xd0, 0xa4, 0x7f, 0x27, 0xca, 0x83,
+	0x61, 0x24, 0x96, 0x77, 0x3d, 0xb0, 0xe4, 0xb8, 0xe4, 0xca, 0xe1, 0x4f, 0xae, 0x83, 0xdf, 0xbb,
+	0xb0, 0xbf, 0xc9, 0x88, 0x49, 0x28, 0xc7, 0x4a, 0x28, 0x76, 0x0c, 0xd5, 0xaf, 0x22, 0xb1, 0xd4,
+	0x33, 0xce, 0xa1, 0x15, 0xec, 0x35, 0x1f, 0x38, 0xa9, 0x62, 0x21, 0x9d, 0x8c, 0x72, 0x3d, 0x91,
+	0x36, 0xb9, 0xa2, 0x70, 0x87, 0xd3, 0x38, 0x19, 0x7d, 0x49, 0x6f, 0x5d, 0x4e, 0xc4, 0x86, 0xc2,
+	0xa8, 0x7e, 0xcd, 0xc2, 0xa8, 0x6d, 0x2c, 0x8c, 0x2e, 0xdc, 0xfb, 0x62, 0x3e, 0x0e, 0x73, 0x71,
+	0x7e, 0x13, 0x65, 0xb9, 0x98, 0x8d, 0x84, 0x5f, 0x97, 0x27, 0x5a, 0x65, 0xe3, 0xd4, 0xbd, 0xad,
+	0x4e, 0x41, 0xa2, 0x3b, 0x9e, 0x45, 0x0c, 0x2a, 0x78, 0x3c, 0x3d, 0xe8, 0xe2, 0xda, 0xa0, 0xe5,
+	0x49, 0x6c, 0x89, 0xc0, 0xf0, 0x0e, 0x44, 0xae, 0x86, 0x6d, 0x5c, 0x62, 0x6b, 0x90, 0x22, 0x2a,
+	0xc7, 0x4c, 0xcd, 0xb5, 0x25, 0x5e, 0xf0, 0x07, 0x07, 0xde, 0x2d, 0x61, 0x2a, 0xcb, 0x51, 0xc7,
+	0xc5, 0xcc, 0xc4, 0x4e, 0x69, 0x26, 0xfe, 0x16, 0x54, 0x87, 0x56, 0x64, 0xf6, 0x68, 0x10, 0xb0,
+	0x4e, 0xc3, 0x49, 0xce, 0x8e, 0xb1, 0xef, 0xcd, 0xc6, 0x11, 0xc6, 0x40, 0x4f, 0x8d, 0x72, 0xda,
+	0xc0, 0x1a, 0x10, 0x85, 0x88, 0x5b, 0x5a, 0xc1, 0xaf, 0x1d, 0xd8, 0x29, 0x8b, 0xef, 0xc0, 0xe6,
+	0x00, 0x6a, 0xd4, 0x42, 0x55, 0x2b, 0x51, 0x94, 0xcc, 0x81, 0xeb, 0x4c, 0xcc, 0x72, 0xf5, 0x2a,
+	0x51, 0x94, 0x79, 0xdd, 0x54, 0xec, 0xd7, 0x8d, 0xfe, 0xb1, 0x56, 0x35, 0x3f, 0xd6, 0x82, 0x41,
+	0x69, 0x7e, 0xc1, 0xde, 0x7e, 0x32, 0x99, 0xa4, 0x62, 0x12, 0xe6, 0x3a, 0xc9, 0x0d, 0x83, 0x7d,
+	0x04, 0x35, 0xa9, 0xac, 0xd1, 0x58, 0x1d, 0x48, 0x95, 0x34, 0xf8, 0xc0, 0x1a, 0x4e, 0x8a, 0xf2,
+	0x70, 0xac, 0xf2, 0xe8, 0xd8, 0x03, 0xc9, 0x46, 0x8d, 0x3f, 0xbb, 0x7a, 0x58, 0xb8, 0x3b, 0x5d,
+	0x56, 0x7f, 0x6b, 0xa0, 0xf7, 0x5c, 0x4c, 0x30, 0xf1, 0xe4, 0xe0, 0x8e, 0xd6, 0x0c, 0x03, 0xbb,
+	0xd4, 0x59, 0x32, 0x9d, 0xa7, 0x22, 0x93, 0xef, 0xb8, 0x8a, 0x7c, 0x6e, 0xda, 0x2c, 0xf6, 0x3d,
+	0x68, 0x9e, 0x89, 0x59, 0x9e, 0x26, 0xd1, 0x98, 0x50, 0x52, 0x21, 0x24, 0x47, 0xb4, 0x88, 0x1b,
+	0x25, 0x4c, 0xc5, 0xcb, 0x68, 0x26, 0x6b, 0xc4, 0xe1, 0xb8, 0x94, 0x9c, 0xf0, 0xc6, 0xaf, 0x2b,
+	0x4e, 0x78, 0x83, 0x6f, 0xdc, 0xb3, 0x70, 0x1e, 0x8e, 0xa2, 0xfc, 0xd6, 0x6f, 0xc8, 0x78, 0x14,
+	0x34, 0x3d, 0x3a, 0xe8, 0xf6, 0x77, 0xae, 0xd8, 0x87, 0x50, 0xed, 0xe7, 0x62, 0x9a, 0xf9, 0x60,
+	0xe0, 0xa5, 0xbd, 0x91, 0xcd, 0x49, 0x58, 0xf4, 0x2c, 0xba, 0xed, 0xe5, 0x3a, 0x78, 0x0a, 0x3b,
+	0x65, 0x27, 0x51, 0xeb, 0x52, 0x84, 0x33, 0x09, 0x9a, 0xc3, 0xe5, 0xba, 0xfc, 0x06, 0x77, 0xf4,
+	0x5f, 0x95, 0x1e, 0x80, 0xd9, 0xc4, 0xfa, 0x37, 0xe3, 0xd9, 0xff, 0x66, 0xdc, 0x0d, 0xff, 0x66,
+	0x3c, 0xeb, 0x25, 0x7f, 0xba, 0xfb, 0xf7, 0x37, 0x47, 0xce, 0x3f, 0xdf, 0x1c, 0x39, 0xff, 0x7e,
+	0x73, 0xe4, 0xfc, 0xf1, 0x3f, 0x47, 0x5b, 0xd7, 0x35, 0xf9, 0x33, 0xfb, 0x07, 0xff, 0x1d, 0x00,
+	0xa4, 0xee, 0x90, 0xea, 0xdc, 0x16, 0x00, 0x00,
 }
 
 func (m *Row) Marshal() (dAtA []byte, err error) {
@@ -4463,6 +4821,20 @@ func (m *QueryResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Sketch != nil {
+		{
+			size, err := m.Sketch.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintPublic(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xaa
+	}
 	if m.ExtractedIDMatrixSorted != nil {
 		{
 			size, err := m.ExtractedIDMatrixSorted.MarshalToSizedBuffer(dAtA[:i])
@@ -4632,20 +5004,20 @@ func (m *QueryResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		}
 	}
 	if len(m.RowIDs) > 0 {
-		dAtA32 := make([]byte, len(m.RowIDs)*10)
-		var j31 int
+		dAtA33 := make([]byte, len(m.RowIDs)*10)
+		var j32 int
 		for _, num := range m.RowIDs {
 			for num >= 1<<7 {
-				dAtA32[j31] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA33[j32] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j31++
+				j32++
 			}
-			dAtA32[j31] = uint8(num)
-			j31++
+			dAtA33[j32] = uint8(num)
+			j32++
 		}
-		i -= j31
-		copy(dAtA[i:], dAtA32[:j31])
-		i = encodeVarintPublic(dAtA, i, uint64(j31))
+		i -= j32
+		copy(dAtA[i:], dAtA33[:j32])
+		i = encodeVarintPublic(dAtA, i, uint64(j32))
 		i--
 		dAtA[i] = 0x3a
 	}
@@ -4773,57 +5145,57 @@ func (m *ImportRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		}
 	}
 	if len(m.Timestamps) > 0 {
-		dAtA36 := make([]byte, len(m.Timestamps)*10)
-		var j35 int
+		dAtA37 := make([]byte, len(m.Timestamps)*10)
+		var j36 int
 		for _, num1 := range m.Timestamps {
 			num := uint64(num1)
 			for num >= 1<<7 {
-				dAtA36[j35] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA37[j36] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j35++
+				j36++
 			}
-			dAtA36[j35] = uint8(num)
-			j35++
+			dAtA37[j36] = uint8(num)
+			j36++
 		}
-		i -= j35
-		copy(dAtA[i:], dAtA36[:j35])
-		i = encodeVarintPublic(dAtA, i, uint64(j35))
+		i -= j36
+		copy(dAtA[i:], dAtA37[:j36])
+		i = encodeVarintPublic(dAtA, i, uint64(j36))
 		i--
 		dAtA[i] = 0x32
 	}
 	if len(m.ColumnIDs) > 0 {
-		dAtA38 := make([]byte, len(m.ColumnIDs)*10)
-		var j37 int
+		dAtA39 := make([]byte, len(m.ColumnIDs)*10)
+		var j38 int
 		for _, num := range m.ColumnIDs {
 			for num >= 1<<7 {
-				dAtA38[j37] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA39[j38] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j37++
+				j38++
 			}
-			dAtA38[j37] = uint8(num)
-			j37++
+			dAtA39[j38] = uint8(num)
+			j38++
 		}
-		i -= j37
-		copy(dAtA[i:], dAtA38[:j37])
-		i = encodeVarintPublic(dAtA, i, uint64(j37))
+		i -= j38
+		copy(dAtA[i:], dAtA39[:j38])
+		i = encodeVarintPublic(dAtA, i, uint64(j38))
 		i--
 		dAtA[i] = 0x2a
 	}
 	if len(m.RowIDs) > 0 {
-		dAtA40 := make([]byte, len(m.RowIDs)*10)
-		var j39 int
+		dAtA41 := make([]byte, len(m.RowIDs)*10)
+		var j40 int
 		for _, num := range m.RowIDs {
 			for num >= 1<<7 {
-				dAtA40[j39] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA41[j40] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j39++
+				j40++
 			}
-			dAtA40[j39] = uint8(num)
-			j39++
+			dAtA41[j40] = uint8(num)
+			j40++
 		}
-		i -= j39
-		copy(dAtA[i:], dAtA40[:j39])
-		i = encodeVarintPublic(dAtA, i, uint64(j39))
+		i -= j40
+		copy(dAtA[i:], dAtA41[:j40])
+		i = encodeVarintPublic(dAtA, i, uint64(j40))
 		i--
 		dAtA[i] = 0x22
 	}
@@ -4904,9 +5276,9 @@ func (m *ImportValueRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.FloatValues) > 0 {
 		for iNdEx := len(m.FloatValues) - 1; iNdEx >= 0; iNdEx-- {
-			f41 := math.Float64bits(float64(m.FloatValues[iNdEx]))
+			f42 := math.Float64bits(float64(m.FloatValues[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f41))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f42))
 		}
 		i = encodeVarintPublic(dAtA, i, uint64(len(m.FloatValues)*8))
 		i--
@@ -4922,39 +5294,39 @@ func (m *ImportValueRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		}
 	}
 	if len(m.Values) > 0 {
-		dAtA43 := make([]byte, len(m.Values)*10)
-		var j42 int
+		dAtA44 := make([]byte, len(m.Values)*10)
+		var j43 int
 		for _, num1 := range m.Values {
 			num := uint64(num1)
 			for num >= 1<<7 {
-				dAtA43[j42] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA44[j43] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j42++
+				j43++
 			}
-			dAtA43[j42] = uint8(num)
-			j42++
+			dAtA44[j43] = uint8(num)
+			j43++
 		}
-		i -= j42
-		copy(dAtA[i:], dAtA43[:j42])
-		i = encodeVarintPublic(dAtA, i, uint64(j42))
+		i -= j43
+		copy(dAtA[i:], dAtA44[:j43])
+		i = encodeVarintPublic(dAtA, i, uint64(j43))
 		i--
 		dAtA[i] = 0x32
 	}
 	if len(m.ColumnIDs) > 0 {
-		dAtA45 := make([]byte, len(m.ColumnIDs)*10)
-		var j44 int
+		dAtA46 := make([]byte, len(m.ColumnIDs)*10)
+		var j45 int
 		for _, num := range m.ColumnIDs {
 			for num >= 1<<7 {
-				dAtA45[j44] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA46[j45] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j44++
+				j45++
 			}
-			dAtA45[j44] = uint8(num)
-			j44++
+			dAtA46[j45] = uint8(num)
+			j45++
 		}
-		i -= j44
-		copy(dAtA[i:], dAtA45[:j44])
-		i = encodeVarintPublic(dAtA, i, uint64(j44))
+		i -= j45
+		copy(dAtA[i:], dAtA46[:j45])
+		i = encodeVarintPublic(dAtA, i, uint64(j45))
 		i--
 		dAtA[i] = 0x2a
 	}
@@ -5166,20 +5538,20 @@ func (m *TranslateKeysResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	if len(m.IDs) > 0 {
-		dAtA47 := make([]byte, len(m.IDs)*10)
-		var j46 int
+		dAtA48 := make([]byte, len(m.IDs)*10)
+		var j47 int
 		for _, num := range m.IDs {
 			for num >= 1<<7 {
-				dAtA47[j46] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA48[j47] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j46++
+				j47++
 			}
-			dAtA47[j46] = uint8(num)
-			j46++
+			dAtA48[j47] = uint8(num)
+			j47++
 		}
-		i -= j46
-		copy(dAtA[i:], dAtA47[:j46])
-		i = encodeVarintPublic(dAtA, i, uint64(j46))
+		i -= j47
+		copy(dAtA[i:], dAtA48[:j47])
+		i = encodeVarintPublic(dAtA, i, uint64(j47))
 		i--
 		dAtA[i] = 0x1a
 	}
@@ -5211,20 +5583,20 @@ func (m *TranslateIDsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
 	if len(m.IDs) > 0 {
-		dAtA49 := make([]byte, len(m.IDs)*10)
-		var j48 int
+		dAtA50 := make([]byte, len(m.IDs)*10)
+		var j49 int
 		for _, num := range m.IDs {
 			for num >= 1<<7 {
-				dAtA49[j48] = uint8(uint64(num)&0x7f | 0x80)
+				dAtA50[j49] = uint8(uint64(num)&0x7f | 0x80)
 				num >>= 7
-				j48++
+				j49++
 			}
-			dAtA49[j48] = uint8(num)
-			j48++
+			dAtA50[j49] = uint8(num)
+			j49++
 		}
-		i -= j48
-		copy(dAtA[i:], dAtA49[:j48])
-		i = encodeVarintPublic(dAtA, i, uint64(j48))
+		i -= j49
+		copy(dAtA[i:], dAtA50[:j49])
+		i = encodeVarintPublic(dAtA, i, uint64(j49))
 		i--
 		dAtA[i] = 0x1a
 	}
@@ -5494,6 +5866,20 @@ func (m *ImportRoaringShardRequest) MarshalToSizedBuffer(dAtA []byte) (int, erro
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.Conditions) > 0 {
+		for iNdEx := len(m.Conditions) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Conditions[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPublic(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
 	if len(m.Views) > 0 {
 		for iNdEx := len(m.Views) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -5521,7 +5907,7 @@ func (m *ImportRoaringShardRequest) MarshalToSizedBuffer(dAtA []byte) (int, erro
 	return len(dAtA) - i, nil
 }
 
-func (m *GroupCounts) Marshal() (dAtA []byte, err error) {
+func (m *WriteCondition) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -5531,12 +5917,12 @@ func (m *GroupCounts) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GroupCounts) MarshalTo(dAtA []byte) (int, error) {
+func (m *WriteCondition) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *GroupCounts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *WriteCondition) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
@@ -5545,14 +5931,86 @@ func (m *GroupCounts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if len(m.Groups) > 0 {
-		for iNdEx := len(m.Groups) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Groups[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
+	if len(m.Rows) > 0 {
+		dAtA52 := make([]byte, len(m.Rows)*10)
+		var j51 int
+		for _, num := range m.Rows {
+			for num >= 1<<7 {
+				dAtA52[j51] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j51++
+			}
+			dAtA52[j51] = uint8(num)
+			j51++
+		}
+		i -= j51
+		copy(dAtA[i:], dAtA52[:j51])
+		i = encodeVarintPublic(dAtA, i, uint64(j51))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Value != 0 {
+		i = encodeVarintPublic(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Absent {
+		i--
+		if m.Absent {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Column != 0 {
+		i = encodeVarintPublic(dAtA, i, uint64(m.Column))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Field) > 0 {
+		i -= len(m.Field)
+		copy(dAtA[i:], m.Field)
+		i = encodeVarintPublic(dAtA, i, uint64(len(m.Field)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GroupCounts) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GroupCounts) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GroupCounts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Groups) > 0 {
+		for iNdEx := len(m.Groups) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Groups[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
 				i = encodeVarintPublic(dAtA, i, uint64(size))
 			}
 			i--
@@ -5637,6 +6095,203 @@ func (m *ArrowTable) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Sketch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Sketch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Sketch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Keys {
+		i--
+		if m.Keys {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x58
+	}
+	if len(m.Items) > 0 {
+		for iNdEx := len(m.Items) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Items[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPublic(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x52
+		}
+	}
+	if m.N != 0 {
+		i = encodeVarintPublic(dAtA, i, uint64(m.N))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.Capacity != 0 {
+		i = encodeVarintPublic(dAtA, i, uint64(m.Capacity))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Max != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Max))))
+		i--
+		dAtA[i] = 0x39
+	}
+	if m.Min != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Min))))
+		i--
+		dAtA[i] = 0x31
+	}
+	if len(m.Centroids) > 0 {
+		for iNdEx := len(m.Centroids) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Centroids[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPublic(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.Compression != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Compression))))
+		i--
+		dAtA[i] = 0x21
+	}
+	if len(m.Registers) > 0 {
+		i -= len(m.Registers)
+		copy(dAtA[i:], m.Registers)
+		i = encodeVarintPublic(dAtA, i, uint64(len(m.Registers)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Type) > 0 {
+		i -= len(m.Type)
+		copy(dAtA[i:], m.Type)
+		i = encodeVarintPublic(dAtA, i, uint64(len(m.Type)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Field) > 0 {
+		i -= len(m.Field)
+		copy(dAtA[i:], m.Field)
+		i = encodeVarintPublic(dAtA, i, uint64(len(m.Field)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SketchCentroid) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SketchCentroid) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SketchCentroid) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Count != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Count))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if m.Mean != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Mean))))
+		i--
+		dAtA[i] = 0x9
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SketchItem) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SketchItem) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SketchItem) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Count != 0 {
+		i = encodeVarintPublic(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintPublic(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.ID != 0 {
+		i = encodeVarintPublic(dAtA, i, uint64(m.ID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintPublic(dAtA []byte, offset int, v uint64) int {
 	offset -= sovPublic(v)
 	base := offset
@@ -6394,6 +7049,10 @@ func (m *QueryResult) Size() (n int) {
 		l = m.ExtractedIDMatrixSorted.Size()
 		n += 2 + l + sovPublic(uint64(l))
 	}
+	if m.Sketch != nil {
+		l = m.Sketch.Size()
+		n += 2 + l + sovPublic(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -6769,27 +7428,43 @@ func (m *ImportRoaringShardRequest) Size() (n int) {
 			n += 1 + l + sovPublic(uint64(l))
 		}
 	}
+	if len(m.Conditions) > 0 {
+		for _, e := range m.Conditions {
+			l = e.Size()
+			n += 1 + l + sovPublic(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
 	return n
 }
 
-func (m *GroupCounts) Size() (n int) {
+func (m *WriteCondition) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	l = len(m.Aggregate)
+	l = len(m.Field)
 	if l > 0 {
 		n += 1 + l + sovPublic(uint64(l))
 	}
-	if len(m.Groups) > 0 {
-		for _, e := range m.Groups {
-			l = e.Size()
-			n += 1 + l + sovPublic(uint64(l))
+	if m.Column != 0 {
+		n += 1 + sovPublic(uint64(m.Column))
+	}
+	if m.Absent {
+		n += 2
+	}
+	if m.Value != 0 {
+		n += 1 + sovPublic(uint64(m.Value))
+	}
+	if len(m.Rows) > 0 {
+		l = 0
+		for _, e := range m.Rows {
+			l += sovPublic(uint64(e))
 		}
+		n += 1 + sovPublic(uint64(l)) + l
 	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
@@ -6797,20 +7472,42 @@ func (m *GroupCounts) Size() (n int) {
 	return n
 }
 
-func (m *DataFrame) Size() (n int) {
+func (m *GroupCounts) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	l = len(m.Data)
+	l = len(m.Aggregate)
 	if l > 0 {
 		n += 1 + l + sovPublic(uint64(l))
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
-	}
-	return n
+	if len(m.Groups) > 0 {
+		for _, e := range m.Groups {
+			l = e.Size()
+			n += 1 + l + sovPublic(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *DataFrame) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovPublic(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
 }
 
 func (m *ArrowTable) Size() (n int) {
@@ -6829,6 +7526,100 @@ func (m *ArrowTable) Size() (n int) {
 	return n
 }
 
+func (m *Sketch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Field)
+	if l > 0 {
+		n += 1 + l + sovPublic(uint64(l))
+	}
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovPublic(uint64(l))
+	}
+	l = len(m.Registers)
+	if l > 0 {
+		n += 1 + l + sovPublic(uint64(l))
+	}
+	if m.Compression != 0 {
+		n += 9
+	}
+	if len(m.Centroids) > 0 {
+		for _, e := range m.Centroids {
+			l = e.Size()
+			n += 1 + l + sovPublic(uint64(l))
+		}
+	}
+	if m.Min != 0 {
+		n += 9
+	}
+	if m.Max != 0 {
+		n += 9
+	}
+	if m.Capacity != 0 {
+		n += 1 + sovPublic(uint64(m.Capacity))
+	}
+	if m.N != 0 {
+		n += 1 + sovPublic(uint64(m.N))
+	}
+	if len(m.Items) > 0 {
+		for _, e := range m.Items {
+			l = e.Size()
+			n += 1 + l + sovPublic(uint64(l))
+		}
+	}
+	if m.Keys {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SketchCentroid) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Mean != 0 {
+		n += 9
+	}
+	if m.Count != 0 {
+		n += 9
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SketchItem) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovPublic(uint64(m.ID))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovPublic(uint64(l))
+	}
+	if m.Count != 0 {
+		n += 1 + sovPublic(uint64(m.Count))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovPublic(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -11008,6 +11799,42 @@ func (m *QueryResult) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 21:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sketch", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sketch == nil {
+				m.Sketch = &Sketch{}
+			}
+			if err := m.Sketch.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipPublic(dAtA[iNdEx:])
@@ -13414,6 +14241,40 @@ func (m *ImportRoaringShardRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Conditions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Conditions = append(m.Conditions, &WriteCondition{})
+			if err := m.Conditions[len(m.Conditions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipPublic(dAtA[iNdEx:])
@@ -13436,7 +14297,7 @@ func (m *ImportRoaringShardRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GroupCounts) Unmarshal(dAtA []byte) error {
+func (m *WriteCondition) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -13459,15 +14320,15 @@ func (m *GroupCounts) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GroupCounts: wiretype end group for non-group")
+			return fmt.Errorf("proto: WriteCondition: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GroupCounts: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: WriteCondition: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Aggregate", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Field", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -13495,13 +14356,13 @@ func (m *GroupCounts) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Aggregate = string(dAtA[iNdEx:postIndex])
+			m.Field = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Groups", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Column", wireType)
 			}
-			var msglen int
+			m.Column = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowPublic
@@ -13511,82 +14372,16 @@ func (m *GroupCounts) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Column |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthPublic
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthPublic
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Groups = append(m.Groups, &GroupCount{})
-			if err := m.Groups[len(m.Groups)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipPublic(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthPublic
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *DataFrame) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowPublic
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: DataFrame: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DataFrame: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Absent", wireType)
 			}
-			var byteLen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowPublic
@@ -13596,24 +14391,222 @@ func (m *DataFrame) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthPublic
+			m.Absent = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
 			}
-			postIndex := iNdEx + byteLen
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowPublic
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Rows = append(m.Rows, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowPublic
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthPublic
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthPublic
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.Rows) == 0 {
+					m.Rows = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowPublic
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Rows = append(m.Rows, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rows", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPublic(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GroupCounts) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPublic
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GroupCounts: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GroupCounts: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Aggregate", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthPublic
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
+			m.Aggregate = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Groups", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Groups = append(m.Groups, &GroupCount{})
+			if err := m.Groups[len(m.Groups)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
 		default:
@@ -13638,7 +14631,7 @@ func (m *DataFrame) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ArrowTable) Unmarshal(dAtA []byte) error {
+func (m *DataFrame) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -13661,10 +14654,10 @@ func (m *ArrowTable) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ArrowTable: wiretype end group for non-group")
+			return fmt.Errorf("proto: DataFrame: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ArrowTable: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DataFrame: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -13723,6 +14716,593 @@ func (m *ArrowTable) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ArrowTable) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPublic
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ArrowTable: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ArrowTable: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPublic(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Sketch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPublic
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Sketch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Sketch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Field", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Field = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Type = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Registers", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Registers = append(m.Registers[:0], dAtA[iNdEx:postIndex]...)
+			if m.Registers == nil {
+				m.Registers = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Compression", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Compression = float64(math.Float64frombits(v))
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Centroids", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Centroids = append(m.Centroids, &SketchCentroid{})
+			if err := m.Centroids[len(m.Centroids)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Min", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Min = float64(math.Float64frombits(v))
+		case 7:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Max", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Max = float64(math.Float64frombits(v))
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
+			}
+			m.Capacity = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Capacity |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field N", wireType)
+			}
+			m.N = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.N |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Items", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Items = append(m.Items, &SketchItem{})
+			if err := m.Items[len(m.Items)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Keys", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Keys = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPublic(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SketchCentroid) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPublic
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SketchCentroid: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SketchCentroid: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mean", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Mean = float64(math.Float64frombits(v))
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Count = float64(math.Float64frombits(v))
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPublic(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SketchItem) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPublic
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SketchItem: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SketchItem: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPublic
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPublic
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPublic(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthPublic
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipPublic(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -55,11 +55,21 @@ var (
 	ErrQueryCancelled   = errors.New("query cancelled")
 	ErrQueryTimeout     = errors.New("query timeout")
 	ErrTooManyWrites    = errors.New("too many write commands")
+	ErrTooManyShards    = errors.New("query touches too many shards")
+	ErrReadOnly         = errors.New("node is in read-only mode")
+
+	// ErrTooManyConcurrentQueries is returned when a user already has as many
+	// queries executing as their QueryConcurrencyLimits allow.
+	ErrTooManyConcurrentQueries = errors.New("too many concurrent queries")
 
 	// TODO(2.0) poorly named - used when a *node* doesn't own a shard. Probably
 	// we won't need this error at all by 2.0 though.
 	ErrClusterDoesNotOwnShard = errors.New("node does not own shard")
 
+	// ErrCorruptShardSnapshot is returned when a shard snapshot being
+	// restored fails its integrity check.
+	ErrCorruptShardSnapshot = errors.New("shard snapshot is corrupt")
+
 	// ErrPreconditionFailed is returned when specified index/field createdAt timestamps don't match
 	ErrPreconditionFailed = errors.New("precondition failed")
 
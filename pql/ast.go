@@ -519,7 +519,13 @@ var callInfoByFunc = map[string]callInfo{
 	},
 	"Union":     {allowUnknown: false},
 	"UnionRows": {allowUnknown: false, callType: PrecallGlobal},
-	"Extract":   {allowUnknown: false},
+	"Extract": {
+		allowUnknown: false,
+		prototypes: map[string]interface{}{
+			"limit": int64(0),
+			"sort":  interfaceOrVariable,
+		},
+	},
 	"ExternalLookup": {
 		allowUnknown: false,
 		prototypes: map[string]interface{}{
@@ -573,6 +579,15 @@ var callInfoByFunc = map[string]callInfo{
 			"nth":    nil,
 		},
 	},
+	"Sketch": {
+		allowUnknown: false,
+		prototypes: map[string]interface{}{
+			"field":    stringOrVariable,
+			"_field":   stringOrVariable,
+			"type":     "",
+			"capacity": int64(0),
+		},
+	},
 	// special cases:
 	"Clear": {
 		allowUnknown: true,
@@ -1362,6 +1377,8 @@ func (cond *Condition) StringSliceValue() ([]string, bool) {
 				ret[i] = strconv.FormatUint(tv, 10)
 			case Decimal:
 				ret[i] = tv.String()
+			case time.Time:
+				ret[i] = formatValue(tv)
 			default:
 				return nil, false
 			}
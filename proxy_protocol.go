@@ -0,0 +1,231 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// proxyProtocolHeaderTimeout is how long a connection from a trusted upstream
+// is given to send its PROXY protocol header.
+const proxyProtocolHeaderTimeout = 10 * time.Second
+
+// proxyProtocolV2Signature is the first 12 bytes of a PROXY protocol v2
+// header.
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// NewProxyProtocolListener wraps ln so that connections from the given
+// upstreams (IP addresses or CIDR networks) may begin with a PROXY protocol v1
+// or v2 header, as sent by L4 load balancers. The RemoteAddr of such a
+// connection is the client address from the header. Headers are only parsed
+// on connections from an upstream; other connections are passed through
+// unchanged, so that clients can't spoof their address.
+//
+// When the server uses TLS, the TLS listener must wrap the returned listener,
+// since the header is sent before the TLS handshake.
+func NewProxyProtocolListener(ln net.Listener, upstreams []string) (net.Listener, error) {
+	if len(upstreams) == 0 {
+		return nil, errors.New("proxy protocol requires at least one trusted upstream")
+	}
+	nets := make([]*net.IPNet, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		if !strings.Contains(upstream, "/") {
+			ip := net.ParseIP(upstream)
+			if ip == nil {
+				return nil, errors.Errorf("invalid proxy protocol upstream: %s", upstream)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(upstream)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid proxy protocol upstream: %s", upstream)
+		}
+		nets = append(nets, ipnet)
+	}
+	return &proxyProtocolListener{Listener: ln, upstreams: nets}, nil
+}
+
+// proxyProtocolListener is a net.Listener whose connections from trusted
+// upstreams are proxyProtocolConns.
+type proxyProtocolListener struct {
+	net.Listener
+	upstreams []*net.IPNet
+}
+
+// Accept returns the next connection. The PROXY protocol header (if any) is
+// not read until the connection is first used, so that a slow upstream can't
+// block Accept.
+func (l *proxyProtocolListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.trusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &proxyProtocolConn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (l *proxyProtocolListener) trusted(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, upstream := range l.upstreams {
+		if upstream.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyProtocolConn is a connection from a trusted upstream which may begin
+// with a PROXY protocol header.
+type proxyProtocolConn struct {
+	net.Conn
+	r *bufio.Reader
+
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+}
+
+// Read reads from the connection, after the PROXY protocol header.
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the client address from the PROXY protocol header, or
+// the address of the upstream if it didn't send a header for this connection
+// (for example, for its own health checks).
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyProtocolConn) readHeader() {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(proxyProtocolHeaderTimeout)); err != nil {
+		c.err = err
+		return
+	}
+	defer func() {
+		if err := c.Conn.SetReadDeadline(time.Time{}); err != nil && c.err == nil {
+			c.err = err
+		}
+	}()
+
+	// A v1 header starts with "PROXY ", and a v2 header with a 12 byte
+	// signature. Peek fails on connections which close early; those have no
+	// header, and the error is left for the next Read to report.
+	if sig, err := c.r.Peek(len(proxyProtocolV2Signature)); err == nil && bytes.Equal(sig, proxyProtocolV2Signature) {
+		c.remoteAddr, c.err = readProxyProtocolV2(c.r)
+	} else if sig, err := c.r.Peek(6); err == nil && string(sig) == "PROXY " {
+		c.remoteAddr, c.err = readProxyProtocolV1(c.r)
+	}
+	if c.err != nil {
+		c.err = errors.Wrap(c.err, "reading proxy protocol header")
+	}
+}
+
+// readProxyProtocolV1 reads a human-readable (v1) PROXY protocol header, such
+// as "PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n". It returns a nil
+// address for connections with an UNKNOWN protocol.
+func readProxyProtocolV1(r *bufio.Reader) (net.Addr, error) {
+	// The longest v1 header is 107 bytes.
+	var line []byte
+	for len(line) < 107 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("v1 header is too long or is not terminated by CRLF")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.Errorf("invalid v1 header: %q", line)
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, errors.Errorf("invalid v1 source address: %s", fields[2])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errors.Errorf("invalid v1 source port: %s", fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyProtocolV2 reads a binary (v2) PROXY protocol header. It returns a
+// nil address for LOCAL connections (which the upstream makes on its own
+// behalf) and for address families other than TCP over IPv4 or IPv6.
+func readProxyProtocolV2(r *bufio.Reader) (net.Addr, error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	verCmd, fam := hdr[12], hdr[13]
+	length := int(binary.BigEndian.Uint16(hdr[14:16]))
+
+	if verCmd>>4 != 2 {
+		return nil, errors.Errorf("unsupported v2 version: %d", verCmd>>4)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	switch verCmd & 0xf {
+	case 0x0: // LOCAL
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, errors.Errorf("unsupported v2 command: %d", verCmd&0xf)
+	}
+
+	switch fam {
+	case 0x11: // TCP over IPv4
+		if length < 12 {
+			return nil, errors.New("v2 IPv4 address block is too short")
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:4]), Port: int(binary.BigEndian.Uint16(body[8:10]))}, nil
+	case 0x21: // TCP over IPv6
+		if length < 36 {
+			return nil, errors.New("v2 IPv6 address block is too short")
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:16]), Port: int(binary.BigEndian.Uint16(body[32:34]))}, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+)
+
+func TestProxyProtocolListener(t *testing.T) {
+	// serve starts an http server, which responds with the RemoteAddr of each
+	// request, on a proxy protocol listener trusting upstreams.
+	serve := func(t *testing.T, upstreams []string) string {
+		t.Helper()
+		tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listening: %v", err)
+		}
+		ln, err := pilosa.NewProxyProtocolListener(tcpLn, upstreams)
+		if err != nil {
+			t.Fatalf("creating listener: %v", err)
+		}
+		srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.RemoteAddr)
+		})}
+		go func() { _ = srv.Serve(ln) }()
+		t.Cleanup(func() { srv.Close() })
+		return tcpLn.Addr().String()
+	}
+
+	// get sends header followed by a GET request, and returns the status and
+	// body of the response.
+	get := func(t *testing.T, addr string, header []byte) (int, string) {
+		t.Helper()
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("dialing: %v", err)
+		}
+		defer conn.Close()
+		if _, err := conn.Write(append(header, "GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"...)); err != nil {
+			t.Fatalf("writing request: %v", err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatalf("reading response: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	v2Header := func(cmd byte, ip net.IP, port uint16) []byte {
+		hdr := []byte("\r\n\r\n\x00\r\nQUIT\n")
+		hdr = append(hdr, 0x20|cmd, 0x11, 0, 12)
+		hdr = append(hdr, ip.To4()...)
+		hdr = append(hdr, 198, 51, 100, 1)
+		var ports [4]byte
+		binary.BigEndian.PutUint16(ports[0:2], port)
+		binary.BigEndian.PutUint16(ports[2:4], 443)
+		return append(hdr, ports[:]...)
+	}
+
+	t.Run("V1", func(t *testing.T) {
+		addr := serve(t, []string{"127.0.0.1"})
+		status, body := get(t, addr, []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"))
+		if status != http.StatusOK || body != "192.0.2.1:56324" {
+			t.Fatalf("expected 200 from 192.0.2.1:56324, got %d from %s", status, body)
+		}
+	})
+
+	t.Run("V2", func(t *testing.T) {
+		addr := serve(t, []string{"127.0.0.0/8"})
+		status, body := get(t, addr, v2Header(0x1, net.IPv4(192, 0, 2, 7), 1234))
+		if status != http.StatusOK || body != "192.0.2.7:1234" {
+			t.Fatalf("expected 200 from 192.0.2.7:1234, got %d from %s", status, body)
+		}
+	})
+
+	t.Run("V2Local", func(t *testing.T) {
+		addr := serve(t, []string{"127.0.0.1"})
+		status, body := get(t, addr, v2Header(0x0, net.IPv4(192, 0, 2, 7), 1234))
+		if status != http.StatusOK || !strings.HasPrefix(body, "127.0.0.1:") {
+			t.Fatalf("expected 200 from the upstream, got %d from %s", status, body)
+		}
+	})
+
+	t.Run("NoHeader", func(t *testing.T) {
+		addr := serve(t, []string{"127.0.0.1"})
+		status, body := get(t, addr, nil)
+		if status != http.StatusOK || !strings.HasPrefix(body, "127.0.0.1:") {
+			t.Fatalf("expected 200 from the upstream, got %d from %s", status, body)
+		}
+	})
+
+	t.Run("Untrusted", func(t *testing.T) {
+		addr := serve(t, []string{"10.0.0.0/8"})
+		// The header isn't parsed, so the request is malformed.
+		status, _ := get(t, addr, []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"))
+		if status != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", status)
+		}
+	})
+
+	t.Run("NoUpstreams", func(t *testing.T) {
+		tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listening: %v", err)
+		}
+		defer tcpLn.Close()
+		if _, err := pilosa.NewProxyProtocolListener(tcpLn, nil); err == nil {
+			t.Fatal("expected error without upstreams")
+		}
+		if _, err := pilosa.NewProxyProtocolListener(tcpLn, []string{"not-an-ip"}); err == nil {
+			t.Fatal("expected error for invalid upstream")
+		}
+	})
+}
@@ -0,0 +1,346 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+	"github.com/pkg/errors"
+)
+
+// QueryConcurrencyLimits limits the number of queries a single user may have
+// executing at once, so that one user running many heavy queries can't starve
+// everyone else, even when the node has capacity to spare. A query which would
+// exceed its user's limit is rejected with ErrTooManyConcurrentQueries (an
+// http 429) rather than queued.
+//
+// Users are identified by the user ID of the authenticated request or, when
+// authentication is off, by the X-Request-Userid header. Requests with
+// neither, and queries forwarded from other nodes, aren't limited.
+//
+// Total and Memory limit the queries the node admits across all users,
+// including those without a user ID, and Reservations set some of that
+// capacity aside for particular users, such as the identity which runs
+// critical scheduled reports. A user with a reservation has their queries
+// admitted from it while it has room, even when the rest of the node is full,
+// and from the rest of the node once it's full. Capacity reserved but unused
+// is lent to everyone else; a reserved query is admitted even while its
+// reservation is lent out, so the node may briefly execute more than Total
+// queries (or admit more than Memory), by at most the capacity it had lent.
+// Reservations together may hold at most half of Total and of Memory, so
+// that they can't starve everyone else.
+type QueryConcurrencyLimits struct {
+	// Default is the limit for each user who isn't listed in Users. If 0,
+	// there is no limit.
+	Default int `toml:"default"`
+
+	// Users overrides Default for particular users. Each entry is of the
+	// form "<user-id>=<limit>", and a limit of 0 means there is no limit.
+	Users []string `toml:"users"`
+
+	// Total is the number of queries the node may have executing at once.
+	// If 0, there is no limit, and reservations can't reserve slots.
+	Total int `toml:"total"`
+
+	// Memory is the memory, in bytes, the node's executing queries may be
+	// admitted with in total. A query is admitted with the max memory of
+	// its request, or QueryMemory if it doesn't set one, and holds it until
+	// it finishes. If 0, there is no limit, and reservations can't reserve
+	// memory.
+	Memory int64 `toml:"memory"`
+
+	// QueryMemory is the memory a query is admitted with if its request
+	// doesn't set a max memory. It's required along with Memory.
+	QueryMemory int64 `toml:"query-memory"`
+
+	// Reservations reserve capacity for particular users. Each entry is of
+	// the form "<user-id>=<slots>" or "<user-id>=<slots>:<memory>", where
+	// slots are queries and memory is in bytes. Slots are only reserved
+	// while Total is set. While Memory is set, a query is only admitted from
+	// a reservation which has memory for it.
+	Reservations []string `toml:"reservations"`
+}
+
+// queryConcurrencyLimiter counts the queries each user has executing, and
+// enforces QueryConcurrencyLimits on them.
+type queryConcurrencyLimiter struct {
+	def   int
+	users map[string]int
+
+	total        int
+	memory       int64
+	queryMemory  int64
+	reservations map[string]*queryReservation
+
+	// reservedSlots and reservedMemory are the capacity held by all the
+	// reservations together.
+	reservedSlots  int
+	reservedMemory int64
+
+	mu       sync.Mutex
+	inFlight map[string]int
+
+	// slots and memoryInUse are the capacity held by queries admitted from
+	// outside any reservation.
+	slots       int
+	memoryInUse int64
+}
+
+// queryReservation is the capacity reserved for a user, and how much of it
+// their executing queries hold.
+type queryReservation struct {
+	slots  int
+	memory int64
+
+	inUse       int
+	memoryInUse int64
+}
+
+func newQueryConcurrencyLimiter(cfg QueryConcurrencyLimits) (*queryConcurrencyLimiter, error) {
+	l := &queryConcurrencyLimiter{
+		def:          cfg.Default,
+		users:        make(map[string]int),
+		total:        cfg.Total,
+		memory:       cfg.Memory,
+		queryMemory:  cfg.QueryMemory,
+		reservations: make(map[string]*queryReservation),
+		inFlight:     make(map[string]int),
+	}
+	if l.def < 0 || l.total < 0 || l.memory < 0 || l.queryMemory < 0 {
+		return nil, errors.New("query concurrency limit can't be negative")
+	}
+	if l.memory > 0 && l.queryMemory == 0 {
+		return nil, errors.New("query memory is required along with a memory limit")
+	}
+	for _, entry := range cfg.Users {
+		user, limit, err := splitQueryLimitEntry(entry)
+		if err != nil {
+			return nil, errors.Errorf("invalid query concurrency limit '%s': expected <user-id>=<limit>", entry)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return nil, errors.Errorf("invalid query concurrency limit '%s': limit must be a non-negative integer", entry)
+		}
+		l.users[user] = n
+	}
+	for _, entry := range cfg.Reservations {
+		user, r, err := parseQueryReservation(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid query reservation '%s'", entry)
+		}
+		if l.total == 0 && l.memory == 0 {
+			return nil, errors.Errorf("invalid query reservation '%s': reserving requires a total or memory limit", entry)
+		}
+		if r.memory > 0 && l.memory == 0 {
+			return nil, errors.Errorf("invalid query reservation '%s': reserving memory requires a memory limit", entry)
+		}
+		if _, ok := l.reservations[user]; ok {
+			return nil, errors.Errorf("invalid query reservation '%s': user '%s' already has a reservation", entry, user)
+		}
+		l.reservations[user] = r
+		l.reservedSlots += r.slots
+		l.reservedMemory += r.memory
+	}
+	if l.total > 0 && l.reservedSlots > l.total/2 {
+		return nil, errors.Errorf("query reservations hold %d slots, more than half of the total of %d", l.reservedSlots, l.total)
+	}
+	if l.reservedMemory > l.memory/2 {
+		return nil, errors.Errorf("query reservations hold %d bytes of memory, more than half of the limit of %d", l.reservedMemory, l.memory)
+	}
+	for user, r := range l.reservations {
+		GaugeQueryReservationSlots.WithLabelValues(user).Set(float64(r.slots))
+		GaugeQueryReservationMemory.WithLabelValues(user).Set(float64(r.memory))
+	}
+	return l, nil
+}
+
+// splitQueryLimitEntry splits an entry of the form "<user-id>=<value>".
+func splitQueryLimitEntry(entry string) (user, value string, err error) {
+	i := strings.LastIndexByte(entry, '=')
+	if i < 0 || strings.TrimSpace(entry[:i]) == "" {
+		return "", "", errors.New("expected <user-id>=<value>")
+	}
+	return strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:]), nil
+}
+
+// parseQueryReservation parses an entry of QueryConcurrencyLimits.Reservations.
+func parseQueryReservation(entry string) (string, *queryReservation, error) {
+	user, value, err := splitQueryLimitEntry(entry)
+	if err != nil {
+		return "", nil, errors.New("expected <user-id>=<slots>[:<memory>]")
+	}
+	r := &queryReservation{}
+	slots, memory := value, ""
+	if i := strings.IndexByte(value, ':'); i >= 0 {
+		slots, memory = strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+		if r.memory, err = strconv.ParseInt(memory, 10, 64); err != nil || r.memory <= 0 {
+			return "", nil, errors.New("memory must be a positive number of bytes")
+		}
+	}
+	if r.slots, err = strconv.Atoi(slots); err != nil || r.slots <= 0 {
+		return "", nil, errors.New("slots must be a positive integer")
+	}
+	return user, r, nil
+}
+
+// limit returns the concurrency limit for user, or 0 if there is no limit.
+func (l *queryConcurrencyLimiter) limit(user string) int {
+	if n, ok := l.users[user]; ok {
+		return n
+	}
+	return l.def
+}
+
+// acquire admits a query from user which may use up to memory bytes (or the
+// limiter's query memory if memory is 0), returning a func to call once the
+// query has finished. If user already has as many queries executing as their
+// limit allows, or the node is full and user's reservation, if any, is too,
+// it returns ErrTooManyConcurrentQueries instead. A nil limiter admits every
+// query, and an empty user is only limited by the node's capacity.
+func (l *queryConcurrencyLimiter) acquire(user string, memory int64) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.memory == 0 {
+		memory = 0
+	} else if memory <= 0 {
+		memory = l.queryMemory
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.inFlight[user]
+	if user != "" {
+		if limit := l.limit(user); limit > 0 && n >= limit {
+			CounterQueryConcurrencyLimitExceeded.WithLabelValues(user).Inc()
+			return nil, errors.Wrapf(ErrTooManyConcurrentQueries, "user '%s' already has %d queries executing, the most allowed; retry once one has finished", user, n)
+		}
+	}
+
+	r := l.reservations[user]
+	reserved := r != nil && r.admits(memory, l.total > 0, l.memory > 0)
+	if reserved {
+		r.inUse++
+		r.memoryInUse += memory
+	} else {
+		if err := l.admitsUnreserved(memory); err != nil {
+			CounterQueryConcurrencyLimitExceeded.WithLabelValues(user).Inc()
+			return nil, err
+		}
+		l.slots++
+		l.memoryInUse += memory
+	}
+	l.updateReservationMetrics(user, r)
+
+	if user != "" {
+		l.inFlight[user] = n + 1
+		GaugeQueriesInFlightByUser.WithLabelValues(user).Set(float64(n + 1))
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.release(user, reserved, memory) })
+	}, nil
+}
+
+// admits returns whether r has room for another query which may use memory
+// bytes. Slots and memory are only checked if the node limits them.
+func (r *queryReservation) admits(memory int64, slots, mem bool) bool {
+	if slots && r.inUse >= r.slots {
+		return false
+	}
+	if mem && r.memoryInUse+memory > r.memory {
+		return false
+	}
+	return true
+}
+
+// admitsUnreserved returns an error if the node has no room for another query
+// which may use memory bytes outside any reservation. Only the capacity the
+// reservations actually hold counts against it, so unused reserved capacity
+// is lent out. l.mu must be held.
+func (l *queryConcurrencyLimiter) admitsUnreserved(memory int64) error {
+	var slots int
+	var memoryInUse int64
+	for _, r := range l.reservations {
+		slots += r.inUse
+		memoryInUse += r.memoryInUse
+	}
+	if l.total > 0 && l.slots+slots >= l.total {
+		return errors.Wrapf(ErrTooManyConcurrentQueries, "the node already has %d queries executing, the most allowed; retry once one has finished", l.slots+slots)
+	}
+	if memory > l.memory {
+		return errors.Wrapf(ErrTooManyConcurrentQueries, "the query may use %d bytes of memory, more than the node's limit of %d", memory, l.memory)
+	}
+	if l.memory > 0 && l.memoryInUse+memoryInUse+memory > l.memory {
+		return errors.Wrapf(ErrTooManyConcurrentQueries, "the node's executing queries already hold %d bytes of memory, too much to admit another needing %d; retry once one has finished", l.memoryInUse+memoryInUse, memory)
+	}
+	return nil
+}
+
+// updateReservationMetrics updates the metrics of user's reservation r, if
+// any, and of the reserved capacity lent out. l.mu must be held.
+func (l *queryConcurrencyLimiter) updateReservationMetrics(user string, r *queryReservation) {
+	if r != nil {
+		GaugeQueryReservationSlotsInUse.WithLabelValues(user).Set(float64(r.inUse))
+		GaugeQueryReservationMemoryInUse.WithLabelValues(user).Set(float64(r.memoryInUse))
+	}
+	if len(l.reservations) == 0 {
+		return
+	}
+	var lent int
+	if l.total > 0 {
+		if lent = l.slots - (l.total - l.reservedSlots); lent < 0 {
+			lent = 0
+		}
+	}
+	var lentMemory int64
+	if l.memory > 0 {
+		if lentMemory = l.memoryInUse - (l.memory - l.reservedMemory); lentMemory < 0 {
+			lentMemory = 0
+		}
+	}
+	GaugeQueryReservationSlotsLent.Set(float64(lent))
+	GaugeQueryReservationMemoryLent.Set(float64(lentMemory))
+}
+
+// release records the end of a query from user which was admitted with
+// memory bytes, from user's reservation if reserved.
+func (l *queryConcurrencyLimiter) release(user string, reserved bool, memory int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := l.reservations[user]
+	if reserved {
+		r.inUse--
+		r.memoryInUse -= memory
+	} else {
+		l.slots--
+		l.memoryInUse -= memory
+	}
+	l.updateReservationMetrics(user, r)
+
+	if user == "" {
+		return
+	}
+	n := l.inFlight[user] - 1
+	if n <= 0 {
+		// Forget idle users, so that neither the map nor the metric grows
+		// with every user who has ever run a query.
+		delete(l.inFlight, user)
+		GaugeQueriesInFlightByUser.DeleteLabelValues(user)
+		return
+	}
+	l.inFlight[user] = n
+	GaugeQueriesInFlightByUser.WithLabelValues(user).Set(float64(n))
+}
+
+// queryUser returns the ID of the user making a request, as put in ctx by
+// Handler.chkAuthZ, or "" if it's unknown.
+func queryUser(ctx context.Context) string {
+	user, _ := fbcontext.UserID(ctx)
+	return user
+}
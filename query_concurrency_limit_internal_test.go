@@ -0,0 +1,193 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestQueryConcurrencyLimiter(t *testing.T) {
+	for _, users := range [][]string{{"alice"}, {"alice=x"}, {"=1"}, {"alice=-1"}} {
+		if _, err := newQueryConcurrencyLimiter(QueryConcurrencyLimits{Users: users}); err == nil {
+			t.Fatalf("expected an error for %v", users)
+		}
+	}
+
+	l, err := newQueryConcurrencyLimiter(QueryConcurrencyLimits{
+		Default: 2,
+		Users:   []string{"etl=0", "intern = 1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// acquireN acquires n queries for user, failing the test if any is
+	// rejected, and returns a func which releases them all.
+	acquireN := func(user string, n int) func() {
+		t.Helper()
+		var releases []func()
+		for i := 0; i < n; i++ {
+			release, err := l.acquire(user, 0)
+			if err != nil {
+				t.Fatalf("query %d for %s: %v", i, user, err)
+			}
+			releases = append(releases, release)
+		}
+		return func() {
+			for _, release := range releases {
+				release()
+			}
+		}
+	}
+	rejected := func(user string) {
+		t.Helper()
+		if _, err := l.acquire(user, 0); errors.Cause(err) != ErrTooManyConcurrentQueries {
+			t.Fatalf("expected %s to be rejected, got %v", user, err)
+		}
+	}
+
+	releaseAlice := acquireN("alice", 2)
+	rejected("alice")
+
+	// Other users aren't affected by alice's queries.
+	releaseBob := acquireN("bob", 2)
+	releaseIntern := acquireN("intern", 1)
+	rejected("intern")
+	defer acquireN("etl", 10)()
+	defer acquireN("", 10)()
+
+	// Releasing twice has no further effect.
+	releaseAlice()
+	releaseAlice()
+	defer acquireN("alice", 2)()
+	rejected("alice")
+	releaseBob()
+	releaseIntern()
+	if n := len(l.inFlight); n != 2 {
+		t.Fatalf("expected only alice and etl to be tracked, got %v", l.inFlight)
+	}
+
+	// A nil limiter admits everything.
+	var nilLimiter *queryConcurrencyLimiter
+	if _, err := nilLimiter.acquire("alice", 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryReservations(t *testing.T) {
+	for _, cfg := range []QueryConcurrencyLimits{
+		{Total: 4, Reservations: []string{"reports"}},
+		{Total: 4, Reservations: []string{"reports=0"}},
+		{Total: 4, Reservations: []string{"reports=x"}},
+		{Total: 4, Reservations: []string{"reports=1:x"}},
+		{Total: 4, Reservations: []string{"reports=1", "reports=1"}},
+		// Reservations may hold at most half the node's capacity.
+		{Total: 4, Reservations: []string{"reports=2", "etl=1"}},
+		{Total: 4, Memory: 100, QueryMemory: 10, Reservations: []string{"reports=1:60"}},
+		// Reserving needs the node to limit what's reserved.
+		{Reservations: []string{"reports=1"}},
+		{Total: 4, Reservations: []string{"reports=1:10"}},
+		{Memory: 100},
+	} {
+		if _, err := newQueryConcurrencyLimiter(cfg); err == nil {
+			t.Fatalf("expected an error for %+v", cfg)
+		}
+	}
+
+	t.Run("Slots", func(t *testing.T) {
+		l, err := newQueryConcurrencyLimiter(QueryConcurrencyLimits{
+			Total:        4,
+			Reservations: []string{"reports=2"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		acquire := func(user string) func() {
+			t.Helper()
+			release, err := l.acquire(user, 0)
+			if err != nil {
+				t.Fatalf("query for %s: %v", user, err)
+			}
+			return release
+		}
+		rejected := func(user string) {
+			t.Helper()
+			if _, err := l.acquire(user, 0); errors.Cause(err) != ErrTooManyConcurrentQueries {
+				t.Fatalf("expected %s to be rejected, got %v", user, err)
+			}
+		}
+
+		// With the reservation unused, everyone else may borrow it.
+		releaseAlice := []func(){acquire("alice"), acquire("alice"), acquire(""), acquire("bob")}
+		rejected("alice")
+		if l.slots != 4 {
+			t.Fatalf("expected 4 unreserved slots in use, got %d", l.slots)
+		}
+
+		// The reservation is admitted even though it's been lent out.
+		releaseReports := []func(){acquire("reports"), acquire("reports")}
+		if r := l.reservations["reports"]; r.inUse != 2 {
+			t.Fatalf("expected 2 reserved slots in use, got %d", r.inUse)
+		}
+
+		// Beyond its reservation, reports competes with everyone else.
+		rejected("reports")
+		for _, release := range releaseAlice {
+			release()
+		}
+		// Only the 2 slots which aren't reserved are left while reports holds
+		// its reservation.
+		acquire("alice")
+		releaseReports = append(releaseReports, acquire("reports"))
+		rejected("alice")
+		if l.slots != 2 {
+			t.Fatalf("expected 2 unreserved slots in use, got %d", l.slots)
+		}
+		for _, release := range releaseReports {
+			release()
+		}
+		if r := l.reservations["reports"]; r.inUse != 0 || l.slots != 1 {
+			t.Fatalf("expected only alice's query to be in use, got %d reserved and %d unreserved", r.inUse, l.slots)
+		}
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		l, err := newQueryConcurrencyLimiter(QueryConcurrencyLimits{
+			Memory:       100,
+			QueryMemory:  10,
+			Reservations: []string{"reports=10:50"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.acquire("alice", 101); errors.Cause(err) != ErrTooManyConcurrentQueries {
+			t.Fatalf("expected a query needing more than the node's memory to be rejected, got %v", err)
+		}
+		releaseAlice, err := l.acquire("alice", 91)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.acquire("bob", 0); errors.Cause(err) != ErrTooManyConcurrentQueries {
+			t.Fatalf("expected bob to be rejected, got %v", err)
+		}
+		// The reservation has room for queries using the default memory
+		// until it's full.
+		for i := 0; i < 5; i++ {
+			if _, err := l.acquire("reports", 0); err != nil {
+				t.Fatalf("query %d for reports: %v", i, err)
+			}
+		}
+		if _, err := l.acquire("reports", 0); errors.Cause(err) != ErrTooManyConcurrentQueries {
+			t.Fatalf("expected reports to be rejected, got %v", err)
+		}
+		releaseAlice()
+		if _, err := l.acquire("reports", 0); err != nil {
+			t.Fatalf("expected reports to be admitted from the rest of the node: %v", err)
+		}
+		if r := l.reservations["reports"]; r.memoryInUse != 50 || l.memoryInUse != 10 {
+			t.Fatalf("unexpected memory in use: %d reserved, %d unreserved", r.memoryInUse, l.memoryInUse)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HeaderQueryPriority is the header in which a client, or another node, sends
+// the QueryPriority of a query.
+const HeaderQueryPriority = "X-Pilosa-Query-Priority"
+
+// QueryPriority is the priority of a query for the executor's workers.
+type QueryPriority string
+
+const (
+	// QueryPriorityInteractive is the default priority, of queries which
+	// someone is waiting for.
+	QueryPriorityInteractive QueryPriority = "interactive"
+
+	// QueryPriorityBatch is the priority of queries, such as reports and
+	// exports, whose latency matters less than that of interactive ones.
+	// Their shards are processed only when no interactive query is being
+	// processed, subject to QueryPreemption.MaxWait.
+	QueryPriorityBatch QueryPriority = "batch"
+)
+
+// ParseQueryPriority returns the QueryPriority named by s; "" is
+// QueryPriorityInteractive.
+func ParseQueryPriority(s string) (QueryPriority, error) {
+	switch p := QueryPriority(s); p {
+	case "":
+		return QueryPriorityInteractive, nil
+	case QueryPriorityInteractive, QueryPriorityBatch:
+		return p, nil
+	default:
+		return "", errors.Errorf("invalid query priority '%s': must be %s or %s", s, QueryPriorityInteractive, QueryPriorityBatch)
+	}
+}
+
+type queryPriorityKey struct{}
+
+// WithQueryPriority returns a copy of ctx carrying the priority of a query.
+// The priority is sent along with the query to the other nodes it's
+// executed on.
+func WithQueryPriority(ctx context.Context, p QueryPriority) context.Context {
+	return context.WithValue(ctx, queryPriorityKey{}, p)
+}
+
+// QueryPriorityFromContext returns the priority of the query whose context is
+// ctx, which is QueryPriorityInteractive unless WithQueryPriority said
+// otherwise.
+func QueryPriorityFromContext(ctx context.Context) QueryPriority {
+	if p, ok := ctx.Value(queryPriorityKey{}).(QueryPriority); ok && p != "" {
+		return p
+	}
+	return QueryPriorityInteractive
+}
+
+// setQueryPriorityHeader sets HeaderQueryPriority on req if its context
+// carries a priority other than the default.
+func setQueryPriorityHeader(req *http.Request) {
+	if p := QueryPriorityFromContext(req.Context()); p != QueryPriorityInteractive {
+		req.Header.Set(HeaderQueryPriority, string(p))
+	} else {
+		req.Header.Del(HeaderQueryPriority)
+	}
+}
+
+// applyQueryPriority gives a request which carries HeaderQueryPriority a
+// context with that priority. A request with an invalid priority is
+// rejected.
+func (h *Handler) applyQueryPriority(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get(HeaderQueryPriority); v != "" {
+			p, err := ParseQueryPriority(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r = r.WithContext(WithQueryPriority(r.Context(), p))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DefaultQueryPreemptionMaxWait is the default longest time a batch query
+// waits before processing each shard.
+const DefaultQueryPreemptionMaxWait = 100 * time.Millisecond
+
+// QueryPreemption configures the preemption of batch queries by interactive
+// ones. While any interactive query is processing shards on a node, batch
+// queries on that node don't start processing more shards, leaving the
+// executor's workers to the interactive queries. Preemption is cooperative:
+// a shard which a batch query has started processing is finished, so the
+// query is paused between shards, never in the middle of one, and picks up
+// where it left off once no interactive query is being processed.
+//
+// So that a steady stream of interactive queries doesn't starve batch ones,
+// a batch query waits at most MaxWait before each shard, after which it
+// processes the shard regardless; a batch query therefore always makes
+// progress of at least a shard every MaxWait, and more when interactive
+// queries leave gaps. A larger MaxWait favors interactive latency, a smaller
+// one batch throughput.
+type QueryPreemption struct {
+	// MaxWait is the longest time a batch query waits before each shard.
+	// 0 uses DefaultQueryPreemptionMaxWait; negative disables preemption,
+	// so that priorities are ignored.
+	MaxWait time.Duration
+}
+
+// queryScheduler implements QueryPreemption. A nil *queryScheduler never
+// preempts.
+type queryScheduler struct {
+	maxWait time.Duration
+
+	mu          sync.Mutex
+	interactive int           // interactive queries processing shards
+	idle        chan struct{} // closed while interactive is 0
+}
+
+// newQueryScheduler returns a queryScheduler for cfg, or nil if cfg disables
+// preemption.
+func newQueryScheduler(cfg QueryPreemption) *queryScheduler {
+	if cfg.MaxWait < 0 {
+		return nil
+	}
+	if cfg.MaxWait == 0 {
+		cfg.MaxWait = DefaultQueryPreemptionMaxWait
+	}
+	idle := make(chan struct{})
+	close(idle)
+	return &queryScheduler{maxWait: cfg.MaxWait, idle: idle}
+}
+
+// begin records that a query with priority p has started processing shards,
+// and returns a function to call when it has finished.
+func (s *queryScheduler) begin(p QueryPriority) func() {
+	if s == nil || p == QueryPriorityBatch {
+		return func() {}
+	}
+	s.mu.Lock()
+	if s.interactive == 0 {
+		s.idle = make(chan struct{})
+	}
+	s.interactive++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.interactive--
+			if s.interactive == 0 {
+				close(s.idle)
+			}
+		})
+	}
+}
+
+// yield is called by a batch query before it processes each shard. It waits
+// until no interactive query is processing shards, or for up to maxWait, and
+// returns an error only if ctx is done first.
+func (s *queryScheduler) yield(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	idle := s.idle
+	s.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(s.maxWait)
+	defer timer.Stop()
+	outcome := "resumed"
+	select {
+	case <-idle:
+	case <-timer.C:
+		outcome = "max-wait"
+	case <-ctx.Done():
+		outcome = "canceled"
+	}
+	CounterQueryPreemptions.WithLabelValues(outcome).Inc()
+	CounterQueryPreemptedSeconds.Add(time.Since(start).Seconds())
+	return ctx.Err()
+}
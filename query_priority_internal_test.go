@@ -0,0 +1,97 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryScheduler(t *testing.T) {
+	if s := newQueryScheduler(QueryPreemption{MaxWait: -1}); s != nil {
+		t.Fatalf("expected no scheduler, got %v", s)
+	}
+	var none *queryScheduler
+	none.begin(QueryPriorityInteractive)()
+	if err := none.yield(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newQueryScheduler(QueryPreemption{MaxWait: time.Minute})
+	ctx := context.Background()
+	if err := s.yield(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A batch query doesn't hold up another batch query.
+	s.begin(QueryPriorityBatch)()
+	done := s.begin(QueryPriorityInteractive)
+	resumed := make(chan error, 1)
+	go func() { resumed <- s.yield(ctx) }()
+	select {
+	case err := <-resumed:
+		t.Fatalf("expected the batch query to wait, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	done()
+	done() // releasing twice has no further effect
+	select {
+	case err := <-resumed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the batch query to resume")
+	}
+
+	// The batch query isn't starved by an interactive query which doesn't
+	// finish.
+	s = newQueryScheduler(QueryPreemption{MaxWait: time.Millisecond})
+	defer s.begin(QueryPriorityInteractive)()
+	if err := s.yield(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	s.maxWait = time.Minute
+	if err := s.yield(canceled); err == nil {
+		t.Fatal("expected an error for a canceled query")
+	}
+}
+
+func TestQueryPriorityHeader(t *testing.T) {
+	if _, err := ParseQueryPriority("urgent"); err == nil {
+		t.Fatal("expected an error for an invalid priority")
+	}
+
+	req := httptest.NewRequest("POST", "/index/i/query", nil)
+	setQueryPriorityHeader(req)
+	if v := req.Header.Get(HeaderQueryPriority); v != "" {
+		t.Fatalf("expected no header for the default priority, got %q", v)
+	}
+	req = req.WithContext(WithQueryPriority(req.Context(), QueryPriorityBatch))
+	setQueryPriorityHeader(req)
+
+	var got QueryPriority
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = QueryPriorityFromContext(r.Context())
+	})
+	h := &Handler{}
+	received := httptest.NewRequest("POST", "/index/i/query", nil)
+	received.Header = req.Header
+	h.applyQueryPriority(next).ServeHTTP(httptest.NewRecorder(), received)
+	if got != QueryPriorityBatch {
+		t.Fatalf("expected batch priority, got %q", got)
+	}
+
+	received.Header.Set(HeaderQueryPriority, "urgent")
+	w := httptest.NewRecorder()
+	h.applyQueryPriority(next).ServeHTTP(w, received)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/pkg/errors"
+)
+
+// QueryShardLimits limits the number of shards a single query may touch, as a
+// guard against queries which would scan every shard of a large index. A
+// query which would touch more shards than its limit is rejected with
+// ErrTooManyShards before any shard is read.
+//
+// Limits apply per index: a query which reads from several indexes (for
+// example, through a SQL join) is limited separately on each.
+type QueryShardLimits struct {
+	// Default is the limit for queries from users who aren't in any of the
+	// groups in Groups, and for all queries when authentication is off. If 0,
+	// there is no limit.
+	Default int `toml:"default"`
+
+	// Groups overrides Default for the members of particular user groups.
+	// Each entry is of the form "<group-id>=<limit>". A user in more than one
+	// of the groups gets the highest of their groups' limits, and a limit of 0
+	// means there is no limit.
+	Groups []string `toml:"groups"`
+
+	// Ceiling caps every limit, including those in Groups and a Default of 0.
+	// If 0, there is no ceiling.
+	Ceiling int `toml:"ceiling"`
+}
+
+// shardLimits is the parsed form of QueryShardLimits.
+type shardLimits struct {
+	def     int
+	groups  map[string]int
+	ceiling int
+}
+
+func newShardLimits(cfg QueryShardLimits) (shardLimits, error) {
+	l := shardLimits{
+		def:     cfg.Default,
+		ceiling: cfg.Ceiling,
+	}
+	if l.def < 0 || l.ceiling < 0 {
+		return l, errors.New("query shard limits can't be negative")
+	}
+	for _, entry := range cfg.Groups {
+		i := strings.LastIndexByte(entry, '=')
+		if i <= 0 {
+			return l, errors.Errorf("invalid query shard limit '%s': expected <group-id>=<limit>", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(entry[i+1:]))
+		if err != nil || n < 0 {
+			return l, errors.Errorf("invalid query shard limit '%s': limit must be a non-negative integer", entry)
+		}
+		if l.groups == nil {
+			l.groups = make(map[string]int)
+		}
+		l.groups[strings.TrimSpace(entry[:i])] = n
+	}
+	return l, nil
+}
+
+// limit returns the shard limit for a member of groups, or 0 if there is no
+// limit.
+func (l shardLimits) limit(groups []string) int {
+	limit, found := l.def, false
+	for _, g := range groups {
+		n, ok := l.groups[g]
+		if !ok {
+			continue
+		}
+		if !found || n == 0 || (limit != 0 && n > limit) {
+			limit = n
+		}
+		found = true
+	}
+	if l.ceiling > 0 && (limit == 0 || limit > l.ceiling) {
+		limit = l.ceiling
+	}
+	return limit
+}
+
+// queryGroups returns the IDs of the user groups which the authenticated user
+// making a request belongs to, as put in ctx by Handler.chkAuthZ.
+func queryGroups(ctx context.Context) []string {
+	switch g := ctx.Value(contextKeyGroupMembership).(type) {
+	case []authn.Group:
+		ids := make([]string, len(g))
+		for i := range g {
+			ids[i] = g[i].GroupID
+		}
+		return ids
+	case []string:
+		return g
+	}
+	return nil
+}
+
+// checkShardLimit returns an error if query, on index, may not touch n shards.
+// Remote queries aren't checked, since they were checked on the node which
+// received the query.
+func (e *executor) checkShardLimit(ctx context.Context, index string, query fmt.Stringer, n int, opt *ExecOptions) error {
+	if opt.Remote {
+		return nil
+	}
+	limit := e.shardLimits.limit(queryGroups(ctx))
+	if limit == 0 || n <= limit {
+		return nil
+	}
+	CounterQueryShardLimitExceeded.WithLabelValues(index).Inc()
+	e.Holder.Logger.Warnf("rejected query on index '%s' touching %d shards (limit %d): %s", index, n, limit, query)
+	return errors.Wrapf(ErrTooManyShards, "query on index '%s' would touch %d shards, but the limit is %d; use a more selective predicate, or restrict the query to fewer shards", index, n, limit)
+}
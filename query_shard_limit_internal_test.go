@@ -0,0 +1,57 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+)
+
+func TestShardLimits(t *testing.T) {
+	l, err := newShardLimits(QueryShardLimits{
+		Default: 10,
+		Groups:  []string{"analysts=100", "etl=0", "interns = 5"},
+		Ceiling: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		groups []string
+		exp    int
+	}{
+		{nil, 10},
+		{[]string{"other"}, 10},
+		{[]string{"interns"}, 5},
+		{[]string{"analysts"}, 100},
+		{[]string{"interns", "analysts"}, 100},
+		// "etl" has no limit of its own, so gets the ceiling.
+		{[]string{"analysts", "etl"}, 1000},
+	} {
+		if got := l.limit(tc.groups); got != tc.exp {
+			t.Errorf("groups %v: expected limit %d, got %d", tc.groups, tc.exp, got)
+		}
+	}
+
+	if got := (shardLimits{}).limit([]string{"analysts"}); got != 0 {
+		t.Errorf("expected no limit, got %d", got)
+	}
+
+	for _, bad := range []string{"analysts", "=10", "analysts=-1", "analysts=ten"} {
+		if _, err := newShardLimits(QueryShardLimits{Groups: []string{bad}}); err == nil {
+			t.Errorf("expected error for group limit %q", bad)
+		}
+	}
+}
+
+func TestQueryGroups(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKeyGroupMembership, []authn.Group{{GroupID: "a"}, {GroupID: "b"}})
+	if got := queryGroups(ctx); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("unexpected groups: %v", got)
+	}
+	if got := queryGroups(context.Background()); got != nil {
+		t.Errorf("expected no groups, got %v", got)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// A node in read-only mode rejects every operation which would change its data
+// or schema with ErrReadOnly, and continues to serve reads. The operations
+// which count as writes are:
+//
+//   - PQL queries containing any write call (Set, Clear, ClearRow, Store,
+//     or Delete), including those forwarded from other nodes;
+//   - SQL statements other than SELECT, PREDICT, SHOW, and EXPLAIN (see
+//     parser.IsReadOnly);
+//   - the HTTP routes in writeRoutes: imports, dataframe changes, schema
+//     changes, key creation and translation store replication, ID
+//     allocation, and restores;
+//   - the gRPC CreateIndex and DeleteIndex methods, and DROP TABLE through
+//     the gRPC SQL interface.
+//
+// Directives and snapshots from a DAX controller aren't client writes, so
+// they're still applied: their effect is to change which data the node
+// serves, not the data itself.
+
+// writeRoutes are the names of the HTTP routes which are rejected while the
+// node is read-only.
+var writeRoutes = map[string]struct{}{
+	"PostImportAtomicRecord":     {},
+	"PostDataframe":              {},
+	"DeleteDataframe":            {},
+	"PostImport":                 {},
+	"PostImportRoaring":          {},
+	"PostIndex":                  {},
+	"DeleteIndex":                {},
+	"PostField":                  {},
+	"PatchField":                 {},
+	"DeleteField":                {},
+	"DeleteView":                 {},
+	"PostSchema":                 {},
+	"PostTranslateData":          {},
+	"CreateIndexKeys":            {},
+	"CreateFieldKeys":            {},
+	"PostTranslateIndexDB":       {},
+	"PostTranslateFieldDB":       {},
+	"ReserveIDs":                 {},
+	"CommitIDs":                  {},
+	"RestoreIDAllocData":         {},
+	"ResetIDAlloc":               {},
+	"Restore":                    {},
+	"DeleteRemoteAvailableShard": {},
+}
+
+// ReadOnly returns true if the node is in read-only mode.
+func (api *API) ReadOnly() bool {
+	return api.server.readOnly()
+}
+
+// SetReadOnly puts the node into, or takes it out of, read-only mode. It
+// returns true if the mode changed.
+func (api *API) SetReadOnly(readOnly bool) bool {
+	return api.server.setReadOnly(readOnly)
+}
+
+func (s *Server) readOnly() bool {
+	return atomic.LoadInt32(&s.readOnlyMode) == 1
+}
+
+func (s *Server) setReadOnly(readOnly bool) bool {
+	from, to := int32(1), int32(0)
+	if readOnly {
+		from, to = 0, 1
+	}
+	return atomic.CompareAndSwapInt32(&s.readOnlyMode, from, to)
+}
+
+// checkReadOnlyStatement returns ErrReadOnly if the node is read-only and
+// stmt is a write.
+func (s *Server) checkReadOnlyStatement(stmt parser.Statement) error {
+	if s.readOnly() && !parser.IsReadOnly(stmt) {
+		return errors.Wrap(ErrReadOnly, "only queries, SHOW, and EXPLAIN statements are allowed")
+	}
+	return nil
+}
+
+// checkReadOnlySQL is checkReadOnlyStatement for unparsed SQL. Statements which
+// don't parse aren't rejected here, so that their parse error is reported.
+func (api *API) checkReadOnlySQL(sql string) error {
+	if !api.ReadOnly() {
+		return nil
+	}
+	stmt, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+	if err != nil {
+		return nil
+	}
+	return api.server.checkReadOnlyStatement(stmt)
+}
+
+// isWriteQuery returns true if q contains any call which writes.
+func isWriteQuery(q *pql.Query) bool {
+	return q.WriteCallN() > 0 || q.HasCall("Delete")
+}
+
+// rejectWritesWhenReadOnly is middleware which rejects requests for any of the
+// writeRoutes with a 503 while the node is read-only.
+func (h *Handler) rejectWritesWhenReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.api.ReadOnly() {
+			if _, ok := writeRoutes[mux.CurrentRoute(r).GetName()]; ok {
+				writeReadOnlyError(w)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeReadOnlyError(w http.ResponseWriter) {
+	data, _ := json.Marshal(errorResponse{Error: ErrReadOnly.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write(data)
+}
+
+type readOnlyResponse struct {
+	ReadOnly bool `json:"readOnly"`
+}
+
+// handleGetReadOnly handles GET /read-only requests.
+func (h *Handler) handleGetReadOnly(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(readOnlyResponse{ReadOnly: h.api.ReadOnly()}); err != nil {
+		h.logger.Errorf("write read-only response error: %s", err)
+	}
+}
+
+// handlePostReadOnly handles POST /read-only?enabled=<bool> requests, which
+// put the node into or take it out of read-only mode.
+func (h *Handler) handlePostReadOnly(w http.ResponseWriter, r *http.Request) {
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	if h.api.SetReadOnly(enabled) {
+		user, _ := fbcontext.UserID(r.Context())
+		mode := "read-write"
+		if enabled {
+			mode = "read-only"
+		}
+		h.logger.Warnf("admin action: node %s switched to %s mode by user '%s' from %s", h.api.NodeID(), mode, user, GetIP(r))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(readOnlyResponse{ReadOnly: h.api.ReadOnly()}); err != nil {
+		h.logger.Errorf("write read-only response error: %s", err)
+	}
+}
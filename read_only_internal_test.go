@@ -0,0 +1,87 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func TestReadOnly(t *testing.T) {
+	h := &Handler{
+		api:    &API{server: &Server{}},
+		logger: logger.NopLogger,
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	router.HandleFunc("/index/{index}", ok).Methods("POST").Name("PostIndex")
+	router.HandleFunc("/index/{index}", ok).Methods("GET").Name("GetIndex")
+	router.HandleFunc("/read-only", h.handleGetReadOnly).Methods("GET").Name("GetReadOnly")
+	router.HandleFunc("/read-only", h.handlePostReadOnly).Methods("POST").Name("PostReadOnly")
+	router.Use(h.rejectWritesWhenReadOnly)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	expect := func(t *testing.T, w *httptest.ResponseRecorder, status int, body string) {
+		t.Helper()
+		if w.Code != status {
+			t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+		} else if body != "" && strings.TrimSpace(w.Body.String()) != body {
+			t.Fatalf("unexpected body: %s", w.Body.String())
+		}
+	}
+
+	expect(t, do("POST", "/index/i"), http.StatusOK, "")
+	expect(t, do("GET", "/read-only"), http.StatusOK, `{"readOnly":false}`)
+
+	expect(t, do("POST", "/read-only?enabled=true"), http.StatusOK, `{"readOnly":true}`)
+	expect(t, do("POST", "/index/i"), http.StatusServiceUnavailable, `{"error":"node is in read-only mode"}`)
+	expect(t, do("GET", "/index/i"), http.StatusOK, "")
+	expect(t, do("POST", "/read-only?enabled=maybe"), http.StatusBadRequest, "")
+	expect(t, do("GET", "/read-only"), http.StatusOK, `{"readOnly":true}`)
+
+	t.Run("Queries", func(t *testing.T) {
+		for query, write := range map[string]bool{
+			"Row(f=1)":              false,
+			"Count(Row(f=1))":       false,
+			"Set(1, f=1)":           true,
+			"Clear(1, f=1)":         true,
+			"ClearRow(f=1)":         true,
+			"Delete(Row(f=1))":      true,
+			"Row(f=1)\nSet(1, f=2)": true,
+		} {
+			q, err := pql.NewParser(strings.NewReader(query)).Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isWriteQuery(q); got != write {
+				t.Errorf("isWriteQuery(%q) = %v, want %v", query, got, write)
+			}
+		}
+	})
+
+	t.Run("SQL", func(t *testing.T) {
+		if err := h.api.checkReadOnlySQL("SELECT * FROM t"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.api.checkReadOnlySQL("DROP TABLE t"); errors.Cause(err) != ErrReadOnly {
+			t.Fatalf("expected ErrReadOnly, got %v", err)
+		}
+	})
+
+	expect(t, do("POST", "/read-only?enabled=false"), http.StatusOK, `{"readOnly":false}`)
+	expect(t, do("POST", "/index/i"), http.StatusOK, "")
+	if err := h.api.checkReadOnlySQL("DROP TABLE t"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+	planner_types "github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// DefaultResultCacheMaxEntries is the default number of results kept in the
+// result cache.
+const DefaultResultCacheMaxEntries = 1000
+
+// DefaultResultCacheMaxEntrySize is the default size, in bytes, of the
+// largest result kept in the result cache.
+const DefaultResultCacheMaxEntrySize = 1 << 20
+
+// ResultCacheConfig configures the cache of PQL query results. The cache
+// holds the results of read-only queries received from clients (not those
+// forwarded from other nodes) for up to TTL, so that repeating a query
+// doesn't repeat its execution.
+//
+// Writes through this node drop the cached results for the index they write
+// to: write queries, imports, schema changes, restores and directives. A
+// write statement through the SQL interface drops every cached result once
+// it has run. Data can still change in ways this node doesn't see, such as
+// writes which reach other replicas directly, so a cached result can be out
+// of date by up to TTL; GET /result-cache shows what's cached, and DELETE
+// /result-cache drops it, for when a result has to be correct now.
+type ResultCacheConfig struct {
+	// TTL is how long a result is kept. If 0, results aren't cached.
+	TTL time.Duration
+
+	// MaxEntries is the most results kept; beyond it, the least recently
+	// used are dropped. 0 uses DefaultResultCacheMaxEntries.
+	MaxEntries int
+
+	// MaxEntrySize is the size, in bytes, of the largest result kept, as
+	// encoded in JSON. 0 uses DefaultResultCacheMaxEntrySize.
+	MaxEntrySize int
+}
+
+// ResultCacheEntry describes a result in the result cache. The result itself
+// isn't included.
+type ResultCacheEntry struct {
+	ID        string    `json:"id"`
+	Index     string    `json:"index"`
+	Query     string    `json:"query"`
+	Shards    []uint64  `json:"shards,omitempty"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Hits      uint64    `json:"hits"`
+	LastHitAt time.Time `json:"lastHitAt,omitempty"`
+
+	results []interface{}
+}
+
+// resultCache is the cache described by ResultCacheConfig. A nil
+// *resultCache caches nothing.
+type resultCache struct {
+	ttl          time.Duration
+	maxEntries   int
+	maxEntrySize int
+
+	mu      sync.Mutex
+	ll      *list.List               // of *ResultCacheEntry, most recently used first
+	entries map[string]*list.Element // by ID
+
+	// gens counts the invalidations of each index, and gen those of every
+	// index, so that a result computed while its index was written to isn't
+	// cached.
+	gens map[string]uint64
+	gen  uint64
+}
+
+// newResultCache returns a resultCache for cfg, or nil if cfg disables the
+// cache.
+func newResultCache(cfg ResultCacheConfig) (*resultCache, error) {
+	if cfg.TTL < 0 || cfg.MaxEntries < 0 || cfg.MaxEntrySize < 0 {
+		return nil, errors.New("result cache settings can't be negative")
+	}
+	if cfg.TTL == 0 {
+		return nil, nil
+	}
+	c := &resultCache{
+		ttl:          cfg.TTL,
+		maxEntries:   cfg.MaxEntries,
+		maxEntrySize: cfg.MaxEntrySize,
+		ll:           list.New(),
+		entries:      make(map[string]*list.Element),
+		gens:         make(map[string]uint64),
+	}
+	if c.maxEntries == 0 {
+		c.maxEntries = DefaultResultCacheMaxEntries
+	}
+	if c.maxEntrySize == 0 {
+		c.maxEntrySize = DefaultResultCacheMaxEntrySize
+	}
+	return c, nil
+}
+
+// resultCacheable returns true if the results of req may be cached. Remote
+// requests (including those from a DAX queryer) are never cached, since their
+// partial results are merged by the node which received the query.
+func resultCacheable(req *QueryRequest) bool {
+	return !req.Remote && !req.Profile && len(req.EmbeddedData) == 0
+}
+
+// resultCacheID returns the ID of the entry for the results of req.
+func resultCacheID(req *QueryRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Index))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Query))
+	h.Write([]byte{0})
+	for _, shard := range req.Shards {
+		h.Write([]byte(strconv.FormatUint(shard, 10)))
+		h.Write([]byte{','})
+	}
+	if req.PreTranslated {
+		h.Write([]byte{1})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// generation returns a value which changes whenever index is invalidated.
+func (c *resultCache) generation(index string) uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen + c.gens[index]
+}
+
+// get returns the cached results of req, if any.
+func (c *resultCache) get(req *QueryRequest) ([]interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	id := resultCacheID(req)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*ResultCacheEntry)
+	if !now.Before(e.ExpiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	e.Hits++
+	e.LastHitAt = now
+	c.ll.MoveToFront(el)
+	return e.results, true
+}
+
+// put caches results as those of req, unless req's index has been invalidated
+// since generation returned gen, or results are too large.
+func (c *resultCache) put(req *QueryRequest, results []interface{}, gen uint64) {
+	if c == nil {
+		return
+	}
+	// The size is an estimate of the memory the results use, and of
+	// what's saved by not sending them again.
+	data, err := json.Marshal(results)
+	if err != nil || len(data) > c.maxEntrySize {
+		return
+	}
+	now := time.Now()
+	e := &ResultCacheEntry{
+		ID:        resultCacheID(req),
+		Index:     req.Index,
+		Query:     req.Query,
+		Shards:    req.Shards,
+		Size:      len(data),
+		CreatedAt: now,
+		ExpiresAt: now.Add(c.ttl),
+		results:   results,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen+c.gens[req.Index] != gen {
+		return
+	}
+	if el, ok := c.entries[e.ID]; ok {
+		c.remove(el)
+	}
+	c.entries[e.ID] = c.ll.PushFront(e)
+	for c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *resultCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.entries, el.Value.(*ResultCacheEntry).ID)
+}
+
+// list returns the unexpired entries for index, or for every index if index
+// is "", most recently used first.
+func (c *resultCache) list(index string) []ResultCacheEntry {
+	out := make([]ResultCacheEntry, 0)
+	if c == nil {
+		return out
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*ResultCacheEntry)
+		if !now.Before(e.ExpiresAt) {
+			c.remove(el)
+		} else if index == "" || e.Index == index {
+			out = append(out, *e)
+		}
+		el = next
+	}
+	return out
+}
+
+// evict drops the entry with the given ID, returning false if there's no
+// such entry.
+func (c *resultCache) evict(id string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[id]
+	if ok {
+		c.remove(el)
+	}
+	return ok
+}
+
+// invalidate drops the entries for index, or every entry if index is "", and
+// returns the number dropped.
+func (c *resultCache) invalidate(index string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index == "" {
+		c.gen++
+	} else {
+		c.gens[index]++
+	}
+	var n int
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		if index == "" || el.Value.(*ResultCacheEntry).Index == index {
+			c.remove(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+// invalidateResultCache drops the cached results for index, or every cached
+// result if index is "". It's called once a write to index has been made, so
+// that results from before the write aren't returned after it.
+func (api *API) invalidateResultCache(index string) {
+	api.server.resultCache.invalidate(index)
+}
+
+// invalidatingPlan is the plan of a SQL write statement. Its iterator drops
+// every cached result once the statement has run, that is, once the iterator
+// is exhausted or fails, so that results from before the write aren't
+// returned after it.
+type invalidatingPlan struct {
+	planner_types.PlanOperator
+	cache *resultCache
+}
+
+func (p *invalidatingPlan) Iterator(ctx context.Context, row planner_types.Row) (planner_types.RowIterator, error) {
+	iter, err := p.PlanOperator.Iterator(ctx, row)
+	if err != nil {
+		p.cache.invalidate("")
+		return nil, err
+	}
+	return &invalidatingIterator{RowIterator: iter, cache: p.cache}, nil
+}
+
+type invalidatingIterator struct {
+	planner_types.RowIterator
+	cache *resultCache
+	once  sync.Once
+}
+
+func (i *invalidatingIterator) Next(ctx context.Context) (planner_types.Row, error) {
+	row, err := i.RowIterator.Next(ctx)
+	if err != nil {
+		i.once.Do(func() { i.cache.invalidate("") })
+	}
+	return row, err
+}
+
+type resultCacheResponse struct {
+	Enabled bool               `json:"enabled"`
+	Entries []ResultCacheEntry `json:"entries"`
+}
+
+// handleGetResultCache handles GET /result-cache?index=<index> requests,
+// which list the cached results, for one index or for all of them.
+func (h *Handler) handleGetResultCache(w http.ResponseWriter, r *http.Request) {
+	c := h.api.server.resultCache
+	resp := resultCacheResponse{
+		Enabled: c != nil,
+		Entries: c.list(r.URL.Query().Get("index")),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Errorf("write result cache response error: %s", err)
+	}
+}
+
+type resultCacheEvictResponse struct {
+	Evicted int `json:"evicted"`
+}
+
+// handleDeleteResultCache handles DELETE /result-cache?index=<index> requests,
+// which drop the cached results for one index, or for all of them.
+func (h *Handler) handleDeleteResultCache(w http.ResponseWriter, r *http.Request) {
+	index := r.URL.Query().Get("index")
+	n := h.api.server.resultCache.invalidate(index)
+
+	what := "all cached results"
+	if index != "" {
+		what = "cached results for index '" + index + "'"
+	}
+	user, _ := fbcontext.UserID(r.Context())
+	h.logger.Warnf("admin action: %s (%d) dropped on node %s by user '%s' from %s", what, n, h.api.NodeID(), user, GetIP(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resultCacheEvictResponse{Evicted: n}); err != nil {
+		h.logger.Errorf("write result cache response error: %s", err)
+	}
+}
+
+// handleDeleteResultCacheEntry handles DELETE /result-cache/{id} requests,
+// which drop a single cached result.
+func (h *Handler) handleDeleteResultCacheEntry(w http.ResponseWriter, r *http.Request) {
+	id := strings.ToLower(mux.Vars(r)["id"])
+	if !h.api.server.resultCache.evict(id) {
+		http.Error(w, "no cached result with id '"+id+"'", http.StatusNotFound)
+		return
+	}
+
+	user, _ := fbcontext.UserID(r.Context())
+	h.logger.Warnf("admin action: cached result %s dropped on node %s by user '%s' from %s", id, h.api.NodeID(), user, GetIP(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resultCacheEvictResponse{Evicted: 1}); err != nil {
+		h.logger.Errorf("write result cache response error: %s", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+	planner_types "github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+	"github.com/gorilla/mux"
+)
+
+func TestResultCache(t *testing.T) {
+	if c, err := newResultCache(ResultCacheConfig{}); err != nil || c != nil {
+		t.Fatalf("expected no cache without a TTL, got %v, %v", c, err)
+	}
+	if _, err := newResultCache(ResultCacheConfig{TTL: time.Minute, MaxEntries: -1}); err == nil {
+		t.Fatal("expected an error for negative max entries")
+	}
+
+	c, err := newResultCache(ResultCacheConfig{TTL: time.Minute, MaxEntries: 2, MaxEntrySize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := func(index, query string) *QueryRequest {
+		return &QueryRequest{Index: index, Query: query}
+	}
+	put := func(r *QueryRequest, results ...interface{}) {
+		c.put(r, results, c.generation(r.Index))
+	}
+
+	a, b := req("i", "Count(All())"), req("j", "Count(All())")
+	put(a, uint64(1))
+	put(b, uint64(2))
+	if results, ok := c.get(a); !ok || results[0] != uint64(1) {
+		t.Fatalf("expected cached result 1, got %v, %v", results, ok)
+	}
+	if _, ok := c.get(&QueryRequest{Index: "i", Query: "Count(All())", Shards: []uint64{1}}); ok {
+		t.Fatal("expected a miss for a different set of shards")
+	}
+
+	// b is now the least recently used, so it's dropped.
+	put(req("i", "Row(f=1)"), uint64(3))
+	if _, ok := c.get(b); ok {
+		t.Fatal("expected b to have been evicted")
+	}
+	entries := c.list("")
+	if len(entries) != 2 || entries[0].Query != "Row(f=1)" || entries[1].Hits != 1 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	// Results too large, or computed across an invalidation, aren't kept.
+	put(req("i", "Extract(All())"), string(make([]byte, 100)))
+	gen := c.generation("i")
+	c.invalidate("j")
+	c.put(req("i", "TopN(f)"), []interface{}{uint64(4)}, gen)
+	if _, ok := c.get(req("i", "TopN(f)")); !ok {
+		t.Fatal("expected a result unaffected by another index's invalidation")
+	}
+	c.invalidate("i")
+	c.put(req("i", "TopN(f)"), []interface{}{uint64(4)}, gen)
+	if n := len(c.list("")); n != 0 {
+		t.Fatalf("expected no entries, got %d", n)
+	}
+
+	put(a, uint64(1))
+	if !c.evict(resultCacheID(a)) || c.evict(resultCacheID(a)) {
+		t.Fatal("expected exactly one eviction")
+	}
+
+	c.ttl = time.Nanosecond
+	put(a, uint64(1))
+	time.Sleep(time.Millisecond)
+	if _, ok := c.get(a); ok {
+		t.Fatal("expected the result to have expired")
+	}
+}
+
+// rowsPlan is a plan whose iterator returns n empty rows.
+type rowsPlan struct {
+	planner_types.PlanOperator
+	n int
+}
+
+func (p *rowsPlan) Iterator(ctx context.Context, row planner_types.Row) (planner_types.RowIterator, error) {
+	return &rowsIterator{n: p.n}, nil
+}
+
+type rowsIterator struct{ n int }
+
+func (i *rowsIterator) Next(ctx context.Context) (planner_types.Row, error) {
+	if i.n == 0 {
+		return nil, planner_types.ErrNoMoreRows
+	}
+	i.n--
+	return planner_types.Row{}, nil
+}
+
+func TestResultCacheInvalidatingPlan(t *testing.T) {
+	c, err := newResultCache(ResultCacheConfig{TTL: time.Minute, MaxEntries: 2, MaxEntrySize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &QueryRequest{Index: "i", Query: "Count(All())"}
+	c.put(req, []interface{}{uint64(1)}, c.generation("i"))
+
+	// Compiling and starting a write doesn't drop anything; a query which
+	// runs meanwhile could cache a result from before the write.
+	ctx := context.Background()
+	iter, err := (&invalidatingPlan{PlanOperator: &rowsPlan{n: 1}, cache: c}).Iterator(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := iter.Next(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.get(req); !ok {
+		t.Fatal("expected the result to be cached until the write finishes")
+	}
+
+	if _, err := iter.Next(ctx); err != planner_types.ErrNoMoreRows {
+		t.Fatalf("expected no more rows, got %v", err)
+	}
+	if _, ok := c.get(req); ok {
+		t.Fatal("expected the write to have dropped the result")
+	}
+}
+
+func TestResultCacheHandlers(t *testing.T) {
+	c, err := newResultCache(ResultCacheConfig{TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{
+		api:    &API{server: &Server{resultCache: c}},
+		logger: logger.NopLogger,
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/result-cache", h.handleGetResultCache).Methods("GET")
+	router.HandleFunc("/result-cache", h.handleDeleteResultCache).Methods("DELETE")
+	router.HandleFunc("/result-cache/{id}", h.handleDeleteResultCacheEntry).Methods("DELETE")
+	do := func(method, path string, status int, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		if w.Code != status {
+			t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+		}
+		if v != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, r := range []*QueryRequest{
+		{Index: "i", Query: "Count(All())"},
+		{Index: "i", Query: "Count(Row(f=1))"},
+		{Index: "j", Query: "Count(All())"},
+	} {
+		c.put(r, []interface{}{uint64(1)}, c.generation(r.Index))
+	}
+
+	var list resultCacheResponse
+	do("GET", "/result-cache?index=i", http.StatusOK, &list)
+	if !list.Enabled || len(list.Entries) != 2 || list.Entries[0].Size != 3 {
+		t.Fatalf("unexpected listing: %+v", list)
+	}
+
+	var evicted resultCacheEvictResponse
+	do("DELETE", "/result-cache/"+list.Entries[0].ID, http.StatusOK, &evicted)
+	do("DELETE", "/result-cache/"+list.Entries[0].ID, http.StatusNotFound, nil)
+	do("DELETE", "/result-cache?index=i", http.StatusOK, &evicted)
+	if evicted.Evicted != 1 {
+		t.Fatalf("expected 1 eviction, got %d", evicted.Evicted)
+	}
+	do("DELETE", "/result-cache", http.StatusOK, &evicted)
+	if evicted.Evicted != 1 {
+		t.Fatalf("expected 1 eviction, got %d", evicted.Evicted)
+	}
+
+	h.api.server.resultCache = nil
+	do("GET", "/result-cache", http.StatusOK, &list)
+	if list.Enabled || list.Entries == nil || len(list.Entries) != 0 {
+		t.Fatalf("unexpected listing: %+v", list)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultResultFlushRows is the default number of rows of a streamed
+	// result after which they're flushed to the client.
+	DefaultResultFlushRows = 1000
+
+	// DefaultResultFlushInterval is the default longest time rows of a
+	// streamed result are held before they're flushed to the client.
+	DefaultResultFlushInterval = 100 * time.Millisecond
+)
+
+// ResultFlush is the policy by which the rows of a streamed result, such as
+// that of POST /sql, are flushed to the client: once Rows rows are waiting,
+// or once the first of them has waited Interval, whichever comes first. The
+// rows still waiting when the result ends are always flushed. Interactive
+// clients will want fewer rows and a shorter interval, for a shorter time to
+// the first row; bulk exports will want more of both, for fewer and larger
+// writes.
+//
+// A request can override the node's policy with the flushRows and
+// flushInterval arguments; an interval given as a bare number is in
+// milliseconds.
+type ResultFlush struct {
+	// Rows is the number of rows after which they're flushed. If 0,
+	// DefaultResultFlushRows is used.
+	Rows int
+
+	// Interval is the longest a row is held before it's flushed. If 0,
+	// DefaultResultFlushInterval is used.
+	Interval time.Duration
+}
+
+func (p ResultFlush) withDefaults() ResultFlush {
+	if p.Rows <= 0 {
+		p.Rows = DefaultResultFlushRows
+	}
+	if p.Interval <= 0 {
+		p.Interval = DefaultResultFlushInterval
+	}
+	return p
+}
+
+// withRequest returns the policy p, overridden by the flushRows and
+// flushInterval arguments of r.
+func (p ResultFlush) withRequest(r *http.Request) (ResultFlush, error) {
+	q := r.URL.Query()
+	if s := q.Get("flushRows"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return p, errors.Errorf("flushRows must be a positive integer: %s", s)
+		}
+		p.Rows = n
+	}
+	if s := q.Get("flushInterval"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			ms, msErr := strconv.ParseInt(s, 10, 64)
+			if msErr != nil {
+				return p, errors.Errorf("flushInterval must be a duration, or a number of milliseconds: %s", s)
+			}
+			d = time.Duration(ms) * time.Millisecond
+		}
+		if d <= 0 {
+			return p, errors.Errorf("flushInterval must be positive: %s", s)
+		}
+		p.Interval = d
+	}
+	return p, nil
+}
+
+// resultFlusher batches the rows of a streamed result, and writes and flushes
+// them according to a ResultFlush policy. Since rows are flushed by a timer as
+// well as by the writer, both hold mu while they use w.
+type resultFlusher struct {
+	w       io.Writer
+	flusher http.Flusher
+	policy  ResultFlush
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	rows   int
+	timer  *time.Timer
+	gen    int // counts flushes, so a timer knows if it's been beaten to one
+	closed bool
+}
+
+func newResultFlusher(w http.ResponseWriter, policy ResultFlush) *resultFlusher {
+	f, _ := w.(http.Flusher)
+	return &resultFlusher{
+		w:       w,
+		flusher: f,
+		policy:  policy.withDefaults(),
+	}
+}
+
+// writeRow adds a row, made up of the concatenation of data, to the batch,
+// and flushes the batch if it's full.
+func (f *resultFlusher) writeRow(data ...[]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range data {
+		f.buf.Write(d)
+	}
+	f.rows++
+	if f.rows >= f.policy.Rows {
+		f.flush()
+		return
+	}
+	if f.timer == nil {
+		gen := f.gen
+		f.timer = time.AfterFunc(f.policy.Interval, func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if !f.closed && f.gen == gen {
+				f.flush()
+			}
+		})
+	}
+}
+
+// close flushes the rows still waiting. w may be used freely once it
+// returns.
+func (f *resultFlusher) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flush()
+	f.closed = true
+}
+
+// flush writes and flushes the batch. f.mu must be held.
+func (f *resultFlusher) flush() {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.gen++
+	if f.buf.Len() == 0 {
+		return
+	}
+	_, _ = f.w.Write(f.buf.Bytes())
+	f.buf.Reset()
+	f.rows = 0
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flushRecorder is a ResponseRecorder which records what had been written at
+// each flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	mu      sync.Mutex
+	flushes []string
+}
+
+func (r *flushRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes = append(r.flushes, r.Body.String())
+}
+
+func (r *flushRecorder) flushed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.flushes...)
+}
+
+func TestResultFlush(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		p := ResultFlush{}.withDefaults()
+		if p.Rows != DefaultResultFlushRows || p.Interval != DefaultResultFlushInterval {
+			t.Fatalf("unexpected defaults: %+v", p)
+		}
+		for args, exp := range map[string]ResultFlush{
+			"":                                    {Rows: 10, Interval: time.Second},
+			"flushRows=1":                         {Rows: 1, Interval: time.Second},
+			"flushInterval=250":                   {Rows: 10, Interval: 250 * time.Millisecond},
+			"flushRows=5000&flushInterval=2s":     {Rows: 5000, Interval: 2 * time.Second},
+			"flushRows=5000&flushInterval=1500ms": {Rows: 5000, Interval: 1500 * time.Millisecond},
+		} {
+			got, err := ResultFlush{Rows: 10, Interval: time.Second}.withRequest(httptest.NewRequest("POST", "/sql?"+args, nil))
+			if err != nil {
+				t.Fatalf("%s: %v", args, err)
+			} else if got != exp {
+				t.Fatalf("%s: expected %+v, got %+v", args, exp, got)
+			}
+		}
+		for _, args := range []string{"flushRows=0", "flushRows=many", "flushInterval=-1s", "flushInterval=0", "flushInterval=soon"} {
+			if _, err := (ResultFlush{}).withRequest(httptest.NewRequest("POST", "/sql?"+args, nil)); err == nil {
+				t.Fatalf("%s: expected an error", args)
+			}
+		}
+	})
+
+	t.Run("Rows", func(t *testing.T) {
+		w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		f := newResultFlusher(w, ResultFlush{Rows: 2, Interval: time.Hour})
+		f.writeRow([]byte("a"))
+		f.writeRow([]byte(","), []byte("b"))
+		f.writeRow([]byte(","), []byte("c"))
+		if got := w.flushed(); len(got) != 1 || got[0] != "a,b" {
+			t.Fatalf("unexpected flushes: %q", got)
+		}
+		// The final partial batch is flushed on close.
+		f.close()
+		if got := w.flushed(); len(got) != 2 || got[1] != "a,b,c" {
+			t.Fatalf("unexpected flushes: %q", got)
+		}
+		// Closing again has nothing more to flush.
+		f.close()
+		if got := w.flushed(); len(got) != 2 {
+			t.Fatalf("unexpected flushes: %q", got)
+		}
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		f := newResultFlusher(w, ResultFlush{Rows: 1000, Interval: 10 * time.Millisecond})
+		f.writeRow([]byte("a"))
+		deadline := time.Now().Add(5 * time.Second)
+		for len(w.flushed()) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("rows weren't flushed after the interval")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		f.close()
+		if got := w.flushed(); len(got) != 1 || got[0] != "a" {
+			t.Fatalf("unexpected flushes: %q", got)
+		}
+		if body := w.Body.String(); body != "a" {
+			t.Fatalf("unexpected body: %q", body)
+		}
+	})
+}
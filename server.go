@@ -81,6 +81,13 @@ type Server struct { // nolint: maligned
 	confirmDownRetries   int
 	syncer               holderSyncer
 	maxQueryMemory       int64
+	queryShardLimits     QueryShardLimits
+	queryRouting         QueryRouting
+	snapshotBudgetConfig SnapshotBudget
+	queryPreemption      QueryPreemption
+	snapshotBudget       *snapshotBudget
+	resultCacheConfig    ResultCacheConfig
+	resultCache          *resultCache
 
 	translationSyncer      TranslationSyncer
 	resetTranslationSyncCh chan struct{}
@@ -102,6 +109,9 @@ type Server struct { // nolint: maligned
 
 	dataframeEnabled    bool
 	dataframeUseParquet bool
+
+	// readOnlyMode is 1 while the node rejects writes; see API.SetReadOnly.
+	readOnlyMode int32
 }
 
 type ExecutionPlannerFn func(executor Executor, api *API, sql string) sql3.CompilePlanner
@@ -407,6 +417,50 @@ func OptServerMaxQueryMemory(v int64) ServerOption {
 	}
 }
 
+// OptServerQueryShardLimits sets the limits on the number of shards a single
+// query may touch.
+func OptServerQueryShardLimits(l QueryShardLimits) ServerOption {
+	return func(s *Server) error {
+		s.queryShardLimits = l
+		return nil
+	}
+}
+
+// OptServerQueryRouting sets how queries choose among the replicas of a
+// shard.
+func OptServerQueryRouting(r QueryRouting) ServerOption {
+	return func(s *Server) error {
+		s.queryRouting = r
+		return nil
+	}
+}
+
+// OptServerQueryPreemption configures the preemption of batch queries by
+// interactive ones.
+func OptServerQueryPreemption(p QueryPreemption) ServerOption {
+	return func(s *Server) error {
+		s.queryPreemption = p
+		return nil
+	}
+}
+
+// OptServerResultCache configures the cache of query results.
+func OptServerResultCache(cfg ResultCacheConfig) ServerOption {
+	return func(s *Server) error {
+		s.resultCacheConfig = cfg
+		return nil
+	}
+}
+
+// OptServerSnapshotBudget sets the limits on the resources used by background
+// shard snapshots.
+func OptServerSnapshotBudget(b SnapshotBudget) ServerOption {
+	return func(s *Server) error {
+		s.snapshotBudgetConfig = b
+		return nil
+	}
+}
+
 // OptServerDisCo is a functional option on Server
 // used to set the Distributed Consensus implementation.
 func OptServerDisCo(disCo disco.DisCo,
@@ -536,10 +590,33 @@ func NewServer(opts ...ServerOption) (*Server, error) {
 		maxQueryMemory = int64(float64(memTotal) * .20)
 	}
 
+	shardLimits, err := newShardLimits(s.queryShardLimits)
+	if err != nil {
+		return nil, errors.Wrap(err, "query shard limits")
+	}
+
+	router, err := newReplicaRouter(s.queryRouting)
+	if err != nil {
+		return nil, errors.Wrap(err, "query routing")
+	}
+
+	s.snapshotBudget, err = newSnapshotBudget(s.snapshotBudgetConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "snapshot budget")
+	}
+
+	s.resultCache, err = newResultCache(s.resultCacheConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "result cache")
+	}
+
 	// set up executor after server opts have been processed
 	executorOpts := []executorOption{
 		optExecutorInternalQueryClient(s.defaultClient),
 		optExecutorMaxMemory(maxQueryMemory),
+		optExecutorShardLimits(shardLimits),
+		optExecutorReplicaRouter(router),
+		optExecutorQueryScheduler(newQueryScheduler(s.queryPreemption)),
 	}
 	if s.executorPoolSize > 0 {
 		executorOpts = append(executorOpts, optExecutorWorkerPoolSize(s.executorPoolSize))
@@ -709,12 +786,13 @@ func (s *Server) Open() error {
 		return errors.Wrap(err, "setting nodeState")
 	}
 
-	if ok := s.addToWaitGroup(3); !ok {
+	if ok := s.addToWaitGroup(4); !ok {
 		return fmt.Errorf("closing server while opening server is NOT allowed")
 	}
 	go func() { defer s.wg.Done(); s.monitorRuntime() }()
 	go func() { defer s.wg.Done(); s.monitorDiagnostics() }()
 	go func() { defer s.wg.Done(); s.monitorViewsRemoval() }()
+	go func() { defer s.wg.Done(); s.monitorMaterializedViews() }()
 
 	toSend := func() []Message {
 		s.holder.startMsgsMu.Lock()
@@ -914,6 +992,38 @@ func (s *Server) monitorViewsRemoval() {
 	}
 }
 
+// monitorMaterializedViews refreshes the materialized views which are due to
+// be refreshed. Only the primary refreshes them, so that the nodes don't
+// refresh each view in turn.
+func (s *Server) monitorMaterializedViews() {
+	ctx := context.Background()
+	ticker := time.NewTicker(sql3.ViewRefreshCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+		if !s.IsPrimary() {
+			continue
+		}
+		if err := s.RefreshMaterializedViews(ctx, time.Now().UTC()); err != nil {
+			s.logger.Errorf("refreshing materialized views: %s", err)
+		}
+	}
+}
+
+// RefreshMaterializedViews refreshes the materialized views which are due to
+// be refreshed at now.
+func (s *Server) RefreshMaterializedViews(ctx context.Context, now time.Time) error {
+	r, ok := s.executionPlannerFn(s.executor, s.executor.client.api, "").(sql3.MaterializedViewRefresher)
+	if !ok {
+		return nil
+	}
+	return r.RefreshMaterializedViews(ctx, now)
+}
+
 // Remove views based on these criterias:
 // 1. views that are older than specified TTL
 // 2. "standard" view of a field if its "noStandardView" option is set to true
@@ -1450,7 +1560,14 @@ func (s *Server) CompileExecutionPlan(ctx context.Context, q string) (planner_ty
 	if err != nil {
 		return nil, err
 	}
-	return s.executionPlannerFn(s.executor, s.executor.client.api, q).CompilePlan(ctx, st)
+	if err := s.checkReadOnlyStatement(st); err != nil {
+		return nil, err
+	}
+	plan, err := s.executionPlannerFn(s.executor, s.executor.client.api, q).CompilePlan(ctx, st)
+	if err != nil || parser.IsReadOnly(st) || s.resultCache == nil {
+		return plan, err
+	}
+	return &invalidatingPlan{PlanOperator: plan, cache: s.resultCache}, nil
 }
 
 func (s *Server) RehydratePlanOperator(ctx context.Context, reader io.Reader) (planner_types.PlanOperator, error) {
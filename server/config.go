@@ -16,9 +16,11 @@ import (
 	"strings"
 	"time"
 
+	pilosa "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/authz"
 	petcd "github.com/featurebasedb/featurebase/v3/etcd"
 	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner"
 	"github.com/featurebasedb/featurebase/v3/storage"
 	"github.com/featurebasedb/featurebase/v3/toml"
 	"github.com/pkg/errors"
@@ -63,11 +65,49 @@ type Config struct {
 	// for availability/durability.
 	WriteloggerDir string `toml:"writelogger-dir"`
 
+	// WriteloggerMinFreeBytes is the amount of headroom to reserve on the
+	// disk holding WriteloggerDir. When free space falls below this value,
+	// writes are rejected and an emergency snapshot is requested in order to
+	// reclaim write log space. A value of 0 disables the check.
+	WriteloggerMinFreeBytes uint64 `toml:"writelogger-min-free-bytes"`
+
+	// WriteloggerBlockOnFull, when true, causes writes to wait up to
+	// WriteloggerBlockTimeout for disk space to be reclaimed instead of being
+	// rejected immediately.
+	WriteloggerBlockOnFull  bool          `toml:"writelogger-block-on-full"`
+	WriteloggerBlockTimeout toml.Duration `toml:"writelogger-block-timeout"`
+
+	// WriteloggerAutoTruncate, when true, causes write logs to be deleted
+	// automatically once a snapshot covering them is listed by the
+	// snapshotter, both as each snapshot is written and by a sweep of every
+	// write log every WriteloggerTruncateInterval (default 5m). The
+	// WriteloggerRetainLogs most recent covered logs of each resource are
+	// kept, as are logs still being read. See writelogger.Retention.
+	WriteloggerAutoTruncate     bool          `toml:"writelogger-auto-truncate"`
+	WriteloggerRetainLogs       int           `toml:"writelogger-retain-logs"`
+	WriteloggerTruncateInterval toml.Duration `toml:"writelogger-truncate-interval"`
+
 	// SnapshotterDir is the location at which this node should
 	// read/write snapshots. Typically a network mounted filesystem
 	// for availability/durability.
 	SnapshotterDir string `toml:"snapshotter-dir"`
 
+	// SnapshotterStagingDir is the location at which snapshots are written
+	// before being moved into SnapshotterDir. It may be on a different disk
+	// than SnapshotterDir. Defaults to a directory within SnapshotterDir.
+	SnapshotterStagingDir string `toml:"snapshotter-staging-dir"`
+
+	// SnapshotterMinFreeBytes is the amount of headroom to reserve on the
+	// disk holding SnapshotterStagingDir. Snapshots which would leave less
+	// than this free are rejected, or aborted if already in progress. Zero
+	// disables the check.
+	SnapshotterMinFreeBytes uint64 `toml:"snapshotter-min-free-bytes"`
+
+	// SnapshotBudget limits the concurrency and disk bandwidth of background
+	// shard snapshots, such as those scheduled by the controller. Snapshots
+	// requested with urgent priority aren't limited.
+	SnapshotBudget pilosa.SnapshotBudget `toml:"snapshot-budget"`
+
 	// DataDir is the directory where Pilosa stores both indexed data and
 	// running state such as cluster topology information.
 	DataDir string `toml:"data-dir"`
@@ -112,6 +152,53 @@ type Config struct {
 	Handler struct {
 		// CORS Allowed Origins
 		AllowedOrigins []string `toml:"allowed-origins"`
+
+		// ProxyProtocol enables parsing of PROXY protocol headers, which
+		// L4 load balancers send to preserve the client's address. Headers
+		// are only trusted from ProxyProtocolUpstreams (IP addresses or
+		// CIDR networks).
+		ProxyProtocol          bool     `toml:"proxy-protocol"`
+		ProxyProtocolUpstreams []string `toml:"proxy-protocol-upstreams"`
+
+		// Compression configures gzip compression of responses.
+		Compression pilosa.ResponseCompression `toml:"compression"`
+
+		// Decompression configures the decompression of gzip and zstd
+		// request bodies.
+		Decompression pilosa.RequestDecompression `toml:"decompression"`
+
+		// ReadOnly starts the node in read-only mode, in which it rejects
+		// all writes and schema changes. It can be toggled at runtime
+		// through POST /read-only.
+		ReadOnly bool `toml:"read-only"`
+
+		// ResultFlush is the default policy by which the rows of streamed
+		// results, such as those of POST /sql, are flushed to clients:
+		// every Rows rows (default 1000) or Interval (default 100ms),
+		// whichever comes first. Requests can override it with the
+		// flushRows and flushInterval arguments.
+		ResultFlush struct {
+			Rows     int           `toml:"rows"`
+			Interval toml.Duration `toml:"interval"`
+		} `toml:"result-flush"`
+
+		// UnsafeFaultInjection enables the /internal/faults endpoints,
+		// through which an admin can make the node fail, slow down, or
+		// reject requests for a limited time, to test failover. It's for
+		// staging and test clusters only.
+		UnsafeFaultInjection bool `toml:"unsafe-fault-injection"`
+
+		// Shutdown bounds each phase of the HTTP server's shutdown: first
+		// in-flight requests are given DrainTimeout to finish, then their
+		// contexts are cancelled and they're given CancelTimeout to
+		// return, and then their connections are closed and they're given
+		// ForceCloseTimeout to return. Zero timeouts use the defaults (20s,
+		// 8s, and 2s).
+		Shutdown struct {
+			DrainTimeout      toml.Duration `toml:"drain-timeout"`
+			CancelTimeout     toml.Duration `toml:"cancel-timeout"`
+			ForceCloseTimeout toml.Duration `toml:"force-close-timeout"`
+		} `toml:"shutdown"`
 	} `toml:"handler"`
 
 	// MaxMapCount puts an in-process limit on the number of mmaps. After this
@@ -152,6 +239,54 @@ type Config struct {
 	// Limits the total amount of memory to be used by Extract() & SELECT queries.
 	MaxQueryMemory int64 `toml:"max-query-memory"`
 
+	// QueryShardLimits limits the number of shards a single query may touch.
+	QueryShardLimits pilosa.QueryShardLimits `toml:"query-shard-limits"`
+
+	// QueryConcurrencyLimits limits the number of queries each user, and the
+	// node, may have executing at once, and reserves some of the node's
+	// capacity for particular users.
+	QueryConcurrencyLimits pilosa.QueryConcurrencyLimits `toml:"query-concurrency-limits"`
+
+	// QueryRouting configures how queries choose among the replicas of a
+	// shard: "primary" (the default), "round-robin", "least-connections",
+	// or "least-load". The least-load strategy uses the load which nodes
+	// report in their query responses, and falls back to least-connections
+	// when a replica hasn't reported within LoadStaleAfter (default 10s).
+	QueryRouting struct {
+		Strategy       string        `toml:"strategy"`
+		LoadStaleAfter toml.Duration `toml:"load-stale-after"`
+	} `toml:"query-routing"`
+
+	// QueryPreemption configures the preemption of batch queries, those
+	// sent with the X-Pilosa-Query-Priority: batch header, by interactive
+	// ones; see pilosa.QueryPreemption. A batch query waits at most MaxWait
+	// (default 100ms) before each shard; negative disables preemption.
+	QueryPreemption struct {
+		MaxWait toml.Duration `toml:"max-wait"`
+	} `toml:"query-preemption"`
+
+	// Distinct limits the resources used by each SQL DISTINCT; see
+	// planner.DistinctConfig. By default, a DISTINCT spills to disk beyond
+	// 1MiB, and may produce any number of rows.
+	Distinct planner.DistinctConfig `toml:"distinct"`
+
+	// ResultCache configures the cache of PQL query results; see
+	// pilosa.ResultCacheConfig. Results are only cached if TTL is set.
+	ResultCache struct {
+		TTL          toml.Duration `toml:"ttl"`
+		MaxEntries   int           `toml:"max-entries"`
+		MaxEntrySize int           `toml:"max-entry-size"`
+	} `toml:"result-cache"`
+
+	// ClockSkewTolerance is the difference between another node's clock and
+	// this one's beyond which the skew is logged and counted in the
+	// clock_skew_exceeded_total metric. Requests between nodes carry their
+	// deadlines as durations, so skew within any bound doesn't shorten them;
+	// the tolerance only decides when skew is reported. It must exceed the
+	// usual time a request between nodes spends in transit. 0 uses the
+	// default (1s).
+	ClockSkewTolerance toml.Duration `toml:"clock-skew-tolerance"`
+
 	// On startup, featurebase server contacts a web server to check the latest version.
 	// This stores the address for that check
 	VerChkAddress string `toml:"verchk-address"`
@@ -118,6 +118,12 @@ func errToStatusError(err error) error {
 	case pilosa.ErrAborted:
 		return status.Error(codes.Aborted, err.Error())
 
+	case pilosa.ErrTooManyShards:
+		return status.Error(codes.ResourceExhausted, err.Error())
+
+	case pilosa.ErrReadOnly:
+		return status.Error(codes.Unavailable, err.Error())
+
 	case pilosa.ErrClusterDoesNotOwnShard,
 		pilosa.ErrNodeNotPrimary,
 		pilosa.ErrTooManyWrites,
@@ -413,6 +419,9 @@ func (h *GRPCHandler) CreateIndex(ctx context.Context, req *pb.CreateIndexReques
 			return nil, status.Error(codes.PermissionDenied, "must be admin to create index")
 		}
 	}
+	if h.api.ReadOnly() {
+		return nil, errToStatusError(pilosa.ErrReadOnly)
+	}
 	// Always enable TrackExistence for gRPC-created indexes
 	opts := pilosa.IndexOptions{Keys: req.Keys, TrackExistence: true}
 	_, err := h.api.CreateIndex(ctx, req.Name, opts)
@@ -488,6 +497,9 @@ func (h *GRPCHandler) DeleteIndex(ctx context.Context, req *pb.DeleteIndexReques
 			return nil, status.Error(codes.PermissionDenied, "must be admin to delete index")
 		}
 	}
+	if h.api.ReadOnly() {
+		return nil, errToStatusError(pilosa.ErrReadOnly)
+	}
 	err := h.api.DeleteIndex(ctx, req.Name)
 	if err != nil {
 		return nil, errToStatusError(err)
@@ -95,6 +95,10 @@ type Command struct {
 	tlsConfig    *tls.Config
 	closeTimeout time.Duration
 
+	// lnProxyProtocol is set when ln itself parses PROXY protocol headers
+	// (beneath TLS), rather than leaving that to the handler.
+	lnProxyProtocol bool
+
 	serverOptions []pilosa.ServerOption
 	auth          *authn.Auth
 
@@ -492,7 +496,10 @@ func (m *Command) setupServer() error {
 	}
 
 	if m.Config.Listener == nil {
-		m.ln, err = getListener(*uri, m.tlsConfig)
+		// The PROXY protocol header is sent before the TLS handshake, so
+		// with TLS it has to be parsed beneath the TLS listener.
+		m.lnProxyProtocol = m.Config.Handler.ProxyProtocol && uri.Scheme == "https" && m.tlsConfig != nil
+		m.ln, err = getListener(*uri, m.tlsConfig, m.lnProxyProtocol, m.Config.Handler.ProxyProtocolUpstreams)
 		if err != nil {
 			return errors.Wrap(err, "getting listener")
 		}
@@ -570,12 +577,16 @@ func (m *Command) setupServer() error {
 		m.serverlessStorage = storage.NewResourceManager(m.snapshotService, m.writelogService, m.logger)
 	}
 
+	views := planner.NewViewRefresher()
 	executionPlannerFn := func(e pilosa.Executor, api *pilosa.API, sql string) sql3.CompilePlanner {
 		fapi := pilosa.NewOnPremSchema(api)
 		fsapi := &pilosa.FeatureBaseSystemAPI{API: api}
 		imp := pilosa.NewOnPremImporter(api)
 
-		return planner.NewExecutionPlanner(e, fapi, fsapi, m.Server.SystemLayer, imp, m.logger, sql)
+		pl := planner.NewExecutionPlanner(e, fapi, fsapi, m.Server.SystemLayer, imp, m.logger, sql)
+		pl.SetDistinctConfig(m.Config.Distinct)
+		pl.SetViewRefresher(views, "")
+		return pl
 	}
 
 	serverOptions := []pilosa.ServerOption{
@@ -600,6 +611,20 @@ func (m *Command) setupServer() error {
 		pilosa.OptServerStorageConfig(m.Config.Storage),
 		pilosa.OptServerRBFConfig(m.Config.RBFConfig),
 		pilosa.OptServerMaxQueryMemory(m.Config.MaxQueryMemory),
+		pilosa.OptServerQueryShardLimits(m.Config.QueryShardLimits),
+		pilosa.OptServerQueryRouting(pilosa.QueryRouting{
+			Strategy:       m.Config.QueryRouting.Strategy,
+			LoadStaleAfter: time.Duration(m.Config.QueryRouting.LoadStaleAfter),
+		}),
+		pilosa.OptServerResultCache(pilosa.ResultCacheConfig{
+			TTL:          time.Duration(m.Config.ResultCache.TTL),
+			MaxEntries:   m.Config.ResultCache.MaxEntries,
+			MaxEntrySize: m.Config.ResultCache.MaxEntrySize,
+		}),
+		pilosa.OptServerQueryPreemption(pilosa.QueryPreemption{
+			MaxWait: time.Duration(m.Config.QueryPreemption.MaxWait),
+		}),
+		pilosa.OptServerSnapshotBudget(m.Config.SnapshotBudget),
 		pilosa.OptServerQueryHistoryLength(m.Config.QueryHistoryLength),
 		pilosa.OptServerPartitionAssigner(m.Config.Cluster.PartitionToNodeAssignment),
 		pilosa.OptServerExecutionPlannerFn(executionPlannerFn),
@@ -712,6 +737,13 @@ func (m *Command) setupServer() error {
 		return errors.Wrap(err, "getting grpcServer")
 	}
 
+	// Queries are admitted with the most memory they may use, unless told
+	// otherwise.
+	queryLimits := m.Config.QueryConcurrencyLimits
+	if queryLimits.QueryMemory == 0 {
+		queryLimits.QueryMemory = m.Config.MaxQueryMemory
+	}
+
 	hndlr, err := pilosa.NewHandler(
 		pilosa.OptHandlerAllowedOrigins(m.Config.Handler.AllowedOrigins),
 		pilosa.OptHandlerAPI(m.API),
@@ -719,7 +751,24 @@ func (m *Command) setupServer() error {
 		pilosa.OptHandlerQueryLogger(m.queryLogger),
 		pilosa.OptHandlerFileSystem(&statik.FileSystem{}),
 		pilosa.OptHandlerListener(m.ln, m.Config.Advertise),
+		pilosa.OptHandlerShutdownTimeouts(pilosa.ShutdownTimeouts{
+			Drain:      time.Duration(m.Config.Handler.Shutdown.DrainTimeout),
+			Cancel:     time.Duration(m.Config.Handler.Shutdown.CancelTimeout),
+			ForceClose: time.Duration(m.Config.Handler.Shutdown.ForceCloseTimeout),
+		}),
 		pilosa.OptHandlerCloseTimeout(m.closeTimeout),
+		pilosa.OptHandlerProxyProtocol(m.Config.Handler.ProxyProtocol && !m.lnProxyProtocol),
+		pilosa.OptHandlerProxyProtocolUpstreams(m.Config.Handler.ProxyProtocolUpstreams),
+		pilosa.OptHandlerResponseCompression(m.Config.Handler.Compression),
+		pilosa.OptHandlerRequestDecompression(m.Config.Handler.Decompression),
+		pilosa.OptHandlerQueryConcurrencyLimits(queryLimits),
+		pilosa.OptHandlerReadOnly(m.Config.Handler.ReadOnly),
+		pilosa.OptHandlerResultFlush(pilosa.ResultFlush{
+			Rows:     m.Config.Handler.ResultFlush.Rows,
+			Interval: time.Duration(m.Config.Handler.ResultFlush.Interval),
+		}),
+		pilosa.OptHandlerUnsafeFaultInjection(m.Config.Handler.UnsafeFaultInjection),
+		pilosa.OptHandlerClockSkewTolerance(time.Duration(m.Config.ClockSkewTolerance)),
 		pilosa.OptHandlerMiddleware(m.grpcServer.middleware(m.Config.Handler.AllowedOrigins)),
 		pilosa.OptHandlerAuthN(m.auth),
 		pilosa.OptHandlerAuthZ(&p),
@@ -727,6 +776,11 @@ func (m *Command) setupServer() error {
 		pilosa.OptHandlerRoaringSerializer(proto.RoaringSerializer),
 	)
 	if err != nil {
+		// A plain listener is wrapped for the PROXY protocol by the
+		// handler, so close the listener we opened if that fails.
+		if m.Config.Listener == nil {
+			m.ln.Close()
+		}
 		return errors.Wrap(err, "new handler")
 	}
 
@@ -750,6 +804,13 @@ func (m *Command) HTTPHandler() http.Handler {
 	return m.httpHandler
 }
 
+// ServerlessStorage returns the ResourceManager holding the snapshot and
+// writelog resources of the tables on this node. It's nil unless the
+// Command has been set up to use a writelogger and snapshotter.
+func (m *Command) ServerlessStorage() *storage.ResourceManager {
+	return m.serverlessStorage
+}
+
 // setupLogger sets up the logger based on the configuration.
 func (m *Command) setupLogger() error {
 	var f *logger.FileWriter
@@ -910,14 +971,25 @@ func (m *Command) Close() error {
 	}
 }
 
-// getListener gets a net.Listener based on the config.
-func getListener(uri pnet.URI, tlsconf *tls.Config) (ln net.Listener, err error) {
+// getListener gets a net.Listener based on the config. If proxyProtocol is
+// set, a TLS listener parses PROXY protocol headers from proxyUpstreams before
+// the TLS handshake.
+func getListener(uri pnet.URI, tlsconf *tls.Config, proxyProtocol bool, proxyUpstreams []string) (ln net.Listener, err error) {
 	// If bind URI has the https scheme, enable TLS
 	if uri.Scheme == "https" && tlsconf != nil {
-		ln, err = tls.Listen("tcp", uri.HostPort(), tlsconf)
+		ln, err = net.Listen("tcp", uri.HostPort())
 		if err != nil {
-			return nil, errors.Wrap(err, "tls.Listener")
+			return nil, errors.Wrap(err, "net.Listen")
+		}
+		if proxyProtocol {
+			pln, err := pilosa.NewProxyProtocolListener(ln, proxyUpstreams)
+			if err != nil {
+				ln.Close()
+				return nil, errors.Wrap(err, "enabling proxy protocol")
+			}
+			ln = pln
 		}
+		ln = tls.NewListener(ln, tlsconf)
 	} else if uri.Scheme == "http" {
 		// Open HTTP listener to determine port (if specified as :0).
 		ln, err = net.Listen("tcp", uri.HostPort())
@@ -1,8 +1,12 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
 	"testing"
+
+	pnet "github.com/featurebasedb/featurebase/v3/net"
 )
 
 // unit tests for internal functions
@@ -38,3 +42,23 @@ func TestIsAllowed(t *testing.T) {
 	}
 
 }
+
+func TestGetListenerClosesOnError(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := uint16(ln.Addr().(*net.TCPAddr).Port)
+	ln.Close()
+
+	uri := pnet.URI{Scheme: "https", Host: "localhost", Port: port}
+	if _, err := getListener(uri, &tls.Config{}, true, nil); err == nil {
+		t.Fatal("expected an error with no trusted upstreams")
+	}
+	// The listener opened before the error must have been closed.
+	ln, err = net.Listen("tcp", uri.HostPort())
+	if err != nil {
+		t.Fatalf("listening again: %v", err)
+	}
+	ln.Close()
+}
@@ -30,6 +30,9 @@ func execSQL(ctx context.Context, api *pilosa.API, logger logger.Logger, querySt
 		handler := sql.NewShowHandler(api)
 		results, err = handler.Handle(ctx, query)
 	case sql.SQLTypeEmpty:
+		if api.ReadOnly() {
+			return nil, errToStatusError(pilosa.ErrReadOnly)
+		}
 		handler := sql.NewDDLHandler(api)
 		results, err = handler.Handle(ctx, query)
 	default:
@@ -0,0 +1,242 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/featurebasedb/featurebase/v3/disco"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxChecksumShards is the maximum number of shards which can be checksummed
+// (or verified) in a single request. Computing a shard's checksum reads all of
+// the shard's data on every replica, so larger sets of shards must be verified
+// in batches.
+const MaxChecksumShards = 256
+
+// ShardChecksum is a checksum of the data held by one node for a shard.
+type ShardChecksum struct {
+	Shard uint64 `json:"shard"`
+	// Checksum covers the bits set in every field and view of the shard.
+	// Replicas holding the same data have the same checksum, regardless of how
+	// that data is physically stored.
+	Checksum string `json:"checksum"`
+}
+
+// ShardVerification is the result of comparing the checksums of each replica
+// of a set of shards.
+type ShardVerification struct {
+	Index  string                  `json:"index"`
+	Shards []ShardReplicaChecksums `json:"shards"`
+	// Divergent holds the shards whose replicas do not all agree.
+	Divergent []uint64 `json:"divergent"`
+	// Errors holds, by node id, any error encountered requesting checksums
+	// from that node. Shards owned by such a node are not compared against it.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ShardReplicaChecksums holds the checksum reported by each replica (by node
+// id) of a shard.
+type ShardReplicaChecksums struct {
+	Shard     uint64            `json:"shard"`
+	Checksums map[string]string `json:"checksums"`
+	Divergent bool              `json:"divergent"`
+}
+
+// ShardChecksumsNode returns the checksum of each of the given shards, as held
+// by this node.
+func (api *API) ShardChecksumsNode(ctx context.Context, indexName string, shards []uint64) ([]ShardChecksum, error) {
+	if err := api.validate(apiShardChecksums); err != nil {
+		return nil, errors.Wrap(err, "validating api method")
+	}
+	if len(shards) > MaxChecksumShards {
+		return nil, NewBadRequestError(errors.Errorf("cannot checksum more than %d shards per request", MaxChecksumShards))
+	}
+
+	index := api.holder.Index(indexName)
+	if index == nil {
+		return nil, newNotFoundError(ErrIndexNotFound, indexName)
+	}
+
+	out := make([]ShardChecksum, 0, len(shards))
+	for _, shard := range shards {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sum, err := api.shardChecksum(index, shard)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checksumming shard %d", shard)
+		}
+		out = append(out, ShardChecksum{Shard: shard, Checksum: sum})
+	}
+	return out, nil
+}
+
+// shardChecksum computes the checksum of a single shard on this node.
+func (api *API) shardChecksum(index *Index, shard uint64) (string, error) {
+	tx := api.holder.txf.NewTx(Txo{Write: !writable, Index: index, Shard: shard})
+	defer tx.Rollback()
+
+	h := sha256.New()
+	for _, field := range index.Fields() {
+		views := field.views()
+		sort.Slice(views, func(i, j int) bool { return views[i].name < views[j].name })
+		for _, view := range views {
+			if err := checksumFragment(tx, h, index.Name(), field.Name(), view.name, shard); err != nil {
+				return "", errors.Wrapf(err, "field %s, view %s", field.Name(), view.name)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumFragment writes the bits of a fragment to h. Containers are written
+// as bitmaps so that the result doesn't depend on the container encoding, and
+// empty fragments write nothing, so that a fragment which has been created
+// but holds no bits matches one which doesn't exist.
+func checksumFragment(tx Tx, h hash.Hash, index, field, view string, shard uint64) error {
+	citer, _, err := tx.ContainerIterator(index, field, view, shard, 0)
+	if err != nil {
+		return err
+	}
+	defer citer.Close()
+
+	var named bool
+	var buf [8]byte
+	words := make([]uint64, 1024)
+	for citer.Next() {
+		key, c := citer.Value()
+		if c.N() == 0 {
+			continue
+		}
+		if !named {
+			fmt.Fprintf(h, "%s\x00%s\x00", field, view)
+			named = true
+		}
+		binary.LittleEndian.PutUint64(buf[:], key)
+		h.Write(buf[:])
+		for _, w := range c.AsBitmap(words) {
+			binary.LittleEndian.PutUint64(buf[:], w)
+			h.Write(buf[:])
+		}
+	}
+	return nil
+}
+
+// VerifyShards requests the checksum of each of the given shards from every
+// node which holds a replica of it, and reports which shards have replicas
+// that disagree. If shards is empty, all available shards of the index are
+// verified. Only checksums are transferred between nodes.
+func (api *API) VerifyShards(ctx context.Context, indexName string, shards []uint64) (*ShardVerification, error) {
+	if err := api.validate(apiShardChecksums); err != nil {
+		return nil, errors.Wrap(err, "validating api method")
+	}
+
+	index, err := api.Index(ctx, indexName)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) == 0 {
+		shards = index.AvailableShards(includeRemote).Slice()
+	}
+	if len(shards) > MaxChecksumShards {
+		return nil, NewBadRequestError(errors.Errorf("cannot verify more than %d shards per request", MaxChecksumShards))
+	}
+
+	// Group the shards by the nodes which own them, so each node is asked
+	// for all of its checksums in one request.
+	snap := api.cluster.NewSnapshot()
+	nodes := make(map[string]*disco.Node)
+	nodeShards := make(map[string][]uint64)
+	for _, shard := range shards {
+		for _, node := range snap.ShardNodes(indexName, shard) {
+			nodes[node.ID] = node
+			nodeShards[node.ID] = append(nodeShards[node.ID], shard)
+		}
+	}
+
+	type nodeResult struct {
+		id        string
+		checksums []ShardChecksum
+		err       error
+	}
+	results := make([]nodeResult, 0, len(nodes))
+	for id := range nodes {
+		results = append(results, nodeResult{id: id})
+	}
+
+	myID := api.NodeID()
+	eg, _ := errgroup.WithContext(ctx)
+	for i := range results {
+		res := &results[i]
+		node := nodes[res.id]
+		eg.Go(func() error {
+			if node.ID == myID {
+				res.checksums, res.err = api.ShardChecksumsNode(ctx, indexName, nodeShards[node.ID])
+			} else {
+				res.checksums, res.err = api.server.defaultClient.ShardChecksums(ctx, &node.URI, indexName, nodeShards[node.ID])
+			}
+			// Errors are reported per node rather than failing the whole
+			// verification.
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	out := &ShardVerification{
+		Index:     indexName,
+		Shards:    make([]ShardReplicaChecksums, 0, len(shards)),
+		Divergent: make([]uint64, 0),
+	}
+	byShard := make(map[uint64]map[string]string, len(shards))
+	for _, res := range results {
+		if res.err != nil {
+			if out.Errors == nil {
+				out.Errors = make(map[string]string)
+			}
+			out.Errors[res.id] = res.err.Error()
+			continue
+		}
+		for _, cs := range res.checksums {
+			if byShard[cs.Shard] == nil {
+				byShard[cs.Shard] = make(map[string]string)
+			}
+			byShard[cs.Shard][res.id] = cs.Checksum
+		}
+	}
+
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	for _, shard := range shards {
+		checksums := byShard[shard]
+		if checksums == nil {
+			checksums = make(map[string]string)
+		}
+		divergent := false
+		var first string
+		for _, sum := range checksums {
+			if first == "" {
+				first = sum
+			} else if sum != first {
+				divergent = true
+			}
+		}
+		out.Shards = append(out.Shards, ShardReplicaChecksums{
+			Shard:     shard,
+			Checksums: checksums,
+			Divergent: divergent,
+		})
+		if divergent {
+			out.Divergent = append(out.Divergent, shard)
+		}
+	}
+
+	return out, nil
+}
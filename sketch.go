@@ -0,0 +1,554 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+// This file contains the mergeable sketches behind the Sketch() call and the
+// approximate aggregate functions of SQL (APPROX_COUNT_DISTINCT,
+// APPROX_PERCENTILE and APPROX_TOP_K). Each sketch has a bounded size
+// regardless of the number of values added to it, and two sketches of the
+// same kind can be merged into one which summarizes the union of their
+// inputs. So a Sketch() call builds a partial sketch per shard, on the node
+// which holds the shard, and only the sketches are sent to be merged.
+
+// Sketch types, as given by the type argument of a Sketch() call.
+const (
+	SketchTypeHLL     = "hll"
+	SketchTypeTDigest = "tdigest"
+	SketchTypeTopK    = "topk"
+)
+
+// Sketch is the result of a Sketch() call: a summary of the values of Field
+// in the columns of the call's filter. Exactly one of HLL, TDigest and TopK is
+// set, depending on the type of the sketch.
+//
+// The values summarized are those FeatureBase stores: row IDs for set and
+// mutex fields, and integers for int, decimal and timestamp fields, except
+// that a t-digest holds the value of a decimal field rather than its integer
+// representation. Once a result has been translated, the values in a top-k
+// sketch of a keyed field are its keys.
+type Sketch struct {
+	Field   string
+	HLL     *HLLSketch
+	TDigest *TDigest
+	TopK    *TopKSketch
+}
+
+// NewSketchFromCall returns an empty sketch of the type given by the type
+// argument of the Sketch() call c. A top-k sketch tracks the number of values
+// given by the call's capacity argument.
+func NewSketchFromCall(c *pql.Call) (*Sketch, error) {
+	field, err := c.FirstStringArg("field", "_field")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sketch(): field required")
+	}
+	typ, ok, err := c.StringArg("type")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sketch(): type")
+	} else if !ok {
+		return nil, errors.New("Sketch(): type required")
+	}
+
+	s := &Sketch{Field: field}
+	switch typ {
+	case SketchTypeHLL:
+		s.HLL = NewHLLSketch()
+	case SketchTypeTDigest:
+		s.TDigest = NewTDigest(TDigestCompression)
+	case SketchTypeTopK:
+		capacity, ok, err := c.IntArg("capacity")
+		if err != nil {
+			return nil, errors.Wrap(err, "Sketch(): capacity")
+		} else if !ok || capacity <= 0 {
+			return nil, errors.New("Sketch(): a topk sketch requires a positive capacity")
+		}
+		s.TopK = NewTopKSketch(int(capacity))
+	default:
+		return nil, errors.Errorf("Sketch(): unknown type %q, expected %q, %q or %q", typ, SketchTypeHLL, SketchTypeTDigest, SketchTypeTopK)
+	}
+	return s, nil
+}
+
+// Type returns the type of the sketch.
+func (s *Sketch) Type() string {
+	switch {
+	case s.HLL != nil:
+		return SketchTypeHLL
+	case s.TDigest != nil:
+		return SketchTypeTDigest
+	case s.TopK != nil:
+		return SketchTypeTopK
+	}
+	return ""
+}
+
+// Merge folds other into s. It returns an error if other isn't a sketch of
+// the same type.
+func (s *Sketch) Merge(other *Sketch) error {
+	switch {
+	case s.HLL != nil && other.HLL != nil:
+		s.HLL.Merge(other.HLL)
+	case s.TDigest != nil && other.TDigest != nil:
+		s.TDigest.Merge(other.TDigest)
+	case s.TopK != nil && other.TopK != nil:
+		s.TopK.Merge(other.TopK)
+	default:
+		return errors.Errorf("can't merge a %q sketch into a %q sketch", other.Type(), s.Type())
+	}
+	return nil
+}
+
+// MarshalJSON encodes a sketch as what it estimates: a distinct count, the
+// distribution of its values, or its most frequent values.
+func (s *Sketch) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"field": s.Field,
+		"type":  s.Type(),
+	}
+	switch {
+	case s.HLL != nil:
+		out["estimate"] = s.HLL.Estimate()
+	case s.TDigest != nil:
+		out["count"] = s.TDigest.Count()
+		if s.TDigest.Count() > 0 {
+			out["min"] = s.TDigest.Min()
+			out["max"] = s.TDigest.Max()
+			out["median"] = s.TDigest.Quantile(0.5)
+		}
+	case s.TopK != nil:
+		items := make([]map[string]interface{}, 0, s.TopK.Capacity())
+		for _, item := range s.TopK.Items() {
+			items = append(items, map[string]interface{}{"value": item.Value, "count": item.Count})
+		}
+		out["items"] = items
+	}
+	return json.Marshal(out)
+}
+
+// hllPrecision is the number of bits of the hash used to pick an HLL register.
+const hllPrecision = 14
+
+// HLLSketch is a HyperLogLog distinct count sketch with 2^14 registers. The
+// relative standard error of its estimate is 1.04/sqrt(m), which is
+// approximately 0.81% for m = 16384; roughly 95% of estimates are within 1.6%
+// of the true distinct count. Small cardinalities (up to about 40,000) use
+// linear counting, which is close to exact.
+type HLLSketch struct {
+	registers []uint8
+}
+
+// NewHLLSketch returns an empty HLLSketch.
+func NewHLLSketch() *HLLSketch {
+	return &HLLSketch{
+		registers: make([]uint8, 1<<hllPrecision),
+	}
+}
+
+// NewHLLSketchFromRegisters returns an HLLSketch with the given registers, as
+// returned by Registers. It returns an error if there are not exactly 2^14
+// registers, since such a sketch could not be merged with another.
+func NewHLLSketchFromRegisters(registers []uint8) (*HLLSketch, error) {
+	if len(registers) != 1<<hllPrecision {
+		return nil, errors.Errorf("hll sketch: expected %d registers, got %d", 1<<hllPrecision, len(registers))
+	}
+	return &HLLSketch{
+		registers: registers,
+	}, nil
+}
+
+// HLLPrecision returns the number of bits of a value's hash used to pick an
+// HLLSketch register.
+func HLLPrecision() int {
+	return hllPrecision
+}
+
+// HLLRelativeError returns the relative standard error of an HLLSketch's
+// estimate.
+func HLLRelativeError() float64 {
+	return 1.04 / math.Sqrt(float64(int(1)<<hllPrecision))
+}
+
+// Registers returns the registers of the sketch.
+func (s *HLLSketch) Registers() []uint8 {
+	return s.registers
+}
+
+// AddHash adds a value (represented by its 64 bit hash) to the sketch.
+func (s *HLLSketch) AddHash(h uint64) {
+	idx := h >> (64 - hllPrecision)
+	rest := h<<hllPrecision | 1<<(hllPrecision-1)
+	rank := uint8(bits.LeadingZeros64(rest)) + 1
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Merge folds other into s.
+func (s *HLLSketch) Merge(other *HLLSketch) {
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+}
+
+// Estimate returns the estimated number of distinct values added to the
+// sketch.
+func (s *HLLSketch) Estimate() int64 {
+	m := float64(len(s.registers))
+	var sum float64
+	var zeros int
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	est := alpha * m * m / sum
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(est))
+}
+
+// HashSketchValue returns a well mixed 64 bit hash of v, for adding to an
+// HLLSketch. v is an int64, a string, or a value with a String method (such
+// as a pql.Decimal or a time.Time).
+func HashSketchValue(v interface{}) uint64 {
+	h := fnv.New64a()
+	switch val := v.(type) {
+	case int64:
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(val))
+		h.Write(buf[:])
+	case string:
+		h.Write([]byte(val))
+	case interface{ String() string }:
+		h.Write([]byte(val.String()))
+	}
+	return mix64(h.Sum64())
+}
+
+// mix64 is the splitmix64 finalizer; fnv on its own does not distribute short
+// inputs well enough across the high bits used to select HLL registers.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// TDigestCompression is the compression parameter (delta) of the t-digests
+// built by Sketch() and APPROX_PERCENTILE. A digest holds at most about
+// 2*delta centroids.
+const TDigestCompression = 100
+
+// Centroid is a cluster of values in a TDigest.
+type Centroid struct {
+	Mean  float64
+	Count float64
+}
+
+// TDigest is a merging t-digest quantile sketch. Centroids are sized using the
+// k1 (arcsine) scale function, so they are smallest near the tails; the
+// absolute rank error of a quantile estimate q is roughly proportional to
+// q(1-q)/delta. For delta = 100 the rank error is typically well under 1% for
+// the median and much smaller for extreme quantiles such as p99. The minimum
+// and maximum values are tracked exactly.
+type TDigest struct {
+	compression float64
+	centroids   []Centroid
+	buffer      []Centroid
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest returns an empty TDigest with the given compression.
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// NewTDigestFromCentroids returns a TDigest with the given compression,
+// centroids and bounds, as returned by the digest's methods.
+func NewTDigestFromCentroids(compression float64, centroids []Centroid, min, max float64) *TDigest {
+	t := NewTDigest(compression)
+	t.centroids = centroids
+	for _, c := range centroids {
+		t.count += c.Count
+	}
+	if len(centroids) > 0 {
+		t.min, t.max = min, max
+	}
+	return t
+}
+
+// Add adds a single value to the digest.
+func (t *TDigest) Add(v float64) {
+	t.addCentroid(Centroid{Mean: v, Count: 1})
+}
+
+func (t *TDigest) addCentroid(c Centroid) {
+	t.buffer = append(t.buffer, c)
+	t.count += c.Count
+	if c.Mean < t.min {
+		t.min = c.Mean
+	}
+	if c.Mean > t.max {
+		t.max = c.Mean
+	}
+	if len(t.buffer) >= int(t.compression)*5 {
+		t.compress()
+	}
+}
+
+// Merge folds other into t.
+func (t *TDigest) Merge(other *TDigest) {
+	other.compress()
+	for _, c := range other.centroids {
+		t.addCentroid(c)
+	}
+	// the centroid means are within, but may not reach, other's bounds
+	t.min = math.Min(t.min, other.min)
+	t.max = math.Max(t.max, other.max)
+}
+
+// Compression returns the compression parameter of the digest.
+func (t *TDigest) Compression() float64 {
+	return t.compression
+}
+
+// Count returns the number of values added to the digest.
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+// Min returns the smallest value added to the digest.
+func (t *TDigest) Min() float64 {
+	return t.min
+}
+
+// Max returns the largest value added to the digest.
+func (t *TDigest) Max() float64 {
+	return t.max
+}
+
+// Centroids returns the centroids of the digest, ordered by mean.
+func (t *TDigest) Centroids() []Centroid {
+	t.compress()
+	return t.centroids
+}
+
+// k1 is the t-digest scale function mapping a quantile to a centroid index.
+func (t *TDigest) k1(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// compress merges the buffered centroids into the digest.
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buffer...)
+	t.buffer = t.buffer[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].Mean < all[j].Mean })
+
+	merged := make([]Centroid, 0, len(all))
+	cur := all[0]
+	var sofar float64
+	kLow := t.k1(0)
+	for _, c := range all[1:] {
+		q := (sofar + cur.Count + c.Count) / t.count
+		if t.k1(q)-kLow <= 1 {
+			// fold c into the current centroid
+			total := cur.Count + c.Count
+			cur.Mean += (c.Mean - cur.Mean) * c.Count / total
+			cur.Count = total
+			continue
+		}
+		sofar += cur.Count
+		merged = append(merged, cur)
+		kLow = t.k1(sofar / t.count)
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+}
+
+// Quantile returns the estimated value at quantile q (0 <= q <= 1).
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+	if len(t.centroids) == 1 {
+		return t.centroids[0].Mean
+	}
+
+	target := q * t.count
+
+	// before the center of the first centroid, interpolate from the minimum
+	first := t.centroids[0]
+	if target < first.Count/2 {
+		return t.min + (first.Mean-t.min)*target/(first.Count/2)
+	}
+
+	var cum float64
+	for i := 0; i < len(t.centroids)-1; i++ {
+		c, next := t.centroids[i], t.centroids[i+1]
+		left := cum + c.Count/2
+		right := cum + c.Count + next.Count/2
+		if target < right {
+			return c.Mean + (next.Mean-c.Mean)*(target-left)/(right-left)
+		}
+		cum += c.Count
+	}
+
+	// after the center of the last centroid, interpolate to the maximum
+	last := t.centroids[len(t.centroids)-1]
+	left := t.count - last.Count/2
+	return last.Mean + (t.max-last.Mean)*(target-left)/(last.Count/2)
+}
+
+// TopKItem is a value tracked by a TopKSketch.
+type TopKItem struct {
+	Value interface{}
+	Count int64
+}
+
+// TopKSketch is a space-saving heavy hitters sketch tracking at most capacity
+// distinct values. Each reported count is within n/capacity of the true count,
+// where n is the total number of values added to (or merged into) the sketch,
+// and every value occurring more than n/capacity times is guaranteed to be
+// tracked.
+type TopKSketch struct {
+	capacity int
+	n        int64
+	counts   map[interface{}]int64
+}
+
+// NewTopKSketch returns an empty TopKSketch tracking at most capacity values.
+func NewTopKSketch(capacity int) *TopKSketch {
+	return &TopKSketch{
+		capacity: capacity,
+		counts:   make(map[interface{}]int64),
+	}
+}
+
+// NewTopKSketchFromItems returns a TopKSketch with the given capacity,
+// number of values and tracked items, as returned by the sketch's methods.
+func NewTopKSketchFromItems(capacity int, n int64, items []TopKItem) *TopKSketch {
+	s := NewTopKSketch(capacity)
+	s.n = n
+	for _, item := range items {
+		s.counts[item.Value] += item.Count
+	}
+	return s
+}
+
+// Capacity returns the number of values the sketch tracks.
+func (s *TopKSketch) Capacity() int {
+	return s.capacity
+}
+
+// N returns the number of values added to (or merged into) the sketch.
+func (s *TopKSketch) N() int64 {
+	return s.n
+}
+
+// Add adds one occurrence of v, which must be comparable, to the sketch.
+func (s *TopKSketch) Add(v interface{}) {
+	s.n++
+	if _, ok := s.counts[v]; ok || len(s.counts) < s.capacity {
+		s.counts[v]++
+		return
+	}
+
+	// replace the value with the smallest count, inheriting its count
+	var minValue interface{}
+	var minCount int64 = math.MaxInt64
+	for value, count := range s.counts {
+		if count < minCount {
+			minValue, minCount = value, count
+		}
+	}
+	delete(s.counts, minValue)
+	s.counts[v] = minCount + 1
+}
+
+// Merge folds other into s. Counts of values tracked by both sketches are
+// summed, and the sketch is then trimmed back to capacity.
+func (s *TopKSketch) Merge(other *TopKSketch) {
+	s.n += other.n
+	for value, count := range other.counts {
+		s.counts[value] += count
+	}
+	if len(s.counts) <= s.capacity {
+		return
+	}
+	for _, item := range s.Items()[s.capacity:] {
+		delete(s.counts, item.Value)
+	}
+}
+
+// Items returns the tracked values ordered by descending count.
+func (s *TopKSketch) Items() []TopKItem {
+	items := make([]TopKItem, 0, len(s.counts))
+	for value, count := range s.counts {
+		items = append(items, TopKItem{Value: value, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return lessSketchValue(items[i].Value, items[j].Value)
+	})
+	return items
+}
+
+// Top returns the k values with the highest estimated counts.
+func (s *TopKSketch) Top(k int) []TopKItem {
+	items := s.Items()
+	if len(items) > k {
+		items = items[:k]
+	}
+	return items
+}
+
+// lessSketchValue orders the values tracked by a TopKSketch so that ties are
+// broken deterministically.
+func lessSketchValue(a, b interface{}) bool {
+	switch av := a.(type) {
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return av < bv
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return av < bv
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSketches(t *testing.T) {
+	t.Run("HLL", func(t *testing.T) {
+		// build two partial sketches with overlapping values and merge them
+		a, b := NewHLLSketch(), NewHLLSketch()
+		for i := 0; i < 60000; i++ {
+			a.AddHash(HashSketchValue(int64(i)))
+		}
+		for i := 40000; i < 100000; i++ {
+			b.AddHash(HashSketchValue(fmt.Sprintf("%d", i)))
+			b.AddHash(HashSketchValue(int64(i)))
+		}
+		a.Merge(b)
+
+		// 100000 distinct ints plus 60000 distinct strings
+		exp := 160000.0
+		got := float64(a.Estimate())
+		if relErr := math.Abs(got-exp) / exp; relErr > 4*HLLRelativeError() {
+			t.Fatalf("estimate %v outside error bound of %v (relative error %v)", got, exp, relErr)
+		}
+	})
+
+	t.Run("TDigest", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		values := make([]float64, 0, 100000)
+		parts := []*TDigest{NewTDigest(TDigestCompression), NewTDigest(TDigestCompression), NewTDigest(TDigestCompression)}
+		for i := 0; i < cap(values); i++ {
+			v := rnd.NormFloat64()*100 + 1000
+			values = append(values, v)
+			parts[i%len(parts)].Add(v)
+		}
+		sort.Float64s(values)
+
+		digest := parts[0]
+		for _, p := range parts[1:] {
+			digest.Merge(p)
+		}
+		if digest.Count() != float64(len(values)) {
+			t.Fatalf("expected count %d, got %v", len(values), digest.Count())
+		}
+		if c := len(digest.Centroids()); c > 2*TDigestCompression {
+			t.Fatalf("expected at most %d centroids, got %d", 2*TDigestCompression, c)
+		}
+
+		for _, q := range []float64{0.01, 0.25, 0.5, 0.75, 0.99} {
+			est := digest.Quantile(q)
+			// convert the estimate back to a rank to check the rank error
+			rank := float64(sort.SearchFloat64s(values, est)) / float64(len(values))
+			if math.Abs(rank-q) > 0.01 {
+				t.Errorf("quantile %v: estimate %v has rank %v", q, est, rank)
+			}
+		}
+		if digest.Quantile(0) != values[0] || digest.Quantile(1) != values[len(values)-1] {
+			t.Errorf("expected exact min and max")
+		}
+	})
+
+	t.Run("TopK", func(t *testing.T) {
+		// value i occurs 1000/(i+1) times, spread across two sketches
+		a, b := NewTopKSketch(100), NewTopKSketch(100)
+		for i := 0; i < 500; i++ {
+			for j := 0; j < 1000/(i+1); j++ {
+				if j%2 == 0 {
+					a.Add(int64(i))
+				} else {
+					b.Add(int64(i))
+				}
+			}
+		}
+		a.Merge(b)
+
+		top := a.Top(5)
+		if len(top) != 5 {
+			t.Fatalf("expected 5 values, got %d", len(top))
+		}
+		for i, item := range top {
+			if item.Value != int64(i) {
+				t.Errorf("expected value %d at position %d, got %v", i, i, item.Value)
+			}
+			exp := int64(1000 / (i + 1))
+			if diff := item.Count - exp; diff < -a.N()/int64(a.Capacity()) || diff > a.N()/int64(a.Capacity()) {
+				t.Errorf("value %d: count %d outside error bound of %d", i, item.Count, exp)
+			}
+		}
+	})
+
+	t.Run("MergeMismatch", func(t *testing.T) {
+		hll := &Sketch{Field: "f", HLL: NewHLLSketch()}
+		if err := hll.Merge(&Sketch{Field: "f", TDigest: NewTDigest(TDigestCompression)}); err == nil {
+			t.Fatal("expected an error merging a tdigest sketch into an hll sketch")
+		}
+		if err := hll.Merge(&Sketch{Field: "f", HLL: NewHLLSketch()}); err != nil {
+			t.Fatalf("merging hll sketches: %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// maxSnapshotBurst is the most shard data a background snapshot reads at once
+// when its rate is limited.
+const maxSnapshotBurst = 1 << 20
+
+// SnapshotBudget limits the resources used by background shard snapshots
+// (those requested with dax.SnapshotPriorityBackground), so that scheduled
+// snapshots can run alongside query traffic. Urgent snapshots are taken as
+// fast as possible, and don't count against the budget.
+//
+// A shard's write transaction is held while it's snapshotted, so a tighter
+// budget delays writes to the shard being snapshotted for longer, but
+// leaves more disk bandwidth for everything else.
+type SnapshotBudget struct {
+	// Concurrency is the most background snapshots taken at once; others
+	// wait for one of them to finish. 0 means no limit.
+	Concurrency int `toml:"concurrency"`
+
+	// BytesPerSecond limits the rate at which background snapshots,
+	// together, read shard data. 0 means no limit.
+	BytesPerSecond int64 `toml:"bytes-per-second"`
+}
+
+// snapshotBudget enforces a SnapshotBudget.
+type snapshotBudget struct {
+	slots   chan struct{} // nil if Concurrency is unlimited
+	limiter *rate.Limiter // nil if BytesPerSecond is unlimited
+}
+
+func newSnapshotBudget(b SnapshotBudget) (*snapshotBudget, error) {
+	if b.Concurrency < 0 {
+		return nil, errors.Errorf("snapshot concurrency can't be negative: %d", b.Concurrency)
+	} else if b.BytesPerSecond < 0 {
+		return nil, errors.Errorf("snapshot bytes per second can't be negative: %d", b.BytesPerSecond)
+	}
+
+	sb := &snapshotBudget{}
+	if b.Concurrency > 0 {
+		sb.slots = make(chan struct{}, b.Concurrency)
+	}
+	if b.BytesPerSecond > 0 {
+		burst := b.BytesPerSecond
+		if burst > maxSnapshotBurst {
+			burst = maxSnapshotBurst
+		}
+		sb.limiter = rate.NewLimiter(rate.Limit(b.BytesPerSecond), int(burst))
+	}
+	return sb, nil
+}
+
+// begin waits until a snapshot with priority p may start, and returns a func
+// to call once it's done.
+func (b *snapshotBudget) begin(ctx context.Context, p dax.SnapshotPriority) (func(), error) {
+	p = normalizeSnapshotPriority(p)
+	background := p == dax.SnapshotPriorityBackground && b.slots != nil
+	if background {
+		start := time.Now()
+		select {
+		case b.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "waiting for a snapshot slot")
+		}
+		CounterSnapshotThrottledSeconds.Add(time.Since(start).Seconds())
+	}
+
+	inProgress := GaugeSnapshotsInProgress.WithLabelValues(string(p))
+	inProgress.Inc()
+	start := time.Now()
+	return func() {
+		inProgress.Dec()
+		HistogramSnapshotDurationSeconds.WithLabelValues(string(p)).Observe(time.Since(start).Seconds())
+		if background {
+			<-b.slots
+		}
+	}, nil
+}
+
+// reader wraps rc, which holds the shard data for a snapshot with priority p,
+// so that what's read from it is counted and, for background snapshots,
+// limited to BytesPerSecond.
+func (b *snapshotBudget) reader(ctx context.Context, rc io.ReadCloser, p dax.SnapshotPriority) io.ReadCloser {
+	p = normalizeSnapshotPriority(p)
+	br := &budgetedReader{
+		ReadCloser: rc,
+		ctx:        ctx,
+		bytes:      CounterSnapshotBytes.WithLabelValues(string(p)),
+	}
+	if p == dax.SnapshotPriorityBackground {
+		br.limiter = b.limiter
+	}
+	return br
+}
+
+func normalizeSnapshotPriority(p dax.SnapshotPriority) dax.SnapshotPriority {
+	if p == "" {
+		return dax.SnapshotPriorityUrgent
+	}
+	return p
+}
+
+type budgetedReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+	bytes   prometheus.Counter
+}
+
+func (r *budgetedReader) Read(p []byte) (int, error) {
+	if r.limiter != nil && len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n <= 0 {
+		return n, err
+	}
+	r.bytes.Add(float64(n))
+	if r.limiter != nil {
+		start := time.Now()
+		if werr := r.limiter.WaitN(r.ctx, n); werr != nil && err == nil {
+			err = errors.Wrap(werr, "waiting for snapshot bandwidth")
+		}
+		CounterSnapshotThrottledSeconds.Add(time.Since(start).Seconds())
+	}
+	return n, err
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+func TestSnapshotBudget(t *testing.T) {
+	if _, err := newSnapshotBudget(SnapshotBudget{Concurrency: -1}); err == nil {
+		t.Fatal("expected an error for negative concurrency")
+	}
+
+	t.Run("Concurrency", func(t *testing.T) {
+		b, err := newSnapshotBudget(SnapshotBudget{Concurrency: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		done, err := b.begin(context.Background(), dax.SnapshotPriorityBackground)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A second background snapshot waits for the first.
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := b.begin(ctx, dax.SnapshotPriorityBackground); err == nil {
+			t.Fatal("expected a second background snapshot to wait")
+		}
+
+		// Urgent snapshots don't.
+		for _, p := range []dax.SnapshotPriority{dax.SnapshotPriorityUrgent, ""} {
+			urgentDone, err := b.begin(context.Background(), p)
+			if err != nil {
+				t.Fatalf("priority %q: %v", p, err)
+			}
+			urgentDone()
+		}
+
+		done()
+		done, err = b.begin(context.Background(), dax.SnapshotPriorityBackground)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done()
+	})
+
+	t.Run("BytesPerSecond", func(t *testing.T) {
+		b, err := newSnapshotBudget(SnapshotBudget{BytesPerSecond: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := bytes.Repeat([]byte{1}, 1200)
+
+		// The limiter starts with a full burst of 1000 bytes, so reading
+		// 1200 takes at least 200ms.
+		start := time.Now()
+		r := b.reader(context.Background(), io.NopCloser(bytes.NewReader(data)), dax.SnapshotPriorityBackground)
+		if got, err := io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(got, data) {
+			t.Fatalf("read %d bytes, expected %d", len(got), len(data))
+		}
+		if d := time.Since(start); d < 150*time.Millisecond {
+			t.Fatalf("expected background read to be throttled, took %s", d)
+		}
+
+		start = time.Now()
+		r = b.reader(context.Background(), io.NopCloser(bytes.NewReader(data)), dax.SnapshotPriorityUrgent)
+		if _, err := io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(start); d > 100*time.Millisecond {
+			t.Fatalf("expected urgent read not to be throttled, took %s", d)
+		}
+	})
+}
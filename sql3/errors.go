@@ -38,6 +38,7 @@ const (
 	ErrIntOrDecimalExpressionExpected                    errors.Code = "ErrIntOrDecimalExpressionExpected"
 	ErrIntOrDecimalOrTimestampExpressionExpected         errors.Code = "ErrIntOrDecimalOrTimestampExpressionExpected"
 	ErrIntOrDecimalOrTimestampOrStringExpressionExpected errors.Code = "ErrIntOrDecimalOrTimestampOrStringExpressionExpected"
+	ErrIntOrStringExpressionExpected                     errors.Code = "ErrIntOrStringExpressionExpected"
 	ErrStringExpressionExpected                          errors.Code = "ErrStringExpressionExpected"
 	ErrSetExpressionExpected                             errors.Code = "ErrSetExpressionExpected"
 	ErrTimeQuantumExpressionExpected                     errors.Code = "ErrTimeQuantumExpressionExpected"
@@ -95,6 +96,11 @@ const (
 	ErrViewExists   errors.Code = "ErrViewExists"
 	ErrViewNotFound errors.Code = "ErrViewNotFound"
 
+	ErrViewNotMaterialized          errors.Code = "ErrViewNotMaterialized"
+	ErrMaterializedViewColumnName   errors.Code = "ErrMaterializedViewColumnName"
+	ErrMaterializedViewColumnType   errors.Code = "ErrMaterializedViewColumnType"
+	ErrMaterializedViewNotRefreshed errors.Code = "ErrMaterializedViewNotRefreshed"
+
 	ErrModelExists   errors.Code = "ErrModelExists"
 	ErrModelNotFound errors.Code = "ErrModelNotFound"
 
@@ -117,6 +123,11 @@ const (
 
 	ErrInsertValueOutOfRange            errors.Code = "ErrInsertValueOutOfRange"
 	ErrUnexpectedTimeQuantumTupleLength errors.Code = "ErrUnexpectedTimeQuantumTupleLength"
+	ErrInsertNullValue                  errors.Code = "ErrInsertNullValue"
+	ErrUpsertNullValue                  errors.Code = "ErrUpsertNullValue"
+	ErrConditionalWriteMultipleRows     errors.Code = "ErrConditionalWriteMultipleRows"
+	ErrInvalidWriteCondition            errors.Code = "ErrInvalidWriteCondition"
+	ErrWriteConflict                    errors.Code = "ErrWriteConflict"
 
 	// bulk insert errors
 
@@ -145,10 +156,14 @@ const (
 	ErrInvalidDatetimePart                 errors.Code = "ErrInvalidDatetimePart"
 	ErrOutputValueOutOfRange               errors.Code = "ErrOutputValueOutOfRange"
 	ErrDivideByZero                        errors.Code = "ErrDivideByZero"
+	ErrIntegerOverflow                     errors.Code = "ErrIntegerOverflow"
 
 	// remote execution
 	ErrRemoteUnauthorized errors.Code = "ErrRemoteUnauthorized"
 
+	// operator limits
+	ErrDistinctRowLimitExceeded errors.Code = "ErrDistinctRowLimitExceeded"
+
 	// query hints
 	ErrUnknownQueryHint               errors.Code = "ErrInvalidQueryHint"
 	ErrInvalidQueryHintParameterCount errors.Code = "ErrInvalidQueryHintParameterCount"
@@ -468,6 +483,13 @@ func NewErrIntOrDecimalOrTimestampOrStringExpressionExpected(line, col int) erro
 	)
 }
 
+func NewErrIntOrStringExpressionExpected(line, col int) error {
+	return errors.New(
+		ErrIntOrStringExpressionExpected,
+		fmt.Sprintf("[%d:%d] integer or string expression expected", line, col),
+	)
+}
+
 func NewErrStringExpressionExpected(line, col int) error {
 	return errors.New(
 		ErrStringExpressionExpected,
@@ -668,6 +690,34 @@ func NewErrViewExists(line, col int, viewName string) error {
 	)
 }
 
+func NewErrViewNotMaterialized(line, col int, viewName string) error {
+	return errors.New(
+		ErrViewNotMaterialized,
+		fmt.Sprintf("[%d:%d] view '%s' is not materialized", line, col, viewName),
+	)
+}
+
+func NewErrMaterializedViewColumnName(line, col int, viewName string, column int) error {
+	return errors.New(
+		ErrMaterializedViewColumnName,
+		fmt.Sprintf("[%d:%d] column %d of materialized view '%s' must be named", line, col, column, viewName),
+	)
+}
+
+func NewErrMaterializedViewColumnType(line, col int, viewName, columnName, typeName string) error {
+	return errors.New(
+		ErrMaterializedViewColumnType,
+		fmt.Sprintf("[%d:%d] column '%s' of materialized view '%s' has type '%s', which can't be materialized", line, col, columnName, viewName, typeName),
+	)
+}
+
+func NewErrMaterializedViewNotRefreshed(line, col int, viewName string) error {
+	return errors.New(
+		ErrMaterializedViewNotRefreshed,
+		fmt.Sprintf("[%d:%d] materialized view '%s' has no result yet", line, col, viewName),
+	)
+}
+
 func NewErrModelNotFound(line, col int, viewName string) error {
 	return errors.New(
 		ErrModelNotFound,
@@ -772,6 +822,41 @@ func NewErrUnexpectedTimeQuantumTupleLength(line, col int, columnName string, ro
 	)
 }
 
+func NewErrInsertNullValue(line, col int, columnName string, rowNumber int) error {
+	return errors.New(
+		ErrInsertNullValue,
+		fmt.Sprintf("[%d:%d] inserting value into column '%s', row %d, column does not allow null values", line, col, columnName, rowNumber),
+	)
+}
+
+func NewErrUpsertNullValue(line, col int, columnName string, rowNumber int) error {
+	return errors.New(
+		ErrUpsertNullValue,
+		fmt.Sprintf("[%d:%d] upserting value into column '%s', row %d, null values are not allowed in an upsert; omit the column to leave its value unchanged", line, col, columnName, rowNumber),
+	)
+}
+
+func NewErrConditionalWriteMultipleRows(line, col int) error {
+	return errors.New(
+		ErrConditionalWriteMultipleRows,
+		fmt.Sprintf("[%d:%d] a conditional write must write a single row", line, col),
+	)
+}
+
+func NewErrInvalidWriteCondition(line, col int) error {
+	return errors.New(
+		ErrInvalidWriteCondition,
+		fmt.Sprintf("[%d:%d] a write condition must be one or more terms of the form 'column = literal' or 'column IS NULL', combined with AND", line, col),
+	)
+}
+
+func NewErrWriteConflict(line, col int, tableName string, conflict string) error {
+	return errors.New(
+		ErrWriteConflict,
+		fmt.Sprintf("[%d:%d] conditional write to table '%s' not applied: %s", line, col, tableName, conflict),
+	)
+}
+
 // bulk insert
 
 func NewErrReadingDatasource(line, col int, dataSource string, errorText string) error {
@@ -912,6 +997,13 @@ func NewErrDivideByZero(line, col int) error {
 	)
 }
 
+func NewErrIntegerOverflow(line, col int, op string) error {
+	return errors.New(
+		ErrIntegerOverflow,
+		fmt.Sprintf("[%d:%d] integer overflow evaluating '%s'", line, col, op),
+	)
+}
+
 func NewErrRemoteUnauthorized(line, col int, remoteUrl string) error {
 	return errors.New(
 		ErrRemoteUnauthorized,
@@ -919,6 +1011,15 @@ func NewErrRemoteUnauthorized(line, col int, remoteUrl string) error {
 	)
 }
 
+// operator limits
+
+func NewErrDistinctRowLimitExceeded(limit int64) error {
+	return errors.New(
+		ErrDistinctRowLimitExceeded,
+		fmt.Sprintf("DISTINCT produced more than the limit of %d rows", limit),
+	)
+}
+
 // query hints
 
 func NewErrUnknownQueryHint(line, col int, hintName string) error {
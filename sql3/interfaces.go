@@ -4,6 +4,7 @@ package sql3
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
@@ -14,6 +15,17 @@ type CompilePlanner interface {
 	RehydratePlanOp(context.Context, io.Reader) (types.PlanOperator, error)
 }
 
+// ViewRefreshCheckInterval is how often the materialized views created WITH
+// REFRESH are checked for ones due to be refreshed.
+const ViewRefreshCheckInterval = 10 * time.Second
+
+// MaterializedViewRefresher is implemented by the CompilePlanners which can
+// refresh, in the background, the materialized views due to be refreshed at
+// now.
+type MaterializedViewRefresher interface {
+	RefreshMaterializedViews(ctx context.Context, now time.Time) error
+}
+
 // Ensure type implements interface.
 var _ CompilePlanner = (*NopCompilePlanner)(nil)
 
@@ -67,6 +67,7 @@ func (*InsertStatement) node()          {}
 func (*JoinClause) node()               {}
 func (*JoinOperator) node()             {}
 func (*KeyPartitionsOption) node()      {}
+func (*MaxSnapshotAgeOption) node()     {}
 func (*MinConstraint) node()            {}
 func (*MaxConstraint) node()            {}
 func (*NotNullConstraint) node()        {}
@@ -83,6 +84,7 @@ func (*PrimaryKeyConstraint) node()     {}
 func (*QualifiedRef) node()             {}
 func (*QualifiedTableName) node()       {}
 func (*Range) node()                    {}
+func (*RefreshViewStatement) node()     {}
 func (*ReturnStatement) node()          {}
 func (*ReleaseStatement) node()         {}
 func (*ResultColumn) node()             {}
@@ -140,6 +142,7 @@ func (*DropModelStatement) stmt()       {}
 func (*PredictStatement) stmt()         {}
 func (*ExplainStatement) stmt()         {}
 func (*InsertStatement) stmt()          {}
+func (*RefreshViewStatement) stmt()     {}
 func (*ReleaseStatement) stmt()         {}
 func (*ReturnStatement) stmt()          {}
 func (*RollbackStatement) stmt()        {}
@@ -196,6 +199,8 @@ func CloneStatement(stmt Statement) Statement {
 		return stmt.Clone()
 	case *BulkInsertStatement:
 		return stmt.Clone()
+	case *RefreshViewStatement:
+		return stmt.Clone()
 	case *ReleaseStatement:
 		return stmt.Clone()
 	case *RollbackStatement:
@@ -219,6 +224,21 @@ func CloneStatement(stmt Statement) Statement {
 	}
 }
 
+// IsReadOnly returns true if executing stmt can't change any data or schema.
+// Only queries (SELECT, PREDICT), SHOW statements, and EXPLAIN (which plans,
+// but doesn't execute, its target) are read-only; every other statement,
+// including INSERT, REPLACE, UPSERT, BULK INSERT, UPDATE, DELETE, COPY,
+// ANALYZE, and all CREATE, ALTER, and DROP statements, is a write.
+func IsReadOnly(stmt Statement) bool {
+	switch stmt.(type) {
+	case *SelectStatement, *PredictStatement, *ExplainStatement,
+		*ShowDatabasesStatement, *ShowTablesStatement, *ShowColumnsStatement, *ShowCreateTableStatement:
+		return true
+	default:
+		return false
+	}
+}
+
 func cloneStatements(a []Statement) []Statement {
 	if a == nil {
 		return nil
@@ -953,6 +973,8 @@ func CloneTableOption(opt TableOption) TableOption {
 	switch cons := opt.(type) {
 	case *KeyPartitionsOption:
 		return cons.Clone()
+	case *MaxSnapshotAgeOption:
+		return cons.Clone()
 	case *CommentOption:
 		return cons.Clone()
 	default:
@@ -960,8 +982,9 @@ func CloneTableOption(opt TableOption) TableOption {
 	}
 }
 
-func (*KeyPartitionsOption) option() {}
-func (*CommentOption) option()       {}
+func (*KeyPartitionsOption) option()  {}
+func (*MaxSnapshotAgeOption) option() {}
+func (*CommentOption) option()        {}
 
 type KeyPartitionsOption struct {
 	KeyPartitions Pos  // position of KEYPARTITIONS keyword
@@ -981,6 +1004,24 @@ func (o *KeyPartitionsOption) Clone() *KeyPartitionsOption {
 	return &other
 }
 
+type MaxSnapshotAgeOption struct {
+	MaxSnapshotAge Pos  // position of MAXSNAPSHOTAGE keyword
+	Expr           Expr // expression
+}
+
+func (o *MaxSnapshotAgeOption) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("MAXSNAPSHOTAGE ")
+	buf.WriteString(o.Expr.String())
+	return buf.String()
+}
+
+func (o *MaxSnapshotAgeOption) Clone() *MaxSnapshotAgeOption {
+	other := *o
+	other.Expr = CloneExpr(o.Expr)
+	return &other
+}
+
 type CommentOption struct {
 	Comment Pos  // position of COMMENT keyword
 	Expr    Expr // expression
@@ -2806,12 +2847,16 @@ func (s *DropTableStatement) String() string {
 }
 
 type CreateViewStatement struct {
-	Create      Pos    // position of CREATE keyword
-	View        Pos    // position of VIEW keyword
-	If          Pos    // position of IF keyword
-	IfNot       Pos    // position of NOT keyword after IF
-	IfNotExists Pos    // position of EXISTS keyword after IF NOT
-	Name        *Ident // view name
+	Create       Pos    // position of CREATE keyword
+	Materialized Pos    // position of MATERIALIZED keyword (optional)
+	View         Pos    // position of VIEW keyword
+	If           Pos    // position of IF keyword
+	IfNot        Pos    // position of NOT keyword after IF
+	IfNotExists  Pos    // position of EXISTS keyword after IF NOT
+	Name         *Ident // view name
+	With         Pos    // position of WITH keyword (optional)
+	Refresh      Pos    // position of REFRESH keyword after WITH
+	RefreshExpr  Expr   // refresh interval of a materialized view
 	// TODO(pok) - we'll do this later - see note in parseCompileView()
 	// Lparen      Pos              // position of column list left paren
 	// Columns     []*Ident         // column list
@@ -2827,6 +2872,7 @@ func (s *CreateViewStatement) Clone() *CreateViewStatement {
 	}
 	other := *s
 	other.Name = s.Name.Clone()
+	other.RefreshExpr = CloneExpr(s.RefreshExpr)
 	// other.Columns = cloneIdents(s.Columns)
 	other.Select = s.Select.Clone()
 	return &other
@@ -2835,11 +2881,18 @@ func (s *CreateViewStatement) Clone() *CreateViewStatement {
 // String returns the string representation of the statement.
 func (s *CreateViewStatement) String() string {
 	var buf bytes.Buffer
-	buf.WriteString("CREATE VIEW")
+	buf.WriteString("CREATE")
+	if s.Materialized.IsValid() {
+		buf.WriteString(" MATERIALIZED")
+	}
+	buf.WriteString(" VIEW")
 	if s.IfNotExists.IsValid() {
 		buf.WriteString(" IF NOT EXISTS")
 	}
 	fmt.Fprintf(&buf, " %s", s.Name.String())
+	if s.RefreshExpr != nil {
+		fmt.Fprintf(&buf, " WITH REFRESH %s", s.RefreshExpr.String())
+	}
 
 	// if len(s.Columns) > 0 {
 	// 	buf.WriteString(" (")
@@ -2931,6 +2984,28 @@ func (s *DropViewStatement) String() string {
 	return buf.String()
 }
 
+type RefreshViewStatement struct {
+	Refresh      Pos    // position of REFRESH keyword
+	Materialized Pos    // position of MATERIALIZED keyword
+	View         Pos    // position of VIEW keyword
+	Name         *Ident // view name
+}
+
+// Clone returns a deep copy of s.
+func (s *RefreshViewStatement) Clone() *RefreshViewStatement {
+	if s == nil {
+		return nil
+	}
+	other := *s
+	other.Name = s.Name.Clone()
+	return &other
+}
+
+// String returns the string representation of the statement.
+func (s *RefreshViewStatement) String() string {
+	return fmt.Sprintf("REFRESH MATERIALIZED VIEW %s", s.Name.String())
+}
+
 type DropModelStatement struct {
 	Drop     Pos    // position of DROP keyword
 	Model    Pos    // position of MODEL keyword
@@ -3420,6 +3495,7 @@ type InsertStatement struct {
 
 	Insert          Pos // position of INSERT keyword
 	Replace         Pos // position of REPLACE keyword
+	Upsert          Pos // position of UPSERT keyword
 	InsertOr        Pos // position of OR keyword after INSERT
 	InsertOrReplace Pos // position of REPLACE keyword after INSERT OR
 	//	InsertOrRollback Pos // position of ROLLBACK keyword after INSERT OR
@@ -3439,6 +3515,9 @@ type InsertStatement struct {
 	Values    Pos         // position of VALUES keyword
 	TupleList []*ExprList // multiple tuples
 
+	If     Pos  // position of IF keyword
+	IfExpr Expr // optional condition on the existing record
+
 	//	Select *SelectStatement // SELECT statement
 
 	//	Default       Pos // position of DEFAULT keyword
@@ -3458,6 +3537,7 @@ func (s *InsertStatement) Clone() *InsertStatement {
 	other.Alias = s.Alias.Clone()
 	other.Columns = cloneIdents(s.Columns)
 	other.TupleList = cloneExprLists(s.TupleList)
+	other.IfExpr = CloneExpr(s.IfExpr)
 	//other.Select = s.Select.Clone()
 	//other.UpsertClause = s.UpsertClause.Clone()
 	return &other
@@ -3472,6 +3552,8 @@ func (s *InsertStatement) String() string {
 
 	if s.Replace.IsValid() {
 		buf.WriteString("REPLACE")
+	} else if s.Upsert.IsValid() {
+		buf.WriteString("UPSERT")
 	} else {
 		buf.WriteString("INSERT")
 	}
@@ -3525,6 +3607,10 @@ func (s *InsertStatement) String() string {
 	}
 	//}
 
+	if s.IfExpr != nil {
+		fmt.Fprintf(&buf, " IF %s", s.IfExpr.String())
+	}
+
 	//if s.UpsertClause != nil {
 	//	fmt.Fprintf(&buf, " %s", s.UpsertClause.String())
 	//}
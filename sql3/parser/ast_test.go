@@ -1777,3 +1777,27 @@ func StripExprPos(root parser.Expr) parser.Expr {
 	StripPos(root)
 	return root
 }
+
+func TestIsReadOnly(t *testing.T) {
+	for sql, want := range map[string]bool{
+		"SELECT * FROM t":                       true,
+		"SHOW TABLES":                           true,
+		"SHOW COLUMNS FROM t":                   true,
+		"SHOW CREATE TABLE t":                   true,
+		"EXPLAIN SELECT * FROM t":               true,
+		"INSERT INTO t (_id, a) VALUES (1, 2)":  false,
+		"REPLACE INTO t (_id, a) VALUES (1, 2)": false,
+		"DELETE FROM t WHERE _id = 1":           false,
+		"CREATE TABLE t (_id id, a int)":        false,
+		"ALTER TABLE t ADD COLUMN b int":        false,
+		"DROP TABLE t":                          false,
+	} {
+		stmt, err := parser.NewParser(strings.NewReader(sql)).ParseStatement()
+		if err != nil {
+			t.Fatalf("parsing %q: %v", sql, err)
+		}
+		if got := parser.IsReadOnly(stmt); got != want {
+			t.Errorf("IsReadOnly(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
@@ -109,7 +109,7 @@ func (p *Parser) parseNonExplainStatement() (Statement, error) {
 		return p.parseSelectStatement(false, nil)
 	case PREDICT:
 		return p.parsePredictStatement()
-	case INSERT, REPLACE:
+	case INSERT, REPLACE, UPSERT:
 		return p.parseInsertStatement(nil)
 	case UPDATE:
 		return p.parseUpdateStatement(nil)
@@ -119,6 +119,8 @@ func (p *Parser) parseNonExplainStatement() (Statement, error) {
 		//		return p.parseWithStatement()
 	case SHOW:
 		return p.parseShowStatement()
+	case REFRESH:
+		return p.parseRefreshViewStatement()
 	default:
 		return nil, p.errorExpected(p.pos, p.tok, "statement")
 	}
@@ -340,7 +342,7 @@ func (p *Parser) parseCreateStatement() (Statement, error) {
 		return p.parseCreateDatabaseStatement(pos)
 	case TABLE:
 		return p.parseCreateTableStatement(pos)
-	case VIEW:
+	case VIEW, MATERIALIZED:
 		return p.parseCreateViewStatement(pos)
 		/*case INDEX, UNIQUE:
 		return p.parseCreateIndexStatement(pos)*/
@@ -574,6 +576,8 @@ func (p *Parser) parseTableOption() (_ TableOption, err error) {
 	switch p.peek() {
 	case KEYPARTITIONS:
 		return p.parseKeyPartitionsOption(optionPos)
+	case MAXSNAPSHOTAGE:
+		return p.parseMaxSnapshotAgeOption()
 	default:
 		assert(p.peek() == COMMENT)
 		return p.parseCommentOption()
@@ -611,6 +615,21 @@ func (p *Parser) parseKeyPartitionsOption(optionPos Pos) (_ *KeyPartitionsOption
 	return &opt, nil
 }
 
+func (p *Parser) parseMaxSnapshotAgeOption() (_ *MaxSnapshotAgeOption, err error) {
+	assert(p.peek() == MAXSNAPSHOTAGE)
+
+	var opt MaxSnapshotAgeOption
+	opt.MaxSnapshotAge, _, _ = p.scan()
+
+	if isLiteralToken(p.peek()) {
+		opt.Expr = p.mustParseLiteral()
+	} else {
+		return &opt, p.errorExpected(p.pos, p.tok, "literal")
+	}
+
+	return &opt, nil
+}
+
 func (p *Parser) parseColumnDefinitions() (_ []*ColumnDefinition, err error) {
 	var columns []*ColumnDefinition
 	for {
@@ -1238,10 +1257,16 @@ func (p *Parser) parseCopyStatement() (_ *CopyStatement, err error) {
 }
 
 func (p *Parser) parseCreateViewStatement(createPos Pos) (_ *CreateViewStatement, err error) {
-	assert(p.peek() == VIEW)
+	assert(p.peek() == VIEW || p.peek() == MATERIALIZED)
 
 	var stmt CreateViewStatement
 	stmt.Create = createPos
+	if p.peek() == MATERIALIZED {
+		stmt.Materialized, _, _ = p.scan()
+		if p.peek() != VIEW {
+			return &stmt, p.errorExpected(p.pos, p.tok, "VIEW")
+		}
+	}
 	stmt.View, _, _ = p.scan()
 
 	// Parse optional "IF NOT EXISTS".
@@ -1263,6 +1288,19 @@ func (p *Parser) parseCreateViewStatement(createPos Pos) (_ *CreateViewStatement
 		return &stmt, err
 	}
 
+	// Parse optional "WITH REFRESH interval" of a materialized view.
+	if stmt.Materialized.IsValid() && p.peek() == WITH {
+		stmt.With, _, _ = p.scan()
+		if p.peek() != REFRESH {
+			return &stmt, p.errorExpected(p.pos, p.tok, "REFRESH")
+		}
+		stmt.Refresh, _, _ = p.scan()
+		if !isLiteralToken(p.peek()) {
+			return &stmt, p.errorExpected(p.pos, p.tok, "literal")
+		}
+		stmt.RefreshExpr = p.mustParseLiteral()
+	}
+
 	// TODO(pok) - we'll do this later - right now views are implemented as
 	// jit compiled from text, which makes implementing these columns a pain
 	// when we can pre-compile a plan op subgraph and stored it, we can put this
@@ -1365,6 +1403,28 @@ func (p *Parser) parseDropViewStatement(dropPos Pos) (_ *DropViewStatement, err
 	return &stmt, nil
 }
 
+func (p *Parser) parseRefreshViewStatement() (_ *RefreshViewStatement, err error) {
+	assert(p.peek() == REFRESH)
+
+	var stmt RefreshViewStatement
+	stmt.Refresh, _, _ = p.scan()
+
+	if p.peek() != MATERIALIZED {
+		return &stmt, p.errorExpected(p.pos, p.tok, "MATERIALIZED")
+	}
+	stmt.Materialized, _, _ = p.scan()
+
+	if p.peek() != VIEW {
+		return &stmt, p.errorExpected(p.pos, p.tok, "VIEW")
+	}
+	stmt.View, _, _ = p.scan()
+
+	if stmt.Name, err = p.parseIdent("view name"); err != nil {
+		return &stmt, err
+	}
+	return &stmt, nil
+}
+
 func (p *Parser) parseDropModelStatement(dropPos Pos) (_ *DropModelStatement, err error) {
 	assert(p.peek() == MODEL)
 
@@ -1978,8 +2038,8 @@ func (p *Parser) parseBulkInsertOption(stmt *BulkInsertStatement) error {
 }
 
 func (p *Parser) parseInsertStatement(withClause *WithClause) (_ *InsertStatement, err error) {
-	if pk := p.peek(); pk != INSERT && pk != REPLACE {
-		return nil, p.errorExpected(p.pos, p.tok, "INSERT or REPLACE")
+	if pk := p.peek(); pk != INSERT && pk != REPLACE && pk != UPSERT {
+		return nil, p.errorExpected(p.pos, p.tok, "INSERT, REPLACE or UPSERT")
 	}
 
 	var stmt InsertStatement
@@ -2006,6 +2066,8 @@ func (p *Parser) parseInsertStatement(withClause *WithClause) (_ *InsertStatemen
 				return &stmt, p.errorExpected(p.pos, p.tok, "REPLACE")
 			}
 		} */
+	} else if p.peek() == UPSERT {
+		stmt.Upsert, _, _ = p.scan()
 	} else {
 		stmt.Replace, _, _ = p.scan()
 	}
@@ -2098,6 +2160,14 @@ func (p *Parser) parseInsertStatement(withClause *WithClause) (_ *InsertStatemen
 		return &stmt, p.errorExpected(p.pos, p.tok, "VALUES")
 	}
 
+	// Parse optional condition, which makes this a conditional write.
+	if p.peek() == IF {
+		stmt.If, _, _ = p.scan()
+		if stmt.IfExpr, err = p.ParseExpr(); err != nil {
+			return &stmt, err
+		}
+	}
+
 	// Parse optional upsert clause.
 	//if p.peek() == ON {
 	//	if stmt.UpsertClause, err = p.parseUpsertClause(); err != nil {
@@ -3867,7 +3937,7 @@ func isDatabaseOptionStartToken(tok Token) bool {
 // isTableOptionStartToken returns true if tok is the initial token of a table option.
 func isTableOptionStartToken(tok Token) bool {
 	switch tok {
-	case KEYPARTITIONS, COMMENT:
+	case KEYPARTITIONS, MAXSNAPSHOTAGE, COMMENT:
 		return true
 	default:
 		return false
@@ -1012,6 +1012,29 @@ func TestParser_ParseStatement(t *testing.T) {
 		AssertParseStatementError(t, `CREATE TABLE tbl (`, `1:18: expected column name, or right paren, found 'EOF'`)
 		AssertParseStatementError(t, `CREATE TABLE tbl (col1 TEXT`, `1:27: expected column name, or right paren, found 'EOF'`)
 
+		AssertParseStatement(t, `CREATE TABLE tbl (col1 TEXT) MAXSNAPSHOTAGE '24h'`, &parser.CreateTableStatement{
+			Create: pos(0),
+			Table:  pos(7),
+			Name: &parser.Ident{
+				Name:    "tbl",
+				NamePos: pos(13),
+			},
+			Lparen: pos(17),
+			Options: []parser.TableOption{
+				&parser.MaxSnapshotAgeOption{MaxSnapshotAge: pos(29), Expr: &parser.StringLit{ValuePos: pos(44), Value: "24h"}},
+			},
+			Columns: []*parser.ColumnDefinition{
+				{
+					Name: &parser.Ident{NamePos: pos(18), Name: "col1"},
+					Type: &parser.Type{
+						Name: &parser.Ident{NamePos: pos(23), Name: "TEXT"},
+					},
+				},
+			},
+			Rparen: pos(27),
+		})
+		AssertParseStatementError(t, `CREATE TABLE tbl (col1 TEXT) MAXSNAPSHOTAGE`, `1:43: expected literal, found 'EOF'`)
+
 		AssertParseStatement(t, `CREATE TABLE IF NOT EXISTS tbl (col1 TEXT)`, &parser.CreateTableStatement{
 			Create:      pos(0),
 			Table:       pos(7),
@@ -1764,6 +1787,54 @@ func TestParser_ParseStatement(t *testing.T) {
 		AssertParseStatementError(t, `CREATE VIEW vw AS SELECT`, `1:24: expected expression, found 'EOF'`)
 	})
 
+	t.Run("CreateMaterializedView", func(t *testing.T) {
+		AssertParseStatement(t, `CREATE MATERIALIZED VIEW vw AS SELECT x`, &parser.CreateViewStatement{
+			Create:       pos(0),
+			Materialized: pos(7),
+			View:         pos(20),
+			Name:         &parser.Ident{NamePos: pos(25), Name: "vw"},
+			As:           pos(28),
+			Select: &parser.SelectStatement{
+				Select: pos(31),
+				Columns: []*parser.ResultColumn{
+					{Expr: &parser.Ident{NamePos: pos(38), Name: "x"}},
+				},
+			},
+		})
+		AssertParseStatement(t, `CREATE MATERIALIZED VIEW vw WITH REFRESH '1h' AS SELECT x`, &parser.CreateViewStatement{
+			Create:       pos(0),
+			Materialized: pos(7),
+			View:         pos(20),
+			Name:         &parser.Ident{NamePos: pos(25), Name: "vw"},
+			With:         pos(28),
+			Refresh:      pos(33),
+			RefreshExpr:  &parser.StringLit{ValuePos: pos(41), Value: "1h"},
+			As:           pos(46),
+			Select: &parser.SelectStatement{
+				Select: pos(49),
+				Columns: []*parser.ResultColumn{
+					{Expr: &parser.Ident{NamePos: pos(56), Name: "x"}},
+				},
+			},
+		})
+		AssertParseStatementError(t, `CREATE MATERIALIZED vw`, `1:21: expected VIEW, found vw`)
+		AssertParseStatementError(t, `CREATE MATERIALIZED VIEW vw WITH`, `1:32: expected REFRESH, found 'EOF'`)
+		AssertParseStatementError(t, `CREATE MATERIALIZED VIEW vw WITH REFRESH AS`, `1:42: expected literal, found 'AS'`)
+		AssertParseStatementError(t, `CREATE VIEW vw WITH REFRESH '1h' AS SELECT x`, `1:16: expected AS, found 'WITH'`)
+	})
+
+	t.Run("RefreshMaterializedView", func(t *testing.T) {
+		AssertParseStatement(t, `REFRESH MATERIALIZED VIEW vw`, &parser.RefreshViewStatement{
+			Refresh:      pos(0),
+			Materialized: pos(8),
+			View:         pos(21),
+			Name:         &parser.Ident{NamePos: pos(26), Name: "vw"},
+		})
+		AssertParseStatementError(t, `REFRESH VIEW vw`, `1:9: expected MATERIALIZED, found 'VIEW'`)
+		AssertParseStatementError(t, `REFRESH MATERIALIZED vw`, `1:22: expected VIEW, found vw`)
+		AssertParseStatementError(t, `REFRESH MATERIALIZED VIEW`, `1:25: expected view name, found 'EOF'`)
+	})
+
 	t.Run("DropView", func(t *testing.T) {
 		AssertParseStatement(t, `DROP VIEW vw`, &parser.DropViewStatement{
 			Drop: pos(0),
@@ -3573,6 +3644,69 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		})
 
+		AssertParseStatement(t, `UPSERT INTO tbl (x, y) VALUES (1, 2)`, &parser.InsertStatement{
+			Upsert:        pos(0),
+			Into:          pos(7),
+			Table:         &parser.Ident{NamePos: pos(12), Name: "tbl"},
+			ColumnsLparen: pos(16),
+			Columns: []*parser.Ident{
+				{NamePos: pos(17), Name: "x"},
+				{NamePos: pos(20), Name: "y"},
+			},
+			ColumnsRparen: pos(21),
+			Values:        pos(23),
+			TupleList: []*parser.ExprList{
+				{
+					Lparen: pos(30),
+					Exprs: []parser.Expr{
+						&parser.IntegerLit{ValuePos: pos(31), Value: "1"},
+						&parser.IntegerLit{ValuePos: pos(34), Value: "2"},
+					},
+					Rparen: pos(35),
+				},
+			},
+		})
+
+		AssertParseStatement(t, `UPSERT INTO tbl (x, y) VALUES (1, 2) IF y = 3 AND z IS NULL`, &parser.InsertStatement{
+			Upsert:        pos(0),
+			Into:          pos(7),
+			Table:         &parser.Ident{NamePos: pos(12), Name: "tbl"},
+			ColumnsLparen: pos(16),
+			Columns: []*parser.Ident{
+				{NamePos: pos(17), Name: "x"},
+				{NamePos: pos(20), Name: "y"},
+			},
+			ColumnsRparen: pos(21),
+			Values:        pos(23),
+			TupleList: []*parser.ExprList{
+				{
+					Lparen: pos(30),
+					Exprs: []parser.Expr{
+						&parser.IntegerLit{ValuePos: pos(31), Value: "1"},
+						&parser.IntegerLit{ValuePos: pos(34), Value: "2"},
+					},
+					Rparen: pos(35),
+				},
+			},
+			If: pos(37),
+			IfExpr: &parser.BinaryExpr{
+				X: &parser.BinaryExpr{
+					X:     &parser.Ident{NamePos: pos(40), Name: "y"},
+					OpPos: pos(42),
+					Op:    parser.EQ,
+					Y:     &parser.IntegerLit{ValuePos: pos(44), Value: "3"},
+				},
+				OpPos: pos(46),
+				Op:    parser.AND,
+				Y: &parser.BinaryExpr{
+					X:     &parser.Ident{NamePos: pos(50), Name: "z"},
+					OpPos: pos(52),
+					Op:    parser.IS,
+					Y:     &parser.NullLit{ValuePos: pos(55)},
+				},
+			},
+		})
+
 		/*AssertParseStatement(t, `REPLACE INTO tbl (x, y) VALUES (1, 2), (3, 4)`, &parser.InsertStatement{
 			Replace:       pos(0),
 			Into:          pos(8),
@@ -3888,6 +4022,7 @@ func TestParser_ParseStatement(t *testing.T) {
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES`, `1:26: expected left paren, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (`, `1:28: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1`, `1:29: expected comma or right paren, found 'EOF'`)
+		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) IF`, `1:33: expected expression, found 'EOF'`)
 		/*AssertParseStatementError(t, `INSERT INTO tbl (x) SELECT`, `1:26: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) DEFAULT`, `1:27: expected VALUES, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) ON`, `1:33: expected CONFLICT, found 'EOF'`)
@@ -169,7 +169,9 @@ const (
 	LRU
 	MAP
 	MATCH
+	MATERIALIZED
 	MAX
+	MAXSNAPSHOTAGE
 	MIN
 	MODEL
 	NO
@@ -201,6 +203,7 @@ const (
 	RANKED
 	RECURSIVE
 	REFERENCES
+	REFRESH
 	REGEXP
 	REGISTER
 	REINDEX
@@ -241,6 +244,7 @@ const (
 	UNIQUE
 	UNITS
 	UPDATE
+	UPSERT
 	USING
 	VACUUM
 	VALUES
@@ -396,7 +400,9 @@ var tokens = [...]string{
 	MAP:               "MAP",
 	LRU:               "LRU",
 	MATCH:             "MATCH",
+	MATERIALIZED:      "MATERIALIZED",
 	MAX:               "MAX",
+	MAXSNAPSHOTAGE:    "MAXSNAPSHOTAGE",
 	MIN:               "MIN",
 	MODEL:             "MODEL",
 	NO:                "NO",
@@ -428,6 +434,7 @@ var tokens = [...]string{
 	RANKED:            "RANKED",
 	RECURSIVE:         "RECURSIVE",
 	REFERENCES:        "REFERENCES",
+	REFRESH:           "REFRESH",
 	REGEXP:            "REGEXP",
 	REGISTER:          "REGISTER",
 	REINDEX:           "REINDEX",
@@ -468,6 +475,7 @@ var tokens = [...]string{
 	UNIQUE:            "UNIQUE",
 	UNITS:             "UNITS",
 	UPDATE:            "UPDATE",
+	UPSERT:            "UPSERT",
 	USING:             "USING",
 	VACUUM:            "VACUUM",
 	VALUES:            "VALUES",
@@ -115,6 +115,9 @@ func walk(v Visitor, node Node) (_ Node, err error) {
 		if err := walkIdent(v, &n.Name); err != nil {
 			return node, err
 		}
+		if err := walkExpr(v, &n.RefreshExpr); err != nil {
+			return node, err
+		}
 		// if err := walkIdentList(v, n.Columns); err != nil {
 		// 	return node, err
 		// }
@@ -138,6 +141,11 @@ func walk(v Visitor, node Node) (_ Node, err error) {
 			return node, err
 		}
 
+	case *RefreshViewStatement:
+		if err := walkIdent(v, &n.Name); err != nil {
+			return node, err
+		}
+
 	case *DropIndexStatement:
 		if err := walkIdent(v, &n.Name); err != nil {
 			return node, err
@@ -268,6 +276,9 @@ func walk(v Visitor, node Node) (_ Node, err error) {
 				n.TupleList[i] = nil
 			}
 		}
+		if err := walkExpr(v, &n.IfExpr); err != nil {
+			return node, err
+		}
 
 		/*if n.Select != nil {
 			if sel, err := walk(v, n.Select); err != nil {
@@ -74,7 +74,7 @@ func (p *ExecutionPlanner) compileCopyStatement(stmt *parser.CopyStatement) (typ
 	tbl, err := p.schemaAPI.TableByName(context.Background(), tname)
 	if err != nil {
 		if isTableNotFoundError(err) {
-			return nil, sql3.NewErrTableNotFound(0, 0, stmt.Source.String())
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, stmt.Source.String()))
 		}
 		return nil, err
 	}
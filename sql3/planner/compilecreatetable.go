@@ -32,6 +32,7 @@ func (p *ExecutionPlanner) compileCreateTableStatement(ctx context.Context, stmt
 	// apply table options
 	keyPartitions := 0
 	description := ""
+	var snapshotMaxAge time.Duration
 	for _, option := range stmt.Options {
 		switch o := option.(type) {
 		case *parser.KeyPartitionsOption:
@@ -41,6 +42,12 @@ func (p *ExecutionPlanner) compileCreateTableStatement(ctx context.Context, stmt
 				return nil, err
 			}
 			keyPartitions = int(i)
+		case *parser.MaxSnapshotAgeOption:
+			e := o.Expr.(*parser.StringLit)
+			snapshotMaxAge, err = time.ParseDuration(e.Value)
+			if err != nil {
+				return nil, err
+			}
 		case *parser.CommentOption:
 			e := o.Expr.(*parser.StringLit)
 			description = e.Value
@@ -68,7 +75,7 @@ func (p *ExecutionPlanner) compileCreateTableStatement(ctx context.Context, stmt
 
 		columns = append(columns, column)
 	}
-	cop := NewPlanOpCreateTable(p, tableName, failIfExists, isKeyed, keyPartitions, description, columns)
+	cop := NewPlanOpCreateTable(p, tableName, failIfExists, isKeyed, keyPartitions, description, snapshotMaxAge, columns)
 	if keyPartitions > 0 {
 		cop.AddWarning("The value of KEYPARTITIONS is currently ignored")
 	}
@@ -296,6 +303,17 @@ func (p *ExecutionPlanner) analyzeCreateTableStatement(stmt *parser.CreateTableS
 				return sql3.NewErrInvalidKeyPartitionsValue(o.Expr.Pos().Line, o.Expr.Pos().Column, i)
 			}
 
+		case *parser.MaxSnapshotAgeOption:
+			literal, ok := o.Expr.(*parser.StringLit)
+			if !ok {
+				return sql3.NewErrStringLiteral(o.Expr.Pos().Line, o.Expr.Pos().Column)
+			}
+			//the max snapshot age needs to be a positive duration
+			d, err := time.ParseDuration(literal.Value)
+			if err != nil || d <= 0 {
+				return sql3.NewErrInvalidDuration(literal.ValuePos.Line, literal.ValuePos.Column, literal.Value)
+			}
+
 		case *parser.CommentOption:
 
 			_, ok := o.Expr.(*parser.StringLit)
@@ -5,7 +5,9 @@ package planner
 import (
 	"context"
 	"strings"
+	"time"
 
+	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 )
@@ -24,6 +26,18 @@ func (p *ExecutionPlanner) compileCreateViewStatement(stmt *parser.CreateViewSta
 	}
 	view.statement = stmt.Select.String()
 
+	if stmt.Materialized.IsValid() {
+		view.materialized = &materializedViewSystemObject{
+			name: viewName,
+		}
+		if lit, ok := stmt.RefreshExpr.(*parser.StringLit); ok {
+			view.materialized.refreshInterval, err = time.ParseDuration(lit.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	query := NewPlanOpQuery(p, NewPlanOpCreateView(p, stmt.IfNotExists.IsValid(), view), p.sql)
 	return query, nil
 }
@@ -53,6 +67,18 @@ func (p *ExecutionPlanner) analyzeCreateViewStatement(ctx context.Context, stmt
 		return err
 	}
 
+	// the refresh interval is a duration of at least a second
+	if stmt.RefreshExpr != nil {
+		lit, ok := stmt.RefreshExpr.(*parser.StringLit)
+		if !ok {
+			return sql3.NewErrStringLiteral(stmt.RefreshExpr.Pos().Line, stmt.RefreshExpr.Pos().Column)
+		}
+		d, err := time.ParseDuration(lit.Value)
+		if err != nil || d < time.Second {
+			return sql3.NewErrInvalidDuration(lit.ValuePos.Line, lit.ValuePos.Column, lit.Value)
+		}
+	}
+
 	return nil
 }
 
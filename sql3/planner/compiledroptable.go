@@ -21,7 +21,7 @@ func (p *ExecutionPlanner) compileDropTableStatement(ctx context.Context, stmt *
 	tbl, err := p.schemaAPI.TableByName(ctx, tname)
 	if err != nil {
 		if isTableNotFoundError(err) {
-			return nil, sql3.NewErrTableNotFound(stmt.Name.NamePos.Line, stmt.Name.NamePos.Column, tableName)
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(stmt.Name.NamePos.Line, stmt.Name.NamePos.Column, tableName))
 		}
 		return nil, err
 	}
@@ -24,7 +24,7 @@ func (p *ExecutionPlanner) compileInsertStatement(ctx context.Context, stmt *par
 	tbl, err := p.schemaAPI.TableByName(ctx, tname)
 	if err != nil {
 		if isTableNotFoundError(err) {
-			return nil, sql3.NewErrTableNotFound(stmt.Table.NamePos.Line, stmt.Table.NamePos.Column, tableName)
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(stmt.Table.NamePos.Line, stmt.Table.NamePos.Column, tableName))
 		}
 		return nil, err
 	}
@@ -67,7 +67,40 @@ func (p *ExecutionPlanner) compileInsertStatement(ctx context.Context, stmt *par
 		insertValues = append(insertValues, tupleValues)
 	}
 
-	return NewPlanOpQuery(p, NewPlanOpInsert(p, tableName, targetColumns, insertValues), p.sql), nil
+	op := NewPlanOpInsert(p, tableName, targetColumns, insertValues)
+	op.upsert = stmt.Upsert.IsValid()
+	if stmt.IfExpr != nil {
+		if op.conditions, err = p.compileWriteConditions(stmt.IfExpr, nil); err != nil {
+			return nil, err
+		}
+	}
+	return NewPlanOpQuery(p, op, p.sql), nil
+}
+
+// compileWriteConditions appends the terms of expr, the analyzed condition of
+// a conditional write, to conds.
+func (p *ExecutionPlanner) compileWriteConditions(expr parser.Expr, conds []*writeCondition) ([]*writeCondition, error) {
+	bin := expr.(*parser.BinaryExpr)
+	if bin.Op == parser.AND {
+		conds, err := p.compileWriteConditions(bin.X, conds)
+		if err != nil {
+			return nil, err
+		}
+		return p.compileWriteConditions(bin.Y, conds)
+	}
+	cond := &writeCondition{
+		columnName: strings.ToLower(parser.IdentName(bin.X.(*parser.Ident))),
+	}
+	if bin.Op == parser.IS {
+		cond.absent = true
+	} else {
+		value, err := p.compileExpr(bin.Y)
+		if err != nil {
+			return nil, err
+		}
+		cond.value = value
+	}
+	return append(conds, cond), nil
 }
 
 // analyzeInsertStatement analyzes an INSERT statement and returns and error if
@@ -79,7 +112,7 @@ func (p *ExecutionPlanner) analyzeInsertStatement(ctx context.Context, stmt *par
 	tbl, err := p.schemaAPI.TableByName(ctx, tname)
 	if err != nil {
 		if isTableNotFoundError(err) {
-			return sql3.NewErrTableNotFound(stmt.Table.NamePos.Line, stmt.Table.NamePos.Column, tableName)
+			return tableNotFoundError(err, sql3.NewErrTableNotFound(stmt.Table.NamePos.Line, stmt.Table.NamePos.Column, tableName))
 		}
 		return err
 	}
@@ -185,5 +218,68 @@ func (p *ExecutionPlanner) analyzeInsertStatement(ctx context.Context, stmt *par
 		}
 	}
 
+	// Check the condition of a conditional write, which is checked, and the
+	// write applied, per record.
+	if stmt.IfExpr != nil {
+		if len(stmt.TupleList) != 1 {
+			return sql3.NewErrConditionalWriteMultipleRows(stmt.If.Line, stmt.If.Column)
+		}
+		if err := p.analyzeWriteCondition(ctx, stmt, tbl, stmt.IfExpr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// analyzeWriteCondition analyzes expr, the condition of a conditional write,
+// which must be one or more terms of the form `column = literal` or
+// `column IS NULL`, combined with AND.
+func (p *ExecutionPlanner) analyzeWriteCondition(ctx context.Context, stmt *parser.InsertStatement, tbl *dax.Table, expr parser.Expr) error {
+	bin, ok := expr.(*parser.BinaryExpr)
+	if !ok {
+		return sql3.NewErrInvalidWriteCondition(expr.Pos().Line, expr.Pos().Column)
+	}
+	if bin.Op == parser.AND {
+		if err := p.analyzeWriteCondition(ctx, stmt, tbl, bin.X); err != nil {
+			return err
+		}
+		return p.analyzeWriteCondition(ctx, stmt, tbl, bin.Y)
+	}
+
+	ident, ok := bin.X.(*parser.Ident)
+	if !ok {
+		return sql3.NewErrInvalidWriteCondition(bin.X.Pos().Line, bin.X.Pos().Column)
+	}
+	colName := strings.ToLower(parser.IdentName(ident))
+	var field *dax.Field
+	for _, fld := range tbl.Fields {
+		if strings.EqualFold(colName, string(fld.Name)) && !fld.IsPrimaryKey() && !strings.EqualFold("_exists", colName) {
+			field = fld
+			break
+		}
+	}
+	if field == nil {
+		return sql3.NewErrColumnNotFound(ident.NamePos.Line, ident.NamePos.Column, colName)
+	}
+
+	switch bin.Op {
+	case parser.IS:
+		if _, ok := bin.Y.(*parser.NullLit); !ok {
+			return sql3.NewErrInvalidWriteCondition(bin.OpPos.Line, bin.OpPos.Column)
+		}
+	case parser.EQ:
+		e, err := p.analyzeExpression(ctx, bin.Y, stmt)
+		if err != nil {
+			return err
+		}
+		typeName := fieldSQLDataType(pilosa.FieldToFieldInfo(field))
+		if !typesAreAssignmentCompatible(typeName, e.DataType()) {
+			return sql3.NewErrTypeAssignmentIncompatible(bin.Y.Pos().Line, bin.Y.Pos().Column, e.DataType().TypeDescription(), typeName.TypeDescription())
+		}
+		bin.Y = e
+	default:
+		return sql3.NewErrInvalidWriteCondition(bin.OpPos.Line, bin.OpPos.Column)
+	}
 	return nil
 }
@@ -0,0 +1,27 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// compileRefreshViewStatement compiles a REFRESH MATERIALIZED VIEW statement
+// into a PlanOperator.
+func (p *ExecutionPlanner) compileRefreshViewStatement(ctx context.Context, stmt *parser.RefreshViewStatement) (_ types.PlanOperator, err error) {
+	viewName := strings.ToLower(parser.IdentName(stmt.Name))
+	v, err := p.getViewByName(ctx, viewName)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, sql3.NewErrViewNotFound(stmt.Name.NamePos.Line, stmt.Name.NamePos.Column, viewName)
+	}
+
+	return NewPlanOpQuery(p, NewPlanOpRefreshView(p, viewName), p.sql), nil
+}
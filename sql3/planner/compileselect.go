@@ -305,6 +305,13 @@ func (p *ExecutionPlanner) compileSelectStatement(stmt *parser.SelectStatement,
 		}
 	}
 
+	// handle distinct - before the top or limit, so that they count distinct
+	// rows; the distinct keeps the first of each set of duplicates, so it
+	// preserves the order of the rows
+	if stmt.Distinct.IsValid() {
+		compiledOp = NewPlanOpDistinct(p, compiledOp)
+	}
+
 	// insert the top operator if it exists, or limit - analyzer should have caught the case of both existing
 	if stmt.Top.IsValid() {
 		topExpr, err := p.compileExpr(stmt.TopExpr)
@@ -322,11 +329,6 @@ func (p *ExecutionPlanner) compileSelectStatement(stmt *parser.SelectStatement,
 		compiledOp = NewPlanOpTop(limitExpr, compiledOp)
 	}
 
-	// handle distinct
-	if stmt.Distinct.IsValid() {
-		compiledOp = NewPlanOpDistinct(p, compiledOp)
-	}
-
 	// if it is a subquery, don't wrap in a PlanOpQuery
 	if isSubquery {
 		return compiledOp, nil
@@ -543,14 +545,28 @@ func (p *ExecutionPlanner) analyzeSource(ctx context.Context, source parser.Sour
 
 		// if view is not null, it exists
 		if view != nil {
-			// parse the select statement
-			ast, err := parser.NewParser(strings.NewReader(view.statement)).ParseStatement()
+			// a materialized view is read from its table, otherwise parse
+			// the select statement
+			mv, err := p.getMaterializedViewByName(ctx, objectName)
 			if err != nil {
 				return nil, err
 			}
-			sel, ok := ast.(*parser.SelectStatement)
-			if !ok {
-				return nil, sql3.NewErrInternalf("unexpected ast type")
+			var sel *parser.SelectStatement
+			if mv != nil {
+				sel, err = p.materializedViewStatement(ctx, view, mv)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				ast, err := parser.NewParser(strings.NewReader(view.statement)).ParseStatement()
+				if err != nil {
+					return nil, err
+				}
+				var ok bool
+				sel, ok = ast.(*parser.SelectStatement)
+				if !ok {
+					return nil, sql3.NewErrInternalf("unexpected ast type")
+				}
 			}
 			// analyze the select statement
 			expr, err := p.analyzeSelectStatement(ctx, sel)
@@ -577,7 +593,7 @@ func (p *ExecutionPlanner) analyzeSource(ctx context.Context, source parser.Sour
 		tbl, err := p.schemaAPI.TableByName(ctx, tname)
 		if err != nil {
 			if isTableNotFoundError(err) {
-				return nil, sql3.NewErrTableOrViewNotFound(source.Name.NamePos.Line, source.Name.NamePos.Column, objectName)
+				return nil, tableNotFoundError(err, sql3.NewErrTableOrViewNotFound(source.Name.NamePos.Line, source.Name.NamePos.Column, objectName))
 			}
 			return nil, err
 		}
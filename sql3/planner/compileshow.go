@@ -156,7 +156,7 @@ func (p *ExecutionPlanner) compileShowColumnsStatement(ctx context.Context, stmt
 	tbl, err := p.schemaAPI.TableByName(ctx, tname)
 	if err != nil {
 		if isTableNotFoundError(err) {
-			return nil, sql3.NewErrTableNotFound(stmt.TableName.NamePos.Line, stmt.TableName.NamePos.Column, tableName)
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(stmt.TableName.NamePos.Line, stmt.TableName.NamePos.Column, tableName))
 		}
 		return nil, err
 	}
@@ -241,7 +241,7 @@ func (p *ExecutionPlanner) compileShowCreateTableStatement(ctx context.Context,
 	tname := dax.TableName(tableName)
 	if _, err := p.schemaAPI.TableByName(ctx, tname); err != nil {
 		if isTableNotFoundError(err) {
-			return nil, sql3.NewErrTableNotFound(stmt.TableName.NamePos.Line, stmt.TableName.NamePos.Column, tableName)
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(stmt.TableName.NamePos.Line, stmt.TableName.NamePos.Column, tableName))
 		}
 		return nil, err
 	}
@@ -25,7 +25,17 @@ func isDatabaseNotFoundError(err error) bool {
 }
 
 func isTableNotFoundError(err error) bool {
-	return errors.Is(err, dax.ErrTableNameDoesNotExist)
+	return dax.IsTableNotFound(err)
+}
+
+// tableNotFoundError returns notFound, the sql3 error for a table which
+// doesn't exist, noting how to restore the table if err, the schema's error
+// for it, says the table was dropped and can be restored.
+func tableNotFoundError(err, notFound error) error {
+	if !errors.Is(err, dax.ErrTableDropped) {
+		return notFound
+	}
+	return errors.New(errors.CodeOf(notFound), fmt.Sprintf("%s: %s", errors.Message(notFound), errors.Message(err)))
 }
 
 // ExecutionPlanner compiles SQL text into a query plan
@@ -37,6 +47,10 @@ type ExecutionPlanner struct {
 	importer       pilosa.Importer
 	logger         logger.Logger
 	sql            string
+	distinct       DistinctConfig
+
+	viewRefresher    *ViewRefresher
+	viewRefreshScope string
 }
 
 func NewExecutionPlanner(executor pilosa.Executor, schemaAPI pilosa.SchemaAPI, systemAPI pilosa.SystemAPI, systemLayerAPI pilosa.SystemLayerAPI, importer pilosa.Importer, logger logger.Logger, sql string) *ExecutionPlanner {
@@ -51,6 +65,12 @@ func NewExecutionPlanner(executor pilosa.Executor, schemaAPI pilosa.SchemaAPI, s
 	}
 }
 
+// SetDistinctConfig sets the limits of the DISTINCT operators in the plans p
+// compiles.
+func (p *ExecutionPlanner) SetDistinctConfig(cfg DistinctConfig) {
+	p.distinct = cfg
+}
+
 // CompilePlan takes an AST (parser.Statement) and compiles into a query plan returning the root
 // PlanOperator
 // The act of compiling includes an analysis step that does semantic analysis of the AST, this includes
@@ -62,8 +82,21 @@ func (p *ExecutionPlanner) CompilePlan(ctx context.Context, stmt parser.Statemen
 	if err != nil {
 		return nil, err
 	}
+	return p.CompileAnalyzedPlan(ctx, stmt)
+}
 
+// AnalyzePlan does the semantic analysis of stmt which CompilePlan does
+// before compiling it, annotating and rewriting stmt in place.
+func (p *ExecutionPlanner) AnalyzePlan(ctx context.Context, stmt parser.Statement) error {
+	return p.analyzePlan(ctx, stmt)
+}
+
+// CompileAnalyzedPlan compiles stmt, which must already have been analyzed by
+// AnalyzePlan, into a query plan. An analyzed statement may be cloned, with
+// parser.CloneStatement, and each clone compiled into its own plan.
+func (p *ExecutionPlanner) CompileAnalyzedPlan(ctx context.Context, stmt parser.Statement) (types.PlanOperator, error) {
 	var rootOperator types.PlanOperator
+	var err error
 	switch stmt := stmt.(type) {
 	case *parser.SelectStatement:
 		rootOperator, err = p.compileSelectStatement(stmt, false)
@@ -97,6 +130,8 @@ func (p *ExecutionPlanner) CompilePlan(ctx context.Context, stmt parser.Statemen
 		rootOperator, err = p.compileDropTableStatement(ctx, stmt)
 	case *parser.DropViewStatement:
 		rootOperator, err = p.compileDropViewStatement(ctx, stmt)
+	case *parser.RefreshViewStatement:
+		rootOperator, err = p.compileRefreshViewStatement(ctx, stmt)
 	case *parser.DropModelStatement:
 		rootOperator, err = p.compileDropModelStatement(stmt)
 	case *parser.InsertStatement:
@@ -169,6 +204,8 @@ func (p *ExecutionPlanner) analyzePlan(ctx context.Context, stmt parser.Statemen
 		return nil
 	case *parser.DropViewStatement:
 		return nil
+	case *parser.RefreshViewStatement:
+		return nil
 	case *parser.DropModelStatement:
 		return nil
 	case *parser.InsertStatement:
@@ -53,7 +53,7 @@ func (s *systemTableDefinitionsWrapper) TableByName(ctx context.Context, tname d
 		if isTableNotFoundError(err) {
 			st, ok := systemTables.table(string(tname))
 			if !ok {
-				return nil, dax.NewErrTableNameDoesNotExist(tname)
+				return nil, err
 			}
 
 			return indexInfoFromSystemTableB(st)
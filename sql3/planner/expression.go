@@ -268,7 +268,7 @@ func (n *unaryOpPlanExpression) minusWithTypeCheck(rhs interface{}) (interface{}
 	case *parser.DataTypeID:
 		nr, nrok := rhs.(int64)
 		if nrok {
-			return -nr, nil
+			return subInt64(0, nr)
 		}
 		return nil, sql3.NewErrInternalf("unexpected incompatible types '%T", rhs)
 
@@ -280,7 +280,7 @@ func (n *unaryOpPlanExpression) minusWithTypeCheck(rhs interface{}) (interface{}
 
 		nr, nrok := coercedRhs.(int64)
 		if nrok {
-			return -nr, nil
+			return subInt64(0, nr)
 		}
 		return nil, sql3.NewErrInternalf("unexpected incompatible types '%T", rhs)
 
@@ -301,7 +301,44 @@ func (n *unaryOpPlanExpression) minusWithTypeCheck(rhs interface{}) (interface{}
 	}
 }
 
-// binOpPlanExpression is a binary op
+// addInt64 returns a + b, or an ErrIntegerOverflow error if the result can't be
+// represented as an int64.
+func addInt64(a, b int64) (interface{}, error) {
+	c := a + b
+	if (c > a) != (b > 0) {
+		return nil, sql3.NewErrIntegerOverflow(0, 0, "+")
+	}
+	return c, nil
+}
+
+// subInt64 returns a - b, or an ErrIntegerOverflow error if the result can't be
+// represented as an int64.
+func subInt64(a, b int64) (interface{}, error) {
+	c := a - b
+	if (c < a) != (b > 0) {
+		return nil, sql3.NewErrIntegerOverflow(0, 0, "-")
+	}
+	return c, nil
+}
+
+// mulInt64 returns a * b, or an ErrIntegerOverflow error if the result can't be
+// represented as an int64.
+func mulInt64(a, b int64) (interface{}, error) {
+	if a == 0 || b == 0 {
+		return int64(0), nil
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return nil, sql3.NewErrIntegerOverflow(0, 0, "*")
+	}
+	return c, nil
+}
+
+// binOpPlanExpression is a binary op. Comparisons and arithmetic with a NULL
+// operand are NULL. Integer arithmetic which overflows an int64 is an
+// ErrIntegerOverflow error rather than wrapping around, and division or
+// remainder by zero is an ErrDivideByZero error; integer division truncates
+// towards zero.
 type binOpPlanExpression struct {
 	lhs types.PlanExpression
 	op  parser.Token
@@ -411,20 +448,26 @@ func (n *binOpPlanExpression) Evaluate(currentRow []interface{}) (interface{}, e
 				return nl >> nr, nil
 
 			case parser.PLUS:
-				return nl + nr, nil
+				return addInt64(nl, nr)
 			case parser.MINUS:
-				return nl - nr, nil
+				return subInt64(nl, nr)
 			case parser.STAR:
-				return nl * nr, nil
+				return mulInt64(nl, nr)
 			case parser.SLASH:
 				if nr == 0 {
 					return nil, sql3.NewErrDivideByZero(0, 0)
 				}
+				if nl == math.MinInt64 && nr == -1 {
+					return nil, sql3.NewErrIntegerOverflow(0, 0, "/")
+				}
 				return nl / nr, nil
 			case parser.REM:
 				if nr == 0 {
 					return nil, sql3.NewErrDivideByZero(0, 0)
 				}
+				if nr == -1 {
+					return int64(0), nil
+				}
 				return nl % nr, nil
 			default:
 				return nil, sql3.NewErrInternalf("unhandled operator %d", n.op)
@@ -476,15 +519,21 @@ func (n *binOpPlanExpression) Evaluate(currentRow []interface{}) (interface{}, e
 				return nl >> nr, nil
 
 			case parser.PLUS:
-				return nl + nr, nil
+				return addInt64(nl, nr)
 			case parser.MINUS:
-				return nl - nr, nil
+				return subInt64(nl, nr)
 			case parser.STAR:
-				return nl * nr, nil
+				return mulInt64(nl, nr)
 			case parser.SLASH:
+				if nr == 0 {
+					return nil, sql3.NewErrDivideByZero(0, 0)
+				}
 				return nl / nr, nil
 
 			case parser.REM:
+				if nr == 0 {
+					return nil, sql3.NewErrDivideByZero(0, 0)
+				}
 				return nl % nr, nil
 
 			default:
@@ -534,6 +583,9 @@ func (n *binOpPlanExpression) Evaluate(currentRow []interface{}) (interface{}, e
 			case parser.STAR:
 				return pql.MultiplyDecimal(nld, nrd), nil
 			case parser.SLASH:
+				if nrd.EqualTo(pql.NewDecimal(0, 0)) {
+					return nil, sql3.NewErrDivideByZero(0, 0)
+				}
 				return pql.DivideDecimal(nld, nrd), nil
 
 			default:
@@ -1885,6 +1937,77 @@ func (n *nullLiteralPlanExpression) WithChildren(children ...types.PlanExpressio
 	return n, nil
 }
 
+// newLiteralPlanExpressionFromValue returns a literal for value, a value of
+// type typ as it's returned by a row iterator.
+func newLiteralPlanExpressionFromValue(typ parser.ExprDataType, value interface{}) (types.PlanExpression, error) {
+	if value == nil {
+		return newNullLiteralPlanExpression(), nil
+	}
+	switch ty := typ.(type) {
+	case *parser.DataTypeID, *parser.DataTypeInt:
+		val, ok := value.(int64)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+		return newIntLiteralPlanExpression(val), nil
+
+	case *parser.DataTypeDecimal:
+		val, ok := value.(pql.Decimal)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+		return newFloatLiteralPlanExpression(val.String()), nil
+
+	case *parser.DataTypeString:
+		val, ok := value.(string)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+		return newStringLiteralPlanExpression(val), nil
+
+	case *parser.DataTypeBool:
+		val, ok := value.(bool)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+		return newBoolLiteralPlanExpression(val), nil
+
+	case *parser.DataTypeTimestamp:
+		val, ok := value.(time.Time)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+		return newTimestampLiteralPlanExpression(val), nil
+
+	case *parser.DataTypeStringSet, *parser.DataTypeStringSetQuantum:
+		val, ok := value.([]string)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+
+		members := make([]types.PlanExpression, 0)
+		for _, m := range val {
+			members = append(members, newStringLiteralPlanExpression(m))
+		}
+		return newExprSetLiteralPlanExpression(members, parser.NewDataTypeStringSet()), nil
+
+	case *parser.DataTypeIDSet, *parser.DataTypeIDSetQuantum:
+		val, ok := value.([]int64)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected type '%T'", value)
+		}
+
+		members := make([]types.PlanExpression, 0)
+		for _, m := range val {
+			members = append(members, newIntLiteralPlanExpression(m))
+		}
+		return newExprSetLiteralPlanExpression(members, parser.NewDataTypeIDSet()), nil
+
+	default:
+		return nil, sql3.NewErrInternalf("unhandled type '%T'", ty)
+	}
+}
+
 // intLiteralPlanExpression is an integer literal
 type intLiteralPlanExpression struct {
 	value int64
@@ -2910,6 +3033,18 @@ func (p *ExecutionPlanner) compileCallExpr(expr *parser.Call) (_ types.PlanExpre
 		agg := newVarPlanExpression(args[0], expr.ResultDataType)
 		return agg, nil
 
+	case "APPROX_COUNT_DISTINCT":
+		agg := newApproxCountDistinctPlanExpression(args[0], expr.ResultDataType)
+		return agg, nil
+
+	case "APPROX_PERCENTILE":
+		agg := newApproxPercentilePlanExpression(args[0], args[1], expr.ResultDataType)
+		return agg, nil
+
+	case "APPROX_TOP_K":
+		agg := newApproxTopKPlanExpression(args[0], args[1], expr.ResultDataType)
+		return agg, nil
+
 	case "MIN":
 		agg := newMinPlanExpression(args[0], expr.ResultDataType)
 		return agg, nil
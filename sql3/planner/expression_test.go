@@ -5,11 +5,13 @@ package planner
 import (
 	"math"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/pql"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 )
 
 func Test_coerceValue(t *testing.T) {
@@ -130,3 +132,62 @@ func Test_coerceValue(t *testing.T) {
 		})
 	}
 }
+
+func Test_binOpPlanExpressionOverflow(t *testing.T) {
+	intLit := func(v int64) *intLiteralPlanExpression { return newIntLiteralPlanExpression(v) }
+	tests := []struct {
+		lhs, rhs int64
+		op       parser.Token
+		want     interface{}
+		wantErr  string
+	}{
+		{math.MaxInt64 - 1, 1, parser.PLUS, int64(math.MaxInt64), ""},
+		{math.MaxInt64, 1, parser.PLUS, nil, "integer overflow"},
+		{math.MinInt64, -1, parser.PLUS, nil, "integer overflow"},
+		{math.MinInt64 + 1, 1, parser.MINUS, int64(math.MinInt64), ""},
+		{math.MinInt64, 1, parser.MINUS, nil, "integer overflow"},
+		{math.MaxInt64, -1, parser.MINUS, nil, "integer overflow"},
+		{0, math.MinInt64, parser.MINUS, nil, "integer overflow"},
+		{math.MaxInt64 / 2, 2, parser.STAR, int64(math.MaxInt64 - 1), ""},
+		{math.MaxInt64, 2, parser.STAR, nil, "integer overflow"},
+		{math.MinInt64, -1, parser.STAR, nil, "integer overflow"},
+		{math.MinInt64, -1, parser.SLASH, nil, "integer overflow"},
+		{math.MinInt64, -1, parser.REM, int64(0), ""},
+		{-7, 2, parser.SLASH, int64(-3), ""},
+		{1, 0, parser.SLASH, nil, "divisor is equal to zero"},
+	}
+	for _, tt := range tests {
+		e := newBinOpPlanExpression(intLit(tt.lhs), tt.op, intLit(tt.rhs), parser.NewDataTypeInt())
+		got, err := e.Evaluate(nil)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%d %s %d: expected error '%s', got %v, %v", tt.lhs, tt.op, tt.rhs, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d %s %d: unexpected error: %v", tt.lhs, tt.op, tt.rhs, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d %s %d: expected %v, got %v", tt.lhs, tt.op, tt.rhs, tt.want, got)
+		}
+	}
+}
+
+func Test_projectionEvaluatedAt(t *testing.T) {
+	ref := newQualifiedRefPlanExpression("t", "a", 0, parser.NewDataTypeInt())
+	sum := newBinOpPlanExpression(ref, parser.PLUS, newIntLiteralPlanExpression(1), parser.NewDataTypeInt())
+
+	for _, tt := range []struct {
+		expr types.PlanExpression
+		want string
+	}{
+		{ref, projectionEvaluatedComputer},
+		{newAliasPlanExpression("b", ref), projectionEvaluatedComputer},
+		{sum, projectionEvaluatedMerge},
+		{newAliasPlanExpression("b", sum), projectionEvaluatedMerge},
+	} {
+		if got := projectionEvaluatedAt(tt.expr); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,523 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// The approximate aggregates are computed by building a mergeable sketch (see
+// sketch.go in the featurebase package). Without a GROUP BY, an aggregate of a
+// column is pushed down to PQL as a Sketch() call: each computer builds the
+// sketch of the column for its shards, and the computers' sketches are merged
+// and evaluated in the queryer. Otherwise the queryer builds a sketch per group
+// from the rows read from the computers. Their Plan() output describes the
+// sketch used, and its accuracy, so that it's visible when the query plan is
+// inspected.
+
+// sketchAggregate is an approximate aggregate which can be pushed down to PQL
+// as a Sketch() call.
+type sketchAggregate interface {
+	types.Aggregable
+
+	// sketchCall returns the Sketch() call building the sketch of field.
+	sketchCall(field string) (*pql.Call, error)
+
+	// evalSketch returns the value of the aggregate given the merged sketch.
+	evalSketch(s *pilosa.Sketch) (interface{}, error)
+
+	// sketchPlan describes the sketch, for Plan().
+	sketchPlan() map[string]interface{}
+}
+
+var (
+	_ sketchAggregate = (*approxCountDistinctPlanExpression)(nil)
+	_ sketchAggregate = (*approxPercentilePlanExpression)(nil)
+	_ sketchAggregate = (*approxTopKPlanExpression)(nil)
+)
+
+// aggregator for APPROX_COUNT_DISTINCT()
+type aggregateApproxCountDistinct struct {
+	expr   types.PlanExpression
+	sketch *pilosa.HLLSketch
+}
+
+func NewAggApproxCountDistinctBuffer(child types.PlanExpression) *aggregateApproxCountDistinct {
+	return &aggregateApproxCountDistinct{
+		expr:   child,
+		sketch: pilosa.NewHLLSketch(),
+	}
+}
+
+func (m *aggregateApproxCountDistinct) Update(ctx context.Context, row types.Row) error {
+	v, err := m.expr.Evaluate(row)
+	if err != nil {
+		return err
+	}
+	// skip if nil
+	if v == nil {
+		return nil
+	}
+	m.sketch.AddHash(pilosa.HashSketchValue(v))
+	return nil
+}
+
+func (m *aggregateApproxCountDistinct) Eval(ctx context.Context) (interface{}, error) {
+	return m.sketch.Estimate(), nil
+}
+
+// approxCountDistinctPlanExpression handles APPROX_COUNT_DISTINCT()
+type approxCountDistinctPlanExpression struct {
+	arg            types.PlanExpression
+	returnDataType parser.ExprDataType
+}
+
+var _ types.Aggregable = (*approxCountDistinctPlanExpression)(nil)
+
+func newApproxCountDistinctPlanExpression(arg types.PlanExpression, returnDataType parser.ExprDataType) *approxCountDistinctPlanExpression {
+	return &approxCountDistinctPlanExpression{
+		arg:            arg,
+		returnDataType: returnDataType,
+	}
+}
+
+func (n *approxCountDistinctPlanExpression) Evaluate(currentRow []interface{}) (interface{}, error) {
+	return nil, sql3.NewErrInternalf("this should never be called")
+}
+
+func (n *approxCountDistinctPlanExpression) NewBuffer() (types.AggregationBuffer, error) {
+	return NewAggApproxCountDistinctBuffer(n.arg), nil
+}
+
+func (n *approxCountDistinctPlanExpression) sketchCall(field string) (*pql.Call, error) {
+	return &pql.Call{
+		Name: "Sketch",
+		Args: map[string]interface{}{
+			"field": field,
+			"type":  pilosa.SketchTypeHLL,
+		},
+	}, nil
+}
+
+func (n *approxCountDistinctPlanExpression) evalSketch(s *pilosa.Sketch) (interface{}, error) {
+	if s.HLL == nil {
+		return nil, sql3.NewErrInternalf("unexpected sketch type '%s'", s.Type())
+	}
+	return s.HLL.Estimate(), nil
+}
+
+func (n *approxCountDistinctPlanExpression) FirstChildExpr() types.PlanExpression {
+	return n.arg
+}
+
+func (n *approxCountDistinctPlanExpression) Type() parser.ExprDataType {
+	return n.returnDataType
+}
+
+func (n *approxCountDistinctPlanExpression) String() string {
+	return fmt.Sprintf("approx_count_distinct(%s)", n.arg.String())
+}
+
+func (n *approxCountDistinctPlanExpression) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_expr"] = fmt.Sprintf("%T", n)
+	result["description"] = n.String()
+	result["dataType"] = n.Type().TypeDescription()
+	result["arg"] = n.arg.Plan()
+	result["sketch"] = n.sketchPlan()
+	return result
+}
+
+func (n *approxCountDistinctPlanExpression) sketchPlan() map[string]interface{} {
+	return map[string]interface{}{
+		"type":          "hyperloglog",
+		"precision":     pilosa.HLLPrecision(),
+		"registers":     1 << pilosa.HLLPrecision(),
+		"relativeError": pilosa.HLLRelativeError(),
+	}
+}
+
+func (n *approxCountDistinctPlanExpression) Children() []types.PlanExpression {
+	return []types.PlanExpression{
+		n.arg,
+	}
+}
+
+func (n *approxCountDistinctPlanExpression) WithChildren(children ...types.PlanExpression) (types.PlanExpression, error) {
+	if len(children) != 1 {
+		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
+	}
+	return newApproxCountDistinctPlanExpression(children[0], n.returnDataType), nil
+}
+
+// aggregator for APPROX_PERCENTILE()
+type aggregateApproxPercentile struct {
+	expr   *approxPercentilePlanExpression
+	nth    float64
+	digest *pilosa.TDigest
+}
+
+func NewAggApproxPercentileBuffer(child *approxPercentilePlanExpression, nth float64) *aggregateApproxPercentile {
+	return &aggregateApproxPercentile{
+		expr:   child,
+		nth:    nth,
+		digest: pilosa.NewTDigest(pilosa.TDigestCompression),
+	}
+}
+
+func (m *aggregateApproxPercentile) Update(ctx context.Context, row types.Row) error {
+	v, err := m.expr.arg.Evaluate(row)
+	if err != nil {
+		return err
+	}
+
+	// skip if nil
+	if v == nil {
+		return nil
+	}
+
+	switch thisVal := v.(type) {
+	case pql.Decimal:
+		m.digest.Add(thisVal.Float64())
+	case int64:
+		m.digest.Add(float64(thisVal))
+	default:
+		return sql3.NewErrInternalf("unexpected type conversion '%T'", v)
+	}
+	return nil
+}
+
+func (m *aggregateApproxPercentile) Eval(ctx context.Context) (interface{}, error) {
+	return m.expr.evalDigest(m.digest, m.nth)
+}
+
+// evalDigest returns the estimated nth percentile of the values in digest.
+func (n *approxPercentilePlanExpression) evalDigest(digest *pilosa.TDigest, nth float64) (interface{}, error) {
+	if digest.Count() == 0 {
+		return nil, nil
+	}
+
+	val := digest.Quantile(nth / 100)
+
+	switch dataType := n.returnDataType.(type) {
+	case *parser.DataTypeDecimal:
+		return pql.FromFloat64WithScale(val, int(dataType.Scale))
+	case *parser.DataTypeID, *parser.DataTypeInt:
+		return int64(math.Round(val)), nil
+	default:
+		return nil, sql3.NewErrInternalf("unhandled aggregate expression datatype '%T'", dataType)
+	}
+}
+
+// approxPercentilePlanExpression handles APPROX_PERCENTILE()
+type approxPercentilePlanExpression struct {
+	arg            types.PlanExpression
+	nthArg         types.PlanExpression
+	returnDataType parser.ExprDataType
+}
+
+var _ types.Aggregable = (*approxPercentilePlanExpression)(nil)
+
+func newApproxPercentilePlanExpression(arg types.PlanExpression, nthArg types.PlanExpression, returnDataType parser.ExprDataType) *approxPercentilePlanExpression {
+	return &approxPercentilePlanExpression{
+		arg:            arg,
+		nthArg:         nthArg,
+		returnDataType: returnDataType,
+	}
+}
+
+func (n *approxPercentilePlanExpression) Evaluate(currentRow []interface{}) (interface{}, error) {
+	return nil, sql3.NewErrInternalf("this should never be called")
+}
+
+// nth returns the percentile to estimate.
+func (n *approxPercentilePlanExpression) nth() (float64, error) {
+	nthValue, err := n.nthArg.Evaluate(nil)
+	if err != nil {
+		return 0, err
+	}
+	coercedNthValue, err := coerceValue(n.nthArg.Type(), parser.NewDataTypeDecimal(4), nthValue, parser.Pos{Line: 0, Column: 0})
+	if err != nil {
+		return 0, err
+	}
+	nth, ok := coercedNthValue.(pql.Decimal)
+	if !ok {
+		return 0, sql3.NewErrInternalf("unexpected aggregate nth arg type '%T'", coercedNthValue)
+	}
+	return nth.Float64(), nil
+}
+
+func (n *approxPercentilePlanExpression) NewBuffer() (types.AggregationBuffer, error) {
+	nth, err := n.nth()
+	if err != nil {
+		return nil, err
+	}
+	return NewAggApproxPercentileBuffer(n, nth), nil
+}
+
+func (n *approxPercentilePlanExpression) sketchCall(field string) (*pql.Call, error) {
+	return &pql.Call{
+		Name: "Sketch",
+		Args: map[string]interface{}{
+			"field": field,
+			"type":  pilosa.SketchTypeTDigest,
+		},
+	}, nil
+}
+
+func (n *approxPercentilePlanExpression) evalSketch(s *pilosa.Sketch) (interface{}, error) {
+	if s.TDigest == nil {
+		return nil, sql3.NewErrInternalf("unexpected sketch type '%s'", s.Type())
+	}
+	nth, err := n.nth()
+	if err != nil {
+		return nil, err
+	}
+	return n.evalDigest(s.TDigest, nth)
+}
+
+func (n *approxPercentilePlanExpression) FirstChildExpr() types.PlanExpression {
+	return n.arg
+}
+
+func (n *approxPercentilePlanExpression) Type() parser.ExprDataType {
+	return n.returnDataType
+}
+
+func (n *approxPercentilePlanExpression) String() string {
+	return fmt.Sprintf("approx_percentile(%s, %s)", n.arg.String(), n.nthArg.String())
+}
+
+func (n *approxPercentilePlanExpression) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_expr"] = fmt.Sprintf("%T", n)
+	result["description"] = n.String()
+	result["dataType"] = n.Type().TypeDescription()
+	result["arg"] = n.arg.Plan()
+	result["ntharg"] = n.nthArg.Plan()
+	result["sketch"] = n.sketchPlan()
+	return result
+}
+
+func (n *approxPercentilePlanExpression) sketchPlan() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "t-digest",
+		"compression": pilosa.TDigestCompression,
+	}
+}
+
+func (n *approxPercentilePlanExpression) Children() []types.PlanExpression {
+	return []types.PlanExpression{
+		n.arg,
+		n.nthArg,
+	}
+}
+
+func (n *approxPercentilePlanExpression) WithChildren(children ...types.PlanExpression) (types.PlanExpression, error) {
+	if len(children) != 2 {
+		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
+	}
+	return newApproxPercentilePlanExpression(children[0], children[1], n.returnDataType), nil
+}
+
+// minTopKCapacity is the smallest number of values tracked by the sketch
+// backing APPROX_TOP_K(); at least 10*k values are tracked.
+const minTopKCapacity = 100
+
+// topKCapacity returns the capacity of the sketch used to find the top k
+// values.
+func topKCapacity(k int) int {
+	if c := k * 10; c > minTopKCapacity {
+		return c
+	}
+	return minTopKCapacity
+}
+
+// aggregator for APPROX_TOP_K()
+type aggregateApproxTopK struct {
+	expr   *approxTopKPlanExpression
+	k      int
+	sketch *pilosa.TopKSketch
+}
+
+func NewAggApproxTopKBuffer(child *approxTopKPlanExpression, k int) *aggregateApproxTopK {
+	return &aggregateApproxTopK{
+		expr:   child,
+		k:      k,
+		sketch: pilosa.NewTopKSketch(topKCapacity(k)),
+	}
+}
+
+func (m *aggregateApproxTopK) Update(ctx context.Context, row types.Row) error {
+	v, err := m.expr.arg.Evaluate(row)
+	if err != nil {
+		return err
+	}
+
+	// skip if nil
+	if v == nil {
+		return nil
+	}
+
+	switch v.(type) {
+	case int64, string:
+		m.sketch.Add(v)
+	default:
+		return sql3.NewErrInternalf("unexpected type conversion '%T'", v)
+	}
+	return nil
+}
+
+func (m *aggregateApproxTopK) Eval(ctx context.Context) (interface{}, error) {
+	return m.expr.evalTop(m.sketch.Top(m.k))
+}
+
+// evalTop returns the values of top, as a set of the aggregate's type.
+func (n *approxTopKPlanExpression) evalTop(top []pilosa.TopKItem) (interface{}, error) {
+	switch dataType := n.returnDataType.(type) {
+	case *parser.DataTypeStringSet:
+		result := make([]string, 0, len(top))
+		for _, item := range top {
+			v, ok := item.Value.(string)
+			if !ok {
+				return nil, sql3.NewErrInternalf("unexpected top value type '%T'", item.Value)
+			}
+			result = append(result, v)
+		}
+		return result, nil
+	case *parser.DataTypeIDSet:
+		result := make([]int64, 0, len(top))
+		for _, item := range top {
+			v, ok := item.Value.(int64)
+			if !ok {
+				return nil, sql3.NewErrInternalf("unexpected top value type '%T'", item.Value)
+			}
+			result = append(result, v)
+		}
+		return result, nil
+	default:
+		return nil, sql3.NewErrInternalf("unhandled aggregate expression datatype '%T'", dataType)
+	}
+}
+
+// approxTopKPlanExpression handles APPROX_TOP_K()
+type approxTopKPlanExpression struct {
+	arg            types.PlanExpression
+	kArg           types.PlanExpression
+	returnDataType parser.ExprDataType
+}
+
+var _ types.Aggregable = (*approxTopKPlanExpression)(nil)
+
+func newApproxTopKPlanExpression(arg types.PlanExpression, kArg types.PlanExpression, returnDataType parser.ExprDataType) *approxTopKPlanExpression {
+	return &approxTopKPlanExpression{
+		arg:            arg,
+		kArg:           kArg,
+		returnDataType: returnDataType,
+	}
+}
+
+func (n *approxTopKPlanExpression) Evaluate(currentRow []interface{}) (interface{}, error) {
+	return nil, sql3.NewErrInternalf("this should never be called")
+}
+
+func (n *approxTopKPlanExpression) k() (int, error) {
+	kValue, err := n.kArg.Evaluate(nil)
+	if err != nil {
+		return 0, err
+	}
+	k, ok := kValue.(int64)
+	if !ok {
+		return 0, sql3.NewErrInternalf("unexpected aggregate k arg type '%T'", kValue)
+	}
+	return int(k), nil
+}
+
+func (n *approxTopKPlanExpression) NewBuffer() (types.AggregationBuffer, error) {
+	k, err := n.k()
+	if err != nil {
+		return nil, err
+	}
+	return NewAggApproxTopKBuffer(n, k), nil
+}
+
+func (n *approxTopKPlanExpression) sketchCall(field string) (*pql.Call, error) {
+	k, err := n.k()
+	if err != nil {
+		return nil, err
+	}
+	return &pql.Call{
+		Name: "Sketch",
+		Args: map[string]interface{}{
+			"field":    field,
+			"type":     pilosa.SketchTypeTopK,
+			"capacity": int64(topKCapacity(k)),
+		},
+	}, nil
+}
+
+func (n *approxTopKPlanExpression) evalSketch(s *pilosa.Sketch) (interface{}, error) {
+	if s.TopK == nil {
+		return nil, sql3.NewErrInternalf("unexpected sketch type '%s'", s.Type())
+	}
+	k, err := n.k()
+	if err != nil {
+		return nil, err
+	}
+	return n.evalTop(s.TopK.Top(k))
+}
+
+func (n *approxTopKPlanExpression) FirstChildExpr() types.PlanExpression {
+	return n.arg
+}
+
+func (n *approxTopKPlanExpression) Type() parser.ExprDataType {
+	return n.returnDataType
+}
+
+func (n *approxTopKPlanExpression) String() string {
+	return fmt.Sprintf("approx_top_k(%s, %s)", n.arg.String(), n.kArg.String())
+}
+
+func (n *approxTopKPlanExpression) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_expr"] = fmt.Sprintf("%T", n)
+	result["description"] = n.String()
+	result["dataType"] = n.Type().TypeDescription()
+	result["arg"] = n.arg.Plan()
+	result["karg"] = n.kArg.Plan()
+	result["sketch"] = n.sketchPlan()
+	return result
+}
+
+func (n *approxTopKPlanExpression) sketchPlan() map[string]interface{} {
+	sketch := map[string]interface{}{
+		"type": "space-saving",
+	}
+	if k, err := n.k(); err == nil {
+		sketch["capacity"] = topKCapacity(k)
+	}
+	return sketch
+}
+
+func (n *approxTopKPlanExpression) Children() []types.PlanExpression {
+	return []types.PlanExpression{
+		n.arg,
+		n.kArg,
+	}
+}
+
+func (n *approxTopKPlanExpression) WithChildren(children ...types.PlanExpression) (types.PlanExpression, error) {
+	if len(children) != 2 {
+		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
+	}
+	return newApproxTopKPlanExpression(children[0], children[1], n.returnDataType), nil
+}
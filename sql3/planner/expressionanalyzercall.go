@@ -4,6 +4,7 @@ package planner
 
 import (
 	"context"
+	"strconv"
 	"strings"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
@@ -12,6 +13,24 @@ import (
 )
 
 // analyze a *parser.Call and return the parser.Expr
+//
+// Besides the aggregates and user defined functions, the scalar functions
+// which can be used in a projection are:
+//
+//   - string: REVERSE, CHAR, ASCII, UPPER, LOWER, STRINGSPLIT, SUBSTRING,
+//     REPLACEALL, TRIM, RTRIM, LTRIM, PREFIX, SUFFIX, SPACE, LEN, REPLICATE,
+//     FORMAT, CHARINDEX and STR
+//   - date and time: DATETIMEPART, DATETIMENAME, DATE_TRUNC, TOTIMESTAMP,
+//     DATETIMEFROMPARTS, DATETIMEADD and DATETIMEDIFF
+//   - set and time quantum: SETCONTAINS, SETCONTAINSANY, SETCONTAINSALL and
+//     RANGEQ
+//
+// along with the arithmetic, comparison, bitwise and concatenation (||)
+// operators, CASE and CAST. A scalar function is NULL if any of the values
+// it's applied to is NULL; see binOpPlanExpression for arithmetic overflow and
+// division by zero. Every one of them is evaluated on the node coordinating the
+// query, once the rows read from the compute nodes are merged (see
+// projectionEvaluatedMerge).
 func (p *ExecutionPlanner) analyzeCallExpression(ctx context.Context, call *parser.Call, scope parser.Statement) (parser.Expr, error) {
 	//analyze all the args
 	for i, a := range call.Args {
@@ -189,6 +208,97 @@ func (p *ExecutionPlanner) analyzeCallExpression(ctx context.Context, call *pars
 		// return the data type of the referenced column
 		call.ResultDataType = parser.NewDataTypeDecimal(6)
 
+	case "APPROX_COUNT_DISTINCT":
+		// can't do this on a *
+		if call.Star.IsValid() && len(call.Args) == 0 {
+			return nil, sql3.NewErrExpectedColumnReference(call.Star.Line, call.Star.Column)
+		}
+
+		if len(call.Args) != 1 {
+			return nil, sql3.NewErrCallParameterCountMismatch(call.Rparen.Line, call.Rparen.Column, call.Name.Name, 1, len(call.Args))
+		}
+
+		// make sure the arg is the right type
+		arg1 := call.Args[0]
+		if !(typeIsInteger(arg1.DataType()) || typeIsDecimal(arg1.DataType()) || typeIsTimestamp(arg1.DataType()) || typeIsString(arg1.DataType())) {
+			return nil, sql3.NewErrIntOrDecimalOrTimestampOrStringExpressionExpected(arg1.Pos().Line, arg1.Pos().Column)
+		}
+
+		// an estimated count is always an int
+		call.ResultDataType = parser.NewDataTypeInt()
+
+	case "APPROX_PERCENTILE":
+		// can't do this on a *
+		if call.Star.IsValid() && len(call.Args) == 0 {
+			return nil, sql3.NewErrExpectedColumnReference(call.Star.Line, call.Star.Column)
+		}
+
+		if len(call.Args) != 2 {
+			return nil, sql3.NewErrCallParameterCountMismatch(call.Rparen.Line, call.Rparen.Column, call.Name.Name, 2, len(call.Args))
+		}
+
+		// if it is a ref, we shouldn't do a percentile on the _id
+		arg1 := call.Args[0]
+		ref, ok := arg1.(*parser.QualifiedRef)
+		if ok && strings.EqualFold(ref.Column.Name, string(dax.PrimaryKeyFieldName)) {
+			return nil, sql3.NewErrIdColumnNotValidForAggregateFunction(arg1.Pos().Line, arg1.Pos().Column, call.Name.Name)
+		}
+
+		// make sure the arg is the right type
+		if !(typeIsInteger(arg1.DataType()) || typeIsDecimal(arg1.DataType())) {
+			return nil, sql3.NewErrIntOrDecimalExpressionExpected(arg1.Pos().Line, arg1.Pos().Column)
+		}
+
+		// second arg is the nth value
+		targetType := parser.NewDataTypeDecimal(4)
+		if !typesAreAssignmentCompatible(targetType, call.Args[1].DataType()) {
+			return nil, sql3.NewErrParameterTypeMistmatch(call.Args[1].Pos().Line, call.Args[1].Pos().Column, targetType.TypeDescription(), call.Args[1].DataType().TypeDescription())
+		}
+
+		// make sure it's literal
+		if !call.Args[1].IsLiteral() {
+			return nil, sql3.NewErrLiteralExpected(call.Args[1].Pos().Line, call.Args[1].Pos().Column)
+		}
+
+		// return the data type of the arg
+		call.ResultDataType = arg1.DataType()
+
+	case "APPROX_TOP_K":
+		// can't do this on a *
+		if call.Star.IsValid() && len(call.Args) == 0 {
+			return nil, sql3.NewErrExpectedColumnReference(call.Star.Line, call.Star.Column)
+		}
+
+		if len(call.Args) != 2 {
+			return nil, sql3.NewErrCallParameterCountMismatch(call.Rparen.Line, call.Rparen.Column, call.Name.Name, 2, len(call.Args))
+		}
+
+		// make sure the arg is the right type
+		arg1 := call.Args[0]
+		if !(typeIsInteger(arg1.DataType()) || typeIsString(arg1.DataType())) {
+			return nil, sql3.NewErrIntOrStringExpressionExpected(arg1.Pos().Line, arg1.Pos().Column)
+		}
+
+		// second arg is k, which must be a positive integer literal
+		arg2 := call.Args[1]
+		if !arg2.IsLiteral() {
+			return nil, sql3.NewErrLiteralExpected(arg2.Pos().Line, arg2.Pos().Column)
+		}
+		lit, ok := arg2.(*parser.IntegerLit)
+		if !ok {
+			return nil, sql3.NewErrIntegerLiteral(arg2.Pos().Line, arg2.Pos().Column)
+		}
+		if k, err := strconv.ParseInt(lit.Value, 10, 64); err != nil || k <= 0 {
+			return nil, sql3.NewErrIntegerLiteral(arg2.Pos().Line, arg2.Pos().Column)
+		}
+
+		// the top values are returned as a set
+		if typeIsString(arg1.DataType()) {
+			call.ResultDataType = parser.NewDataTypeStringSet()
+		} else {
+			call.ResultDataType = parser.NewDataTypeIDSet()
+		}
+
 	case "MIN", "MAX":
 		// can't do an min/max on a *
 		if call.Star.IsValid() && len(call.Args) == 0 {
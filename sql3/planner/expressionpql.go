@@ -14,7 +14,32 @@ import (
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 )
 
-// generatePQLCallFromExpr returns a *pql.Call tree for a given plan expression
+// generatePQLCallFromExpr returns a *pql.Call tree for a given plan expression.
+// Filters which can be translated are pushed down into the table scan and
+// evaluated as bitmap operations on each shard; any which can't (the call
+// returns an error) are instead evaluated row by row over the extracted
+// rows. The operators which are translated, by column type, are:
+//
+//   - int, decimal, timestamp: =, <>, <, <=, >, >=, BETWEEN, NOT BETWEEN, and
+//     IS [NOT] NULL. These are range queries against the column's BSI
+//     bitmaps, costing one bitmap operation per bit of the column's bit depth
+//     regardless of how many rows match. NOT BETWEEN is the union of two
+//     ranges.
+//   - id, string: = and IN, which read one bitmap per value, and IS [NOT]
+//     NULL. On _id, = and IN become ConstRow(). Range comparisons aren't
+//     translated, as id and string values have no order in the bitmaps.
+//   - idset, stringset: SETCONTAINS, SETCONTAINSALL and SETCONTAINSANY, which
+//     read one bitmap per value.
+//   - bool: =, and IS [NOT] NULL.
+//   - time quantum (idset and stringset with a quantum): RANGEQ(col, from,
+//     to), which becomes Rows(col, from=, to=) and reads only the views
+//     for the quantum's units of time covering the range, rather than the
+//     column's standard view. RANGEQ restricts the values returned for col
+//     to those set within the range.
+//
+// AND and OR of translated filters become Intersect() and Union(). The
+// translated filters are shown as pqlFilter and pqlTqfilters in the
+// output of EXPLAIN; a pqlFilter of All() means every row is scanned.
 func (p *ExecutionPlanner) generatePQLCallFromExpr(ctx context.Context, expr types.PlanExpression) (_ *pql.Call, err error) {
 	if expr == nil {
 		return nil, nil
@@ -233,32 +258,7 @@ func (p *ExecutionPlanner) generatePQLCallFromExpr(ctx context.Context, expr typ
 		}
 		return call, nil
 	case *betweenOpPlanExpression:
-		lhs, ok := expr.lhs.(*qualifiedRefPlanExpression)
-		if !ok {
-			return nil, sql3.NewErrInternalf("expected expression type: planner.qualifiedRefPlanExpression got:%T", expr.lhs)
-		}
-		rexp, ok := expr.rhs.(*rangePlanExpression)
-		if !ok {
-			return nil, sql3.NewErrInternalf("expected expression type: planner.rangePlanExpression got:%T", expr.rhs)
-		}
-		lower, err := planExprToValue(rexp.lhs)
-		if err != nil {
-			return nil, err
-		}
-		upper, err := planExprToValue(rexp.rhs)
-		if err != nil {
-			return nil, err
-		}
-		call := &pql.Call{
-			Name: "Row",
-			Args: map[string]interface{}{
-				lhs.columnName: &pql.Condition{
-					Op:    pql.BETWEEN,
-					Value: []interface{}{lower, upper},
-				},
-			},
-		}
-		return call, nil
+		return p.generatePQLCallFromBetweenExpr(ctx, expr)
 	default:
 		return nil, sql3.NewErrInternalf("unexpected expression type: %T", expr)
 	}
@@ -578,6 +578,80 @@ func (p *ExecutionPlanner) generatePQLCallFromBinaryExpr(ctx context.Context, ex
 	}
 }
 
+// generatePQLCallFromBetweenExpr returns a *pql.Call for a BETWEEN or NOT
+// BETWEEN expression. BETWEEN becomes a single range Row() call, and NOT
+// BETWEEN becomes the Union() of the two ranges either side of it, so that,
+// as in SQL, a null value matches neither.
+func (p *ExecutionPlanner) generatePQLCallFromBetweenExpr(ctx context.Context, expr *betweenOpPlanExpression) (*pql.Call, error) {
+	lhs, ok := expr.lhs.(*qualifiedRefPlanExpression)
+	if !ok {
+		return nil, sql3.NewErrInternalf("expected expression type: planner.qualifiedRefPlanExpression got:%T", expr.lhs)
+	}
+	rexp, ok := expr.rhs.(*rangePlanExpression)
+	if !ok {
+		return nil, sql3.NewErrInternalf("expected expression type: planner.rangePlanExpression got:%T", expr.rhs)
+	}
+
+	switch typ := expr.lhs.Type().(type) {
+	case *parser.DataTypeInt, *parser.DataTypeDecimal, *parser.DataTypeTimestamp:
+	case *parser.DataTypeID:
+		return nil, sql3.NewErrUnsupported(0, 0, false, "range queries on id typed columns")
+	default:
+		return nil, sql3.NewErrInternalf("unsupported type for between expression: %v (%T)", typ, typ)
+	}
+
+	lower, err := planExprToRangeValue(expr.lhs.Type(), rexp.lhs)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := planExprToRangeValue(expr.lhs.Type(), rexp.rhs)
+	if err != nil {
+		return nil, err
+	}
+
+	row := func(cond *pql.Condition) *pql.Call {
+		return &pql.Call{
+			Name: "Row",
+			Args: map[string]interface{}{
+				lhs.columnName: cond,
+			},
+		}
+	}
+	if expr.op == parser.NOTBETWEEN {
+		return &pql.Call{
+			Name: "Union",
+			Children: []*pql.Call{
+				row(&pql.Condition{Op: pql.LT, Value: lower}),
+				row(&pql.Condition{Op: pql.GT, Value: upper}),
+			},
+		}, nil
+	}
+	return row(&pql.Condition{
+		Op:    pql.BETWEEN,
+		Value: []interface{}{lower, upper},
+	}), nil
+}
+
+// planExprToRangeValue converts a literal bound of a range predicate against
+// a column of type typ to the value a PQL condition expects.
+func planExprToRangeValue(typ parser.ExprDataType, expr types.PlanExpression) (interface{}, error) {
+	pqlValue, err := planExprToValue(expr)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := typ.(*parser.DataTypeDecimal); !ok {
+		return pqlValue, nil
+	}
+	switch val := pqlValue.(type) {
+	case float64:
+		return pql.FromFloat64(val), nil
+	case int64:
+		return pql.FromInt64(val, 0), nil
+	default:
+		return nil, sql3.NewErrInternalf("unexpected type '%T", pqlValue)
+	}
+}
+
 // sqlToPQLOp converts a parser operation token to PQL.
 func sqlToPQLOp(op parser.Token) (pql.Token, error) {
 	switch op {
@@ -0,0 +1,381 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// A materialized view is a view whose result is stored in a table, which
+// queries against the view read instead of running the view's statement.
+//
+// The result is refreshed in full: the statement is run again, and its result
+// written to a new table, which replaces the previous one once it's complete.
+// Reads never refresh the result; they read whatever the view's table holds.
+// A view created WITH REFRESH is refreshed in the background, by whatever
+// calls RefreshMaterializedViews every sql3.ViewRefreshCheckInterval, so the result
+// read may be older than the refresh interval by up to that interval plus the
+// time the refresh takes. Any view is also refreshed by REFRESH MATERIALIZED
+// VIEW, and when it's altered. The time at which the result was computed is
+// the refreshed_at column of fb_materialized_views.
+//
+// Planners sharing a ViewRefresher don't run concurrent refreshes of the same
+// view: a refresh of a view which is already being refreshed waits for that
+// one, and returns its result.
+
+// materializedTablePrefix is the prefix of the names of the tables holding the
+// results of materialized views.
+const materializedTablePrefix = "fb_mv_"
+
+// materializeBatchSize is the number of rows of a view's result inserted into
+// its table at a time.
+const materializeBatchSize = 1000
+
+// materializedTableName returns the name of the table holding the result of
+// the view refreshed at the given time. The names of the tables of a view
+// order by the time of the refresh.
+func materializedTableName(viewName string, refreshedAt time.Time) string {
+	return fmt.Sprintf("%s%s_%019d", materializedTablePrefix, viewName, refreshedAt.UnixNano())
+}
+
+// ViewRefresher deduplicates the refreshes of materialized views by the
+// planners which share it.
+type ViewRefresher struct {
+	group singleflight.Group
+}
+
+// NewViewRefresher returns a new ViewRefresher.
+func NewViewRefresher() *ViewRefresher {
+	return &ViewRefresher{}
+}
+
+// SetViewRefresher makes p's refreshes of materialized views deduplicated
+// with those of the other planners sharing r. Only the views of planners with
+// the same scope are the same views; in DAX, the scope is the database.
+func (p *ExecutionPlanner) SetViewRefresher(r *ViewRefresher, scope string) {
+	p.viewRefresher = r
+	p.viewRefreshScope = scope
+}
+
+// due returns true if mv was created WITH REFRESH, and its refresh interval
+// has passed at now. A view without a result yet is still being created, and
+// is left to the CREATE.
+func (mv *materializedViewSystemObject) due(now time.Time) bool {
+	return mv.refreshInterval > 0 && mv.tableName != "" && now.Sub(mv.refreshedAt) >= mv.refreshInterval
+}
+
+var _ sql3.MaterializedViewRefresher = (*ExecutionPlanner)(nil)
+
+// RefreshMaterializedViews refreshes the materialized views which are due to
+// be refreshed at now. A view which fails to refresh keeps its previous
+// result until the next call; the first error is returned once the other
+// views have been refreshed.
+func (p *ExecutionPlanner) RefreshMaterializedViews(ctx context.Context, now time.Time) error {
+	mvs, err := p.getMaterializedViews(ctx)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, mv := range mvs {
+		if !mv.due(now) {
+			continue
+		}
+		err := func() error {
+			view, err := p.getViewByName(ctx, mv.name)
+			if err != nil {
+				return err
+			}
+			if view == nil {
+				// The view was dropped since it was listed.
+				return nil
+			}
+			// Each refresh is a query of its own.
+			requestID, err := uuid.NewV4()
+			if err != nil {
+				return err
+			}
+			return p.refreshMaterializedViewOnce(fbcontext.WithRequestID(ctx, requestID.String()), view, mv)
+		}()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// materializedViewStatement returns the statement which reads the result of
+// view from its table.
+func (p *ExecutionPlanner) materializedViewStatement(ctx context.Context, view *viewSystemObject, mv *materializedViewSystemObject) (*parser.SelectStatement, error) {
+	if mv.tableName == "" {
+		return nil, sql3.NewErrMaterializedViewNotRefreshed(0, 0, view.name)
+	}
+
+	tbl, err := p.schemaAPI.TableByName(ctx, dax.TableName(mv.tableName))
+	if err != nil {
+		if isTableNotFoundError(err) {
+			return nil, sql3.NewErrTableNotFound(0, 0, mv.tableName)
+		}
+		return nil, err
+	}
+
+	sel := &parser.SelectStatement{
+		Source: &parser.QualifiedTableName{
+			Name: &parser.Ident{Name: mv.tableName},
+		},
+	}
+	for _, fld := range tbl.Fields {
+		if mv.syntheticID && fld.Name == dax.PrimaryKeyFieldName {
+			continue
+		}
+		sel.Columns = append(sel.Columns, &parser.ResultColumn{
+			Expr: &parser.Ident{Name: string(fld.Name)},
+		})
+	}
+	return sel, nil
+}
+
+// refreshMaterializedViewOnce refreshes view like refreshMaterializedView,
+// unless it's already being refreshed by a planner sharing p's ViewRefresher,
+// in which case it waits for that refresh instead.
+func (p *ExecutionPlanner) refreshMaterializedViewOnce(ctx context.Context, view *viewSystemObject, mv *materializedViewSystemObject) error {
+	if p.viewRefresher == nil {
+		return p.refreshMaterializedView(ctx, view, mv)
+	}
+	_, err, _ := p.viewRefresher.group.Do(p.viewRefreshScope+"\x00"+view.name, func() (interface{}, error) {
+		return nil, p.refreshMaterializedView(ctx, view, mv)
+	})
+	return err
+}
+
+// refreshMaterializedView replaces the result of view, whose materialization
+// is mv, with the result of running view's statement now. If a refresh which
+// started later has already replaced the result, this one is discarded.
+func (p *ExecutionPlanner) refreshMaterializedView(ctx context.Context, view *viewSystemObject, mv *materializedViewSystemObject) error {
+	refreshedAt := time.Now().UTC()
+
+	ast, err := parser.NewParser(strings.NewReader(view.statement)).ParseStatement()
+	if err != nil {
+		return err
+	}
+	sel, ok := ast.(*parser.SelectStatement)
+	if !ok {
+		return sql3.NewErrInternalf("unexpected ast type")
+	}
+	if _, err := p.analyzeSelectStatement(ctx, sel); err != nil {
+		return err
+	}
+	op, err := p.compileSelectStatement(sel, false)
+	if err != nil {
+		return err
+	}
+	op, err = p.optimizePlan(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	// Create the table with a column for each column of the result. The
+	// result's _id column, if it has one, is the table's primary key;
+	// otherwise the rows are numbered.
+	tableName := materializedTableName(view.name, refreshedAt)
+	ct := &parser.CreateTableStatement{
+		Name: &parser.Ident{Name: tableName},
+	}
+	targetColumns := make([]*qualifiedRefPlanExpression, 0)
+	syntheticID := true
+	for i, col := range op.Schema() {
+		columnName := strings.ToLower(col.ColumnName)
+		if columnName == "" {
+			return sql3.NewErrMaterializedViewColumnName(0, 0, view.name, i+1)
+		}
+		typ, err := materializedColumnType(view.name, columnName, col.Type)
+		if err != nil {
+			return err
+		}
+		if columnName == string(dax.PrimaryKeyFieldName) {
+			switch typ.(type) {
+			case *parser.DataTypeID, *parser.DataTypeString:
+				syntheticID = false
+			default:
+				return sql3.NewErrTableIDColumnType(0, 0)
+			}
+		}
+		ct.Columns = append(ct.Columns, materializedColumnDefinition(columnName, typ))
+		targetColumns = append(targetColumns, newQualifiedRefPlanExpression(tableName, columnName, i, typ))
+	}
+	if syntheticID {
+		ct.Columns = append(ct.Columns, materializedColumnDefinition(string(dax.PrimaryKeyFieldName), parser.NewDataTypeID()))
+		targetColumns = append(targetColumns, newQualifiedRefPlanExpression(tableName, string(dax.PrimaryKeyFieldName), len(targetColumns), parser.NewDataTypeID()))
+	}
+
+	if err := p.analyzeCreateTableStatement(ct); err != nil {
+		return err
+	}
+	ctOp, err := p.compileCreateTableStatement(ctx, ct)
+	if err != nil {
+		return err
+	}
+	ctIter, err := ctOp.Iterator(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, err = ctIter.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+
+	// The table is dropped unless it replaces the previous result, even if
+	// ctx is cancelled (as it is when a refresher loses its grant), so that
+	// abandoned refreshes don't leave their tables behind.
+	installed := false
+	defer func() {
+		if !installed {
+			_ = p.dropMaterializedTable(uncancelledContext{ctx}, tableName)
+		}
+	}()
+
+	if err := p.materialize(ctx, op, tableName, targetColumns, syntheticID); err != nil {
+		return err
+	}
+
+	// Replace the previous result, unless a later refresh already has.
+	current, err := p.getMaterializedViewByName(ctx, view.name)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.tableName > tableName {
+		return nil
+	}
+	mv.tableName = tableName
+	mv.syntheticID = syntheticID
+	mv.refreshedAt = refreshedAt
+	if err := p.upsertMaterializedView(ctx, mv); err != nil {
+		return err
+	}
+	installed = true
+	if current != nil && current.tableName != "" {
+		return p.dropMaterializedTable(uncancelledContext{ctx}, current.tableName)
+	}
+	return nil
+}
+
+// uncancelledContext is a context holding the values of another, which is
+// never cancelled, for cleaning up after work which was.
+type uncancelledContext struct {
+	parent context.Context
+}
+
+func (uncancelledContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (uncancelledContext) Done() <-chan struct{}               { return nil }
+func (uncancelledContext) Err() error                          { return nil }
+func (c uncancelledContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// materialize inserts the rows returned by op into the table.
+func (p *ExecutionPlanner) materialize(ctx context.Context, op types.PlanOperator, tableName string, targetColumns []*qualifiedRefPlanExpression, syntheticID bool) error {
+	iter, err := op.Iterator(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	insertIter := &insertRowIter{
+		planner:       p,
+		tableName:     tableName,
+		targetColumns: targetColumns,
+	}
+	flush := func(batch [][]types.PlanExpression) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		insertIter.insertValues = batch
+		_, err := insertIter.Next(ctx)
+		if err != nil && err != types.ErrNoMoreRows {
+			return err
+		}
+		return nil
+	}
+
+	var id int64
+	batch := make([][]types.PlanExpression, 0, materializeBatchSize)
+	for {
+		row, err := iter.Next(ctx)
+		if err != nil {
+			if err == types.ErrNoMoreRows {
+				break
+			}
+			return err
+		}
+
+		tuple := make([]types.PlanExpression, len(targetColumns))
+		for i, v := range row {
+			tuple[i], err = newLiteralPlanExpressionFromValue(targetColumns[i].Type(), v)
+			if err != nil {
+				return err
+			}
+		}
+		if syntheticID {
+			id++
+			tuple[len(row)] = newIntLiteralPlanExpression(id)
+		}
+		batch = append(batch, tuple)
+
+		if len(batch) == materializeBatchSize {
+			if err := flush(batch); err != nil {
+				return err
+			}
+			batch = make([][]types.PlanExpression, 0, materializeBatchSize)
+		}
+	}
+	return flush(batch)
+}
+
+// dropMaterializedTable drops a table holding a result of a view. The table
+// may already have been dropped by a concurrent refresh.
+func (p *ExecutionPlanner) dropMaterializedTable(ctx context.Context, tableName string) error {
+	err := p.schemaAPI.DeleteTable(ctx, dax.TableName(tableName))
+	if err != nil && !isTableNotFoundError(err) {
+		return err
+	}
+	return nil
+}
+
+// materializedColumnType returns the type of the column holding values of
+// type typ. Time quantums aren't part of the values a query returns, so sets
+// with time quantums are stored as plain sets.
+func materializedColumnType(viewName, columnName string, typ parser.ExprDataType) (parser.ExprDataType, error) {
+	switch typ.(type) {
+	case *parser.DataTypeIDSetQuantum:
+		return parser.NewDataTypeIDSet(), nil
+	case *parser.DataTypeStringSetQuantum:
+		return parser.NewDataTypeStringSet(), nil
+	case *parser.DataTypeBool, *parser.DataTypeDecimal, *parser.DataTypeID, *parser.DataTypeIDSet,
+		*parser.DataTypeInt, *parser.DataTypeString, *parser.DataTypeStringSet, *parser.DataTypeTimestamp:
+		return typ, nil
+	default:
+		return nil, sql3.NewErrMaterializedViewColumnType(0, 0, viewName, columnName, typ.TypeDescription())
+	}
+}
+
+// materializedColumnDefinition returns the definition of a column of type typ.
+func materializedColumnDefinition(columnName string, typ parser.ExprDataType) *parser.ColumnDefinition {
+	def := &parser.ColumnDefinition{
+		Name: &parser.Ident{Name: columnName},
+		Type: &parser.Type{
+			Name: &parser.Ident{Name: typ.BaseTypeName()},
+		},
+	}
+	if dec, ok := typ.(*parser.DataTypeDecimal); ok {
+		def.Type.Scale = &parser.IntegerLit{Value: strconv.FormatInt(dec.Scale, 10)}
+	}
+	return def
+}
@@ -88,6 +88,20 @@ func (i *alterViewIter) Next(ctx context.Context) (types.Row, error) {
 		return nil, sql3.NewErrViewNotFound(0, 0, i.view.name)
 	}
 
+	// a materialized view is refreshed with the new statement before the
+	// statement is stored, so a statement which can't be materialized
+	// leaves the view as it was
+	mv, err := i.planner.getMaterializedViewByName(ctx, i.view.name)
+	if err != nil {
+		return nil, err
+	}
+	if mv != nil {
+		err = i.planner.refreshMaterializedView(ctx, i.view, mv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// now store the view into fb_views
 	err = i.planner.updateView(ctx, i.view)
 	if err != nil {
@@ -23,6 +23,7 @@ import (
 	"github.com/apache/arrow/go/v10/parquet/file"
 	"github.com/apache/arrow/go/v10/parquet/pqarrow"
 	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/pql"
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
@@ -142,12 +143,28 @@ func (p *PlanOpBulkInsert) Children() []types.PlanOperator {
 }
 
 func (p *PlanOpBulkInsert) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	// The defaults, computed values and NOT NULL constraints of the fields
+	// which aren't mapped are the same for every batch, so a missing NOT NULL
+	// column fails the statement before anything is read.
+	tbl, err := p.planner.schemaAPI.TableByName(ctx, dax.TableName(p.tableName))
+	if err != nil {
+		if isTableNotFoundError(err) {
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, p.tableName))
+		}
+		return nil, err
+	}
+	defaults, err := newFieldDefaults(tbl, p.options.targetColumns, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
 	switch strings.ToUpper(p.options.format) {
 	case "CSV":
 		return &bulkInsertLineRowIter{
 			planner:   p.planner,
 			tableName: p.tableName,
 			options:   p.options,
+			defaults:  defaults,
 			sourceIter: &bulkInsertSourceCSVRowIter{
 				planner: p.planner,
 				options: p.options,
@@ -159,6 +176,7 @@ func (p *PlanOpBulkInsert) Iterator(ctx context.Context, row types.Row) (types.R
 			planner:   p.planner,
 			tableName: p.tableName,
 			options:   p.options,
+			defaults:  defaults,
 			sourceIter: &bulkInsertSourceNDJsonRowIter{
 				planner: p.planner,
 				options: p.options,
@@ -169,6 +187,7 @@ func (p *PlanOpBulkInsert) Iterator(ctx context.Context, row types.Row) (types.R
 			planner:   p.planner,
 			tableName: p.tableName,
 			options:   p.options,
+			defaults:  defaults,
 			sourceIter: &bulkInsertSourceParquetRowIter{
 				planner: p.planner,
 				options: p.options,
@@ -368,6 +387,7 @@ type bulkInsertLineRowIter struct {
 	planner   *ExecutionPlanner
 	tableName string
 	options   *bulkInsertOptions
+	defaults  *fieldDefaults
 	linesRead int
 
 	currentBatch [][]interface{}
@@ -394,7 +414,7 @@ func (i *bulkInsertLineRowIter) Next(ctx context.Context) (types.Row, error) {
 		}
 		i.currentBatch = append(i.currentBatch, row)
 		if len(i.currentBatch) >= i.options.batchSize {
-			err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options)
+			err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options, i.defaults)
 			if err != nil {
 				return nil, err
 			}
@@ -407,7 +427,7 @@ func (i *bulkInsertLineRowIter) Next(ctx context.Context) (types.Row, error) {
 		}
 	}
 	if len(i.currentBatch) > 0 {
-		err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options)
+		err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options, i.defaults)
 		if err != nil {
 			return nil, err
 		}
@@ -776,6 +796,7 @@ type bulkInsertNDJsonRowIter struct {
 	planner   *ExecutionPlanner
 	tableName string
 	options   *bulkInsertOptions
+	defaults  *fieldDefaults
 	linesRead int
 
 	currentBatch [][]interface{}
@@ -802,7 +823,7 @@ func (i *bulkInsertNDJsonRowIter) Next(ctx context.Context) (types.Row, error) {
 		}
 		i.currentBatch = append(i.currentBatch, row)
 		if len(i.currentBatch) >= i.options.batchSize {
-			err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options)
+			err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options, i.defaults)
 			if err != nil {
 				return nil, err
 			}
@@ -815,7 +836,7 @@ func (i *bulkInsertNDJsonRowIter) Next(ctx context.Context) (types.Row, error) {
 		}
 	}
 	if len(i.currentBatch) > 0 {
-		err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options)
+		err := processBatch(ctx, i.planner, i.tableName, i.currentBatch, i.options, i.defaults)
 		if err != nil {
 			return nil, err
 		}
@@ -895,7 +916,33 @@ func processColumnValue(rawValue interface{}, targetType parser.ExprDataType) (t
 	}
 }
 
-func processBatch(ctx context.Context, planner *ExecutionPlanner, tableName string, currentBatch [][]interface{}, options *bulkInsertOptions) error {
+func processBatch(ctx context.Context, planner *ExecutionPlanner, tableName string, currentBatch [][]interface{}, options *bulkInsertOptions, defaults *fieldDefaults) error {
+	insertValues, err := bulkInsertValues(currentBatch, options)
+	if err != nil {
+		return err
+	}
+
+	insert := &insertRowIter{
+		planner:       planner,
+		tableName:     tableName,
+		targetColumns: defaults.targetColumns,
+		insertValues:  defaults.apply(insertValues),
+	}
+
+	_, err = insert.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+
+	// update the counter for bulk inserts
+	pilosa.PerfCounterSQLBulkInsertsSec.Add(int64(len(insertValues)))
+
+	return nil
+}
+
+// bulkInsertValues returns the tuples of values of options.targetColumns for
+// the rows of currentBatch.
+func bulkInsertValues(currentBatch [][]interface{}, options *bulkInsertOptions) ([][]types.PlanExpression, error) {
 	insertValues := [][]types.PlanExpression{}
 
 	// we're going to take a different path if transforms are specified
@@ -911,7 +958,7 @@ func processBatch(ctx context.Context, planner *ExecutionPlanner, tableName stri
 			for idx, mc := range options.transformExpressions {
 				rawValue, err := mc.Evaluate(row)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				// handle nulls
@@ -922,7 +969,7 @@ func processBatch(ctx context.Context, planner *ExecutionPlanner, tableName stri
 
 				tupleExpr, err := processColumnValue(rawValue, options.targetColumns[idx].dataType)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				tupleValues = append(tupleValues, tupleExpr)
 			}
@@ -940,7 +987,7 @@ func processBatch(ctx context.Context, planner *ExecutionPlanner, tableName stri
 			for idx, rawValue := range row {
 				tupleExpr, err := processColumnValue(rawValue, options.targetColumns[idx].dataType)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				tupleValues = append(tupleValues, tupleExpr)
 			}
@@ -948,22 +995,7 @@ func processBatch(ctx context.Context, planner *ExecutionPlanner, tableName stri
 		}
 	}
 
-	insert := &insertRowIter{
-		planner:       planner,
-		tableName:     tableName,
-		targetColumns: options.targetColumns,
-		insertValues:  insertValues,
-	}
-
-	_, err := insert.Next(ctx)
-	if err != nil && err != types.ErrNoMoreRows {
-		return err
-	}
-
-	// update the counter for bulk inserts
-	pilosa.PerfCounterSQLBulkInsertsSec.Add(int64(len(insertValues)))
-
-	return nil
+	return insertValues, nil
 }
 
 // /
@@ -0,0 +1,125 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+)
+
+// tableSchemaAPI is a SchemaAPI holding a single table.
+type tableSchemaAPI struct {
+	pilosa.SchemaAPI
+	tbl *dax.Table
+}
+
+func (s *tableSchemaAPI) TableByName(ctx context.Context, tname dax.TableName) (*dax.Table, error) {
+	return s.tbl, nil
+}
+
+func TestBulkInsertFieldDefaults(t *testing.T) {
+	tbl := &dax.Table{
+		Name: "t",
+		Fields: []*dax.Field{
+			{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID},
+			{Name: "region", Type: dax.BaseTypeString, Options: dax.FieldOptions{Default: "us-east"}},
+			{Name: "home", Type: dax.BaseTypeString, Options: dax.FieldOptions{Computed: "region"}},
+			{Name: "score", Type: dax.BaseTypeInt, Options: dax.FieldOptions{NotNull: true}},
+		},
+	}
+	p := &ExecutionPlanner{schemaAPI: &tableSchemaAPI{tbl: tbl}}
+
+	t.Run("Defaults", func(t *testing.T) {
+		options := &bulkInsertOptions{
+			format: "CSV",
+			targetColumns: []*qualifiedRefPlanExpression{
+				newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+				newQualifiedRefPlanExpression("t", "score", 3, parser.NewDataTypeInt()),
+			},
+		}
+		iter, err := NewPlanOpBulkInsert(p, "t", options).Iterator(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defaults := iter.(*bulkInsertLineRowIter).defaults
+
+		values, err := bulkInsertValues([][]interface{}{{int64(1), int64(10)}, {int64(2), nil}}, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tuples := defaults.apply(values)
+		for r, tuple := range tuples {
+			row := make(map[string]interface{})
+			for c, expr := range tuple {
+				v, err := expr.Evaluate(nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				row[defaults.targetColumns[c].columnName] = v
+			}
+			if row["region"] != "us-east" || row["home"] != "us-east" {
+				t.Fatalf("row %d: unexpected row: %v", r, row)
+			}
+		}
+	})
+
+	t.Run("NotNull", func(t *testing.T) {
+		options := &bulkInsertOptions{
+			format: "CSV",
+			targetColumns: []*qualifiedRefPlanExpression{
+				newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+				newQualifiedRefPlanExpression("t", "region", 1, parser.NewDataTypeString()),
+			},
+		}
+		_, err := NewPlanOpBulkInsert(p, "t", options).Iterator(context.Background(), nil)
+		if !errors.Is(err, sql3.ErrInsertNullValue) {
+			t.Fatalf("expected null value error, got %v", err)
+		}
+	})
+
+	// Computed timestamps are the time of the statement, not of each batch.
+	t.Run("Timestamp", func(t *testing.T) {
+		tbl := &dax.Table{
+			Name: "t",
+			Fields: []*dax.Field{
+				{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID},
+				{Name: "ingested", Type: dax.BaseTypeTimestamp, Options: dax.FieldOptions{Computed: dax.ComputedCurrentTimestamp}},
+			},
+		}
+		p := &ExecutionPlanner{schemaAPI: &tableSchemaAPI{tbl: tbl}}
+		options := &bulkInsertOptions{
+			format: "NDJSON",
+			targetColumns: []*qualifiedRefPlanExpression{
+				newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+			},
+		}
+		iter, err := NewPlanOpBulkInsert(p, "t", options).Iterator(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defaults := iter.(*bulkInsertNDJsonRowIter).defaults
+		var got []interface{}
+		for _, batch := range [][][]interface{}{{{int64(1)}}, {{int64(2)}}} {
+			values, err := bulkInsertValues(batch, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(time.Millisecond)
+			v, err := defaults.apply(values)[0][1].Evaluate(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, v)
+		}
+		if _, ok := got[0].(time.Time); !ok || got[0] != got[1] {
+			t.Fatalf("unexpected timestamps: %v", got)
+		}
+	})
+}
@@ -13,7 +13,6 @@ import (
 	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
-	"github.com/featurebasedb/featurebase/v3/pql"
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
@@ -181,68 +180,9 @@ func (i *copyIterator) Next(ctx context.Context) (types.Row, error) {
 
 			irow := make([]types.PlanExpression, len(row))
 			for i, s := range i.copySchema {
-				switch ty := s.Type.(type) {
-				case *parser.DataTypeID, *parser.DataTypeInt:
-					val, ok := row[i].(int64)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-					irow[i] = newIntLiteralPlanExpression(val)
-
-				case *parser.DataTypeDecimal:
-					val, ok := row[i].(pql.Decimal)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-					irow[i] = newFloatLiteralPlanExpression(val.String())
-
-				case *parser.DataTypeString:
-					val, ok := row[i].(string)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-					irow[i] = newStringLiteralPlanExpression(val)
-
-				case *parser.DataTypeBool:
-					val, ok := row[i].(bool)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-					irow[i] = newBoolLiteralPlanExpression(val)
-
-				case *parser.DataTypeTimestamp:
-					val, ok := row[i].(time.Time)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-					irow[i] = newTimestampLiteralPlanExpression(val)
-
-				case *parser.DataTypeStringSet:
-					val, ok := row[i].([]string)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-
-					members := make([]types.PlanExpression, 0)
-					for _, m := range val {
-						members = append(members, newStringLiteralPlanExpression(m))
-					}
-					irow[i] = newExprSetLiteralPlanExpression(members, parser.NewDataTypeStringSet())
-
-				case *parser.DataTypeIDSet:
-					val, ok := row[i].([]int64)
-					if !ok {
-						return nil, sql3.NewErrInternalf("unexpected type '%T'", row[i])
-					}
-
-					members := make([]types.PlanExpression, 0)
-					for _, m := range val {
-						members = append(members, newIntLiteralPlanExpression(m))
-					}
-					irow[i] = newExprSetLiteralPlanExpression(members, parser.NewDataTypeIDSet())
-
-				default:
-					return nil, sql3.NewErrInternalf("unhandled type '%T'", ty)
+				irow[i], err = newLiteralPlanExpressionFromValue(s.Type, row[i])
+				if err != nil {
+					return nil, err
 				}
 			}
 			insertBatch = append(insertBatch, irow)
@@ -5,6 +5,7 @@ package planner
 import (
 	"context"
 	"fmt"
+	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/dax"
@@ -15,27 +16,29 @@ import (
 
 // PlanOpCreateTable plan operator that creates a table.
 type PlanOpCreateTable struct {
-	planner       *ExecutionPlanner
-	tableName     string
-	failIfExists  bool
-	isKeyed       bool
-	keyPartitions int
-	description   string
-	columns       []*createTableField
-	warnings      []string
+	planner        *ExecutionPlanner
+	tableName      string
+	failIfExists   bool
+	isKeyed        bool
+	keyPartitions  int
+	description    string
+	snapshotMaxAge time.Duration
+	columns        []*createTableField
+	warnings       []string
 }
 
 // NewPlanOpCreateTable returns a new PlanOpCreateTable planoperator
-func NewPlanOpCreateTable(p *ExecutionPlanner, tableName string, failIfExists bool, isKeyed bool, keyPartitions int, description string, columns []*createTableField) *PlanOpCreateTable {
+func NewPlanOpCreateTable(p *ExecutionPlanner, tableName string, failIfExists bool, isKeyed bool, keyPartitions int, description string, snapshotMaxAge time.Duration, columns []*createTableField) *PlanOpCreateTable {
 	return &PlanOpCreateTable{
-		planner:       p,
-		tableName:     tableName,
-		failIfExists:  failIfExists,
-		isKeyed:       isKeyed,
-		keyPartitions: keyPartitions,
-		columns:       columns,
-		description:   description,
-		warnings:      make([]string, 0),
+		planner:        p,
+		tableName:      tableName,
+		failIfExists:   failIfExists,
+		isKeyed:        isKeyed,
+		keyPartitions:  keyPartitions,
+		columns:        columns,
+		description:    description,
+		snapshotMaxAge: snapshotMaxAge,
+		warnings:       make([]string, 0),
 	}
 }
 
@@ -69,13 +72,14 @@ func (p *PlanOpCreateTable) Children() []types.PlanOperator {
 
 func (p *PlanOpCreateTable) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
 	return &createTableRowIter{
-		planner:       p.planner,
-		tableName:     p.tableName,
-		failIfExists:  p.failIfExists,
-		isKeyed:       p.isKeyed,
-		keyPartitions: p.keyPartitions,
-		columns:       p.columns,
-		description:   p.description,
+		planner:        p.planner,
+		tableName:      p.tableName,
+		failIfExists:   p.failIfExists,
+		isKeyed:        p.isKeyed,
+		keyPartitions:  p.keyPartitions,
+		columns:        p.columns,
+		description:    p.description,
+		snapshotMaxAge: p.snapshotMaxAge,
 	}, nil
 }
 
@@ -84,13 +88,14 @@ func (p *PlanOpCreateTable) WithChildren(children ...types.PlanOperator) (types.
 }
 
 type createTableRowIter struct {
-	planner       *ExecutionPlanner
-	tableName     string
-	failIfExists  bool
-	isKeyed       bool
-	keyPartitions int
-	description   string
-	columns       []*createTableField
+	planner        *ExecutionPlanner
+	tableName      string
+	failIfExists   bool
+	isKeyed        bool
+	keyPartitions  int
+	description    string
+	snapshotMaxAge time.Duration
+	columns        []*createTableField
 }
 
 var _ types.RowIterator = (*createTableRowIter)(nil)
@@ -127,7 +132,8 @@ func (i *createTableRowIter) Next(ctx context.Context) (types.Row, error) {
 		// replace dax.DefaultPartitionN with i.keyPartitions.
 		PartitionN: dax.DefaultPartitionN,
 
-		Description: i.description,
+		Description:    i.description,
+		SnapshotMaxAge: i.snapshotMaxAge,
 	}
 
 	if err := i.planner.schemaAPI.CreateTable(ctx, tbl); err != nil {
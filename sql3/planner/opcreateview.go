@@ -116,5 +116,18 @@ func (i *createViewIter) Next(ctx context.Context) (types.Row, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	// materialize the view; if that fails, so does creating it
+	if mv := i.view.materialized; mv != nil {
+		err = i.planner.upsertMaterializedView(ctx, mv)
+		if err == nil {
+			err = i.planner.refreshMaterializedView(ctx, i.view, mv)
+		}
+		if err != nil {
+			_ = i.planner.deleteMaterializedView(ctx, i.view.name)
+			_ = i.planner.deleteView(ctx, i.view.name)
+			return nil, err
+		}
+	}
 	return nil, types.ErrNoMoreRows
 }
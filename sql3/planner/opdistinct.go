@@ -5,6 +5,7 @@ package planner
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/featurebasedb/featurebase/v3/bufferpool"
@@ -13,6 +14,46 @@ import (
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 )
 
+// DefaultDistinctMemoryLimit is the default size of the in-memory part of the
+// hash table of a DISTINCT.
+const DefaultDistinctMemoryLimit = 1 << 20
+
+// DistinctConfig limits the resources used by DISTINCT.
+//
+// The distinct rows are recorded in a hash table which is held in memory up
+// to MemoryLimit, with as much again cached by its buffer pool, and spills to
+// a temporary file beyond that, so a high-cardinality DISTINCT is slower, but
+// doesn't use more memory. The rows are recorded by a SHA-256 digest of their
+// values, so every row takes the same space in the table, however wide it is;
+// distinct rows whose digests collide would be collapsed, but the chance of
+// that is negligible. Since only the digests are kept, the spilled file holds
+// none of the values. A statement whose DISTINCT produces more than MaxRows
+// rows fails, rather than filling the disk.
+type DistinctConfig struct {
+	// MemoryLimit is the size, in bytes, of the in-memory part of the hash
+	// table. 0 uses DefaultDistinctMemoryLimit.
+	MemoryLimit int64 `toml:"memory-limit"`
+
+	// MaxRows is the most rows a DISTINCT may produce. 0 is unlimited.
+	MaxRows int64 `toml:"max-rows"`
+}
+
+// minDistinctPages is the fewest pages the buffer pool of the hash table can
+// work with: splitting a bucket pins it and the new bucket at once.
+const minDistinctPages = 4
+
+// pages returns the number of pages of the in-memory part of the hash table.
+func (c DistinctConfig) pages() int {
+	limit := c.MemoryLimit
+	if limit <= 0 {
+		limit = DefaultDistinctMemoryLimit
+	}
+	if n := int(limit / int64(bufferpool.PAGE_SIZE)); n > minDistinctPages {
+		return n
+	}
+	return minDistinctPages
+}
+
 // PlanOpDistinct plan operator handles DISTINCT
 // DISTINCT returns unique rows from its iterator and does this by
 // creating a hash table and probing new rows against that hash table,
@@ -20,12 +61,14 @@ import (
 // seen, a 'key' is created from all the values in the row and this is
 // inserted into the hash table.
 // The hash table is implemented using Extendible Hashing and is backed
-// by a buffer pool. The buffer pool is allocated to 128 pages (or 1Mb)
-// and the disk manager used by the buffer pool will use an in-memory
-// implementation up to 128 pages and thereafter spill to disk
+// by a buffer pool. The buffer pool and the in-memory part of the disk
+// manager it uses are each allocated the planner's DistinctConfig.MemoryLimit
+// (by default 1Mb, or 128 8K pages), beyond which the disk manager spills to
+// disk.
 type PlanOpDistinct struct {
 	planner  *ExecutionPlanner
 	ChildOp  types.PlanOperator
+	config   DistinctConfig
 	warnings []string
 }
 
@@ -33,6 +76,7 @@ func NewPlanOpDistinct(p *ExecutionPlanner, child types.PlanOperator) *PlanOpDis
 	return &PlanOpDistinct{
 		planner:  p,
 		ChildOp:  child,
+		config:   p.distinct,
 		warnings: make([]string, 0),
 	}
 }
@@ -46,7 +90,7 @@ func (p *PlanOpDistinct) Iterator(ctx context.Context, row types.Row) (types.Row
 	if err != nil {
 		return nil, err
 	}
-	return newDistinctIterator(p.Schema(), i), nil
+	return newDistinctIterator(p.Schema(), i, p.config), nil
 }
 
 func (p *PlanOpDistinct) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
@@ -66,6 +110,8 @@ func (p *PlanOpDistinct) Plan() map[string]interface{} {
 	result := make(map[string]interface{})
 	result["_op"] = fmt.Sprintf("%T", p)
 	result["_schema"] = p.Schema().Plan()
+	result["memoryLimit"] = p.config.pages() * bufferpool.PAGE_SIZE
+	result["maxRows"] = p.config.MaxRows
 	result["child"] = p.ChildOp.Plan()
 	return result
 }
@@ -88,14 +134,17 @@ func (p *PlanOpDistinct) Warnings() []string {
 type distinctIterator struct {
 	child      types.RowIterator
 	schema     types.Schema
+	config     DistinctConfig
 	hasStarted *struct{}
 	hashTable  *extendiblehash.ExtendibleHashTable
+	rows       int64
 }
 
-func newDistinctIterator(schema types.Schema, child types.RowIterator) *distinctIterator {
+func newDistinctIterator(schema types.Schema, child types.RowIterator, config DistinctConfig) *distinctIterator {
 	return &distinctIterator{
 		schema: schema,
 		child:  child,
+		config: config,
 	}
 }
 
@@ -103,11 +152,13 @@ func (i *distinctIterator) rowSeen(ctx context.Context, row types.Row) (bool, er
 	keyBytes := generateRowKey(row)
 	_, found, err := i.hashTable.Get(keyBytes)
 	if err != nil {
-		return false, nil
+		return false, err
 	}
 	// put the row in the hash table to recored that we've seen it
 	if !found {
-		i.hashTable.Put(keyBytes, []byte{1})
+		if err := i.hashTable.Put(keyBytes, []byte{1}); err != nil {
+			return false, err
+		}
 	}
 	return found, nil
 }
@@ -116,14 +167,17 @@ func (i *distinctIterator) Next(ctx context.Context) (types.Row, error) {
 	if i.hasStarted == nil {
 		//create the hashtable
 
-		// ask the diskmanager to spill after 1Mb (128 8K pages)
-		diskManager := bufferpool.NewInMemDiskSpillingDiskManager(128)
-		// use 1Mb (128 8K pages)
-		bufferPool := bufferpool.NewBufferPool(128, diskManager)
+		// ask the diskmanager to spill after the memory limit (by default
+		// 1Mb, or 128 8K pages), and give the buffer pool as much again
+		pages := i.config.pages()
+		diskManager := bufferpool.NewInMemDiskSpillingDiskManager(pages)
+		bufferPool := bufferpool.NewBufferPool(pages, diskManager)
 
-		// we're going to use something pretty conservative here - we could have sets, strings, ... all sorts of stuff
-		// and we don't yet support long key overflowing
-		keyLength := 128 // bytes
+		// keys are digests, so they're all the same length, however
+		// wide the rows are - but the hash table doesn't count the
+		// lengths it stores with each key when it decides a page is
+		// full, so leave room for those
+		keyLength := 2 * sha256.Size // bytes
 
 		valueLength := 1 // we're going to store a 1 (byte) for every key in the table
 
@@ -149,20 +203,32 @@ func (i *distinctIterator) Next(ctx context.Context) (types.Row, error) {
 		// does row exist in hash table
 		seen, err := i.rowSeen(ctx, row)
 		if err != nil {
+			i.hashTable.Close()
 			return nil, err
 		}
 		// if we've seen it before, go to the next row
 		if seen {
 			continue
 		}
+		i.rows++
+		if i.config.MaxRows > 0 && i.rows > i.config.MaxRows {
+			i.hashTable.Close()
+			return nil, sql3.NewErrDistinctRowLimitExceeded(i.config.MaxRows)
+		}
 		return row, nil
 	}
 }
 
+// generateRowKey returns the key of row in the hash table: a digest of its
+// values.
 func generateRowKey(row types.Row) []byte {
 	var buf bytes.Buffer
 	for _, v := range row {
 		buf.WriteString(fmt.Sprintf("%#v", v))
+		// separate the values, so that ("ab", "c") and ("a", "bc")
+		// have different keys
+		buf.WriteByte(0)
 	}
-	return buf.Bytes()
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:]
 }
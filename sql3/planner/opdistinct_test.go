@@ -0,0 +1,95 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/bufferpool"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// rowSliceIterator returns each of its rows in turn.
+type rowSliceIterator struct {
+	rows []types.Row
+}
+
+func (i *rowSliceIterator) Next(ctx context.Context) (types.Row, error) {
+	if len(i.rows) == 0 {
+		return nil, types.ErrNoMoreRows
+	}
+	row := i.rows[0]
+	i.rows = i.rows[1:]
+	return row, nil
+}
+
+func Test_distinctIterator(t *testing.T) {
+	ctx := context.Background()
+	wide := strings.Repeat("x", 1000)
+	drain := func(cfg DistinctConfig, rows ...types.Row) ([]types.Row, error) {
+		iter := newDistinctIterator(nil, &rowSliceIterator{rows: rows}, cfg)
+		var out []types.Row
+		for {
+			row, err := iter.Next(ctx)
+			if err == types.ErrNoMoreRows {
+				return out, nil
+			} else if err != nil {
+				return out, err
+			}
+			out = append(out, row)
+		}
+	}
+
+	out, err := drain(DistinctConfig{},
+		types.Row{int64(1), "a"},
+		types.Row{int64(1), "a"},
+		types.Row{wide + "1"},
+		types.Row{wide + "2"},
+		types.Row{wide + "1"},
+		types.Row{"ab", "c"},
+		types.Row{"a", "bc"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 5 {
+		t.Fatalf("expected 5 distinct rows, got %d: %v", len(out), out)
+	}
+
+	// Enough rows to spill past a single page of memory.
+	var rows []types.Row
+	for i := 0; i < 2000; i++ {
+		rows = append(rows, types.Row{int64(i % 1000)})
+	}
+	out, err = drain(DistinctConfig{MemoryLimit: 1}, rows...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1000 {
+		t.Fatalf("expected 1000 distinct rows, got %d", len(out))
+	}
+
+	_, err = drain(DistinctConfig{MaxRows: 999}, rows...)
+	if !errors.Is(err, sql3.ErrDistinctRowLimitExceeded) {
+		t.Fatalf("expected a row limit error, got %v", err)
+	}
+}
+
+func TestDistinctConfig_pages(t *testing.T) {
+	for _, tt := range []struct {
+		limit int64
+		pages int
+	}{
+		{0, DefaultDistinctMemoryLimit / bufferpool.PAGE_SIZE},
+		{1, minDistinctPages},
+		{16 * int64(bufferpool.PAGE_SIZE), 16},
+	} {
+		if got := (DistinctConfig{MemoryLimit: tt.limit}).pages(); got != tt.pages {
+			t.Errorf("limit %d: expected %d pages, got %d", tt.limit, tt.pages, got)
+		}
+	}
+}
@@ -98,5 +98,23 @@ func (i *dropViewRowIter) Next(ctx context.Context) (types.Row, error) {
 		return nil, err
 	}
 
+	// drop the materialization of a materialized view
+	mv, err := i.planner.getMaterializedViewByName(ctx, i.viewName)
+	if err != nil {
+		return nil, err
+	}
+	if mv != nil {
+		err = i.planner.deleteMaterializedView(ctx, i.viewName)
+		if err != nil {
+			return nil, err
+		}
+		if mv.tableName != "" {
+			err = i.planner.dropMaterializedTable(ctx, mv.tableName)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return nil, types.ErrNoMoreRows
 }
@@ -18,15 +18,63 @@ import (
 	"github.com/pkg/errors"
 )
 
-// PlanOpInsert plan operator to handle INSERT.
+// PlanOpInsert plan operator to handle INSERT and UPSERT.
+//
+// An UPSERT writes only the columns it's given, to a record which is created
+// if it doesn't exist; the other columns of an existing record are left
+// unchanged. A record which doesn't exist yet is given the defaults and
+// computed values of its omitted columns, and NOT NULL is enforced, as they
+// are by INSERT. Unlike INSERT, the values given for set columns replace a
+// record's existing values rather than being added to them. Values given for
+// time quantum columns are still added, so that their history is kept. NULL
+// values aren't allowed, since there'd be no way to tell leaving a column
+// unchanged from clearing it.
+//
+// All the columns written to a record are written in one transaction on the
+// record's shard, so an UPSERT is atomic per record. Concurrent UPSERTs to the
+// same record are applied one after the other; where they write the same
+// column, the last one applied wins, and where they write different columns,
+// both writes are kept.
+//
+// An INSERT or UPSERT of a single record can be made conditional on the
+// record's existing values with an IF clause, e.g.
+//
+//	UPSERT INTO t (_id, a) VALUES (1, 10) IF a = 5 AND b IS NULL
+//
+// The conditions are checked in the same transaction as the write, so the
+// record can't change in between: the write is applied only if every
+// condition holds, and otherwise nothing is written and the statement fails
+// with ErrWriteConflict, giving the actual value of each column whose
+// condition didn't hold.
 type PlanOpInsert struct {
 	planner       *ExecutionPlanner
 	tableName     string
 	targetColumns []*qualifiedRefPlanExpression
 	insertValues  [][]types.PlanExpression
+	upsert        bool
+	conditions    []*writeCondition
 	warnings      []string
 }
 
+// writeCondition is a term of the condition of a conditional write: that the
+// column has the given value, or, if absent is true, no value.
+type writeCondition struct {
+	columnName string
+	absent     bool
+	value      types.PlanExpression
+}
+
+func (c *writeCondition) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["column"] = c.columnName
+	if c.absent {
+		result["absent"] = true
+	} else {
+		result["value"] = c.value.Plan()
+	}
+	return result
+}
+
 func NewPlanOpInsert(p *ExecutionPlanner, tableName string, targetColumns []*qualifiedRefPlanExpression, insertValues [][]types.PlanExpression) *PlanOpInsert {
 	return &PlanOpInsert{
 		planner:       p,
@@ -48,6 +96,14 @@ func (p *PlanOpInsert) Plan() map[string]interface{} {
 	}
 	result["targetColumns"] = ps
 	result["insertTupleCount"] = len(p.insertValues)
+	result["upsert"] = p.upsert
+	if len(p.conditions) > 0 {
+		cs := make([]interface{}, 0, len(p.conditions))
+		for _, c := range p.conditions {
+			cs = append(cs, c.Plan())
+		}
+		result["conditions"] = cs
+	}
 	return result
 }
 
@@ -77,11 +133,16 @@ func (p *PlanOpInsert) Iterator(ctx context.Context, row types.Row) (types.RowIt
 		tableName:     p.tableName,
 		targetColumns: p.targetColumns,
 		insertValues:  p.insertValues,
+		upsert:        p.upsert,
+		conditions:    p.conditions,
 	}, nil
 }
 
 func (p *PlanOpInsert) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
-	return NewPlanOpInsert(p.planner, p.tableName, p.targetColumns, p.insertValues), nil
+	op := NewPlanOpInsert(p.planner, p.tableName, p.targetColumns, p.insertValues)
+	op.upsert = p.upsert
+	op.conditions = p.conditions
+	return op, nil
 }
 
 type insertRowIter struct {
@@ -89,11 +150,139 @@ type insertRowIter struct {
 	tableName     string
 	targetColumns []*qualifiedRefPlanExpression
 	insertValues  [][]types.PlanExpression
+	upsert        bool
+	conditions    []*writeCondition
 }
 
 var _ types.RowIterator = (*insertRowIter)(nil)
 
 func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
+	tname := dax.TableName(i.tableName)
+	tbl, err := i.planner.schemaAPI.TableByName(ctx, tname)
+	if err != nil {
+		if isTableNotFoundError(err) {
+			return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, i.tableName))
+		}
+		return nil, err
+	}
+
+	// Populate any fields omitted from the statement which have a default or
+	// computed value. An upsert leaves the omitted fields of existing records
+	// unchanged, so only new records are populated.
+	now := time.Now().UTC()
+	if !i.upsert {
+		targetColumns, insertValues, err := applyFieldDefaults(tbl, i.targetColumns, i.insertValues, now)
+		if err != nil {
+			return nil, err
+		}
+		return i.write(ctx, tbl, targetColumns, insertValues)
+	}
+
+	existing, added, err := i.splitExisting(ctx, tbl)
+	if err != nil {
+		return nil, err
+	}
+	if len(added) > 0 {
+		targetColumns, insertValues, err := applyFieldDefaults(tbl, i.targetColumns, added, now)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := i.write(ctx, tbl, targetColumns, insertValues); err != types.ErrNoMoreRows {
+			return nil, err
+		}
+	}
+	if len(existing) > 0 {
+		return i.write(ctx, tbl, i.targetColumns, existing)
+	}
+	return nil, types.ErrNoMoreRows
+}
+
+// splitExisting returns the tuples of i.insertValues whose records exist in
+// tbl, followed by those whose records don't.
+func (i *insertRowIter) splitExisting(ctx context.Context, tbl *dax.Table) (existing, added [][]types.PlanExpression, err error) {
+	posID := -1
+	for j, col := range i.targetColumns {
+		if strings.EqualFold(col.columnName, string(dax.PrimaryKeyFieldName)) {
+			posID = j
+			break
+		}
+	}
+	if posID < 0 {
+		return nil, nil, sql3.NewErrInternalf("no _id column in upsert")
+	}
+
+	// ids holds the record ID of each tuple, as it's returned by Extract: a
+	// string key or a uint64 ID.
+	ids := make([]interface{}, len(i.insertValues))
+	for j, tuple := range i.insertValues {
+		eval, err := tuple[posID].Evaluate(nil)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "evaluating record id: %v", tuple[posID])
+		}
+		switch id := eval.(type) {
+		case string, uint64:
+			ids[j] = id
+		case int64:
+			if id < 0 {
+				return nil, nil, sql3.NewErrInternalf("_id value cannot be negative: %d", id)
+			}
+			ids[j] = uint64(id)
+		default:
+			return nil, nil, sql3.NewErrInternalf("unexpected record id type '%T'", eval)
+		}
+	}
+
+	call := &pql.Call{
+		Name: "Extract",
+		Children: []*pql.Call{{
+			Name: "Intersect",
+			Children: []*pql.Call{
+				{Name: "All"},
+				{
+					Name: "ConstRow",
+					Args: map[string]interface{}{"columns": ids},
+					Type: pql.PrecallGlobal,
+				},
+			},
+		}},
+	}
+	queryResponse, err := i.planner.executor.Execute(ctx, tbl, &pql.Query{Calls: []*pql.Call{call}}, nil, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "finding existing records")
+	}
+	extbl, ok := queryResponse.Results[0].(pilosa.ExtractedTable)
+	if !ok {
+		return nil, nil, sql3.NewErrInternalf("unexpected Extract() result type: %T", queryResponse.Results[0])
+	}
+	found := make(map[interface{}]struct{}, len(extbl.Columns))
+	for _, col := range extbl.Columns {
+		if col.Column.Keyed {
+			found[col.Column.Key] = struct{}{}
+		} else {
+			found[col.Column.ID] = struct{}{}
+		}
+	}
+
+	for j, tuple := range i.insertValues {
+		if _, ok := found[ids[j]]; ok {
+			existing = append(existing, tuple)
+		} else {
+			added = append(added, tuple)
+		}
+	}
+	return existing, added, nil
+}
+
+// write writes the records whose columns, targetColumns, have the values of
+// the tuples of insertValues.
+func (i *insertRowIter) write(ctx context.Context, tbl *dax.Table, targetColumns []*qualifiedRefPlanExpression, insertValues [][]types.PlanExpression) (types.Row, error) {
+	notNull := make(map[string]bool)
+	for _, fld := range tbl.Fields {
+		if fld.Options.NotNull {
+			notNull[string(fld.Name)] = true
+		}
+	}
+
 	// posID is the position of the "_id" column in both the targetColumns and
 	// values lists.
 	var posID int
@@ -109,15 +298,15 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 	// VALUES positions (0,2,3) to row.Values (0, 1, 2). Note, the _id position
 	// (shown as 1* in the example above) isn't used because we handle it
 	// separately.
-	posVals := make([]int, len(i.targetColumns))
+	posVals := make([]int, len(targetColumns))
 
 	var foundPosID bool
-	for j := range i.targetColumns {
+	for j := range targetColumns {
 		if foundPosID {
 			posVals[j] = j - 1
 			continue
 		}
-		if strings.EqualFold(i.targetColumns[j].columnName, string(dax.PrimaryKeyFieldName)) {
+		if strings.EqualFold(targetColumns[j].columnName, string(dax.PrimaryKeyFieldName)) {
 			posID = j
 			foundPosID = true
 		}
@@ -127,17 +316,12 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 	// batchSize is currently set to the size of the entire
 	// VALUES list. In the future we may want to break this up into smaller
 	// batches.
-	batchSize := len(i.insertValues)
+	batchSize := len(insertValues)
 
 	// idxInfoBase is the full IndexInfo stored in the schema. The instance of
 	// IndexInfo used in the import (and created below) will be based on the
 	// information from idxInfoBase, but the fields may be a limited subset, and
 	// may be in a different order.
-	tname := dax.TableName(i.tableName)
-	tbl, err := i.planner.schemaAPI.TableByName(ctx, tname)
-	if err != nil {
-		return nil, sql3.NewErrTableNotFound(0, 0, i.tableName)
-	}
 	idxInfoBase := pilosa.TableToIndexInfo(tbl)
 
 	// idxInfo is a subset of idxInfoBase, containing only those fields included
@@ -147,13 +331,13 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 		Name:       idxInfoBase.Name,
 		CreatedAt:  idxInfoBase.CreatedAt,
 		Options:    idxInfoBase.Options,
-		Fields:     make([]*pilosa.FieldInfo, len(i.targetColumns)-1),
+		Fields:     make([]*pilosa.FieldInfo, len(targetColumns)-1),
 		ShardWidth: idxInfoBase.ShardWidth,
 	}
 
-	// Set up Fields based on i.targetColumns.
+	// Set up Fields based on targetColumns.
 	var counter int
-	for ii, targetColumn := range i.targetColumns {
+	for ii, targetColumn := range targetColumns {
 		// Skip the "_id" column.
 		if ii == posID {
 			continue
@@ -164,6 +348,7 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 
 	batch, err := fbbatch.NewBatch(i.planner.importer, batchSize, tbl, idxInfo.Fields,
 		fbbatch.OptUseShardTransactionalEndpoint(true),
+		fbbatch.OptReplaceSets(i.upsert),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "setting up batch")
@@ -178,9 +363,9 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 
 	// Initialize row.Values to the size of the target columns, but exclude the
 	// record ID ("_id") since that's stored in row.ID.
-	row.Values = make([]interface{}, len(i.targetColumns)-1)
+	row.Values = make([]interface{}, len(targetColumns)-1)
 
-	for rowNumber, tuple := range i.insertValues {
+	for rowNumber, tuple := range insertValues {
 		// Evaluate and set the record ID.
 		if eval, err := tuple[posID].Evaluate(nil); err != nil {
 			return nil, errors.Wrapf(err, "evaluating record id: %v", tuple[posID])
@@ -219,6 +404,12 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 			}
 
 			columnName := idxInfo.Fields[posVals[idx]].Name
+			if eval == nil && i.upsert {
+				return nil, sql3.NewErrUpsertNullValue(0, 0, columnName, rowNumber+1)
+			}
+			if eval == nil && notNull[columnName] {
+				return nil, sql3.NewErrInsertNullValue(0, 0, columnName, rowNumber+1)
+			}
 
 			// batch.Add does not typically look at field type to determine how
 			// to handle a particular value in a row. Instead, it uses value
@@ -294,7 +485,7 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 					row.Time = qrowTime
 
 					// second member must be a set of the correct type
-					targetCol := i.targetColumns[idx]
+					targetCol := targetColumns[idx]
 
 					switch targetCol.Type().(type) {
 					case *parser.DataTypeStringSetQuantum:
@@ -442,7 +633,18 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 
 	count := batch.Len()
 
+	// A conditional write has a single row, whose ID is still in row.ID.
+	if len(i.conditions) > 0 {
+		if err := i.addWriteConditions(ctx, tbl, idxInfoBase, batch, row.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := batch.Import(); err != nil {
+		var conflict pilosa.ConflictError
+		if len(i.conditions) > 0 && errors.As(err, &conflict) {
+			return nil, sql3.NewErrWriteConflict(0, 0, i.tableName, conflict.Error())
+		}
 		return nil, errors.Wrap(err, "importing batch")
 	}
 
@@ -451,3 +653,294 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 
 	return nil, types.ErrNoMoreRows
 }
+
+// addWriteConditions adds the conditions of a conditional write to the
+// record with the given ID to batch, converting their values to the form in
+// which they're stored.
+func (i *insertRowIter) addWriteConditions(ctx context.Context, tbl *dax.Table, idxInfo *pilosa.IndexInfo, batch *fbbatch.Batch, id interface{}) error {
+	var col uint64
+	switch v := id.(type) {
+	case uint64:
+		col = v
+	case string:
+		ids, err := i.planner.importer.CreateTableKeys(ctx, tbl.ID, v)
+		if err != nil {
+			return errors.Wrap(err, "translating record id")
+		}
+		col = ids[v]
+	default:
+		return sql3.NewErrInternalf("unexpected record id type '%T'", id)
+	}
+
+	for _, c := range i.conditions {
+		fld := idxInfo.Field(c.columnName)
+		if fld == nil {
+			return sql3.NewErrColumnNotFound(0, 0, c.columnName)
+		}
+		cond := pilosa.WriteCondition{Field: fld.Name, Column: col, Absent: c.absent}
+		if !c.absent {
+			eval, err := c.value.Evaluate(nil)
+			if err != nil {
+				return errors.Wrapf(err, "evaluating condition value: %v", c.value)
+			}
+			if err := i.setConditionValue(ctx, tbl, fld, &cond, eval); err != nil {
+				return err
+			}
+		}
+		if err := batch.AddWriteCondition(cond); err != nil {
+			return errors.Wrap(err, "adding write condition")
+		}
+	}
+	return nil
+}
+
+// setConditionValue sets the value of cond to eval, a value for fld, in the
+// form it's stored in: the value of an int-like field, or the row IDs of a
+// set-like one.
+func (i *insertRowIter) setConditionValue(ctx context.Context, tbl *dax.Table, fld *pilosa.FieldInfo, cond *pilosa.WriteCondition, eval interface{}) error {
+	opts := fld.Options
+	switch opts.Type {
+	case pilosa.FieldTypeInt:
+		v, ok := eval.(int64)
+		if !ok {
+			return sql3.NewErrInternalf("unexpected type %v", eval)
+		}
+		cond.Value = v
+
+	case pilosa.FieldTypeDecimal:
+		switch v := eval.(type) {
+		case pql.Decimal:
+			cond.Value = v.ToInt64(opts.Scale)
+		case int64:
+			cond.Value = pql.NewDecimal(v, 0).ToInt64(opts.Scale)
+		default:
+			return sql3.NewErrInternalf("unexpected type %v", eval)
+		}
+
+	case pilosa.FieldTypeTimestamp:
+		var ts time.Time
+		switch v := eval.(type) {
+		case time.Time:
+			ts = v
+		case string:
+			var err error
+			if ts, err = timestampFromString(v); err != nil {
+				return errors.Wrapf(err, "parsing timestamp: %s", v)
+			}
+		case int64:
+			// As for the values written, an integer is a number of seconds.
+			ts = time.Unix(v, 0).UTC()
+		default:
+			return sql3.NewErrInternalf("unsupported timestamp type: %T", eval)
+		}
+		cond.Value = pilosa.TimestampToVal(opts.TimeUnit, ts)
+
+	case pilosa.FieldTypeBool:
+		v, ok := eval.(bool)
+		if !ok {
+			return sql3.NewErrInternalf("unexpected type %v", eval)
+		}
+		// The rows of a bool field are 0 for false and 1 for true.
+		if v {
+			cond.Rows = []uint64{1}
+		} else {
+			cond.Rows = []uint64{0}
+		}
+
+	case pilosa.FieldTypeSet, pilosa.FieldTypeMutex, pilosa.FieldTypeTime:
+		switch v := eval.(type) {
+		case int64:
+			if v < 0 {
+				return sql3.NewErrInternalf("converting negative value to uint64: %d", v)
+			}
+			cond.Rows = []uint64{uint64(v)}
+		case []int64:
+			cond.Rows = make([]uint64, len(v))
+			for j := range v {
+				if v[j] < 0 {
+					return sql3.NewErrInternalf("converting negative slice value to uint64: %d", v[j])
+				}
+				cond.Rows[j] = uint64(v[j])
+			}
+		case string:
+			return i.setConditionKeys(ctx, tbl, fld, cond, []string{v})
+		case []string:
+			return i.setConditionKeys(ctx, tbl, fld, cond, v)
+		default:
+			return sql3.NewErrInternalf("unexpected type %v", eval)
+		}
+
+	default:
+		return sql3.NewErrInternalf("unsupported field type for write condition: %s", opts.Type)
+	}
+	return nil
+}
+
+// setConditionKeys sets the rows of cond to the IDs of keys. Keys are only
+// looked up, never created, so that a write which isn't applied leaves no
+// keys behind. Since no record can have a key which doesn't exist, a
+// condition on one can't hold, and a write conflict is returned.
+func (i *insertRowIter) setConditionKeys(ctx context.Context, tbl *dax.Table, fld *pilosa.FieldInfo, cond *pilosa.WriteCondition, keys []string) error {
+	ids, err := i.planner.importer.FindFieldKeys(ctx, tbl.ID, dax.FieldName(fld.Name), keys...)
+	if err != nil {
+		return errors.Wrapf(err, "translating keys of column '%s'", fld.Name)
+	}
+	cond.Rows = make([]uint64, len(keys))
+	for j, key := range keys {
+		id, ok := ids[key]
+		if !ok {
+			conflict := pilosa.WriteConflictError{Conflicts: []string{fmt.Sprintf("%s has no value '%s'", fld.Name, key)}}
+			return sql3.NewErrWriteConflict(0, 0, i.tableName, conflict.Error())
+		}
+		cond.Rows[j] = id
+	}
+	return nil
+}
+
+// applyFieldDefaults returns targetColumns and insertValues extended with the
+// fields of tbl which are omitted from targetColumns but have a default or
+// computed value (as described by dax.Table.ValidateFieldDefault). now is the
+// value of CURRENT_TIMESTAMP. It returns an error if a NOT NULL field is
+// omitted and has neither.
+func applyFieldDefaults(tbl *dax.Table, targetColumns []*qualifiedRefPlanExpression, insertValues [][]types.PlanExpression, now time.Time) ([]*qualifiedRefPlanExpression, [][]types.PlanExpression, error) {
+	if len(insertValues) == 0 {
+		return targetColumns, insertValues, nil
+	}
+	d, err := newFieldDefaults(tbl, targetColumns, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.targetColumns, d.apply(insertValues), nil
+}
+
+// fieldDefaults holds the columns added to records written to a table for the
+// fields omitted from them which have a default or computed value.
+type fieldDefaults struct {
+	// targetColumns are the columns of the records, followed by the added
+	// columns.
+	targetColumns []*qualifiedRefPlanExpression
+
+	// exprs holds the expression appended to every tuple for each added
+	// column. Computed values which copy another column have a nil expression
+	// and the position of that column in srcs.
+	exprs []types.PlanExpression
+	srcs  []int
+}
+
+// newFieldDefaults returns the fieldDefaults of records with targetColumns
+// written to tbl, as described by applyFieldDefaults.
+func newFieldDefaults(tbl *dax.Table, targetColumns []*qualifiedRefPlanExpression, now time.Time) (*fieldDefaults, error) {
+	colPos := make(map[string]int, len(targetColumns))
+	for pos, col := range targetColumns {
+		colPos[strings.ToLower(col.columnName)] = pos
+	}
+
+	type fieldAt struct {
+		fld *dax.Field
+		idx int
+	}
+	var defaulted, computed []fieldAt
+	for idx, fld := range tbl.Fields {
+		if fld.IsPrimaryKey() || strings.EqualFold("_exists", string(fld.Name)) {
+			continue
+		}
+		if _, ok := colPos[strings.ToLower(string(fld.Name))]; ok {
+			continue
+		}
+		switch {
+		case fld.Options.Default != "":
+			defaulted = append(defaulted, fieldAt{fld, idx})
+		case fld.Options.Computed != "":
+			computed = append(computed, fieldAt{fld, idx})
+		case fld.Options.NotNull:
+			return nil, sql3.NewErrInsertNullValue(0, 0, string(fld.Name), 1)
+		}
+	}
+
+	d := &fieldDefaults{
+		targetColumns: make([]*qualifiedRefPlanExpression, len(targetColumns), len(targetColumns)+len(defaulted)+len(computed)),
+	}
+	copy(d.targetColumns, targetColumns)
+	addColumn := func(f fieldAt, expr types.PlanExpression, src int) {
+		colPos[strings.ToLower(string(f.fld.Name))] = len(d.targetColumns)
+		d.targetColumns = append(d.targetColumns, newQualifiedRefPlanExpression(string(tbl.Name), string(f.fld.Name), f.idx, fieldSQLDataType(pilosa.FieldToFieldInfo(f.fld))))
+		d.exprs = append(d.exprs, expr)
+		d.srcs = append(d.srcs, src)
+	}
+
+	// Defaults are added first, so that computed values can copy them.
+	for _, f := range defaulted {
+		val, err := f.fld.DefaultValue()
+		if err != nil {
+			return nil, sql3.NewErrInternalf("default for column '%s': %v", f.fld.Name, err)
+		}
+		expr, err := defaultValuePlanExpression(val)
+		if err != nil {
+			return nil, err
+		}
+		addColumn(f, expr, -1)
+	}
+	for _, f := range computed {
+		if strings.EqualFold(f.fld.Options.Computed, dax.ComputedCurrentTimestamp) {
+			addColumn(f, newTimestampLiteralPlanExpression(now), -1)
+		} else if src, ok := colPos[strings.ToLower(f.fld.Options.Computed)]; ok {
+			addColumn(f, nil, src)
+		} else {
+			// The source column is omitted too, so there's nothing to copy.
+			addColumn(f, newNullLiteralPlanExpression(), -1)
+		}
+	}
+	return d, nil
+}
+
+// apply returns insertValues, whose tuples hold the values of the records'
+// columns, extended with the values of the added columns.
+func (d *fieldDefaults) apply(insertValues [][]types.PlanExpression) [][]types.PlanExpression {
+	if len(d.exprs) == 0 {
+		return insertValues
+	}
+	values := make([][]types.PlanExpression, len(insertValues))
+	for r, tuple := range insertValues {
+		vals := make([]types.PlanExpression, len(tuple), len(d.targetColumns))
+		copy(vals, tuple)
+		for k, expr := range d.exprs {
+			if d.srcs[k] >= 0 {
+				expr = vals[d.srcs[k]]
+			}
+			vals = append(vals, expr)
+		}
+		values[r] = vals
+	}
+	return values
+}
+
+// defaultValuePlanExpression returns a literal expression for a value
+// returned by dax.Field.DefaultValue.
+func defaultValuePlanExpression(val interface{}) (types.PlanExpression, error) {
+	switch v := val.(type) {
+	case int64:
+		return newIntLiteralPlanExpression(v), nil
+	case pql.Decimal:
+		return newFloatLiteralPlanExpression(v.String()), nil
+	case bool:
+		return newBoolLiteralPlanExpression(v), nil
+	case string:
+		return newStringLiteralPlanExpression(v), nil
+	case time.Time:
+		return newTimestampLiteralPlanExpression(v), nil
+	case []int64:
+		members := make([]types.PlanExpression, len(v))
+		for i := range v {
+			members[i] = newIntLiteralPlanExpression(v[i])
+		}
+		return newExprSetLiteralPlanExpression(members, parser.NewDataTypeIDSet()), nil
+	case []string:
+		members := make([]types.PlanExpression, len(v))
+		for i := range v {
+			members[i] = newStringLiteralPlanExpression(v[i])
+		}
+		return newExprSetLiteralPlanExpression(members, parser.NewDataTypeStringSet()), nil
+	default:
+		return nil, sql3.NewErrInternalf("unexpected default value type '%T'", val)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+func Test_applyFieldDefaults(t *testing.T) {
+	tbl := &dax.Table{
+		Name: "t",
+		Fields: []*dax.Field{
+			{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID},
+			{Name: "region", Type: dax.BaseTypeString, Options: dax.FieldOptions{Default: "us-east"}},
+			{Name: "ingested", Type: dax.BaseTypeTimestamp, Options: dax.FieldOptions{Computed: dax.ComputedCurrentTimestamp}},
+			{Name: "home", Type: dax.BaseTypeString, Options: dax.FieldOptions{Computed: "region"}},
+			{Name: "score", Type: dax.BaseTypeInt, Options: dax.FieldOptions{NotNull: true}},
+		},
+	}
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// evaluate returns the value of each column for each tuple.
+	evaluate := func(t *testing.T, cols []*qualifiedRefPlanExpression, tuples [][]types.PlanExpression) []map[string]interface{} {
+		t.Helper()
+		out := make([]map[string]interface{}, len(tuples))
+		for r, tuple := range tuples {
+			if len(tuple) != len(cols) {
+				t.Fatalf("row %d: expected %d values, got %d", r, len(cols), len(tuple))
+			}
+			out[r] = make(map[string]interface{})
+			for c, expr := range tuple {
+				v, err := expr.Evaluate(nil)
+				if err != nil {
+					t.Fatalf("evaluating %s: %v", cols[c].columnName, err)
+				}
+				out[r][cols[c].columnName] = v
+			}
+		}
+		return out
+	}
+
+	t.Run("Omitted", func(t *testing.T) {
+		cols := []*qualifiedRefPlanExpression{
+			newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+			newQualifiedRefPlanExpression("t", "score", 4, parser.NewDataTypeInt()),
+		}
+		tuples := [][]types.PlanExpression{
+			{newIntLiteralPlanExpression(1), newIntLiteralPlanExpression(10)},
+		}
+		gotCols, gotTuples, err := applyFieldDefaults(tbl, cols, tuples, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cols) != 2 || len(tuples[0]) != 2 {
+			t.Fatal("arguments were modified")
+		}
+		row := evaluate(t, gotCols, gotTuples)[0]
+		if row["region"] != "us-east" || row["home"] != "us-east" || row["ingested"] != now {
+			t.Fatalf("unexpected row: %v", row)
+		}
+	})
+
+	t.Run("Provided", func(t *testing.T) {
+		cols := []*qualifiedRefPlanExpression{
+			newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+			newQualifiedRefPlanExpression("t", "region", 1, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("t", "score", 4, parser.NewDataTypeInt()),
+		}
+		tuples := [][]types.PlanExpression{
+			{newIntLiteralPlanExpression(1), newStringLiteralPlanExpression("eu-west"), newIntLiteralPlanExpression(10)},
+		}
+		gotCols, gotTuples, err := applyFieldDefaults(tbl, cols, tuples, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := evaluate(t, gotCols, gotTuples)[0]
+		if row["region"] != "eu-west" || row["home"] != "eu-west" {
+			t.Fatalf("unexpected row: %v", row)
+		}
+	})
+
+	t.Run("NotNull", func(t *testing.T) {
+		cols := []*qualifiedRefPlanExpression{
+			newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+			newQualifiedRefPlanExpression("t", "region", 1, parser.NewDataTypeString()),
+		}
+		tuples := [][]types.PlanExpression{
+			{newIntLiteralPlanExpression(1), newStringLiteralPlanExpression("eu-west")},
+		}
+		_, _, err := applyFieldDefaults(tbl, cols, tuples, now)
+		if !errors.Is(err, sql3.ErrInsertNullValue) {
+			t.Fatalf("expected null value error, got %v", err)
+		}
+	})
+}
+
+// extractExecutor is an Executor whose Extract() queries return the records
+// with the given IDs.
+type extractExecutor struct {
+	ids []uint64
+}
+
+func (e *extractExecutor) Execute(ctx context.Context, tbl dax.TableKeyer, q *pql.Query, shards []uint64, opt *pilosa.ExecOptions) (pilosa.QueryResponse, error) {
+	var extbl pilosa.ExtractedTable
+	for _, id := range e.ids {
+		extbl.Columns = append(extbl.Columns, pilosa.ExtractedTableColumn{Column: pilosa.KeyOrID{ID: id}})
+	}
+	return pilosa.QueryResponse{Results: []interface{}{extbl}}, nil
+}
+
+func TestUpsertNewRecords(t *testing.T) {
+	tbl := &dax.Table{
+		Name: "t",
+		Fields: []*dax.Field{
+			{Name: dax.PrimaryKeyFieldName, Type: dax.BaseTypeID},
+			{Name: "region", Type: dax.BaseTypeString, Options: dax.FieldOptions{Default: "us-east"}},
+			{Name: "score", Type: dax.BaseTypeInt, Options: dax.FieldOptions{NotNull: true}},
+		},
+	}
+	p := &ExecutionPlanner{
+		executor:  &extractExecutor{ids: []uint64{1}},
+		schemaAPI: &tableSchemaAPI{tbl: tbl},
+	}
+	cols := []*qualifiedRefPlanExpression{
+		newQualifiedRefPlanExpression("t", "_id", 0, parser.NewDataTypeID()),
+		newQualifiedRefPlanExpression("t", "region", 1, parser.NewDataTypeString()),
+	}
+	tuples := [][]types.PlanExpression{
+		{newIntLiteralPlanExpression(1), newStringLiteralPlanExpression("eu-west")},
+		{newIntLiteralPlanExpression(2), newStringLiteralPlanExpression("eu-west")},
+	}
+	op := NewPlanOpInsert(p, "t", cols, tuples)
+	op.upsert = true
+	iter, err := op.Iterator(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Split", func(t *testing.T) {
+		existing, added, err := iter.(*insertRowIter).splitExisting(context.Background(), tbl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(existing) != 1 || existing[0][0] != tuples[0][0] {
+			t.Fatalf("unexpected existing records: %v", existing)
+		}
+		if len(added) != 1 || added[0][0] != tuples[1][0] {
+			t.Fatalf("unexpected new records: %v", added)
+		}
+	})
+
+	// Record 2 doesn't exist, so it must be given a score.
+	t.Run("NotNull", func(t *testing.T) {
+		if _, err := iter.Next(context.Background()); !errors.Is(err, sql3.ErrInsertNullValue) {
+			t.Fatalf("expected null value error, got %v", err)
+		}
+	})
+}
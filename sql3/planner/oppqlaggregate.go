@@ -43,6 +43,10 @@ func (p *PlanOpPQLAggregate) Plan() map[string]interface{} {
 		result["filter"] = p.filter.Plan()
 	}
 	result["aggregate"] = p.aggregate.String()
+	if agg, ok := p.aggregate.(sketchAggregate); ok {
+		// the sketch is built by the computers and merged in the queryer
+		result["sketch"] = agg.sketchPlan()
+	}
 	return result
 
 }
@@ -119,7 +123,7 @@ func (i *pqlAggregateRowIter) Next(ctx context.Context) (types.Row, error) {
 			return nil, sql3.NewErrInternalf("unexpected aggregate expression type '%T'", i.aggregate.FirstChildExpr())
 		}
 
-		switch i.aggregate.(type) {
+		switch agg := i.aggregate.(type) {
 		case *countDistinctPlanExpression:
 			//make a distinct call
 			distinctCond := &pql.Call{
@@ -241,13 +245,25 @@ func (i *pqlAggregateRowIter) Next(ctx context.Context) (types.Row, error) {
 				call.Args["filter"] = cond
 			}
 
+		case sketchAggregate:
+			call, err = agg.sketchCall(expr.columnName)
+			if err != nil {
+				return nil, err
+			}
+			if cond != nil {
+				call.Children = []*pql.Call{cond}
+			}
+
 		default:
 			return nil, sql3.NewErrInternalf("unhandled aggregate type '%T'", i.aggregate)
 		}
 
 		tbl, err := i.planner.schemaAPI.TableByName(ctx, dax.TableName(i.tableName))
 		if err != nil {
-			return nil, sql3.NewErrTableNotFound(0, 0, i.tableName)
+			if isTableNotFoundError(err) {
+				return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, i.tableName))
+			}
+			return nil, err
 		}
 
 		queryResponse, err := i.planner.executor.Execute(ctx, tbl, &pql.Query{Calls: []*pql.Call{call}}, nil, nil)
@@ -294,6 +310,15 @@ func (i *pqlAggregateRowIter) Next(ctx context.Context) (types.Row, error) {
 			default:
 				return nil, sql3.NewErrInternalf("unhandled return type '%T'", i.aggregate.Type())
 			}
+		case *pilosa.Sketch:
+			agg, ok := i.aggregate.(sketchAggregate)
+			if !ok {
+				return nil, sql3.NewErrInternalf("unexpected sketch for aggregate type '%T'", i.aggregate)
+			}
+			i.resultValue, err = agg.evalSketch(actualResult)
+			if err != nil {
+				return nil, err
+			}
 		case nil:
 			// it's valid for an aggregate to yield a NULL in some cases, such as
 			// when it's called on what turns out to be an empty set.
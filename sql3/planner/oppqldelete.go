@@ -159,7 +159,10 @@ func (i *constRowDeleteRowIter) Next(ctx context.Context) (types.Row, error) {
 
 		tbl, err := i.planner.schemaAPI.TableByName(ctx, dax.TableName(i.tableName))
 		if err != nil {
-			return nil, sql3.NewErrTableNotFound(0, 0, i.tableName)
+			if isTableNotFoundError(err) {
+				return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, i.tableName))
+			}
+			return nil, err
 		}
 
 		_, err = i.planner.executor.Execute(ctx, tbl, &pql.Query{Calls: []*pql.Call{call}}, nil, nil)
@@ -150,7 +150,8 @@ func (i *distinctScanRowIter) Next(ctx context.Context) (types.Row, error) {
 		table, err := i.planner.schemaAPI.TableByName(ctx, tname)
 		if err != nil {
 			if isTableNotFoundError(err) {
-				return nil, sql3.NewErrInternalf("table not found '%s'", i.tableName)
+				// The table was dropped since the query was planned.
+				return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, i.tableName))
 			}
 			return nil, err
 		}
@@ -28,8 +28,12 @@ type PlanOpPQLTableScan struct {
 	filter             types.PlanExpression
 	timeQuantumFilters []types.PlanExpression
 	topExpr            types.PlanExpression
-	hints              []*TableQueryHint
-	warnings           []string
+	// topOrderBy is the ordering of the rows within topExpr, if the top
+	// is of ordered rows; the rows are then the top rows of each compute
+	// node, and still have to be ordered and limited to merge them.
+	topOrderBy []*OrderByExpression
+	hints      []*TableQueryHint
+	warnings   []string
 }
 
 func NewPlanOpPQLTableScan(p *ExecutionPlanner, tableName string, columns []string, hints []*TableQueryHint) *PlanOpPQLTableScan {
@@ -51,15 +55,37 @@ func (p *PlanOpPQLTableScan) Plan() map[string]interface{} {
 
 	if p.topExpr != nil {
 		result["topExpr"] = p.topExpr.Plan()
+		// pqlTopSort is how the compute nodes order their rows, each
+		// returning only its rows within the top, or empty if the top is
+		// taken of rows in column order.
+		sort := make([]string, 0, len(p.topOrderBy))
+		for _, s := range pqlExtractSort(p.topOrderBy, p.columns) {
+			sort = append(sort, s.(string))
+		}
+		result["pqlTopSort"] = strings.Join(sort, ",")
 	}
 	if p.filter != nil {
 		result["filter"] = p.filter.Plan()
 	}
+	// pqlFilter is the bitmap call which selects the rows to extract, so
+	// that it's clear whether a filter was translated into (for instance)
+	// range operations, or whether every row is scanned, which is All().
+	if cond, err := p.planner.generatePQLCallFromExpr(context.Background(), p.filter); err == nil {
+		if cond == nil {
+			cond = &pql.Call{Name: "All"}
+		}
+		result["pqlFilter"] = cond.String()
+	}
 	tqfilters := make([]map[string]interface{}, len(p.timeQuantumFilters))
+	pqltqfilters := make([]string, 0, len(p.timeQuantumFilters))
 	for i, f := range p.timeQuantumFilters {
 		tqfilters[i] = f.Plan()
+		if call, err := p.planner.generatePQLCallFromExpr(context.Background(), f); err == nil {
+			pqltqfilters = append(pqltqfilters, call.String())
+		}
 	}
 	result["tqfilters"] = tqfilters
+	result["pqlTqfilters"] = pqltqfilters
 	result["columns"] = p.columns
 	return result
 }
@@ -130,6 +156,7 @@ func (p *PlanOpPQLTableScan) Iterator(ctx context.Context, row types.Row) (types
 		predicate:          p.filter,
 		timeQuantumFilters: p.timeQuantumFilters,
 		topExpr:            p.topExpr,
+		topOrderBy:         p.topOrderBy,
 	}, nil
 }
 
@@ -164,6 +191,7 @@ type tableScanRowIter struct {
 	predicate          types.PlanExpression
 	timeQuantumFilters []types.PlanExpression
 	topExpr            types.PlanExpression
+	topOrderBy         []*OrderByExpression
 
 	result    []pilosa.ExtractedTableColumn
 	rowWidth  int
@@ -184,7 +212,8 @@ func (i *tableScanRowIter) Next(ctx context.Context) (types.Row, error) {
 		table, err := i.planner.schemaAPI.TableByName(ctx, tname)
 		if err != nil {
 			if isTableNotFoundError(err) {
-				return nil, sql3.NewErrInternalf("table not found '%s'", i.tableName)
+				// The table was dropped since the query was planned.
+				return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, i.tableName))
 			}
 			return nil, err
 		}
@@ -215,6 +244,10 @@ func (i *tableScanRowIter) Next(ctx context.Context) (types.Row, error) {
 			cond = &pql.Call{Name: "All"}
 		}
 
+		call := &pql.Call{Name: "Extract", Children: []*pql.Call{cond}}
+
+		// The top is applied by the Extract(), so that each compute node
+		// returns only its rows within the top.
 		if i.topExpr != nil {
 			_, ok := i.topExpr.(*intLiteralPlanExpression)
 			if !ok {
@@ -224,15 +257,11 @@ func (i *tableScanRowIter) Next(ctx context.Context) (types.Row, error) {
 			if err != nil {
 				return nil, err
 			}
-			cond = &pql.Call{
-				Name:     "Limit",
-				Children: []*pql.Call{cond},
-				Args:     map[string]interface{}{"limit": pqlValue},
-				Type:     pql.PrecallGlobal,
+			call.Args = map[string]interface{}{"limit": pqlValue}
+			if sort := pqlExtractSort(i.topOrderBy, i.columns); len(sort) > 0 {
+				call.Args["sort"] = sort
 			}
 		}
-
-		call := &pql.Call{Name: "Extract", Children: []*pql.Call{cond}}
 		for _, c := range i.columns {
 
 			// skip the _id field
@@ -274,7 +303,10 @@ func (i *tableScanRowIter) Next(ctx context.Context) (types.Row, error) {
 
 		tbl, err := i.planner.schemaAPI.TableByName(ctx, dax.TableName(i.tableName))
 		if err != nil {
-			return nil, sql3.NewErrTableNotFound(0, 0, i.tableName)
+			if isTableNotFoundError(err) {
+				return nil, tableNotFoundError(err, sql3.NewErrTableNotFound(0, 0, i.tableName))
+			}
+			return nil, err
 		}
 
 		queryResponse, err := i.planner.executor.Execute(ctx, tbl, &pql.Query{Calls: []*pql.Call{call}}, nil, nil)
@@ -354,3 +386,27 @@ func (i *tableScanRowIter) Next(ctx context.Context) (types.Row, error) {
 	}
 	return nil, types.ErrNoMoreRows
 }
+
+// pqlExtractSort returns the sort argument of an Extract() for the ordering
+// orderBy, which must be of references to the columns.
+func pqlExtractSort(orderBy []*OrderByExpression, columns []string) []interface{} {
+	sort := make([]interface{}, 0, len(orderBy))
+	for _, ob := range orderBy {
+		ref, ok := ob.Expr.(*qualifiedRefPlanExpression)
+		if !ok {
+			continue
+		}
+		name := ref.columnName
+		for _, c := range columns {
+			if strings.EqualFold(c, name) {
+				name = c
+				break
+			}
+		}
+		if ob.Order == orderByDesc {
+			name = "-" + name
+		}
+		sort = append(sort, name)
+	}
+	return sort
+}
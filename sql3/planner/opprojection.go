@@ -10,6 +10,21 @@ import (
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 )
 
+// The stages at which a projection can be evaluated, as shown in the plan of a
+// PlanOpProjection.
+const (
+	// projectionEvaluatedComputer is the stage of a projection which is simply
+	// a column of its child's rows, such as a field read, or an aggregate
+	// computed, by the compute nodes (or, outside of DAX, by the nodes which
+	// own the shards being read).
+	projectionEvaluatedComputer = "computer"
+
+	// projectionEvaluatedMerge is the stage of a projection which is computed
+	// by the projection itself, on the node coordinating the query, once its
+	// child's rows have been merged.
+	projectionEvaluatedMerge = "merge"
+)
+
 // PlanOpProjection handles row projection and expression evaluation
 type PlanOpProjection struct {
 	ChildOp     types.PlanOperator
@@ -67,13 +82,27 @@ func (p *PlanOpProjection) Plan() map[string]interface{} {
 
 	ps := make([]interface{}, 0)
 	for _, e := range p.Projections {
-		ps = append(ps, e.Plan())
+		ep := e.Plan()
+		ep["_evaluated"] = projectionEvaluatedAt(e)
+		ps = append(ps, ep)
 	}
 	result["projections"] = ps
 
 	return result
 }
 
+// projectionEvaluatedAt returns the stage at which the projection e is
+// evaluated.
+func projectionEvaluatedAt(e types.PlanExpression) string {
+	if alias, ok := e.(*aliasPlanExpression); ok {
+		e = alias.expr
+	}
+	if _, ok := e.(*qualifiedRefPlanExpression); ok {
+		return projectionEvaluatedComputer
+	}
+	return projectionEvaluatedMerge
+}
+
 func (p *PlanOpProjection) String() string {
 	return ""
 }
@@ -0,0 +1,99 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// PlanOpRefreshView plan operator to refresh a materialized view.
+type PlanOpRefreshView struct {
+	planner  *ExecutionPlanner
+	viewName string
+	warnings []string
+}
+
+func NewPlanOpRefreshView(p *ExecutionPlanner, viewName string) *PlanOpRefreshView {
+	return &PlanOpRefreshView{
+		planner:  p,
+		viewName: viewName,
+		warnings: make([]string, 0),
+	}
+}
+
+func (p *PlanOpRefreshView) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_op"] = fmt.Sprintf("%T", p)
+	result["viewName"] = p.viewName
+	return result
+}
+
+func (p *PlanOpRefreshView) String() string {
+	return ""
+}
+
+func (p *PlanOpRefreshView) AddWarning(warning string) {
+	p.warnings = append(p.warnings, warning)
+}
+
+func (p *PlanOpRefreshView) Warnings() []string {
+	return p.warnings
+}
+
+func (p *PlanOpRefreshView) Schema() types.Schema {
+	return types.Schema{}
+}
+
+func (p *PlanOpRefreshView) Children() []types.PlanOperator {
+	return []types.PlanOperator{}
+}
+
+func (p *PlanOpRefreshView) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	return &refreshViewRowIter{
+		planner:  p.planner,
+		viewName: p.viewName,
+	}, nil
+}
+
+func (p *PlanOpRefreshView) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
+	return nil, nil
+}
+
+type refreshViewRowIter struct {
+	planner  *ExecutionPlanner
+	viewName string
+}
+
+var _ types.RowIterator = (*refreshViewRowIter)(nil)
+
+func (i *refreshViewRowIter) Next(ctx context.Context) (types.Row, error) {
+	err := i.planner.checkAccess(ctx, i.viewName, accessTypeWriteData)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := i.planner.getViewByName(ctx, i.viewName)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, sql3.NewErrViewNotFound(0, 0, i.viewName)
+	}
+	mv, err := i.planner.getMaterializedViewByName(ctx, i.viewName)
+	if err != nil {
+		return nil, err
+	}
+	if mv == nil {
+		return nil, sql3.NewErrViewNotMaterialized(0, 0, i.viewName)
+	}
+
+	err = i.planner.refreshMaterializedViewOnce(ctx, v, mv)
+	if err != nil {
+		return nil, err
+	}
+	return nil, types.ErrNoMoreRows
+}
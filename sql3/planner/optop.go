@@ -12,9 +12,13 @@ import (
 
 // PlanOpTop implements the TOP operator
 type PlanOpTop struct {
-	ChildOp  types.PlanOperator
-	expr     types.PlanExpression
-	warnings []string
+	ChildOp types.PlanOperator
+	expr    types.PlanExpression
+	// pushedDown is true if the top of ordered rows is also taken by the
+	// table scan below it, so that this only merges the top rows returned
+	// by each compute node.
+	pushedDown bool
+	warnings   []string
 }
 
 func NewPlanOpTop(expr types.PlanExpression, child types.PlanOperator) *PlanOpTop {
@@ -47,7 +51,9 @@ func (p *PlanOpTop) WithChildren(children ...types.PlanOperator) (types.PlanOper
 	if len(children) != 1 {
 		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
 	}
-	return NewPlanOpTop(p.expr, children[0]), nil
+	op := NewPlanOpTop(p.expr, children[0])
+	op.pushedDown = p.pushedDown
+	return op, nil
 }
 
 func (p *PlanOpTop) Plan() map[string]interface{} {
@@ -55,6 +61,7 @@ func (p *PlanOpTop) Plan() map[string]interface{} {
 	result["_op"] = fmt.Sprintf("%T", p)
 	result["_schema"] = p.Schema().Plan()
 	result["expr"] = p.expr
+	result["pushedDown"] = p.pushedDown
 	result["child"] = p.ChildOp.Plan()
 	return result
 }
@@ -698,6 +698,8 @@ func tryToReplaceGroupByWithPQLAggregate(ctx context.Context, a *ExecutionPlanne
 				// 1. the expression we are aggregating on is a qualifiedRef
 				// 2. it is a bsi type
 				// we always push down to pql if it's a ref and it's the _id column
+				// the approximate aggregates are pushed down as sketches of
+				// any column other than the _id column
 				for i, agg := range thisNode.Aggregates {
 					switch aggregable := agg.(type) {
 					case *countStarPlanExpression:
@@ -709,10 +711,16 @@ func tryToReplaceGroupByWithPQLAggregate(ctx context.Context, a *ExecutionPlanne
 						}
 						thisNode.Aggregates[i] = newAgg
 
-					// these two can't be done in PQL
+					// these can't be done in PQL
 					case *corrPlanExpression, *varPlanExpression:
 						return thisNode, true, nil
 
+					case sketchAggregate:
+						ref, ok := aggregable.FirstChildExpr().(*qualifiedRefPlanExpression)
+						if !ok || strings.EqualFold(ref.columnName, string(dax.PrimaryKeyFieldName)) {
+							return thisNode, true, nil
+						}
+
 					case types.Aggregable:
 						switch ref := aggregable.FirstChildExpr().(type) {
 						case *qualifiedRefPlanExpression:
@@ -940,6 +948,15 @@ func tryToReplaceGroupByWithPQLGroupBy(ctx context.Context, a *ExecutionPlanner,
 				return thisNode, true, err
 			}
 
+			// with a GROUP BY, the approximate aggregates are built per group
+			// in the queryer, so they can't be done in PQL
+			for _, agg := range thisNode.Aggregates {
+				switch agg.(type) {
+				case *approxCountDistinctPlanExpression, *approxPercentilePlanExpression, *approxTopKPlanExpression:
+					return thisNode, true, nil
+				}
+			}
+
 			// for each of the aggregates, go make a PlanOpPQLGroupBy operator
 			ops := make([]*PlanOpPQLGroupBy, 0)
 			for _, agg := range thisNode.Aggregates {
@@ -977,53 +994,77 @@ func tryToReplaceGroupByWithPQLGroupBy(ctx context.Context, a *ExecutionPlanner,
 	})
 }
 
+// pushdownPQLTop pushes a top (or limit) down into the table scan below it,
+// so that each compute node returns only its rows within the top, rather
+// than all of them. This is only correct if the top is of the scan's rows as
+// they are, since aggregates, distinct, having, filters and joins all need
+// every row, so a top is only pushed down if:
+//   - it's of a projection directly over the scan, in which case the scan
+//     takes the top of its rows in column order, and the top is removed, or
+//   - it's of a projection over an ORDER BY directly over the scan, which is
+//     only of the scan's columns, and of types which the compute nodes can
+//     order (int, decimal, timestamp and id). The compute nodes then return
+//     their top rows in that order, and the ORDER BY and the top are kept to
+//     merge them.
 func pushdownPQLTop(ctx context.Context, a *ExecutionPlanner, n types.PlanOperator, scope *OptimizerScope) (types.PlanOperator, bool, error) {
-	// bail if there are any joins
-	joins, err := hasJoins(ctx, a, n, scope)
-	if err != nil {
-		return nil, false, err
-	}
-	if joins {
-		return n, true, nil
-	}
-
-	// get a list of tables that have projections as parents
-	var tables []*PlanOpPQLTableScan
-	_, _, err = TransformPlanOpWithParent(n, func(c ParentContext) bool { return true }, func(c ParentContext) (types.PlanOperator, bool, error) {
-		parent := c.Parent
-		node := c.Operator
+	return TransformPlanOp(n, func(node types.PlanOperator) (types.PlanOperator, bool, error) {
+		top, ok := node.(*PlanOpTop)
+		if !ok {
+			return node, true, nil
+		}
+		if _, ok := top.expr.(*intLiteralPlanExpression); !ok {
+			return node, true, nil
+		}
+		projection, ok := top.ChildOp.(*PlanOpProjection)
+		if !ok {
+			return node, true, nil
+		}
 
-		switch thisNode := node.(type) {
+		switch child := projection.ChildOp.(type) {
 		case *PlanOpPQLTableScan:
-			switch parent.(type) {
-			case *PlanOpProjection:
-				tables = append(tables, thisNode)
-			}
+			child.topExpr = top.expr
+			// return the child of the top node to eliminate it
+			return top.ChildOp, false, nil
 
+		case *PlanOpOrderBy:
+			table, ok := child.ChildOp.(*PlanOpPQLTableScan)
+			if !ok || !canPushdownOrderBy(table, child.orderByFields) {
+				return node, true, nil
+			}
+			table.topExpr = top.expr
+			table.topOrderBy = child.orderByFields
+			top.pushedDown = true
+			return node, true, nil
 		}
 		return node, true, nil
-
 	})
-	if err != nil {
-		return nil, false, err
-	}
+}
 
-	// only do this if we have one TableScanOperator
-	if len(tables) == 1 {
-		return TransformPlanOp(n, func(node types.PlanOperator) (types.PlanOperator, bool, error) {
-			switch n := node.(type) {
-			case *PlanOpTop:
-				table := tables[0]
-				//set the topExpr for the PlanOpTableScan
-				table.topExpr = n.expr
-				//return the child of the top node to eliminate it
-				return n.ChildOp, false, nil
-			default:
-				return n, true, nil
-			}
-		})
+// canPushdownOrderBy returns true if the compute nodes can order the rows of
+// table by orderBy.
+func canPushdownOrderBy(table *PlanOpPQLTableScan, orderBy []*OrderByExpression) bool {
+	for _, ob := range orderBy {
+		ref, ok := ob.Expr.(*qualifiedRefPlanExpression)
+		if !ok || ob.NullOrdering != nullOrderingFirst {
+			return false
+		}
+		if ref.tableName != "" && !strings.EqualFold(ref.tableName, table.tableName) {
+			return false
+		}
+		found := false
+		for _, c := range table.columns {
+			found = found || strings.EqualFold(c, ref.columnName)
+		}
+		if !found {
+			return false
+		}
+		switch ref.dataType.(type) {
+		case *parser.DataTypeInt, *parser.DataTypeDecimal, *parser.DataTypeTimestamp, *parser.DataTypeID:
+		default:
+			return false
+		}
 	}
-	return n, true, nil
+	return true
 }
 
 // fixes references for a projection op depending on child
@@ -1388,7 +1429,8 @@ func fixFieldRefIndexesForHaving(ctx context.Context, scope *OptimizerScope, a *
 		switch typedExpr := e.(type) {
 		case *sumPlanExpression, *countPlanExpression, *countDistinctPlanExpression,
 			*avgPlanExpression, *minPlanExpression, *maxPlanExpression, *countStarPlanExpression,
-			*percentilePlanExpression:
+			*percentilePlanExpression, *approxCountDistinctPlanExpression, *approxPercentilePlanExpression,
+			*approxTopKPlanExpression:
 			for i, col := range schema {
 				if strings.EqualFold(typedExpr.String(), col.ColumnName) {
 					e := newQualifiedRefPlanExpression("", "", i, typedExpr.Type())
@@ -1415,7 +1457,8 @@ func fixFieldRefIndexesForHaving(ctx context.Context, scope *OptimizerScope, a *
 		switch parentExpr.(type) {
 		case *sumPlanExpression, *countPlanExpression, *countDistinctPlanExpression,
 			*avgPlanExpression, *minPlanExpression, *maxPlanExpression,
-			*percentilePlanExpression:
+			*percentilePlanExpression, *approxCountDistinctPlanExpression, *approxPercentilePlanExpression,
+			*approxTopKPlanExpression:
 			return false
 		default:
 			return true
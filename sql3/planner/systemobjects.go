@@ -5,6 +5,7 @@ package planner
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
@@ -17,6 +18,19 @@ import (
 type viewSystemObject struct {
 	name      string
 	statement string
+
+	// materialized is set when creating a materialized view.
+	materialized *materializedViewSystemObject
+}
+
+// materializedViewSystemObject is the materialization of a view: the table
+// holding the view's result as of the last refresh.
+type materializedViewSystemObject struct {
+	name            string
+	refreshInterval time.Duration
+	tableName       string
+	syntheticID     bool
+	refreshedAt     time.Time
 }
 
 type functionSystemObject struct {
@@ -261,6 +275,235 @@ func (p *ExecutionPlanner) deleteView(ctx context.Context, viewName string) erro
 	return nil
 }
 
+func (p *ExecutionPlanner) ensureMaterializedViewsSystemTableExists(ctx context.Context) error {
+	_, err := p.schemaAPI.TableByName(ctx, "fb_materialized_views")
+	if err != nil {
+		if !isTableNotFoundError(err) {
+			return err
+		}
+
+		//  create table fb_materialized_views (
+		// 		_id string
+		//		refresh_interval int
+		//		table_name string
+		//		synthetic_id bool
+		//		refreshed_at timestamp
+		//  );
+
+		// if it doesn't, create it by making the appropriate iterator
+		iter := &createTableRowIter{
+			planner:       p,
+			tableName:     "fb_materialized_views",
+			failIfExists:  false,
+			isKeyed:       true,
+			keyPartitions: 0,
+			columns: []*createTableField{
+				{
+					planner:  p,
+					name:     "refresh_interval",
+					typeName: dax.BaseTypeInt,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeInt(0, math.MaxInt64),
+					},
+				},
+				{
+					planner:  p,
+					name:     "table_name",
+					typeName: dax.BaseTypeString,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeMutex(pilosa.DefaultCacheType, pilosa.DefaultCacheSize),
+						pilosa.OptFieldKeys(),
+					},
+				},
+				{
+					planner:  p,
+					name:     "synthetic_id",
+					typeName: dax.BaseTypeBool,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeBool(),
+					},
+				},
+				{
+					planner:  p,
+					name:     "refreshed_at",
+					typeName: dax.BaseTypeTimestamp,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeTimestamp(pilosa.DefaultEpoch, pilosa.TimeUnitSeconds),
+					},
+				},
+			},
+			description: "system table for materialized views",
+		}
+		// call next on our iterator to create the table
+		_, err := iter.Next(ctx)
+		if err != nil && err != types.ErrNoMoreRows {
+			return err
+		}
+	}
+	return nil
+}
+
+// getMaterializedViewByName returns the materialization of the view with the
+// given name, or nil if the view isn't materialized.
+func (p *ExecutionPlanner) getMaterializedViewByName(ctx context.Context, name string) (*materializedViewSystemObject, error) {
+	// The system table is only created along with the first materialized
+	// view, so there's nothing to read until then.
+	tbl, err := p.schemaAPI.TableByName(ctx, "fb_materialized_views")
+	if err != nil {
+		if isTableNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cols := make([]string, len(tbl.Fields))
+	for i, c := range tbl.Fields {
+		cols[i] = string(c.Name)
+	}
+
+	iter := &tableScanRowIter{
+		planner:   p,
+		tableName: "fb_materialized_views",
+		columns:   cols,
+		predicate: newBinOpPlanExpression(
+			newQualifiedRefPlanExpression("fb_materialized_views", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			parser.EQ,
+			newStringLiteralPlanExpression(name),
+			parser.NewDataTypeBool(),
+		),
+		topExpr: nil,
+	}
+
+	row, err := iter.Next(ctx)
+	if err != nil {
+		if err == types.ErrNoMoreRows {
+			// view is not materialized
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return materializedViewFromRow(row), nil
+}
+
+// getMaterializedViews returns the materializations of all the materialized
+// views.
+func (p *ExecutionPlanner) getMaterializedViews(ctx context.Context) ([]*materializedViewSystemObject, error) {
+	tbl, err := p.schemaAPI.TableByName(ctx, "fb_materialized_views")
+	if err != nil {
+		if isTableNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cols := make([]string, len(tbl.Fields))
+	for i, c := range tbl.Fields {
+		cols[i] = string(c.Name)
+	}
+
+	iter := &tableScanRowIter{
+		planner:   p,
+		tableName: "fb_materialized_views",
+		columns:   cols,
+		topExpr:   nil,
+	}
+
+	var mvs []*materializedViewSystemObject
+	for {
+		row, err := iter.Next(ctx)
+		if err != nil {
+			if err == types.ErrNoMoreRows {
+				return mvs, nil
+			}
+			return nil, err
+		}
+		mvs = append(mvs, materializedViewFromRow(row))
+	}
+}
+
+// materializedViewFromRow returns the materialization held by a row of
+// fb_materialized_views.
+func materializedViewFromRow(row types.Row) *materializedViewSystemObject {
+	mv := &materializedViewSystemObject{
+		name: row[0].(string),
+	}
+	if v, ok := row[1].(int64); ok {
+		mv.refreshInterval = time.Duration(v) * time.Second
+	}
+	if v, ok := row[2].(string); ok {
+		mv.tableName = v
+	}
+	if v, ok := row[3].(bool); ok {
+		mv.syntheticID = v
+	}
+	if v, ok := row[4].(time.Time); ok {
+		mv.refreshedAt = v
+	}
+	return mv
+}
+
+func (p *ExecutionPlanner) upsertMaterializedView(ctx context.Context, mv *materializedViewSystemObject) error {
+	err := p.ensureMaterializedViewsSystemTableExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	refreshedAt := types.PlanExpression(newNullLiteralPlanExpression())
+	if !mv.refreshedAt.IsZero() {
+		refreshedAt = newTimestampLiteralPlanExpression(mv.refreshedAt)
+	}
+
+	iter := &insertRowIter{
+		planner:   p,
+		tableName: "fb_materialized_views",
+		targetColumns: []*qualifiedRefPlanExpression{
+			newQualifiedRefPlanExpression("fb_materialized_views", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_materialized_views", "refresh_interval", 0, parser.NewDataTypeInt()),
+			newQualifiedRefPlanExpression("fb_materialized_views", "table_name", 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_materialized_views", "synthetic_id", 0, parser.NewDataTypeBool()),
+			newQualifiedRefPlanExpression("fb_materialized_views", "refreshed_at", 0, parser.NewDataTypeTimestamp()),
+		},
+		insertValues: [][]types.PlanExpression{
+			{
+				newStringLiteralPlanExpression(mv.name),
+				newIntLiteralPlanExpression(int64(mv.refreshInterval / time.Second)),
+				newStringLiteralPlanExpression(mv.tableName),
+				newBoolLiteralPlanExpression(mv.syntheticID),
+				refreshedAt,
+			},
+		},
+	}
+	_, err = iter.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+	return nil
+}
+
+func (p *ExecutionPlanner) deleteMaterializedView(ctx context.Context, viewName string) error {
+	err := p.ensureMaterializedViewsSystemTableExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	iter := &filteredDeleteRowIter{
+		planner:   p,
+		tableName: "fb_materialized_views",
+		filter: newBinOpPlanExpression(
+			newQualifiedRefPlanExpression("fb_materialized_views", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			parser.EQ,
+			newStringLiteralPlanExpression(viewName),
+			parser.NewDataTypeBool(),
+		),
+	}
+	_, err = iter.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+	return nil
+}
+
 func (p *ExecutionPlanner) ensureFunctionsSystemTableExists() error {
 	_, err := p.schemaAPI.TableByName(context.Background(), "fb_functions")
 	if err != nil {
@@ -2,11 +2,13 @@
 package sql3_test
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/sql3"
@@ -192,3 +194,41 @@ func TestInternalError(t *testing.T) {
 		t.Fatalf("internal error from *_test.go file should contain test.go string")
 	}
 }
+
+func TestSQL_MaterializedViewRefresh(t *testing.T) {
+	c := test.MustRunCluster(t, 1)
+	defer c.Close()
+
+	svr := c.GetNode(0).Server
+
+	mustQuery := func(q string) [][]interface{} {
+		t.Helper()
+		rows, _, _, err := sql_test.MustQueryRows(t, nil, svr, q)
+		require.NoError(t, err)
+		return rows
+	}
+	count := func() int64 {
+		t.Helper()
+		rows := mustQuery("select count(*) from mvrefresh_view;")
+		require.Len(t, rows, 1)
+		return rows[0][0].(int64)
+	}
+
+	mustQuery("create table mvrefresh (_id id, a int);")
+	mustQuery("insert into mvrefresh (_id, a) values (1, 10), (2, 20);")
+	mustQuery("create materialized view mvrefresh_view with refresh '1s' as select _id, a from mvrefresh;")
+	require.Equal(t, int64(2), count())
+
+	// Reads serve the current result, even once the refresh interval has
+	// passed.
+	mustQuery("insert into mvrefresh (_id, a) values (3, 30);")
+	time.Sleep(1100 * time.Millisecond)
+	require.Equal(t, int64(2), count())
+
+	// A view which isn't due keeps its result.
+	require.NoError(t, svr.RefreshMaterializedViews(context.Background(), time.Now().UTC().Add(-time.Hour)))
+	require.Equal(t, int64(2), count())
+
+	require.NoError(t, svr.RefreshMaterializedViews(context.Background(), time.Now().UTC()))
+	require.Equal(t, int64(3), count())
+}
@@ -38,6 +38,7 @@ var TableTests []TableTest = []TableTest{
 
 	subqueryTests,
 	viewTests,
+	materializedViewTests,
 
 	topLimitTests,
 
@@ -58,6 +59,8 @@ var TableTests []TableTest = []TableTest{
 
 	insertTest,
 	insertTimestampTest,
+	upsertTest,
+	conditionalWriteTest,
 	keyedInsertTest,
 	timestampLiterals,
 	unaryOpExprWithInt,
@@ -167,6 +170,7 @@ var TableTests []TableTest = []TableTest{
 	// between tests
 	betweenTests,
 	notBetweenTests,
+	betweenFilterTests,
 
 	// in tests
 	inTests,
@@ -181,6 +185,7 @@ var TableTests []TableTest = []TableTest{
 	minmaxTests,
 	corrTests,
 	varTests,
+	approxTests,
 
 	// groupby tests
 	groupByTests,
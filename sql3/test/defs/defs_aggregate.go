@@ -914,3 +914,108 @@ var varTests = TableTest{
 		},
 	},
 }
+
+var approxTests = TableTest{
+	Table: tbl(
+		"approx_test",
+		srcHdrs(
+			srcHdr("_id", fldTypeID),
+			srcHdr("i1", fldTypeInt, "min 0", "max 1000"),
+			srcHdr("d1", fldTypeDecimal2),
+			srcHdr("s1", fldTypeString),
+		),
+		srcRows(
+			srcRow(int64(1), int64(10), float64(10), string("foo")),
+			srcRow(int64(2), int64(10), float64(10), string("foo")),
+			srcRow(int64(3), int64(11), float64(11), string("foo")),
+			srcRow(int64(4), int64(12), float64(12), string("bar")),
+			srcRow(int64(5), int64(12), float64(12), string("bar")),
+			srcRow(int64(6), int64(13), float64(13), string("baz")),
+		),
+	),
+	SQLTests: []SQLTest{
+		{
+			SQLs: sqls(
+				"SELECT approx_count_distinct(*) AS a FROM approx_test",
+			),
+			ExpErr: "column reference expected",
+		},
+		{
+			SQLs: sqls(
+				"SELECT approx_percentile(s1, 50) AS a FROM approx_test",
+			),
+			ExpErr: "integer or decimal expression expected",
+		},
+		{
+			SQLs: sqls(
+				"SELECT approx_top_k(d1, 2) AS a FROM approx_test",
+			),
+			ExpErr: "integer or string expression expected",
+		},
+		{
+			SQLs: sqls(
+				"SELECT approx_top_k(s1, 0) AS a FROM approx_test",
+			),
+			ExpErr: "integer literal expected",
+		},
+		{
+			SQLs: sqls(
+				"SELECT approx_count_distinct(i1) AS a, approx_count_distinct(s1) AS b FROM approx_test",
+			),
+			ExpHdrs: hdrs(
+				hdr("a", fldTypeInt),
+				hdr("b", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(4), int64(3)),
+			),
+			Compare: CompareExactUnordered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child.operators[0]._op", "*planner.PlanOpPQLAggregate")
+			},
+		},
+		{
+			SQLs: sqls(
+				"SELECT approx_percentile(i1, 50) AS a, approx_percentile(d1, 100) AS b FROM approx_test",
+			),
+			ExpHdrs: hdrs(
+				hdr("a", fldTypeInt),
+				hdr("b", fldTypeDecimal2),
+			),
+			ExpRows: rows(
+				row(int64(12), pql.NewDecimal(1300, 2)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			SQLs: sqls(
+				"SELECT approx_top_k(s1, 2) AS a FROM approx_test",
+			),
+			ExpHdrs: hdrs(
+				hdr("a", fldTypeStringSet),
+			),
+			ExpRows: rows(
+				row([]string{"foo", "bar"}),
+			),
+			Compare: CompareExactOrdered,
+		},
+		{
+			SQLs: sqls(
+				"SELECT s1, approx_count_distinct(i1) AS a FROM approx_test GROUP BY s1",
+			),
+			ExpHdrs: hdrs(
+				hdr("s1", fldTypeString),
+				hdr("a", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(string("bar"), int64(1)),
+				row(string("baz"), int64(1)),
+				row(string("foo"), int64(2)),
+			),
+			Compare: CompareExactUnordered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child._op", "*planner.PlanOpGroupBy")
+			},
+		},
+	},
+}
@@ -237,3 +237,109 @@ var notBetweenTests = TableTest{
 		},
 	},
 }
+
+// BETWEEN and NOT BETWEEN filter tests. These are pushed down into the
+// table scan as range queries, and a null value matches neither.
+var betweenFilterTests = TableTest{
+	Table: tbl(
+		"between_filter",
+		srcHdrs(
+			srcHdr("_id", fldTypeID),
+			srcHdr("i1", fldTypeInt, "min 0", "max 1000"),
+			srcHdr("d1", fldTypeDecimal2),
+			srcHdr("t1", fldTypeTimestamp),
+		),
+		srcRows(
+			srcRow(int64(1), int64(5), float64(1.25), knownTimestamp()),
+			srcRow(int64(2), int64(50), float64(12.34), knownTimestamp().AddDate(1, 0, 0)),
+			srcRow(int64(3), int64(500), float64(123.45), knownTimestamp().AddDate(10, 0, 0)),
+			srcRow(int64(4), nil, nil, nil),
+		),
+	),
+	SQLTests: []SQLTest{
+		{
+			SQLs: sqls(
+				"select _id from between_filter where d1 between 10 and 123.45",
+				"select _id from between_filter where t1 between '2013-01-01T00:00:00Z' and '2025-01-01T00:00:00Z'",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(2)),
+				row(int64(3)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			SQLs: sqls(
+				"select _id from between_filter where d1 not between 10 and 123.45",
+				"select _id from between_filter where t1 not between '2013-01-01T00:00:00Z' and '2025-01-01T00:00:00Z'",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(1)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			SQLs: sqls(
+				"select _id from between_filter where (i1 between 10 and 100) or (d1 not between 1 and 100)",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(2)),
+				row(int64(3)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			SQLs: sqls(
+				"select _id from between_filter where i1 between 10 and 500",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(2)),
+				row(int64(3)),
+			),
+			Compare: CompareExactUnordered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child.pqlFilter", "Row(10<=i1<=500)")
+			},
+		},
+		{
+			SQLs: sqls(
+				"select _id from between_filter where i1 not between 10 and 500",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(1)),
+			),
+			Compare: CompareExactUnordered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child.pqlFilter", "Union(Row(i1<10), Row(i1>500))")
+			},
+		},
+		{
+			SQLs: sqls(
+				"select _id from between_filter where _id between 2 and 3",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(2)),
+				row(int64(3)),
+			),
+			Compare: CompareExactUnordered,
+		},
+	},
+}
@@ -48,6 +48,20 @@ var binOpExprWithIntInt = TableTest{
 			),
 			ExpErr: "divisor is equal to zero",
 		},
+		{
+			name: "AdditionOverflowRow",
+			SQLs: sqls(
+				"select a + 9223372036854775807 from binoptesti_i;",
+			),
+			ExpErr: "integer overflow",
+		},
+		{
+			name: "MultiplicationOverflowRow",
+			SQLs: sqls(
+				"select a * 1000000000000000000 from binoptesti_i;",
+			),
+			ExpErr: "integer overflow",
+		},
 		{
 			SQLs: sqls(
 				"select a != b from binoptesti_i;",
@@ -31,6 +31,26 @@ var createTable = TableTest{
 			),
 			ExpErr: "expected literal, found bad",
 		},
+		{
+			name: "maxSnapshotAgeInt",
+			SQLs: sqls(
+				"create table foo (_id id, i1 int) maxsnapshotage 24",
+			),
+			ExpErr: "string literal expected",
+		},
+		{
+			name: "maxSnapshotAgeInvalid",
+			SQLs: sqls(
+				"create table foo (_id id, i1 int) maxsnapshotage '0s'",
+			),
+			ExpErr: "'0s' is not a valid time duration",
+		},
+		{
+			name: "maxSnapshotAge",
+			SQLs: sqls(
+				"create table baz (_id id, i1 int) maxsnapshotage '24h' comment 'this should work'",
+			),
+		},
 		{
 			name: "minAboveMax",
 			SQLs: sqls(
@@ -197,3 +197,132 @@ var insertTimestampTest = TableTest{
 		},
 	},
 }
+
+var upsertTest = TableTest{
+	SQLTests: []SQLTest{
+		{
+			SQLs: sqls(
+				"CREATE TABLE upsertTest (_id id, a int, s string, ids idset, strings stringset);",
+				"INSERT INTO upsertTest (_id, a, s, ids, strings) VALUES (1, 10, 'foo', [1, 2], ['red', 'blue']), (2, 20, 'bar', [3], ['green']);",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			// Upsert replaces the values of the given set columns, leaves the
+			// omitted columns unchanged, and creates records which don't exist.
+			SQLs: sqls(
+				"UPSERT INTO upsertTest (_id, a, ids) VALUES (1, 11, [5]), (3, 30, [6, 7]);",
+				"UPSERT INTO upsertTest (_id, strings) VALUES (2, ['yellow']);",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			SQLs: sqls(
+				"SELECT _id, a, s, ids, strings FROM upsertTest;",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+				hdr("a", fldTypeInt),
+				hdr("s", fldTypeString),
+				hdr("ids", fldTypeIDSet),
+				hdr("strings", fldTypeStringSet),
+			),
+			ExpRows: rows(
+				row(int64(1), int64(11), "foo", []int64{5}, []string{"blue", "red"}),
+				row(int64(2), int64(20), "bar", []int64{3}, []string{"yellow"}),
+				row(int64(3), int64(30), nil, []int64{6, 7}, nil),
+			),
+			Compare:        CompareExactUnordered,
+			SortStringKeys: true,
+		},
+		{
+			SQLs: sqls(
+				"UPSERT INTO upsertTest (_id, a) VALUES (1, null);",
+			),
+			ExpErr: "null values are not allowed in an upsert",
+		},
+	},
+}
+
+var conditionalWriteTest = TableTest{
+	SQLTests: []SQLTest{
+		{
+			SQLs: sqls(
+				"CREATE TABLE conditionalWriteTest (_id id, a int, d decimal(2), s string, strings stringset, b bool);",
+				"INSERT INTO conditionalWriteTest (_id, a, d, s, strings) VALUES (1, 5, 1.25, 'foo', ['red', 'blue']);",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			// Every condition holds, so the write is applied.
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a, b) VALUES (1, 10, true) IF a = 5 AND d = 1.25 AND s = 'foo' AND strings = ['blue', 'red'] AND b IS NULL;",
+				"UPSERT INTO conditionalWriteTest (_id, a) VALUES (2, 20) IF a IS NULL;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			// A condition doesn't hold, so nothing is written, and the actual
+			// value is reported.
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a, s) VALUES (1, 11, 'bar') IF a = 5 AND b = true;",
+			),
+			ExpErr: "conditional write to table 'conditionalwritetest' not applied: write conflict: a is 10",
+		},
+		{
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a) VALUES (2, 21) IF a IS NULL;",
+			),
+			ExpErr: "write conflict: a is 20",
+		},
+		{
+			// A condition on a key which was never written can't hold.
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a) VALUES (1, 13) IF s = 'baz';",
+			),
+			ExpErr: "conditional write to table 'conditionalwritetest' not applied: write conflict: s has no value 'baz'",
+		},
+		{
+			SQLs: sqls(
+				"SELECT _id, a, s, b FROM conditionalWriteTest;",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+				hdr("a", fldTypeInt),
+				hdr("s", fldTypeString),
+				hdr("b", fldTypeBool),
+			),
+			ExpRows: rows(
+				row(int64(1), int64(10), "foo", true),
+				row(int64(2), int64(20), nil, nil),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a) VALUES (1, 12), (2, 22) IF a = 10;",
+			),
+			ExpErr: "a conditional write must write a single row",
+		},
+		{
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a) VALUES (1, 12) IF a > 10;",
+			),
+			ExpErr: "a write condition must be one or more terms",
+		},
+		{
+			SQLs: sqls(
+				"UPSERT INTO conditionalWriteTest (_id, a) VALUES (1, 12) IF a = 'ten';",
+			),
+			ExpErr: "an expression of type 'string' cannot be assigned to type 'int'",
+		},
+	},
+}
@@ -0,0 +1,209 @@
+package defs
+
+var materializedViewTests = TableTest{
+	name: "materializedviewtests",
+	Table: tbl(
+		"mvtable",
+		srcHdrs(
+			srcHdr("_id", fldTypeID),
+			srcHdr("a_string", fldTypeString),
+			srcHdr("a_int", fldTypeInt),
+		),
+		srcRows(
+			srcRow(int64(1), "str1", int64(10)),
+			srcRow(int64(2), "str1", int64(20)),
+			srcRow(int64(3), "str2", int64(30)),
+		),
+	),
+	SQLTests: []SQLTest{
+		{
+			name: "create-materialized-view",
+			SQLs: sqls(
+				"create materialized view mvtotals as select a_string, sum(a_int) as total from mvtable group by a_string;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-materialized-view",
+			SQLs: sqls(
+				"select * from mvtotals;",
+				"select a_string, total from mvtotals;",
+			),
+			ExpHdrs: hdrs(
+				hdr("a_string", fldTypeString),
+				hdr("total", fldTypeInt),
+			),
+			ExpRows: rows(
+				row("str1", int64(30)),
+				row("str2", int64(30)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "insert-materialized-view-source",
+			SQLs: sqls(
+				"insert into mvtable (_id, a_string, a_int) values (4, 'str2', 40);",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-materialized-view-after-insert",
+			SQLs: sqls(
+				"select * from mvtotals;",
+			),
+			ExpHdrs: hdrs(
+				hdr("a_string", fldTypeString),
+				hdr("total", fldTypeInt),
+			),
+			ExpRows: rows(
+				row("str1", int64(30)),
+				row("str2", int64(30)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "refresh-materialized-view",
+			SQLs: sqls(
+				"refresh materialized view mvtotals;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-refreshed-materialized-view",
+			SQLs: sqls(
+				"select * from mvtotals;",
+			),
+			ExpHdrs: hdrs(
+				hdr("a_string", fldTypeString),
+				hdr("total", fldTypeInt),
+			),
+			ExpRows: rows(
+				row("str1", int64(30)),
+				row("str2", int64(70)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "alter-materialized-view",
+			SQLs: sqls(
+				"alter view mvtotals as select a_string, count(*) as cnt from mvtable where a_int > 10 group by a_string;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-altered-materialized-view",
+			SQLs: sqls(
+				"select * from mvtotals;",
+			),
+			ExpHdrs: hdrs(
+				hdr("a_string", fldTypeString),
+				hdr("cnt", fldTypeInt),
+			),
+			ExpRows: rows(
+				row("str1", int64(1)),
+				row("str2", int64(2)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "create-materialized-view-with-refresh",
+			SQLs: sqls(
+				"create materialized view mvids with refresh '1h' as select _id, a_string from mvtable where a_int > 20;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-materialized-view-with-id",
+			SQLs: sqls(
+				"select * from mvids;",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+				hdr("a_string", fldTypeString),
+			),
+			ExpRows: rows(
+				row(int64(3), "str2"),
+				row(int64(4), "str2"),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-materialized-views-system-table",
+			SQLs: sqls(
+				"select _id, refresh_interval from fb_materialized_views;",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeString),
+				hdr("refresh_interval", fldTypeInt),
+			),
+			ExpRows: rows(
+				row("mvids", int64(3600)),
+				row("mvtotals", int64(0)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "create-view-not-materialized",
+			SQLs: sqls(
+				"create view mvplain as select _id from mvtable;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "refresh-view-not-materialized",
+			SQLs: sqls(
+				"refresh materialized view mvplain;",
+			),
+			ExpErr: "view 'mvplain' is not materialized",
+		},
+		{
+			name: "create-materialized-view-unnamed-column",
+			SQLs: sqls(
+				"create materialized view mvunnamed as select count(*) from mvtable;",
+			),
+			ExpErr: "column 1 of materialized view 'mvunnamed' must be named",
+		},
+		{
+			name: "select-materialized-view-not-created",
+			SQLs: sqls(
+				"select * from mvunnamed;",
+			),
+			ExpErr: "table or view 'mvunnamed' not found",
+		},
+		{
+			name: "create-materialized-view-invalid-refresh",
+			SQLs: sqls(
+				"create materialized view mvinvalid with refresh 'soon' as select _id from mvtable;",
+			),
+			ExpErr: "'soon' is not a valid time duration",
+		},
+		{
+			name: "drop-materialized-view",
+			SQLs: sqls(
+				"drop view mvtotals;",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "select-materialized-view-after-drop",
+			SQLs: sqls(
+				"select * from mvtotals;",
+			),
+			ExpErr: "table or view 'mvtotals' not found",
+		},
+	},
+}
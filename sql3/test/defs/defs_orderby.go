@@ -157,5 +157,74 @@ var orderByTests = TableTest{
 			),
 			Compare: CompareExactOrdered,
 		},
+		{
+			name: "top-order-by-int",
+			SQLs: sqls(
+				"select top(2) an_int, an_id from order_by_test order by an_int desc",
+				"select an_int, an_id from order_by_test order by an_int desc limit 2",
+			),
+			ExpHdrs: hdrs(
+				hdr("an_int", fldTypeInt),
+				hdr("an_id", fldTypeID),
+			),
+			ExpRows: rows(
+				row(int64(44), int64(101)),
+				row(int64(33), int64(201)),
+			),
+			Compare: CompareExactOrdered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child.child.child.pqlTopSort", "-an_int")
+			},
+		},
+		{
+			name: "limit-order-by-unprojected",
+			SQLs: sqls(
+				"select an_int from order_by_test order by a_decimal desc limit 3",
+			),
+			ExpHdrs: hdrs(
+				hdr("an_int", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(10)),
+				row(int64(21)),
+				row(int64(33)),
+			),
+			Compare: CompareExactOrdered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child.child.child.pqlTopSort", "-a_decimal")
+			},
+		},
+		{
+			// the order is of an expression, so the limit can't be taken
+			// until every row has been ordered
+			name: "limit-order-by-expression",
+			SQLs: sqls(
+				"select an_int + 1 as bar from order_by_test order by bar asc limit 2",
+			),
+			ExpHdrs: hdrs(
+				hdr("bar", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(11)),
+				row(int64(22)),
+			),
+			Compare: CompareExactOrdered,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child._op", "*planner.PlanOpTop")
+			},
+		},
+		{
+			name: "limit-order-by-string",
+			SQLs: sqls(
+				"select a_string from order_by_test order by a_string desc limit 1",
+			),
+			ExpHdrs: hdrs(
+				hdr("a_string", fldTypeString),
+			),
+			ExpRows: rows(
+				row("str4"),
+			),
+			Compare: CompareExactOrdered,
+		},
 	},
 }
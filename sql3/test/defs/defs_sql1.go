@@ -226,32 +226,32 @@ var sql1TestsQueries = TableTest{
 			),
 			Compare: CompareExactOrdered,
 		},
-		// {
-		// 	// Distinct(Row(score!=null),index='grouper',field='score')
-		// 	SQLs:    sqls("select distinct score from grouper order by score asc limit 5;"),
-		// 	ExpHdrs: hdrs(hdr("score", fldTypeInt)),
-		// 	ExpRows: rows(
-		// 		row(int64(-13)),
-		// 		row(int64(-10)),
-		// 		row(int64(-8)),
-		// 		row(int64(-2)),
-		// 		row(int64(0)),
-		// 	),
-		// 	Compare: CompareExactOrdered,
-		// },
-		// {
-		// 	// Distinct(Row(score!=null),index='grouper',field='score')
-		// 	SQLs:    sqls("select distinct score from grouper order by score desc limit 5;"),
-		// 	ExpHdrs: hdrs(hdr("score", fldTypeInt)),
-		// 	ExpRows: rows(
-		// 		row(int64(100)),
-		// 		row(int64(80)),
-		// 		row(int64(6)),
-		// 		row(int64(0)),
-		// 		row(int64(-2)),
-		// 	),
-		// 	Compare: CompareExactOrdered,
-		// },
+		{
+			// Distinct(Row(score!=null),index='grouper',field='score')
+			SQLs:    sqls("select distinct score from grouper order by score asc limit 5;"),
+			ExpHdrs: hdrs(hdr("score", fldTypeInt)),
+			ExpRows: rows(
+				row(int64(-13)),
+				row(int64(-10)),
+				row(int64(-8)),
+				row(int64(-2)),
+				row(int64(0)),
+			),
+			Compare: CompareExactOrdered,
+		},
+		{
+			// Distinct(Row(score!=null),index='grouper',field='score')
+			SQLs:    sqls("select distinct score from grouper order by score desc limit 5;"),
+			ExpHdrs: hdrs(hdr("score", fldTypeInt)),
+			ExpRows: rows(
+				row(int64(100)),
+				row(int64(80)),
+				row(int64(6)),
+				row(int64(0)),
+				row(int64(-2)),
+			),
+			Compare: CompareExactOrdered,
+		},
 		// distinct
 		{
 			// Distinct(Row(score!=null),index='grouper',field='score')
@@ -53,6 +53,9 @@ var topLimitTests = TableTest{
 			),
 			Compare:        CompareExactUnordered,
 			SortStringKeys: true,
+			PlanCheck: func(jplan []byte) error {
+				return operatorPresentAtPath(jplan, "$.child.child._op", "*planner.PlanOpPQLTableScan")
+			},
 		},
 		{
 			// TODO(pok) this is a bit weird type consistency wise - grouping by a set in FB is grouping by the members of a set
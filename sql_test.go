@@ -91,5 +91,40 @@ func TestSQL(t *testing.T) {
 			assert.Equal(t, "foobar", row0[5])
 			assert.Equal(t, int64(8), row0[6])
 		})
+
+		t.Run("NullModeOmit", func(t *testing.T) {
+			_, err := featurebase.ParseNullMode("absent")
+			assert.Error(t, err)
+			mode, err := featurebase.ParseNullMode("OMIT")
+			assert.NoError(t, err)
+
+			resp := &featurebase.WireQueryResponse{
+				Schema: featurebase.WireQuerySchema{
+					Fields: []*featurebase.WireQueryField{
+						{Name: "_id", Type: "id", BaseType: "id"},
+						{Name: "an_int", Type: "int", BaseType: "int"},
+						{Name: "an_idset", Type: "idset", BaseType: "idset"},
+					},
+				},
+				Data: [][]interface{}{
+					{int64(1), int64(10), nil},
+					{int64(2), nil, []int64{4, 5}},
+				},
+			}
+			resp.ApplyNullMode(mode)
+			assert.Nil(t, resp.Data)
+			assert.Equal(t, []map[string]interface{}{
+				{"_id": int64(1), "an_int": int64(10)},
+				{"_id": int64(2), "an_idset": []int64{4, 5}},
+			}, resp.Records)
+
+			// The records are converted to the schema's types like rows are.
+			body, err := json.Marshal(resp)
+			assert.NoError(t, err)
+			decoded := &featurebase.WireQueryResponse{}
+			assert.NoError(t, json.Unmarshal(body, decoded))
+			assert.Nil(t, decoded.Data)
+			assert.Equal(t, resp.Records, decoded.Records)
+		})
 	})
 }
@@ -40,6 +40,13 @@ type Transaction struct {
 	// Deadline is calculated from Timeout. TODO reset deadline each time there is activity
 	// on the transaction. (we can't do this until there is some method of associating a
 	// request/call with a transaction)
+	//
+	// Deadline is by the clock of the node which set it. In JSON, the time
+	// remaining until the deadline is sent along with it, and a
+	// Transaction decoded from JSON takes its Deadline from the time
+	// remaining, by the decoder's clock, so that it doesn't depend on the
+	// two clocks agreeing. Nodes receiving a transaction from the primary
+	// likewise set their own deadlines from Timeout.
 	Deadline time.Time `json:"deadline"`
 
 	// Stats track statistics for the transaction. Not yet used.
@@ -403,6 +410,7 @@ func (trns *Transaction) UnmarshalJSON(b []byte) error {
 		Exclusive bool        `json:"exclusive"`
 		Timeout   interface{} `json:"timeout"`
 		Deadline  string      `json:"deadline"`
+		Remaining string      `json:"remaining"`
 	}{}
 	err := json.Unmarshal(b, tmp)
 	if err != nil {
@@ -429,6 +437,14 @@ func (trns *Transaction) UnmarshalJSON(b []byte) error {
 		return errors.New("timeout must be float64 or string")
 	}
 
+	if tmp.Remaining != "" {
+		remaining, err := time.ParseDuration(tmp.Remaining)
+		if err != nil {
+			return errors.Wrap(err, "parsing remaining")
+		}
+		trns.Deadline = time.Now().Add(remaining)
+		return nil
+	}
 	if tmp.Deadline != "" {
 		trns.Deadline, err = time.Parse(time.RFC3339Nano, tmp.Deadline)
 	}
@@ -436,6 +452,10 @@ func (trns *Transaction) UnmarshalJSON(b []byte) error {
 }
 
 func (trns *Transaction) MarshalJSON() ([]byte, error) {
+	var remaining string
+	if !trns.Deadline.IsZero() {
+		remaining = time.Until(trns.Deadline).String()
+	}
 	return json.Marshal(&struct {
 		ID        string `json:"id"`
 		Active    bool   `json:"active"`
@@ -443,6 +463,7 @@ func (trns *Transaction) MarshalJSON() ([]byte, error) {
 		Timeout   string `json:"timeout"`
 		CreatedAt string `json:"createdAt"`
 		Deadline  string `json:"deadline"`
+		Remaining string `json:"remaining,omitempty"`
 	}{
 		ID:        trns.ID,
 		Active:    trns.Active,
@@ -450,6 +471,7 @@ func (trns *Transaction) MarshalJSON() ([]byte, error) {
 		Timeout:   trns.Timeout.String(),
 		CreatedAt: trns.CreatedAt.In(time.UTC).Format(time.RFC3339Nano),
 		Deadline:  trns.Deadline.In(time.UTC).Format(time.RFC3339Nano),
+		Remaining: remaining,
 	})
 }
 
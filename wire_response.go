@@ -9,19 +9,67 @@ import (
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
+	fberrors "github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/pkg/errors"
 )
 
 // WireQueryResponse is the standard featurebase response type which can be
 // serialized and sent over the wire.
 type WireQueryResponse struct {
-	Schema        WireQuerySchema        `json:"schema"`
-	Data          [][]interface{}        `json:"data"`
-	Error         string                 `json:"error"`
+	Schema  WireQuerySchema          `json:"schema"`
+	Data    [][]interface{}          `json:"data"`
+	Records []map[string]interface{} `json:"records,omitempty"`
+	Error   string                   `json:"error"`
+
+	// ErrorCode is the code of Error, if it's a coded error (see
+	// WireErrorCode), so that clients can tell errors apart without parsing
+	// their messages.
+	ErrorCode string `json:"error-code,omitempty"`
+
 	Warnings      []string               `json:"warnings"`
 	QueryPlan     map[string]interface{} `json:"query-plan"`
 	ExecutionTime int64                  `json:"execution-time"`
+
+	// HasMore and NextOffset are set when a single page of the results was
+	// requested. HasMore is true if there are rows beyond the page, in
+	// which case NextOffset is the offset of the next page.
+	HasMore    *bool  `json:"has-more,omitempty"`
+	NextOffset *int64 `json:"next-offset,omitempty"`
+
+	// Approximations describes the counts which were estimated, rather
+	// than computed exactly, when approximate counts were requested.
+	Approximations []WireApproximation `json:"approximations,omitempty"`
+
+	Debug *dax.QueryDebug `json:"debug,omitempty"`
+}
+
+// WireErrorCode returns the code reported alongside err in a query response:
+// the code of the coded error err wraps, or "" if none. An error for a table
+// which doesn't exist is reported as sql3.ErrTableNotFound however the query
+// came across it, whether the table never existed or was dropped, before or
+// after the query was planned, so that clients can tell it apart from other
+// errors with a single check.
+func WireErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if dax.IsTableNotFound(err) || fberrors.Is(err, sql3.ErrTableOrViewNotFound) {
+		return string(sql3.ErrTableNotFound)
+	}
+	return string(fberrors.CodeOf(err))
+}
+
+// WireApproximation describes a count estimated from a sample of shards. At
+// the given Confidence, the exact count is within ErrorBound of Estimate.
+type WireApproximation struct {
+	Call          string  `json:"call"`
+	Estimate      uint64  `json:"estimate"`
+	ErrorBound    uint64  `json:"error-bound"`
+	Confidence    float64 `json:"confidence"`
+	SampledShards int     `json:"sampled-shards"`
+	TotalShards   int     `json:"total-shards"`
 }
 
 // WireQuerySchema is a list of Fields which map to the data columns in the
@@ -39,6 +87,62 @@ type WireQueryField struct {
 	TypeInfo map[string]interface{} `json:"type-info"` // type modifiers (like scale), but not constraints (like min/max)
 }
 
+// NullMode is the way a WireQueryResponse represents a column which has no
+// value in a row.
+//
+// FeatureBase doesn't store an explicit null separately from a value which
+// was never set: writing null to a field clears its value, so both read back
+// the same way. The null mode only chooses how that one state is shown, which
+// lets a client tell the columns a row has values for from those it doesn't
+// without checking every value of every row. The null mode applies only to
+// the JSON encoding of a WireQueryResponse; clients which render it as CSV or
+// a table, such as the CLI, request NullModeEmit and show a column with no
+// value as empty.
+type NullMode string
+
+const (
+	// NullModeEmit returns every row in Data as an array with one element per
+	// column of the schema, and null for a column with no value. It's the
+	// default.
+	NullModeEmit NullMode = "emit"
+
+	// NullModeOmit returns every row in Records instead of Data, as an object
+	// keyed by column name, in which a column with no value is left out.
+	NullModeOmit NullMode = "omit"
+)
+
+// ParseNullMode returns the NullMode named by s. The empty string is
+// NullModeEmit.
+func ParseNullMode(s string) (NullMode, error) {
+	switch m := NullMode(strings.ToLower(s)); m {
+	case "":
+		return NullModeEmit, nil
+	case NullModeEmit, NullModeOmit:
+		return m, nil
+	default:
+		return "", errors.Errorf("invalid null mode '%s': expected '%s' or '%s'", s, NullModeEmit, NullModeOmit)
+	}
+}
+
+// ApplyNullMode rearranges the rows of s, which must be in Data, as described
+// by m.
+func (s *WireQueryResponse) ApplyNullMode(m NullMode) {
+	if m != NullModeOmit || s.Error != "" {
+		return
+	}
+	s.Records = make([]map[string]interface{}, len(s.Data))
+	for i, row := range s.Data {
+		rec := make(map[string]interface{}, len(row))
+		for j, fld := range s.Schema.Fields {
+			if j < len(row) && row[j] != nil {
+				rec[string(fld.Name)] = row[j]
+			}
+		}
+		s.Records[i] = rec
+	}
+	s.Data = nil
+}
+
 // UnmarshalJSON is a custom unmarshaller for the SQLResponse that converts the
 // value types in `Data` based on the types in `Schema`.
 func (s *WireQueryResponse) UnmarshalJSON(in []byte) error {
@@ -88,6 +192,28 @@ func (s *WireQueryResponse) UnmarshalJSONTyped(in []byte, typed bool) error {
 		}
 	}
 
+	// Rows returned in Records (see NullModeOmit) are converted as though
+	// they were in Data.
+	if len(s.Records) > 0 {
+		s.Data = make([][]interface{}, len(s.Records))
+		for i, rec := range s.Records {
+			s.Data[i] = make([]interface{}, len(s.Schema.Fields))
+			for j, fld := range s.Schema.Fields {
+				s.Data[i][j] = rec[string(fld.Name)]
+			}
+		}
+		defer func() {
+			for i, rec := range s.Records {
+				for j, fld := range s.Schema.Fields {
+					if _, ok := rec[string(fld.Name)]; ok {
+						rec[string(fld.Name)] = s.Data[i][j]
+					}
+				}
+			}
+			s.Data = nil
+		}()
+	}
+
 	// Try to convert the data types based on the headers.
 	for i := range s.Data {
 		for j, hdr := range s.Schema.Fields {